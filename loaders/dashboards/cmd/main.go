@@ -13,6 +13,8 @@ import (
 
 	"github.com/spf13/pflag"
 	"github.com/stolostron/multicluster-observability-operator/loaders/dashboards/pkg/controller"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
@@ -29,6 +31,10 @@ func main() {
 
 	var grafanaURI string
 	flagset.StringVar(&grafanaURI, "grafana-uri", "http://127.0.0.1:3001", "The URI of the Grafana server.")
+	var remoteAllowedHosts []string
+	flagset.StringSliceVar(&remoteAllowedHosts, "remote-allowed-hosts", nil,
+		"The hosts the URL and Git dashboard sources can be fetched from, e.g. grafana.com,*.example.com. "+
+			"When empty, the sources can be fetched from any public address.")
 
 	// Parse flags
 	if err := flagset.Parse(os.Args[1:]); err != nil {
@@ -54,7 +60,33 @@ func main() {
 	if err != nil {
 		klog.Fatalf("failed to create controller: %v", err)
 	}
+
+	c.SetRemoteAllowedHosts(remoteAllowedHosts)
+
+	// Load the ObservabilityDashboard resources when their CRD is installed.
+	if hasDashboardResources(kubeClient) {
+		dynamicClient, err := dynamic.NewForConfig(config)
+		if err != nil {
+			klog.Fatalf("failed to build dynamic client: %v", err)
+		}
+		c.EnableDashboardResources(dynamicClient)
+	} else {
+		klog.Info("ObservabilityDashboard CRD not found, only loading dashboards from ConfigMaps")
+	}
 	if err := c.Run(ctx); err != nil {
 		klog.Fatalf("controller failed: %v", err)
 	}
 }
+
+func hasDashboardResources(kubeClient kubernetes.Interface) bool {
+	resources, err := kubeClient.Discovery().ServerResourcesForGroupVersion(mcov1beta2.GroupVersion.String())
+	if err != nil {
+		return false
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == "observabilitydashboards" {
+			return true
+		}
+	}
+	return false
+}
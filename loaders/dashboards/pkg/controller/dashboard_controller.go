@@ -12,18 +12,23 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/stolostron/multicluster-observability-operator/loaders/dashboards/pkg/util"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 )
@@ -35,13 +40,16 @@ const (
 	DefaultCustomFolder = "Custom"
 	HomeDashboardUIDKey = "home-dashboard-uid"
 	SetHomeDashboardKey = "set-home-dashboard"
+	// FolderPermissionsKey is the annotation holding the permissions of the folder of the dashboards,
+	// as a JSON list of {"team"|"role", "permission"} entries.
+	FolderPermissionsKey = "observability.open-cluster-management.io/dashboard-folder-permissions"
 
 	// CustomDashboardLabelKey is the label used to identify Grafana custom dashboards
 	CustomDashboardLabelKey = "grafana-custom-dashboard"
 
 	// ARCHITECTURAL NOTE: This controller assumes EXCLUSIVE ownership of the Grafana instance's
 	// dashboard space. Any dashboard found in Grafana that does not have a corresponding
-	// ConfigMap in the watched namespace will be deleted during the periodic orphan cleanup.
+	// ConfigMap or ObservabilityDashboard in the watched namespace will be deleted during the periodic orphan cleanup.
 	// This ensures that Kubernetes remains the absolute source of truth.
 
 	// Timing and Retries
@@ -50,6 +58,11 @@ const (
 	defaultMaxDashboardRetry = 40
 	resyncPeriod             = 10 * time.Minute
 	reconcileTimeout         = 2 * time.Minute
+
+	// Events
+	eventSourceComponent          = "grafana-dashboard-loader"
+	reasonDashboardInvalid        = "DashboardValidationFailed"
+	reasonDashboardValidationWarn = "DashboardValidationWarning"
 )
 
 type trackedState struct {
@@ -65,8 +78,8 @@ type GrafanaDashboardController struct {
 	watchedNS         string
 	indexer           cache.Indexer
 
-	// uidMap tracks which Grafana UIDs and folders are associated with a given ConfigMap (key).
-	// This allows for immediate deletion of dashboards and empty folders when a ConfigMap is removed or moved.
+	// uidMap tracks which Grafana UIDs and folders are associated with a given ConfigMap or ObservabilityDashboard (key).
+	// This allows for immediate deletion of dashboards and empty folders when a source is removed or moved.
 	uidMap map[string]trackedState
 
 	// reconcileMu ensures that only one reconciliation operation (ConfigMap update, deletion,
@@ -74,6 +87,23 @@ type GrafanaDashboardController struct {
 	// This prevents race conditions between the incremental worker and the periodic sweep.
 	// We use a channel as a semaphore to support context-aware locking and timeouts.
 	reconcileMu chan struct{}
+
+	// dynamicClient and resourceIndexer are only set when ObservabilityDashboard resources are enabled.
+	dynamicClient   dynamic.Interface
+	resourceIndexer cache.Indexer
+
+	// remoteDashboards caches the last content fetched for the URL and Git sources, keyed like uidMap.
+	// It lets the orphan sweep know the UIDs of remote dashboards without fetching them again.
+	remoteMu         sync.Mutex
+	remoteDashboards map[string]string
+
+	// recorder reports the dashboard validation issues as events on the source objects.
+	eventBroadcaster record.EventBroadcaster
+	recorder         record.EventRecorder
+
+	// fetchURL and fetchGit retrieve remote dashboards, they are replaced in tests.
+	fetchURL func(ctx context.Context, url string) (string, error)
+	fetchGit func(ctx context.Context, source *mcov1beta2.DashboardGitSource) (string, error)
 }
 
 // NewGrafanaDashboardController creates a new GrafanaDashboardController
@@ -94,6 +124,7 @@ func NewGrafanaDashboardController(kubeClient corev1client.CoreV1Interface, uri
 		ns = "open-cluster-management-observability"
 		klog.InfoS("POD_NAMESPACE environment variable is empty. Defaulting to standard observability namespace.", "namespace", ns)
 	}
+	eventBroadcaster := record.NewBroadcaster()
 	c := &GrafanaDashboardController{
 		kubeClient:        kubeClient,
 		grafana:           &grafanaClient{uri: uri},
@@ -101,11 +132,23 @@ func NewGrafanaDashboardController(kubeClient corev1client.CoreV1Interface, uri
 		watchedNS:         ns,
 		uidMap:            make(map[string]trackedState),
 		reconcileMu:       make(chan struct{}, 1),
+		remoteDashboards:  make(map[string]string),
+		eventBroadcaster:  eventBroadcaster,
+		recorder:          eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: eventSourceComponent}),
 	}
+	c.SetRemoteAllowedHosts(nil)
 	c.reconcileMu <- struct{}{}
 	return c, nil
 }
 
+// SetRemoteAllowedHosts restricts the URL and Git sources of the dashboards to the given hosts, e.g. "grafana.com"
+// or "*.example.com". Without allowed hosts, the sources can only be fetched from public addresses.
+func (c *GrafanaDashboardController) SetRemoteAllowedHosts(hosts []string) {
+	fetcher := newRemoteFetcher(hosts)
+	c.fetchURL = fetcher.fetchURL
+	c.fetchGit = fetcher.fetchGit
+}
+
 // Run runs the controller until the context is canceled.
 func (c *GrafanaDashboardController) Run(ctx context.Context) error {
 	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[any]())
@@ -113,15 +156,29 @@ func (c *GrafanaDashboardController) Run(ctx context.Context) error {
 	defer wg.Wait()
 	defer queue.ShutDown()
 
+	c.eventBroadcaster.StartRecordingToSink(&corev1client.EventSinkImpl{Interface: c.kubeClient.Events("")})
+	defer c.eventBroadcaster.Shutdown()
+
 	informer, err := c.newKubeInformer(queue)
 	if err != nil {
 		return fmt.Errorf("failed to get informer: %w", err)
 	}
 
 	go informer.Run(ctx.Done())
+	cacheSyncs := []cache.InformerSynced{informer.HasSynced}
+
+	if c.dynamicClient != nil {
+		resourceInformer, err := c.newDashboardResourceInformer(queue)
+		if err != nil {
+			return fmt.Errorf("failed to get dashboard resource informer: %w", err)
+		}
+		go resourceInformer.Run(ctx.Done())
+		cacheSyncs = append(cacheSyncs, resourceInformer.HasSynced)
+		c.resourceIndexer = resourceInformer.GetIndexer()
+	}
 
 	klog.Info("waiting for informer caches to sync")
-	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+	if !cache.WaitForCacheSync(ctx.Done(), cacheSyncs...) {
 		if ctx.Err() != nil {
 			return fmt.Errorf("context canceled while waiting for cache sync: %w", ctx.Err())
 		}
@@ -185,6 +242,7 @@ func (c *GrafanaDashboardController) processNextItem(ctx context.Context, queue
 	err := c.syncHandler(tCtx, obj, indexer)
 	if err == nil {
 		queue.Forget(obj)
+		c.scheduleResync(queue, obj)
 		return true
 	}
 
@@ -196,6 +254,8 @@ func (c *GrafanaDashboardController) processNextItem(ctx context.Context, queue
 
 	queue.Forget(obj)
 	klog.ErrorS(err, "dashboard could not be processed after maximum retries", "object", obj, "maxRetries", c.maxDashboardRetry)
+	// Remote sources may recover on their own, keep polling them.
+	c.scheduleResync(queue, obj)
 	return true
 }
 
@@ -206,6 +266,10 @@ func (c *GrafanaDashboardController) syncHandler(ctx context.Context, item any,
 		return nil
 	}
 
+	if isDashboardResourceKey(key) {
+		return c.syncDashboardResource(ctx, key)
+	}
+
 	obj, exists, err := indexer.GetByKey(key)
 	if err != nil {
 		return err
@@ -387,28 +451,24 @@ func (c *GrafanaDashboardController) cleanupOrphanDashboards(ctx context.Context
 			continue
 		}
 		if isDesiredDashboardConfigmap(cm) {
-			cmKey, err := cache.MetaNamespaceKeyFunc(cm)
+			set, err := configMapDashboardSet(cm)
 			if err != nil {
 				klog.ErrorS(err, "failed to get key for ConfigMap during cleanup", "namespace", cm.Namespace, "name", cm.Name)
 				continue
 			}
-			folder := getDashboardCustomFolderTitle(cm)
-			state := trackedState{folder: folder}
-			for key, val := range cm.Data {
-				dashboard := map[string]any{}
-				if err := json.Unmarshal([]byte(val), &dashboard); err != nil {
-					klog.V(2).InfoS("failed to unmarshal dashboard during cleanup, falling back to name-based UID", "namespace", cm.Namespace, "name", cm.Name, "key", key)
-					dashboard = nil
-				}
-				uid, err := c.resolveDashboardUID(dashboard, cm, key)
-				if err == nil {
-					expectedUIDs[uid] = struct{}{}
-					state.uids = append(state.uids, uid)
-				} else {
-					klog.ErrorS(err, "failed to resolve UID for dashboard key during cleanup", "namespace", cm.Namespace, "name", cm.Name, "key", key)
-				}
-			}
-			newUIDMap[cmKey] = state
+			newUIDMap[set.key] = c.expectedState(set)
+		}
+	}
+
+	// ObservabilityDashboard resources are expected as well. Their remote content is not fetched
+	// here, the last fetched content or the UID recorded in their status is used instead.
+	for _, set := range c.dashboardResourceSets() {
+		newUIDMap[set.key] = c.expectedState(set)
+	}
+
+	for _, state := range newUIDMap {
+		for _, uid := range state.uids {
+			expectedUIDs[uid] = struct{}{}
 		}
 	}
 
@@ -567,9 +627,10 @@ func (c *GrafanaDashboardController) cleanupAllEmptyFolders(ctx context.Context,
 	}
 }
 
-// resolveDashboardUID extracts the UID from the dashboard JSON or generates a unique fallback.
+// resolveDashboardUID extracts the UID from the dashboard JSON or generates a unique fallback
+// from the source object (ConfigMap or ObservabilityDashboard) and the key of the dashboard in it.
 // If dashboard is nil, it skips JSON extraction and directly generates the fallback UID.
-func (c *GrafanaDashboardController) resolveDashboardUID(dashboard map[string]any, cm *corev1.ConfigMap, key string) (string, error) {
+func (c *GrafanaDashboardController) resolveDashboardUID(dashboard map[string]any, cm metav1.Object, key string) (string, error) {
 	if dashboard != nil {
 		if uidVal, ok := dashboard["uid"]; ok && uidVal != nil {
 			uidStr, ok := uidVal.(string)
@@ -602,62 +663,74 @@ func (c *GrafanaDashboardController) resolveDashboardUID(dashboard map[string]an
 }
 
 // updateDashboard handles the synchronization of dashboards from a ConfigMap to Grafana.
-// Note: In most use cases, a ConfigMap contains a single dashboard key. We optimize for this
+func (c *GrafanaDashboardController) updateDashboard(ctx context.Context, newObj *corev1.ConfigMap) error {
+	set, err := configMapDashboardSet(newObj)
+	if err != nil {
+		return fmt.Errorf("failed to get key for ConfigMap: %w", err)
+	}
+	_, err = c.syncDashboardSet(ctx, set)
+	return err
+}
+
+// syncDashboardSet handles the synchronization of a set of dashboards to Grafana and returns their UIDs.
+// Note: In most use cases, a set contains a single dashboard. We optimize for this
 // pattern while still supporting multi-dashboard ConfigMaps by accumulating errors and
 // ensuring sibling dashboards are not blocked by a failure in one.
-func (c *GrafanaDashboardController) updateDashboard(ctx context.Context, newObj *corev1.ConfigMap) error {
+func (c *GrafanaDashboardController) syncDashboardSet(ctx context.Context, set *dashboardSet) ([]string, error) {
 	if err := c.lockReconcile(ctx); err != nil {
-		return err
+		return nil, err
 	}
 	defer c.unlockReconcile()
 
 	var folderID int64
-	folderTitle := getDashboardCustomFolderTitle(newObj)
+	folderTitle := set.folder
 	if folderTitle != "" {
 		folderID = c.createCustomFolder(ctx, folderTitle)
 		if folderID == 0 {
-			return errors.New("failed to get folder id")
+			return nil, errors.New("failed to get folder id")
+		}
+		// The permissions are applied before loading the dashboards, so that the dashboards of a
+		// private folder are never exposed with the default permissions of a new folder.
+		if err := c.reconcileFolderPermissions(ctx, folderID, set); err != nil {
+			return nil, err
 		}
-	}
-
-	homeDashboardUID := ""
-	labels := newObj.Labels
-	annotations := newObj.Annotations
-	if strings.ToLower(annotations[SetHomeDashboardKey]) == "true" && labels[HomeDashboardUIDKey] != "" {
-		homeDashboardUID = labels[HomeDashboardUIDKey]
-	}
-
-	cmKey, err := cache.MetaNamespaceKeyFunc(newObj)
-	if err != nil {
-		return fmt.Errorf("failed to get key for ConfigMap: %w", err)
 	}
 
 	var errs []error
-	currentUIDs := make([]string, 0, len(newObj.Data))
+	currentUIDs := make([]string, 0, len(set.dashboards))
+	validator := c.newDashboardValidator(ctx)
+	set.validation = make(map[string]*validationResult, len(set.dashboards))
 
-	for key, value := range newObj.Data {
+	for key, value := range set.dashboards {
 		dashboard := map[string]any{}
 		err := json.Unmarshal([]byte(value), &dashboard)
 		if err != nil {
 			// Unmarshal errors are terminal user input issues. We log them as errors
 			// for visibility but skip the entry to avoid endless workqueue retries,
-			// while letting other valid dashboards in the same ConfigMap proceed.
-			klog.ErrorS(err, "failed to unmarshal dashboard data, skipping", "namespace", newObj.Namespace, "name", newObj.Name, "key", key)
+			// while letting other valid dashboards in the same set proceed.
+			klog.ErrorS(err, "failed to unmarshal dashboard data, skipping", "namespace", set.owner.GetNamespace(), "name", set.owner.GetName(), "key", key)
 			continue
 		}
 
-		uid, err := c.resolveDashboardUID(dashboard, newObj, key)
+		uid, err := c.resolveDashboardUID(dashboard, set.owner, key)
 		if err != nil {
 			// UID resolution errors are also considered terminal input errors.
-			klog.ErrorS(err, "failed to resolve dashboard UID, skipping", "namespace", newObj.Namespace, "name", newObj.Name, "key", key)
+			klog.ErrorS(err, "failed to resolve dashboard UID, skipping", "namespace", set.owner.GetNamespace(), "name", set.owner.GetName(), "key", key)
 			continue
 		}
 
 		// We record the UID as part of the "desired state" (currentUIDs) BEFORE the API call.
 		// This ensures that if the API call fails, we still "remember" this dashboard
-		// belongs to this ConfigMap, protecting it from being erroneously deleted
+		// belongs to this set, protecting it from being erroneously deleted
 		// by the cleanup phase below.
 		currentUIDs = append(currentUIDs, uid)
+
+		// Validation issues are reported to the authors but don't prevent loading the dashboard,
+		// as it may still be partially usable.
+		result := validator.validate(dashboard)
+		set.validation[key] = result
+		c.reportValidation(set, key, result)
+
 		dashboard["uid"] = uid
 		delete(dashboard, "id")
 
@@ -674,7 +747,7 @@ func (c *GrafanaDashboardController) updateDashboard(ctx context.Context, newObj
 			continue
 		}
 
-		if homeDashboardUID != "" && uid == homeDashboardUID && id != 0 {
+		if set.homeUID != "" && uid == set.homeUID && id != 0 {
 			klog.InfoS("Setting home dashboard", "title", dashboard["title"])
 			if err := c.grafana.SetHomeDashboard(ctx, id); err != nil {
 				klog.ErrorS(err, "failed to set home dashboard", "title", dashboard["title"])
@@ -683,15 +756,15 @@ func (c *GrafanaDashboardController) updateDashboard(ctx context.Context, newObj
 				klog.InfoS("home dashboard set successfully", "title", dashboard["title"], "id", id)
 			}
 		}
-		klog.InfoS("dashboard created/updated successfully", "name", newObj.Name, "uid", uid)
+		klog.InfoS("dashboard created/updated successfully", "name", set.owner.GetName(), "uid", uid)
 	}
 
-	// Immediate cleanup for keys removed from this ConfigMap.
+	// Immediate cleanup for dashboards removed from this set.
 	// We compare the UIDs we just identified as desired (currentUIDs) against
 	// the UIDs we knew about from the previous successful reconcile (oldUIDs).
-	oldState := c.uidMap[cmKey]
+	oldState := c.uidMap[set.key]
 
-	// Find UIDs that were in the map but are not in the current ConfigMap data
+	// Find UIDs that were in the map but are not in the current set
 	currentSet := make(map[string]struct{}, len(currentUIDs))
 	for _, uid := range currentUIDs {
 		currentSet[uid] = struct{}{}
@@ -700,7 +773,7 @@ func (c *GrafanaDashboardController) updateDashboard(ctx context.Context, newObj
 	var failedDeletions []string
 	for _, oldUID := range oldState.uids {
 		if _, exists := currentSet[oldUID]; !exists {
-			klog.InfoS("dashboard key removed from ConfigMap, deleting from Grafana", "namespace", newObj.Namespace, "name", newObj.Name, "uid", oldUID)
+			klog.InfoS("dashboard removed from its source, deleting from Grafana", "namespace", set.owner.GetNamespace(), "name", set.owner.GetName(), "uid", oldUID)
 			if err := c.grafana.DeleteDashboard(ctx, oldUID); err != nil {
 				klog.ErrorS(err, "failed to delete removed dashboard", "uid", oldUID)
 				errs = append(errs, err)
@@ -712,13 +785,13 @@ func (c *GrafanaDashboardController) updateDashboard(ctx context.Context, newObj
 	}
 
 	// Update the map with current state + those that failed to delete.
-	c.uidMap[cmKey] = trackedState{
-		uids:   append(currentUIDs, failedDeletions...),
+	c.uidMap[set.key] = trackedState{
+		uids:   append(slices.Clone(currentUIDs), failedDeletions...),
 		folder: folderTitle,
 	}
 
 	// Perform immediate folder cleanup.
-	// 1. Check the CURRENT folder (in case all dashboards were removed from it but CM still exists)
+	// 1. Check the CURRENT folder (in case all dashboards were removed from it but the source still exists)
 	if folderTitle != "" && len(currentUIDs) == 0 {
 		if err := c.cleanupFolderIfEmpty(ctx, folderTitle); err != nil {
 			errs = append(errs, err)
@@ -733,10 +806,10 @@ func (c *GrafanaDashboardController) updateDashboard(ctx context.Context, newObj
 	// For immediate UX, the sweep is 10m away, which is acceptable for an empty folder.
 
 	if len(errs) > 0 {
-		return errors.Join(errs...)
+		return currentUIDs, errors.Join(errs...)
 	}
 
-	return nil
+	return currentUIDs, nil
 }
 
 func isDesiredDashboardConfigmap(cm *corev1.ConfigMap) bool {
@@ -7,6 +7,7 @@ package controller
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
@@ -17,6 +18,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 )
 
@@ -44,6 +46,14 @@ type mockGrafanaClient struct {
 	permResp  bool
 	emptyResp map[string]bool
 
+	folderPerms       map[string][]folderPermission
+	teams             map[string]int64
+	updatePermsCalled int
+	updatePermsErr    error
+
+	datasources    []grafanaDatasource
+	datasourcesErr error
+
 	lastDashboard map[string]any
 }
 
@@ -53,6 +63,8 @@ func newMockGrafanaClient() *mockGrafanaClient {
 		deleteFolderCalled: make(map[string]int),
 		emptyResp:          make(map[string]bool),
 		permResp:           true,
+		folderPerms:        make(map[string][]folderPermission),
+		teams:              make(map[string]int64),
 	}
 }
 
@@ -117,6 +129,30 @@ func (m *mockGrafanaClient) IsEmpty(ctx context.Context, uid string) (bool, erro
 	return true, m.emptyErr
 }
 
+func (m *mockGrafanaClient) GetFolderPermissions(ctx context.Context, uid string) ([]folderPermission, error) {
+	return m.folderPerms[uid], nil
+}
+
+func (m *mockGrafanaClient) UpdateFolderPermissions(ctx context.Context, uid string, items []folderPermission) error {
+	m.updatePermsCalled++
+	if m.updatePermsErr != nil {
+		return m.updatePermsErr
+	}
+	m.folderPerms[uid] = items
+	return nil
+}
+
+func (m *mockGrafanaClient) GetTeamID(ctx context.Context, name string) (int64, error) {
+	if id, ok := m.teams[name]; ok {
+		return id, nil
+	}
+	return 0, fmt.Errorf("grafana team %q not found", name)
+}
+
+func (m *mockGrafanaClient) ListDatasources(ctx context.Context) ([]grafanaDatasource, error) {
+	return m.datasources, m.datasourcesErr
+}
+
 func createDashboard() (*corev1.ConfigMap, error) {
 	data, err := os.ReadFile("../../examples/k8s-dashboard.yaml")
 	if err != nil {
@@ -138,6 +174,7 @@ func newTestController(t *testing.T, ns string) (*GrafanaDashboardController, *m
 		watchedNS:         ns,
 		uidMap:            make(map[string]trackedState),
 		reconcileMu:       make(chan struct{}, 1),
+		recorder:          &record.FakeRecorder{},
 	}
 	c.reconcileMu <- struct{}{}
 	return c, mock
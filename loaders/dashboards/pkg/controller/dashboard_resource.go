@@ -0,0 +1,363 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// dashboardResourceKeyPrefix distinguishes the ObservabilityDashboard keys from the ConfigMap keys
+	// in the workqueue and in the uidMap.
+	dashboardResourceKeyPrefix = "observabilitydashboard/"
+	// dashboardResourceDataKey is the key of the single dashboard of an ObservabilityDashboard,
+	// used to generate its UID when the JSON doesn't set one.
+	dashboardResourceDataKey = "observabilitydashboard"
+	// generalFolderTitle places the dashboards of an ObservabilityDashboard in the General folder.
+	generalFolderTitle = "General"
+	// defaultRemoteResyncPeriod is how often the URL and Git sources are fetched again by default.
+	defaultRemoteResyncPeriod = 10 * time.Minute
+
+	// Reasons of the Synced condition.
+	reasonSynced           = "Synced"
+	reasonFetchFailed      = "FetchFailed"
+	reasonInvalidDashboard = "InvalidDashboard"
+	reasonGrafanaError     = "GrafanaError"
+
+	// Reasons of the Validated condition.
+	reasonValid              = "Valid"
+	reasonValidationWarnings = "ValidationWarnings"
+	reasonValidationFailed   = "ValidationFailed"
+
+	// Reason of the FolderPermissions condition when the resource owns the permissions of its folder.
+	reasonFolderPermissionsApplied = "Applied"
+)
+
+var dashboardResourceGVR = mcov1beta2.GroupVersion.WithResource("observabilitydashboards")
+
+// EnableDashboardResources makes the controller load the ObservabilityDashboard resources of the
+// watched namespace, in addition to the ConfigMaps. It must be called before Run.
+func (c *GrafanaDashboardController) EnableDashboardResources(dynamicClient dynamic.Interface) {
+	c.dynamicClient = dynamicClient
+}
+
+func isDashboardResourceKey(key string) bool {
+	return strings.HasPrefix(key, dashboardResourceKeyPrefix)
+}
+
+func (c *GrafanaDashboardController) newDashboardResourceInformer(queue workqueue.TypedRateLimitingInterface[any]) (cache.SharedIndexInformer, error) {
+	client := c.dynamicClient.Resource(dashboardResourceGVR).Namespace(c.watchedNS)
+	watchlist := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, opts metav1.ListOptions) (k8sruntime.Object, error) {
+			return client.List(ctx, opts)
+		},
+		WatchFuncWithContext: func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+			return client.Watch(ctx, opts)
+		},
+	}
+	resourceInformer := cache.NewSharedIndexInformer(
+		watchlist,
+		&unstructured.Unstructured{},
+		resyncPeriod,
+		cache.Indexers{},
+	)
+
+	enqueue := func(obj any) {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err == nil {
+			queue.Add(dashboardResourceKeyPrefix + key)
+		}
+	}
+	_, err := resourceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueue,
+		UpdateFunc: func(oldObj, newObj any) {
+			oldU, _ := oldObj.(*unstructured.Unstructured)
+			newU, ok := newObj.(*unstructured.Unstructured)
+			if !ok || oldU == nil {
+				return
+			}
+			// Skip the status updates made by the loader itself, but keep the periodic resyncs
+			// so that changes made in the Grafana UI are reverted.
+			if oldU.GetGeneration() != newU.GetGeneration() || oldU.GetResourceVersion() == newU.GetResourceVersion() {
+				enqueue(newObj)
+			}
+		},
+		DeleteFunc: enqueue,
+	})
+	return resourceInformer, err
+}
+
+// getDashboardResource returns the ObservabilityDashboard of a cache key, without the prefix.
+func (c *GrafanaDashboardController) getDashboardResource(key string) (*mcov1beta2.ObservabilityDashboard, bool, error) {
+	if c.resourceIndexer == nil {
+		return nil, false, nil
+	}
+	obj, exists, err := c.resourceIndexer.GetByKey(key)
+	if err != nil || !exists {
+		return nil, exists, err
+	}
+	dash, err := toDashboardResource(obj)
+	return dash, err == nil, err
+}
+
+func toDashboardResource(obj any) (*mcov1beta2.ObservabilityDashboard, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type in cache: %T", obj)
+	}
+	dash := &mcov1beta2.ObservabilityDashboard{}
+	if err := k8sruntime.DefaultUnstructuredConverter.FromUnstructured(u.Object, dash); err != nil {
+		return nil, fmt.Errorf("failed to convert ObservabilityDashboard %s/%s: %w", u.GetNamespace(), u.GetName(), err)
+	}
+	return dash, nil
+}
+
+// syncDashboardResource loads the dashboard of an ObservabilityDashboard in Grafana and reports the
+// result in its status.
+func (c *GrafanaDashboardController) syncDashboardResource(ctx context.Context, key string) error {
+	dash, exists, err := c.getDashboardResource(strings.TrimPrefix(key, dashboardResourceKeyPrefix))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		klog.InfoS("ObservabilityDashboard deleted, performing immediate cleanup from Grafana", "key", key)
+		c.setRemoteDashboard(key, "")
+		return c.deleteTrackedUIDs(ctx, key)
+	}
+
+	klog.InfoS("syncing dashboard", "namespace", dash.Namespace, "name", dash.Name, "kind", "ObservabilityDashboard")
+	content, err := c.fetchDashboardResource(ctx, dash)
+	if err != nil {
+		c.updateDashboardResourceStatus(ctx, dash, "", reasonFetchFailed, err, nil)
+		return err
+	}
+	if dash.Spec.JSON == "" {
+		c.setRemoteDashboard(key, content)
+	}
+
+	dashboard := map[string]any{}
+	if err := json.Unmarshal([]byte(content), &dashboard); err != nil {
+		// Invalid dashboards are terminal user input issues, they are reported in the status without retrying.
+		c.updateDashboardResourceStatus(ctx, dash, "", reasonInvalidDashboard, fmt.Errorf("failed to unmarshal dashboard: %w", err), nil)
+		return nil
+	}
+	uid, err := c.resolveDashboardUID(dashboard, dash, dashboardResourceDataKey)
+	if err != nil {
+		c.updateDashboardResourceStatus(ctx, dash, "", reasonInvalidDashboard, err, nil)
+		return nil
+	}
+
+	set := &dashboardSet{
+		key:         key,
+		owner:       dash,
+		folder:      dashboardResourceFolder(dash),
+		dashboards:  map[string]string{dashboardResourceDataKey: content},
+		permissions: dash.Spec.Permissions,
+	}
+	if dash.Spec.Home {
+		set.homeUID = uid
+	}
+
+	_, err = c.syncDashboardSet(ctx, set)
+	if err != nil {
+		c.updateDashboardResourceStatus(ctx, dash, uid, reasonGrafanaError, err, set)
+		return err
+	}
+	c.updateDashboardResourceStatus(ctx, dash, uid, reasonSynced, nil, set)
+	return nil
+}
+
+// fetchDashboardResource returns the JSON definition of the dashboard from the source of the resource.
+func (c *GrafanaDashboardController) fetchDashboardResource(ctx context.Context, dash *mcov1beta2.ObservabilityDashboard) (string, error) {
+	switch {
+	case dash.Spec.JSON != "":
+		return dash.Spec.JSON, nil
+	case dash.Spec.URL != "":
+		return c.fetchURL(ctx, dash.Spec.URL)
+	case dash.Spec.Git != nil:
+		return c.fetchGit(ctx, dash.Spec.Git)
+	}
+	return "", fmt.Errorf("one of json, url or git must be set")
+}
+
+func dashboardResourceFolder(dash *mcov1beta2.ObservabilityDashboard) string {
+	switch dash.Spec.Folder {
+	case "":
+		return DefaultCustomFolder
+	case generalFolderTitle:
+		return ""
+	}
+	return dash.Spec.Folder
+}
+
+// updateDashboardResourceStatus records the result of a sync in the status of the resource, the result
+// of its validation if it was validated, and whether it owns the permissions of its folder once synced.
+// Failing to update the status is only logged, as it doesn't affect the dashboard in Grafana.
+func (c *GrafanaDashboardController) updateDashboardResourceStatus(ctx context.Context, dash *mcov1beta2.ObservabilityDashboard, uid, reason string, syncErr error, set *dashboardSet) {
+	dash = dash.DeepCopy()
+	condition := metav1.Condition{
+		Type:               mcov1beta2.DashboardSyncedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            "The dashboard is loaded in Grafana",
+		ObservedGeneration: dash.Generation,
+	}
+	if syncErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Message = truncate(syncErr.Error(), 1024)
+	} else {
+		now := metav1.Now()
+		dash.Status.LastSyncTime = &now
+		dash.Status.ObservedGeneration = dash.Generation
+	}
+	if uid != "" {
+		dash.Status.UID = uid
+	}
+	meta.SetStatusCondition(&dash.Status.Conditions, condition)
+	if set != nil {
+		if validation := set.validation[dashboardResourceDataKey]; validation != nil {
+			meta.SetStatusCondition(&dash.Status.Conditions, validationCondition(validation, dash.Generation))
+		}
+		switch {
+		case !set.declaresPermissions():
+			meta.RemoveStatusCondition(&dash.Status.Conditions, mcov1beta2.DashboardFolderPermissionsCondition)
+		case syncErr == nil:
+			meta.SetStatusCondition(&dash.Status.Conditions, folderPermissionsCondition(set, dash.Generation))
+		}
+	}
+
+	obj, err := k8sruntime.DefaultUnstructuredConverter.ToUnstructured(dash)
+	if err != nil {
+		klog.ErrorS(err, "failed to convert ObservabilityDashboard", "namespace", dash.Namespace, "name", dash.Name)
+		return
+	}
+	_, err = c.dynamicClient.Resource(dashboardResourceGVR).Namespace(dash.Namespace).
+		UpdateStatus(ctx, &unstructured.Unstructured{Object: obj}, metav1.UpdateOptions{})
+	if err != nil {
+		klog.ErrorS(err, "failed to update ObservabilityDashboard status", "namespace", dash.Namespace, "name", dash.Name)
+	}
+}
+
+func folderPermissionsCondition(set *dashboardSet, generation int64) metav1.Condition {
+	if set.permissionsConflict != "" {
+		return metav1.Condition{
+			Type:               mcov1beta2.DashboardFolderPermissionsCondition,
+			Status:             metav1.ConditionFalse,
+			Reason:             reasonFolderPermissionsConflict,
+			Message:            truncate(set.permissionsConflict, 1024),
+			ObservedGeneration: generation,
+		}
+	}
+	return metav1.Condition{
+		Type:               mcov1beta2.DashboardFolderPermissionsCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonFolderPermissionsApplied,
+		Message:            fmt.Sprintf("The permissions of the folder %q are applied", set.folder),
+		ObservedGeneration: generation,
+	}
+}
+
+func validationCondition(validation *validationResult, generation int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               mcov1beta2.DashboardValidatedCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonValid,
+		Message:            "No issues found in the dashboard",
+		ObservedGeneration: generation,
+	}
+	switch {
+	case len(validation.errors) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = reasonValidationFailed
+		condition.Message = truncate(validation.summary(), 1024)
+	case len(validation.warnings) > 0:
+		condition.Reason = reasonValidationWarnings
+		condition.Message = truncate(validation.summary(), 1024)
+	}
+	return condition
+}
+
+// scheduleResync requeues the ObservabilityDashboard resources with a remote source after their resync period.
+func (c *GrafanaDashboardController) scheduleResync(queue workqueue.TypedRateLimitingInterface[any], item any) {
+	key, ok := item.(string)
+	if !ok || !isDashboardResourceKey(key) {
+		return
+	}
+	dash, exists, err := c.getDashboardResource(strings.TrimPrefix(key, dashboardResourceKeyPrefix))
+	if err != nil || !exists || dash.Spec.JSON != "" {
+		return
+	}
+
+	period := defaultRemoteResyncPeriod
+	if dash.Spec.ResyncPeriod != nil && dash.Spec.ResyncPeriod.Duration > 0 {
+		period = dash.Spec.ResyncPeriod.Duration
+	}
+	queue.AddAfter(key, period)
+}
+
+// dashboardResourceSets returns the dashboard sets of all ObservabilityDashboard resources, using the
+// last fetched content of the remote sources.
+func (c *GrafanaDashboardController) dashboardResourceSets() []*dashboardSet {
+	if c.resourceIndexer == nil {
+		return nil
+	}
+
+	var sets []*dashboardSet
+	for _, obj := range c.resourceIndexer.List() {
+		dash, err := toDashboardResource(obj)
+		if err != nil {
+			klog.ErrorS(err, "skipping ObservabilityDashboard during cleanup")
+			continue
+		}
+		key := dashboardResourceKeyPrefix + dash.Namespace + "/" + dash.Name
+		set := &dashboardSet{
+			key:         key,
+			owner:       dash,
+			folder:      dashboardResourceFolder(dash),
+			permissions: dash.Spec.Permissions,
+			knownUID:    dash.Status.UID,
+		}
+		content := dash.Spec.JSON
+		if content == "" {
+			content = c.getRemoteDashboard(key)
+		}
+		if content != "" {
+			set.dashboards = map[string]string{dashboardResourceDataKey: content}
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+func (c *GrafanaDashboardController) setRemoteDashboard(key, content string) {
+	c.remoteMu.Lock()
+	defer c.remoteMu.Unlock()
+	if content == "" {
+		delete(c.remoteDashboards, key)
+		return
+	}
+	c.remoteDashboards[key] = content
+}
+
+func (c *GrafanaDashboardController) getRemoteDashboard(key string) string {
+	c.remoteMu.Lock()
+	defer c.remoteMu.Unlock()
+	return c.remoteDashboards[key]
+}
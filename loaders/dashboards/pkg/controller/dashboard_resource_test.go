@@ -0,0 +1,341 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+const testDashboardJSON = `{"uid":"dash-uid","title":"Test"}`
+
+func newTestDashboardResource(t *testing.T, name string, spec mcov1beta2.ObservabilityDashboardSpec) *unstructured.Unstructured {
+	dash := &mcov1beta2.ObservabilityDashboard{
+		TypeMeta:   metav1.TypeMeta{APIVersion: mcov1beta2.GroupVersion.String(), Kind: "ObservabilityDashboard"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Generation: 1},
+		Spec:       spec,
+	}
+	obj, err := k8sruntime.DefaultUnstructuredConverter.ToUnstructured(dash)
+	require.NoError(t, err)
+	return &unstructured.Unstructured{Object: obj}
+}
+
+// newTestResourceController returns a controller with ObservabilityDashboard resources enabled,
+// the given resources being both in the informer cache and in the API server.
+func newTestResourceController(t *testing.T, objs ...*unstructured.Unstructured) (*GrafanaDashboardController, *mockGrafanaClient) {
+	c, mock := newTestController(t, "default")
+	mock.folders = []grafanaFolder{{ID: 1, UID: "custom", Title: DefaultCustomFolder}}
+
+	runtimeObjs := make([]k8sruntime.Object, 0, len(objs))
+	c.resourceIndexer = newTestIndexer()
+	for _, obj := range objs {
+		runtimeObjs = append(runtimeObjs, obj.DeepCopy())
+		require.NoError(t, c.resourceIndexer.Add(obj))
+	}
+	c.dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{dashboardResourceGVR: "ObservabilityDashboardList"}, runtimeObjs...)
+	c.remoteDashboards = make(map[string]string)
+	c.fetchURL = func(ctx context.Context, url string) (string, error) {
+		return "", errors.New("unexpected URL fetch")
+	}
+	c.fetchGit = func(ctx context.Context, source *mcov1beta2.DashboardGitSource) (string, error) {
+		return "", errors.New("unexpected Git fetch")
+	}
+	return c, mock
+}
+
+func getTestDashboardResource(t *testing.T, c *GrafanaDashboardController, name string) *mcov1beta2.ObservabilityDashboard {
+	obj, err := c.dynamicClient.Resource(dashboardResourceGVR).Namespace("default").Get(t.Context(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	dash, err := toDashboardResource(obj)
+	require.NoError(t, err)
+	return dash
+}
+
+func TestSyncDashboardResource(t *testing.T) {
+	testCases := []struct {
+		name            string
+		spec            mcov1beta2.ObservabilityDashboardSpec
+		fetchURL        func(ctx context.Context, url string) (string, error)
+		createErr       error
+		expectErr       bool
+		expectCreated   int
+		expectSetHome   int
+		expectStatus    metav1.ConditionStatus
+		expectReason    string
+		expectUID       string
+		expectFolderCnt int
+	}{
+		{
+			name:          "inline dashboard",
+			spec:          mcov1beta2.ObservabilityDashboardSpec{JSON: testDashboardJSON},
+			expectCreated: 1,
+			expectStatus:  metav1.ConditionTrue,
+			expectReason:  reasonSynced,
+			expectUID:     "dash-uid",
+		},
+		{
+			name:          "home dashboard",
+			spec:          mcov1beta2.ObservabilityDashboardSpec{JSON: testDashboardJSON, Home: true},
+			expectCreated: 1,
+			expectSetHome: 1,
+			expectStatus:  metav1.ConditionTrue,
+			expectReason:  reasonSynced,
+			expectUID:     "dash-uid",
+		},
+		{
+			name: "URL dashboard",
+			spec: mcov1beta2.ObservabilityDashboardSpec{URL: "https://example.com/dashboard.json"},
+			fetchURL: func(ctx context.Context, url string) (string, error) {
+				return testDashboardJSON, nil
+			},
+			expectCreated: 1,
+			expectStatus:  metav1.ConditionTrue,
+			expectReason:  reasonSynced,
+			expectUID:     "dash-uid",
+		},
+		{
+			name: "URL fetch failure",
+			spec: mcov1beta2.ObservabilityDashboardSpec{URL: "https://example.com/dashboard.json"},
+			fetchURL: func(ctx context.Context, url string) (string, error) {
+				return "", errors.New("connection refused")
+			},
+			expectErr:    true,
+			expectStatus: metav1.ConditionFalse,
+			expectReason: reasonFetchFailed,
+		},
+		{
+			name:         "invalid dashboard is not retried",
+			spec:         mcov1beta2.ObservabilityDashboardSpec{JSON: "{invalid"},
+			expectStatus: metav1.ConditionFalse,
+			expectReason: reasonInvalidDashboard,
+		},
+		{
+			name:          "grafana error",
+			spec:          mcov1beta2.ObservabilityDashboardSpec{JSON: testDashboardJSON},
+			createErr:     &GrafanaError{Status: http.StatusBadRequest, Body: "bad dashboard"},
+			expectErr:     true,
+			expectCreated: 1,
+			expectStatus:  metav1.ConditionFalse,
+			expectReason:  reasonGrafanaError,
+			expectUID:     "dash-uid",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, mock := newTestResourceController(t, newTestDashboardResource(t, "dash", tc.spec))
+			mock.createErr = tc.createErr
+			if tc.fetchURL != nil {
+				c.fetchURL = tc.fetchURL
+			}
+
+			err := c.syncHandler(t.Context(), dashboardResourceKeyPrefix+"default/dash", newTestIndexer())
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectCreated, mock.createDashCalled)
+			assert.Equal(t, tc.expectSetHome, mock.setHomeCalled)
+
+			dash := getTestDashboardResource(t, c, "dash")
+			cond := meta.FindStatusCondition(dash.Status.Conditions, mcov1beta2.DashboardSyncedCondition)
+			require.NotNil(t, cond)
+			assert.Equal(t, tc.expectStatus, cond.Status)
+			assert.Equal(t, tc.expectReason, cond.Reason)
+			assert.Equal(t, tc.expectUID, dash.Status.UID)
+			if tc.expectStatus == metav1.ConditionTrue {
+				assert.NotNil(t, dash.Status.LastSyncTime)
+				assert.Equal(t, int64(1), dash.Status.ObservedGeneration)
+			}
+		})
+	}
+}
+
+func TestSyncDashboardResource_Deleted(t *testing.T) {
+	c, mock := newTestResourceController(t)
+	key := dashboardResourceKeyPrefix + "default/gone"
+	c.uidMap[key] = trackedState{uids: []string{"gone-uid"}}
+	c.remoteDashboards[key] = testDashboardJSON
+
+	assert.NoError(t, c.syncHandler(t.Context(), key, newTestIndexer()))
+	assert.Equal(t, 1, mock.deleteDashCalled["gone-uid"])
+	assert.NotContains(t, c.uidMap, key)
+	assert.NotContains(t, c.remoteDashboards, key)
+}
+
+func TestDashboardResourceFolder(t *testing.T) {
+	testCases := map[string]string{
+		"":          DefaultCustomFolder,
+		"General":   "",
+		"Team SREs": "Team SREs",
+	}
+	for folder, expected := range testCases {
+		dash := &mcov1beta2.ObservabilityDashboard{Spec: mcov1beta2.ObservabilityDashboardSpec{Folder: folder}}
+		assert.Equal(t, expected, dashboardResourceFolder(dash))
+	}
+}
+
+func TestCleanupOrphanDashboards_DashboardResources(t *testing.T) {
+	inline := newTestDashboardResource(t, "inline", mcov1beta2.ObservabilityDashboardSpec{JSON: `{"uid":"inline-uid"}`})
+	fetched := newTestDashboardResource(t, "fetched", mcov1beta2.ObservabilityDashboardSpec{URL: "https://example.com/a.json"})
+	notFetched := newTestDashboardResource(t, "not-fetched", mcov1beta2.ObservabilityDashboardSpec{URL: "https://example.com/b.json"})
+	require.NoError(t, unstructured.SetNestedField(notFetched.Object, "status-uid", "status", "uid"))
+
+	c, mock := newTestResourceController(t, inline, fetched, notFetched)
+	c.remoteDashboards[dashboardResourceKeyPrefix+"default/fetched"] = `{"uid":"fetched-uid"}`
+	mock.dashboards = []grafanaDashboard{
+		{UID: "inline-uid", FolderUID: "custom"},
+		{UID: "fetched-uid", FolderUID: "custom"},
+		{UID: "status-uid", FolderUID: "custom"},
+		{UID: "orphan", FolderUID: "custom"},
+	}
+
+	assert.NoError(t, c.cleanupOrphanDashboards(t.Context(), newTestIndexer()))
+	assert.Equal(t, map[string]int{"orphan": 1}, mock.deleteDashCalled)
+	assert.Equal(t, []string{"fetched-uid"}, c.uidMap[dashboardResourceKeyPrefix+"default/fetched"].uids)
+}
+
+func TestScheduleResync(t *testing.T) {
+	inline := newTestDashboardResource(t, "inline", mcov1beta2.ObservabilityDashboardSpec{JSON: testDashboardJSON})
+	remote := newTestDashboardResource(t, "remote", mcov1beta2.ObservabilityDashboardSpec{
+		URL:          "https://example.com/a.json",
+		ResyncPeriod: &metav1.Duration{Duration: 10 * time.Millisecond},
+	})
+	c, _ := newTestResourceController(t, inline, remote)
+	queue := newTestQueue()
+	defer queue.ShutDown()
+
+	c.scheduleResync(queue, "default/configmap")
+	c.scheduleResync(queue, dashboardResourceKeyPrefix+"default/inline")
+	c.scheduleResync(queue, dashboardResourceKeyPrefix+"default/remote")
+
+	assert.Eventually(t, func() bool { return queue.Len() == 1 }, time.Second, 5*time.Millisecond)
+	item, _ := queue.Get()
+	assert.Equal(t, dashboardResourceKeyPrefix+"default/remote", item)
+}
+
+func TestFetchURLDashboard(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dashboard.json":
+			_, _ = w.Write([]byte(testDashboardJSON))
+		case "/redirect":
+			http.Redirect(w, r, "http://localhost/dashboard.json", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Without allowed hosts, the loopback and private addresses are refused.
+	_, err := newRemoteFetcher(nil).fetchURL(t.Context(), server.URL+"/dashboard.json")
+	assert.ErrorContains(t, err, "not a public address")
+	_, err = newRemoteFetcher(nil).fetchURL(t.Context(), "file:///etc/passwd")
+	assert.ErrorContains(t, err, "unsupported scheme")
+
+	fetcher := newRemoteFetcher([]string{"127.0.0.1"})
+	content, err := fetcher.fetchURL(t.Context(), server.URL+"/dashboard.json")
+	assert.NoError(t, err)
+	assert.Equal(t, testDashboardJSON, content)
+
+	_, err = fetcher.fetchURL(t.Context(), server.URL+"/missing.json")
+	assert.Error(t, err)
+
+	// The redirects to the hosts not allowed are refused.
+	_, err = fetcher.fetchURL(t.Context(), server.URL+"/redirect")
+	assert.ErrorContains(t, err, `the host "localhost" is not allowed`)
+	_, err = fetcher.fetchURL(t.Context(), "http://localhost/dashboard.json")
+	assert.ErrorContains(t, err, `the host "localhost" is not allowed`)
+}
+
+func TestRemoteFetcherHostAllowed(t *testing.T) {
+	assert.True(t, newRemoteFetcher(nil).hostAllowed("example.com"))
+
+	fetcher := newRemoteFetcher([]string{"Grafana.com", " *.example.com", ""})
+	assert.True(t, fetcher.hostAllowed("grafana.com"))
+	assert.True(t, fetcher.hostAllowed("dashboards.example.com"))
+	assert.False(t, fetcher.hostAllowed("example.com"))
+	assert.False(t, fetcher.hostAllowed("evilexample.com"))
+	assert.False(t, fetcher.hostAllowed("grafana.com.evil.io"))
+
+	for ip, public := range map[string]bool{
+		"8.8.8.8": true, "2001:4860:4860::8888": true, "127.0.0.1": false, "10.0.0.1": false, "172.30.0.1": false,
+		"169.254.169.254": false, "::1": false, "0.0.0.0": false, "fd00::1": false,
+	} {
+		assert.Equal(t, public, isPublicIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestFetchGitDashboard(t *testing.T) {
+	fetcher := newRemoteFetcher([]string{"example.com"})
+	_, err := fetcher.fetchGit(t.Context(), &mcov1beta2.DashboardGitSource{Repository: "https://example.com/repo.git", Path: "../escape.json"})
+	assert.Error(t, err)
+	_, err = fetcher.fetchGit(t.Context(), &mcov1beta2.DashboardGitSource{Repository: "https://other.com/repo.git", Path: "test.json"})
+	assert.ErrorContains(t, err, `the host "other.com" is not allowed`)
+	for _, repository := range []string{"http://example.com/repo.git", "git://example.com/repo.git", "file:///tmp/repo", "ext::sh -c id"} {
+		_, err = fetcher.fetchGit(t.Context(), &mcov1beta2.DashboardGitSource{Repository: repository, Path: "test.json"})
+		assert.Error(t, err, repository)
+	}
+	_, err = newRemoteFetcher(nil).fetchGit(t.Context(), &mcov1beta2.DashboardGitSource{Repository: "https://127.0.0.1/repo.git", Path: "test.json"})
+	assert.ErrorContains(t, err, "not a public address")
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// The transports other than HTTPS are refused by git too, and the files of the clone are read without following
+	// the symbolic links of the repository.
+	repo := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "dashboards"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "dashboards", "test.json"), []byte(testDashboardJSON), 0o600))
+	require.NoError(t, os.Symlink("/etc/hostname", filepath.Join(repo, "dashboards", "link.json")))
+	require.NoError(t, os.Symlink("dashboards", filepath.Join(repo, "linkdir")))
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch=main"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	fetcher.allowedHosts = nil
+	_, err = fetcher.fetchGit(t.Context(), &mcov1beta2.DashboardGitSource{Repository: "file://" + repo, Path: "dashboards/test.json"})
+	assert.ErrorContains(t, err, "unsupported scheme")
+
+	content, err := readRepositoryFile(repo, "dashboards/test.json")
+	assert.NoError(t, err)
+	assert.Equal(t, testDashboardJSON, content)
+	content, err = readRepositoryFile(repo, "linkdir/test.json")
+	assert.NoError(t, err)
+	assert.Equal(t, testDashboardJSON, content)
+	_, err = readRepositoryFile(repo, "dashboards/link.json")
+	assert.ErrorContains(t, err, "not a regular file")
+	_, err = readRepositoryFile(repo, "dashboards/missing.json")
+	assert.Error(t, err)
+	_, err = readRepositoryFile(repo, "dashboards")
+	assert.ErrorContains(t, err, "not a regular file")
+}
@@ -0,0 +1,95 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// dashboardSet is the set of dashboards loaded from a single source object, either a ConfigMap
+// or an ObservabilityDashboard. It is the unit of synchronization and of tracking in the uidMap.
+type dashboardSet struct {
+	// key identifies the source object in the workqueue and in the uidMap.
+	key string
+	// owner is the source object, used to generate the UIDs of dashboards that don't set one.
+	owner metav1.Object
+	// folder is the title of the Grafana folder, empty for the General folder.
+	folder string
+	// homeUID is the UID of the dashboard to set as the Grafana home dashboard, if any.
+	homeUID string
+	// dashboards maps the key of each dashboard in the source to its JSON definition.
+	dashboards map[string]string
+	// permissions are the folder permissions declared by the source, permissionsErr is set when they are invalid.
+	permissions    []mcov1beta2.DashboardFolderPermission
+	permissionsErr error
+	// permissionsConflict is set during a sync when the source declares permissions for a folder owned by another source.
+	permissionsConflict string
+	// validation holds the issues found in each dashboard during the last sync.
+	validation map[string]*validationResult
+	// knownUID is the last UID loaded in Grafana, used when the content of a remote source isn't known yet.
+	knownUID string
+}
+
+// declaresPermissions reports whether the source of the set declares permissions for its folder. The permissions
+// are ignored for the General folder.
+func (s *dashboardSet) declaresPermissions() bool {
+	return s.folder != "" && (len(s.permissions) > 0 || s.permissionsErr != nil)
+}
+
+// configMapDashboardSet returns the dashboards defined in a ConfigMap.
+func configMapDashboardSet(cm *corev1.ConfigMap) (*dashboardSet, error) {
+	key, err := cache.MetaNamespaceKeyFunc(cm)
+	if err != nil {
+		return nil, err
+	}
+
+	homeDashboardUID := ""
+	if strings.ToLower(cm.Annotations[SetHomeDashboardKey]) == "true" && cm.Labels[HomeDashboardUIDKey] != "" {
+		homeDashboardUID = cm.Labels[HomeDashboardUIDKey]
+	}
+
+	set := &dashboardSet{
+		key:        key,
+		owner:      cm,
+		folder:     getDashboardCustomFolderTitle(cm),
+		homeUID:    homeDashboardUID,
+		dashboards: cm.Data,
+	}
+	if value, ok := cm.Annotations[FolderPermissionsKey]; ok {
+		set.permissions, set.permissionsErr = parseFolderPermissions(value)
+	}
+	return set, nil
+}
+
+// expectedState returns the UIDs and folder that a set is expected to have in Grafana.
+func (c *GrafanaDashboardController) expectedState(set *dashboardSet) trackedState {
+	state := trackedState{folder: set.folder}
+	if len(set.dashboards) == 0 && set.knownUID != "" {
+		state.uids = []string{set.knownUID}
+		return state
+	}
+
+	for key, val := range set.dashboards {
+		dashboard := map[string]any{}
+		if err := json.Unmarshal([]byte(val), &dashboard); err != nil {
+			klog.V(2).InfoS("failed to unmarshal dashboard during cleanup, falling back to name-based UID", "namespace", set.owner.GetNamespace(), "name", set.owner.GetName(), "key", key)
+			dashboard = nil
+		}
+		uid, err := c.resolveDashboardUID(dashboard, set.owner, key)
+		if err != nil {
+			klog.ErrorS(err, "failed to resolve UID for dashboard key during cleanup", "namespace", set.owner.GetNamespace(), "name", set.owner.GetName(), "key", key)
+			continue
+		}
+		state.uids = append(state.uids, uid)
+	}
+	return state
+}
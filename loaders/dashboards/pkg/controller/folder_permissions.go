@@ -0,0 +1,194 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+)
+
+// folderPermissionLevels maps the permissions of the API to the Grafana permission levels.
+var folderPermissionLevels = map[string]int{
+	"View":  1,
+	"Edit":  2,
+	"Admin": 4,
+}
+
+// reasonFolderPermissionsConflict is the reason of the events and conditions reporting the folder permissions
+// declared by a source that doesn't own them.
+const reasonFolderPermissionsConflict = "FolderPermissionsConflict"
+
+// parseFolderPermissions parses the value of the folder permissions annotation, a JSON list
+// using the same format as the permissions of an ObservabilityDashboard.
+func parseFolderPermissions(value string) ([]mcov1beta2.DashboardFolderPermission, error) {
+	var perms []mcov1beta2.DashboardFolderPermission
+	if err := json.Unmarshal([]byte(value), &perms); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal folder permissions: %w", err)
+	}
+	for _, p := range perms {
+		if err := validateFolderPermission(p); err != nil {
+			return nil, err
+		}
+	}
+	return perms, nil
+}
+
+func validateFolderPermission(p mcov1beta2.DashboardFolderPermission) error {
+	if (p.Team == "") == (p.Role == "") {
+		return errors.New("invalid folder permission: exactly one of team or role must be set")
+	}
+	if p.Role != "" && p.Role != "Viewer" && p.Role != "Editor" {
+		return fmt.Errorf("invalid folder permission: unsupported role %q, must be Viewer or Editor", p.Role)
+	}
+	if _, ok := folderPermissionLevels[p.Permission]; !ok {
+		return fmt.Errorf("invalid folder permission: unsupported permission %q, must be View, Edit or Admin", p.Permission)
+	}
+	return nil
+}
+
+// folderPermissions returns the permissions of the folder of a set and the key of the source owning them.
+// The permissions of a folder are owned by the oldest source declaring permissions for it, so that the
+// authors of the other sources placing dashboards in the folder can't change who can access it.
+// It returns no permissions when no source declares them.
+func (c *GrafanaDashboardController) folderPermissions(set *dashboardSet) ([]mcov1beta2.DashboardFolderPermission, string, error) {
+	sets := []*dashboardSet{set}
+	if c.indexer != nil {
+		for _, obj := range c.indexer.List() {
+			cm, ok := obj.(*corev1.ConfigMap)
+			if !ok || !isDesiredDashboardConfigmap(cm) {
+				continue
+			}
+			if other, err := configMapDashboardSet(cm); err == nil {
+				sets = append(sets, other)
+			}
+		}
+	}
+	sets = append(sets, c.dashboardResourceSets()...)
+
+	var owner *dashboardSet
+	for _, other := range sets {
+		if other.folder != set.folder || (other != set && other.key == set.key) || !other.declaresPermissions() {
+			continue
+		}
+		if owner == nil || ownsFolderBefore(other, owner) {
+			owner = other
+		}
+	}
+	if owner == nil {
+		return nil, "", nil
+	}
+	if owner.permissionsErr != nil {
+		return nil, owner.key, fmt.Errorf("invalid folder permissions in %s: %w", owner.key, owner.permissionsErr)
+	}
+
+	perms := slices.Clone(owner.permissions)
+	slices.SortFunc(perms, func(a, b mcov1beta2.DashboardFolderPermission) int {
+		return cmp.Or(cmp.Compare(a.Team, b.Team), cmp.Compare(a.Role, b.Role))
+	})
+	return perms, owner.key, nil
+}
+
+// ownsFolderBefore reports whether the source of a set was created before the source of another set,
+// using the keys of the sources to break the ties.
+func ownsFolderBefore(set, other *dashboardSet) bool {
+	created, otherCreated := set.owner.GetCreationTimestamp(), other.owner.GetCreationTimestamp()
+	if !created.Equal(&otherCreated) {
+		return created.Before(&otherCreated)
+	}
+	return set.key < other.key
+}
+
+// reconcileFolderPermissions makes the permissions of a folder match the permissions declared by the
+// source owning them, reverting the changes made in Grafana. Folders without declared permissions are
+// left untouched and keep the Grafana defaults. When the set declares permissions without owning them,
+// the conflict is recorded in the set and reported on its source.
+func (c *GrafanaDashboardController) reconcileFolderPermissions(ctx context.Context, folderID int64, set *dashboardSet) error {
+	perms, ownerKey, err := c.folderPermissions(set)
+	set.permissionsConflict = ""
+	if set.declaresPermissions() && ownerKey != set.key {
+		set.permissionsConflict = fmt.Sprintf("the permissions of the folder %q are owned by %s, the permissions declared by this source are ignored", set.folder, ownerKey)
+		c.reportPermissionsConflict(set)
+	}
+	if err != nil {
+		// Invalid annotations are terminal user input issues. The current permissions are kept
+		// rather than risking to expose a private folder.
+		klog.ErrorS(err, "skipping folder permissions", "folderTitle", set.folder)
+		return nil
+	}
+	if len(perms) == 0 {
+		return nil
+	}
+
+	desired := make([]folderPermission, 0, len(perms))
+	for _, p := range perms {
+		item := folderPermission{Role: p.Role, Permission: folderPermissionLevels[p.Permission]}
+		if p.Team != "" {
+			teamID, err := c.grafana.GetTeamID(ctx, p.Team)
+			if err != nil {
+				return fmt.Errorf("failed to resolve team for folder %q: %w", set.folder, err)
+			}
+			item.TeamID = teamID
+		}
+		desired = append(desired, item)
+	}
+
+	folder, err := c.grafana.GetFolderByID(ctx, folderID)
+	if err != nil {
+		return fmt.Errorf("failed to get folder %q: %w", set.folder, err)
+	}
+	current, err := c.grafana.GetFolderPermissions(ctx, folder.UID)
+	if err != nil {
+		return fmt.Errorf("failed to get permissions of folder %q: %w", set.folder, err)
+	}
+	if folderPermissionsMatch(current, desired) {
+		return nil
+	}
+
+	klog.InfoS("updating folder permissions", "folderTitle", set.folder, "uid", folder.UID, "owner", ownerKey)
+	if err := c.grafana.UpdateFolderPermissions(ctx, folder.UID, desired); err != nil {
+		return fmt.Errorf("failed to update permissions of folder %q: %w", set.folder, err)
+	}
+	return nil
+}
+
+// reportPermissionsConflict records the folder permissions ignored in a source as an event on the source object.
+func (c *GrafanaDashboardController) reportPermissionsConflict(set *dashboardSet) {
+	klog.InfoS("ignoring the folder permissions of a source not owning them", "namespace", set.owner.GetNamespace(), "name", set.owner.GetName(), "conflict", set.permissionsConflict)
+	obj, ok := set.owner.(k8sruntime.Object)
+	if !ok || c.recorder == nil {
+		return
+	}
+	c.recorder.Event(obj, corev1.EventTypeWarning, reasonFolderPermissionsConflict, set.permissionsConflict)
+}
+
+// folderPermissionsMatch compares the permissions set on a folder to the desired ones,
+// ignoring the permissions inherited from a parent folder.
+func folderPermissionsMatch(current, desired []folderPermission) bool {
+	currentSet := make(map[folderPermission]struct{}, len(current))
+	for _, p := range current {
+		if p.Inherited {
+			continue
+		}
+		currentSet[p] = struct{}{}
+	}
+	if len(currentSet) != len(desired) {
+		return false
+	}
+	for _, p := range desired {
+		if _, ok := currentSet[p]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func newTestPermissionsConfigMap(name, folder, permissions string) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Labels:      map[string]string{CustomDashboardLabelKey: "true"},
+			Annotations: map[string]string{CustomFolderKey: folder},
+		},
+		Data: map[string]string{name + ".json": `{"uid":"` + name + `","title":"` + name + `"}`},
+	}
+	if permissions != "" {
+		cm.Annotations[FolderPermissionsKey] = permissions
+	}
+	return cm
+}
+
+func TestParseFolderPermissions(t *testing.T) {
+	testCases := []struct {
+		name      string
+		value     string
+		expected  []mcov1beta2.DashboardFolderPermission
+		expectErr bool
+	}{
+		{
+			name:  "team and role",
+			value: `[{"team":"sre","permission":"Edit"},{"role":"Viewer","permission":"View"}]`,
+			expected: []mcov1beta2.DashboardFolderPermission{
+				{Team: "sre", Permission: "Edit"},
+				{Role: "Viewer", Permission: "View"},
+			},
+		},
+		{name: "invalid JSON", value: `{"team":"sre"}`, expectErr: true},
+		{name: "team and role in the same entry", value: `[{"team":"sre","role":"Viewer","permission":"View"}]`, expectErr: true},
+		{name: "missing subject", value: `[{"permission":"View"}]`, expectErr: true},
+		{name: "unsupported role", value: `[{"role":"Admin","permission":"View"}]`, expectErr: true},
+		{name: "unsupported permission", value: `[{"team":"sre","permission":"Owner"}]`, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			perms, err := parseFolderPermissions(tc.value)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, perms)
+		})
+	}
+}
+
+func TestReconcileFolderPermissions(t *testing.T) {
+	t.Run("applies the declared permissions and reverts drift", func(t *testing.T) {
+		c, mock := newTestController(t, "default")
+		mock.folders = []grafanaFolder{{ID: 2, UID: "sre-folder", Title: "SRE"}}
+		mock.teams["sre"] = 3
+		cm := newTestPermissionsConfigMap("sre", "SRE", `[{"team":"sre","permission":"Edit"},{"role":"Viewer","permission":"View"}]`)
+
+		require.NoError(t, c.updateDashboard(t.Context(), cm))
+		expected := []folderPermission{{Role: "Viewer", Permission: 1}, {TeamID: 3, Permission: 2}}
+		assert.Equal(t, expected, mock.folderPerms["sre-folder"])
+		assert.Equal(t, 1, mock.updatePermsCalled)
+		assert.Equal(t, 1, mock.createDashCalled)
+
+		// No update when the permissions already match, inherited permissions are ignored.
+		mock.folderPerms["sre-folder"] = append(mock.folderPerms["sre-folder"], folderPermission{Role: "Editor", Permission: 2, Inherited: true})
+		require.NoError(t, c.updateDashboard(t.Context(), cm))
+		assert.Equal(t, 1, mock.updatePermsCalled)
+
+		// Permissions changed in Grafana are reverted.
+		mock.folderPerms["sre-folder"] = []folderPermission{{Role: "Editor", Permission: 2}}
+		require.NoError(t, c.updateDashboard(t.Context(), cm))
+		assert.Equal(t, 2, mock.updatePermsCalled)
+		assert.Equal(t, expected, mock.folderPerms["sre-folder"])
+	})
+
+	t.Run("the oldest source owns the permissions of a folder", func(t *testing.T) {
+		created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		dash := newTestDashboardResource(t, "dash", mcov1beta2.ObservabilityDashboardSpec{
+			JSON:        testDashboardJSON,
+			Folder:      "Shared",
+			Permissions: []mcov1beta2.DashboardFolderPermission{{Team: "dev", Permission: "Admin"}},
+		})
+		dash.SetCreationTimestamp(metav1.NewTime(created.Add(time.Hour)))
+		c, mock := newTestResourceController(t, dash)
+		recorder := record.NewFakeRecorder(10)
+		c.recorder = recorder
+		mock.folders = []grafanaFolder{{ID: 2, UID: "shared-folder", Title: "Shared"}}
+		mock.teams["sre"] = 3
+		mock.teams["dev"] = 4
+		mock.teams["ops"] = 5
+		owner := newTestPermissionsConfigMap("owner", "Shared", `[{"team":"sre","permission":"Admin"},{"team":"dev","permission":"View"}]`)
+		owner.CreationTimestamp = metav1.NewTime(created)
+		c.indexer = newTestIndexer()
+		require.NoError(t, c.indexer.Add(owner))
+		require.NoError(t, c.indexer.Add(newTestPermissionsConfigMap("elsewhere", "Other", `[{"team":"ops","permission":"Admin"}]`)))
+		ownerPerms := []folderPermission{{TeamID: 4, Permission: 1}, {TeamID: 3, Permission: 4}}
+
+		// A newer ConfigMap can't change the permissions of the folder, the conflict is reported in an event.
+		late := newTestPermissionsConfigMap("late", "Shared", `[{"team":"ops","permission":"Admin"}]`)
+		late.CreationTimestamp = metav1.NewTime(created.Add(2 * time.Hour))
+		require.NoError(t, c.updateDashboard(t.Context(), late))
+		assert.Equal(t, ownerPerms, mock.folderPerms["shared-folder"])
+		assert.Equal(t, 1, mock.createDashCalled)
+		require.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, `Warning FolderPermissionsConflict the permissions of the folder "Shared" are owned by default/owner`)
+
+		// A newer ObservabilityDashboard neither, the conflict is reported in its status.
+		require.NoError(t, c.syncHandler(t.Context(), dashboardResourceKeyPrefix+"default/dash", newTestIndexer()))
+		assert.Equal(t, ownerPerms, mock.folderPerms["shared-folder"])
+		cond := meta.FindStatusCondition(getTestDashboardResource(t, c, "dash").Status.Conditions, mcov1beta2.DashboardFolderPermissionsCondition)
+		require.NotNil(t, cond)
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Equal(t, reasonFolderPermissionsConflict, cond.Reason)
+
+		// Once the owner is deleted, the next oldest source owns the permissions.
+		require.NoError(t, c.indexer.Delete(owner))
+		require.NoError(t, c.syncHandler(t.Context(), dashboardResourceKeyPrefix+"default/dash", newTestIndexer()))
+		assert.Equal(t, []folderPermission{{TeamID: 4, Permission: 4}}, mock.folderPerms["shared-folder"])
+		cond = meta.FindStatusCondition(getTestDashboardResource(t, c, "dash").Status.Conditions, mcov1beta2.DashboardFolderPermissionsCondition)
+		require.NotNil(t, cond)
+		assert.Equal(t, metav1.ConditionTrue, cond.Status)
+		assert.Equal(t, reasonFolderPermissionsApplied, cond.Reason)
+	})
+
+	t.Run("unknown team blocks loading the dashboards", func(t *testing.T) {
+		c, mock := newTestController(t, "default")
+		mock.folders = []grafanaFolder{{ID: 2, UID: "sre-folder", Title: "SRE"}}
+		cm := newTestPermissionsConfigMap("sre", "SRE", `[{"team":"sre","permission":"Edit"}]`)
+
+		assert.ErrorContains(t, c.updateDashboard(t.Context(), cm), `grafana team "sre" not found`)
+		assert.Equal(t, 0, mock.updatePermsCalled)
+		assert.Equal(t, 0, mock.createDashCalled)
+	})
+
+	t.Run("invalid permissions keep the current permissions", func(t *testing.T) {
+		c, mock := newTestController(t, "default")
+		mock.folders = []grafanaFolder{{ID: 2, UID: "sre-folder", Title: "SRE"}}
+		cm := newTestPermissionsConfigMap("sre", "SRE", `[{"team":"sre"}]`)
+
+		assert.NoError(t, c.updateDashboard(t.Context(), cm))
+		assert.Equal(t, 0, mock.updatePermsCalled)
+		assert.Equal(t, 1, mock.createDashCalled)
+	})
+
+	t.Run("folders without permissions are left untouched", func(t *testing.T) {
+		c, mock := newTestController(t, "default")
+		mock.folders = []grafanaFolder{{ID: 2, UID: "sre-folder", Title: "SRE"}}
+		cm := newTestPermissionsConfigMap("sre", "SRE", "")
+
+		assert.NoError(t, c.updateDashboard(t.Context(), cm))
+		assert.Equal(t, 0, mock.updatePermsCalled)
+		assert.Equal(t, 1, mock.createDashCalled)
+	})
+}
+
+func TestFolderPermissionsMatch(t *testing.T) {
+	desired := []folderPermission{{TeamID: 1, Permission: 2}, {Role: "Viewer", Permission: 1}}
+	assert.True(t, folderPermissionsMatch([]folderPermission{{Role: "Viewer", Permission: 1}, {TeamID: 1, Permission: 2}}, desired))
+	assert.True(t, folderPermissionsMatch([]folderPermission{{Role: "Viewer", Permission: 1}, {TeamID: 1, Permission: 2}, {Role: "Editor", Permission: 2, Inherited: true}}, desired))
+	assert.False(t, folderPermissionsMatch([]folderPermission{{TeamID: 1, Permission: 1}, {Role: "Viewer", Permission: 1}}, desired))
+	assert.False(t, folderPermissionsMatch([]folderPermission{{TeamID: 1, Permission: 2}}, desired))
+	assert.False(t, folderPermissionsMatch([]folderPermission{{TeamID: 1, Permission: 2}, {Role: "Viewer", Permission: 1}, {UserID: 1, Permission: 4}}, desired))
+}
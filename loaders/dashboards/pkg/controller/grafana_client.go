@@ -24,6 +24,8 @@ const (
 	apiFoldersID            = "/api/folders/id/"
 	folderPermissionsSuffix = "/permissions"
 	apiPreferences          = "/api/org/preferences"
+	apiTeamsSearch          = "/api/teams/search"
+	apiDatasources          = "/api/datasources"
 
 	// Grafana Error Messages
 	errVersionMismatch = "version-mismatch"
@@ -81,6 +83,23 @@ type grafanaFolder struct {
 	Title string `json:"title"`
 }
 
+// grafanaDatasource represents a Grafana datasource
+type grafanaDatasource struct {
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// folderPermission is an entry of the access control list of a Grafana folder.
+type folderPermission struct {
+	TeamID     int64  `json:"teamId,omitempty"`
+	UserID     int64  `json:"userId,omitempty"`
+	Role       string `json:"role,omitempty"`
+	Permission int    `json:"permission"`
+	// Inherited is only set in responses, for the entries inherited from a parent folder.
+	Inherited bool `json:"inherited,omitempty"`
+}
+
 // GrafanaClient defines the interface for interacting with Grafana
 type GrafanaClient interface {
 	ListAllDashboards(ctx context.Context) ([]grafanaDashboard, error)
@@ -94,6 +113,10 @@ type GrafanaClient interface {
 	DeleteFolder(ctx context.Context, uid string) error
 	HasPermissions(ctx context.Context, uid string) (bool, error)
 	IsEmpty(ctx context.Context, uid string) (bool, error)
+	GetFolderPermissions(ctx context.Context, uid string) ([]folderPermission, error)
+	UpdateFolderPermissions(ctx context.Context, uid string, items []folderPermission) error
+	GetTeamID(ctx context.Context, name string) (int64, error)
+	ListDatasources(ctx context.Context) ([]grafanaDatasource, error)
 }
 
 type grafanaClient struct {
@@ -250,6 +273,72 @@ func (g *grafanaClient) IsEmpty(ctx context.Context, uid string) (bool, error) {
 	return len(res) == 0, nil
 }
 
+func (g *grafanaClient) GetFolderPermissions(ctx context.Context, uid string) ([]folderPermission, error) {
+	targetURL := g.uri + apiFolders + "/" + uid + folderPermissionsSuffix
+	body, status := util.SendRequest(ctx, nil, http.MethodGet, targetURL, nil)
+	if status != http.StatusOK {
+		return nil, &GrafanaError{Status: status, Body: string(body)}
+	}
+	var res []folderPermission
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UpdateFolderPermissions replaces the access control list of a folder.
+func (g *grafanaClient) UpdateFolderPermissions(ctx context.Context, uid string, items []folderPermission) error {
+	payload, err := json.Marshal(map[string][]folderPermission{"items": items})
+	if err != nil {
+		return fmt.Errorf("failed to marshal folder permissions payload: %w", err)
+	}
+	targetURL := g.uri + apiFolders + "/" + uid + folderPermissionsSuffix
+	body, status := util.SendRequest(ctx, nil, http.MethodPost, targetURL, bytes.NewReader(payload))
+	if status != http.StatusOK {
+		return &GrafanaError{Status: status, Body: string(body)}
+	}
+	return nil
+}
+
+// GetTeamID returns the ID of the Grafana team with the given name.
+func (g *grafanaClient) GetTeamID(ctx context.Context, name string) (int64, error) {
+	params := url.Values{}
+	params.Add("name", name)
+	targetURL := g.uri + apiTeamsSearch + "?" + params.Encode()
+	body, status := util.SendRequest(ctx, nil, http.MethodGet, targetURL, nil)
+	if status != http.StatusOK {
+		return 0, &GrafanaError{Status: status, Body: string(body)}
+	}
+	var res struct {
+		Teams []struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		} `json:"teams"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return 0, err
+	}
+	for _, team := range res.Teams {
+		if team.Name == name {
+			return team.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("grafana team %q not found", name)
+}
+
+func (g *grafanaClient) ListDatasources(ctx context.Context) ([]grafanaDatasource, error) {
+	targetURL := g.uri + apiDatasources
+	body, status := util.SendRequest(ctx, nil, http.MethodGet, targetURL, nil)
+	if status != http.StatusOK {
+		return nil, &GrafanaError{Status: status, Body: string(body)}
+	}
+	var res []grafanaDatasource
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
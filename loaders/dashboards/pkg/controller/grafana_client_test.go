@@ -6,8 +6,10 @@ package controller
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 )
 
@@ -190,6 +192,60 @@ func TestGrafanaClient(t *testing.T) {
 			t.Errorf("expected not empty")
 		}
 	})
+
+	t.Run("Folder permissions and teams", func(t *testing.T) {
+		var updated string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/folders/f1" + folderPermissionsSuffix:
+				if r.Method == http.MethodPost {
+					body, _ := io.ReadAll(r.Body)
+					updated = string(body)
+					w.Write([]byte("{\"message\": \"Folder permissions updated\"}"))
+					return
+				}
+				w.Write([]byte("[{\"teamId\": 3, \"team\": \"sre\", \"permission\": 2}, {\"role\": \"Viewer\", \"permission\": 1, \"inherited\": true}]"))
+			case apiTeamsSearch:
+				if r.URL.Query().Get("name") == "sre" {
+					w.Write([]byte("{\"totalCount\": 1, \"teams\": [{\"id\": 3, \"name\": \"sre\"}]}"))
+				} else {
+					w.Write([]byte("{\"totalCount\": 0, \"teams\": []}"))
+				}
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+
+		client := &grafanaClient{uri: ts.URL}
+
+		perms, err := client.GetFolderPermissions(ctx, "f1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []folderPermission{{TeamID: 3, Permission: 2}, {Role: "Viewer", Permission: 1, Inherited: true}}
+		if !reflect.DeepEqual(perms, expected) {
+			t.Errorf("unexpected permissions: %v", perms)
+		}
+		if _, err := client.GetFolderPermissions(ctx, "f2"); err == nil {
+			t.Errorf("expected error for unknown folder")
+		}
+
+		if err := client.UpdateFolderPermissions(ctx, "f1", []folderPermission{{TeamID: 3, Permission: 4}}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if updated != "{\"items\":[{\"teamId\":3,\"permission\":4}]}" {
+			t.Errorf("unexpected update payload: %s", updated)
+		}
+
+		id, err := client.GetTeamID(ctx, "sre")
+		if err != nil || id != 3 {
+			t.Errorf("unexpected team ID %d: %v", id, err)
+		}
+		if _, err := client.GetTeamID(ctx, "unknown"); err == nil {
+			t.Errorf("expected error for unknown team")
+		}
+	})
 }
 
 func TestGrafanaError(t *testing.T) {
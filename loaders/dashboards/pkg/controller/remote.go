@@ -0,0 +1,242 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+)
+
+const (
+	// maxRemoteDashboardSize limits the size of the dashboards fetched from remote sources (10MB).
+	maxRemoteDashboardSize = 10 * 1024 * 1024
+	remoteFetchTimeout     = 30 * time.Second
+	maxRemoteRedirects     = 5
+)
+
+// remoteFetcher fetches the dashboards of the URL and Git sources. The sources are set by the authors of
+// the ObservabilityDashboards, so the fetches are restricted to HTTPS Git repositories and HTTP(S) URLs,
+// and to the allowed hosts when set. Without allowed hosts, only the public addresses can be reached,
+// which keeps the in-cluster services, the local Grafana API and the cloud metadata endpoints out of reach.
+type remoteFetcher struct {
+	allowedHosts []string
+	client       *http.Client
+}
+
+func newRemoteFetcher(allowedHosts []string) *remoteFetcher {
+	f := &remoteFetcher{}
+	for _, host := range allowedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			f.allowedHosts = append(f.allowedHosts, host)
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: remoteFetchTimeout}
+	if len(f.allowedHosts) == 0 {
+		dialer.Control = refuseNonPublicAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	f.client = &http.Client{
+		Timeout:   remoteFetchTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRemoteRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRemoteRedirects)
+			}
+			return f.checkURL(req.URL, "http", "https")
+		},
+	}
+	return f
+}
+
+// checkURL returns an error when the scheme of the URL is not one of the schemes or its host is not allowed.
+func (f *remoteFetcher) checkURL(u *url.URL, schemes ...string) error {
+	if !slices.Contains(schemes, u.Scheme) {
+		return fmt.Errorf("unsupported scheme %q, expected one of %s", u.Scheme, strings.Join(schemes, ", "))
+	}
+	if u.Hostname() == "" {
+		return errors.New("the host is missing")
+	}
+	if !f.hostAllowed(u.Hostname()) {
+		return fmt.Errorf("the host %q is not allowed", u.Hostname())
+	}
+	return nil
+}
+
+// hostAllowed reports whether the host matches one of the allowed hosts, e.g. "grafana.com" or "*.example.com".
+// All the hosts are allowed when no allowed host is set.
+func (f *remoteFetcher) hostAllowed(host string) bool {
+	if len(f.allowedHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, allowed := range f.allowedHosts {
+		if host == allowed {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok && strings.HasPrefix(suffix, ".") && strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// refuseNonPublicAddress refuses the connections to the loopback, private, link-local and unspecified addresses.
+// It checks the resolved address, so that a public host name resolving to a private address is refused too.
+func refuseNonPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !isPublicIP(net.ParseIP(host)) {
+		return fmt.Errorf("the address %s is not a public address", host)
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	return ip != nil && ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// fetchURL fetches a dashboard definition from an HTTP or HTTPS URL.
+func (f *remoteFetcher) fetchURL(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if err := f.checkURL(u, "http", "https"); err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request for %s: %w", rawURL, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch dashboard from %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch dashboard from %s: unexpected status code %d", rawURL, resp.StatusCode)
+	}
+	return readDashboard(resp.Body)
+}
+
+// fetchGit fetches a dashboard definition from a file in a Git repository, using a shallow HTTPS clone.
+func (f *remoteFetcher) fetchGit(ctx context.Context, source *mcov1beta2.DashboardGitSource) (string, error) {
+	if !filepath.IsLocal(source.Path) {
+		return "", fmt.Errorf("invalid path %q: it must be relative to the root of the repository", source.Path)
+	}
+	u, err := url.Parse(source.Repository)
+	if err != nil {
+		return "", fmt.Errorf("invalid repository %q: %w", source.Repository, err)
+	}
+	if err := f.checkURL(u, "https"); err != nil {
+		return "", fmt.Errorf("invalid repository %q: %w", source.Repository, err)
+	}
+	// Git connects by itself, so the addresses of the host are checked before the clone.
+	if len(f.allowedHosts) == 0 {
+		if err := checkPublicHost(ctx, u.Hostname()); err != nil {
+			return "", fmt.Errorf("invalid repository %q: %w", source.Repository, err)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "dashboard-git-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create clone directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Only the HTTPS transport is allowed, also for the submodules and the redirects.
+	args := []string{"-c", "protocol.allow=never", "-c", "protocol.https.allow=always", "-c", "http.followRedirects=false",
+		"clone", "--quiet", "--depth=1", "--single-branch", "--no-tags"}
+	if source.Ref != "" {
+		args = append(args, "--branch", source.Ref)
+	}
+	args = append(args, "--", source.Repository, dir)
+
+	ctx, cancel := context.WithTimeout(ctx, remoteFetchTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...) //nolint:gosec // arguments are passed without a shell and options are terminated by --
+	// Never prompt for credentials, only public repositories are supported.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to clone %s: %w: %s", source.Repository, err, truncate(string(out), 512))
+	}
+
+	content, err := readRepositoryFile(dir, source.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s in %s: %w", source.Path, source.Repository, err)
+	}
+	return content, nil
+}
+
+// checkPublicHost returns an error when the host resolves to an address that is not public.
+func checkPublicHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("the host %s resolves to %s, which is not a public address", host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// readRepositoryFile reads a regular file of the cloned repository. The symbolic links committed in the repository
+// are refused, and the file is opened within the clone so that no path can escape it.
+func readRepositoryFile(dir, path string) (string, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return "", err
+	}
+	defer root.Close()
+
+	info, err := root.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+	file, err := root.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return readDashboard(file)
+}
+
+func readDashboard(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxRemoteDashboardSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read dashboard: %w", err)
+	}
+	if len(data) > maxRemoteDashboardSize {
+		return "", errors.New("dashboard exceeds the size limit of 10MB")
+	}
+	return string(data), nil
+}
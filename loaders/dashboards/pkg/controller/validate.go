@@ -0,0 +1,412 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// minSchemaVersion is the schema version of the Grafana 8.0 dashboards. Older dashboards may
+	// rely on the deprecated Angular panels.
+	minSchemaVersion = 27
+	// templateVariablePlaceholder replaces the Grafana template variables to parse PromQL expressions.
+	templateVariablePlaceholder = "__grafana_variable__"
+	// templateRangePlaceholder replaces the Grafana template variables in range selectors.
+	templateRangePlaceholder = "5m"
+	// maxReportedIssues limits the number of issues listed in a validation report.
+	maxReportedIssues = 10
+
+	// Names of the hub recording rules ConfigMaps, whose metrics can be used in dashboards.
+	ruleDefaultConfigMapName = "thanos-ruler-default-rules"
+	ruleDefaultConfigMapKey  = "default_rules.yaml"
+	ruleCustomConfigMapName  = "thanos-ruler-custom-rules"
+	ruleCustomConfigMapKey   = "custom_rules.yaml"
+)
+
+var (
+	// templateVariableRegexp matches the $var, ${var} and [[var]] template variable syntaxes.
+	templateVariableRegexp = regexp.MustCompile(`\$\{[^}]*\}|\[\[[^\]]*\]\]|\$\w+`)
+	// rangeSelectorRegexp matches the range and subquery selectors of a PromQL expression.
+	rangeSelectorRegexp = regexp.MustCompile(`\[[^\[\]]*\]`)
+
+	// builtinDatasources are the datasources provided by Grafana itself.
+	builtinDatasources = []string{"grafana", "-- Grafana --", "-- Mixed --", "-- Dashboard --", "__expr__", "default"}
+)
+
+// validationResult holds the issues found in a dashboard. Errors are issues that break the
+// dashboard, like invalid queries, while warnings are likely mistakes.
+type validationResult struct {
+	errors   []string
+	warnings []string
+}
+
+func (r *validationResult) empty() bool {
+	return r == nil || (len(r.errors) == 0 && len(r.warnings) == 0)
+}
+
+// summary returns a human readable report of the issues, listing at most maxReportedIssues of them.
+func (r *validationResult) summary() string {
+	issues := make([]string, 0, len(r.errors)+len(r.warnings))
+	for _, e := range r.errors {
+		issues = append(issues, "error: "+e)
+	}
+	for _, w := range r.warnings {
+		issues = append(issues, "warning: "+w)
+	}
+	if len(issues) > maxReportedIssues {
+		issues = append(issues[:maxReportedIssues], fmt.Sprintf("and %d more issues", len(issues)-maxReportedIssues))
+	}
+	return strings.Join(issues, "; ")
+}
+
+// dashboardValidator checks dashboards against the datasources provisioned in Grafana and the
+// metrics collected from the managed clusters.
+type dashboardValidator struct {
+	// datasources maps the UIDs and names of the Grafana datasources to their type.
+	// It is nil when the datasources are unknown, in which case they aren't checked.
+	datasources map[string]string
+	// allowedMetrics is the set of metrics available on the hub. It is nil when the allowlist
+	// is unknown, in which case the metrics aren't checked.
+	allowedMetrics map[string]struct{}
+}
+
+// newDashboardValidator returns a validator for the current Grafana datasources and metrics allowlist.
+func (c *GrafanaDashboardController) newDashboardValidator(ctx context.Context) *dashboardValidator {
+	v := &dashboardValidator{}
+
+	datasources, err := c.grafana.ListDatasources(ctx)
+	if err != nil {
+		klog.ErrorS(err, "failed to list datasources, skipping the datasource validation")
+	}
+	if len(datasources) > 0 {
+		v.datasources = make(map[string]string, 2*len(datasources))
+		for _, ds := range datasources {
+			v.datasources[ds.UID] = ds.Type
+			v.datasources[ds.Name] = ds.Type
+		}
+	}
+
+	if c.indexer != nil {
+		v.allowedMetrics = allowedMetrics(c.indexer.GetByKey, c.watchedNS)
+	}
+	return v
+}
+
+// allowedMetrics returns the metrics collected from the managed clusters and recorded on the hub,
+// read from the allowlist and rules ConfigMaps. It returns nil if the default allowlist isn't found.
+func allowedMetrics(getByKey func(key string) (any, bool, error), ns string) map[string]struct{} {
+	getConfigMap := func(name string) *corev1.ConfigMap {
+		obj, exists, err := getByKey(ns + "/" + name)
+		if err != nil || !exists {
+			return nil
+		}
+		cm, _ := obj.(*corev1.ConfigMap)
+		return cm
+	}
+
+	defaultAllowlist := getConfigMap(operatorconfig.AllowlistConfigMapName)
+	if defaultAllowlist == nil {
+		return nil
+	}
+
+	metrics := map[string]struct{}{}
+	addNames := func(names ...string) {
+		for _, name := range names {
+			if name != "" {
+				metrics[name] = struct{}{}
+			}
+		}
+	}
+	addMatches := func(matches []string) {
+		for _, match := range matches {
+			matchers, err := parser.ParseMetricSelector("{" + match + "}")
+			if err != nil {
+				continue
+			}
+			for _, m := range matchers {
+				if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+					addNames(m.Value)
+				}
+			}
+		}
+	}
+
+	for _, cm := range []*corev1.ConfigMap{defaultAllowlist, getConfigMap(operatorconfig.AllowlistCustomConfigMapName)} {
+		if cm == nil {
+			continue
+		}
+		for _, key := range []string{operatorconfig.MetricsConfigMapKey, operatorconfig.UwlMetricsConfigMapKey} {
+			if cm.Data[key] == "" {
+				continue
+			}
+			allowlist := &operatorconfig.MetricsAllowlist{}
+			if err := yaml.Unmarshal([]byte(cm.Data[key]), allowlist); err != nil {
+				klog.ErrorS(err, "failed to unmarshal metrics allowlist", "name", cm.Name, "key", key)
+				continue
+			}
+			addNames(allowlist.NameList...)
+			addMatches(allowlist.MatchList)
+			for from, to := range allowlist.RenameMap {
+				addNames(from, to)
+			}
+			for _, rule := range slices.Concat(allowlist.RuleList, allowlist.RecordingRuleList) {
+				addNames(rule.Record)
+			}
+			for _, group := range allowlist.CollectRuleGroupList {
+				for _, rule := range group.CollectRuleList {
+					addNames(rule.Metrics.NameList...)
+					addMatches(rule.Metrics.MatchList)
+				}
+			}
+		}
+	}
+
+	for name, key := range map[string]string{ruleDefaultConfigMapName: ruleDefaultConfigMapKey, ruleCustomConfigMapName: ruleCustomConfigMapKey} {
+		cm := getConfigMap(name)
+		if cm == nil || cm.Data[key] == "" {
+			continue
+		}
+		var rules struct {
+			Groups []struct {
+				Rules []struct {
+					Record string `yaml:"record"`
+				} `yaml:"rules"`
+			} `yaml:"groups"`
+		}
+		if err := yaml.Unmarshal([]byte(cm.Data[key]), &rules); err != nil {
+			klog.ErrorS(err, "failed to unmarshal rules", "name", name, "key", key)
+			continue
+		}
+		for _, group := range rules.Groups {
+			for _, rule := range group.Rules {
+				addNames(rule.Record)
+			}
+		}
+	}
+	return metrics
+}
+
+// validate checks the schema version, the datasources and the PromQL queries of a dashboard.
+func (v *dashboardValidator) validate(dashboard map[string]any) *validationResult {
+	result := &validationResult{}
+
+	if version, ok := dashboard["schemaVersion"]; ok {
+		switch n := version.(type) {
+		case float64:
+			if n < minSchemaVersion {
+				result.warnings = append(result.warnings, fmt.Sprintf("schemaVersion %v is older than %d, the dashboard may use deprecated panels", n, minSchemaVersion))
+			}
+		default:
+			result.errors = append(result.errors, fmt.Sprintf("schemaVersion must be a number, got %v", version))
+		}
+	}
+
+	if templating, ok := dashboard["templating"].(map[string]any); ok {
+		for _, variable := range toObjects(templating["list"]) {
+			if err := v.checkDatasource(variable["datasource"]); err != "" {
+				result.errors = append(result.errors, fmt.Sprintf("variable %q: %s", variable["name"], err))
+			}
+		}
+	}
+
+	missingMetrics := map[string]struct{}{}
+	for _, panel := range dashboardPanels(dashboard) {
+		name := panelName(panel)
+		panelDatasource := panel["datasource"]
+		if err := v.checkDatasource(panelDatasource); err != "" {
+			result.errors = append(result.errors, fmt.Sprintf("%s: %s", name, err))
+		}
+
+		for _, target := range toObjects(panel["targets"]) {
+			datasource := target["datasource"]
+			if datasource == nil {
+				datasource = panelDatasource
+			} else if err := v.checkDatasource(datasource); err != "" {
+				result.errors = append(result.errors, fmt.Sprintf("%s target %v: %s", name, target["refId"], err))
+			}
+			if !v.isPrometheusDatasource(datasource) {
+				continue
+			}
+
+			expr, _ := target["expr"].(string)
+			if strings.TrimSpace(expr) == "" {
+				continue
+			}
+			parsed, err := parsePanelQuery(expr)
+			if err != nil {
+				result.errors = append(result.errors, fmt.Sprintf("%s target %v: invalid PromQL: %v", name, target["refId"], err))
+				continue
+			}
+			for _, metric := range queryMetrics(parsed) {
+				if _, ok := v.allowedMetrics[metric]; !ok && v.allowedMetrics != nil {
+					missingMetrics[metric] = struct{}{}
+				}
+			}
+		}
+	}
+
+	if len(missingMetrics) > 0 {
+		names := make([]string, 0, len(missingMetrics))
+		for name := range missingMetrics {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		result.warnings = append(result.warnings, fmt.Sprintf("metrics not in the collector allowlist: %s", strings.Join(names, ", ")))
+	}
+	return result
+}
+
+// checkDatasource returns an issue if the datasource reference isn't provisioned in Grafana.
+// References may be a datasource name, or an object with the UID of the datasource.
+func (v *dashboardValidator) checkDatasource(ref any) string {
+	id := datasourceID(ref)
+	if id == "" || v.datasources == nil {
+		return ""
+	}
+	if _, ok := v.datasources[id]; !ok {
+		return fmt.Sprintf("unknown datasource %q", id)
+	}
+	return ""
+}
+
+// isPrometheusDatasource returns false if the datasource is known not to be a Prometheus datasource.
+// The default datasource and datasources set by template variables are assumed to be Prometheus.
+func (v *dashboardValidator) isPrometheusDatasource(ref any) bool {
+	if obj, ok := ref.(map[string]any); ok {
+		if t, _ := obj["type"].(string); t != "" && !strings.Contains(t, "$") {
+			return t == "prometheus"
+		}
+		ref = obj["uid"]
+	}
+	id, _ := ref.(string)
+	if slices.Contains(builtinDatasources, id) {
+		return false
+	}
+	if t, ok := v.datasources[id]; ok {
+		return t == "prometheus"
+	}
+	return true
+}
+
+// datasourceID returns the UID or name of a datasource reference, or an empty string for the default
+// datasource, the Grafana built-in datasources and the datasources set by template variables.
+func datasourceID(ref any) string {
+	var id string
+	switch r := ref.(type) {
+	case string:
+		id = r
+	case map[string]any:
+		id, _ = r["uid"].(string)
+	}
+	if strings.Contains(id, "$") || slices.Contains(builtinDatasources, id) {
+		return ""
+	}
+	return id
+}
+
+// parsePanelQuery parses a PromQL expression after replacing the Grafana template variables.
+// Variables in range selectors are replaced by a duration and the other ones by an identifier,
+// falling back to a number for the variables used as scalar parameters.
+func parsePanelQuery(expr string) (parser.Expr, error) {
+	if !templateVariableRegexp.MatchString(expr) {
+		return parser.ParseExpr(expr)
+	}
+
+	expr = rangeSelectorRegexp.ReplaceAllStringFunc(expr, func(selector string) string {
+		return templateVariableRegexp.ReplaceAllString(selector, templateRangePlaceholder)
+	})
+	parsed, err := parser.ParseExpr(templateVariableRegexp.ReplaceAllString(expr, templateVariablePlaceholder))
+	if err == nil {
+		return parsed, nil
+	}
+	if parsed, scalarErr := parser.ParseExpr(templateVariableRegexp.ReplaceAllString(expr, "1")); scalarErr == nil {
+		return parsed, nil
+	}
+	return nil, err
+}
+
+// queryMetrics returns the metric names selected by a PromQL expression, excluding the names set by template variables.
+func queryMetrics(expr parser.Expr) []string {
+	var metrics []string
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		vs, ok := node.(*parser.VectorSelector)
+		if !ok {
+			return nil
+		}
+		for _, m := range vs.LabelMatchers {
+			if m.Name == labels.MetricName && m.Type == labels.MatchEqual && !strings.Contains(m.Value, templateVariablePlaceholder) {
+				metrics = append(metrics, m.Value)
+			}
+		}
+		return nil
+	})
+	return metrics
+}
+
+// dashboardPanels returns all the panels of a dashboard, including the panels of collapsed rows
+// and of the legacy rows of old schema versions.
+func dashboardPanels(dashboard map[string]any) []map[string]any {
+	var panels []map[string]any
+	var walk func(list any)
+	walk = func(list any) {
+		for _, panel := range toObjects(list) {
+			panels = append(panels, panel)
+			walk(panel["panels"])
+		}
+	}
+	walk(dashboard["panels"])
+	for _, row := range toObjects(dashboard["rows"]) {
+		walk(row["panels"])
+	}
+	return panels
+}
+
+func panelName(panel map[string]any) string {
+	if title, ok := panel["title"].(string); ok && title != "" {
+		return fmt.Sprintf("panel %q", title)
+	}
+	return fmt.Sprintf("panel %v", panel["id"])
+}
+
+func toObjects(list any) []map[string]any {
+	items, _ := list.([]any)
+	objects := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		if obj, ok := item.(map[string]any); ok {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
+// reportValidation records the validation issues of a dashboard as an event on its source object.
+func (c *GrafanaDashboardController) reportValidation(set *dashboardSet, key string, result *validationResult) {
+	if result.empty() {
+		return
+	}
+	klog.InfoS("dashboard validation found issues", "namespace", set.owner.GetNamespace(), "name", set.owner.GetName(), "key", key, "issues", result.summary())
+	obj, ok := set.owner.(k8sruntime.Object)
+	if !ok || c.recorder == nil {
+		return
+	}
+	reason := reasonDashboardValidationWarn
+	if len(result.errors) > 0 {
+		reason = reasonDashboardInvalid
+	}
+	c.recorder.Eventf(obj, corev1.EventTypeWarning, reason, "dashboard %s: %s", key, result.summary())
+}
@@ -0,0 +1,248 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package controller
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/yaml"
+)
+
+var testDatasources = []grafanaDatasource{
+	{UID: "000000001", Name: "Observatorium", Type: "prometheus"},
+	{UID: "000000002", Name: "Observatorium-Dynamic", Type: "prometheus"},
+	{UID: "loki", Name: "Loki", Type: "loki"},
+}
+
+func newTestValidator(metrics ...string) *dashboardValidator {
+	v := &dashboardValidator{datasources: map[string]string{}}
+	for _, ds := range testDatasources {
+		v.datasources[ds.UID] = ds.Type
+		v.datasources[ds.Name] = ds.Type
+	}
+	if metrics != nil {
+		v.allowedMetrics = map[string]struct{}{}
+		for _, m := range metrics {
+			v.allowedMetrics[m] = struct{}{}
+		}
+	}
+	return v
+}
+
+func TestParsePanelQuery(t *testing.T) {
+	testCases := []struct {
+		name      string
+		expr      string
+		expectErr bool
+	}{
+		{name: "plain query", expr: `sum(rate(node_cpu_seconds_total{mode="idle"}[5m])) by (cluster)`},
+		{name: "variable in matcher", expr: `up{cluster=~"$cluster", namespace="${namespace}"}`},
+		{name: "variable in range", expr: `rate(container_cpu_usage_seconds_total[$__rate_interval])`},
+		{name: "variable in subquery", expr: `max_over_time(rate(up[5m])[$__range:$__interval])`},
+		{name: "variable in grouping", expr: `sum(up) by ($groupby)`},
+		{name: "scalar variable", expr: `topk($limit, up)`},
+		{name: "legacy variable", expr: `up{cluster="[[cluster]]"}`},
+		{name: "invalid query", expr: `sum(rate(up[5m]) by (cluster)`, expectErr: true},
+		{name: "invalid query with variables", expr: `rate(up{cluster="$cluster"}[$__rate_interval]`, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parsePanelQuery(tc.expr)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDashboardValidatorValidate(t *testing.T) {
+	testCases := []struct {
+		name           string
+		dashboard      string
+		validator      *dashboardValidator
+		expectErrors   []string
+		expectWarnings []string
+	}{
+		{
+			name: "valid dashboard",
+			dashboard: `{"schemaVersion": 39, "panels": [{"title": "CPU", "datasource": {"type": "prometheus", "uid": "000000001"},
+				"targets": [{"refId": "A", "expr": "sum(rate(node_cpu_seconds_total{cluster=\"$cluster\"}[$__rate_interval]))"}]}]}`,
+			validator: newTestValidator("node_cpu_seconds_total"),
+		},
+		{
+			name:           "old schema version",
+			dashboard:      `{"schemaVersion": 16}`,
+			validator:      newTestValidator(),
+			expectWarnings: []string{"schemaVersion 16 is older than 27"},
+		},
+		{
+			name:         "invalid schema version",
+			dashboard:    `{"schemaVersion": "39"}`,
+			validator:    newTestValidator(),
+			expectErrors: []string{"schemaVersion must be a number"},
+		},
+		{
+			name: "unknown datasources",
+			dashboard: `{"templating": {"list": [{"name": "cluster", "datasource": "Prometheus"}]},
+				"panels": [{"title": "CPU", "datasource": {"type": "prometheus", "uid": "unknown"}}, {"id": 2, "datasource": "Observatorium"}]}`,
+			validator: newTestValidator(),
+			expectErrors: []string{
+				`variable "cluster": unknown datasource "Prometheus"`,
+				`panel "CPU": unknown datasource "unknown"`,
+			},
+		},
+		{
+			name: "builtin and variable datasources",
+			dashboard: `{"annotations": {"list": [{"datasource": "-- Grafana --"}]}, "panels": [
+				{"title": "CPU", "datasource": "$datasource", "targets": [{"refId": "A", "expr": "up"}]},
+				{"title": "Mixed", "datasource": {"uid": "-- Mixed --"}, "targets": [{"refId": "A", "datasource": {"uid": "${ds}"}, "expr": "up"}]}]}`,
+			validator: newTestValidator(),
+		},
+		{
+			name: "invalid PromQL in nested panel",
+			dashboard: `{"panels": [{"title": "Row", "type": "row", "panels": [
+				{"title": "CPU", "targets": [{"refId": "A", "expr": "sum(rate(up[5m])"}]}]}]}`,
+			validator:    newTestValidator(),
+			expectErrors: []string{`panel "CPU" target A: invalid PromQL`},
+		},
+		{
+			name: "non Prometheus targets are not parsed",
+			dashboard: `{"panels": [{"title": "Logs", "datasource": {"type": "loki", "uid": "loki"},
+				"targets": [{"refId": "A", "expr": "{app=\"foo\"} |= \"error\""}]},
+				{"title": "Logs by UID", "datasource": "Loki", "targets": [{"refId": "A", "expr": "{app=\"foo\"} |= \"error\""}]}]}`,
+			validator: newTestValidator(),
+		},
+		{
+			name: "metrics not in the allowlist",
+			dashboard: `{"rows": [{"panels": [{"title": "Legacy", "targets": [
+				{"refId": "A", "expr": "rate(custom_metric_total[5m]) / on(cluster) up"},
+				{"refId": "B", "expr": "{__name__=\"another_metric\"} + ${metric}"}]}]}]}`,
+			validator:      newTestValidator("up"),
+			expectWarnings: []string{"metrics not in the collector allowlist: another_metric, custom_metric_total"},
+		},
+		{
+			name:      "unknown datasources and allowlist are not checked",
+			dashboard: `{"panels": [{"title": "CPU", "datasource": "unknown", "targets": [{"refId": "A", "expr": "custom_metric"}]}]}`,
+			validator: &dashboardValidator{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dashboard := map[string]any{}
+			require.NoError(t, json.Unmarshal([]byte(tc.dashboard), &dashboard))
+
+			result := tc.validator.validate(dashboard)
+			require.Len(t, result.errors, len(tc.expectErrors), "errors: %v", result.errors)
+			for i, expected := range tc.expectErrors {
+				assert.Contains(t, result.errors[i], expected)
+			}
+			require.Len(t, result.warnings, len(tc.expectWarnings), "warnings: %v", result.warnings)
+			for i, expected := range tc.expectWarnings {
+				assert.Contains(t, result.warnings[i], expected)
+			}
+		})
+	}
+}
+
+func TestDashboardValidatorExampleDashboard(t *testing.T) {
+	cm, err := createDashboard()
+	require.NoError(t, err)
+
+	for key, value := range cm.Data {
+		dashboard := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(value), &dashboard))
+		result := newTestValidator().validate(dashboard)
+		assert.Empty(t, result.errors, key)
+	}
+}
+
+func TestAllowedMetrics(t *testing.T) {
+	data, err := os.ReadFile("../../../../operators/multiclusterobservability/manifests/base/config/metrics_allowlist.yaml")
+	require.NoError(t, err)
+	allowlist := &corev1.ConfigMap{}
+	require.NoError(t, yaml.Unmarshal(data, allowlist))
+	allowlist.Namespace = "default"
+
+	indexer := newTestIndexer()
+	assert.Nil(t, allowedMetrics(indexer.GetByKey, "default"))
+
+	require.NoError(t, indexer.Add(allowlist))
+	require.NoError(t, indexer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability-metrics-custom-allowlist", Namespace: "default"},
+		Data:       map[string]string{"metrics_list.yaml": "names:\n  - custom_metric\n"},
+	}))
+	require.NoError(t, indexer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ruleCustomConfigMapName, Namespace: "default"},
+		Data:       map[string]string{ruleCustomConfigMapKey: "groups:\n- name: test\n  rules:\n  - record: hub:custom:sum\n    expr: sum(up)\n"},
+	}))
+
+	metrics := allowedMetrics(indexer.GetByKey, "default")
+	for _, name := range []string{
+		"ALERTS",               // names
+		"workqueue_adds_total", // matches
+		"namespace_workload_pod:kube_pod_owner:relabel", // renames
+		"sum:apiserver_request_total:5m",                // recording rules
+		"container_cpu_cfs_periods_total",               // collect rules
+		"custom_metric",                                 // custom allowlist
+		"hub:custom:sum",                                // hub recording rules
+	} {
+		assert.Contains(t, metrics, name)
+	}
+	assert.NotContains(t, metrics, "unknown_metric")
+}
+
+func TestSyncDashboardSetReportsValidation(t *testing.T) {
+	c, mock := newTestController(t, "default")
+	mock.folders = []grafanaFolder{{ID: 1, UID: "custom", Title: DefaultCustomFolder}}
+	mock.datasources = testDatasources
+	recorder := record.NewFakeRecorder(10)
+	c.recorder = recorder
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "dash", Namespace: "default", Labels: map[string]string{CustomDashboardLabelKey: "true"}},
+		Data: map[string]string{
+			"valid.json":   `{"uid": "valid", "panels": [{"title": "CPU", "datasource": "Observatorium", "targets": [{"refId": "A", "expr": "up"}]}]}`,
+			"invalid.json": `{"uid": "invalid", "panels": [{"title": "CPU", "datasource": "Prometheus", "targets": [{"refId": "A", "expr": "up"}]}]}`,
+		},
+	}
+	require.NoError(t, c.updateDashboard(t.Context(), cm))
+	// Invalid dashboards are still loaded.
+	assert.Equal(t, 2, mock.createDashCalled)
+
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.True(t, strings.HasPrefix(event, "Warning "+reasonDashboardInvalid+" dashboard invalid.json:"), event)
+	assert.Contains(t, event, `unknown datasource "Prometheus"`)
+}
+
+func TestSyncDashboardResourceValidatedCondition(t *testing.T) {
+	c, mock := newTestResourceController(t, newTestDashboardResource(t, "dash", mcov1beta2.ObservabilityDashboardSpec{
+		JSON: `{"uid": "dash-uid", "panels": [{"title": "CPU", "targets": [{"refId": "A", "expr": "sum(up"}]}]}`,
+	}))
+	mock.datasources = testDatasources
+
+	require.NoError(t, c.syncHandler(t.Context(), dashboardResourceKeyPrefix+"default/dash", newTestIndexer()))
+	dash := getTestDashboardResource(t, c, "dash")
+	assert.True(t, meta.IsStatusConditionTrue(dash.Status.Conditions, mcov1beta2.DashboardSyncedCondition))
+	cond := meta.FindStatusCondition(dash.Status.Conditions, mcov1beta2.DashboardValidatedCondition)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, reasonValidationFailed, cond.Reason)
+	assert.Contains(t, cond.Message, "invalid PromQL")
+}
@@ -0,0 +1,322 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilityendpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	cmomanifests "github.com/openshift/cluster-monitoring-operator/pkg/manifests"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	externalLabelsFieldPrefix = "prometheusK8s.externalLabels."
+	alertmanagerConfigsField  = "prometheusK8s.alertmanagerConfigs"
+)
+
+type cmoConfigStatusReporter interface {
+	UpdateComponentCondition(context.Context, status.Component, status.Reason, string) (bool, error)
+	GetConditionReason(context.Context, status.Component) (status.Reason, error)
+}
+
+// cmoConfigConflict is a field injected by the addon into the cluster-monitoring-config whose live value differs
+// from the applied one. A missing field has an empty value.
+type cmoConfigConflict struct {
+	Field   string
+	Applied string
+	Live    string
+}
+
+// cmoConfigPreview is the cluster-monitoring-config once the fields of the addon are injected.
+type cmoConfigPreview struct {
+	// configMap is the live configmap, nil when it does not exist.
+	configMap *corev1.ConfigMap
+	// live and injected are the fields of the addon in the live and in the merged configurations.
+	live     map[string]string
+	injected map[string]string
+	merged   string
+}
+
+// CmoConfigDriftDetector detects the changes made by other actors to the fields injected by the addon into the
+// cluster-monitoring-config configmap. The live configuration is split into the fields injected by the addon and
+// the ones desired by the users, the former being compared with the fields last applied by the addon.
+// A drift is reported with an event on the configmap and with the CMOConfig condition of the ObservabilityAddon,
+// naming the conflicting fields and the field manager that last updated the configuration.
+//
+// The applied fields are kept in memory, so that no drift is detected before the first update following a restart.
+type CmoConfigDriftDetector struct {
+	client         client.Client
+	statusReporter cmoConfigStatusReporter
+	recorder       events.EventRecorder
+	logger         logr.Logger
+	applied        map[string]string
+	lastDrift      string
+}
+
+// NewCmoConfigDriftDetector creates a detector of the drift of the cluster-monitoring-config.
+func NewCmoConfigDriftDetector(
+	c client.Client,
+	statusReporter cmoConfigStatusReporter,
+	recorder events.EventRecorder,
+	logger logr.Logger,
+) *CmoConfigDriftDetector {
+	return &CmoConfigDriftDetector{
+		client:         c,
+		statusReporter: statusReporter,
+		recorder:       recorder,
+		logger:         logger,
+	}
+}
+
+// Check reports the drift of the live configuration from the fields last applied by the addon.
+// It returns the fields injected by the addon, recorded with SetApplied once the configuration is updated.
+func (d *CmoConfigDriftDetector) Check(ctx context.Context, hubInfo *operatorconfig.HubInfo, clusterID string) (map[string]string, error) {
+	preview, err := d.preview(ctx, hubInfo, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := []cmoConfigConflict{}
+	if d.applied != nil {
+		conflicts = diffCMOConfigFields(d.applied, preview.live)
+	}
+	if err := d.report(ctx, preview.configMap, conflicts); err != nil {
+		return nil, err
+	}
+
+	return preview.injected, nil
+}
+
+// SetApplied records the fields of the configuration applied by the addon.
+func (d *CmoConfigDriftDetector) SetApplied(fields map[string]string) {
+	d.applied = fields
+}
+
+// Reset forgets the applied fields, when the addon no longer injects them.
+func (d *CmoConfigDriftDetector) Reset() {
+	d.applied = nil
+}
+
+// DryRun reports the configuration merged with the fields of the addon without applying it. The merged
+// configuration is logged, and the fields that would be updated are reported with an event and the
+// CMOConfig condition of the ObservabilityAddon.
+func (d *CmoConfigDriftDetector) DryRun(ctx context.Context, hubInfo *operatorconfig.HubInfo, clusterID string) error {
+	preview, err := d.preview(ctx, hubInfo, clusterID)
+	if err != nil {
+		return err
+	}
+	// The applied fields are unknown once the configuration is left to the other actors
+	d.Reset()
+
+	fields := conflictFields(diffCMOConfigFields(preview.live, preview.injected))
+	d.logger.Info("Dry-run mode, the cluster-monitoring-config is not updated", "updatedFields", fields, "merged", preview.merged)
+
+	msg := "Dry-run mode, the cluster-monitoring-config is not updated. It is in sync with the fields of the addon."
+	if len(fields) > 0 {
+		msg = fmt.Sprintf("Dry-run mode, the cluster-monitoring-config is not updated. The addon would update the fields %s.", strings.Join(fields, ", "))
+		if preview.configMap != nil {
+			d.recorder.Eventf(preview.configMap, nil, corev1.EventTypeNormal, "ConfigDryRun", "ConfigPreview", "%s", msg)
+		}
+	}
+	d.updateCondition(ctx, status.CmoConfigDryRun, msg)
+
+	return nil
+}
+
+// preview reads the live cluster-monitoring-config and injects the fields of the addon, like
+// createOrUpdateClusterMonitoringConfig.
+func (d *CmoConfigDriftDetector) preview(ctx context.Context, hubInfo *operatorconfig.HubInfo, clusterID string) (*cmoConfigPreview, error) {
+	caSecret := AppendHubClusterID(amMtlsCaName, hubInfo.HubClusterID)
+	certSecret := AppendHubClusterID(amMtlsCertName, hubInfo.HubClusterID)
+	accessorSecret := AppendHubClusterID(HubAmAccessorSecretName, hubInfo.HubClusterID)
+
+	ret := &cmoConfigPreview{}
+	cfg := &cmomanifests.ClusterMonitoringConfiguration{}
+	cm := &corev1.ConfigMap{}
+	err := d.client.Get(ctx, types.NamespacedName{Name: clusterMonitoringConfigName, Namespace: promNamespace}, cm)
+	switch {
+	case err == nil:
+		ret.configMap = cm
+		if err := yaml.Unmarshal([]byte(cm.Data[ClusterMonitoringConfigDataKey]), cfg); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the cluster monitoring config: %w", err)
+		}
+	case !apierrors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get configmap %s: %w", clusterMonitoringConfigName, err)
+	}
+
+	ret.live = injectedCMOConfigFields(cfg, caSecret)
+	injectClusterMonitoringConfig(cfg, clusterID, "", hubInfo.AlertmanagerEndpoint, caSecret, certSecret, accessorSecret)
+	ret.injected = injectedCMOConfigFields(cfg, caSecret)
+
+	merged, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the cluster monitoring config: %w", err)
+	}
+	ret.merged = string(merged)
+
+	return ret, nil
+}
+
+// report reports the conflicting fields. The condition is only set once a drift is detected or a dry-run is
+// reported, and the last drift is kept in its message once resolved.
+func (d *CmoConfigDriftDetector) report(ctx context.Context, cm *corev1.ConfigMap, conflicts []cmoConfigConflict) error {
+	if len(conflicts) == 0 {
+		reason, err := d.statusReporter.GetConditionReason(ctx, status.CmoConfig)
+		if err != nil {
+			return fmt.Errorf("failed to get current addon status reason: %w", err)
+		}
+		if reason != status.CmoConfigDriftDetected && reason != status.CmoConfigDryRun {
+			return nil
+		}
+		msg := "The fields of the addon in the cluster-monitoring-config are in sync."
+		if d.lastDrift != "" {
+			msg += " Last drift: " + d.lastDrift
+		}
+		d.updateCondition(ctx, status.CmoConfigInSync, msg)
+		return nil
+	}
+
+	owner := "another actor"
+	if cm != nil {
+		if manager := cmoConfigOwner(cm); manager != "" {
+			owner = fmt.Sprintf("the field manager %q", manager)
+		}
+	} else {
+		owner = "a deletion of the configmap"
+	}
+	d.lastDrift = fmt.Sprintf("the fields %s were changed by %s.", strings.Join(conflictFields(conflicts), ", "), owner)
+	msg := "The fields of the addon in the cluster-monitoring-config drifted, they are applied again: " + d.lastDrift
+
+	d.logger.Info("Detected a drift of the cluster-monitoring-config", "owner", owner, "conflicts", conflicts)
+	if cm != nil {
+		d.recorder.Eventf(cm, nil, corev1.EventTypeWarning, "ConfigDrift", "ConfigReapply", "%s", msg)
+	}
+	d.updateCondition(ctx, status.CmoConfigDriftDetected, msg)
+
+	return nil
+}
+
+func (d *CmoConfigDriftDetector) updateCondition(ctx context.Context, reason status.Reason, msg string) {
+	wasReported, err := d.statusReporter.UpdateComponentCondition(ctx, status.CmoConfig, reason, msg)
+	if err != nil {
+		if errors.Is(err, status.ErrInvalidTransition) {
+			d.logger.Info("Addon status is in an incompatible state to transition, ignoring invalid transition", "message", err.Error())
+		} else {
+			d.logger.Error(err, "Failed to report status")
+		}
+		return
+	}
+	if wasReported {
+		d.logger.Info("Status updated", "component", status.CmoConfig, "reason", reason)
+	}
+}
+
+// injectedCMOConfigFields returns the fields of the configuration injected by the addon, keyed by their path.
+// The managed alertmanager configurations are a single field.
+func injectedCMOConfigFields(cfg *cmomanifests.ClusterMonitoringConfiguration, caSecret string) map[string]string {
+	ret := map[string]string{}
+	if cfg.PrometheusK8sConfig == nil {
+		return ret
+	}
+
+	for _, key := range []string{operatorconfig.ClusterLabelKeyForAlerts, operatorconfig.ClusterNameLabelKeyForAlerts} {
+		if value, ok := cfg.PrometheusK8sConfig.ExternalLabels[key]; ok {
+			ret[externalLabelsFieldPrefix+key] = value
+		}
+	}
+
+	managed := []cmomanifests.AdditionalAlertmanagerConfig{}
+	for _, amc := range cfg.PrometheusK8sConfig.AlertmanagerConfigs {
+		if IsManaged(amc, caSecret) {
+			managed = append(managed, amc)
+		}
+	}
+	if len(managed) > 0 {
+		// The marshaling of the structs does not fail
+		data, _ := yaml.Marshal(managed)
+		ret[alertmanagerConfigsField] = string(data)
+	}
+
+	return ret
+}
+
+// diffCMOConfigFields returns the fields that differ between the applied and the live fields, sorted by path.
+func diffCMOConfigFields(applied, live map[string]string) []cmoConfigConflict {
+	fields := make([]string, 0, len(applied)+len(live))
+	for field := range applied {
+		fields = append(fields, field)
+	}
+	for field := range live {
+		if _, ok := applied[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+
+	ret := []cmoConfigConflict{}
+	for _, field := range fields {
+		appliedValue, inApplied := applied[field]
+		liveValue, inLive := live[field]
+		if inApplied == inLive && appliedValue == liveValue {
+			continue
+		}
+		ret = append(ret, cmoConfigConflict{Field: field, Applied: appliedValue, Live: liveValue})
+	}
+
+	return ret
+}
+
+func conflictFields(conflicts []cmoConfigConflict) []string {
+	ret := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		ret = append(ret, conflict.Field)
+	}
+	return ret
+}
+
+// cmoConfigOwner returns the field manager that last updated the configuration of the configmap, other than the
+// addon. It returns an empty string when it is unknown.
+func cmoConfigOwner(cm *corev1.ConfigMap) string {
+	owner := ""
+	var lastUpdate time.Time
+	for _, entry := range cm.ManagedFields {
+		if entry.Manager == EndpointMonitoringOperatorMgr || entry.FieldsV1 == nil {
+			continue
+		}
+
+		fields := map[string]any{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		data, _ := fields["f:data"].(map[string]any)
+		if _, ok := data["f:"+ClusterMonitoringConfigDataKey]; !ok {
+			continue
+		}
+
+		if entry.Time != nil && entry.Time.Time.Before(lastUpdate) {
+			continue
+		}
+		owner = entry.Manager
+		if entry.Time != nil {
+			lastUpdate = entry.Time.Time
+		}
+	}
+
+	return owner
+}
@@ -0,0 +1,190 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilityendpoint
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	cmomanifests "github.com/openshift/cluster-monitoring-operator/pkg/manifests"
+	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+const userClusterMonitoringConfig = `
+prometheusK8s:
+  retention: 7d
+  externalLabels:
+    env: prod
+`
+
+func newDriftTestClient(t *testing.T, objs ...client.Object) client.Client {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, oav1beta1.AddToScheme(s))
+
+	addon := &oav1beta1.ObservabilityAddon{
+		ObjectMeta: metav1.ObjectMeta{Name: operatorconfig.ObservabilityAddonName, Namespace: "test-ns"},
+	}
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(append(objs, addon)...).WithStatusSubresource(addon).
+		WithReturnManagedFields().Build()
+}
+
+func getCmoConfigCondition(t *testing.T, c client.Client) *oav1beta1.StatusCondition {
+	addon := &oav1beta1.ObservabilityAddon{}
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: operatorconfig.ObservabilityAddonName, Namespace: "test-ns"}, addon))
+	for _, condition := range addon.Status.Conditions {
+		if condition.Type == string(status.CmoConfig) {
+			return &condition
+		}
+	}
+	return nil
+}
+
+func TestCmoConfigDriftDetector(t *testing.T) {
+	ctx := context.Background()
+	hubInfo := &operatorconfig.HubInfo{HubClusterID: "hub-id", AlertmanagerEndpoint: "https://alertmanager"}
+	caSecret := AppendHubClusterID(amMtlsCaName, hubInfo.HubClusterID)
+	certSecret := AppendHubClusterID(amMtlsCertName, hubInfo.HubClusterID)
+	accessorSecret := AppendHubClusterID(HubAmAccessorSecretName, hubInfo.HubClusterID)
+	apply := func(c client.Client) {
+		_, err := CreateOrUpdateCMOConfig(ctx, c, testClusterID, "", hubInfo.AlertmanagerEndpoint, caSecret, certSecret, accessorSecret, "")
+		require.NoError(t, err)
+	}
+
+	c := newDriftTestClient(t, newClusterMonitoringConfigCM(userClusterMonitoringConfig, "kubectl-edit"))
+	recorder := events.NewFakeRecorder(10)
+	d := NewCmoConfigDriftDetector(c, status.NewStatus(c, operatorconfig.ObservabilityAddonName, "test-ns", logr.Discard()), recorder, logr.Discard())
+
+	// The fields of the users are not a drift
+	fields, err := d.Check(ctx, hubInfo, testClusterID)
+	require.NoError(t, err)
+	assert.Equal(t, testClusterID, fields[externalLabelsFieldPrefix+operatorconfig.ClusterLabelKeyForAlerts])
+	assert.Contains(t, fields, alertmanagerConfigsField)
+	assert.NotContains(t, fields, externalLabelsFieldPrefix+"env")
+	apply(c)
+	d.SetApplied(fields)
+
+	fields, err = d.Check(ctx, hubInfo, testClusterID)
+	require.NoError(t, err)
+	assert.Nil(t, getCmoConfigCondition(t, c))
+	assert.Empty(t, recorder.Events)
+
+	// Another actor overwrites the cluster label and removes the alertmanager configuration
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: clusterMonitoringConfigName, Namespace: promNamespace}, cm))
+	cm.Data[ClusterMonitoringConfigDataKey] = "prometheusK8s:\n  externalLabels:\n    managed_cluster: other\n"
+	require.NoError(t, c.Update(ctx, cm, client.FieldOwner("argocd-controller")))
+
+	_, err = d.Check(ctx, hubInfo, testClusterID)
+	require.NoError(t, err)
+	condition := getCmoConfigCondition(t, c)
+	require.NotNil(t, condition)
+	assert.Equal(t, string(status.CmoConfigDriftDetected), condition.Reason)
+	assert.Contains(t, condition.Message, "the fields prometheusK8s.alertmanagerConfigs, prometheusK8s.externalLabels.managed_cluster were changed by the field manager \"argocd-controller\"")
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Warning ConfigDrift")
+
+	// Once applied again, the drift is resolved
+	apply(c)
+	d.SetApplied(fields)
+	_, err = d.Check(ctx, hubInfo, testClusterID)
+	require.NoError(t, err)
+	condition = getCmoConfigCondition(t, c)
+	assert.Equal(t, string(status.CmoConfigInSync), condition.Reason)
+	assert.Contains(t, condition.Message, "Last drift: the fields prometheusK8s.alertmanagerConfigs")
+}
+
+func TestCmoConfigDryRun(t *testing.T) {
+	ctx := context.Background()
+	hubInfo := &operatorconfig.HubInfo{HubClusterID: "hub-id", AlertmanagerEndpoint: "https://alertmanager"}
+	c := newDriftTestClient(t, newClusterMonitoringConfigCM(userClusterMonitoringConfig, "kubectl-edit"))
+	recorder := events.NewFakeRecorder(10)
+	r := &ObservabilityAddonReconciler{
+		Client:                 c,
+		CmoConfigDriftDetector: NewCmoConfigDriftDetector(c, status.NewStatus(c, operatorconfig.ObservabilityAddonName, "test-ns", logr.Discard()), recorder, logr.Discard()),
+		CmoConfigDryRun:        true,
+	}
+
+	updated, err := r.reconcileClusterMonitoringConfig(ctx, hubInfo, testClusterID)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: clusterMonitoringConfigName, Namespace: promNamespace}, cm))
+	assert.Equal(t, userClusterMonitoringConfig, cm.Data[ClusterMonitoringConfigDataKey])
+	condition := getCmoConfigCondition(t, c)
+	require.NotNil(t, condition)
+	assert.Equal(t, string(status.CmoConfigDryRun), condition.Reason)
+	assert.Contains(t, condition.Message, "The addon would update the fields prometheusK8s.alertmanagerConfigs, prometheusK8s.externalLabels.managed_cluster.")
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Normal ConfigDryRun")
+
+	// The preview keeps the fields of the users
+	preview, err := r.CmoConfigDriftDetector.preview(ctx, hubInfo, testClusterID)
+	require.NoError(t, err)
+	merged := &cmomanifests.ClusterMonitoringConfiguration{}
+	require.NoError(t, yaml.Unmarshal([]byte(preview.merged), merged))
+	assert.Equal(t, "7d", merged.PrometheusK8sConfig.Retention)
+	assert.Equal(t, cmomanifests.ExternalLabels{"env": "prod", operatorconfig.ClusterLabelKeyForAlerts: testClusterID}, merged.PrometheusK8sConfig.ExternalLabels)
+	assert.Len(t, merged.PrometheusK8sConfig.AlertmanagerConfigs, 1)
+}
+
+func TestCmoConfigOwner(t *testing.T) {
+	now := time.Now()
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{
+					Manager:  EndpointMonitoringOperatorMgr,
+					Time:     &metav1.Time{Time: now.Add(2 * time.Minute)},
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:config.yaml":{}}}`)},
+				},
+				{
+					Manager:  "kubectl-edit",
+					Time:     &metav1.Time{Time: now},
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:config.yaml":{}}}`)},
+				},
+				{
+					Manager:  "gitops",
+					Time:     &metav1.Time{Time: now.Add(time.Minute)},
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:config.yaml":{}}}`)},
+				},
+				{
+					Manager:  "labeler",
+					Time:     &metav1.Time{Time: now.Add(3 * time.Minute)},
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{}}}`)},
+				},
+			},
+		},
+	}
+	assert.Equal(t, "gitops", cmoConfigOwner(cm))
+	assert.Empty(t, cmoConfigOwner(&corev1.ConfigMap{}))
+}
+
+func TestDiffCMOConfigFields(t *testing.T) {
+	applied := map[string]string{"a": "1", "b": "2", "c": ""}
+	live := map[string]string{"a": "1", "b": "3", "d": "4"}
+	assert.Equal(t, []cmoConfigConflict{
+		{Field: "b", Applied: "2", Live: "3"},
+		{Field: "c"},
+		{Field: "d", Live: "4"},
+	}, diffCMOConfigFields(applied, live))
+	assert.Empty(t, diffCMOConfigFields(applied, applied))
+}
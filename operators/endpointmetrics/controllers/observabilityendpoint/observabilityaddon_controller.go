@@ -9,11 +9,14 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"time"
 
 	"github.com/go-logr/logr"
 	prometheusv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/agent"
 	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/collector"
 	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/hypershift"
+	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/microshift"
 	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/openshift"
 	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/rendering"
 	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/util"
@@ -29,6 +32,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -75,6 +79,15 @@ type ObservabilityAddonReconciler struct {
 	ServiceAccountName    string
 	InstallPrometheus     bool
 	CmoReconcilesDetector *openshift.CmoConfigChangesWatcher
+	// MetricsProfile is the metrics profile chosen by the hub for the cluster, see operatorconfig.PrometheusAgentProfile.
+	MetricsProfile string
+	// MetricsBufferRetention is the retention of the metrics buffered by the Prometheus agent while the hub is unreachable.
+	MetricsBufferRetention string
+	// CmoConfigDriftDetector reports the changes made by other actors to the fields of the cluster-monitoring-config
+	// injected by the addon. It is only set on the managed clusters.
+	CmoConfigDriftDetector *CmoConfigDriftDetector
+	// CmoConfigDryRun only reports the cluster-monitoring-config merged with the fields of the addon, without applying it.
+	CmoConfigDryRun bool
 }
 
 // +kubebuilder:rbac:groups=observability.open-cluster-management.io.open-cluster-management.io,resources=observabilityaddons,verbs=get;list;watch;create;update;patch;delete
@@ -85,10 +98,9 @@ type ObservabilityAddonReconciler struct {
 // and what is in the ObservabilityAddon.Spec
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
-func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	r.Logger.Info("Reconciling", "Request", req.String())
 
-	var err error
 	isHypershift, err := hypershift.IsHypershiftCluster()
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to check if the cluster is hypershift: %w", err)
@@ -111,6 +123,27 @@ func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.R
 	}
 	hubInfo.ClusterName = string(hubSecret.Data[operatorconfig.ClusterNameKey])
 
+	// The alerts are not forwarded to the hub during the maintenance windows of the cluster, the forwarding is
+	// removed as when the alerting is disabled on the hub, and restored at the end of the windows.
+	now := time.Now()
+	inMaintenance, nextBoundary := hubInfo.InMaintenance(now)
+	if inMaintenance && hubInfo.AlertmanagerEndpoint != "" {
+		r.Logger.Info("The cluster is under maintenance, the alerts are not forwarded to the hub")
+		hubInfo.AlertmanagerEndpoint = ""
+	}
+	if !nextBoundary.IsZero() {
+		defer func() {
+			if err == nil && result.IsZero() {
+				result.RequeueAfter = nextBoundary.Sub(now)
+			}
+		}()
+	}
+
+	profile, err := r.metricsProfile(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	hubObsAddon := &oav1beta1.ObservabilityAddon{}
 	obsAddon := &oav1beta1.ObservabilityAddon{}
 	deleteFlag := false
@@ -150,7 +183,7 @@ func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.R
 			deleteFlag = true
 		}
 		// Init finalizers
-		deleted, err := r.initFinalization(ctx, deleteFlag, hubObsAddon, isHypershift, hubInfo)
+		deleted, err := r.initFinalization(ctx, deleteFlag, hubObsAddon, isHypershift, hubInfo, profile)
 		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("failed to init finalization: %w", err)
 		}
@@ -174,11 +207,13 @@ func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.R
 	rendering.Images = imagesCM.Data
 
 	if isHypershift {
-		updatedHCs, err := hypershift.ReconcileHostedClustersServiceMonitors(ctx, r.Client)
+		components, err := hypershift.EnabledComponents(r.Client, r.Namespace)
 		if err != nil {
+			r.Logger.Error(err, "Failed to get the enabled hosted control plane components")
+		} else if updatedHCs, err := hypershift.ReconcileHostedClustersServiceMonitors(ctx, r.Client, components); err != nil {
 			r.Logger.Error(err, "Failed to create ServiceMonitors for hypershift")
 		} else {
-			r.Logger.Info("Reconciled hypershift service monitors", "updatedHCs", updatedHCs)
+			r.Logger.Info("Reconciled hypershift service monitors", "updatedHCs", updatedHCs, "components", components)
 		}
 	}
 
@@ -235,6 +270,16 @@ func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.R
 			return ctrl.Result{}, fmt.Errorf("failed to render prometheus templates: %w", err)
 		}
 
+		if usesPrometheusAgent(profile) {
+			var toDelete []*unstructured.Unstructured
+			toDeploy, toDelete = rendering.SplitProfileResources(profile, toDeploy)
+			for _, res := range toDelete {
+				if err := r.Client.Delete(ctx, res); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+					return ctrl.Result{}, fmt.Errorf("failed to delete %s %s/%s: %w", res.GetKind(), res.GetNamespace(), res.GetName(), err)
+				}
+			}
+		}
+
 		deployer := deploying.NewDeployer(r.Client, operatorconfig.EndpointMetricsOperatorManagerName)
 
 		// Ordering resources to ensure they are applied in the correct order
@@ -268,9 +313,13 @@ func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.R
 	}
 
 	// create or update the cluster-monitoring-config configmap and relevant resources
-	cmoWasUpdated, err := createOrUpdateClusterMonitoringConfig(ctx, hubInfo, clusterID, r.Client, r.InstallPrometheus, r.Namespace)
-	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("failed to create or update cluster monitoring config: %w", err)
+	// MicroShift has no cluster monitoring operator and the agent does not forward alerts
+	cmoWasUpdated := false
+	if profile != operatorconfig.MicroShiftProfile {
+		cmoWasUpdated, err = r.reconcileClusterMonitoringConfig(ctx, hubInfo, clusterID)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to create or update cluster monitoring config: %w", err)
+		}
 	}
 
 	if !r.IsHubMetricsCollector {
@@ -317,6 +366,38 @@ func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.R
 		Owner:              resourcesOwner,
 	}
 
+	prometheusAgent := agent.PrometheusAgent{
+		Client:          r.Client,
+		ClusterID:       clusterID,
+		HubInfo:         hubInfo,
+		Log:             r.Logger.WithName("prometheus-agent"),
+		Namespace:       r.Namespace,
+		ObsAddon:        obsAddon,
+		Owner:           resourcesOwner,
+		Profile:         profile,
+		BufferRetention: r.MetricsBufferRetention,
+	}
+	if usesPrometheusAgent(profile) {
+		// The agent remote writes the metrics to the hub in place of the metrics collector
+		if err := metricsCollector.Delete(ctx); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to delete metrics collector: %w", err)
+		}
+		if err := prometheusAgent.Update(ctx); err != nil {
+			wrappedErr := fmt.Errorf("failed to update prometheus agent: %w", err)
+			if apierrors.IsConflict(err) || util.IsTransientClientErr(err) {
+				r.Logger.Info("Retrying due to conflict or transient client error")
+				return ctrl.Result{Requeue: true}, wrappedErr
+			}
+			return ctrl.Result{}, wrappedErr
+		}
+		return ctrl.Result{}, nil
+	}
+	if r.InstallPrometheus {
+		if err := prometheusAgent.Delete(ctx); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to delete prometheus agent: %w", err)
+		}
+	}
+
 	if err := metricsCollector.Update(ctx, req); err != nil {
 		wrappedErr := fmt.Errorf("failed to update metrics collector: %w", err)
 		if apierrors.IsConflict(err) || util.IsTransientClientErr(err) {
@@ -331,7 +412,7 @@ func (r *ObservabilityAddonReconciler) Reconcile(ctx context.Context, req ctrl.R
 
 func (r *ObservabilityAddonReconciler) initFinalization(
 	ctx context.Context, isDelete bool, hubObsAddon *oav1beta1.ObservabilityAddon,
-	isHypershift bool, hubInfo *operatorconfig.HubInfo,
+	isHypershift bool, hubInfo *operatorconfig.HubInfo, profile string,
 ) (bool, error) {
 	if isDelete || hubObsAddon.GetDeletionTimestamp() != nil {
 		if !slices.Contains(hubObsAddon.GetFinalizers(), obsAddonFinalizer) {
@@ -347,29 +428,43 @@ func (r *ObservabilityAddonReconciler) initFinalization(
 		if err := metricsCollector.Delete(ctx); err != nil {
 			return false, fmt.Errorf("failed to delete metrics collector: %w", err)
 		}
-
-		// revert the change to cluster monitoring stack
-		caSecret := AppendHubClusterID(HubAmRouterCASecretName, hubInfo.HubClusterID)
-		err := RevertClusterMonitoringConfig(ctx, r.Client, caSecret, "")
-		if err != nil {
-			return false, err
+		if usesPrometheusAgent(profile) {
+			prometheusAgent := agent.PrometheusAgent{
+				Client:    r.Client,
+				Log:       r.Logger.WithName("prometheus-agent"),
+				Namespace: r.Namespace,
+			}
+			if err := prometheusAgent.Delete(ctx); err != nil {
+				return false, fmt.Errorf("failed to delete prometheus agent: %w", err)
+			}
 		}
 
-		// revert the change to user workload monitoring stack
-		err = RevertUserWorkloadMonitoringConfig(ctx, r.Client, caSecret)
-		if err != nil {
-			return false, err
-		}
+		// MicroShift has no cluster monitoring operator
+		var err error
+		if profile != operatorconfig.MicroShiftProfile {
+			// revert the change to cluster monitoring stack
+			caSecret := AppendHubClusterID(HubAmRouterCASecretName, hubInfo.HubClusterID)
+			err = RevertClusterMonitoringConfig(ctx, r.Client, caSecret, "")
+			if err != nil {
+				return false, err
+			}
 
-		// revert the mTLS CA configurations as well (mirroring the two-pass behavior in cmo-config-revert)
-		mtlsCASecret := AppendHubClusterID(HubAmMtlsCASecretName, hubInfo.HubClusterID)
-		err = RevertClusterMonitoringConfig(ctx, r.Client, mtlsCASecret, "")
-		if err != nil {
-			return false, err
-		}
-		err = RevertUserWorkloadMonitoringConfig(ctx, r.Client, mtlsCASecret)
-		if err != nil {
-			return false, err
+			// revert the change to user workload monitoring stack
+			err = RevertUserWorkloadMonitoringConfig(ctx, r.Client, caSecret)
+			if err != nil {
+				return false, err
+			}
+
+			// revert the mTLS CA configurations as well (mirroring the two-pass behavior in cmo-config-revert)
+			mtlsCASecret := AppendHubClusterID(HubAmMtlsCASecretName, hubInfo.HubClusterID)
+			err = RevertClusterMonitoringConfig(ctx, r.Client, mtlsCASecret, "")
+			if err != nil {
+				return false, err
+			}
+			err = RevertUserWorkloadMonitoringConfig(ctx, r.Client, mtlsCASecret)
+			if err != nil {
+				return false, err
+			}
 		}
 
 		if isHypershift {
@@ -441,6 +536,60 @@ func (r *ObservabilityAddonReconciler) initFinalization(
 	return false, nil
 }
 
+// reconcileClusterMonitoringConfig creates or updates the cluster-monitoring-config configmap, reporting the drift of
+// the fields injected by the addon. In dry-run mode, the merged configuration is reported without being applied.
+// Returns a boolean indicating wether the configmap was effectively updated.
+func (r *ObservabilityAddonReconciler) reconcileClusterMonitoringConfig(ctx context.Context, hubInfo *operatorconfig.HubInfo, clusterID string) (bool, error) {
+	// The fields are only injected when the alerts are forwarded by the OpenShift monitoring
+	injected := !r.InstallPrometheus && hubInfo.AlertmanagerEndpoint != ""
+	if r.CmoConfigDriftDetector == nil || !injected {
+		if r.CmoConfigDriftDetector != nil {
+			r.CmoConfigDriftDetector.Reset()
+		}
+		return createOrUpdateClusterMonitoringConfig(ctx, hubInfo, clusterID, r.Client, r.InstallPrometheus, r.Namespace)
+	}
+
+	if r.CmoConfigDryRun {
+		return false, r.CmoConfigDriftDetector.DryRun(ctx, hubInfo, clusterID)
+	}
+
+	fields, err := r.CmoConfigDriftDetector.Check(ctx, hubInfo, clusterID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check the drift of the cluster monitoring config: %w", err)
+	}
+	updated, err := createOrUpdateClusterMonitoringConfig(ctx, hubInfo, clusterID, r.Client, r.InstallPrometheus, r.Namespace)
+	if err != nil {
+		return updated, err
+	}
+	r.CmoConfigDriftDetector.SetApplied(fields)
+
+	return updated, nil
+}
+
+// metricsProfile returns the metrics profile of the cluster running the bundled Prometheus: the one chosen by the hub,
+// or the MicroShift profile on the MicroShift clusters. It returns an empty string for the default profile.
+func (r *ObservabilityAddonReconciler) metricsProfile(ctx context.Context) (string, error) {
+	if !r.InstallPrometheus || r.IsHubMetricsCollector {
+		return "", nil
+	}
+	if r.MetricsProfile != "" {
+		return r.MetricsProfile, nil
+	}
+	microshiftVersion, err := microshift.IsMicroshiftCluster(ctx, r.Client)
+	if err != nil {
+		return "", fmt.Errorf("failed to check if the cluster is microshift: %w", err)
+	}
+	if microshiftVersion != "" {
+		return operatorconfig.MicroShiftProfile, nil
+	}
+	return "", nil
+}
+
+// usesPrometheusAgent returns true when the metrics are remote written to the hub by a Prometheus agent.
+func usesPrometheusAgent(profile string) bool {
+	return profile == operatorconfig.PrometheusAgentProfile || profile == operatorconfig.MicroShiftProfile
+}
+
 func (r *ObservabilityAddonReconciler) ensureOpenShiftMonitoringLabelAndRole(ctx context.Context) error {
 	existingNs := &corev1.Namespace{}
 	resNS := r.Namespace
@@ -552,15 +701,13 @@ func (r *ObservabilityAddonReconciler) SetupWithManager(mgr ctrl.Manager) error
 	}
 
 	if isHypershift, err := hypershift.IsHypershiftCluster(); err == nil && isHypershift {
-		ctrlBuilder = ctrlBuilder.Watches(
-			&prometheusv1.ServiceMonitor{},
-			&handler.EnqueueRequestForObject{},
-			builder.WithPredicates(getPred(hypershift.EtcdSmName, "", true, false, false)),
-		).Watches(
-			&prometheusv1.ServiceMonitor{},
-			&handler.EnqueueRequestForObject{},
-			builder.WithPredicates(getPred(hypershift.ApiServerSmName, "", true, false, false)),
-		)
+		for _, name := range hypershift.SourceServiceMonitorNames() {
+			ctrlBuilder = ctrlBuilder.Watches(
+				&prometheusv1.ServiceMonitor{},
+				&handler.EnqueueRequestForObject{},
+				builder.WithPredicates(getPred(name, "", true, false, false)),
+			)
+		}
 	}
 
 	return ctrlBuilder.
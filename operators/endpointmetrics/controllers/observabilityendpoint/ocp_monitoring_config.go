@@ -549,21 +549,8 @@ func CreateOrUpdateCMOConfig(
 	accessorSecret string,
 	namespace string,
 ) (bool, error) {
-	newExternalLabels := map[string]string{
-		operatorconfig.ClusterLabelKeyForAlerts: clusterID,
-	}
-	if clusterName != "" {
-		newExternalLabels[operatorconfig.ClusterNameLabelKeyForAlerts] = clusterName
-	}
-	newAlertmanagerConfigs := []cmomanifests.AdditionalAlertmanagerConfig{newAdditionalAlertmanagerConfig(alertmanagerEndpoint, caSecret, certSecret, accessorSecret)}
-	newPmK8sConfig := &cmomanifests.PrometheusK8sConfig{
-		ExternalLabels:      newExternalLabels,
-		AlertmanagerConfigs: newAlertmanagerConfigs,
-	}
-
-	newClusterMonitoringConfiguration := cmomanifests.ClusterMonitoringConfiguration{
-		PrometheusK8sConfig: newPmK8sConfig,
-	}
+	newClusterMonitoringConfiguration := &cmomanifests.ClusterMonitoringConfiguration{}
+	injectClusterMonitoringConfig(newClusterMonitoringConfiguration, clusterID, clusterName, alertmanagerEndpoint, caSecret, certSecret, accessorSecret)
 
 	yamlBytes, err := yaml.Marshal(newClusterMonitoringConfiguration)
 	if err != nil {
@@ -605,38 +592,7 @@ func CreateOrUpdateCMOConfig(
 	if err := yaml.Unmarshal([]byte(currentYAML), updatedCMOCfg); err != nil {
 		return false, fmt.Errorf("failed to unmarshal updated CMO config: %w", err)
 	}
-
-	if updatedCMOCfg.PrometheusK8sConfig != nil {
-		// check and set externalLabels
-		if updatedCMOCfg.PrometheusK8sConfig.ExternalLabels != nil {
-			updatedCMOCfg.PrometheusK8sConfig.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts] = clusterID
-			if clusterName != "" {
-				updatedCMOCfg.PrometheusK8sConfig.ExternalLabels[operatorconfig.ClusterNameLabelKeyForAlerts] = clusterName
-			} else {
-				delete(updatedCMOCfg.PrometheusK8sConfig.ExternalLabels, operatorconfig.ClusterNameLabelKeyForAlerts)
-			}
-		} else {
-			updatedCMOCfg.PrometheusK8sConfig.ExternalLabels = newExternalLabels
-		}
-
-		// Filter out any of our pre-existing managed Alertmanager configurations (including legacy ones).
-		// This guarantees we only ever have exactly one active ACM/MCOA alertmanager configuration,
-		// and handles the upgrade path (Router CA -> mTLS CA) cleanly.
-		cleanAlertmanagerConfigs := make([]cmomanifests.AdditionalAlertmanagerConfig, 0)
-		for _, cfg := range updatedCMOCfg.PrometheusK8sConfig.AlertmanagerConfigs {
-			if !IsManaged(cfg, caSecret) {
-				cleanAlertmanagerConfigs = append(cleanAlertmanagerConfigs, cfg)
-			}
-		}
-		// Append exactly one fresh configured stanza
-		cleanAlertmanagerConfigs = append(
-			cleanAlertmanagerConfigs,
-			newAdditionalAlertmanagerConfig(alertmanagerEndpoint, caSecret, certSecret, accessorSecret),
-		)
-		updatedCMOCfg.PrometheusK8sConfig.AlertmanagerConfigs = cleanAlertmanagerConfigs
-	} else {
-		updatedCMOCfg.PrometheusK8sConfig = newPmK8sConfig
-	}
+	injectClusterMonitoringConfig(updatedCMOCfg, clusterID, clusterName, alertmanagerEndpoint, caSecret, certSecret, accessorSecret)
 
 	updatedYAML, err := yaml.Marshal(updatedCMOCfg)
 	if err != nil {
@@ -651,6 +607,61 @@ func CreateOrUpdateCMOConfig(
 	return true, updateClusterMonitoringConfigAndUnset(ctx, client, found, namespace)
 }
 
+// injectClusterMonitoringConfig injects the external labels and the hub alertmanager configuration of the addon
+// into the cluster monitoring configuration, keeping the fields set by the users.
+func injectClusterMonitoringConfig(
+	cfg *cmomanifests.ClusterMonitoringConfiguration,
+	clusterID string,
+	clusterName string,
+	alertmanagerEndpoint string,
+	caSecret string,
+	certSecret string,
+	accessorSecret string,
+) {
+	newExternalLabels := map[string]string{
+		operatorconfig.ClusterLabelKeyForAlerts: clusterID,
+	}
+	if clusterName != "" {
+		newExternalLabels[operatorconfig.ClusterNameLabelKeyForAlerts] = clusterName
+	}
+
+	if cfg.PrometheusK8sConfig == nil {
+		cfg.PrometheusK8sConfig = &cmomanifests.PrometheusK8sConfig{
+			ExternalLabels:      newExternalLabels,
+			AlertmanagerConfigs: []cmomanifests.AdditionalAlertmanagerConfig{newAdditionalAlertmanagerConfig(alertmanagerEndpoint, caSecret, certSecret, accessorSecret)},
+		}
+		return
+	}
+
+	// check and set externalLabels
+	if cfg.PrometheusK8sConfig.ExternalLabels != nil {
+		cfg.PrometheusK8sConfig.ExternalLabels[operatorconfig.ClusterLabelKeyForAlerts] = clusterID
+		if clusterName != "" {
+			cfg.PrometheusK8sConfig.ExternalLabels[operatorconfig.ClusterNameLabelKeyForAlerts] = clusterName
+		} else {
+			delete(cfg.PrometheusK8sConfig.ExternalLabels, operatorconfig.ClusterNameLabelKeyForAlerts)
+		}
+	} else {
+		cfg.PrometheusK8sConfig.ExternalLabels = newExternalLabels
+	}
+
+	// Filter out any of our pre-existing managed Alertmanager configurations (including legacy ones).
+	// This guarantees we only ever have exactly one active ACM/MCOA alertmanager configuration,
+	// and handles the upgrade path (Router CA -> mTLS CA) cleanly.
+	cleanAlertmanagerConfigs := make([]cmomanifests.AdditionalAlertmanagerConfig, 0)
+	for _, amc := range cfg.PrometheusK8sConfig.AlertmanagerConfigs {
+		if !IsManaged(amc, caSecret) {
+			cleanAlertmanagerConfigs = append(cleanAlertmanagerConfigs, amc)
+		}
+	}
+	// Append exactly one fresh configured stanza
+	cleanAlertmanagerConfigs = append(
+		cleanAlertmanagerConfigs,
+		newAdditionalAlertmanagerConfig(alertmanagerEndpoint, caSecret, certSecret, accessorSecret),
+	)
+	cfg.PrometheusK8sConfig.AlertmanagerConfigs = cleanAlertmanagerConfigs
+}
+
 // CreateOrUpdateUserWorkloadMonitoringConfig creates/updates the user-workload-monitoring-config configmap
 func CreateOrUpdateUserWorkloadMonitoringConfig(
 	ctx context.Context,
@@ -473,22 +473,34 @@ func runStandard(args []string) {
 		}
 	}
 
+	var cmoConfigDryRun bool
+	if envVal := os.Getenv(operatorconfig.CmoConfigDryRun); envVal != "" {
+		cmoConfigDryRun, err = strconv.ParseBool(envVal)
+		if err != nil {
+			setupLog.Error(err, "Failed to parse the value of the environment variable", "variable", operatorconfig.CmoConfigDryRun)
+		}
+	}
+
 	obsAddonCtrlLogger := ctrl.Log.WithName("controllers").WithName("ObservabilityAddon")
 	obsaddonreconciler := &obsepctl.ObservabilityAddonReconciler{
-		Client:                mgr.GetClient(),
-		Scheme:                mgr.GetScheme(),
-		HubClient:             hubClientWithReload,
-		HubNamespace:          os.Getenv("HUB_NAMESPACE"),
-		Namespace:             namespace,
-		ServiceAccountName:    os.Getenv("SERVICE_ACCOUNT"),
-		IsHubMetricsCollector: os.Getenv("HUB_ENDPOINT_OPERATOR") == "true",
-		InstallPrometheus:     installPrometheus,
-		Logger:                obsAddonCtrlLogger,
+		Client:                 mgr.GetClient(),
+		Scheme:                 mgr.GetScheme(),
+		HubClient:              hubClientWithReload,
+		HubNamespace:           os.Getenv("HUB_NAMESPACE"),
+		Namespace:              namespace,
+		ServiceAccountName:     os.Getenv("SERVICE_ACCOUNT"),
+		IsHubMetricsCollector:  os.Getenv("HUB_ENDPOINT_OPERATOR") == "true",
+		InstallPrometheus:      installPrometheus,
+		MetricsProfile:         os.Getenv(operatorconfig.MetricsProfile),
+		MetricsBufferRetention: os.Getenv(operatorconfig.MetricsBufferRetention),
+		CmoConfigDryRun:        cmoConfigDryRun,
+		Logger:                 obsAddonCtrlLogger,
 	}
 	if !obsaddonreconciler.IsHubMetricsCollector {
 		// Only add on spokes as there is no addon on the hub and status update would fail
 		statusReporter := status.NewStatus(mgr.GetClient(), operatorconfig.ObservabilityAddonName, namespace, obsAddonCtrlLogger)
 		obsaddonreconciler.CmoReconcilesDetector = openshift.NewCmoConfigChangesWatcher(mgr.GetClient(), obsAddonCtrlLogger.WithName("cmoWatcher"), statusReporter, 5, 5*time.Minute, 0.6)
+		obsaddonreconciler.CmoConfigDriftDetector = obsepctl.NewCmoConfigDriftDetector(mgr.GetClient(), statusReporter, mgr.GetEventRecorder("endpoint-monitoring-operator"), obsAddonCtrlLogger.WithName("cmoDrift"))
 	}
 	if err = (obsaddonreconciler).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ObservabilityAddon")
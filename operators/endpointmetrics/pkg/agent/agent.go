@@ -0,0 +1,433 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/rendering"
+	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/status"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	agentName               = "prometheus-agent"
+	configSecretName        = "prometheus-agent-config"
+	configKey               = "prometheus.yaml"
+	configMountPath         = "/etc/prometheus/config"
+	scrapeTargetsSecretName = "prometheus-scrape-targets"
+	scrapeTargetsKey        = "scrape-targets.yaml"
+	serviceAccountName      = "prometheus-k8s"
+	endpointDeploymentName  = "endpoint-observability-operator"
+	mtlsCertName            = "observability-controller-open-cluster-management.io-observability-signer-client-cert"
+	mtlsCaName              = "observability-managed-cluster-certs"
+	mtlsCertMountPath       = "/tlscerts/certs"
+	mtlsCAMountPath         = "/tlscerts/ca"
+	configHashAnnotation    = "observability.open-cluster-management.io/config-hash"
+	selectorKey             = "component"
+	defaultInterval         = "30s"
+	storageName             = "prometheus-agent-storage"
+	storageMountPath        = "/prometheus"
+	// bufferStorageSize is the size of the persistent volume buffering the metrics, sized for edge devices.
+	bufferStorageSize = "2Gi"
+)
+
+// microshiftScrapeTargets are the scrape targets of the MicroShift profile: the kubelet, the API server embedded
+// in MicroShift and kube-state-metrics, deployed in the namespace replacing _NAMESPACE_.
+//
+//go:embed microshift-scrape-targets.yaml
+var microshiftScrapeTargets string
+
+// PrometheusAgent deploys the Prometheus in agent mode of the prometheus-agent metrics profile.
+// The agent scrapes the targets of the bundled Prometheus and remote writes the series of the allowlist
+// directly to the hub, replacing the Prometheus federated by the metrics collector.
+type PrometheusAgent struct {
+	Client    client.Client
+	ClusterID string
+	HubInfo   *operatorconfig.HubInfo
+	Log       logr.Logger
+	Namespace string
+	ObsAddon  *oav1beta1.ObservabilityAddon
+	Owner     client.Object
+	// Profile is the metrics profile of the cluster, operatorconfig.PrometheusAgentProfile or
+	// operatorconfig.MicroShiftProfile.
+	Profile string
+	// BufferRetention is the retention of the metrics buffered on a persistent volume while the hub is
+	// unreachable. The metrics are buffered in memory backed storage when it is empty.
+	BufferRetention string
+}
+
+// Update updates the configuration and the deployment of the agent and the addon status when needed.
+func (a *PrometheusAgent) Update(ctx context.Context) error {
+	endpointDeployment := &appsv1.Deployment{}
+	if err := a.Client.Get(ctx, types.NamespacedName{Name: endpointDeploymentName, Namespace: a.Namespace}, endpointDeployment); err != nil {
+		a.reportStatus(ctx, status.MetricsCollector, status.UpdateFailed, "Failed to get the endpoint operator deployment")
+		return fmt.Errorf("failed to get endpoint deployment %s/%s: %w", a.Namespace, endpointDeploymentName, err)
+	}
+	proxyEnv := []corev1.EnvVar{}
+	for _, container := range endpointDeployment.Spec.Template.Spec.Containers {
+		if container.Name != endpointDeploymentName {
+			continue
+		}
+		for _, env := range container.Env {
+			if env.Name == "HTTP_PROXY" || env.Name == "HTTPS_PROXY" || env.Name == "NO_PROXY" {
+				proxyEnv = append(proxyEnv, env)
+			}
+		}
+	}
+
+	config, err := a.generateConfig(ctx, len(proxyEnv) > 0)
+	if err != nil {
+		a.reportStatus(ctx, status.MetricsCollector, status.UpdateFailed, "Failed to generate the Prometheus agent configuration")
+		return err
+	}
+	if err := a.ensureConfigSecret(ctx, config); err != nil {
+		a.reportStatus(ctx, status.MetricsCollector, status.UpdateFailed, "Failed to update the Prometheus agent configuration")
+		return err
+	}
+	if err := a.ensureStorage(ctx); err != nil {
+		a.reportStatus(ctx, status.MetricsCollector, status.UpdateFailed, "Failed to update the Prometheus agent storage")
+		return err
+	}
+
+	wasUpdated, err := a.ensureDeployment(ctx, config, proxyEnv, endpointDeployment.Spec.Template.Spec)
+	if err != nil {
+		a.reportStatus(ctx, status.MetricsCollector, status.UpdateFailed, "Failed to update the Prometheus agent")
+		return err
+	}
+	if !a.ObsAddon.Spec.EnableMetrics {
+		a.reportStatus(ctx, status.MetricsCollector, status.Disabled, "Metrics collector disabled")
+	} else if wasUpdated {
+		a.reportStatus(ctx, status.MetricsCollector, status.UpdateSuccessful, "Prometheus agent updated")
+	}
+	// The user workload metrics are only collected on OpenShift
+	a.reportStatus(ctx, status.UwlMetricsCollector, status.Disabled, "UWL Metrics collector disabled")
+
+	return nil
+}
+
+// Delete deletes the resources of the agent.
+func (a *PrometheusAgent) Delete(ctx context.Context) error {
+	objects := []client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: agentName, Namespace: a.Namespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: configSecretName, Namespace: a.Namespace}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: storageName, Namespace: a.Namespace}},
+	}
+	for _, obj := range objects {
+		if err := a.Client.Delete(ctx, obj); err != nil {
+			if !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+			}
+		} else {
+			a.Log.Info("Deleted object", "name", obj.GetName(), "namespace", obj.GetNamespace())
+		}
+	}
+
+	return nil
+}
+
+func (a *PrometheusAgent) reportStatus(ctx context.Context, component status.Component, conditionReason status.Reason, message string) {
+	statusReporter := status.NewStatus(a.Client, a.ObsAddon.Name, a.Namespace, a.Log)
+	if wasUpdated, err := statusReporter.UpdateComponentCondition(ctx, component, conditionReason, message); err != nil {
+		a.Log.Error(err, "Failed to report status")
+	} else if wasUpdated {
+		a.Log.Info("Status reported", "component", component, "conditionReason", conditionReason, "message", message)
+	}
+}
+
+func (a *PrometheusAgent) generateConfig(ctx context.Context, useProxy bool) ([]byte, error) {
+	scrapeTargets := strings.ReplaceAll(microshiftScrapeTargets, "_NAMESPACE_", a.Namespace)
+	if a.Profile != operatorconfig.MicroShiftProfile {
+		secret := &corev1.Secret{}
+		if err := a.Client.Get(ctx, types.NamespacedName{Name: scrapeTargetsSecretName, Namespace: a.Namespace}, secret); err != nil {
+			return nil, fmt.Errorf("failed to get secret %s/%s: %w", a.Namespace, scrapeTargetsSecretName, err)
+		}
+		scrapeTargets = string(secret.Data[scrapeTargetsKey])
+	}
+
+	allowlist, _, err := util.GetAllowList(a.Client, operatorconfig.AllowlistConfigMapName, a.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the allowlist %s/%s: %w", a.Namespace, operatorconfig.AllowlistConfigMapName, err)
+	}
+	if a.Profile == operatorconfig.MicroShiftProfile {
+		// The allowlist of the hubs not shipping a MicroShift allowlist is kept
+		microshiftAllowlist, err := util.GetMicroShiftAllowList(a.Client, operatorconfig.AllowlistConfigMapName, a.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the microshift allowlist %s/%s: %w", a.Namespace, operatorconfig.AllowlistConfigMapName, err)
+		}
+		if microshiftAllowlist != nil {
+			allowlist = microshiftAllowlist
+		}
+	}
+	customAllowlist, customUwlAllowlist, err := util.GetAllowList(a.Client, operatorconfig.AllowlistCustomConfigMapName, a.Namespace)
+	if err == nil {
+		allowlist, _ = util.MergeAllowlist(allowlist, customAllowlist, &operatorconfig.MetricsAllowlist{}, customUwlAllowlist)
+	} else if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get the custom allowlist %s/%s: %w", a.Namespace, operatorconfig.AllowlistCustomConfigMapName, err)
+	}
+
+	interval := defaultInterval
+	if a.ObsAddon.Spec.Interval != 0 {
+		interval = fmt.Sprintf("%ds", a.ObsAddon.Spec.Interval)
+	}
+	clusterID := a.ClusterID
+	if clusterID == "" {
+		clusterID = a.HubInfo.ClusterName
+	}
+	externalLabels := map[string]string{
+		"cluster":   a.HubInfo.ClusterName,
+		"clusterID": clusterID,
+	}
+
+	return generateConfig(scrapeTargets, allowlist, interval, externalLabels, a.HubInfo.ObservatoriumAPIEndpoint, useProxy)
+}
+
+func (a *PrometheusAgent) ensureConfigSecret(ctx context.Context, config []byte) error {
+	desired := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configSecretName,
+			Namespace: a.Namespace,
+		},
+		Data: map[string][]byte{configKey: config},
+	}
+	if err := controllerutil.SetControllerReference(a.Owner, desired, a.Client.Scheme()); err != nil {
+		return fmt.Errorf("failed to set controller reference: %w", err)
+	}
+
+	found := &corev1.Secret{}
+	err := a.Client.Get(ctx, types.NamespacedName{Name: configSecretName, Namespace: a.Namespace}, found)
+	if errors.IsNotFound(err) {
+		a.Log.Info("Creating Secret", "name", configSecretName, "namespace", a.Namespace)
+		if err := a.Client.Create(ctx, desired); err != nil {
+			return fmt.Errorf("failed to create Secret %s/%s: %w", a.Namespace, configSecretName, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get Secret %s/%s: %w", a.Namespace, configSecretName, err)
+	}
+
+	if equality.Semantic.DeepEqual(desired.Data, found.Data) && metav1.IsControlledBy(found, a.Owner) {
+		return nil
+	}
+	desired.ResourceVersion = found.ResourceVersion
+	if err := a.Client.Update(ctx, desired); err != nil {
+		return fmt.Errorf("failed to update Secret %s/%s: %w", a.Namespace, configSecretName, err)
+	}
+
+	return nil
+}
+
+// ensureStorage creates the persistent volume claim buffering the metrics when the buffering is enabled,
+// and deletes it otherwise.
+func (a *PrometheusAgent) ensureStorage(ctx context.Context) error {
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := a.Client.Get(ctx, types.NamespacedName{Name: storageName, Namespace: a.Namespace}, pvc)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get PersistentVolumeClaim %s/%s: %w", a.Namespace, storageName, err)
+	}
+	found := err == nil
+
+	if a.BufferRetention == "" {
+		if !found {
+			return nil
+		}
+		a.Log.Info("Deleting PersistentVolumeClaim", "name", storageName, "namespace", a.Namespace)
+		if err := a.Client.Delete(ctx, pvc); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete PersistentVolumeClaim %s/%s: %w", a.Namespace, storageName, err)
+		}
+		return nil
+	}
+	if found {
+		// The claim is immutable once bound
+		return nil
+	}
+
+	pvc = &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      storageName,
+			Namespace: a.Namespace,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(bufferStorageSize)},
+			},
+		},
+	}
+	if err := controllerutil.SetControllerReference(a.Owner, pvc, a.Client.Scheme()); err != nil {
+		return fmt.Errorf("failed to set controller reference: %w", err)
+	}
+	a.Log.Info("Creating PersistentVolumeClaim", "name", storageName, "namespace", a.Namespace)
+	if err := a.Client.Create(ctx, pvc); err != nil {
+		return fmt.Errorf("failed to create PersistentVolumeClaim %s/%s: %w", a.Namespace, storageName, err)
+	}
+
+	return nil
+}
+
+func (a *PrometheusAgent) ensureDeployment(ctx context.Context, config []byte, proxyEnv []corev1.EnvVar,
+	endpointSpec corev1.PodSpec,
+) (bool, error) {
+	replicas := int32(0)
+	if a.ObsAddon.Spec.EnableMetrics {
+		replicas = 1
+	}
+	args := []string{
+		"--agent",
+		"--config.file=" + configMountPath + "/" + configKey,
+		"--storage.agent.path=" + storageMountPath,
+		"--web.listen-address=127.0.0.1:9090",
+	}
+	storage := corev1.Volume{Name: "storage", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}
+	strategy := appsv1.DeploymentStrategy{}
+	if a.BufferRetention != "" {
+		// The samples not yet sent to the hub are kept in the write-ahead log of the persistent volume,
+		// up to the retention, so that they survive the restarts of the edge devices
+		args = append(args, "--storage.agent.retention.max-time="+a.BufferRetention)
+		storage.VolumeSource = corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: storageName},
+		}
+		strategy.Type = appsv1.RecreateDeploymentStrategyType
+	}
+	trueVal := true
+	userNumber := int64(65534)
+	desired := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      agentName,
+			Namespace: a.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Strategy: strategy,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{selectorKey: agentName},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						configHashAnnotation: fmt.Sprintf("%x", sha256.Sum256(config)),
+					},
+					Labels: map[string]string{selectorKey: agentName},
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: serviceAccountName,
+					Containers: []corev1.Container{
+						{
+							Name:  agentName,
+							Image: rendering.Images[operatorconfig.PrometheusKey],
+							Args:  args,
+							Env:   proxyEnv,
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "config", MountPath: configMountPath, ReadOnly: true},
+								{Name: "mtlscerts", MountPath: mtlsCertMountPath, ReadOnly: true},
+								{Name: "mtlsca", MountPath: mtlsCAMountPath, ReadOnly: true},
+								{Name: "storage", MountPath: storageMountPath},
+							},
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							SecurityContext: &corev1.SecurityContext{
+								RunAsUser:                &userNumber,
+								RunAsGroup:               &userNumber,
+								RunAsNonRoot:             &trueVal,
+								ReadOnlyRootFilesystem:   &trueVal,
+								AllowPrivilegeEscalation: new(bool),
+								Capabilities: &corev1.Capabilities{
+									Drop: []corev1.Capability{"ALL"},
+								},
+							},
+						},
+					},
+					ImagePullSecrets: []corev1.LocalObjectReference{
+						{Name: os.Getenv(operatorconfig.PullSecret)},
+					},
+					Volumes: []corev1.Volume{
+						secretVolume("config", configSecretName),
+						secretVolume("mtlscerts", mtlsCertName),
+						secretVolume("mtlsca", mtlsCaName),
+						storage,
+					},
+					NodeSelector: endpointSpec.NodeSelector,
+					Tolerations:  endpointSpec.Tolerations,
+				},
+			},
+		},
+	}
+	if a.ObsAddon.Spec.Resources != nil {
+		desired.Spec.Template.Spec.Containers[0].Resources = *a.ObsAddon.Spec.Resources
+	}
+	if a.Profile == operatorconfig.MicroShiftProfile {
+		// The user and the fsGroup are set by the restricted SCC of MicroShift
+		desired.Spec.Template.Spec.Containers[0].SecurityContext.RunAsUser = nil
+		desired.Spec.Template.Spec.Containers[0].SecurityContext.RunAsGroup = nil
+	} else {
+		desired.Spec.Template.Spec.SecurityContext = &corev1.PodSecurityContext{FSGroup: &userNumber}
+	}
+
+	if err := controllerutil.SetControllerReference(a.Owner, desired, a.Client.Scheme()); err != nil {
+		return false, fmt.Errorf("failed to set controller reference: %w", err)
+	}
+
+	wasUpdated := false
+	retryErr := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		found := &appsv1.Deployment{}
+		err := a.Client.Get(ctx, types.NamespacedName{Name: agentName, Namespace: a.Namespace}, found)
+		if errors.IsNotFound(err) {
+			a.Log.Info("Creating Deployment", "name", agentName, "namespace", a.Namespace)
+			if err := a.Client.Create(ctx, desired); err != nil {
+				return fmt.Errorf("failed to create Deployment %s/%s: %w", a.Namespace, agentName, err)
+			}
+			wasUpdated = true
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get Deployment %s/%s: %w", a.Namespace, agentName, err)
+		}
+
+		isDifferentSpec := !equality.Semantic.DeepDerivative(desired.Spec.Template, found.Spec.Template)
+		isDifferentReplicas := !equality.Semantic.DeepEqual(desired.Spec.Replicas, found.Spec.Replicas)
+		isDifferentOwner := !metav1.IsControlledBy(found, a.Owner)
+		if !isDifferentSpec && !isDifferentReplicas && !isDifferentOwner {
+			return nil
+		}
+
+		a.Log.Info("Updating Deployment", "name", agentName, "namespace", a.Namespace,
+			"isDifferentSpec", isDifferentSpec, "isDifferentReplicas", isDifferentReplicas, "isDifferentOwner", isDifferentOwner)
+		desired.ResourceVersion = found.ResourceVersion
+		if err := a.Client.Update(ctx, desired); err != nil {
+			return fmt.Errorf("failed to update Deployment %s/%s: %w", a.Namespace, agentName, err)
+		}
+		wasUpdated = true
+		return nil
+	})
+
+	return wasUpdated, retryErr
+}
+
+func secretVolume(name, secretName string) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: secretName},
+		},
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	oashared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	oav1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testNamespace = "open-cluster-management-addon-observability"
+
+func TestPrometheusAgent(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, oav1beta1.AddToScheme(s))
+
+	obsAddon := &oav1beta1.ObservabilityAddon{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability-addon", Namespace: testNamespace},
+		Spec:       oashared.ObservabilityAddonSpec{EnableMetrics: true, Interval: 60},
+	}
+	endpointDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: endpointDeploymentName, Namespace: testNamespace},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: endpointDeploymentName,
+							Env:  []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "https://proxy"}},
+						},
+					},
+					NodeSelector: map[string]string{"kubernetes.io/os": "linux"},
+				},
+			},
+		},
+	}
+	scrapeTargets := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: scrapeTargetsSecretName, Namespace: testNamespace},
+		Data: map[string][]byte{
+			scrapeTargetsKey: []byte("- job_name: node-exporter\n  static_configs:\n  - targets:\n    - node-exporter:9100\n"),
+		},
+	}
+	allowlist := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: operatorconfig.AllowlistConfigMapName, Namespace: testNamespace},
+		Data:       map[string]string{operatorconfig.MetricsConfigMapKey: "names:\n- up\n"},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(obsAddon, endpointDeployment, scrapeTargets, allowlist).
+		WithStatusSubresource(obsAddon).Build()
+
+	a := &PrometheusAgent{
+		Client:    c,
+		HubInfo:   &operatorconfig.HubInfo{ClusterName: "cluster1", ObservatoriumAPIEndpoint: "https://observatorium-api"},
+		Log:       logr.Discard(),
+		Namespace: testNamespace,
+		ObsAddon:  obsAddon,
+		Owner:     obsAddon,
+	}
+	ctx := context.Background()
+	require.NoError(t, a.Update(ctx))
+
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: configSecretName, Namespace: testNamespace}, secret))
+	assert.Contains(t, string(secret.Data[configKey]), "clusterID: cluster1")
+	assert.Contains(t, string(secret.Data[configKey]), "proxy_from_environment: true")
+	assert.True(t, metav1.IsControlledBy(secret, obsAddon))
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: agentName, Namespace: testNamespace}, deployment))
+	assert.Equal(t, int32(1), *deployment.Spec.Replicas)
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].Args, "--agent")
+	assert.Equal(t, endpointDeployment.Spec.Template.Spec.Containers[0].Env, deployment.Spec.Template.Spec.Containers[0].Env)
+	assert.Equal(t, map[string]string{"kubernetes.io/os": "linux"}, deployment.Spec.Template.Spec.NodeSelector)
+	hash := deployment.Spec.Template.Annotations[configHashAnnotation]
+
+	// A change of the allowlist rolls out the agent
+	allowlist.Data[operatorconfig.MetricsConfigMapKey] = "names:\n- up\n- kube_pod_info\n"
+	require.NoError(t, c.Update(ctx, allowlist))
+	require.NoError(t, a.Update(ctx))
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: agentName, Namespace: testNamespace}, deployment))
+	assert.NotEqual(t, hash, deployment.Spec.Template.Annotations[configHashAnnotation])
+
+	// Disabling the metrics scales the agent down
+	obsAddon.Spec.EnableMetrics = false
+	require.NoError(t, a.Update(ctx))
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: agentName, Namespace: testNamespace}, deployment))
+	assert.Equal(t, int32(0), *deployment.Spec.Replicas)
+
+	require.NoError(t, a.Delete(ctx))
+	assert.True(t, errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: agentName, Namespace: testNamespace}, deployment)))
+	assert.True(t, errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: configSecretName, Namespace: testNamespace}, secret)))
+	// Deleting twice is a no-op
+	require.NoError(t, a.Delete(ctx))
+}
+
+func TestPrometheusAgentMicroShift(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, oav1beta1.AddToScheme(s))
+
+	obsAddon := &oav1beta1.ObservabilityAddon{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability-addon", Namespace: testNamespace},
+		Spec:       oashared.ObservabilityAddonSpec{EnableMetrics: true},
+	}
+	endpointDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: endpointDeploymentName, Namespace: testNamespace},
+	}
+	allowlist := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: operatorconfig.AllowlistConfigMapName, Namespace: testNamespace},
+		Data: map[string]string{
+			operatorconfig.MetricsConfigMapKey:           "names:\n- default_metric\n",
+			operatorconfig.MicroShiftMetricsConfigMapKey: "names:\n- microshift_metric\n",
+		},
+	}
+	// The scrape targets secret of the bundled Prometheus is not used
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(obsAddon, endpointDeployment, allowlist).
+		WithStatusSubresource(obsAddon).Build()
+
+	a := &PrometheusAgent{
+		Client:          c,
+		HubInfo:         &operatorconfig.HubInfo{ClusterName: "edge1", ObservatoriumAPIEndpoint: "https://observatorium-api"},
+		Log:             logr.Discard(),
+		Namespace:       testNamespace,
+		ObsAddon:        obsAddon,
+		Owner:           obsAddon,
+		Profile:         operatorconfig.MicroShiftProfile,
+		BufferRetention: "24h",
+	}
+	ctx := context.Background()
+	require.NoError(t, a.Update(ctx))
+
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: configSecretName, Namespace: testNamespace}, secret))
+	config := string(secret.Data[configKey])
+	assert.Contains(t, config, "job_name: kubelet-cadvisor")
+	assert.Contains(t, config, "- "+testNamespace)
+	assert.NotContains(t, config, "_NAMESPACE_")
+	assert.Contains(t, config, "microshift_metric")
+	assert.NotContains(t, config, "default_metric")
+
+	// The metrics are buffered on a persistent volume
+	pvc := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: storageName, Namespace: testNamespace}, pvc))
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: agentName, Namespace: testNamespace}, deployment))
+	podSpec := deployment.Spec.Template.Spec
+	assert.Contains(t, podSpec.Containers[0].Args, "--storage.agent.retention.max-time=24h")
+	assert.Equal(t, storageName, podSpec.Volumes[3].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, appsv1.RecreateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+	// The user is set by the restricted SCC
+	assert.Nil(t, podSpec.Containers[0].SecurityContext.RunAsUser)
+
+	// Disabling the buffering removes the persistent volume
+	a.BufferRetention = ""
+	require.NoError(t, a.Update(ctx))
+	assert.True(t, errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: storageName, Namespace: testNamespace}, pvc)))
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: agentName, Namespace: testNamespace}, deployment))
+	assert.NotNil(t, deployment.Spec.Template.Spec.Volumes[3].EmptyDir)
+	assert.NotContains(t, deployment.Spec.Template.Spec.Containers[0].Args, "--storage.agent.retention.max-time=24h")
+}
@@ -0,0 +1,227 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// keepLabel is set on the series of the allowlist, the others are dropped.
+	keepLabel = "__tmp_acm_keep"
+	// matchLabel is set on the series matching the matchers of one match of the allowlist.
+	matchLabel = "__tmp_acm_match"
+)
+
+type prometheusConfig struct {
+	Global        globalConfig        `yaml:"global"`
+	ScrapeConfigs []yaml.MapSlice     `yaml:"scrape_configs"`
+	RemoteWrite   []remoteWriteConfig `yaml:"remote_write"`
+}
+
+type globalConfig struct {
+	ScrapeInterval string            `yaml:"scrape_interval"`
+	ExternalLabels map[string]string `yaml:"external_labels"`
+}
+
+type remoteWriteConfig struct {
+	URL                  string    `yaml:"url"`
+	TLSConfig            tlsConfig `yaml:"tls_config"`
+	ProxyFromEnvironment bool      `yaml:"proxy_from_environment,omitempty"`
+}
+
+type tlsConfig struct {
+	CAFile   string `yaml:"ca_file"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// relabelConfig is a relabel config of Prometheus. The regex is always set, the empty regex only matching
+// the empty value unlike the default one.
+type relabelConfig struct {
+	SourceLabels []string `yaml:"source_labels,omitempty"`
+	Separator    string   `yaml:"separator,omitempty"`
+	Regex        string   `yaml:"regex"`
+	TargetLabel  string   `yaml:"target_label,omitempty"`
+	Replacement  *string  `yaml:"replacement,omitempty"`
+	Action       string   `yaml:"action"`
+}
+
+// generateConfig returns the configuration of the Prometheus agent. The scrape targets are the ones of the
+// prometheus-scrape-targets secret, or the embedded ones in the MicroShift profile, their samples being filtered by the allowlist before being remote written
+// to the hub with the mTLS certificates of the managed cluster.
+func generateConfig(scrapeTargets string, allowlist *operatorconfig.MetricsAllowlist, interval string,
+	externalLabels map[string]string, remoteWriteURL string, useProxy bool,
+) ([]byte, error) {
+	scrapeConfigs := []yaml.MapSlice{}
+	if err := yaml.Unmarshal([]byte(scrapeTargets), &scrapeConfigs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the scrape targets: %w", err)
+	}
+
+	relabelConfigs, err := allowlistRelabelConfigs(allowlist)
+	if err != nil {
+		return nil, err
+	}
+	for i := range scrapeConfigs {
+		scrapeConfigs[i] = appendMetricRelabelConfigs(scrapeConfigs[i], relabelConfigs)
+	}
+
+	return yaml.Marshal(prometheusConfig{
+		Global: globalConfig{
+			ScrapeInterval: interval,
+			ExternalLabels: externalLabels,
+		},
+		ScrapeConfigs: scrapeConfigs,
+		RemoteWrite: []remoteWriteConfig{
+			{
+				URL: remoteWriteURL,
+				TLSConfig: tlsConfig{
+					CAFile:   mtlsCAMountPath + "/ca.crt",
+					CertFile: mtlsCertMountPath + "/tls.crt",
+					KeyFile:  mtlsCertMountPath + "/tls.key",
+				},
+				ProxyFromEnvironment: useProxy,
+			},
+		},
+	})
+}
+
+// appendMetricRelabelConfigs appends the relabel configs to the metric_relabel_configs of the scrape config,
+// after the ones already dropping the disabled metrics.
+func appendMetricRelabelConfigs(scrapeConfig yaml.MapSlice, relabelConfigs []relabelConfig) yaml.MapSlice {
+	for i, item := range scrapeConfig {
+		if item.Key != "metric_relabel_configs" {
+			continue
+		}
+		existing, _ := item.Value.([]any)
+		for _, cfg := range relabelConfigs {
+			existing = append(existing, cfg)
+		}
+		scrapeConfig[i].Value = existing
+		return scrapeConfig
+	}
+
+	return append(scrapeConfig, yaml.MapItem{Key: "metric_relabel_configs", Value: relabelConfigs})
+}
+
+// allowlistRelabelConfigs translates the names, the matches and the renames of the allowlist into relabel configs
+// keeping the series of the allowlist. A match is translated into the relabel configs setting the matchLabel when
+// its equality and regexp matchers match, and removing it when one of its negative matchers matches.
+// The recording rules and the collect rules of the allowlist are not supported, the agent not evaluating rules.
+func allowlistRelabelConfigs(allowlist *operatorconfig.MetricsAllowlist) ([]relabelConfig, error) {
+	trueVal, emptyVal := "true", ""
+	ret := []relabelConfig{}
+
+	if len(allowlist.NameList) > 0 {
+		names := make([]string, 0, len(allowlist.NameList))
+		for _, name := range allowlist.NameList {
+			names = append(names, regexp.QuoteMeta(name))
+		}
+		ret = append(ret, relabelConfig{
+			SourceLabels: []string{"__name__"},
+			Regex:        "(" + strings.Join(names, "|") + ")",
+			TargetLabel:  keepLabel,
+			Replacement:  &trueVal,
+			Action:       "replace",
+		})
+	}
+
+	for _, match := range allowlist.MatchList {
+		matchers, err := parser.ParseMetricSelector("{" + match + "}")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the match %q of the allowlist: %w", match, err)
+		}
+
+		sourceLabels, regexes := []string{}, []string{}
+		negatives := []relabelConfig{}
+		for _, matcher := range matchers {
+			switch matcher.Type {
+			case labels.MatchEqual:
+				sourceLabels = append(sourceLabels, matcher.Name)
+				regexes = append(regexes, regexp.QuoteMeta(matcher.Value))
+			case labels.MatchRegexp:
+				sourceLabels = append(sourceLabels, matcher.Name)
+				regexes = append(regexes, "(?:"+matcher.Value+")")
+			case labels.MatchNotEqual, labels.MatchNotRegexp:
+				value := matcher.Value
+				if matcher.Type == labels.MatchNotEqual {
+					value = regexp.QuoteMeta(value)
+				}
+				negatives = append(negatives, relabelConfig{
+					SourceLabels: []string{matcher.Name},
+					Regex:        value,
+					TargetLabel:  matchLabel,
+					Replacement:  &emptyVal,
+					Action:       "replace",
+				})
+			}
+		}
+
+		// A replace without source labels sets the label on all the series
+		ret = append(ret, relabelConfig{
+			SourceLabels: sourceLabels,
+			Separator:    ";",
+			Regex:        strings.Join(regexes, ";"),
+			TargetLabel:  matchLabel,
+			Replacement:  &trueVal,
+			Action:       "replace",
+		})
+		ret = append(ret, negatives...)
+		ret = append(ret,
+			relabelConfig{
+				SourceLabels: []string{matchLabel},
+				Regex:        "true",
+				TargetLabel:  keepLabel,
+				Replacement:  &trueVal,
+				Action:       "replace",
+			},
+			relabelConfig{
+				Regex:  matchLabel,
+				Action: "labeldrop",
+			},
+		)
+	}
+
+	ret = append(ret,
+		relabelConfig{
+			SourceLabels: []string{keepLabel},
+			Regex:        "true",
+			Action:       "keep",
+		},
+		relabelConfig{
+			Regex:  keepLabel,
+			Action: "labeldrop",
+		},
+	)
+
+	renames := make([]string, 0, len(allowlist.RenameMap))
+	for name := range allowlist.RenameMap {
+		renames = append(renames, name)
+	}
+	slices.Sort(renames)
+	for _, name := range renames {
+		ret = append(ret, relabelConfig{
+			SourceLabels: []string{"__name__"},
+			Regex:        regexp.QuoteMeta(name),
+			TargetLabel:  "__name__",
+			Replacement:  stringPtr(allowlist.RenameMap[name]),
+			Action:       "replace",
+		})
+	}
+
+	return ret, nil
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
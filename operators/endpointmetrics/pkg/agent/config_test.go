@@ -0,0 +1,138 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package agent
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestAllowlistRelabelConfigs(t *testing.T) {
+	allowlist := &operatorconfig.MetricsAllowlist{
+		NameList: []string{"up", "kube_pod_info"},
+		MatchList: []string{
+			`__name__="container_memory_cache",container!=""`,
+			`__name__=~"apiserver_request_.*",verb!~"WATCH|CONNECT"`,
+		},
+		RenameMap: map[string]string{"kube_pod_info": "pod_info"},
+	}
+	relabelConfigs, err := allowlistRelabelConfigs(allowlist)
+	require.NoError(t, err)
+
+	// The relabel configs are parsed the way Prometheus parses them
+	out, err := yaml.Marshal(relabelConfigs)
+	require.NoError(t, err)
+	cfgs := []*relabel.Config{}
+	require.NoError(t, yaml.Unmarshal(out, &cfgs))
+
+	testCases := map[string]struct {
+		series   labels.Labels
+		expected labels.Labels
+		keep     bool
+	}{
+		"name of the allowlist": {
+			series:   labels.FromStrings("__name__", "up", "job", "node-exporter"),
+			expected: labels.FromStrings("__name__", "up", "job", "node-exporter"),
+			keep:     true,
+		},
+		"renamed metric": {
+			series:   labels.FromStrings("__name__", "kube_pod_info", "pod", "foo"),
+			expected: labels.FromStrings("__name__", "pod_info", "pod", "foo"),
+			keep:     true,
+		},
+		"metric not in the allowlist": {
+			series: labels.FromStrings("__name__", "go_goroutines"),
+		},
+		"match with a negative equality matcher": {
+			series:   labels.FromStrings("__name__", "container_memory_cache", "container", "foo"),
+			expected: labels.FromStrings("__name__", "container_memory_cache", "container", "foo"),
+			keep:     true,
+		},
+		"match excluded by a negative equality matcher": {
+			series: labels.FromStrings("__name__", "container_memory_cache"),
+		},
+		"match with a regexp matcher": {
+			series:   labels.FromStrings("__name__", "apiserver_request_total", "verb", "GET"),
+			expected: labels.FromStrings("__name__", "apiserver_request_total", "verb", "GET"),
+			keep:     true,
+		},
+		"match excluded by a negative regexp matcher": {
+			series: labels.FromStrings("__name__", "apiserver_request_total", "verb", "WATCH"),
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, keep := relabel.Process(tc.series, cfgs...)
+			assert.Equal(t, tc.keep, keep)
+			if tc.keep {
+				assert.Equal(t, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestAllowlistRelabelConfigsInvalidMatch(t *testing.T) {
+	_, err := allowlistRelabelConfigs(&operatorconfig.MetricsAllowlist{MatchList: []string{`__name__=~"(`}})
+	assert.Error(t, err)
+}
+
+func TestGenerateConfig(t *testing.T) {
+	scrapeTargets := `
+- job_name: kube-state-metrics
+  static_configs:
+  - targets:
+    - kube-state-metrics:8080
+  metric_relabel_configs:
+  - source_labels: [__name__]
+    regex: kube_secret_info
+    action: drop
+- job_name: node-exporter
+  static_configs:
+  - targets:
+    - node-exporter:9100
+`
+	allowlist := &operatorconfig.MetricsAllowlist{NameList: []string{"up"}}
+	out, err := generateConfig(scrapeTargets, allowlist, "60s", map[string]string{"cluster": "c1", "clusterID": "id1"},
+		"https://observatorium-api/api/metrics/v1/default/api/v1/receive", true)
+	require.NoError(t, err)
+
+	config := struct {
+		Global struct {
+			ScrapeInterval string            `yaml:"scrape_interval"`
+			ExternalLabels map[string]string `yaml:"external_labels"`
+		} `yaml:"global"`
+		ScrapeConfigs []struct {
+			JobName              string            `yaml:"job_name"`
+			MetricRelabelConfigs []*relabel.Config `yaml:"metric_relabel_configs"`
+		} `yaml:"scrape_configs"`
+		RemoteWrite []remoteWriteConfig `yaml:"remote_write"`
+	}{}
+	require.NoError(t, yaml.Unmarshal(out, &config))
+
+	assert.Equal(t, "60s", config.Global.ScrapeInterval)
+	assert.Equal(t, map[string]string{"cluster": "c1", "clusterID": "id1"}, config.Global.ExternalLabels)
+	require.Len(t, config.ScrapeConfigs, 2)
+	// The metrics disabled by the scrape targets are still dropped before the allowlist is applied
+	assert.Equal(t, relabel.Drop, config.ScrapeConfigs[0].MetricRelabelConfigs[0].Action)
+	assert.Len(t, config.ScrapeConfigs[0].MetricRelabelConfigs, 4)
+	assert.Len(t, config.ScrapeConfigs[1].MetricRelabelConfigs, 3)
+	assert.Equal(t, []remoteWriteConfig{
+		{
+			URL: "https://observatorium-api/api/metrics/v1/default/api/v1/receive",
+			TLSConfig: tlsConfig{
+				CAFile:   "/tlscerts/ca/ca.crt",
+				CertFile: "/tlscerts/certs/tls.crt",
+				KeyFile:  "/tlscerts/certs/tls.key",
+			},
+			ProxyFromEnvironment: true,
+		},
+	}, config.RemoteWrite)
+}
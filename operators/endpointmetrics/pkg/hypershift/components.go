@@ -0,0 +1,196 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package hypershift
+
+import (
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	operatorutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// component is a hosted control plane component scraped for ACM.
+// The ACM ServiceMonitor of the component replicates the endpoint of the ServiceMonitor created by hypershift
+// in the hosted control plane namespace, keeps the metrics of the component and sets the job label.
+type component struct {
+	// name is the name of the component in the hosted_control_plane_components list of the allowlist.
+	name string
+	// serviceMonitor is the name of the ServiceMonitor created by hypershift for the component.
+	serviceMonitor string
+	// job is the value of the job label of the metrics of the component.
+	job     string
+	metrics []string
+}
+
+// acmServiceMonitor returns the name of the ACM ServiceMonitor of the component.
+func (comp component) acmServiceMonitor() string {
+	return "acm-" + comp.serviceMonitor
+}
+
+var components = []component{
+	{
+		name:           "etcd",
+		serviceMonitor: EtcdSmName,
+		job:            "etcd",
+		metrics: []string{
+			"etcd_disk_backend_commit_duration_seconds_bucket",
+			"etcd_disk_wal_fsync_duration_seconds_bucket",
+			"etcd_mvcc_db_total_size_in_bytes",
+			"etcd_mvcc_db_total_size_in_use_in_bytes",
+			"etcd_network_client_grpc_received_bytes_total",
+			"etcd_network_client_grpc_sent_bytes_total",
+			"etcd_network_peer_received_bytes_total",
+			"etcd_network_peer_round_trip_time_seconds_bucket",
+			"etcd_network_peer_sent_bytes_total",
+			"etcd_server_has_leader",
+			"etcd_server_leader_changes_seen_total",
+			"etcd_server_proposals_applied_total",
+			"etcd_server_proposals_committed_total",
+			"etcd_server_proposals_failed_total",
+			"etcd_server_proposals_pending",
+			"grpc_server_handled_total",
+			"grpc_server_started_total",
+			"process_resident_memory_bytes",
+		},
+	},
+	{
+		name:           "kube-apiserver",
+		serviceMonitor: ApiServerSmName,
+		job:            "apiserver",
+		metrics: []string{
+			"apiserver_current_inflight_requests",
+			"apiserver_request_count",
+			"apiserver_request_duration_seconds_bucket",
+			"apiserver_request_total",
+			"apiserver_storage_objects",
+			"go_goroutines",
+			"process_cpu_seconds_total",
+			"process_resident_memory_bytes",
+			"up",
+			"workqueue_adds_total",
+			"workqueue_depth",
+			"workqueue_queue_duration_seconds_bucket",
+		},
+	},
+	{
+		name:           "kube-controller-manager",
+		serviceMonitor: "kube-controller-manager",
+		job:            "kube-controller-manager",
+		metrics: []string{
+			"leader_election_master_status",
+			"process_cpu_seconds_total",
+			"process_resident_memory_bytes",
+			"rest_client_requests_total",
+			"up",
+			"workqueue_adds_total",
+			"workqueue_depth",
+			"workqueue_queue_duration_seconds_bucket",
+		},
+	},
+	{
+		name:           "kube-scheduler",
+		serviceMonitor: "kube-scheduler",
+		job:            "scheduler",
+		metrics: []string{
+			"leader_election_master_status",
+			"process_cpu_seconds_total",
+			"process_resident_memory_bytes",
+			"scheduler_pending_pods",
+			"scheduler_schedule_attempts_total",
+			"scheduler_scheduling_attempt_duration_seconds_bucket",
+			"up",
+		},
+	},
+	{
+		name:           "openshift-apiserver",
+		serviceMonitor: "openshift-apiserver",
+		job:            "openshift-apiserver",
+		metrics: []string{
+			"apiserver_current_inflight_requests",
+			"apiserver_request_duration_seconds_bucket",
+			"apiserver_request_total",
+			"process_cpu_seconds_total",
+			"process_resident_memory_bytes",
+			"up",
+		},
+	},
+	{
+		name:           "oauth-apiserver",
+		serviceMonitor: "openshift-oauth-apiserver",
+		job:            "oauth-apiserver",
+		metrics: []string{
+			"apiserver_current_inflight_requests",
+			"apiserver_request_duration_seconds_bucket",
+			"apiserver_request_total",
+			"process_cpu_seconds_total",
+			"process_resident_memory_bytes",
+			"up",
+		},
+	},
+	{
+		name:           "ignition-server",
+		serviceMonitor: "ignition-server",
+		job:            "ignition-server",
+		metrics: []string{
+			"process_cpu_seconds_total",
+			"process_resident_memory_bytes",
+			"up",
+		},
+	},
+	{
+		name:           "konnectivity-server",
+		serviceMonitor: "konnectivity-server",
+		job:            "konnectivity-server",
+		metrics: []string{
+			"konnectivity_network_proxy_server_dial_failure_count",
+			"konnectivity_network_proxy_server_established_connections",
+			"konnectivity_network_proxy_server_pending_backend_dials",
+			"konnectivity_network_proxy_server_ready_backend_connections",
+			"process_cpu_seconds_total",
+			"process_resident_memory_bytes",
+			"up",
+		},
+	},
+}
+
+// defaultComponents are the components scraped when the allowlist does not list the components.
+var defaultComponents = []string{"etcd", "kube-apiserver"}
+
+// SourceServiceMonitorNames returns the names of the ServiceMonitors created by hypershift
+// for the hosted control plane components of the catalog.
+func SourceServiceMonitorNames() []string {
+	names := make([]string, 0, len(components))
+	for _, comp := range components {
+		names = append(names, comp.serviceMonitor)
+	}
+	return names
+}
+
+// EnabledComponents returns the hosted control plane components listed in the uwl allowlist of the namespace,
+// merged with the ones of the custom allowlist. A component prefixed with "-" in the custom allowlist is disabled.
+func EnabledComponents(c client.Client, namespace string) ([]string, error) {
+	_, uwlAllowlist, err := operatorutil.GetAllowList(c, operatorconfig.AllowlistConfigMapName, namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return defaultComponents, nil
+		}
+		return nil, err
+	}
+	if uwlAllowlist.HostedControlPlaneComponentList == nil {
+		uwlAllowlist.HostedControlPlaneComponentList = defaultComponents
+	}
+
+	_, customUwlAllowlist, err := operatorutil.GetAllowList(c, operatorconfig.AllowlistCustomConfigMapName, namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return uwlAllowlist.HostedControlPlaneComponentList, nil
+		}
+		return nil, err
+	}
+	_, uwlAllowlist = operatorutil.MergeAllowlist(&operatorconfig.MetricsAllowlist{}, &operatorconfig.MetricsAllowlist{},
+		uwlAllowlist, customUwlAllowlist)
+
+	return uwlAllowlist.HostedControlPlaneComponentList, nil
+}
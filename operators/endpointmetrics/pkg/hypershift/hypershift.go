@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
 
 	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
@@ -30,10 +31,10 @@ const (
 
 // ReconcileHostedClustersServiceMonitors reconciles ServiceMonitors for hypershift hosted clusters
 // It returns the number of hosted clusters reconciled and an error if any
-// For each hosted cluster, it adds a ServiceMonitor for etcd and kube-apiserver
+// For each hosted cluster, it adds a ServiceMonitor for each enabled component of the catalog
 // so that metrics are scraped by the user workload cluster's prometheus
-// with relevant labels added for ACM.
-func ReconcileHostedClustersServiceMonitors(ctx context.Context, c client.Client) (int, error) {
+// with relevant labels added for ACM. The ServiceMonitors of the disabled components are deleted.
+func ReconcileHostedClustersServiceMonitors(ctx context.Context, c client.Client, enabledComponents []string) (int, error) {
 	hostedClusters := &hyperv1.HostedClusterList{}
 	if err := c.List(ctx, hostedClusters, &client.ListOptions{}); err != nil {
 		return 0, fmt.Errorf("failed to list HostedClusterList: %w", err)
@@ -42,28 +43,27 @@ func ReconcileHostedClustersServiceMonitors(ctx context.Context, c client.Client
 	reconciledHCsCount := 0
 	for _, cluster := range hostedClusters.Items {
 		reconciledSMsCount := 0
-		namespace := fmt.Sprintf("%s-%s", cluster.Namespace, cluster.Name)
+		namespace := HostedClusterNamespace(&cluster)
 
-		etcdSMDesired, err := getEtcdServiceMonitor(ctx, c, namespace, cluster.Spec.ClusterID, cluster.Name)
-		// In case hypershift's etcd ServiceMonitor is not created yet,
-		// we can skip this cluster and wait for the next reconciliation loop
-		if err == nil {
-			if err := createOrUpdateSM(ctx, c, etcdSMDesired); err != nil {
-				return reconciledHCsCount, fmt.Errorf("failed to create/update etcd ServiceMonitor %s/%s: %w", etcdSMDesired.GetNamespace(), etcdSMDesired.GetName(), err)
+		for _, comp := range components {
+			if !slices.Contains(enabledComponents, comp.name) {
+				if err := deleteServiceMonitor(ctx, c, comp.acmServiceMonitor(), namespace); err != nil {
+					return reconciledHCsCount, fmt.Errorf("failed to delete %s ServiceMonitor %s/%s: %w", comp.name, namespace, comp.acmServiceMonitor(), err)
+				}
+				continue
 			}
-			reconciledSMsCount++
-		} else if !errors.IsNotFound(err) {
-			return reconciledHCsCount, fmt.Errorf("failed to get etcd ServiceMonitor: %w", err)
-		}
 
-		apiServerSMDesired, err := getKubeServiceMonitor(ctx, c, namespace, cluster.Spec.ClusterID, cluster.Name)
-		if err == nil {
-			if err := createOrUpdateSM(ctx, c, apiServerSMDesired); err != nil {
-				return reconciledHCsCount, fmt.Errorf("failed to create/update api-server ServiceMonitor %s/%s: %w", apiServerSMDesired.GetNamespace(), apiServerSMDesired.GetName(), err)
+			smDesired, err := getServiceMonitor(ctx, c, comp, namespace, cluster.Spec.ClusterID, cluster.Name)
+			// In case hypershift's ServiceMonitor of the component is not created yet,
+			// we can skip it and wait for the next reconciliation loop
+			if err == nil {
+				if err := createOrUpdateSM(ctx, c, smDesired); err != nil {
+					return reconciledHCsCount, fmt.Errorf("failed to create/update %s ServiceMonitor %s/%s: %w", comp.name, smDesired.GetNamespace(), smDesired.GetName(), err)
+				}
+				reconciledSMsCount++
+			} else if !errors.IsNotFound(err) {
+				return reconciledHCsCount, fmt.Errorf("failed to get %s ServiceMonitor: %w", comp.name, err)
 			}
-			reconciledSMsCount++
-		} else if !errors.IsNotFound(err) {
-			return reconciledHCsCount, fmt.Errorf("failed to get api-server ServiceMonitor: %w", err)
 		}
 
 		if reconciledSMsCount > 0 {
@@ -83,12 +83,10 @@ func DeleteServiceMonitors(ctx context.Context, c client.Client) error {
 
 	for _, cluster := range hList.Items {
 		namespace := HostedClusterNamespace(&cluster)
-		if err := deleteServiceMonitor(ctx, c, AcmEtcdSmName, namespace); err != nil {
-			return fmt.Errorf("failed to delete ServiceMonitor %s/%s: %w", namespace, AcmEtcdSmName, err)
-		}
-
-		if err := deleteServiceMonitor(ctx, c, AcmApiServerSmName, namespace); err != nil {
-			return fmt.Errorf("failed to delete ServiceMonitor %s/%s: %w", namespace, AcmApiServerSmName, err)
+		for _, comp := range components {
+			if err := deleteServiceMonitor(ctx, c, comp.acmServiceMonitor(), namespace); err != nil {
+				return fmt.Errorf("failed to delete ServiceMonitor %s/%s: %w", namespace, comp.acmServiceMonitor(), err)
+			}
 		}
 	}
 
@@ -143,127 +141,23 @@ func createOrUpdateSM(ctx context.Context, c client.Client, smDesired *promv1.Se
 	return nil
 }
 
-func getEtcdServiceMonitor(ctx context.Context, c client.Client, namespace, clusterID, clusterName string) (*promv1.ServiceMonitor, error) {
-	// Get the hypershift's etcd service monitor to replicate some of its settings
-	hypershiftEtcdSM := &promv1.ServiceMonitor{}
-	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "etcd"}, hypershiftEtcdSM); err != nil {
-		return nil, fmt.Errorf("failed to get hypershift's etcd ServiceMonitor: %w", err)
-	}
-
-	if len(hypershiftEtcdSM.Spec.Endpoints) != 1 {
-		return nil, fmt.Errorf("hypershift's etcd ServiceMonitor has more than one endpoint") // safe check
-	}
-
-	originalEndpoint := hypershiftEtcdSM.Spec.Endpoints[0]
-	metricsList := []string{
-		"etcd_disk_backend_commit_duration_seconds_bucket",
-		"etcd_disk_wal_fsync_duration_seconds_bucket",
-		"etcd_mvcc_db_total_size_in_bytes",
-		"etcd_mvcc_db_total_size_in_use_in_bytes",
-		"etcd_network_client_grpc_received_bytes_total",
-		"etcd_network_client_grpc_sent_bytes_total",
-		"etcd_network_peer_received_bytes_total",
-		"etcd_network_peer_round_trip_time_seconds_bucket",
-		"etcd_network_peer_sent_bytes_total",
-		"etcd_server_has_leader",
-		"etcd_server_leader_changes_seen_total",
-		"etcd_server_leader_changes_seen_total",
-		"etcd_server_proposals_applied_total",
-		"etcd_server_proposals_committed_total",
-		"etcd_server_proposals_failed_total",
-		"etcd_server_proposals_pending",
-		"grpc_server_handled_total",
-		"grpc_server_started_total",
-		"process_resident_memory_bytes",
-	}
-
-	return &promv1.ServiceMonitor{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      AcmEtcdSmName,
-			Namespace: namespace,
-		},
-		Spec: promv1.ServiceMonitorSpec{
-			Endpoints: []promv1.Endpoint{
-				{
-					Scheme:            "https",
-					Interval:          "15s",
-					Port:              originalEndpoint.Port,
-					BearerTokenSecret: &corev1.SecretKeySelector{},
-					TLSConfig:         originalEndpoint.TLSConfig,
-					MetricRelabelConfigs: []promv1.RelabelConfig{
-						{
-							SourceLabels: []promv1.LabelName{"__name__"},
-							Action:       "keep",
-							Regex:        fmt.Sprintf("(%s)", strings.Join(metricsList, "|")),
-						},
-						{
-							TargetLabel: "_id",
-							Action:      "replace",
-							Replacement: &clusterID,
-						},
-						{
-							TargetLabel: "cluster_id",
-							Action:      "replace",
-							Replacement: &clusterID,
-						},
-						{
-							TargetLabel: "cluster",
-							Action:      "replace",
-							Replacement: &clusterName,
-						},
-					},
-					RelabelConfigs: []promv1.RelabelConfig{
-						{
-							TargetLabel: "_id",
-							Action:      "replace",
-							Replacement: &clusterID,
-						},
-						{
-							TargetLabel: "job",
-							Action:      "replace",
-							Replacement: stringPtr("etcd"),
-						},
-					},
-				},
-			},
-			Selector:          hypershiftEtcdSM.Spec.Selector,
-			NamespaceSelector: hypershiftEtcdSM.Spec.NamespaceSelector,
-		},
-	}, nil
-}
-
-func getKubeServiceMonitor(ctx context.Context, c client.Client, namespace, clusterID, clusterName string) (*promv1.ServiceMonitor, error) {
-	// Get the hypershift's api-server service monitor and replicate some of its settings
-	hypershiftKubeSM := &promv1.ServiceMonitor{}
-	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ApiServerSmName}, hypershiftKubeSM); err != nil {
-		return nil, fmt.Errorf("failed to get hypershift's kube-apiserver ServiceMonitor: %w", err)
+func getServiceMonitor(ctx context.Context, c client.Client, comp component, namespace, clusterID, clusterName string) (*promv1.ServiceMonitor, error) {
+	// Get the hypershift's service monitor of the component to replicate some of its settings
+	hypershiftSM := &promv1.ServiceMonitor{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: comp.serviceMonitor}, hypershiftSM); err != nil {
+		return nil, fmt.Errorf("failed to get hypershift's %s ServiceMonitor: %w", comp.serviceMonitor, err)
 	}
 
-	smEndpointsLen := len(hypershiftKubeSM.Spec.Endpoints)
+	smEndpointsLen := len(hypershiftSM.Spec.Endpoints)
 	if smEndpointsLen != 1 {
-		return nil, fmt.Errorf("expecting one endpoint from hypershift's kube-apiserver ServiceMonitor, has %d", smEndpointsLen) // safe check
+		return nil, fmt.Errorf("expecting one endpoint from hypershift's %s ServiceMonitor, has %d", comp.serviceMonitor, smEndpointsLen) // safe check
 	}
 
-	originalEndpoint := hypershiftKubeSM.Spec.Endpoints[0]
-
-	metricsList := []string{
-		"apiserver_current_inflight_requests",
-		"apiserver_request_count",
-		"apiserver_request_duration_seconds_bucket",
-		"apiserver_request_total",
-		"apiserver_storage_objects",
-		"go_goroutines",
-		"process_cpu_seconds_total",
-		"process_resident_memory_bytes",
-		"up",
-		"workqueue_adds_total",
-		"workqueue_depth",
-		"workqueue_queue_duration_seconds_bucket",
-	}
+	originalEndpoint := hypershiftSM.Spec.Endpoints[0]
 
 	return &promv1.ServiceMonitor{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      AcmApiServerSmName,
+			Name:      comp.acmServiceMonitor(),
 			Namespace: namespace,
 		},
 		Spec: promv1.ServiceMonitorSpec{
@@ -271,6 +165,7 @@ func getKubeServiceMonitor(ctx context.Context, c client.Client, namespace, clus
 				{
 					Scheme:            "https",
 					Interval:          "15s",
+					Port:              originalEndpoint.Port,
 					TargetPort:        originalEndpoint.TargetPort,
 					BearerTokenSecret: &corev1.SecretKeySelector{},
 					TLSConfig:         originalEndpoint.TLSConfig,
@@ -278,7 +173,7 @@ func getKubeServiceMonitor(ctx context.Context, c client.Client, namespace, clus
 						{
 							SourceLabels: []promv1.LabelName{"__name__"},
 							Action:       "keep",
-							Regex:        fmt.Sprintf("(%s)", strings.Join(metricsList, "|")),
+							Regex:        fmt.Sprintf("(%s)", strings.Join(comp.metrics, "|")),
 						},
 						{
 							TargetLabel: "_id",
@@ -305,13 +200,13 @@ func getKubeServiceMonitor(ctx context.Context, c client.Client, namespace, clus
 						{
 							TargetLabel: "job",
 							Action:      "replace",
-							Replacement: stringPtr("apiserver"),
+							Replacement: stringPtr(comp.job),
 						},
 					},
 				},
 			},
-			Selector:          hypershiftKubeSM.Spec.Selector,
-			NamespaceSelector: hypershiftKubeSM.Spec.NamespaceSelector,
+			Selector:          hypershiftSM.Spec.Selector,
+			NamespaceSelector: hypershiftSM.Spec.NamespaceSelector,
 		},
 	}, nil
 }
@@ -7,11 +7,13 @@ package hypershift_test
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	hyperv1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
 	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/stolostron/multicluster-observability-operator/operators/endpointmetrics/pkg/hypershift"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -182,7 +184,7 @@ func TestHypershiftServiceMonitors(t *testing.T) {
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
 			client := tc.getClient()
-			hostedClustersReconciled, err := hypershift.ReconcileHostedClustersServiceMonitors(context.Background(), client)
+			hostedClustersReconciled, err := hypershift.ReconcileHostedClustersServiceMonitors(context.Background(), client, []string{"etcd", "kube-apiserver"})
 			if tc.expectError {
 				assert.Error(t, err)
 				return
@@ -287,3 +289,113 @@ func TestDeleteServiceMonitors(t *testing.T) {
 		assert.Contains(t, []string{hypershift.ApiServerSmName, hypershift.EtcdSmName}, sm.Name)
 	}
 }
+
+func TestHostedControlPlaneComponents(t *testing.T) {
+	hCluster := &hyperv1.HostedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-hosted-cluster",
+			Namespace: "clusters",
+		},
+		Spec: hyperv1.HostedClusterSpec{
+			ClusterID: "test-hosted-cluster-id",
+		},
+	}
+	namespace := hypershift.HostedClusterNamespace(hCluster)
+	newSourceSM := func(name string) *promv1.ServiceMonitor {
+		return &promv1.ServiceMonitor{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: promv1.ServiceMonitorSpec{
+				Endpoints: []promv1.Endpoint{{Port: "https"}},
+				Selector:  metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+			},
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	hyperv1.AddToScheme(scheme)
+	promv1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	objs := []runtime.Object{hCluster}
+	for _, name := range hypershift.SourceServiceMonitorNames() {
+		objs = append(objs, newSourceSM(name))
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objs...).Build()
+
+	// All the components of the catalog are enabled by the default allowlist.
+	_, err := hypershift.ReconcileHostedClustersServiceMonitors(context.Background(), c,
+		[]string{"etcd", "kube-apiserver", "kube-controller-manager", "kube-scheduler", "openshift-apiserver",
+			"oauth-apiserver", "ignition-server", "konnectivity-server"})
+	assert.NoError(t, err)
+	smList := &promv1.ServiceMonitorList{}
+	assert.NoError(t, c.List(context.Background(), smList, client.InNamespace(namespace)))
+	assert.Len(t, smList.Items, 2*len(hypershift.SourceServiceMonitorNames()))
+
+	sm := &promv1.ServiceMonitor{}
+	assert.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: "acm-kube-scheduler", Namespace: namespace}, sm))
+	assert.Equal(t, "https", sm.Spec.Endpoints[0].Port)
+	assert.Equal(t, map[string]string{"app": "kube-scheduler"}, sm.Spec.Selector.MatchLabels)
+	assert.Equal(t, "scheduler", *sm.Spec.Endpoints[0].RelabelConfigs[1].Replacement)
+	assert.Contains(t, sm.Spec.Endpoints[0].MetricRelabelConfigs[0].Regex, "scheduler_pending_pods")
+	assert.Equal(t, "test-hosted-cluster", *sm.Spec.Endpoints[0].MetricRelabelConfigs[3].Replacement)
+
+	// The ServiceMonitors of the disabled components are deleted.
+	_, err = hypershift.ReconcileHostedClustersServiceMonitors(context.Background(), c, []string{"etcd", "konnectivity-server"})
+	assert.NoError(t, err)
+	assert.NoError(t, c.List(context.Background(), smList, client.InNamespace(namespace)))
+	acmSMs := []string{}
+	for _, sm := range smList.Items {
+		if strings.HasPrefix(sm.Name, "acm-") {
+			acmSMs = append(acmSMs, sm.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{hypershift.AcmEtcdSmName, "acm-konnectivity-server"}, acmSMs)
+
+	assert.NoError(t, hypershift.DeleteServiceMonitors(context.Background(), c))
+	assert.NoError(t, c.List(context.Background(), smList, client.InNamespace(namespace)))
+	assert.Len(t, smList.Items, len(hypershift.SourceServiceMonitorNames()))
+}
+
+func TestEnabledComponents(t *testing.T) {
+	namespace := "open-cluster-management-addon-observability"
+	newAllowlist := func(name, components string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string]string{operatorconfig.UwlMetricsConfigMapKey: components},
+		}
+	}
+
+	testCases := map[string]struct {
+		objs     []runtime.Object
+		expected []string
+	}{
+		"no allowlist": {
+			expected: []string{"etcd", "kube-apiserver"},
+		},
+		"allowlist without components": {
+			objs:     []runtime.Object{newAllowlist(operatorconfig.AllowlistConfigMapName, "names: [up]")},
+			expected: []string{"etcd", "kube-apiserver"},
+		},
+		"allowlist": {
+			objs: []runtime.Object{
+				newAllowlist(operatorconfig.AllowlistConfigMapName, "hosted_control_plane_components: [etcd, kube-apiserver, ignition-server]"),
+			},
+			expected: []string{"etcd", "kube-apiserver", "ignition-server"},
+		},
+		"custom allowlist": {
+			objs: []runtime.Object{
+				newAllowlist(operatorconfig.AllowlistConfigMapName, "hosted_control_plane_components: [etcd, kube-apiserver, ignition-server]"),
+				newAllowlist(operatorconfig.AllowlistCustomConfigMapName, "hosted_control_plane_components: [-ignition-server, kube-scheduler]"),
+			},
+			expected: []string{"etcd", "kube-apiserver", "kube-scheduler"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithRuntimeObjects(tc.objs...).Build()
+			components, err := hypershift.EnabledComponents(c, namespace)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, components)
+		})
+	}
+}
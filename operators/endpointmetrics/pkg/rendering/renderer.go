@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -320,6 +321,53 @@ func Render(
 	return resources, nil
 }
 
+type profileResource struct{ kind, name string }
+
+// profileReplaced lists, for each metrics profile, the kinds and the names of the resources of the bundled Prometheus
+// and of its operator which are replaced by the Prometheus agent. An empty name matches all the resources of the kind.
+var profileReplaced = map[string][]profileResource{
+	operatorconfig.PrometheusAgentProfile: agentProfileReplaced,
+	// The MicroShift profile scrapes the kubelet directly instead of node-exporter
+	operatorconfig.MicroShiftProfile: append(slices.Clone(agentProfileReplaced),
+		profileResource{"DaemonSet", "node-exporter"},
+		profileResource{"Service", "node-exporter"},
+		profileResource{"ServiceAccount", "node-exporter"},
+		profileResource{"ClusterRole", "node-exporter"},
+		profileResource{"ClusterRoleBinding", "node-exporter"},
+		profileResource{"Secret", "prometheus-scrape-targets"},
+	),
+}
+
+var agentProfileReplaced = []profileResource{
+	{"Prometheus", ""},
+	{"PrometheusRule", ""},
+	{"Deployment", "prometheus-operator"},
+	{"ServiceAccount", "prometheus-operator"},
+	{"ClusterRole", "acm-prometheus-operator-role"},
+	{"ClusterRoleBinding", "acm-prometheus-operator-rolebinding"},
+	{"Secret", "prometheus-alertmanager"},
+	{"Service", "prometheus-k8s"},
+}
+
+// SplitProfileResources splits the rendered resources into the ones deployed in the metrics profile and the ones
+// replaced by the Prometheus agent that are removed.
+func SplitProfileResources(profile string, resources []*unstructured.Unstructured) ([]*unstructured.Unstructured, []*unstructured.Unstructured) {
+	toDeploy := []*unstructured.Unstructured{}
+	toDelete := []*unstructured.Unstructured{}
+	for _, res := range resources {
+		replaced := slices.ContainsFunc(profileReplaced[profile], func(r profileResource) bool {
+			return r.kind == res.GetKind() && (r.name == "" || r.name == res.GetName())
+		})
+		if replaced {
+			toDelete = append(toDelete, res)
+		} else {
+			toDeploy = append(toDeploy, res)
+		}
+	}
+
+	return toDeploy, toDelete
+}
+
 func getDisabledMetrics(ctx context.Context, c runtimeclient.Client, namespace string) (string, error) {
 	cm := &corev1.ConfigMap{}
 	err := c.Get(ctx, types.NamespacedName{
@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func getAllowlistCM(ns string) *corev1.ConfigMap {
@@ -183,3 +184,58 @@ func TestRenderAlertmanagerConfigWithPath(t *testing.T) {
 		}
 	}
 }
+
+func TestSplitProfileResources(t *testing.T) {
+	newResource := func(kind, name string) *unstructured.Unstructured {
+		res := &unstructured.Unstructured{}
+		res.SetKind(kind)
+		res.SetName(name)
+		return res
+	}
+	resources := []*unstructured.Unstructured{
+		newResource("Prometheus", "k8s"),
+		newResource("PrometheusRule", "acm-observability-alert-rules"),
+		newResource("Deployment", "prometheus-operator"),
+		newResource("Deployment", "kube-state-metrics"),
+		newResource("DaemonSet", "node-exporter"),
+		newResource("Secret", "prometheus-alertmanager"),
+		newResource("Secret", "prometheus-scrape-targets"),
+		newResource("Service", "prometheus-k8s"),
+		newResource("ServiceAccount", "prometheus-k8s"),
+	}
+
+	names := func(resources []*unstructured.Unstructured) []string {
+		ret := []string{}
+		for _, res := range resources {
+			ret = append(ret, res.GetKind()+"/"+res.GetName())
+		}
+		return ret
+	}
+
+	toDeploy, toDelete := SplitProfileResources(operatorconfig.PrometheusAgentProfile, resources)
+	assert.Equal(t, []string{
+		"Deployment/kube-state-metrics",
+		"DaemonSet/node-exporter",
+		"Secret/prometheus-scrape-targets",
+		"ServiceAccount/prometheus-k8s",
+	}, names(toDeploy))
+	assert.Equal(t, []string{
+		"Prometheus/k8s",
+		"PrometheusRule/acm-observability-alert-rules",
+		"Deployment/prometheus-operator",
+		"Secret/prometheus-alertmanager",
+		"Service/prometheus-k8s",
+	}, names(toDelete))
+
+	toDeploy, toDelete = SplitProfileResources(operatorconfig.MicroShiftProfile, resources)
+	assert.Equal(t, []string{
+		"Deployment/kube-state-metrics",
+		"ServiceAccount/prometheus-k8s",
+	}, names(toDeploy))
+	assert.Len(t, toDelete, 7)
+
+	// The default profile deploys all the resources
+	toDeploy, toDelete = SplitProfileResources("", resources)
+	assert.Len(t, toDeploy, len(resources))
+	assert.Empty(t, toDelete)
+}
@@ -51,6 +51,193 @@ type MultiClusterObservabilitySpec struct {
 	// clusters which have observability add-on enabled.
 	// +required
 	ObservabilityAddonSpec *observabilityshared.ObservabilityAddonSpec `json:"observabilityAddonSpec"`
+	// Tenants declares Observatorium tenants in addition to the default one. The metrics of
+	// each managed cluster are written into the first tenant selecting it, or into the default
+	// tenant when no tenant selects it.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Tenants []ObservabilityTenant `json:"tenants,omitempty"`
+	// Federation enables the global query mode, in which the rbac-query-proxy fans out the queries and
+	// alerts of its /federation path to this hub and to the peer hubs, and labels the results with the hub.
+	// +optional
+	Federation *FederationSpec `json:"federation,omitempty"`
+	// AddonRollout stages the updates of the endpoint operator, metrics allowlist and images pushed to the
+	// managed clusters, batch after batch. When not set, all the managed clusters are updated at once.
+	// +optional
+	AddonRollout *AddonRolloutStrategy `json:"addonRollout,omitempty"`
+	// CertManager delegates the server certificate of the Observatorium API and the client certificate of
+	// Grafana and the rbac-query-proxy to cert-manager, instead of the built-in CAs of the operator.
+	// +optional
+	CertManager *CertManagerSpec `json:"certManager,omitempty"`
+	// Certificates defines the keys and the lifetimes of the certificates of the hub and of the client
+	// certificates of the managed clusters. When not set, they have RSA 2048 keys, and the lifetime set by the
+	// mco-cert-duration annotation, one year by default, five times longer for the CAs.
+	// +optional
+	Certificates *CertificatesSpec `json:"certificates,omitempty"`
+}
+
+// CertificatesSpec defines the keys and the lifetimes of the certificates issued by the operator.
+// +kubebuilder:validation:XValidation:rule="!has(self.keySize) || (self.keyAlgorithm == 'RSA' && self.keySize in [2048, 3072, 4096]) || (self.keyAlgorithm == 'ECDSA' && self.keySize in [256, 384, 521])",message="the key size must be 2048, 3072 or 4096 for RSA, 256, 384 or 521 for ECDSA, and not set for Ed25519"
+type CertificatesSpec struct {
+	// KeyAlgorithm is the algorithm of the keys of the CAs and the certificates: RSA, ECDSA or Ed25519.
+	// A change renews the CAs and the certificates with new keys.
+	// +optional
+	// +kubebuilder:default:=RSA
+	// +kubebuilder:validation:Enum=RSA;ECDSA;Ed25519
+	KeyAlgorithm string `json:"keyAlgorithm,omitempty"`
+	// KeySize is the size of the RSA keys, 2048 by default, or of the curve of the ECDSA keys, 256 by default.
+	// +optional
+	KeySize int32 `json:"keySize,omitempty"`
+	// CADuration is the lifetime of the CAs, five times the lifetime of the server certificates by default.
+	// It applies from the next renewal of the CAs.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	CADuration string `json:"caDuration,omitempty"`
+	// ServerCertDuration is the lifetime of the server certificate of the Observatorium API, the one set by
+	// the mco-cert-duration annotation by default. It applies from the next renewal of the certificate.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	ServerCertDuration string `json:"serverCertDuration,omitempty"`
+	// ClientCertDuration is the lifetime of the client certificates of Grafana, the hub metrics collector and
+	// the managed clusters, the one set by the mco-cert-duration annotation by default. It applies from the next
+	// renewal of the certificates.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	ClientCertDuration string `json:"clientCertDuration,omitempty"`
+}
+
+// CertManagerSpec defines the cert-manager issuers of the certificates of the hub, and the CA signing the
+// client certificates of the managed clusters.
+type CertManagerSpec struct {
+	// ServerIssuer issues the server certificate of the Observatorium API. The CA of the issued certificate,
+	// its ca.crt, is trusted by the managed clusters and the rbac-query-proxy.
+	// +required
+	ServerIssuer CertManagerIssuerReference `json:"serverIssuer"`
+	// ClientIssuer issues the client certificate of Grafana and the rbac-query-proxy. The CA of the issued
+	// certificate is trusted by the Observatorium API.
+	// +required
+	ClientIssuer CertManagerIssuerReference `json:"clientIssuer"`
+	// ClientCASecretName is the name of a secret of the namespace of the operands holding the tls.crt and tls.key
+	// of the CA signing the client certificates of the managed clusters and the hub metrics collector. When not
+	// set, they are signed by the built-in client CA.
+	// +optional
+	ClientCASecretName string `json:"clientCASecretName,omitempty"`
+}
+
+// CertManagerIssuerReference references a cert-manager Issuer or ClusterIssuer.
+type CertManagerIssuerReference struct {
+	// Name of the issuer.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Kind of the issuer: Issuer, in the namespace of the operands, or ClusterIssuer.
+	// +optional
+	// +kubebuilder:default:=Issuer
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	Kind string `json:"kind,omitempty"`
+	// Group of the issuer, cert-manager.io when not set. It is set for the external issuers.
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+// AddonRolloutStrategy defines the batches of managed clusters the addon updates are rolled out to,
+// and the health gates between them.
+type AddonRolloutStrategy struct {
+	// CanaryClusterSelector selects the managed clusters updated first, all in the first batch.
+	// +optional
+	CanaryClusterSelector *metav1.LabelSelector `json:"canaryClusterSelector,omitempty"`
+	// BatchSize is the number of managed clusters updated in each batch after the canary clusters.
+	// +optional
+	// +kubebuilder:default:=10
+	// +kubebuilder:validation:Minimum=1
+	BatchSize int32 `json:"batchSize,omitempty"`
+	// PauseBetweenBatches is how long to wait once the managed clusters of a batch are healthy,
+	// before updating the next batch.
+	// +optional
+	// +kubebuilder:default:="5m"
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	PauseBetweenBatches string `json:"pauseBetweenBatches,omitempty"`
+	// HealthTimeout is how long the managed clusters of a batch have to become healthy before the rollout
+	// is halted. A managed cluster is healthy when its ManifestWork is applied and its ObservabilityAddon is
+	// available and not degraded.
+	// +optional
+	// +kubebuilder:default:="30m"
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	HealthTimeout string `json:"healthTimeout,omitempty"`
+	// MaxUnhealthyClusters is the number of updated managed clusters allowed to be unhealthy when
+	// updating the next batch.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxUnhealthyClusters int32 `json:"maxUnhealthyClusters,omitempty"`
+}
+
+// FederationSpec defines this hub and the peer hubs of the global query mode.
+// +kubebuilder:validation:XValidation:rule="!has(self.peers) || self.peers.all(p, p.name != self.hubName)",message="a peer can't have the name of this hub"
+type FederationSpec struct {
+	// HubName is the value of the hub label of the metrics and alerts of this hub.
+	// +required
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	HubName string `json:"hubName"`
+	// Peers are the hubs queried in addition to this one.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Peers []FederationPeer `json:"peers,omitempty"`
+}
+
+// FederationPeer defines a peer hub and the mTLS credentials used to query its Observatorium API.
+type FederationPeer struct {
+	// Name is the value of the hub label of the metrics and alerts of the peer hub.
+	// +required
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// URL of the Observatorium API of the peer hub, the host of its observatorium-api route.
+	// +required
+	// +kubebuilder:validation:Pattern=`^https://`
+	URL string `json:"url"`
+	// TLSSecretName is the name of the secret holding the ca.crt of the Observatorium API of the peer hub,
+	// and the tls.crt and tls.key of a client certificate it grants read access to.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	TLSSecretName string `json:"tlsSecretName"`
+}
+
+// ObservabilityTenant defines an Observatorium tenant and the managed clusters writing into it.
+// +kubebuilder:validation:XValidation:rule="self.name != 'default'",message="the default tenant can't be redeclared"
+type ObservabilityTenant struct {
+	// Name of the tenant, used in the Observatorium API paths.
+	// +required
+	// +kubebuilder:validation:MaxLength=32
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+	// ClusterSets selects the managed clusters of the given ManagedClusterSets.
+	// +optional
+	ClusterSets []string `json:"clusterSets,omitempty"`
+	// ClusterSelector selects the managed clusters by label.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// Retention overrides the bucket retention of the metrics of the tenant, so of the managed clusters selected
+	// by clusterSets and clusterSelector. It must be shorter than the retention of spec.advanced.retentionConfig,
+	// which applies to all the metrics.
+	// +optional
+	Retention *TenantRetentionConfig `json:"retention,omitempty"`
+}
+
+// TenantRetentionConfig defines the bucket retention of the metrics of a tenant.
+// The blocks of the tenant older than the retention are marked for deletion by a periodic job.
+type TenantRetentionConfig struct {
+	// How long to retain raw samples in a bucket.
+	// +optional
+	RetentionResolutionRaw string `json:"retentionResolutionRaw,omitempty"`
+	// How long to retain samples of resolution 1 (5 minutes) in bucket.
+	// +optional
+	RetentionResolution5m string `json:"retentionResolution5m,omitempty"`
+	// How long to retain samples of resolution 2 (1 hour) in bucket.
+	// +optional
+	RetentionResolution1h string `json:"retentionResolution1h,omitempty"`
 }
 
 // T Shirt size class for a particular o11y resource.
@@ -483,6 +670,11 @@ type ReceiveSpec struct {
 	// +optional
 	Debug *ReceiveDebugSpec `json:"debug,omitempty"`
 
+	// Limits enforced by the receiver on the remote write requests. Changes are reloaded without
+	// restarting the receiver.
+	// +optional
+	Limits *ReceiveLimits `json:"limits,omitempty"`
+
 	CommonSpec `json:",inline"`
 }
 
@@ -564,6 +756,72 @@ type ReceiveDebugSpec struct {
 	LogLevel string `json:"logLevel,omitempty"`
 }
 
+// ReceiveLimits defines the limits of the receiver, rendered into its limits configuration file.
+type ReceiveLimits struct {
+	// Global limits, shared by all the tenants.
+	// +optional
+	Global *ReceiveGlobalLimits `json:"global,omitempty"`
+
+	// Default limits of each tenant without its own limits.
+	// +optional
+	Default *ReceiveTenantLimits `json:"default,omitempty"`
+
+	// Tenants overrides the default limits of some tenants, either `default` or one of the tenants of the spec.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Tenants []ReceiveTenantLimitsOverride `json:"tenants,omitempty"`
+}
+
+// ReceiveGlobalLimits defines the limits shared by all the tenants.
+type ReceiveGlobalLimits struct {
+	// Maximum number of remote write requests processed concurrently. 0 means no limit.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxConcurrency *int64 `json:"maxConcurrency,omitempty"`
+
+	// URL of the Prometheus compatible API queried for the number of head series of each tenant.
+	// Required to enforce headSeriesLimit.
+	// +optional
+	MetaMonitoringURL string `json:"metaMonitoringURL,omitempty"`
+
+	// Query returning the number of head series of each tenant, with a `tenant` label.
+	// +optional
+	MetaMonitoringLimitQuery string `json:"metaMonitoringLimitQuery,omitempty"`
+}
+
+// ReceiveTenantLimits defines the limits of a tenant. 0 means no limit.
+type ReceiveTenantLimits struct {
+	// Maximum size of a remote write request, in bytes.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RequestSizeBytesLimit *int64 `json:"requestSizeBytesLimit,omitempty"`
+
+	// Maximum number of series in a remote write request.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RequestSeriesLimit *int64 `json:"requestSeriesLimit,omitempty"`
+
+	// Maximum number of samples in a remote write request.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RequestSamplesLimit *int64 `json:"requestSamplesLimit,omitempty"`
+
+	// Maximum number of active series of the tenant. Requires the global metaMonitoringURL.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	HeadSeriesLimit *int64 `json:"headSeriesLimit,omitempty"`
+}
+
+// ReceiveTenantLimitsOverride defines the limits of a tenant, overriding the default limits.
+type ReceiveTenantLimitsOverride struct {
+	// Name of the tenant.
+	// +required
+	Name string `json:"name"`
+
+	ReceiveTenantLimits `json:",inline"`
+}
+
 // CacheConfig is the spec of memcached.
 type CacheConfig struct {
 	// Memory limit of Memcached in megabytes.
@@ -625,6 +883,10 @@ type StorageConfig struct {
 	// WriteStorage storage config secret list for metrics
 	// +optional
 	WriteStorage []*observabilityshared.PreConfiguredStorage `json:"writeStorage,omitempty"`
+	// WorkloadIdentity configures the cloud workload identity used by the Thanos components to access the
+	// metric object storage, instead of the static credentials of the object store config secret.
+	// +optional
+	WorkloadIdentity *WorkloadIdentityConfig `json:"workloadIdentity,omitempty"`
 	// Specify the storageClass Stateful Sets. This storage class will also
 	// be used for Object Storage if MetricObjectStorage was configured for
 	// the system to create the storage.
@@ -652,6 +914,59 @@ type StorageConfig struct {
 	StoreStorageSize string `json:"storeStorageSize,omitempty"`
 }
 
+// WorkloadIdentityConfig is the cloud workload identity of the Thanos components. Their service accounts are
+// annotated with the identity, and their projected service account token is exchanged for cloud credentials.
+// Exactly one of aws, azure and gcp must be set.
+type WorkloadIdentityConfig struct {
+	// AWS configures AWS STS, with IAM roles for service accounts.
+	// +optional
+	AWS *AWSWorkloadIdentity `json:"aws,omitempty"`
+	// Azure configures Microsoft Entra Workload ID.
+	// +optional
+	Azure *AzureWorkloadIdentity `json:"azure,omitempty"`
+	// GCP configures GCP Workload Identity.
+	// +optional
+	GCP *GCPWorkloadIdentity `json:"gcp,omitempty"`
+}
+
+// AWSWorkloadIdentity is the IAM role assumed by the Thanos components.
+type AWSWorkloadIdentity struct {
+	// ARN of the IAM role, which must trust the OIDC provider of the cluster.
+	// +required
+	// +kubebuilder:validation:Pattern=`^arn:aws[a-z-]*:iam::[0-9]{12}:role/.+$`
+	RoleARN string `json:"roleARN"`
+	// Audience of the projected service account token, which must be a client ID of the OIDC provider of the
+	// cluster. Defaults to sts.amazonaws.com.
+	// +optional
+	Audience string `json:"audience,omitempty"`
+}
+
+// AzureWorkloadIdentity is the managed identity, or application, used by the Thanos components.
+type AzureWorkloadIdentity struct {
+	// Client ID of the managed identity or application, which must have a federated credential for the
+	// service accounts of the Thanos components.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	ClientID string `json:"clientID"`
+	// ID of the Microsoft Entra tenant of the managed identity or application.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	TenantID string `json:"tenantID"`
+	// Audience of the projected service account token, which must be the audience of the federated credential.
+	// Defaults to api://AzureADTokenExchange.
+	// +optional
+	Audience string `json:"audience,omitempty"`
+}
+
+// GCPWorkloadIdentity is the Google service account impersonated by the Thanos components.
+type GCPWorkloadIdentity struct {
+	// Email of the Google service account, which must allow the service accounts of the Thanos components
+	// to impersonate it.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	ServiceAccount string `json:"serviceAccount"`
+}
+
 // MultiClusterObservabilityStatus defines the observed state of MultiClusterObservability.
 type MultiClusterObservabilityStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
@@ -660,6 +975,118 @@ type MultiClusterObservabilityStatus struct {
 	// Represents the status of each deployment
 	// +optional
 	Conditions []observabilityshared.Condition `json:"conditions,omitempty"`
+
+	// Storage records the storage configuration applied to the PVCs and StatefulSets,
+	// and the progress of the migration to a new one.
+	// +optional
+	Storage *StorageStatus `json:"storage,omitempty"`
+
+	// MaintenanceWindows are the maintenance windows in progress, during which the alerts of the managed
+	// clusters are silenced.
+	// +optional
+	MaintenanceWindows []ActiveMaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// AddonRollout is the progress of the staged rollout of the addon updates to the managed clusters.
+	// +optional
+	AddonRollout *AddonRolloutStatus `json:"addonRollout,omitempty"`
+}
+
+// AddonRolloutPhase is the phase of the staged rollout of the addon updates.
+// +kubebuilder:validation:Enum=Progressing;Paused;Halted;Completed
+type AddonRolloutPhase string
+
+const (
+	// AddonRolloutProgressing means that a batch is being updated, waiting for its managed clusters to
+	// become healthy.
+	AddonRolloutProgressing AddonRolloutPhase = "Progressing"
+	// AddonRolloutPaused means that the last batch is healthy, and the next one waits for the pause
+	// between batches.
+	AddonRolloutPaused AddonRolloutPhase = "Paused"
+	// AddonRolloutHalted means that more managed clusters than allowed are still unhealthy after the
+	// health timeout. The rollout resumes when they recover.
+	AddonRolloutHalted AddonRolloutPhase = "Halted"
+	// AddonRolloutCompleted means that all the managed clusters are updated.
+	AddonRolloutCompleted AddonRolloutPhase = "Completed"
+)
+
+// AddonRolloutStatus defines the progress of the staged rollout of a revision of the addon.
+type AddonRolloutStatus struct {
+	// Revision is the hash of the endpoint operator, metrics allowlist and images being rolled out.
+	Revision string `json:"revision"`
+	// Phase of the rollout.
+	Phase AddonRolloutPhase `json:"phase"`
+	// UpdatedClusters is the number of managed clusters updated to the revision.
+	UpdatedClusters int32 `json:"updatedClusters"`
+	// TotalClusters is the number of managed clusters the revision is rolled out to.
+	TotalClusters int32 `json:"totalClusters"`
+	// UnhealthyClusters are the updated managed clusters which are not healthy.
+	// +optional
+	UnhealthyClusters []string `json:"unhealthyClusters,omitempty"`
+	// BatchStartTime is when the last batch was updated.
+	// +optional
+	BatchStartTime *metav1.Time `json:"batchStartTime,omitempty"`
+	// BatchHealthyTime is when the managed clusters of the last batch became healthy.
+	// +optional
+	BatchHealthyTime *metav1.Time `json:"batchHealthyTime,omitempty"`
+	// Message describes the progress of the rollout.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ActiveMaintenanceWindow is an ObservabilityMaintenanceWindow in progress.
+type ActiveMaintenanceWindow struct {
+	// Name of the ObservabilityMaintenanceWindow.
+	Name string `json:"name"`
+	// Clusters is the number of managed clusters whose alerts are silenced.
+	Clusters int32 `json:"clusters"`
+	// EndsAt is the end of the window.
+	EndsAt metav1.Time `json:"endsAt"`
+}
+
+// StorageMigrationPhase is the phase of the migration of the PVCs and StatefulSets to a new
+// storage configuration.
+// +kubebuilder:validation:Enum=Pending;ResizingPVCs;RecreatingStatefulSets;Done
+type StorageMigrationPhase string
+
+const (
+	// StorageMigrationPending means that a new storage configuration has been detected.
+	StorageMigrationPending StorageMigrationPhase = "Pending"
+	// StorageMigrationResizingPVCs means that the PVCs are being resized.
+	StorageMigrationResizingPVCs StorageMigrationPhase = "ResizingPVCs"
+	// StorageMigrationRecreatingStatefulSets means that the StatefulSets are being deleted to be
+	// re-created, with their PVCs when the storage class changed.
+	StorageMigrationRecreatingStatefulSets StorageMigrationPhase = "RecreatingStatefulSets"
+	// StorageMigrationDone means that the target storage configuration has been applied.
+	StorageMigrationDone StorageMigrationPhase = "Done"
+)
+
+// StorageStatus defines the storage configuration applied to the PVCs and StatefulSets.
+type StorageStatus struct {
+	// Applied is the last storage configuration fully applied.
+	// +optional
+	Applied *AppliedStorageConfig `json:"applied,omitempty"`
+	// Target is the storage configuration being applied, until the migration is done.
+	// +optional
+	Target *AppliedStorageConfig `json:"target,omitempty"`
+	// Phase of the migration to the target storage configuration.
+	// +optional
+	Phase StorageMigrationPhase `json:"phase,omitempty"`
+}
+
+// AppliedStorageConfig is the part of the StorageConfig applied to the PVCs and StatefulSets.
+type AppliedStorageConfig struct {
+	// +optional
+	StorageClass string `json:"storageClass,omitempty"`
+	// +optional
+	AlertmanagerStorageSize string `json:"alertmanagerStorageSize,omitempty"`
+	// +optional
+	RuleStorageSize string `json:"ruleStorageSize,omitempty"`
+	// +optional
+	CompactStorageSize string `json:"compactStorageSize,omitempty"`
+	// +optional
+	ReceiveStorageSize string `json:"receiveStorageSize,omitempty"`
+	// +optional
+	StoreStorageSize string `json:"storeStorageSize,omitempty"`
 }
 
 // +kubebuilder:object:root=true
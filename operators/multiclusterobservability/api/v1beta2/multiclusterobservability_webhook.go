@@ -7,8 +7,11 @@ package v1beta2
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"slices"
 	"strings"
 
+	"github.com/prometheus/common/model"
 	"gopkg.in/yaml.v2"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -96,6 +99,10 @@ func (mco *MultiClusterObservability) validateMultiClusterObservability(old runt
 	if err := mco.validateMultiClusterObservabilitySpec(); err != nil {
 		allErrs = append(allErrs, err)
 	}
+	allErrs = append(allErrs, mco.validateReceiveLimits()...)
+	allErrs = append(allErrs, mco.validateTenantRetention()...)
+	allErrs = append(allErrs, mco.validateWorkloadIdentity()...)
+	allErrs = append(allErrs, mco.validateFederation()...)
 
 	// validate the MultiClusterObservability CR update
 	if old != nil {
@@ -169,7 +176,7 @@ func (mco *MultiClusterObservability) validateMultiClusterObservabilitySpec() *f
 	}
 
 	// Validate the object storage configuration (including bucket name length)
-	err = validateObjectStorageConfig(data)
+	err = mco.validateObjectStorageConfig(data)
 	if err != nil {
 		return field.Invalid(
 			storageConfigPath,
@@ -181,8 +188,143 @@ func (mco *MultiClusterObservability) validateMultiClusterObservabilitySpec() *f
 	return nil
 }
 
+// validateWorkloadIdentity validates that a single cloud workload identity is configured.
+func (mco *MultiClusterObservability) validateWorkloadIdentity() field.ErrorList {
+	if mco.Spec.StorageConfig == nil || mco.Spec.StorageConfig.WorkloadIdentity == nil {
+		return nil
+	}
+
+	identity := mco.Spec.StorageConfig.WorkloadIdentity
+	identityPath := field.NewPath("spec").Child("storageConfig").Child("workloadIdentity")
+	var providers []string
+	if identity.AWS != nil {
+		providers = append(providers, "aws")
+	}
+	if identity.Azure != nil {
+		providers = append(providers, "azure")
+	}
+	if identity.GCP != nil {
+		providers = append(providers, "gcp")
+	}
+	switch len(providers) {
+	case 0:
+		return field.ErrorList{field.Required(identityPath, "one of aws, azure and gcp must be set")}
+	case 1:
+		return nil
+	default:
+		return field.ErrorList{field.Invalid(identityPath, strings.Join(providers, ", "), "only one of aws, azure and gcp can be set")}
+	}
+}
+
+// validateFederation validates that the peer hubs have absolute URLs, and names distinct from this hub.
+func (mco *MultiClusterObservability) validateFederation() field.ErrorList {
+	if mco.Spec.Federation == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	peersPath := field.NewPath("spec").Child("federation").Child("peers")
+	for i, peer := range mco.Spec.Federation.Peers {
+		if peer.Name == mco.Spec.Federation.HubName {
+			errs = append(errs, field.Invalid(peersPath.Index(i).Child("name"), peer.Name, "must differ from spec.federation.hubName"))
+		}
+		u, err := url.Parse(peer.URL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			errs = append(errs, field.Invalid(peersPath.Index(i).Child("url"), peer.URL, "must be an absolute https URL"))
+		}
+	}
+	return errs
+}
+
+// validateReceiveLimits validates that the receive limits reference existing tenants, and that the
+// head series limits can be enforced.
+func (mco *MultiClusterObservability) validateReceiveLimits() field.ErrorList {
+	if mco.Spec.AdvancedConfig == nil || mco.Spec.AdvancedConfig.Receive == nil ||
+		mco.Spec.AdvancedConfig.Receive.Limits == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	limits := mco.Spec.AdvancedConfig.Receive.Limits
+	limitsPath := field.NewPath("spec").Child("advanced").Child("receive").Child("limits")
+
+	metaMonitoringURL := ""
+	if limits.Global != nil && limits.Global.MetaMonitoringURL != "" {
+		metaMonitoringURL = limits.Global.MetaMonitoringURL
+		u, err := url.Parse(metaMonitoringURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, field.Invalid(limitsPath.Child("global").Child("metaMonitoringURL"),
+				metaMonitoringURL, "must be an absolute http or https URL"))
+		}
+	}
+
+	headSeriesLimitSet := func(tenantLimits *ReceiveTenantLimits) bool {
+		return tenantLimits.HeadSeriesLimit != nil && *tenantLimits.HeadSeriesLimit > 0
+	}
+	headSeriesLimitRequired := "requires spec.advanced.receive.limits.global.metaMonitoringURL"
+	if limits.Default != nil && headSeriesLimitSet(limits.Default) && metaMonitoringURL == "" {
+		errs = append(errs, field.Invalid(limitsPath.Child("default").Child("headSeriesLimit"),
+			*limits.Default.HeadSeriesLimit, headSeriesLimitRequired))
+	}
+
+	tenants := map[string]bool{"default": true}
+	for _, tenant := range mco.Spec.Tenants {
+		tenants[tenant.Name] = true
+	}
+	for i, tenant := range limits.Tenants {
+		tenantPath := limitsPath.Child("tenants").Index(i)
+		if !tenants[tenant.Name] {
+			errs = append(errs, field.NotFound(tenantPath.Child("name"), tenant.Name))
+		}
+		if headSeriesLimitSet(&tenant.ReceiveTenantLimits) && metaMonitoringURL == "" {
+			errs = append(errs, field.Invalid(tenantPath.Child("headSeriesLimit"), *tenant.HeadSeriesLimit, headSeriesLimitRequired))
+		}
+	}
+
+	return errs
+}
+
+// validateTenantRetention validates that the retention of the tenants are positive durations, shorter than the
+// global retention when it is set. The default global retention is checked by the operator.
+func (mco *MultiClusterObservability) validateTenantRetention() field.ErrorList {
+	var errs field.ErrorList
+	global := &RetentionConfig{}
+	if mco.Spec.AdvancedConfig != nil && mco.Spec.AdvancedConfig.RetentionConfig != nil {
+		global = mco.Spec.AdvancedConfig.RetentionConfig
+	}
+
+	for i, tenant := range mco.Spec.Tenants {
+		if tenant.Retention == nil {
+			continue
+		}
+		retentionPath := field.NewPath("spec").Child("tenants").Index(i).Child("retention")
+		for _, r := range []struct {
+			name, value, global string
+		}{
+			{"retentionResolutionRaw", tenant.Retention.RetentionResolutionRaw, global.RetentionResolutionRaw},
+			{"retentionResolution5m", tenant.Retention.RetentionResolution5m, global.RetentionResolution5m},
+			{"retentionResolution1h", tenant.Retention.RetentionResolution1h, global.RetentionResolution1h},
+		} {
+			if r.value == "" {
+				continue
+			}
+			value, err := model.ParseDuration(r.value)
+			if err != nil || value <= 0 {
+				errs = append(errs, field.Invalid(retentionPath.Child(r.name), r.value, "must be a positive duration"))
+				continue
+			}
+			globalValue, err := model.ParseDuration(r.global)
+			if err == nil && globalValue > 0 && value > globalValue {
+				errs = append(errs, field.Invalid(retentionPath.Child(r.name), r.value,
+					fmt.Sprintf("must not be longer than the global retention %s", r.global)))
+			}
+		}
+	}
+	return errs
+}
+
 // validateObjectStorageConfig validates object storage configuration including bucket name length
-func validateObjectStorageConfig(data []byte) error {
+func (mco *MultiClusterObservability) validateObjectStorageConfig(data []byte) error {
 	var objConf objectStorageConf
 	err := yaml.Unmarshal(data, &objConf)
 	if err != nil {
@@ -198,11 +340,38 @@ func validateObjectStorageConfig(data []byte) error {
 	case "azure":
 		// Azure uses "container" instead of "bucket", skip bucket validation
 		return nil
+	case "swift", "cos", "oci":
+		// the provider specific fields are validated by the operator, and reported in the status
+		return nil
+	case "filesystem":
+		return mco.validateFilesystemReplicas()
 	default:
 		return fmt.Errorf("unsupported storage type: %s", objConf.Type)
 	}
 }
 
+// validateFilesystemReplicas validates that the Thanos components writing and reading the filesystem backend run a
+// single replica. Each pod has its own directory, so the filesystem backend is only meant for test setups.
+func (mco *MultiClusterObservability) validateFilesystemReplicas() error {
+	var replicas []*int32
+	if advanced := mco.Spec.AdvancedConfig; advanced != nil {
+		if advanced.Receive != nil {
+			replicas = append(replicas, advanced.Receive.Replicas)
+		}
+		if advanced.Rule != nil {
+			replicas = append(replicas, advanced.Rule.Replicas)
+		}
+		if advanced.Store != nil {
+			replicas = append(replicas, advanced.Store.Replicas)
+		}
+	}
+	if len(replicas) < 3 || slices.ContainsFunc(replicas, func(r *int32) bool { return r == nil || *r != 1 }) {
+		return fmt.Errorf("the filesystem storage is only supported for test setups, with a single replica of " +
+			"thanos receive, rule and store: advanced.receive.replicas, advanced.rule.replicas and advanced.store.replicas must be 1")
+	}
+	return nil
+}
+
 // validateBucketName validates bucket name length according to S3/GCS specifications
 func validateBucketName(bucket string, storageType string) error {
 	if bucket == "" {
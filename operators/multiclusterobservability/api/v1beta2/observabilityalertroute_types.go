@@ -0,0 +1,212 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package v1beta2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AlertRouteAcceptedCondition reports whether the routes and receivers were merged into the Alertmanager
+	// configuration, with the validation errors and the conflicts with the base configuration.
+	AlertRouteAcceptedCondition = "Accepted"
+)
+
+// AlertWebhookConfig sends the alerts to a webhook.
+// +kubebuilder:validation:XValidation:rule="has(self.url) != has(self.urlSecret)",message="exactly one of url or urlSecret must be set"
+type AlertWebhookConfig struct {
+	// URL of the webhook.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// URLSecret is the key of the secret holding the URL of the webhook, in the namespace of the ObservabilityAlertRoute.
+	// +optional
+	URLSecret *corev1.SecretKeySelector `json:"urlSecret,omitempty"`
+
+	// SendResolved sends the resolved alerts as well. Defaults to true.
+	// +optional
+	SendResolved *bool `json:"sendResolved,omitempty"`
+}
+
+// AlertSlackConfig sends the alerts to a Slack channel.
+type AlertSlackConfig struct {
+	// APIURLSecret is the key of the secret holding the Slack webhook URL, in the namespace of the ObservabilityAlertRoute.
+	APIURLSecret corev1.SecretKeySelector `json:"apiURLSecret"`
+
+	// Channel overrides the channel of the Slack webhook.
+	// +optional
+	Channel string `json:"channel,omitempty"`
+
+	// SendResolved sends the resolved alerts as well. Defaults to false.
+	// +optional
+	SendResolved *bool `json:"sendResolved,omitempty"`
+}
+
+// AlertPagerDutyConfig sends the alerts to PagerDuty with the Events API v2.
+type AlertPagerDutyConfig struct {
+	// RoutingKeySecret is the key of the secret holding the integration key of the PagerDuty service,
+	// in the namespace of the ObservabilityAlertRoute.
+	RoutingKeySecret corev1.SecretKeySelector `json:"routingKeySecret"`
+
+	// SendResolved sends the resolved alerts as well. Defaults to true.
+	// +optional
+	SendResolved *bool `json:"sendResolved,omitempty"`
+}
+
+// AlertEmailConfig sends the alerts by email, with the SMTP settings of the global Alertmanager configuration.
+type AlertEmailConfig struct {
+	// To is the email address the alerts are sent to.
+	// +kubebuilder:validation:MinLength=1
+	To string `json:"to"`
+
+	// SendResolved sends the resolved alerts as well. Defaults to false.
+	// +optional
+	SendResolved *bool `json:"sendResolved,omitempty"`
+}
+
+// AlertReceiver is a named set of notification integrations.
+type AlertReceiver struct {
+	// Name of the receiver, unique in the ObservabilityAlertRoute.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// +optional
+	WebhookConfigs []AlertWebhookConfig `json:"webhookConfigs,omitempty"`
+
+	// +optional
+	SlackConfigs []AlertSlackConfig `json:"slackConfigs,omitempty"`
+
+	// +optional
+	PagerDutyConfigs []AlertPagerDutyConfig `json:"pagerdutyConfigs,omitempty"`
+
+	// +optional
+	EmailConfigs []AlertEmailConfig `json:"emailConfigs,omitempty"`
+}
+
+// AlertSubRoute sends the matching alerts of the team to one of its receivers.
+type AlertSubRoute struct {
+	// Receiver is the name of one of the receivers of the ObservabilityAlertRoute.
+	// +kubebuilder:validation:MinLength=1
+	Receiver string `json:"receiver"`
+
+	// Matchers select the alerts of the route, in the Alertmanager syntax, e.g. severity="critical".
+	// +optional
+	Matchers []string `json:"matchers,omitempty"`
+
+	// GroupBy overrides the labels the alerts are grouped by.
+	// +optional
+	GroupBy []string `json:"groupBy,omitempty"`
+
+	// RepeatInterval overrides the interval at which the notifications of a firing alert are sent again.
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	// +optional
+	RepeatInterval string `json:"repeatInterval,omitempty"`
+
+	// Continue evaluates the next routes after a match.
+	// +optional
+	Continue bool `json:"continue,omitempty"`
+}
+
+// ObservabilityAlertRouteSpec defines the receivers of a team and the routes of the alerts of its clusters.
+type ObservabilityAlertRouteSpec struct {
+	// ClusterSets restricts the routes to the alerts of the managed clusters of the given ManagedClusterSets,
+	// which must be bound to the namespace with a ManagedClusterSetBinding.
+	// +optional
+	ClusterSets []string `json:"clusterSets,omitempty"`
+
+	// ClusterSelector restricts the routes to the alerts of the managed clusters selected by label.
+	// When neither clusterSets nor clusterSelector is set, the routes receive the alerts of all the managed clusters.
+	// The clusters are always selected among the ManagedClusterSets bound to the namespace.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// Namespaces restricts the routes to the alerts of the given namespaces.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Receiver is the receiver of the alerts of the team not matching any route.
+	// +kubebuilder:validation:MinLength=1
+	Receiver string `json:"receiver"`
+
+	// GroupBy is the list of labels the alerts of the team are grouped by. Defaults to the grouping of the root route.
+	// +optional
+	GroupBy []string `json:"groupBy,omitempty"`
+
+	// GroupWait is how long to wait before sending the first notification of a group.
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	// +optional
+	GroupWait string `json:"groupWait,omitempty"`
+
+	// GroupInterval is how long to wait before sending the notification of new alerts of a group.
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	// +optional
+	GroupInterval string `json:"groupInterval,omitempty"`
+
+	// RepeatInterval is the interval at which the notifications of a firing alert are sent again.
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	// +optional
+	RepeatInterval string `json:"repeatInterval,omitempty"`
+
+	// Routes are evaluated in order, the alerts are sent to the receiver of the first matching route.
+	// +optional
+	Routes []AlertSubRoute `json:"routes,omitempty"`
+
+	// Receivers are the notification integrations of the team.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=name
+	Receivers []AlertReceiver `json:"receivers"`
+}
+
+// ObservabilityAlertRouteStatus defines the observed state of ObservabilityAlertRoute.
+type ObservabilityAlertRouteStatus struct {
+	// ObservedGeneration is the generation of the spec last processed by the operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// MatchedClusters is the number of managed clusters whose alerts are routed.
+	// +optional
+	MatchedClusters int32 `json:"matchedClusters"`
+
+	// Conditions describe the merge state of the routes, including the validation errors and conflicts.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=observabilityalertroutes,scope=Namespaced,shortName=obroute
+// +kubebuilder:printcolumn:name="Receiver",type=string,JSONPath=`.spec.receiver`
+// +kubebuilder:printcolumn:name="Clusters",type=integer,JSONPath=`.status.matchedClusters`
+// +kubebuilder:printcolumn:name="Accepted",type=string,JSONPath=`.status.conditions[?(@.type=="Accepted")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +operator-sdk:csv:customresourcedefinitions:displayName="ObservabilityAlertRoute"
+
+// ObservabilityAlertRoute declares the receivers of a team and routes the alerts of its managed clusters to them.
+// The operator merges the ObservabilityAlertRoutes into the configuration of the hub Alertmanager.
+type ObservabilityAlertRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ObservabilityAlertRouteSpec   `json:"spec,omitempty"`
+	Status ObservabilityAlertRouteStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ObservabilityAlertRouteList contains a list of ObservabilityAlertRoute
+type ObservabilityAlertRouteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ObservabilityAlertRoute `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ObservabilityAlertRoute{}, &ObservabilityAlertRouteList{})
+}
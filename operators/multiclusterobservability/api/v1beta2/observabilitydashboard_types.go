@@ -0,0 +1,142 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DashboardSyncedCondition reports whether the dashboard was loaded in Grafana.
+	DashboardSyncedCondition = "Synced"
+	// DashboardValidatedCondition reports the issues found in the dashboard, like unknown datasources or invalid queries.
+	DashboardValidatedCondition = "Validated"
+	// DashboardFolderPermissionsCondition reports whether the permissions of the resource are applied to its folder,
+	// or ignored as another source owns the permissions of the folder.
+	DashboardFolderPermissionsCondition = "FolderPermissions"
+)
+
+// DashboardGitSource is a dashboard JSON file stored in a Git repository.
+type DashboardGitSource struct {
+	// Repository is the HTTPS URL of the Git repository, e.g. https://github.com/org/dashboards.git.
+	// Only public repositories are supported.
+	// +kubebuilder:validation:Pattern=`^https://`
+	Repository string `json:"repository"`
+
+	// Ref is the branch or tag to load the dashboard from. Defaults to the default branch of the repository.
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// Path is the path of the dashboard JSON file, relative to the root of the repository.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+}
+
+// DashboardFolderPermission grants a Grafana team or organization role access to a dashboard folder.
+// +kubebuilder:validation:XValidation:rule="has(self.team) != has(self.role)",message="exactly one of team or role must be set"
+type DashboardFolderPermission struct {
+	// Team is the name of a Grafana team.
+	// +optional
+	Team string `json:"team,omitempty"`
+
+	// Role is a Grafana organization role.
+	// +kubebuilder:validation:Enum=Viewer;Editor
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// Permission is the access level granted on the folder.
+	// +kubebuilder:validation:Enum=View;Edit;Admin
+	Permission string `json:"permission"`
+}
+
+// ObservabilityDashboardSpec defines the source and placement of a Grafana dashboard.
+// +kubebuilder:validation:XValidation:rule="[has(self.json), has(self.url), has(self.git)].filter(x, x).size() == 1",message="exactly one of json, url or git must be set"
+type ObservabilityDashboardSpec struct {
+	// JSON is the inline definition of the dashboard.
+	// +optional
+	JSON string `json:"json,omitempty"`
+
+	// URL is an HTTP or HTTPS URL serving the definition of the dashboard.
+	// Only public addresses, or the hosts allowed by the dashboard loader, can be fetched.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Git is the Git repository file holding the definition of the dashboard.
+	// +optional
+	Git *DashboardGitSource `json:"git,omitempty"`
+
+	// Folder is the title of the Grafana folder of the dashboard. Defaults to "Custom".
+	// Set it to "General" to place the dashboard in the General folder.
+	// +optional
+	Folder string `json:"folder,omitempty"`
+
+	// Permissions replace the default permissions of the folder of the dashboard, e.g. to make it
+	// private to a team. The permissions of a folder are owned by the oldest source declaring them,
+	// the permissions declared by the other sources of the folder are ignored and reported in the
+	// FolderPermissions condition. They are ignored for the General folder.
+	// +optional
+	Permissions []DashboardFolderPermission `json:"permissions,omitempty"`
+
+	// Home sets the dashboard as the Grafana home dashboard.
+	// +optional
+	Home bool `json:"home,omitempty"`
+
+	// ResyncPeriod is the interval at which the URL and Git sources are fetched again. Defaults to 10m.
+	// +optional
+	ResyncPeriod *metav1.Duration `json:"resyncPeriod,omitempty"`
+}
+
+// ObservabilityDashboardStatus defines the observed state of ObservabilityDashboard.
+type ObservabilityDashboardStatus struct {
+	// ObservedGeneration is the generation of the spec last loaded in Grafana.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// UID is the Grafana UID of the dashboard.
+	// +optional
+	UID string `json:"uid,omitempty"`
+
+	// LastSyncTime is the last time the dashboard was successfully loaded in Grafana.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Conditions describe the sync state of the dashboard, including the Grafana errors.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=observabilitydashboards,scope=Namespaced,shortName=obdash
+// +kubebuilder:printcolumn:name="Folder",type=string,JSONPath=`.spec.folder`
+// +kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +operator-sdk:csv:customresourcedefinitions:displayName="ObservabilityDashboard"
+
+// ObservabilityDashboard is a Grafana dashboard loaded in the hub Grafana by the dashboard loader.
+// Only the resources in the observability namespace are loaded.
+type ObservabilityDashboard struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ObservabilityDashboardSpec   `json:"spec,omitempty"`
+	Status ObservabilityDashboardStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ObservabilityDashboardList contains a list of ObservabilityDashboard
+type ObservabilityDashboardList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ObservabilityDashboard `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ObservabilityDashboard{}, &ObservabilityDashboardList{})
+}
@@ -0,0 +1,112 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MaintenanceWindowActiveCondition reports whether the alerts of the managed clusters are silenced, with the
+	// schedule and the validation errors of the window.
+	MaintenanceWindowActiveCondition = "Active"
+)
+
+// ObservabilityMaintenanceWindowSpec defines the managed clusters under maintenance and when.
+// +kubebuilder:validation:XValidation:rule="has(self.schedule) != has(self.startTime)",message="exactly one of schedule or startTime must be set"
+// +kubebuilder:validation:XValidation:rule="!has(self.schedule) || has(self.duration)",message="duration is required with schedule"
+// +kubebuilder:validation:XValidation:rule="!has(self.startTime) || has(self.endTime)",message="endTime is required with startTime"
+// +kubebuilder:validation:XValidation:rule="has(self.clusterSets) || has(self.clusterSelector)",message="at least one of clusterSets or clusterSelector must be set"
+type ObservabilityMaintenanceWindowSpec struct {
+	// ClusterSets silences the alerts of the managed clusters of the given ManagedClusterSets.
+	// +optional
+	ClusterSets []string `json:"clusterSets,omitempty"`
+
+	// ClusterSelector silences the alerts of the managed clusters selected by label.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// Schedule is the cron schedule of the start of the windows, e.g. "0 2 * * 6" for every Saturday at 2:00.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// TimeZone is the time zone of the schedule, e.g. "Europe/Paris". Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Duration of the windows started by the schedule.
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	// +optional
+	Duration string `json:"duration,omitempty"`
+
+	// StartTime is the start of a one-off window.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// EndTime is the end of a one-off window.
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// Comment is added to the Alertmanager silences, e.g. the reason of the maintenance.
+	// +optional
+	Comment string `json:"comment,omitempty"`
+}
+
+// ObservabilityMaintenanceWindowStatus defines the observed state of ObservabilityMaintenanceWindow.
+type ObservabilityMaintenanceWindowStatus struct {
+	// ObservedGeneration is the generation of the spec last processed by the operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// MatchedClusters is the number of managed clusters under maintenance.
+	// +optional
+	MatchedClusters int32 `json:"matchedClusters"`
+
+	// ActiveUntil is the end of the window in progress.
+	// +optional
+	ActiveUntil *metav1.Time `json:"activeUntil,omitempty"`
+
+	// NextWindowStart is the start of the next window.
+	// +optional
+	NextWindowStart *metav1.Time `json:"nextWindowStart,omitempty"`
+
+	// Conditions describe the state of the window, including the validation errors.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=observabilitymaintenancewindows,scope=Cluster,shortName=obmw
+// +kubebuilder:printcolumn:name="Clusters",type=integer,JSONPath=`.status.matchedClusters`
+// +kubebuilder:printcolumn:name="Active",type=string,JSONPath=`.status.conditions[?(@.type=="Active")].status`
+// +kubebuilder:printcolumn:name="Until",type=date,JSONPath=`.status.activeUntil`
+// +kubebuilder:printcolumn:name="Next",type=date,JSONPath=`.status.nextWindowStart`
+// +operator-sdk:csv:customresourcedefinitions:displayName="ObservabilityMaintenanceWindow"
+
+// ObservabilityMaintenanceWindow schedules maintenance windows of managed clusters, during which the hub
+// Alertmanager silences their alerts.
+type ObservabilityMaintenanceWindow struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ObservabilityMaintenanceWindowSpec   `json:"spec,omitempty"`
+	Status ObservabilityMaintenanceWindowStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ObservabilityMaintenanceWindowList contains a list of ObservabilityMaintenanceWindow
+type ObservabilityMaintenanceWindowList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ObservabilityMaintenanceWindow `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ObservabilityMaintenanceWindow{}, &ObservabilityMaintenanceWindowList{})
+}
@@ -0,0 +1,126 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RuleLoadedCondition reports whether the rules were loaded in thanos-rule, with the validation errors.
+	RuleLoadedCondition = "Loaded"
+)
+
+// ObservabilityRuleGroup is a group of rules evaluated sequentially, like a PrometheusRule group.
+type ObservabilityRuleGroup struct {
+	// Name of the group, unique in the ObservabilityRule.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Interval is the evaluation interval of the group. Defaults to the thanos-rule evaluation interval.
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	// +optional
+	Interval string `json:"interval,omitempty"`
+
+	// Rules are the recording and alerting rules of the group.
+	// +kubebuilder:validation:MinItems=1
+	Rules []ObservabilityRuleItem `json:"rules"`
+}
+
+// ObservabilityRuleItem is a recording or alerting rule.
+// +kubebuilder:validation:XValidation:rule="has(self.record) != has(self.alert)",message="exactly one of record or alert must be set"
+type ObservabilityRuleItem struct {
+	// Record is the name of the time series recorded by a recording rule.
+	// +optional
+	Record string `json:"record,omitempty"`
+
+	// Alert is the name of the alert of an alerting rule.
+	// +optional
+	Alert string `json:"alert,omitempty"`
+
+	// Expr is the PromQL expression of the rule. The operator restricts its selectors to the metrics
+	// of the managed clusters targeted by the ObservabilityRule.
+	// +kubebuilder:validation:MinLength=1
+	Expr string `json:"expr"`
+
+	// For is how long the expression of an alerting rule must be true before the alert fires.
+	// +kubebuilder:validation:Pattern=`^(0|(([0-9]+)y)?(([0-9]+)w)?(([0-9]+)d)?(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$`
+	// +optional
+	For string `json:"for,omitempty"`
+
+	// Labels are added to the recorded series or to the alerts.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to the alerts.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ObservabilityRuleSpec defines the rules evaluated by thanos-rule and the managed clusters they target.
+type ObservabilityRuleSpec struct {
+	// ClusterSets restricts the rules to the metrics of the managed clusters of the given ManagedClusterSets.
+	// +optional
+	ClusterSets []string `json:"clusterSets,omitempty"`
+
+	// ClusterSelector restricts the rules to the metrics of the managed clusters selected by label.
+	// When neither clusterSets nor clusterSelector is set, the rules target all the managed clusters.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// Groups are the rule groups, in the PrometheusRule format.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=name
+	Groups []ObservabilityRuleGroup `json:"groups"`
+}
+
+// ObservabilityRuleStatus defines the observed state of ObservabilityRule.
+type ObservabilityRuleStatus struct {
+	// ObservedGeneration is the generation of the spec last processed by the operator.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// MatchedClusters is the number of managed clusters targeted by the rules.
+	// +optional
+	MatchedClusters int32 `json:"matchedClusters"`
+
+	// Conditions describe the load state of the rules, including the validation errors.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=observabilityrules,scope=Namespaced,shortName=obrule
+// +kubebuilder:printcolumn:name="Clusters",type=integer,JSONPath=`.status.matchedClusters`
+// +kubebuilder:printcolumn:name="Loaded",type=string,JSONPath=`.status.conditions[?(@.type=="Loaded")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +operator-sdk:csv:customresourcedefinitions:displayName="ObservabilityRule"
+
+// ObservabilityRule is a set of recording and alerting rules evaluated by the hub thanos-rule
+// against the metrics of the targeted managed clusters.
+type ObservabilityRule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ObservabilityRuleSpec   `json:"spec,omitempty"`
+	Status ObservabilityRuleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ObservabilityRuleList contains a list of ObservabilityRule
+type ObservabilityRuleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ObservabilityRule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ObservabilityRule{}, &ObservabilityRuleList{})
+}
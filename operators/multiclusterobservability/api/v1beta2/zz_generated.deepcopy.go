@@ -23,9 +23,41 @@ package v1beta2
 import (
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSWorkloadIdentity) DeepCopyInto(out *AWSWorkloadIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSWorkloadIdentity.
+func (in *AWSWorkloadIdentity) DeepCopy() *AWSWorkloadIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSWorkloadIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveMaintenanceWindow) DeepCopyInto(out *ActiveMaintenanceWindow) {
+	*out = *in
+	in.EndsAt.DeepCopyInto(&out.EndsAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMaintenanceWindow.
+func (in *ActiveMaintenanceWindow) DeepCopy() *ActiveMaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ActiveMaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AddonManagerSpec) DeepCopyInto(out *AddonManagerSpec) {
 	*out = *in
@@ -51,6 +83,54 @@ func (in *AddonManagerSpec) DeepCopy() *AddonManagerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonRolloutStatus) DeepCopyInto(out *AddonRolloutStatus) {
+	*out = *in
+	if in.UnhealthyClusters != nil {
+		in, out := &in.UnhealthyClusters, &out.UnhealthyClusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BatchStartTime != nil {
+		in, out := &in.BatchStartTime, &out.BatchStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.BatchHealthyTime != nil {
+		in, out := &in.BatchHealthyTime, &out.BatchHealthyTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonRolloutStatus.
+func (in *AddonRolloutStatus) DeepCopy() *AddonRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonRolloutStrategy) DeepCopyInto(out *AddonRolloutStrategy) {
+	*out = *in
+	if in.CanaryClusterSelector != nil {
+		in, out := &in.CanaryClusterSelector, &out.CanaryClusterSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonRolloutStrategy.
+func (in *AddonRolloutStrategy) DeepCopy() *AddonRolloutStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonRolloutStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AdvancedConfig) DeepCopyInto(out *AdvancedConfig) {
 	*out = *in
@@ -136,6 +216,161 @@ func (in *AdvancedConfig) DeepCopy() *AdvancedConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertEmailConfig) DeepCopyInto(out *AlertEmailConfig) {
+	*out = *in
+	if in.SendResolved != nil {
+		in, out := &in.SendResolved, &out.SendResolved
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertEmailConfig.
+func (in *AlertEmailConfig) DeepCopy() *AlertEmailConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertEmailConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertPagerDutyConfig) DeepCopyInto(out *AlertPagerDutyConfig) {
+	*out = *in
+	in.RoutingKeySecret.DeepCopyInto(&out.RoutingKeySecret)
+	if in.SendResolved != nil {
+		in, out := &in.SendResolved, &out.SendResolved
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertPagerDutyConfig.
+func (in *AlertPagerDutyConfig) DeepCopy() *AlertPagerDutyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertPagerDutyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertReceiver) DeepCopyInto(out *AlertReceiver) {
+	*out = *in
+	if in.WebhookConfigs != nil {
+		in, out := &in.WebhookConfigs, &out.WebhookConfigs
+		*out = make([]AlertWebhookConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SlackConfigs != nil {
+		in, out := &in.SlackConfigs, &out.SlackConfigs
+		*out = make([]AlertSlackConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PagerDutyConfigs != nil {
+		in, out := &in.PagerDutyConfigs, &out.PagerDutyConfigs
+		*out = make([]AlertPagerDutyConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EmailConfigs != nil {
+		in, out := &in.EmailConfigs, &out.EmailConfigs
+		*out = make([]AlertEmailConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertReceiver.
+func (in *AlertReceiver) DeepCopy() *AlertReceiver {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertReceiver)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertSlackConfig) DeepCopyInto(out *AlertSlackConfig) {
+	*out = *in
+	in.APIURLSecret.DeepCopyInto(&out.APIURLSecret)
+	if in.SendResolved != nil {
+		in, out := &in.SendResolved, &out.SendResolved
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertSlackConfig.
+func (in *AlertSlackConfig) DeepCopy() *AlertSlackConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertSlackConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertSubRoute) DeepCopyInto(out *AlertSubRoute) {
+	*out = *in
+	if in.Matchers != nil {
+		in, out := &in.Matchers, &out.Matchers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GroupBy != nil {
+		in, out := &in.GroupBy, &out.GroupBy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertSubRoute.
+func (in *AlertSubRoute) DeepCopy() *AlertSubRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertSubRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertWebhookConfig) DeepCopyInto(out *AlertWebhookConfig) {
+	*out = *in
+	if in.URLSecret != nil {
+		in, out := &in.URLSecret, &out.URLSecret
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SendResolved != nil {
+		in, out := &in.SendResolved, &out.SendResolved
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertWebhookConfig.
+func (in *AlertWebhookConfig) DeepCopy() *AlertWebhookConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertWebhookConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AlertmanagerSpec) DeepCopyInto(out *AlertmanagerSpec) {
 	*out = *in
@@ -157,6 +392,36 @@ func (in *AlertmanagerSpec) DeepCopy() *AlertmanagerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppliedStorageConfig) DeepCopyInto(out *AppliedStorageConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppliedStorageConfig.
+func (in *AppliedStorageConfig) DeepCopy() *AppliedStorageConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AppliedStorageConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureWorkloadIdentity) DeepCopyInto(out *AzureWorkloadIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureWorkloadIdentity.
+func (in *AzureWorkloadIdentity) DeepCopy() *AzureWorkloadIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureWorkloadIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CacheConfig) DeepCopyInto(out *CacheConfig) {
 	*out = *in
@@ -213,6 +478,53 @@ func (in *CapabilitiesSpec) DeepCopy() *CapabilitiesSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerIssuerReference) DeepCopyInto(out *CertManagerIssuerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerIssuerReference.
+func (in *CertManagerIssuerReference) DeepCopy() *CertManagerIssuerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerIssuerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerSpec) DeepCopyInto(out *CertManagerSpec) {
+	*out = *in
+	out.ServerIssuer = in.ServerIssuer
+	out.ClientIssuer = in.ClientIssuer
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerSpec.
+func (in *CertManagerSpec) DeepCopy() *CertManagerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificatesSpec) DeepCopyInto(out *CertificatesSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificatesSpec.
+func (in *CertificatesSpec) DeepCopy() *CertificatesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificatesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterLogForwarderSpec) DeepCopyInto(out *ClusterLogForwarderSpec) {
 	*out = *in
@@ -261,145 +573,817 @@ func (in *CompactDebugSpec) DeepCopyInto(out *CompactDebugSpec) {
 		*out = new(int32)
 		**out = **in
 	}
-	if in.DownsampleConcurrency != nil {
-		in, out := &in.DownsampleConcurrency, &out.DownsampleConcurrency
-		*out = new(int32)
-		**out = **in
+	if in.DownsampleConcurrency != nil {
+		in, out := &in.DownsampleConcurrency, &out.DownsampleConcurrency
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompactDebugSpec.
+func (in *CompactDebugSpec) DeepCopy() *CompactDebugSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CompactDebugSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompactSpec) DeepCopyInto(out *CompactSpec) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccountAnnotations != nil {
+		in, out := &in.ServiceAccountAnnotations, &out.ServiceAccountAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Debug != nil {
+		in, out := &in.Debug, &out.Debug
+		*out = new(CompactDebugSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompactSpec.
+func (in *CompactSpec) DeepCopy() *CompactSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CompactSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DashboardFolderPermission) DeepCopyInto(out *DashboardFolderPermission) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DashboardFolderPermission.
+func (in *DashboardFolderPermission) DeepCopy() *DashboardFolderPermission {
+	if in == nil {
+		return nil
+	}
+	out := new(DashboardFolderPermission)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DashboardGitSource) DeepCopyInto(out *DashboardGitSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DashboardGitSource.
+func (in *DashboardGitSource) DeepCopy() *DashboardGitSource {
+	if in == nil {
+		return nil
+	}
+	out := new(DashboardGitSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationPeer) DeepCopyInto(out *FederationPeer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationPeer.
+func (in *FederationPeer) DeepCopy() *FederationPeer {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationPeer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationSpec) DeepCopyInto(out *FederationSpec) {
+	*out = *in
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]FederationPeer, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationSpec.
+func (in *FederationSpec) DeepCopy() *FederationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPWorkloadIdentity) DeepCopyInto(out *GCPWorkloadIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPWorkloadIdentity.
+func (in *GCPWorkloadIdentity) DeepCopy() *GCPWorkloadIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPWorkloadIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstrumentationSpec) DeepCopyInto(out *InstrumentationSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstrumentationSpec.
+func (in *InstrumentationSpec) DeepCopy() *InstrumentationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(InstrumentationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsAlertsSpec) DeepCopyInto(out *MetricsAlertsSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsAlertsSpec.
+func (in *MetricsAlertsSpec) DeepCopy() *MetricsAlertsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsAlertsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultiClusterObservability) DeepCopyInto(out *MultiClusterObservability) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiClusterObservability.
+func (in *MultiClusterObservability) DeepCopy() *MultiClusterObservability {
+	if in == nil {
+		return nil
+	}
+	out := new(MultiClusterObservability)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MultiClusterObservability) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultiClusterObservabilityList) DeepCopyInto(out *MultiClusterObservabilityList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MultiClusterObservability, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiClusterObservabilityList.
+func (in *MultiClusterObservabilityList) DeepCopy() *MultiClusterObservabilityList {
+	if in == nil {
+		return nil
+	}
+	out := new(MultiClusterObservabilityList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MultiClusterObservabilityList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultiClusterObservabilitySpec) DeepCopyInto(out *MultiClusterObservabilitySpec) {
+	*out = *in
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = new(CapabilitiesSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdvancedConfig != nil {
+		in, out := &in.AdvancedConfig, &out.AdvancedConfig
+		*out = new(AdvancedConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StorageConfig != nil {
+		in, out := &in.StorageConfig, &out.StorageConfig
+		*out = new(StorageConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ObservabilityAddonSpec != nil {
+		in, out := &in.ObservabilityAddonSpec, &out.ObservabilityAddonSpec
+		*out = new(shared.ObservabilityAddonSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tenants != nil {
+		in, out := &in.Tenants, &out.Tenants
+		*out = make([]ObservabilityTenant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Federation != nil {
+		in, out := &in.Federation, &out.Federation
+		*out = new(FederationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AddonRollout != nil {
+		in, out := &in.AddonRollout, &out.AddonRollout
+		*out = new(AddonRolloutStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CertManager != nil {
+		in, out := &in.CertManager, &out.CertManager
+		*out = new(CertManagerSpec)
+		**out = **in
+	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = new(CertificatesSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiClusterObservabilitySpec.
+func (in *MultiClusterObservabilitySpec) DeepCopy() *MultiClusterObservabilitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MultiClusterObservabilitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultiClusterObservabilityStatus) DeepCopyInto(out *MultiClusterObservabilityStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]shared.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(StorageStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]ActiveMaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AddonRollout != nil {
+		in, out := &in.AddonRollout, &out.AddonRollout
+		*out = new(AddonRolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiClusterObservabilityStatus.
+func (in *MultiClusterObservabilityStatus) DeepCopy() *MultiClusterObservabilityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MultiClusterObservabilityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilityAlertRoute) DeepCopyInto(out *ObservabilityAlertRoute) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityAlertRoute.
+func (in *ObservabilityAlertRoute) DeepCopy() *ObservabilityAlertRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilityAlertRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObservabilityAlertRoute) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilityAlertRouteList) DeepCopyInto(out *ObservabilityAlertRouteList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ObservabilityAlertRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityAlertRouteList.
+func (in *ObservabilityAlertRouteList) DeepCopy() *ObservabilityAlertRouteList {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilityAlertRouteList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObservabilityAlertRouteList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilityAlertRouteSpec) DeepCopyInto(out *ObservabilityAlertRouteSpec) {
+	*out = *in
+	if in.ClusterSets != nil {
+		in, out := &in.ClusterSets, &out.ClusterSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GroupBy != nil {
+		in, out := &in.GroupBy, &out.GroupBy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]AlertSubRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Receivers != nil {
+		in, out := &in.Receivers, &out.Receivers
+		*out = make([]AlertReceiver, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityAlertRouteSpec.
+func (in *ObservabilityAlertRouteSpec) DeepCopy() *ObservabilityAlertRouteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilityAlertRouteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilityAlertRouteStatus) DeepCopyInto(out *ObservabilityAlertRouteStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityAlertRouteStatus.
+func (in *ObservabilityAlertRouteStatus) DeepCopy() *ObservabilityAlertRouteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilityAlertRouteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilityDashboard) DeepCopyInto(out *ObservabilityDashboard) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityDashboard.
+func (in *ObservabilityDashboard) DeepCopy() *ObservabilityDashboard {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilityDashboard)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObservabilityDashboard) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilityDashboardList) DeepCopyInto(out *ObservabilityDashboardList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ObservabilityDashboard, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityDashboardList.
+func (in *ObservabilityDashboardList) DeepCopy() *ObservabilityDashboardList {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilityDashboardList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObservabilityDashboardList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilityDashboardSpec) DeepCopyInto(out *ObservabilityDashboardSpec) {
+	*out = *in
+	if in.Git != nil {
+		in, out := &in.Git, &out.Git
+		*out = new(DashboardGitSource)
+		**out = **in
+	}
+	if in.Permissions != nil {
+		in, out := &in.Permissions, &out.Permissions
+		*out = make([]DashboardFolderPermission, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResyncPeriod != nil {
+		in, out := &in.ResyncPeriod, &out.ResyncPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityDashboardSpec.
+func (in *ObservabilityDashboardSpec) DeepCopy() *ObservabilityDashboardSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilityDashboardSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilityDashboardStatus) DeepCopyInto(out *ObservabilityDashboardStatus) {
+	*out = *in
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityDashboardStatus.
+func (in *ObservabilityDashboardStatus) DeepCopy() *ObservabilityDashboardStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilityDashboardStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilityMaintenanceWindow) DeepCopyInto(out *ObservabilityMaintenanceWindow) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityMaintenanceWindow.
+func (in *ObservabilityMaintenanceWindow) DeepCopy() *ObservabilityMaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilityMaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObservabilityMaintenanceWindow) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilityMaintenanceWindowList) DeepCopyInto(out *ObservabilityMaintenanceWindowList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ObservabilityMaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityMaintenanceWindowList.
+func (in *ObservabilityMaintenanceWindowList) DeepCopy() *ObservabilityMaintenanceWindowList {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilityMaintenanceWindowList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObservabilityMaintenanceWindowList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilityMaintenanceWindowSpec) DeepCopyInto(out *ObservabilityMaintenanceWindowSpec) {
+	*out = *in
+	if in.ClusterSets != nil {
+		in, out := &in.ClusterSets, &out.ClusterSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompactDebugSpec.
-func (in *CompactDebugSpec) DeepCopy() *CompactDebugSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityMaintenanceWindowSpec.
+func (in *ObservabilityMaintenanceWindowSpec) DeepCopy() *ObservabilityMaintenanceWindowSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CompactDebugSpec)
+	out := new(ObservabilityMaintenanceWindowSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CompactSpec) DeepCopyInto(out *CompactSpec) {
+func (in *ObservabilityMaintenanceWindowStatus) DeepCopyInto(out *ObservabilityMaintenanceWindowStatus) {
 	*out = *in
-	if in.Resources != nil {
-		in, out := &in.Resources, &out.Resources
-		*out = new(v1.ResourceRequirements)
-		(*in).DeepCopyInto(*out)
+	if in.ActiveUntil != nil {
+		in, out := &in.ActiveUntil, &out.ActiveUntil
+		*out = (*in).DeepCopy()
 	}
-	if in.ServiceAccountAnnotations != nil {
-		in, out := &in.ServiceAccountAnnotations, &out.ServiceAccountAnnotations
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.NextWindowStart != nil {
+		in, out := &in.NextWindowStart, &out.NextWindowStart
+		*out = (*in).DeepCopy()
 	}
-	if in.Containers != nil {
-		in, out := &in.Containers, &out.Containers
-		*out = make([]v1.Container, len(*in))
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.Debug != nil {
-		in, out := &in.Debug, &out.Debug
-		*out = new(CompactDebugSpec)
-		(*in).DeepCopyInto(*out)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompactSpec.
-func (in *CompactSpec) DeepCopy() *CompactSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityMaintenanceWindowStatus.
+func (in *ObservabilityMaintenanceWindowStatus) DeepCopy() *ObservabilityMaintenanceWindowStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(CompactSpec)
+	out := new(ObservabilityMaintenanceWindowStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *InstrumentationSpec) DeepCopyInto(out *InstrumentationSpec) {
+func (in *ObservabilityRule) DeepCopyInto(out *ObservabilityRule) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstrumentationSpec.
-func (in *InstrumentationSpec) DeepCopy() *InstrumentationSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityRule.
+func (in *ObservabilityRule) DeepCopy() *ObservabilityRule {
 	if in == nil {
 		return nil
 	}
-	out := new(InstrumentationSpec)
+	out := new(ObservabilityRule)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ObservabilityRule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MetricsAlertsSpec) DeepCopyInto(out *MetricsAlertsSpec) {
+func (in *ObservabilityRuleGroup) DeepCopyInto(out *ObservabilityRuleGroup) {
 	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]ObservabilityRuleItem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsAlertsSpec.
-func (in *MetricsAlertsSpec) DeepCopy() *MetricsAlertsSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityRuleGroup.
+func (in *ObservabilityRuleGroup) DeepCopy() *ObservabilityRuleGroup {
 	if in == nil {
 		return nil
 	}
-	out := new(MetricsAlertsSpec)
+	out := new(ObservabilityRuleGroup)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MultiClusterObservability) DeepCopyInto(out *MultiClusterObservability) {
+func (in *ObservabilityRuleItem) DeepCopyInto(out *ObservabilityRuleItem) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiClusterObservability.
-func (in *MultiClusterObservability) DeepCopy() *MultiClusterObservability {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityRuleItem.
+func (in *ObservabilityRuleItem) DeepCopy() *ObservabilityRuleItem {
 	if in == nil {
 		return nil
 	}
-	out := new(MultiClusterObservability)
+	out := new(ObservabilityRuleItem)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MultiClusterObservability) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MultiClusterObservabilityList) DeepCopyInto(out *MultiClusterObservabilityList) {
+func (in *ObservabilityRuleList) DeepCopyInto(out *ObservabilityRuleList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]MultiClusterObservability, len(*in))
+		*out = make([]ObservabilityRule, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiClusterObservabilityList.
-func (in *MultiClusterObservabilityList) DeepCopy() *MultiClusterObservabilityList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityRuleList.
+func (in *ObservabilityRuleList) DeepCopy() *ObservabilityRuleList {
 	if in == nil {
 		return nil
 	}
-	out := new(MultiClusterObservabilityList)
+	out := new(ObservabilityRuleList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MultiClusterObservabilityList) DeepCopyObject() runtime.Object {
+func (in *ObservabilityRuleList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -407,72 +1391,55 @@ func (in *MultiClusterObservabilityList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MultiClusterObservabilitySpec) DeepCopyInto(out *MultiClusterObservabilitySpec) {
+func (in *ObservabilityRuleSpec) DeepCopyInto(out *ObservabilityRuleSpec) {
 	*out = *in
-	if in.Capabilities != nil {
-		in, out := &in.Capabilities, &out.Capabilities
-		*out = new(CapabilitiesSpec)
-		(*in).DeepCopyInto(*out)
+	if in.ClusterSets != nil {
+		in, out := &in.ClusterSets, &out.ClusterSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.AdvancedConfig != nil {
-		in, out := &in.AdvancedConfig, &out.AdvancedConfig
-		*out = new(AdvancedConfig)
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(metav1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]ObservabilityRuleGroup, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.StorageConfig != nil {
-		in, out := &in.StorageConfig, &out.StorageConfig
-		*out = new(StorageConfig)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.ObservabilityAddonSpec != nil {
-		in, out := &in.ObservabilityAddonSpec, &out.ObservabilityAddonSpec
-		*out = new(shared.ObservabilityAddonSpec)
-		(*in).DeepCopyInto(*out)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiClusterObservabilitySpec.
-func (in *MultiClusterObservabilitySpec) DeepCopy() *MultiClusterObservabilitySpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityRuleSpec.
+func (in *ObservabilityRuleSpec) DeepCopy() *ObservabilityRuleSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MultiClusterObservabilitySpec)
+	out := new(ObservabilityRuleSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MultiClusterObservabilityStatus) DeepCopyInto(out *MultiClusterObservabilityStatus) {
+func (in *ObservabilityRuleStatus) DeepCopyInto(out *ObservabilityRuleStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]shared.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiClusterObservabilityStatus.
-func (in *MultiClusterObservabilityStatus) DeepCopy() *MultiClusterObservabilityStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityRuleStatus.
+func (in *ObservabilityRuleStatus) DeepCopy() *ObservabilityRuleStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MultiClusterObservabilityStatus)
+	out := new(ObservabilityRuleStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -509,6 +1476,36 @@ func (in *OpenTelemetryCollectorSpec) DeepCopy() *OpenTelemetryCollectorSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilityTenant) DeepCopyInto(out *ObservabilityTenant) {
+	*out = *in
+	if in.ClusterSets != nil {
+		in, out := &in.ClusterSets, &out.ClusterSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(TenantRetentionConfig)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityTenant.
+func (in *ObservabilityTenant) DeepCopy() *ObservabilityTenant {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilityTenant)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlatformAnalyticsSpec) DeepCopyInto(out *PlatformAnalyticsSpec) {
 	*out = *in
@@ -707,6 +1704,58 @@ func (in *ReceiveDebugSpec) DeepCopy() *ReceiveDebugSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReceiveGlobalLimits) DeepCopyInto(out *ReceiveGlobalLimits) {
+	*out = *in
+	if in.MaxConcurrency != nil {
+		in, out := &in.MaxConcurrency, &out.MaxConcurrency
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReceiveGlobalLimits.
+func (in *ReceiveGlobalLimits) DeepCopy() *ReceiveGlobalLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(ReceiveGlobalLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReceiveLimits) DeepCopyInto(out *ReceiveLimits) {
+	*out = *in
+	if in.Global != nil {
+		in, out := &in.Global, &out.Global
+		*out = new(ReceiveGlobalLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(ReceiveTenantLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tenants != nil {
+		in, out := &in.Tenants, &out.Tenants
+		*out = make([]ReceiveTenantLimitsOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReceiveLimits.
+func (in *ReceiveLimits) DeepCopy() *ReceiveLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(ReceiveLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReceiveSpec) DeepCopyInto(out *ReceiveSpec) {
 	*out = *in
@@ -729,6 +1778,11 @@ func (in *ReceiveSpec) DeepCopyInto(out *ReceiveSpec) {
 		*out = new(ReceiveDebugSpec)
 		**out = **in
 	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(ReceiveLimits)
+		(*in).DeepCopyInto(*out)
+	}
 	in.CommonSpec.DeepCopyInto(&out.CommonSpec)
 }
 
@@ -742,6 +1796,57 @@ func (in *ReceiveSpec) DeepCopy() *ReceiveSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReceiveTenantLimits) DeepCopyInto(out *ReceiveTenantLimits) {
+	*out = *in
+	if in.RequestSizeBytesLimit != nil {
+		in, out := &in.RequestSizeBytesLimit, &out.RequestSizeBytesLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RequestSeriesLimit != nil {
+		in, out := &in.RequestSeriesLimit, &out.RequestSeriesLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RequestSamplesLimit != nil {
+		in, out := &in.RequestSamplesLimit, &out.RequestSamplesLimit
+		*out = new(int64)
+		**out = **in
+	}
+	if in.HeadSeriesLimit != nil {
+		in, out := &in.HeadSeriesLimit, &out.HeadSeriesLimit
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReceiveTenantLimits.
+func (in *ReceiveTenantLimits) DeepCopy() *ReceiveTenantLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(ReceiveTenantLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReceiveTenantLimitsOverride) DeepCopyInto(out *ReceiveTenantLimitsOverride) {
+	*out = *in
+	in.ReceiveTenantLimits.DeepCopyInto(&out.ReceiveTenantLimits)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReceiveTenantLimitsOverride.
+func (in *ReceiveTenantLimitsOverride) DeepCopy() *ReceiveTenantLimitsOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ReceiveTenantLimitsOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RetentionConfig) DeepCopyInto(out *RetentionConfig) {
 	*out = *in
@@ -806,6 +1911,11 @@ func (in *StorageConfig) DeepCopyInto(out *StorageConfig) {
 			}
 		}
 	}
+	if in.WorkloadIdentity != nil {
+		in, out := &in.WorkloadIdentity, &out.WorkloadIdentity
+		*out = new(WorkloadIdentityConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageConfig.
@@ -818,6 +1928,31 @@ func (in *StorageConfig) DeepCopy() *StorageConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageStatus) DeepCopyInto(out *StorageStatus) {
+	*out = *in
+	if in.Applied != nil {
+		in, out := &in.Applied, &out.Applied
+		*out = new(AppliedStorageConfig)
+		**out = **in
+	}
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = new(AppliedStorageConfig)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageStatus.
+func (in *StorageStatus) DeepCopy() *StorageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StoreSpec) DeepCopyInto(out *StoreSpec) {
 	*out = *in
@@ -848,6 +1983,21 @@ func (in *StoreSpec) DeepCopy() *StoreSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantRetentionConfig) DeepCopyInto(out *TenantRetentionConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantRetentionConfig.
+func (in *TenantRetentionConfig) DeepCopy() *TenantRetentionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantRetentionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UIConfig) DeepCopyInto(out *UIConfig) {
 	*out = *in
@@ -960,3 +2110,33 @@ func (in *UserWorkloadTracesSpec) DeepCopy() *UserWorkloadTracesSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentityConfig) DeepCopyInto(out *WorkloadIdentityConfig) {
+	*out = *in
+	if in.AWS != nil {
+		in, out := &in.AWS, &out.AWS
+		*out = new(AWSWorkloadIdentity)
+		**out = **in
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(AzureWorkloadIdentity)
+		**out = **in
+	}
+	if in.GCP != nil {
+		in, out := &in.GCP, &out.GCP
+		*out = new(GCPWorkloadIdentity)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentityConfig.
+func (in *WorkloadIdentityConfig) DeepCopy() *WorkloadIdentityConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentityConfig)
+	in.DeepCopyInto(out)
+	return out
+}
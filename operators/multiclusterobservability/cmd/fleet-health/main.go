@@ -0,0 +1,113 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+/*
+CLI printing the observability health report of the managed clusters of the hub, as served by the /fleet-health
+endpoint of the operator, with the permissions of the current kubeconfig user. The last samples are queried through
+the rbac-query-proxy route with the token of the user, so only the clusters whose metrics the user can read have one.
+*/
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	mcov1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/fleethealth"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/transport"
+	"k8s.io/klog/v2"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func main() {
+	var format, queryURL, token string
+	var insecureSkipTLSVerify bool
+	flag.StringVar(&format, "format", fleethealth.FormatJSON, "The format of the report: json or csv.")
+	flag.StringVar(&queryURL, "query-url", "",
+		"The URL of the metrics query API (optional). Defaults to the rbac-query-proxy route of the hub.")
+	flag.StringVar(&token, "token", "",
+		"The bearer token of the metrics query API (optional). Defaults to the token of the kubeconfig.")
+	flag.BoolVar(&insecureSkipTLSVerify, "insecure-skip-tls-verify", false,
+		"Skip the verification of the certificate of the metrics query API.")
+
+	klog.InitFlags(flag.CommandLine)
+	flag.Parse()
+	ctrl.SetLogger(klog.NewKlogr())
+
+	if err := run(format, queryURL, token, insecureSkipTLSVerify); err != nil {
+		log.Fatalf("fleet health report failed: %v", err)
+	}
+}
+
+func run(format, queryURL, token string, insecureSkipTLSVerify bool) error {
+	if format != fleethealth.FormatJSON && format != fleethealth.FormatCSV {
+		return fmt.Errorf("unsupported format %q, expected json or csv", format)
+	}
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clusterv1.Install(scheme))
+	utilruntime.Must(workv1.Install(scheme))
+	utilruntime.Must(mcov1beta1.AddToScheme(scheme))
+	utilruntime.Must(routev1.Install(scheme))
+
+	restConfig := ctrl.GetConfigOrDie()
+	kubeClient, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("unable to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if queryURL == "" {
+		route := &routev1.Route{}
+		if err := kubeClient.Get(ctx, types.NamespacedName{Name: config.ProxyRouteName, Namespace: config.GetDefaultNamespace()}, route); err != nil {
+			log.Printf("the last samples are not reported, the route %s is not found: %v", config.ProxyRouteName, err)
+		} else {
+			queryURL = "https://" + route.Spec.Host
+		}
+	}
+
+	var samples fleethealth.SampleSource
+	if queryURL != "" {
+		tokenFile := ""
+		if token == "" {
+			token, tokenFile = restConfig.BearerToken, restConfig.BearerTokenFile
+		}
+		rt, err := transport.NewBearerAuthWithRefreshRoundTripper(token, tokenFile, &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			// #nosec G402 -- the verification is only skipped when requested by the user.
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipTLSVerify, MinVersion: tls.VersionTLS12},
+		})
+		if err != nil {
+			return fmt.Errorf("unable to create the query transport: %w", err)
+		}
+		if samples, err = fleethealth.NewQuerySampleSource(queryURL, rt); err != nil {
+			return err
+		}
+	}
+
+	report, err := fleethealth.Build(ctx, kubeClient, samples, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, warning := range report.Warnings {
+		log.Printf("warning: %s", warning)
+	}
+	return report.Write(os.Stdout, format)
+}
@@ -102,7 +102,7 @@ func GenerateGrafanaDataSource(
 	}
 	queryTimeoutSec := strconv.Itoa(secs)
 
-	grafanaDatasources, err := yaml.Marshal(GrafanaDatasources{
+	datasources := GrafanaDatasources{
 		APIVersion: 1,
 		Datasources: []*GrafanaDatasource{
 			{
@@ -142,7 +142,50 @@ func GenerateGrafanaDataSource(
 				},
 			},
 		},
-	})
+	}
+	// The rbac-query-proxy queries the tenant given in the path, the default tenant otherwise.
+	for _, tenant := range mco.Spec.Tenants {
+		datasources.Datasources = append(datasources.Datasources, &GrafanaDatasource{
+			Name:   "Observatorium-" + tenant.Name,
+			Type:   "prometheus",
+			Access: "proxy",
+			URL: fmt.Sprintf(
+				"http://%s.%s.svc.cluster.local:8080/tenants/%s",
+				config.ProxyServiceName,
+				config.GetDefaultNamespace(),
+				tenant.Name,
+			),
+			UID: "tenant-" + tenant.Name,
+			JSONData: &JsonData{
+				Timeout:               queryTimeoutSec,
+				CustomQueryParameters: "max_source_resolution=auto",
+				TimeInterval:          fmt.Sprintf("%ds", mco.Spec.ObservabilityAddonSpec.Interval),
+				ForwardHeaders:        []string{"X-Forwarded-Access-Token"},
+			},
+		})
+	}
+	// The rbac-query-proxy fans the federated queries out to the peer hubs, labeling the series with their hub.
+	if mco.Spec.Federation != nil {
+		datasources.Datasources = append(datasources.Datasources, &GrafanaDatasource{
+			Name:   "Observatorium-Global",
+			Type:   "prometheus",
+			Access: "proxy",
+			URL: fmt.Sprintf(
+				"http://%s.%s.svc.cluster.local:8080/federation",
+				config.ProxyServiceName,
+				config.GetDefaultNamespace(),
+			),
+			UID: "federation",
+			JSONData: &JsonData{
+				Timeout:               queryTimeoutSec,
+				CustomQueryParameters: "max_source_resolution=auto",
+				TimeInterval:          fmt.Sprintf("%ds", mco.Spec.ObservabilityAddonSpec.Interval),
+				ForwardHeaders:        []string{"X-Forwarded-Access-Token"},
+			},
+		})
+	}
+
+	grafanaDatasources, err := yaml.Marshal(datasources)
 	if err != nil {
 		return &ctrl.Result{}, err
 	}
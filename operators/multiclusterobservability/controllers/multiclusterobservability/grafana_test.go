@@ -7,6 +7,7 @@ package multiclusterobservability
 import (
 	"context"
 	"reflect"
+	"strings"
 	"testing"
 
 	routev1 "github.com/openshift/api/route/v1"
@@ -313,3 +314,79 @@ func TestGenerateGrafanaDataSource(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateGrafanaDataSourceTenants(t *testing.T) {
+	s := scheme.Scheme
+	if err := mcov1beta2.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add scheme: (%v)", err)
+	}
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-mco"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			ObservabilityAddonSpec: &mcoshared.ObservabilityAddonSpec{Interval: 300},
+			Tenants:                []mcov1beta2.ObservabilityTenant{{Name: "finance"}},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(s).Build()
+	if _, err := GenerateGrafanaDataSource(context.Background(), c, s, mco); err != nil {
+		t.Fatalf("GenerateGrafanaDataSource() error = %v", err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(context.Background(), client.ObjectKey{Name: "grafana-datasources", Namespace: config.GetDefaultNamespace()}, secret); err != nil {
+		t.Fatalf("Failed to get datasource secret: %v", err)
+	}
+	var dss GrafanaDatasources
+	if err := yaml.Unmarshal(secret.Data["datasources.yaml"], &dss); err != nil {
+		t.Fatalf("Failed to unmarshal datasources: %v", err)
+	}
+	if len(dss.Datasources) != 3 {
+		t.Fatalf("Expected 3 datasources, got %d", len(dss.Datasources))
+	}
+	ds := dss.Datasources[2]
+	if ds.Name != "Observatorium-finance" || ds.UID != "tenant-finance" || ds.IsDefault {
+		t.Errorf("Unexpected tenant datasource: %+v", ds)
+	}
+	if !strings.HasSuffix(ds.URL, ":8080/tenants/finance") {
+		t.Errorf("Expected the tenant path in the datasource URL, got %s", ds.URL)
+	}
+}
+
+func TestGenerateGrafanaDataSourceFederation(t *testing.T) {
+	s := scheme.Scheme
+	if err := mcov1beta2.AddToScheme(s); err != nil {
+		t.Fatalf("Unable to add scheme: (%v)", err)
+	}
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-mco"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			ObservabilityAddonSpec: &mcoshared.ObservabilityAddonSpec{Interval: 300},
+			Federation:             &mcov1beta2.FederationSpec{HubName: "hub-a"},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(s).Build()
+	if _, err := GenerateGrafanaDataSource(context.Background(), c, s, mco); err != nil {
+		t.Fatalf("GenerateGrafanaDataSource() error = %v", err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(context.Background(), client.ObjectKey{Name: "grafana-datasources", Namespace: config.GetDefaultNamespace()}, secret); err != nil {
+		t.Fatalf("Failed to get datasource secret: %v", err)
+	}
+	var dss GrafanaDatasources
+	if err := yaml.Unmarshal(secret.Data["datasources.yaml"], &dss); err != nil {
+		t.Fatalf("Failed to unmarshal datasources: %v", err)
+	}
+	if len(dss.Datasources) != 3 {
+		t.Fatalf("Expected 3 datasources, got %d", len(dss.Datasources))
+	}
+	ds := dss.Datasources[2]
+	if ds.Name != "Observatorium-Global" || ds.UID != "federation" || ds.IsDefault {
+		t.Errorf("Unexpected federation datasource: %+v", ds)
+	}
+	if !strings.HasSuffix(ds.URL, ":8080/federation") {
+		t.Errorf("Expected the federation path in the datasource URL, got %s", ds.URL)
+	}
+}
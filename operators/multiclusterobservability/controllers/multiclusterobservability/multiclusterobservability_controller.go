@@ -34,6 +34,7 @@ import (
 	commonutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
 	observatoriumv1alpha1 "github.com/stolostron/observatorium-operator/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -41,7 +42,6 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -81,15 +81,17 @@ var (
 
 // MultiClusterObservabilityReconciler reconciles a MultiClusterObservability object
 type MultiClusterObservabilityReconciler struct {
-	Manager           manager.Manager
-	Client            client.Client
-	Log               logr.Logger
-	Scheme            *runtime.Scheme
-	CRDMap            map[string]bool
-	APIReader         client.Reader
-	RESTMapper        meta.RESTMapper
-	ImageClient       imagev1client.ImageV1Interface
-	LastStorageConfig *mcov1beta2.StorageConfig
+	Manager     manager.Manager
+	Client      client.Client
+	Log         logr.Logger
+	Scheme      *runtime.Scheme
+	CRDMap      map[string]bool
+	APIReader   client.Reader
+	RESTMapper  meta.RESTMapper
+	ImageClient imagev1client.ImageV1Interface
+
+	// objStorageProbeHash is the hash of the object storage config last probed successfully.
+	objStorageProbeHash string
 }
 
 // +kubebuilder:rbac:groups=observability.open-cluster-management.io,resources=multiclusterobservabilities,verbs=get;list;watch;create;update;patch;delete
@@ -410,11 +412,23 @@ func (r *MultiClusterObservabilityReconciler) Reconcile(ctx context.Context, req
 		return ctrl.Result{}, fmt.Errorf("failed to create observability certs: %w", err)
 	}
 
+	// block the rollout of the Thanos components until the object storage is reachable
+	reachable, err := r.ensureObjStorageReachable(ctx, instance)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to probe the object storage: %w", err)
+	}
+	if !reachable {
+		return ctrl.Result{RequeueAfter: objStorageProbeRetryInterval}, nil
+	}
+
 	// create an Observatorium CR
 	result, err = GenerateObservatoriumCR(ctx, r.Client, r.Scheme, instance)
 	if result != nil {
 		return *result, fmt.Errorf("failed to generate the observatorium CR: %w", err)
 	}
+	if err := ensureWorkloadIdentityPodLabels(ctx, r.Client, instance); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to add the workload identity pod labels: %w", err)
+	}
 
 	// generate grafana datasource to point to observatorium api gateway
 	result, err = GenerateGrafanaDataSource(ctx, r.Client, r.Scheme, instance)
@@ -564,6 +578,15 @@ func (r *MultiClusterObservabilityReconciler) SetupWithManager(mgr ctrl.Manager)
 		Owns(&addonv1beta1.ClusterManagementAddOn{}).
 		// Watch for changes to secondary PrometheusRule CR and requeue the owner MultiClusterObservability
 		Owns(&monitoringv1.PrometheusRule{}).
+		// Watch for changes to secondary tenant retention CronJob and requeue the owner MultiClusterObservability
+		Owns(&batchv1.CronJob{}).
+
+		// Watch the object storage secrets to probe the object storage when they change
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(objStorageSecretToMCO(c)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		// Watch the Thanos StatefulSets rendered by the Observatorium operator to keep their workload identity pod labels
+		Watches(&appsv1.StatefulSet{}, handler.EnqueueRequestsFromMapFunc(workloadIdentityStatefulSetToMCO(c)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
 
 		// Watch the configmap for thanos-ruler-custom-rules update
 		Watches(&corev1.ConfigMap{}, &handler.EnqueueRequestForObject{}, builder.WithPredicates(cmPred)).
@@ -640,161 +663,6 @@ func (r *MultiClusterObservabilityReconciler) SetupWithManager(mgr ctrl.Manager)
 	return ctrBuilder.Complete(r)
 }
 
-// HandleStorageSizeChange compares the current storage config against the last known config
-// and handles any changes:
-// 1. For storage size changes: updates PVC sizes directly and deletes StatefulSets for re-creation
-// 2. For storage class changes: deletes StatefulSets and PVCs for re-creation with the new class
-func (r *MultiClusterObservabilityReconciler) HandleStorageSizeChange(
-	ctx context.Context,
-	mco *mcov1beta2.MultiClusterObservability,
-) (*reconcile.Result, error) {
-	currentConfig := mco.Spec.StorageConfig
-	lastConfig := r.LastStorageConfig
-
-	// On first reconcile there is no previous config to compare against
-	if lastConfig == nil {
-		r.LastStorageConfig = currentConfig.DeepCopy()
-		return nil, nil
-	}
-
-	if lastConfig.AlertmanagerStorageSize != currentConfig.AlertmanagerStorageSize {
-		err := updateStorageSizeChange(ctx, r.Client,
-			map[string]string{
-				"observability.open-cluster-management.io/name": mco.GetName(),
-				"alertmanager": "observability",
-			}, currentConfig.AlertmanagerStorageSize)
-		if err != nil {
-			return &reconcile.Result{}, err
-		}
-	}
-
-	if lastConfig.ReceiveStorageSize != currentConfig.ReceiveStorageSize {
-		err := updateStorageSizeChange(ctx, r.Client,
-			map[string]string{
-				"app.kubernetes.io/instance": mco.GetName(),
-				"app.kubernetes.io/name":     "thanos-receive",
-			}, currentConfig.ReceiveStorageSize)
-		if err != nil {
-			return &reconcile.Result{}, err
-		}
-	}
-
-	if lastConfig.CompactStorageSize != currentConfig.CompactStorageSize {
-		err := updateStorageSizeChange(ctx, r.Client,
-			map[string]string{
-				"app.kubernetes.io/instance": mco.GetName(),
-				"app.kubernetes.io/name":     "thanos-compact",
-			}, currentConfig.CompactStorageSize)
-		if err != nil {
-			return &reconcile.Result{}, err
-		}
-	}
-
-	if lastConfig.RuleStorageSize != currentConfig.RuleStorageSize {
-		err := updateStorageSizeChange(ctx, r.Client,
-			map[string]string{
-				"app.kubernetes.io/instance": mco.GetName(),
-				"app.kubernetes.io/name":     "thanos-rule",
-			}, currentConfig.RuleStorageSize)
-		if err != nil {
-			return &reconcile.Result{}, err
-		}
-	}
-
-	if lastConfig.StoreStorageSize != currentConfig.StoreStorageSize {
-		err := updateStorageSizeChange(ctx, r.Client,
-			map[string]string{
-				"app.kubernetes.io/instance": mco.GetName(),
-				"app.kubernetes.io/name":     "thanos-store",
-			}, currentConfig.StoreStorageSize)
-		if err != nil {
-			return &reconcile.Result{}, err
-		}
-	}
-
-	if lastConfig.StorageClass != currentConfig.StorageClass {
-		for _, component := range []map[string]string{
-			{"observability.open-cluster-management.io/name": mco.GetName(), "alertmanager": "observability"},
-			{"app.kubernetes.io/instance": mco.GetName(), "app.kubernetes.io/name": "thanos-receive"},
-			{"app.kubernetes.io/instance": mco.GetName(), "app.kubernetes.io/name": "thanos-compact"},
-			{"app.kubernetes.io/instance": mco.GetName(), "app.kubernetes.io/name": "thanos-rule"},
-			{"app.kubernetes.io/instance": mco.GetName(), "app.kubernetes.io/name": "thanos-store"},
-		} {
-			if err := deleteStatefulSetsAndPVCs(ctx, r.Client, component); err != nil {
-				return &reconcile.Result{}, err
-			}
-		}
-	}
-	r.LastStorageConfig = currentConfig.DeepCopy()
-	return nil, nil
-}
-
-func deleteStatefulSetsAndPVCs(ctx context.Context, c client.Client, matchLabels map[string]string) error {
-	stsList, err := commonutil.GetStatefulSetList(c, config.GetDefaultNamespace(), matchLabels)
-	if err != nil {
-		return err
-	}
-	for index, sts := range stsList {
-		if err := c.Delete(ctx, &stsList[index]); err != nil && !apierrors.IsNotFound(err) {
-			return err
-		}
-		log.Info("Deleted StatefulSet due to storage class change", "sts", sts.Name)
-	}
-	pvcList, err := commonutil.GetPVCList(c, config.GetDefaultNamespace(), matchLabels)
-	if err != nil {
-		return err
-	}
-	for index, pvc := range pvcList {
-		if err := c.Delete(ctx, &pvcList[index]); err != nil && !apierrors.IsNotFound(err) {
-			return err
-		}
-		log.Info("Deleted PVC due to storage class change", "pvc", pvc.Name)
-	}
-	return nil
-}
-
-func updateStorageSizeChange(ctx context.Context, c client.Client, matchLabels map[string]string, storageSize string) error {
-	pvcList, err := commonutil.GetPVCList(c, config.GetDefaultNamespace(), matchLabels)
-	if err != nil {
-		return err
-	}
-
-	stsList, err := commonutil.GetStatefulSetList(c, config.GetDefaultNamespace(), matchLabels)
-	if err != nil {
-		return err
-	}
-
-	// update pvc directly
-	newSize := resource.MustParse(storageSize)
-	for index, pvc := range pvcList {
-		if pvc.Spec.Resources.Requests.Storage().Equal(newSize) {
-			continue
-		}
-		if currentCap := pvc.Status.Capacity.Storage(); currentCap != nil && newSize.Cmp(*currentCap) < 0 {
-			log.Info("Skipping PVC storage shrink: Kubernetes does not support reducing PVC size",
-				"pvc", pvc.Name, "currentCapacity", currentCap.String(), "requestedSize", storageSize)
-			continue
-		}
-		pvcList[index].Spec.Resources.Requests = corev1.ResourceList{
-			corev1.ResourceStorage: newSize,
-		}
-		err := c.Update(ctx, &pvcList[index])
-		if err != nil {
-			return err
-		}
-		log.Info("Update storage size for PVC", "pvc", pvc.Name)
-	}
-	// update sts
-	for index, sts := range stsList {
-		err := c.Delete(ctx, &stsList[index], &client.DeleteOptions{})
-		if err != nil && !apierrors.IsNotFound(err) {
-			return err
-		}
-		log.Info("Successfully delete sts due to storage size changed", "sts", sts.Name)
-	}
-	return nil
-}
-
 // GenerateAlertmanagerRoute creates a route for external read access to the Alertmanager API.
 // Spoke alert forwarding uses observatorium-api; this route is for hub-side consumers (e.g. E2E tests, admins).
 func GenerateAlertmanagerRoute(
@@ -325,6 +325,7 @@ func createFailedDeployment(name, namespace string) *appsv1.Deployment {
 }
 
 func TestMultiClusterMonitoringCRUpdate(t *testing.T) {
+	setInMemObjStorage(t)
 	var (
 		name      = "monitoring"
 		namespace = config.GetDefaultNamespace()
@@ -799,6 +800,7 @@ func TestInitFinalizationRemovesResFinalizerOnDelete(t *testing.T) {
 }
 
 func TestImageReplaceForMCO(t *testing.T) {
+	setInMemObjStorage(t)
 	var (
 		name      = "test-monitoring"
 		namespace = config.GetDefaultNamespace()
@@ -875,7 +877,8 @@ func TestImageReplaceForMCO(t *testing.T) {
 		gp2StorageClass,
 	}
 	// Create a fake client to mock API calls.
-	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objs...).Build()
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objs...).
+		WithStatusSubresource(&mcov1beta2.MultiClusterObservability{}).Build()
 
 	// Create fake imagestream client
 	imageClient := &fakeimagev1client.FakeImageV1{Fake: &(fakeimageclient.NewSimpleClientset().Fake)}
@@ -1102,6 +1105,26 @@ func TestCheckObjStorageStatus(t *testing.T) {
 	failed = mcostatusctrl.FindStatusCondition(mco.Status.Conditions, mcostatusctrl.ConditionTypeFailed)
 	assert.NotNil(t, failed)
 	assert.Equal(t, mcostatusctrl.ReasonObjectStorageInvalid, failed.Reason)
+
+	// 4. Valid secret, but the object storage probe failed
+	validSecret := createSecret("test", "test", config.GetDefaultNamespace())
+	validSecret.ObjectMeta.ResourceVersion = "2"
+	assert.NoError(t, c.Update(t.Context(), validSecret))
+	mcostatusctrl.SetStatusCondition(&mco.Status.Conditions, mcoshared.Condition{
+		Type:    mcostatusctrl.ConditionTypeObjectStorageReachable,
+		Status:  metav1.ConditionFalse,
+		Reason:  reasonObjStorageUnreachable,
+		Message: "failed to list the bucket test: access denied",
+	})
+	assert.NoError(t, c.Status().Update(t.Context(), mco))
+
+	_, err = sr.Reconcile(t.Context(), req)
+	assert.NoError(t, err)
+	_ = c.Get(t.Context(), req.NamespacedName, mco)
+	failed = mcostatusctrl.FindStatusCondition(mco.Status.Conditions, mcostatusctrl.ConditionTypeFailed)
+	assert.NotNil(t, failed)
+	assert.Equal(t, mcostatusctrl.ReasonObjectStorageUnreachable, failed.Reason)
+	assert.Contains(t, failed.Message, "access denied")
 }
 
 func TestHandleStorageSizeChange(t *testing.T) {
@@ -1126,18 +1149,38 @@ func TestHandleStorageSizeChange(t *testing.T) {
 		createStatefulSet(mco.Name, config.GetDefaultNamespace(), "test"),
 		createPersistentVolumeClaim(mco.Name, config.GetDefaultNamespace(), "test"),
 	}
-	c := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+	c := fake.NewClientBuilder().WithRuntimeObjects(objs...).
+		WithStatusSubresource(&mcov1beta2.MultiClusterObservability{}).Build()
 	r := &MultiClusterObservabilityReconciler{
 		Client: c,
 		Scheme: s,
-		LastStorageConfig: &mcov1beta2.StorageConfig{
-			AlertmanagerStorageSize: "1Gi",
-		},
 	}
-	r.HandleStorageSizeChange(context.TODO(), mco)
+	require.NoError(t, c.Get(t.Context(), types.NamespacedName{Name: mco.Name}, mco))
+	mco.Status.Storage = &mcov1beta2.StorageStatus{
+		Applied: &mcov1beta2.AppliedStorageConfig{AlertmanagerStorageSize: "1Gi"},
+		Phase:   mcov1beta2.StorageMigrationDone,
+	}
+	require.NoError(t, c.Status().Update(t.Context(), mco))
+	_, err := r.HandleStorageSizeChange(context.TODO(), mco)
+	require.NoError(t, err)
+
+	// the StatefulSet is deleted to be re-created with the new size
+	sts := &appsv1.StatefulSet{}
+	err = c.Get(t.Context(), types.NamespacedName{Name: "test", Namespace: config.GetDefaultNamespace()}, sts)
+	assert.True(t, errors.IsNotFound(err), "StatefulSet should have been deleted")
+
+	// the new config is recorded as applied
+	updated := &mcov1beta2.MultiClusterObservability{}
+	require.NoError(t, c.Get(t.Context(), types.NamespacedName{Name: mco.Name}, updated))
+	assert.Equal(t, mcov1beta2.StorageMigrationDone, updated.Status.Storage.Phase)
+	assert.Nil(t, updated.Status.Storage.Target)
+	assert.Equal(t, "2Gi", updated.Status.Storage.Applied.AlertmanagerStorageSize)
+	cond := mcostatusctrl.FindStatusCondition(updated.Status.Conditions, mcostatusctrl.ConditionTypeStorageConfigApplied)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
 
 	pvc := &corev1.PersistentVolumeClaim{}
-	err := c.Get(t.Context(), types.NamespacedName{
+	err = c.Get(t.Context(), types.NamespacedName{
 		Name:      "test",
 		Namespace: config.GetDefaultNamespace(),
 	}, pvc)
@@ -1283,15 +1326,18 @@ func TestHandleStorageClassChange(t *testing.T) {
 	}
 
 	objs := []runtime.Object{mco, receiveSts, receivePVC}
-	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objs...).Build()
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objs...).
+		WithStatusSubresource(&mcov1beta2.MultiClusterObservability{}).Build()
 	r := &MultiClusterObservabilityReconciler{
 		Client: c,
 		Scheme: s,
-		LastStorageConfig: &mcov1beta2.StorageConfig{
-			StorageClass:            "gp2",
-			AlertmanagerStorageSize: "1Gi",
-		},
 	}
+	require.NoError(t, c.Get(t.Context(), types.NamespacedName{Name: mco.Name}, mco))
+	mco.Status.Storage = &mcov1beta2.StorageStatus{
+		Applied: &mcov1beta2.AppliedStorageConfig{StorageClass: "gp2", AlertmanagerStorageSize: "1Gi"},
+		Phase:   mcov1beta2.StorageMigrationDone,
+	}
+	require.NoError(t, c.Status().Update(t.Context(), mco))
 
 	// HandleStorageSizeChange detects storage class changed from gp2 -> gp3
 	result, err := r.HandleStorageSizeChange(context.TODO(), mco)
@@ -1315,6 +1361,80 @@ func TestHandleStorageClassChange(t *testing.T) {
 	assert.True(t, errors.IsNotFound(err), "PVC should have been deleted")
 }
 
+func TestHandleStorageSizeChangeResume(t *testing.T) {
+	s := scheme.Scheme
+	require.NoError(t, mcov1beta2.SchemeBuilder.AddToScheme(s))
+
+	applied := &mcov1beta2.AppliedStorageConfig{AlertmanagerStorageSize: "1Gi"}
+	target := &mcov1beta2.AppliedStorageConfig{AlertmanagerStorageSize: "2Gi"}
+
+	testCases := []struct {
+		name          string
+		storage       *mcov1beta2.StorageStatus
+		expectDeleted bool
+	}{
+		{
+			// the applied config is recorded, nothing is re-created
+			name:          "first reconcile",
+			storage:       nil,
+			expectDeleted: false,
+		},
+		{
+			name:          "no change",
+			storage:       &mcov1beta2.StorageStatus{Applied: target, Phase: mcov1beta2.StorageMigrationDone},
+			expectDeleted: false,
+		},
+		{
+			// the PVCs were resized before the operator restarted
+			name:          "interrupted while re-creating the StatefulSets",
+			storage:       &mcov1beta2.StorageStatus{Applied: applied, Target: target, Phase: mcov1beta2.StorageMigrationRecreatingStatefulSets},
+			expectDeleted: true,
+		},
+		{
+			name:          "unknown phase",
+			storage:       &mcov1beta2.StorageStatus{Applied: applied, Target: target, Phase: "Unknown"},
+			expectDeleted: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mco := &mcov1beta2.MultiClusterObservability{
+				TypeMeta:   metav1.TypeMeta{Kind: "MultiClusterObservability"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: mcov1beta2.MultiClusterObservabilitySpec{
+					StorageConfig: &mcov1beta2.StorageConfig{AlertmanagerStorageSize: "2Gi"},
+				},
+			}
+			objs := []runtime.Object{mco, createStatefulSet(mco.Name, config.GetDefaultNamespace(), "test")}
+			c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objs...).
+				WithStatusSubresource(&mcov1beta2.MultiClusterObservability{}).Build()
+			r := &MultiClusterObservabilityReconciler{Client: c, Scheme: s}
+
+			require.NoError(t, c.Get(t.Context(), types.NamespacedName{Name: mco.Name}, mco))
+			if tc.storage != nil {
+				mco.Status.Storage = tc.storage
+				require.NoError(t, c.Status().Update(t.Context(), mco))
+			}
+
+			result, err := r.HandleStorageSizeChange(t.Context(), mco)
+			require.NoError(t, err)
+			assert.Nil(t, result)
+
+			sts := &appsv1.StatefulSet{}
+			err = c.Get(t.Context(), types.NamespacedName{Name: "test", Namespace: config.GetDefaultNamespace()}, sts)
+			assert.Equal(t, tc.expectDeleted, errors.IsNotFound(err))
+
+			updated := &mcov1beta2.MultiClusterObservability{}
+			require.NoError(t, c.Get(t.Context(), types.NamespacedName{Name: mco.Name}, updated))
+			require.NotNil(t, updated.Status.Storage)
+			assert.Equal(t, mcov1beta2.StorageMigrationDone, updated.Status.Storage.Phase)
+			assert.Equal(t, target, updated.Status.Storage.Applied)
+			assert.Nil(t, updated.Status.Storage.Target)
+		})
+	}
+}
+
 func createStatefulSet(name, namespace, statefulSetName string) *appsv1.StatefulSet {
 	return &appsv1.StatefulSet{
 		TypeMeta: metav1.TypeMeta{
@@ -0,0 +1,252 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package multiclusterobservability
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	gokitlog "github.com/go-kit/log"
+	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcostatusctrl "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/controllers/status"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	objstoreclient "github.com/thanos-io/objstore/client"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// objStorageProbeObject is the sentinel object written and read by the probe. Thanos ignores the objects
+	// which are not blocks.
+	objStorageProbeObject        = "multicluster-observability-operator-probe"
+	objStorageProbeTimeout       = 30 * time.Second
+	objStorageProbeRetryInterval = time.Minute
+
+	reasonObjStorageReachable    = "ProbeSucceeded"
+	reasonObjStorageUnreachable  = "ProbeFailed"
+	reasonObjStorageProbeSkipped = "ProbeSkipped"
+)
+
+var (
+	errStopIter = errors.New("stop iteration")
+	// Override in tests to inject a fake object storage.
+	newObjStorageBucketFunc = objstoreclient.NewBucket
+)
+
+// objStorageProbeConfig is the object storage config used by the probe, with the paths of the TLS files
+// mounted in the Thanos pods replaced by local copies.
+type objStorageProbeConfig struct {
+	conf     []byte
+	tlsFiles map[string][]byte
+	tlsPath  string
+}
+
+func (c *objStorageProbeConfig) hash() string {
+	hasher := sha256.New()
+	hasher.Write(c.conf)
+	hasher.Write([]byte(c.tlsPath))
+	names := make([]string, 0, len(c.tlsFiles))
+	for name := range c.tlsFiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		hasher.Write([]byte(name))
+		hasher.Write(c.tlsFiles[name])
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// ensureObjStorageReachable probes the object storage the Thanos components are configured with, and reports
+// the result in the ObjectStorageReachable condition. A successful probe is not repeated until the object
+// storage secrets change, a failed one is retried until the object storage is reachable.
+func (r *MultiClusterObservabilityReconciler) ensureObjStorageReachable(
+	ctx context.Context,
+	mco *mcov1beta2.MultiClusterObservability,
+) (bool, error) {
+	objStorage := mco.Spec.StorageConfig.MetricObjectStorage
+	if usesServiceAccountToken(mco.Spec.StorageConfig) {
+		// the Thanos pods authenticate with their projected service account token, that the operator doesn't have
+		r.objStorageProbeHash = ""
+		return true, patchStatusCondition(ctx, r.Client, mco, mcostatusctrl.ConditionTypeObjectStorageReachable, &mcoshared.Condition{
+			Type:    mcostatusctrl.ConditionTypeObjectStorageReachable,
+			Status:  metav1.ConditionUnknown,
+			Reason:  reasonObjStorageProbeSkipped,
+			Message: "The object storage is not probed, as the Thanos components authenticate with a projected service account token",
+		})
+	}
+
+	probeConfig, err := getObjStorageProbeConfig(ctx, r.Client, objStorage)
+	var statusErr k8serrors.APIStatus
+	if errors.As(err, &statusErr) && !k8serrors.IsNotFound(err) {
+		return false, err
+	}
+	if err == nil && probeConfig.isFilesystem() {
+		// the directory is local to each Thanos pod, the probe would write inside the operator pod
+		r.objStorageProbeHash = ""
+		return true, patchStatusCondition(ctx, r.Client, mco, mcostatusctrl.ConditionTypeObjectStorageReachable, &mcoshared.Condition{
+			Type:    mcostatusctrl.ConditionTypeObjectStorageReachable,
+			Status:  metav1.ConditionUnknown,
+			Reason:  reasonObjStorageProbeSkipped,
+			Message: "The object storage is not probed, as the filesystem storage is local to each Thanos pod",
+		})
+	}
+	if err == nil {
+		hash := probeConfig.hash()
+		if hash == r.objStorageProbeHash {
+			return true, nil
+		}
+		if err = probeConfig.probe(ctx); err == nil {
+			r.objStorageProbeHash = hash
+			log.Info("The object storage is reachable")
+			return true, patchStatusCondition(ctx, r.Client, mco, mcostatusctrl.ConditionTypeObjectStorageReachable, &mcoshared.Condition{
+				Type:    mcostatusctrl.ConditionTypeObjectStorageReachable,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonObjStorageReachable,
+				Message: "The object storage is reachable, the probe object was written and read",
+			})
+		}
+	}
+
+	r.objStorageProbeHash = ""
+	log.Info("The object storage is unreachable, the rollout of the Thanos components is blocked", "error", err.Error())
+	return false, patchStatusCondition(ctx, r.Client, mco, mcostatusctrl.ConditionTypeObjectStorageReachable, &mcoshared.Condition{
+		Type:    mcostatusctrl.ConditionTypeObjectStorageReachable,
+		Status:  metav1.ConditionFalse,
+		Reason:  reasonObjStorageUnreachable,
+		Message: err.Error(),
+	})
+}
+
+// getObjStorageProbeConfig reads the object storage config, and the TLS secret mounted in the Thanos pods.
+// The mount path of the TLS secret is the tlsSecretMountPath, or the directory of the CA file by default.
+func getObjStorageProbeConfig(
+	ctx context.Context,
+	c client.Client,
+	objStorage *mcoshared.PreConfiguredStorage,
+) (*objStorageProbeConfig, error) {
+	secret := &v1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: objStorage.Name, Namespace: mcoconfig.GetDefaultNamespace()}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get the object storage secret %s: %w", objStorage.Name, err)
+	}
+	conf, ok := secret.Data[objStorage.Key]
+	if !ok {
+		return nil, fmt.Errorf("the object storage secret %s has no %q key", objStorage.Name, objStorage.Key)
+	}
+	probeConfig := &objStorageProbeConfig{conf: conf}
+	if objStorage.TLSSecretName == "" {
+		return probeConfig, nil
+	}
+
+	probeConfig.tlsPath = objStorage.TLSSecretMountPath
+	if probeConfig.tlsPath == "" {
+		var objectConfig mcoconfig.ObjectStorgeConf
+		if err := yaml.Unmarshal(conf, &objectConfig); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the object storage config: %w", err)
+		}
+		if caFile := objectConfig.Config.HTTPConfig.TLSConfig.CAFile; caFile != "" {
+			probeConfig.tlsPath = path.Dir(caFile)
+		}
+	}
+	tlsSecret := &v1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: objStorage.TLSSecretName, Namespace: mcoconfig.GetDefaultNamespace()}, tlsSecret); err != nil {
+		return nil, fmt.Errorf("failed to get the object storage TLS secret %s: %w", objStorage.TLSSecretName, err)
+	}
+	probeConfig.tlsFiles = tlsSecret.Data
+	return probeConfig, nil
+}
+
+// isFilesystem returns true when the object storage is the local filesystem of the Thanos pods.
+func (c *objStorageProbeConfig) isFilesystem() bool {
+	var objectConfig mcoconfig.ObjectStorgeConf
+	return yaml.Unmarshal(c.conf, &objectConfig) == nil && strings.EqualFold(objectConfig.Type, "filesystem")
+}
+
+// probe lists the bucket, then writes, reads and deletes the sentinel object, with the TLS and HTTP
+// settings of the Thanos components.
+func (c *objStorageProbeConfig) probe(ctx context.Context) error {
+	conf := c.conf
+	if c.tlsPath != "" && len(c.tlsFiles) > 0 {
+		dir, err := os.MkdirTemp("", "objstore-tls-")
+		if err != nil {
+			return fmt.Errorf("failed to create the directory of the TLS files: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		for name, data := range c.tlsFiles {
+			if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+				return fmt.Errorf("failed to write the TLS file %s: %w", name, err)
+			}
+		}
+		conf = []byte(strings.ReplaceAll(string(conf), strings.TrimSuffix(c.tlsPath, "/")+"/", dir+"/"))
+	}
+
+	bkt, err := newObjStorageBucketFunc(gokitlog.NewNopLogger(), conf, "multicluster-observability-operator", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create the object storage client: %w", err)
+	}
+	defer bkt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, objStorageProbeTimeout)
+	defer cancel()
+	if err := bkt.Iter(ctx, "", func(string) error { return errStopIter }); err != nil && !errors.Is(err, errStopIter) {
+		return fmt.Errorf("failed to list the bucket %s: %w", bkt.Name(), err)
+	}
+	sentinel := []byte(time.Now().UTC().Format(time.RFC3339Nano))
+	if err := bkt.Upload(ctx, objStorageProbeObject, bytes.NewReader(sentinel)); err != nil {
+		return fmt.Errorf("failed to write the object %s to the bucket %s: %w", objStorageProbeObject, bkt.Name(), err)
+	}
+	rc, err := bkt.Get(ctx, objStorageProbeObject)
+	if err != nil {
+		return fmt.Errorf("failed to read the object %s from the bucket %s: %w", objStorageProbeObject, bkt.Name(), err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read the object %s from the bucket %s: %w", objStorageProbeObject, bkt.Name(), err)
+	}
+	if !bytes.Equal(data, sentinel) {
+		return fmt.Errorf("the object %s read from the bucket %s differs from the written one", objStorageProbeObject, bkt.Name())
+	}
+	if err := bkt.Delete(ctx, objStorageProbeObject); err != nil {
+		log.Info("Failed to delete the object storage probe object", "object", objStorageProbeObject, "error", err.Error())
+	}
+	return nil
+}
+
+// objStorageSecretToMCO requeues the MCO when the object storage secret or its TLS secret changes.
+func objStorageSecretToMCO(c client.Client) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		if obj.GetNamespace() != mcoconfig.GetDefaultNamespace() {
+			return nil
+		}
+		instance, err := mcoconfig.GetMCOInstance(ctx, c)
+		if err != nil || instance.Spec.StorageConfig == nil || instance.Spec.StorageConfig.MetricObjectStorage == nil {
+			return nil
+		}
+		objStorage := instance.Spec.StorageConfig.MetricObjectStorage
+		if obj.GetName() != objStorage.Name && obj.GetName() != objStorage.TLSSecretName {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: instance.GetName()}}}
+	}
+}
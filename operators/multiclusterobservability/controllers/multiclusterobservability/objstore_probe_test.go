@@ -0,0 +1,166 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package multiclusterobservability
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	gokitlog "github.com/go-kit/log"
+	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcostatusctrl "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/controllers/status"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	objstoreclient "github.com/thanos-io/objstore/client"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// setInMemObjStorage makes the object storage probe use an in-memory bucket.
+func setInMemObjStorage(t *testing.T) {
+	newObjStorageBucketFunc = func(gokitlog.Logger, []byte, string, func(http.RoundTripper) http.RoundTripper) (objstore.Bucket, error) {
+		return objstore.NewInMemBucket(), nil
+	}
+	t.Cleanup(func() { newObjStorageBucketFunc = objstoreclient.NewBucket })
+}
+
+func TestEnsureObjStorageReachable(t *testing.T) {
+	mco := &mcov1beta2.MultiClusterObservability{
+		TypeMeta:   metav1.TypeMeta{Kind: "MultiClusterObservability"},
+		ObjectMeta: metav1.ObjectMeta{Name: mcoconfig.GetDefaultCRName()},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{
+				MetricObjectStorage: &mcoshared.PreConfiguredStorage{Key: "thanos.yaml", Name: "thanos-object-storage"},
+			},
+		},
+	}
+	bucket := objstore.NewInMemBucket()
+	newObjStorageBucketFunc = func(gokitlog.Logger, []byte, string, func(http.RoundTripper) http.RoundTripper) (objstore.Bucket, error) {
+		return bucket, nil
+	}
+	t.Cleanup(func() { newObjStorageBucketFunc = objstoreclient.NewBucket })
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "thanos-object-storage", Namespace: mcoconfig.GetDefaultNamespace()},
+		Data: map[string][]byte{
+			"thanos.yaml": []byte("type: S3\nconfig:\n  bucket: b\n  endpoint: s3.example.com\n"),
+		},
+	}
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, mcov1beta2.SchemeBuilder.AddToScheme(s))
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(mco, secret).
+		WithStatusSubresource(&mcov1beta2.MultiClusterObservability{}).Build()
+	require.NoError(t, cl.Get(t.Context(), types.NamespacedName{Name: mco.Name}, mco))
+	r := &MultiClusterObservabilityReconciler{Client: cl, Scheme: s}
+
+	getCondition := func() *mcoshared.Condition {
+		updated := &mcov1beta2.MultiClusterObservability{}
+		require.NoError(t, cl.Get(t.Context(), types.NamespacedName{Name: mco.Name}, updated))
+		return mcostatusctrl.FindStatusCondition(updated.Status.Conditions, mcostatusctrl.ConditionTypeObjectStorageReachable)
+	}
+
+	reachable, err := r.ensureObjStorageReachable(t.Context(), mco)
+	require.NoError(t, err)
+	assert.True(t, reachable)
+	condition := getCondition()
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Empty(t, bucket.Objects(), "the probe object must be deleted")
+
+	// The object storage is not probed again until its secret changes.
+	newObjStorageBucketFunc = func(gokitlog.Logger, []byte, string, func(http.RoundTripper) http.RoundTripper) (objstore.Bucket, error) {
+		t.Error("the object storage must not be probed again")
+		return objstore.NewInMemBucket(), nil
+	}
+	reachable, err = r.ensureObjStorageReachable(t.Context(), mco)
+	require.NoError(t, err)
+	assert.True(t, reachable)
+	newObjStorageBucketFunc = objstoreclient.NewBucket
+
+	// The filesystem storage is local to each Thanos pod, it is not probed from the operator pod.
+	secret.Data["thanos.yaml"] = []byte("type: FILESYSTEM\nconfig:\n  directory: /var/thanos/bucket\n")
+	require.NoError(t, cl.Update(t.Context(), secret))
+	reachable, err = r.ensureObjStorageReachable(t.Context(), mco)
+	require.NoError(t, err)
+	assert.True(t, reachable)
+	condition = getCondition()
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+	assert.Equal(t, reasonObjStorageProbeSkipped, condition.Reason)
+
+	// An invalid config blocks the rollout.
+	secret.Data["thanos.yaml"] = []byte("type: UNKNOWN\nconfig: {}\n")
+	require.NoError(t, cl.Update(t.Context(), secret))
+	reachable, err = r.ensureObjStorageReachable(t.Context(), mco)
+	require.NoError(t, err)
+	assert.False(t, reachable)
+	condition = getCondition()
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, reasonObjStorageUnreachable, condition.Reason)
+	assert.Contains(t, condition.Message, "UNKNOWN")
+
+	// So does a missing secret.
+	require.NoError(t, cl.Delete(t.Context(), secret))
+	reachable, err = r.ensureObjStorageReachable(t.Context(), mco)
+	require.NoError(t, err)
+	assert.False(t, reachable)
+	assert.Contains(t, getCondition().Message, "not found")
+
+	// The object storage can't be probed with the projected service account token of the Thanos pods.
+	mco.Spec.StorageConfig.MetricObjectStorage.ServiceAccountProjection = true
+	reachable, err = r.ensureObjStorageReachable(t.Context(), mco)
+	require.NoError(t, err)
+	assert.True(t, reachable)
+	condition = getCondition()
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+	assert.Equal(t, reasonObjStorageProbeSkipped, condition.Reason)
+}
+
+func TestObjStorageProbeTLSFiles(t *testing.T) {
+	objStorage := &mcoshared.PreConfiguredStorage{Key: "thanos.yaml", Name: "thanos-object-storage", TLSSecretName: "tls"}
+	conf := mcoconfig.ObjectStorgeConf{Type: "s3", Config: mcoconfig.Config{Bucket: "b", Endpoint: "s3.example.com"}}
+	conf.Config.HTTPConfig.TLSConfig.CAFile = "/etc/minio/certs/ca.crt"
+	data, err := yaml.Marshal(conf)
+	require.NoError(t, err)
+	cl := fake.NewClientBuilder().WithRuntimeObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "thanos-object-storage", Namespace: mcoconfig.GetDefaultNamespace()},
+			Data:       map[string][]byte{"thanos.yaml": data},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: mcoconfig.GetDefaultNamespace()},
+			Data:       map[string][]byte{"ca.crt": []byte("ca")},
+		},
+	).Build()
+
+	probeConfig, err := getObjStorageProbeConfig(t.Context(), cl, objStorage)
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/minio/certs", probeConfig.tlsPath)
+
+	// The probe uses a local copy of the TLS files mounted in the Thanos pods.
+	newObjStorageBucketFunc = func(_ gokitlog.Logger, confYaml []byte, _ string, _ func(http.RoundTripper) http.RoundTripper) (objstore.Bucket, error) {
+		probed := mcoconfig.ObjectStorgeConf{}
+		require.NoError(t, yaml.Unmarshal(confYaml, &probed))
+		caFile := probed.Config.HTTPConfig.TLSConfig.CAFile
+		assert.NotEqual(t, "/etc/minio/certs/ca.crt", caFile)
+		ca, err := os.ReadFile(caFile)
+		require.NoError(t, err)
+		assert.Equal(t, "ca", string(ca))
+		return objstore.NewInMemBucket(), nil
+	}
+	t.Cleanup(func() { newObjStorageBucketFunc = objstoreclient.NewBucket })
+	require.NoError(t, probeConfig.probe(t.Context()))
+}
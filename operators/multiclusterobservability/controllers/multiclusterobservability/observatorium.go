@@ -9,6 +9,7 @@ import (
 	"crypto/md5" // #nosec G401 G501
 	"encoding/hex"
 	"fmt"
+	"maps"
 	"os"
 	"path"
 	"reflect"
@@ -34,6 +35,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -43,6 +45,10 @@ const (
 	endpointsConfigName = "observability-remotewrite-endpoints"
 	endpointsKey        = "endpoints.yaml"
 
+	receiveLimitsConfigName = "observability-thanos-receive-limits"
+	receiveLimitsKey        = "limits.yaml"
+	receiveLimitsMountPath  = "/etc/thanos/receive-limits"
+
 	obsAPIGateway           = "observatorium-api"
 	obsApiGatewayTargetPort = "public"
 
@@ -143,6 +149,9 @@ func GenerateObservatoriumCR(
 	)
 
 	if err != nil && k8serrors.IsNotFound(err) {
+		if err := applyTenantResources(ctx, cl, scheme, mco, &observatoriumCR.Spec); err != nil {
+			return &ctrl.Result{}, err
+		}
 		log.Info("Creating a new observatorium CR",
 			"observatorium", observatoriumCR.Name,
 		)
@@ -166,6 +175,11 @@ func GenerateObservatoriumCR(
 		}
 	}
 
+	// The tenant resources use the tenant IDs, they are applied once the IDs of the existing tenants are known.
+	if err := applyTenantResources(ctx, cl, scheme, mco, &newSpec); err != nil {
+		return &ctrl.Result{}, err
+	}
+
 	if equality.Semantic.DeepEqual(newSpec, oldSpec) &&
 		labels[obsCRConfigHashLabelName] == observatoriumCRFound.Labels[obsCRConfigHashLabelName] {
 		return nil, nil
@@ -195,6 +209,23 @@ func GenerateObservatoriumCR(
 	return nil, nil
 }
 
+// applyTenantResources applies the resources configuring the tenants of the Observatorium spec.
+func applyTenantResources(
+	ctx context.Context,
+	cl client.Client,
+	scheme *runtime.Scheme,
+	mco *mcov1beta2.MultiClusterObservability,
+	obsSpec *obsv1alpha1.ObservatoriumSpec,
+) error {
+	if err := applyReceiveLimitsConfigMap(ctx, cl, scheme, mco, obsSpec.API.Tenants); err != nil {
+		return fmt.Errorf("failed to apply the receive limits: %w", err)
+	}
+	if err := applyTenantRetentionCronJobs(ctx, cl, scheme, mco, obsSpec.API.Tenants, obsSpec.Thanos); err != nil {
+		return fmt.Errorf("failed to apply the tenant retention: %w", err)
+	}
+	return nil
+}
+
 func getTLSSecretMountPath(client client.Client,
 	objectStorage *oashared.PreConfiguredStorage,
 ) (string, error) {
@@ -236,15 +267,14 @@ func updateTenantID(
 	oldTenant obsv1alpha1.APITenant,
 	idx int,
 ) {
-	if oldTenant.Name == newTenant.Name && newTenant.ID == oldTenant.ID {
+	if oldTenant.Name != newTenant.Name || newTenant.ID == oldTenant.ID {
 		return
 	}
 
 	newSpec.API.Tenants[idx].ID = oldTenant.ID
-	for j, hashring := range newSpec.Hashrings {
-		if slices.Contains(hashring.Tenants, newTenant.ID) {
-			newSpec.Hashrings[j].Tenants = util.Remove(newSpec.Hashrings[j].Tenants, newTenant.ID)
-			newSpec.Hashrings[j].Tenants = append(newSpec.Hashrings[j].Tenants, oldTenant.ID)
+	for _, hashring := range newSpec.Hashrings {
+		if i := slices.Index(hashring.Tenants, newTenant.ID); i >= 0 {
+			hashring.Tenants[i] = oldTenant.ID
 		}
 	}
 }
@@ -348,9 +378,21 @@ func newDefaultObservatoriumSpec(cl client.Client, mco *mcov1beta2.MultiClusterO
 	if util.ProxyEnvVarsAreSet() {
 		obs.EnvVars = newEnvVars()
 	}
+	if mco.Spec.StorageConfig != nil && usesObservatoriumToken(mco.Spec.StorageConfig) {
+		if envVars := workloadIdentityEnvVars(mco.Spec.StorageConfig.WorkloadIdentity); envVars != nil {
+			if obs.EnvVars == nil {
+				obs.EnvVars = map[string]string{}
+			}
+			maps.Copy(obs.EnvVars, envVars)
+		}
+	}
 
+	tenantIDs := make([]string, 0, len(obs.API.Tenants))
+	for _, tenant := range obs.API.Tenants {
+		tenantIDs = append(tenantIDs, tenant.ID)
+	}
 	obs.Hashrings = []*obsv1alpha1.Hashring{
-		{Hashring: "default", Tenants: []string{mcoconfig.GetTenantUID()}},
+		{Hashring: "default", Tenants: tenantIDs},
 	}
 
 	obs.ObjectStorageConfig.Thanos = &obsv1alpha1.ThanosObjectStorageConfigSpec{}
@@ -367,7 +409,7 @@ func newDefaultObservatoriumSpec(cl client.Client, mco *mcov1beta2.MultiClusterO
 		}
 
 		obs.ObjectStorageConfig.Thanos.TLSSecretMountPath = objStorageConf.TLSSecretMountPath
-		obs.ObjectStorageConfig.Thanos.ServiceAccountProjection = mco.Spec.StorageConfig.MetricObjectStorage.ServiceAccountProjection
+		obs.ObjectStorageConfig.Thanos.ServiceAccountProjection = usesObservatoriumToken(mco.Spec.StorageConfig)
 	}
 	return obs, nil
 }
@@ -382,8 +424,12 @@ func newEnvVars() map[string]string {
 	}
 }
 
-func newAPIRBAC() obsv1alpha1.APIRBAC {
-	return obsv1alpha1.APIRBAC{
+// newAPIRBAC allows Grafana to read the metrics of all the tenants, and the managed clusters to write into the
+// tenant selecting them only. The certificate of a managed cluster holds the group of its tenant, see
+// mcoconfig.GetTenantGroup, and the placement controller sets the tenant the cluster writes into.
+func newAPIRBAC(mco *mcov1beta2.MultiClusterObservability) obsv1alpha1.APIRBAC {
+	tenantNames := mcoconfig.GetTenantNames(mco)
+	rbac := obsv1alpha1.APIRBAC{
 		Roles: []obsv1alpha1.RBACRole{
 			{
 				Name: readOnlyRoleName,
@@ -393,22 +439,7 @@ func newAPIRBAC() obsv1alpha1.APIRBAC {
 				Permissions: []obsv1alpha1.Permission{
 					obsv1alpha1.Read,
 				},
-				Tenants: []string{
-					mcoconfig.GetDefaultTenantName(),
-				},
-			},
-			{
-				Name: writeOnlyRoleName,
-				Resources: []string{
-					"metrics",
-					"alertmanager",
-				},
-				Permissions: []obsv1alpha1.Permission{
-					obsv1alpha1.Write,
-				},
-				Tenants: []string{
-					mcoconfig.GetDefaultTenantName(),
-				},
+				Tenants: tenantNames,
 			},
 		},
 		RoleBindings: []obsv1alpha1.RBACRoleBinding{
@@ -424,31 +455,64 @@ func newAPIRBAC() obsv1alpha1.APIRBAC {
 					},
 				},
 			},
+		},
+	}
+	for _, tenant := range tenantNames {
+		roleName := writeOnlyRoleName + "-" + tenant
+		subjects := []obsv1alpha1.Subject{
 			{
-				Name: writeOnlyRoleName,
-				Roles: []string{
-					writeOnlyRoleName,
-				},
-				Subjects: []obsv1alpha1.Subject{
-					{
-						Name: mcoconfig.ManagedClusterOU,
-						Kind: obsv1alpha1.Group,
-					},
-				},
+				Name: mcoconfig.GetTenantGroup(tenant),
+				Kind: obsv1alpha1.Group,
 			},
-		},
+		}
+		// The certificates issued without a tenant group, before the upgrade of the hub and until the managed
+		// clusters rotate them, keep writing into the default tenant.
+		if tenant == mcoconfig.GetDefaultTenantName() {
+			subjects = append(subjects, obsv1alpha1.Subject{
+				Name: mcoconfig.ManagedClusterOU,
+				Kind: obsv1alpha1.Group,
+			})
+		}
+		rbac.Roles = append(rbac.Roles, obsv1alpha1.RBACRole{
+			Name: roleName,
+			Resources: []string{
+				"metrics",
+				"alertmanager",
+			},
+			Permissions: []obsv1alpha1.Permission{
+				obsv1alpha1.Write,
+			},
+			Tenants: []string{tenant},
+		})
+		rbac.RoleBindings = append(rbac.RoleBindings, obsv1alpha1.RBACRoleBinding{
+			Name: roleName,
+			Roles: []string{
+				roleName,
+			},
+			Subjects: subjects,
+		})
 	}
+	return rbac
 }
 
-func newAPITenants() []obsv1alpha1.APITenant {
-	return []obsv1alpha1.APITenant{
-		{
-			Name: mcoconfig.GetDefaultTenantName(),
-			ID:   mcoconfig.GetTenantUID(),
-			MTLS: &obsv1alpha1.TenantMTLS{
-				SecretName: mcoconfig.ClientCACerts,
-				CAKey:      "tls.crt",
-			},
+func newAPITenants(mco *mcov1beta2.MultiClusterObservability) []obsv1alpha1.APITenant {
+	tenants := []obsv1alpha1.APITenant{
+		newAPITenant(mcoconfig.GetDefaultTenantName(), mcoconfig.GetTenantUID()),
+	}
+	// The IDs of the existing tenants are kept by updateTenantID.
+	for _, tenant := range mco.Spec.Tenants {
+		tenants = append(tenants, newAPITenant(tenant.Name, string(uuid.NewUUID())))
+	}
+	return tenants
+}
+
+func newAPITenant(name, id string) obsv1alpha1.APITenant {
+	return obsv1alpha1.APITenant{
+		Name: name,
+		ID:   id,
+		MTLS: &obsv1alpha1.TenantMTLS{
+			SecretName: mcoconfig.ClientCACerts,
+			CAKey:      "tls.crt",
 		},
 	}
 }
@@ -514,8 +578,8 @@ func applyEndpointsSecret(c client.Client, eps []mcoutil.RemoteWriteEndpointWith
 
 func newAPISpec(c client.Client, mco *mcov1beta2.MultiClusterObservability) (obsv1alpha1.APISpec, error) {
 	apiSpec := obsv1alpha1.APISpec{}
-	apiSpec.RBAC = newAPIRBAC()
-	apiSpec.Tenants = newAPITenants()
+	apiSpec.RBAC = newAPIRBAC(mco)
+	apiSpec.Tenants = newAPITenants(mco)
 	apiSpec.TLS = newAPITLS()
 	apiSpec.Replicas = mcoconfig.GetReplicas(mcoconfig.ObservatoriumAPI, mco.Spec.InstanceSize, mco.Spec.AdvancedConfig)
 	if !mcoconfig.WithoutResourcesRequests(mco.GetAnnotations()) {
@@ -670,6 +734,7 @@ func newReceiversSpec(
 		mco.Spec.AdvancedConfig.Receive.ServiceAccountAnnotations != nil {
 		receSpec.ServiceAccountAnnotations = mco.Spec.AdvancedConfig.Receive.ServiceAccountAnnotations
 	}
+	receSpec.ServiceAccountAnnotations = withWorkloadIdentityAnnotations(mco.Spec.StorageConfig, receSpec.ServiceAccountAnnotations)
 
 	if mco.Spec.AdvancedConfig != nil && mco.Spec.AdvancedConfig.Receive != nil &&
 		mco.Spec.AdvancedConfig.Receive.Containers != nil {
@@ -684,9 +749,155 @@ func newReceiversSpec(
 		mco.Spec.AdvancedConfig.Receive.Debug != nil && mco.Spec.AdvancedConfig.Receive.Debug.LogLevel != "" {
 		receSpec.LogLevel = mco.Spec.AdvancedConfig.Receive.Debug.LogLevel
 	}
+
+	// As for the Alertmanager CA bundle of the ruler, the key of the ConfigMap is mounted in the mount path
+	// directory, so that the kubelet updates the file and the receivers reload the limits.
+	if getReceiveLimits(mco) != nil {
+		receSpec.Args = append(receSpec.Args,
+			"--receive.limits-config-file="+path.Join(receiveLimitsMountPath, receiveLimitsKey))
+		receSpec.ExtraVolumeMounts = []obsv1alpha1.VolumeMount{
+			{
+				Type:      obsv1alpha1.VolumeMountTypeConfigMap,
+				MountPath: receiveLimitsMountPath,
+				Name:      receiveLimitsConfigName,
+				Key:       receiveLimitsKey,
+			},
+		}
+	}
 	return receSpec
 }
 
+// receiveLimitsConfig is the limits configuration file of Thanos Receive.
+type receiveLimitsConfig struct {
+	Write receiveWriteLimitsConfig `yaml:"write"`
+}
+
+type receiveWriteLimitsConfig struct {
+	Global  *receiveGlobalLimitsConfig           `yaml:"global,omitempty"`
+	Default *receiveTenantLimitsConfig           `yaml:"default,omitempty"`
+	Tenants map[string]receiveTenantLimitsConfig `yaml:"tenants,omitempty"`
+}
+
+type receiveGlobalLimitsConfig struct {
+	MaxConcurrency           *int64 `yaml:"max_concurrency,omitempty"`
+	MetaMonitoringURL        string `yaml:"meta_monitoring_url,omitempty"`
+	MetaMonitoringLimitQuery string `yaml:"meta_monitoring_limit_query,omitempty"`
+}
+
+type receiveTenantLimitsConfig struct {
+	Request         *receiveRequestLimitsConfig `yaml:"request,omitempty"`
+	HeadSeriesLimit *int64                      `yaml:"head_series_limit,omitempty"`
+}
+
+type receiveRequestLimitsConfig struct {
+	SizeBytesLimit *int64 `yaml:"size_bytes_limit,omitempty"`
+	SeriesLimit    *int64 `yaml:"series_limit,omitempty"`
+	SamplesLimit   *int64 `yaml:"samples_limit,omitempty"`
+}
+
+func getReceiveLimits(mco *mcov1beta2.MultiClusterObservability) *mcov1beta2.ReceiveLimits {
+	if mco.Spec.AdvancedConfig == nil || mco.Spec.AdvancedConfig.Receive == nil {
+		return nil
+	}
+	return mco.Spec.AdvancedConfig.Receive.Limits
+}
+
+func newReceiveTenantLimitsConfig(limits *mcov1beta2.ReceiveTenantLimits) receiveTenantLimitsConfig {
+	config := receiveTenantLimitsConfig{HeadSeriesLimit: limits.HeadSeriesLimit}
+	if limits.RequestSizeBytesLimit != nil || limits.RequestSeriesLimit != nil || limits.RequestSamplesLimit != nil {
+		config.Request = &receiveRequestLimitsConfig{
+			SizeBytesLimit: limits.RequestSizeBytesLimit,
+			SeriesLimit:    limits.RequestSeriesLimit,
+			SamplesLimit:   limits.RequestSamplesLimit,
+		}
+	}
+	return config
+}
+
+// newReceiveLimitsConfig renders the receive limits. Thanos Receive identifies the tenants by the ID set by the
+// Observatorium API, so the tenant limits are keyed by ID.
+func newReceiveLimitsConfig(limits *mcov1beta2.ReceiveLimits, tenants []obsv1alpha1.APITenant) ([]byte, error) {
+	config := receiveLimitsConfig{}
+	if limits.Global != nil {
+		config.Write.Global = &receiveGlobalLimitsConfig{
+			MaxConcurrency:           limits.Global.MaxConcurrency,
+			MetaMonitoringURL:        limits.Global.MetaMonitoringURL,
+			MetaMonitoringLimitQuery: limits.Global.MetaMonitoringLimitQuery,
+		}
+	}
+	if limits.Default != nil {
+		defaultLimits := newReceiveTenantLimitsConfig(limits.Default)
+		config.Write.Default = &defaultLimits
+	}
+	for _, tenantLimits := range limits.Tenants {
+		idx := slices.IndexFunc(tenants, func(tenant obsv1alpha1.APITenant) bool { return tenant.Name == tenantLimits.Name })
+		if idx < 0 {
+			log.Info("Ignoring the receive limits of an unknown tenant", "tenant", tenantLimits.Name)
+			continue
+		}
+		if config.Write.Tenants == nil {
+			config.Write.Tenants = map[string]receiveTenantLimitsConfig{}
+		}
+		config.Write.Tenants[tenants[idx].ID] = newReceiveTenantLimitsConfig(&tenantLimits.ReceiveTenantLimits)
+	}
+	return yaml.Marshal(config)
+}
+
+// applyReceiveLimitsConfigMap creates or updates the ConfigMap holding the receive limits, or deletes it when
+// no limits are set.
+func applyReceiveLimitsConfigMap(
+	ctx context.Context,
+	c client.Client,
+	scheme *runtime.Scheme,
+	mco *mcov1beta2.MultiClusterObservability,
+	tenants []obsv1alpha1.APITenant,
+) error {
+	limits := getReceiveLimits(mco)
+	found := &v1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Name: receiveLimitsConfigName, Namespace: mcoconfig.GetDefaultNamespace()}, found)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if limits == nil {
+		if !exists {
+			return nil
+		}
+		log.Info("Deleting the receive limits", "configmap", receiveLimitsConfigName)
+		if err := c.Delete(ctx, found); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	limitsYaml, err := newReceiveLimitsConfig(limits, tenants)
+	if err != nil {
+		return err
+	}
+	limitsCM := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      receiveLimitsConfigName,
+			Namespace: mcoconfig.GetDefaultNamespace(),
+		},
+		Data: map[string]string{receiveLimitsKey: string(limitsYaml)},
+	}
+	if err := controllerutil.SetControllerReference(mco, limitsCM, scheme); err != nil {
+		return err
+	}
+
+	if !exists {
+		log.Info("Creating the receive limits", "configmap", receiveLimitsConfigName)
+		return c.Create(ctx, limitsCM)
+	}
+	if reflect.DeepEqual(found.Data, limitsCM.Data) {
+		return nil
+	}
+	log.Info("Updating the receive limits", "configmap", receiveLimitsConfigName)
+	found.Data = limitsCM.Data
+	return c.Update(ctx, found)
+}
+
 func newRuleSpec(mco *mcov1beta2.MultiClusterObservability, scSelected string) obsv1alpha1.RuleSpec {
 	ruleSpec := obsv1alpha1.RuleSpec{}
 	if mco.Spec.AdvancedConfig != nil && mco.Spec.AdvancedConfig.RetentionConfig != nil &&
@@ -778,10 +989,17 @@ func newRuleSpec(mco *mcov1beta2.MultiClusterObservability, scSelected string) o
 		Key:  mcoconfig.AlertRuleHubMetricsFileKey,
 	})
 
+	// The ObservabilityRules are aggregated into a single ConfigMap by the ObservabilityRule controller
+	ruleSpec.RulesConfig = append(ruleSpec.RulesConfig, obsv1alpha1.RuleConfig{
+		Name: mcoconfig.AlertRuleObservabilityConfigMapName,
+		Key:  mcoconfig.AlertRuleObservabilityFileKey,
+	})
+
 	if mco.Spec.AdvancedConfig != nil && mco.Spec.AdvancedConfig.Rule != nil &&
 		mco.Spec.AdvancedConfig.Rule.ServiceAccountAnnotations != nil {
 		ruleSpec.ServiceAccountAnnotations = mco.Spec.AdvancedConfig.Rule.ServiceAccountAnnotations
 	}
+	ruleSpec.ServiceAccountAnnotations = withWorkloadIdentityAnnotations(mco.Spec.StorageConfig, ruleSpec.ServiceAccountAnnotations)
 
 	if mco.Spec.AdvancedConfig != nil && mco.Spec.AdvancedConfig.Rule != nil &&
 		mco.Spec.AdvancedConfig.Rule.Containers != nil {
@@ -809,6 +1027,7 @@ func newStoreSpec(mco *mcov1beta2.MultiClusterObservability, scSelected string)
 		mco.Spec.AdvancedConfig.Store.ServiceAccountAnnotations != nil {
 		storeSpec.ServiceAccountAnnotations = mco.Spec.AdvancedConfig.Store.ServiceAccountAnnotations
 	}
+	storeSpec.ServiceAccountAnnotations = withWorkloadIdentityAnnotations(mco.Spec.StorageConfig, storeSpec.ServiceAccountAnnotations)
 
 	if mco.Spec.AdvancedConfig != nil && mco.Spec.AdvancedConfig.Store != nil &&
 		mco.Spec.AdvancedConfig.Store.Containers != nil {
@@ -1012,6 +1231,7 @@ func newCompactSpec(mco *mcov1beta2.MultiClusterObservability, scSelected string
 		mco.Spec.AdvancedConfig.Compact.ServiceAccountAnnotations != nil {
 		compactSpec.ServiceAccountAnnotations = mco.Spec.AdvancedConfig.Compact.ServiceAccountAnnotations
 	}
+	compactSpec.ServiceAccountAnnotations = withWorkloadIdentityAnnotations(mco.Spec.StorageConfig, compactSpec.ServiceAccountAnnotations)
 
 	if mco.Spec.AdvancedConfig != nil && mco.Spec.AdvancedConfig.Compact != nil &&
 		mco.Spec.AdvancedConfig.Compact.Containers != nil {
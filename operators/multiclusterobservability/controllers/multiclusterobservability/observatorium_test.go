@@ -7,18 +7,24 @@ package multiclusterobservability
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
 	routev1 "github.com/openshift/api/route/v1"
 	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
 	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	certctrl "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/certificates"
 	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
 	mcoutil "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/util"
 	observatoriumv1alpha1 "github.com/stolostron/observatorium-operator/api/v1alpha1"
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -337,6 +343,255 @@ func TestUpdateObservatoriumCR(t *testing.T) {
 	}
 }
 
+func TestObservatoriumCRTenants(t *testing.T) {
+	namespace := mcoconfig.GetDefaultNamespace()
+	mco := &mcov1beta2.MultiClusterObservability{
+		TypeMeta:   metav1.TypeMeta{Kind: "MultiClusterObservability"},
+		ObjectMeta: metav1.ObjectMeta{Name: mcoconfig.GetDefaultCRName()},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{
+				MetricObjectStorage:     &mcoshared.PreConfiguredStorage{Key: "test", Name: "test"},
+				StorageClass:            storageClassName,
+				AlertmanagerStorageSize: "1Gi",
+				CompactStorageSize:      "1Gi",
+				RuleStorageSize:         "1Gi",
+				ReceiveStorageSize:      "1Gi",
+				StoreStorageSize:        "1Gi",
+			},
+			ObservabilityAddonSpec: &mcoshared.ObservabilityAddonSpec{EnableMetrics: true, Interval: 300},
+			Tenants: []mcov1beta2.ObservabilityTenant{
+				{Name: "finance", ClusterSets: []string{"finance"}},
+			},
+		},
+	}
+	s := runtime.NewScheme()
+	scheme.AddToScheme(s)
+	mcov1beta2.SchemeBuilder.AddToScheme(s)
+	observatoriumv1alpha1.AddToScheme(s)
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(mco, alertmanagerCABundleConfigMap()).Build()
+	mcoconfig.SetOperandNames(cl)
+
+	getObservatorium := func() *observatoriumv1alpha1.Observatorium {
+		obs := &observatoriumv1alpha1.Observatorium{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: mcoconfig.GetDefaultCRName(), Namespace: namespace}, obs); err != nil {
+			t.Fatalf("Failed to get observatorium: %v", err)
+		}
+		return obs
+	}
+
+	if _, err := GenerateObservatoriumCR(context.TODO(), cl, s, mco); err != nil {
+		t.Fatalf("Failed to create observatorium due to %v", err)
+	}
+	created := getObservatorium()
+	tenants := created.Spec.API.Tenants
+	if len(tenants) != 2 || tenants[0].Name != "default" || tenants[1].Name != "finance" {
+		t.Fatalf("Unexpected tenants: %v", tenants)
+	}
+	if tenants[0].ID != mcoconfig.GetTenantUID() || tenants[1].ID == "" || tenants[1].ID == tenants[0].ID {
+		t.Errorf("Unexpected tenant IDs: %v", tenants)
+	}
+	expectedHashrings := []*observatoriumv1alpha1.Hashring{{Hashring: "default", Tenants: []string{tenants[0].ID, tenants[1].ID}}}
+	if !reflect.DeepEqual(created.Spec.Hashrings, expectedHashrings) {
+		t.Errorf("Unexpected hashrings: %v", created.Spec.Hashrings)
+	}
+	// Grafana reads all the tenants, and the managed clusters write into the tenant of their group only.
+	rbac := created.Spec.API.RBAC
+	expectedRoles := map[string][]string{
+		readOnlyRoleName:               {"default", "finance"},
+		writeOnlyRoleName + "-default": {"default"},
+		writeOnlyRoleName + "-finance": {"finance"},
+	}
+	if len(rbac.Roles) != len(expectedRoles) {
+		t.Fatalf("Unexpected roles: %v", rbac.Roles)
+	}
+	for _, role := range rbac.Roles {
+		if !reflect.DeepEqual(role.Tenants, expectedRoles[role.Name]) {
+			t.Errorf("Unexpected tenants for role %s: %v", role.Name, role.Tenants)
+		}
+	}
+	for _, binding := range rbac.RoleBindings {
+		if binding.Name == readOnlyRoleName {
+			continue
+		}
+		tenant := strings.TrimPrefix(binding.Name, writeOnlyRoleName+"-")
+		expectedSubjects := []observatoriumv1alpha1.Subject{
+			{Name: mcoconfig.GetTenantGroup(tenant), Kind: observatoriumv1alpha1.Group},
+		}
+		if tenant == mcoconfig.GetDefaultTenantName() {
+			expectedSubjects = append(expectedSubjects, observatoriumv1alpha1.Subject{Name: mcoconfig.ManagedClusterOU, Kind: observatoriumv1alpha1.Group})
+		}
+		if !reflect.DeepEqual(binding.Subjects, expectedSubjects) {
+			t.Errorf("Unexpected subjects for role binding %s: %v", binding.Name, binding.Subjects)
+		}
+	}
+
+	// The tenant IDs are kept when the spec is generated again.
+	mco.Spec.Tenants = append(mco.Spec.Tenants, mcov1beta2.ObservabilityTenant{Name: "retail"})
+	if _, err := GenerateObservatoriumCR(context.TODO(), cl, s, mco); err != nil {
+		t.Fatalf("Failed to update observatorium due to %v", err)
+	}
+	updated := getObservatorium()
+	updatedTenants := updated.Spec.API.Tenants
+	if len(updatedTenants) != 3 || updatedTenants[0].ID != tenants[0].ID || updatedTenants[1].ID != tenants[1].ID {
+		t.Fatalf("Unexpected tenants after update: %v", updatedTenants)
+	}
+	if !reflect.DeepEqual(updated.Spec.Hashrings[0].Tenants, []string{tenants[0].ID, tenants[1].ID, updatedTenants[2].ID}) {
+		t.Errorf("Unexpected hashrings after update: %v", updated.Spec.Hashrings)
+	}
+}
+
+// TestHubCollectorWritePath checks that the client certificate of the metrics collector of the hub, and the
+// certificates issued before the tenant groups, can write into the default tenant only.
+func TestHubCollectorWritePath(t *testing.T) {
+	mco := &mcov1beta2.MultiClusterObservability{
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			Tenants: []mcov1beta2.ObservabilityTenant{{Name: "finance"}},
+		},
+	}
+	rbac := newAPIRBAC(mco)
+	writableTenants := func(groups []string) []string {
+		var tenants []string
+		for _, binding := range rbac.RoleBindings {
+			if !slices.ContainsFunc(binding.Subjects, func(s observatoriumv1alpha1.Subject) bool {
+				return s.Kind == observatoriumv1alpha1.Group && slices.Contains(groups, s.Name)
+			}) {
+				continue
+			}
+			for _, role := range rbac.Roles {
+				if slices.Contains(binding.Roles, role.Name) && slices.Contains(role.Permissions, observatoriumv1alpha1.Write) &&
+					slices.Contains(role.Resources, "metrics") {
+					tenants = append(tenants, role.Tenants...)
+				}
+			}
+		}
+		return tenants
+	}
+
+	csrPEM, _, err := certctrl.GenerateKeyAndCSR()
+	if err != nil {
+		t.Fatalf("Failed to generate the CSR of the hub metrics collector: %v", err)
+	}
+	block, _ := pem.Decode(csrPEM)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("Failed to parse the CSR of the hub metrics collector: %v", err)
+	}
+	groups := csr.Subject.OrganizationalUnit
+	if tenants := writableTenants(groups); !reflect.DeepEqual(tenants, []string{mcoconfig.GetDefaultTenantName()}) {
+		t.Errorf("The hub metrics collector with the groups %v writes into the tenants %v", groups, tenants)
+	}
+	if tenants := writableTenants([]string{mcoconfig.ManagedClusterOU}); !reflect.DeepEqual(tenants, []string{mcoconfig.GetDefaultTenantName()}) {
+		t.Errorf("The certificates without a tenant group write into the tenants %v", tenants)
+	}
+	if tenants := writableTenants([]string{mcoconfig.ManagedClusterOU, mcoconfig.GetTenantGroup("finance")}); !reflect.DeepEqual(tenants, []string{mcoconfig.GetDefaultTenantName(), "finance"}) {
+		t.Errorf("The certificates of the finance tenant write into the tenants %v", tenants)
+	}
+}
+
+func TestObservatoriumCRReceiveLimits(t *testing.T) {
+	namespace := mcoconfig.GetDefaultNamespace()
+	mco := &mcov1beta2.MultiClusterObservability{
+		TypeMeta:   metav1.TypeMeta{Kind: "MultiClusterObservability"},
+		ObjectMeta: metav1.ObjectMeta{Name: mcoconfig.GetDefaultCRName()},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{
+				MetricObjectStorage:     &mcoshared.PreConfiguredStorage{Key: "test", Name: "test"},
+				StorageClass:            storageClassName,
+				AlertmanagerStorageSize: "1Gi",
+				CompactStorageSize:      "1Gi",
+				RuleStorageSize:         "1Gi",
+				ReceiveStorageSize:      "1Gi",
+				StoreStorageSize:        "1Gi",
+			},
+			ObservabilityAddonSpec: &mcoshared.ObservabilityAddonSpec{EnableMetrics: true, Interval: 300},
+			Tenants: []mcov1beta2.ObservabilityTenant{
+				{Name: "finance", ClusterSets: []string{"finance"}},
+			},
+			AdvancedConfig: &mcov1beta2.AdvancedConfig{
+				Receive: &mcov1beta2.ReceiveSpec{
+					Limits: &mcov1beta2.ReceiveLimits{
+						Global: &mcov1beta2.ReceiveGlobalLimits{MaxConcurrency: ptr.To(int64(30))},
+						Default: &mcov1beta2.ReceiveTenantLimits{
+							RequestSizeBytesLimit: ptr.To(int64(1048576)),
+							RequestSamplesLimit:   ptr.To(int64(1000)),
+						},
+						Tenants: []mcov1beta2.ReceiveTenantLimitsOverride{
+							{Name: "finance", ReceiveTenantLimits: mcov1beta2.ReceiveTenantLimits{RequestSamplesLimit: ptr.To(int64(0))}},
+						},
+					},
+				},
+			},
+		},
+	}
+	s := runtime.NewScheme()
+	scheme.AddToScheme(s)
+	mcov1beta2.SchemeBuilder.AddToScheme(s)
+	observatoriumv1alpha1.AddToScheme(s)
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(mco, alertmanagerCABundleConfigMap()).Build()
+	mcoconfig.SetOperandNames(cl)
+
+	if _, err := GenerateObservatoriumCR(context.TODO(), cl, s, mco); err != nil {
+		t.Fatalf("Failed to create observatorium due to %v", err)
+	}
+	obs := &observatoriumv1alpha1.Observatorium{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: mcoconfig.GetDefaultCRName(), Namespace: namespace}, obs); err != nil {
+		t.Fatalf("Failed to get observatorium: %v", err)
+	}
+
+	receivers := obs.Spec.Thanos.Receivers
+	if !slices.Contains(receivers.Args, "--receive.limits-config-file=/etc/thanos/receive-limits/limits.yaml") {
+		t.Errorf("Missing limits config file arg: %v", receivers.Args)
+	}
+	expectedMounts := []observatoriumv1alpha1.VolumeMount{{
+		Type:      observatoriumv1alpha1.VolumeMountTypeConfigMap,
+		MountPath: receiveLimitsMountPath,
+		Name:      receiveLimitsConfigName,
+		Key:       receiveLimitsKey,
+	}}
+	if !reflect.DeepEqual(receivers.ExtraVolumeMounts, expectedMounts) {
+		t.Errorf("Unexpected receive volume mounts: %v", receivers.ExtraVolumeMounts)
+	}
+	// As the Alertmanager CA bundle of the ruler, the key of the ConfigMap is mounted in the mount path directory.
+	limitsFile := "--receive.limits-config-file=" + receivers.ExtraVolumeMounts[0].MountPath + "/" + receivers.ExtraVolumeMounts[0].Key
+	if !slices.Contains(receivers.Args, limitsFile) {
+		t.Errorf("The limits config file arg doesn't match the volume mount %s: %v", limitsFile, receivers.Args)
+	}
+
+	limitsCM := &corev1.ConfigMap{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: receiveLimitsConfigName, Namespace: namespace}, limitsCM); err != nil {
+		t.Fatalf("Failed to get the receive limits: %v", err)
+	}
+	if len(limitsCM.OwnerReferences) != 1 || limitsCM.OwnerReferences[0].Name != mco.Name {
+		t.Errorf("Unexpected owner references: %v", limitsCM.OwnerReferences)
+	}
+	financeID := obs.Spec.API.Tenants[1].ID
+	expectedLimits := `write:
+  global:
+    max_concurrency: 30
+  default:
+    request:
+      size_bytes_limit: 1048576
+      samples_limit: 1000
+  tenants:
+    ` + financeID + `:
+      request:
+        samples_limit: 0
+`
+	if limitsCM.Data[receiveLimitsKey] != expectedLimits {
+		t.Errorf("Unexpected receive limits:\n%s\nexpected:\n%s", limitsCM.Data[receiveLimitsKey], expectedLimits)
+	}
+
+	// The ConfigMap is deleted with the limits.
+	mco.Spec.AdvancedConfig = nil
+	if _, err := GenerateObservatoriumCR(context.TODO(), cl, s, mco); err != nil {
+		t.Fatalf("Failed to update observatorium due to %v", err)
+	}
+	err := cl.Get(context.TODO(), types.NamespacedName{Name: receiveLimitsConfigName, Namespace: namespace}, limitsCM)
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("The receive limits should be deleted, got: %v", err)
+	}
+}
+
 func TestTShirtSizeUpdateObservatoriumCR(t *testing.T) {
 	namespace := mcoconfig.GetDefaultNamespace()
 
@@ -908,25 +1163,25 @@ func TestNewRuleSpec(t *testing.T) {
 			name:                 "MCOA disabled, no custom rules",
 			mcoaEnabled:          false,
 			customRulesConfig:    false,
-			expectedRulesConfigs: []string{mcoconfig.AlertRuleDefaultConfigMapName, mcoconfig.AlertRuleHubMetricsConfigMapName},
+			expectedRulesConfigs: []string{mcoconfig.AlertRuleDefaultConfigMapName, mcoconfig.AlertRuleHubMetricsConfigMapName, mcoconfig.AlertRuleObservabilityConfigMapName},
 		},
 		{
 			name:                 "MCOA disabled, with custom rules",
 			mcoaEnabled:          false,
 			customRulesConfig:    true,
-			expectedRulesConfigs: []string{mcoconfig.AlertRuleCustomConfigMapName, mcoconfig.AlertRuleDefaultConfigMapName, mcoconfig.AlertRuleHubMetricsConfigMapName},
+			expectedRulesConfigs: []string{mcoconfig.AlertRuleCustomConfigMapName, mcoconfig.AlertRuleDefaultConfigMapName, mcoconfig.AlertRuleHubMetricsConfigMapName, mcoconfig.AlertRuleObservabilityConfigMapName},
 		},
 		{
 			name:                 "MCOA enabled, no custom rules",
 			mcoaEnabled:          true,
 			customRulesConfig:    false,
-			expectedRulesConfigs: []string{mcoconfig.AlertRuleHubMetricsConfigMapName},
+			expectedRulesConfigs: []string{mcoconfig.AlertRuleHubMetricsConfigMapName, mcoconfig.AlertRuleObservabilityConfigMapName},
 		},
 		{
 			name:                 "MCOA enabled, with custom rules",
 			mcoaEnabled:          true,
 			customRulesConfig:    true,
-			expectedRulesConfigs: []string{mcoconfig.AlertRuleCustomConfigMapName, mcoconfig.AlertRuleHubMetricsConfigMapName},
+			expectedRulesConfigs: []string{mcoconfig.AlertRuleCustomConfigMapName, mcoconfig.AlertRuleHubMetricsConfigMapName, mcoconfig.AlertRuleObservabilityConfigMapName},
 		},
 	}
 
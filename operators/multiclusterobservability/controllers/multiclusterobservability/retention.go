@@ -0,0 +1,335 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package multiclusterobservability
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcostatusctrl "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/controllers/status"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	obsv1alpha1 "github.com/stolostron/observatorium-operator/api/v1alpha1"
+	"gopkg.in/yaml.v2"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	tenantRetentionLabel    = "observability.open-cluster-management.io/retention-tenant"
+	tenantRetentionSchedule = "0 * * * *"
+	objStorageMountPath     = "/etc/thanos/objstore"
+	// The projected service account token used by the object storage clients on STS enabled clusters.
+	boundSATokenMountPath = "/var/run/secrets/openshift/serviceaccount"
+
+	reasonTenantRetentionApplied = "Applied"
+	reasonTenantRetentionInvalid = "InvalidTenantRetention"
+)
+
+// tenantRetention is the retention of the metrics of a tenant, applied by a CronJob.
+type tenantRetention struct {
+	raw, fiveMinutes, oneHour string
+}
+
+// tenantRetentionScope describes the managed clusters a tenant retention applies to. The retention is keyed by
+// ManagedClusterSet or cluster label through the tenant: the blocks only carry the tenant_id external label of the
+// receivers, so the blocks of a single cluster can't be selected within a tenant.
+func tenantRetentionScope(tenant mcov1beta2.ObservabilityTenant) string {
+	var scope []string
+	if len(tenant.ClusterSets) > 0 {
+		scope = append(scope, "clusterSets "+strings.Join(tenant.ClusterSets, ", "))
+	}
+	if tenant.ClusterSelector != nil {
+		scope = append(scope, "clusterSelector "+metav1.FormatLabelSelector(tenant.ClusterSelector))
+	}
+	if len(scope) == 0 {
+		return tenant.Name
+	}
+	return fmt.Sprintf("%s (%s)", tenant.Name, strings.Join(scope, "; "))
+}
+
+func tenantRetentionCronJobName(tenant string) string {
+	return mcoconfig.GetOperandNamePrefix() + "thanos-retention-" + tenant
+}
+
+// getTenantRetention returns the retention of a tenant, using the global retention of the unset resolutions.
+// The retention of a tenant must be shorter than the global one, as the compactor deletes the older blocks anyway.
+func getTenantRetention(global tenantRetention, retention *mcov1beta2.TenantRetentionConfig) (tenantRetention, error) {
+	result := global
+	overrides := []struct {
+		name     string
+		value    string
+		global   string
+		override *string
+	}{
+		{"retentionResolutionRaw", retention.RetentionResolutionRaw, global.raw, &result.raw},
+		{"retentionResolution5m", retention.RetentionResolution5m, global.fiveMinutes, &result.fiveMinutes},
+		{"retentionResolution1h", retention.RetentionResolution1h, global.oneHour, &result.oneHour},
+	}
+	for _, o := range overrides {
+		if o.value == "" {
+			continue
+		}
+		value, err := model.ParseDuration(o.value)
+		if err != nil {
+			return result, fmt.Errorf("invalid %s %q: %w", o.name, o.value, err)
+		}
+		if value <= 0 {
+			return result, fmt.Errorf("%s must be positive", o.name)
+		}
+		// A global retention of 0 keeps the blocks forever.
+		globalValue, err := model.ParseDuration(o.global)
+		if err == nil && globalValue > 0 && value > globalValue {
+			return result, fmt.Errorf("%s %s is longer than the global retention %s", o.name, o.value, o.global)
+		}
+		*o.override = o.value
+	}
+	return result, nil
+}
+
+// newTenantRetentionCronJob returns the CronJob marking for deletion the blocks of a tenant older than its retention.
+// The blocks of a tenant are selected by the tenant_id external label set by the receivers.
+func newTenantRetentionCronJob(
+	mco *mcov1beta2.MultiClusterObservability,
+	tenant obsv1alpha1.APITenant,
+	retention tenantRetention,
+	thanosSpec obsv1alpha1.ThanosSpec,
+) (*batchv1.CronJob, error) {
+	relabelConfig, err := yaml.Marshal([]map[string]any{{
+		"action":        "keep",
+		"source_labels": []string{"tenant_id"},
+		"regex":         tenant.ID,
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	objStorage := mco.Spec.StorageConfig.MetricObjectStorage
+	volumes := []v1.Volume{{
+		Name: "objstore",
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{SecretName: objStorage.Name},
+		},
+	}}
+	volumeMounts := []v1.VolumeMount{{Name: "objstore", MountPath: objStorageMountPath, ReadOnly: true}}
+	if objStorage.TLSSecretName != "" && objStorage.TLSSecretMountPath != "" {
+		volumes = append(volumes, v1.Volume{
+			Name: "objstore-tls",
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{SecretName: objStorage.TLSSecretName},
+			},
+		})
+		volumeMounts = append(volumeMounts, v1.VolumeMount{Name: "objstore-tls", MountPath: objStorage.TLSSecretMountPath, ReadOnly: true})
+	}
+	if usesServiceAccountToken(mco.Spec.StorageConfig) {
+		volumes = append(volumes, v1.Volume{
+			Name: "bound-sa-token",
+			VolumeSource: v1.VolumeSource{
+				Projected: &v1.ProjectedVolumeSource{
+					Sources: []v1.VolumeProjection{{
+						ServiceAccountToken: &v1.ServiceAccountTokenProjection{
+							Audience: workloadIdentityAudience(mco.Spec.StorageConfig.WorkloadIdentity),
+							Path:     boundSATokenFileName,
+						},
+					}},
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, v1.VolumeMount{Name: "bound-sa-token", MountPath: boundSATokenMountPath, ReadOnly: true})
+	}
+
+	var imagePullSecrets []v1.LocalObjectReference
+	if pullSecret := mcoconfig.GetImagePullSecret(mco.Spec); pullSecret != "" {
+		imagePullSecrets = []v1.LocalObjectReference{{Name: pullSecret}}
+	}
+
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      tenantRetentionCronJobName(tenant.Name),
+			Namespace: mcoconfig.GetDefaultNamespace(),
+			Labels:    map[string]string{tenantRetentionLabel: tenant.Name},
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   tenantRetentionSchedule,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: ptr.To(int32(1)),
+			FailedJobsHistoryLimit:     ptr.To(int32(1)),
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					BackoffLimit: ptr.To(int32(2)),
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{tenantRetentionLabel: tenant.Name},
+						},
+						Spec: v1.PodSpec{
+							RestartPolicy: v1.RestartPolicyNever,
+							// The compactor service account has the permissions of the object storage.
+							ServiceAccountName: mcoconfig.GetOperandName(mcoconfig.Observatorium) + "-" + mcoconfig.ThanosCompact,
+							ImagePullSecrets:   imagePullSecrets,
+							NodeSelector:       mco.Spec.NodeSelector,
+							Tolerations:        mco.Spec.Tolerations,
+							Volumes:            volumes,
+							Containers: []v1.Container{{
+								Name:            "thanos-retention",
+								Image:           thanosSpec.Image,
+								ImagePullPolicy: thanosSpec.ImagePullPolicy,
+								Args: []string{
+									"tools", "bucket", "retention",
+									"--objstore.config-file=" + path.Join(objStorageMountPath, objStorage.Key),
+									"--selector.relabel-config=" + string(relabelConfig),
+									"--retention.resolution-raw=" + retention.raw,
+									"--retention.resolution-5m=" + retention.fiveMinutes,
+									"--retention.resolution-1h=" + retention.oneHour,
+									"--delete-delay=" + thanosSpec.Compact.DeleteDelay,
+									"--log.format=logfmt",
+								},
+								Env:          newWorkloadIdentityEnv(mco.Spec.StorageConfig),
+								VolumeMounts: volumeMounts,
+							}},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// applyTenantRetentionCronJobs creates, updates or deletes the retention CronJobs of the tenants, and reports
+// the invalid retentions in the TenantRetentionApplied condition of the MCO.
+func applyTenantRetentionCronJobs(
+	ctx context.Context,
+	c client.Client,
+	scheme *runtime.Scheme,
+	mco *mcov1beta2.MultiClusterObservability,
+	tenants []obsv1alpha1.APITenant,
+	thanosSpec obsv1alpha1.ThanosSpec,
+) error {
+	// The compactor applies the global retention to all the metrics.
+	global := tenantRetention{
+		raw:         thanosSpec.Compact.RetentionResolutionRaw,
+		fiveMinutes: thanosSpec.Compact.RetentionResolution5m,
+		oneHour:     thanosSpec.Compact.RetentionResolution1h,
+	}
+	desired := map[string]*batchv1.CronJob{}
+	var applied, invalid []string
+	for _, tenant := range mco.Spec.Tenants {
+		if tenant.Retention == nil {
+			continue
+		}
+		idx := slices.IndexFunc(tenants, func(t obsv1alpha1.APITenant) bool { return t.Name == tenant.Name })
+		if idx < 0 {
+			continue
+		}
+		retention, err := getTenantRetention(global, tenant.Retention)
+		if err != nil {
+			log.Info("Ignoring the invalid retention of the tenant", "tenant", tenant.Name, "error", err.Error())
+			invalid = append(invalid, fmt.Sprintf("%s: %s", tenant.Name, err))
+			continue
+		}
+		cronJob, err := newTenantRetentionCronJob(mco, tenants[idx], retention, thanosSpec)
+		if err != nil {
+			return err
+		}
+		if err := controllerutil.SetControllerReference(mco, cronJob, scheme); err != nil {
+			return err
+		}
+		desired[cronJob.Name] = cronJob
+		applied = append(applied, tenantRetentionScope(tenant))
+	}
+
+	existing := &batchv1.CronJobList{}
+	if err := c.List(ctx, existing, client.InNamespace(mcoconfig.GetDefaultNamespace()),
+		client.HasLabels{tenantRetentionLabel}); err != nil {
+		return err
+	}
+	for i := range existing.Items {
+		found := &existing.Items[i]
+		cronJob, ok := desired[found.Name]
+		if !ok {
+			log.Info("Deleting the retention CronJob", "cronjob", found.Name)
+			if err := c.Delete(ctx, found); err != nil && !k8serrors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+		delete(desired, found.Name)
+		if equality.Semantic.DeepDerivative(cronJob.Spec, found.Spec) && equality.Semantic.DeepEqual(cronJob.Labels, found.Labels) {
+			continue
+		}
+		log.Info("Updating the retention CronJob", "cronjob", found.Name)
+		found.Labels = cronJob.Labels
+		found.Spec = cronJob.Spec
+		if err := c.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+	for _, cronJob := range desired {
+		log.Info("Creating the retention CronJob", "cronjob", cronJob.Name)
+		if err := c.Create(ctx, cronJob); err != nil {
+			return err
+		}
+	}
+
+	var condition *mcoshared.Condition
+	switch {
+	case len(invalid) > 0:
+		condition = &mcoshared.Condition{
+			Type:    mcostatusctrl.ConditionTypeTenantRetentionApplied,
+			Status:  metav1.ConditionFalse,
+			Reason:  reasonTenantRetentionInvalid,
+			Message: "The retention of the tenants is ignored: " + strings.Join(invalid, "; "),
+		}
+	case len(applied) > 0:
+		condition = &mcoshared.Condition{
+			Type:    mcostatusctrl.ConditionTypeTenantRetentionApplied,
+			Status:  metav1.ConditionTrue,
+			Reason:  reasonTenantRetentionApplied,
+			Message: "The retention of the tenants is applied: " + strings.Join(applied, ", "),
+		}
+	}
+	return patchStatusCondition(ctx, c, mco, mcostatusctrl.ConditionTypeTenantRetentionApplied, condition)
+}
+
+// patchStatusCondition sets the condition of the given type in the MCO status, or removes it when nil.
+// The optimistic lock prevents overwriting the conditions updated concurrently by the status controller.
+func patchStatusCondition(
+	ctx context.Context,
+	c client.Client,
+	mco *mcov1beta2.MultiClusterObservability,
+	conditionType string,
+	condition *mcoshared.Condition,
+) error {
+	existing := mcostatusctrl.FindStatusCondition(mco.Status.Conditions, conditionType)
+	if existing == nil && condition == nil {
+		return nil
+	}
+	if existing != nil && condition != nil && existing.Status == condition.Status &&
+		existing.Reason == condition.Reason && existing.Message == condition.Message {
+		return nil
+	}
+
+	orig := mco.DeepCopy()
+	if condition == nil {
+		mcostatusctrl.RemoveStatusCondition(&mco.Status.Conditions, conditionType)
+	} else {
+		mcostatusctrl.SetStatusCondition(&mco.Status.Conditions, *condition)
+	}
+	if err := c.Status().Patch(ctx, mco, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})); err != nil {
+		return fmt.Errorf("failed to update the %s condition: %w", conditionType, err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package multiclusterobservability
+
+import (
+	"testing"
+
+	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcostatusctrl "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/controllers/status"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	obsv1alpha1 "github.com/stolostron/observatorium-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetTenantRetention(t *testing.T) {
+	global := tenantRetention{raw: "365d", fiveMinutes: "365d", oneHour: "0d"}
+
+	testCases := []struct {
+		name      string
+		retention mcov1beta2.TenantRetentionConfig
+		expected  tenantRetention
+		expectErr bool
+	}{
+		{
+			name:      "override raw only",
+			retention: mcov1beta2.TenantRetentionConfig{RetentionResolutionRaw: "14d"},
+			expected:  tenantRetention{raw: "14d", fiveMinutes: "365d", oneHour: "0d"},
+		},
+		{
+			name:      "global retention of 0 keeps the blocks forever",
+			retention: mcov1beta2.TenantRetentionConfig{RetentionResolution1h: "730d"},
+			expected:  tenantRetention{raw: "365d", fiveMinutes: "365d", oneHour: "730d"},
+		},
+		{
+			name:      "longer than the global retention",
+			retention: mcov1beta2.TenantRetentionConfig{RetentionResolution5m: "2y"},
+			expectErr: true,
+		},
+		{
+			name:      "zero",
+			retention: mcov1beta2.TenantRetentionConfig{RetentionResolutionRaw: "0d"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid duration",
+			retention: mcov1beta2.TenantRetentionConfig{RetentionResolutionRaw: "two weeks"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := getTenantRetention(global, &tc.retention)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestApplyTenantRetentionCronJobs(t *testing.T) {
+	mco := &mcov1beta2.MultiClusterObservability{
+		TypeMeta:   metav1.TypeMeta{Kind: "MultiClusterObservability"},
+		ObjectMeta: metav1.ObjectMeta{Name: mcoconfig.GetDefaultCRName()},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{
+				MetricObjectStorage: &mcoshared.PreConfiguredStorage{Key: "thanos.yaml", Name: "thanos-object-storage"},
+			},
+			Tenants: []mcov1beta2.ObservabilityTenant{
+				{Name: "prod", ClusterSets: []string{"prod"}},
+				{
+					Name:            "dev",
+					ClusterSets:     []string{"dev"},
+					ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}},
+					Retention: &mcov1beta2.TenantRetentionConfig{
+						RetentionResolutionRaw: "14d",
+						RetentionResolution5m:  "14d",
+						RetentionResolution1h:  "14d",
+					},
+				},
+			},
+		},
+	}
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, mcov1beta2.SchemeBuilder.AddToScheme(s))
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(mco).
+		WithStatusSubresource(&mcov1beta2.MultiClusterObservability{}).Build()
+	require.NoError(t, cl.Get(t.Context(), types.NamespacedName{Name: mco.Name}, mco))
+
+	tenants := []obsv1alpha1.APITenant{
+		{Name: "default", ID: "default-id"},
+		{Name: "prod", ID: "prod-id"},
+		{Name: "dev", ID: "dev-id"},
+	}
+	thanosSpec := obsv1alpha1.ThanosSpec{
+		Image: "thanos:test",
+		Compact: obsv1alpha1.CompactSpec{
+			RetentionResolutionRaw: "365d",
+			RetentionResolution5m:  "365d",
+			RetentionResolution1h:  "365d",
+			DeleteDelay:            "48h",
+		},
+	}
+	require.NoError(t, applyTenantRetentionCronJobs(t.Context(), cl, s, mco, tenants, thanosSpec))
+
+	cronJobs := &batchv1.CronJobList{}
+	require.NoError(t, cl.List(t.Context(), cronJobs, client.InNamespace(mcoconfig.GetDefaultNamespace())))
+	require.Len(t, cronJobs.Items, 1)
+	cronJob := cronJobs.Items[0]
+	assert.Equal(t, tenantRetentionCronJobName("dev"), cronJob.Name)
+	container := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "thanos:test", container.Image)
+	assert.Equal(t, []string{"tools", "bucket", "retention"}, container.Args[:3])
+	assert.Contains(t, container.Args, "--objstore.config-file=/etc/thanos/objstore/thanos.yaml")
+	assert.Contains(t, container.Args, "--retention.resolution-raw=14d")
+	assert.Contains(t, container.Args, "--delete-delay=48h")
+	assert.Contains(t, container.Args, "--selector.relabel-config=- action: keep\n  regex: dev-id\n  source_labels:\n  - tenant_id\n")
+
+	updated := &mcov1beta2.MultiClusterObservability{}
+	require.NoError(t, cl.Get(t.Context(), types.NamespacedName{Name: mco.Name}, updated))
+	condition := mcostatusctrl.FindStatusCondition(updated.Status.Conditions, mcostatusctrl.ConditionTypeTenantRetentionApplied)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "The retention of the tenants is applied: dev (clusterSets dev; clusterSelector env=dev)", condition.Message)
+
+	// An invalid retention is reported, and its CronJob is deleted.
+	mco.Spec.Tenants[1].Retention.RetentionResolutionRaw = "2y"
+	require.NoError(t, applyTenantRetentionCronJobs(t.Context(), cl, s, mco, tenants, thanosSpec))
+	require.NoError(t, cl.List(t.Context(), cronJobs, client.InNamespace(mcoconfig.GetDefaultNamespace())))
+	assert.Empty(t, cronJobs.Items)
+	require.NoError(t, cl.Get(t.Context(), types.NamespacedName{Name: mco.Name}, updated))
+	condition = mcostatusctrl.FindStatusCondition(updated.Status.Conditions, mcostatusctrl.ConditionTypeTenantRetentionApplied)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, reasonTenantRetentionInvalid, condition.Reason)
+
+	// The condition is removed with the tenant retention.
+	mco.Spec.Tenants[1].Retention = nil
+	require.NoError(t, applyTenantRetentionCronJobs(t.Context(), cl, s, mco, tenants, thanosSpec))
+	require.NoError(t, cl.Get(t.Context(), types.NamespacedName{Name: mco.Name}, updated))
+	assert.Nil(t, mcostatusctrl.FindStatusCondition(updated.Status.Conditions, mcostatusctrl.ConditionTypeTenantRetentionApplied))
+}
@@ -0,0 +1,286 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package multiclusterobservability
+
+import (
+	"context"
+	"fmt"
+
+	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcostatusctrl "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/controllers/status"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	commonutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// storageComponents are the components with PVCs sized by the StorageConfig.
+var storageComponents = []string{"alertmanager", "thanos-receive", "thanos-compact", "thanos-rule", "thanos-store"}
+
+// HandleStorageSizeChange compares the current storage config against the last applied config
+// and handles any changes:
+// 1. For storage size changes: updates PVC sizes directly and deletes StatefulSets for re-creation
+// 2. For storage class changes: deletes StatefulSets and PVCs for re-creation with the new class
+// The applied config and the phase of the migration are recorded in the MCO status, so that changes made
+// while the operator was down are applied, and interrupted migrations are resumed.
+func (r *MultiClusterObservabilityReconciler) HandleStorageSizeChange(
+	ctx context.Context,
+	mco *mcov1beta2.MultiClusterObservability,
+) (*reconcile.Result, error) {
+	target := newAppliedStorageConfig(mco.Spec.StorageConfig)
+	storage := mco.Status.Storage.DeepCopy()
+
+	// On first reconcile there is no previous config to compare against
+	if storage == nil || storage.Applied == nil {
+		storage = &mcov1beta2.StorageStatus{Applied: target, Phase: mcov1beta2.StorageMigrationDone}
+		if err := r.updateStorageStatus(ctx, mco, storage); err != nil {
+			return &reconcile.Result{}, err
+		}
+		return nil, nil
+	}
+
+	switch {
+	case storage.Target != nil && *storage.Target != *target:
+		// the config changed again during the migration, restart it with the new target
+		log.Info("Storage config changed during the migration, restarting it", "phase", storage.Phase)
+		storage.Target = target
+		storage.Phase = mcov1beta2.StorageMigrationPending
+	case storage.Target == nil && *storage.Applied != *target:
+		log.Info("Storage config changed, starting the migration")
+		storage.Target = target
+		storage.Phase = mcov1beta2.StorageMigrationPending
+	case storage.Target == nil:
+		return nil, nil
+	default:
+		log.Info("Resuming the storage migration", "phase", storage.Phase)
+	}
+
+	for storage.Phase != mcov1beta2.StorageMigrationDone {
+		if err := r.updateStorageStatus(ctx, mco, storage); err != nil {
+			return &reconcile.Result{}, err
+		}
+
+		switch storage.Phase {
+		case mcov1beta2.StorageMigrationPending:
+			storage.Phase = mcov1beta2.StorageMigrationResizingPVCs
+		case mcov1beta2.StorageMigrationResizingPVCs:
+			if err := resizeStoragePVCs(ctx, r.Client, mco.GetName(), storage.Applied, storage.Target); err != nil {
+				return &reconcile.Result{}, err
+			}
+			storage.Phase = mcov1beta2.StorageMigrationRecreatingStatefulSets
+		case mcov1beta2.StorageMigrationRecreatingStatefulSets:
+			if err := recreateStorageStatefulSets(ctx, r.Client, mco.GetName(), storage.Applied, storage.Target); err != nil {
+				return &reconcile.Result{}, err
+			}
+			storage.Applied = storage.Target
+			storage.Target = nil
+			storage.Phase = mcov1beta2.StorageMigrationDone
+		default:
+			log.Info("Unknown storage migration phase, restarting the migration", "phase", storage.Phase)
+			storage.Phase = mcov1beta2.StorageMigrationPending
+		}
+	}
+
+	if err := r.updateStorageStatus(ctx, mco, storage); err != nil {
+		return &reconcile.Result{}, err
+	}
+	log.Info("Storage migration done")
+	return nil, nil
+}
+
+// updateStorageStatus records the storage status and its condition in the MCO status. The optimistic lock
+// prevents overwriting the conditions updated concurrently by the status controller.
+func (r *MultiClusterObservabilityReconciler) updateStorageStatus(
+	ctx context.Context,
+	mco *mcov1beta2.MultiClusterObservability,
+	storage *mcov1beta2.StorageStatus,
+) error {
+	orig := mco.DeepCopy()
+	mco.Status.Storage = storage.DeepCopy()
+	mcostatusctrl.SetStatusCondition(&mco.Status.Conditions, newStorageCondition(storage))
+	if err := r.Client.Status().Patch(ctx, mco, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})); err != nil {
+		return fmt.Errorf("failed to update the storage status: %w", err)
+	}
+	return nil
+}
+
+func newStorageCondition(storage *mcov1beta2.StorageStatus) mcoshared.Condition {
+	switch storage.Phase {
+	case mcov1beta2.StorageMigrationDone:
+		return mcoshared.Condition{
+			Type:    mcostatusctrl.ConditionTypeStorageConfigApplied,
+			Status:  metav1.ConditionTrue,
+			Reason:  string(storage.Phase),
+			Message: "The storage config is applied to the PVCs and StatefulSets",
+		}
+	case mcov1beta2.StorageMigrationResizingPVCs:
+		return mcoshared.Condition{
+			Type:    mcostatusctrl.ConditionTypeStorageConfigApplied,
+			Status:  metav1.ConditionFalse,
+			Reason:  string(storage.Phase),
+			Message: "Resizing the PVCs to the new storage config",
+		}
+	case mcov1beta2.StorageMigrationRecreatingStatefulSets:
+		return mcoshared.Condition{
+			Type:    mcostatusctrl.ConditionTypeStorageConfigApplied,
+			Status:  metav1.ConditionFalse,
+			Reason:  string(storage.Phase),
+			Message: "Re-creating the StatefulSets with the new storage config",
+		}
+	default:
+		return mcoshared.Condition{
+			Type:    mcostatusctrl.ConditionTypeStorageConfigApplied,
+			Status:  metav1.ConditionFalse,
+			Reason:  string(mcov1beta2.StorageMigrationPending),
+			Message: "A new storage config is pending",
+		}
+	}
+}
+
+func newAppliedStorageConfig(storageConfig *mcov1beta2.StorageConfig) *mcov1beta2.AppliedStorageConfig {
+	return &mcov1beta2.AppliedStorageConfig{
+		StorageClass:            storageConfig.StorageClass,
+		AlertmanagerStorageSize: storageConfig.AlertmanagerStorageSize,
+		RuleStorageSize:         storageConfig.RuleStorageSize,
+		CompactStorageSize:      storageConfig.CompactStorageSize,
+		ReceiveStorageSize:      storageConfig.ReceiveStorageSize,
+		StoreStorageSize:        storageConfig.StoreStorageSize,
+	}
+}
+
+func storageComponentSize(storageConfig *mcov1beta2.AppliedStorageConfig, component string) string {
+	switch component {
+	case "alertmanager":
+		return storageConfig.AlertmanagerStorageSize
+	case "thanos-receive":
+		return storageConfig.ReceiveStorageSize
+	case "thanos-compact":
+		return storageConfig.CompactStorageSize
+	case "thanos-rule":
+		return storageConfig.RuleStorageSize
+	case "thanos-store":
+		return storageConfig.StoreStorageSize
+	}
+	return ""
+}
+
+func storageComponentLabels(mcoName, component string) map[string]string {
+	if component == "alertmanager" {
+		return map[string]string{
+			"observability.open-cluster-management.io/name": mcoName,
+			"alertmanager": "observability",
+		}
+	}
+	return map[string]string{
+		"app.kubernetes.io/instance": mcoName,
+		"app.kubernetes.io/name":     component,
+	}
+}
+
+// resizeStoragePVCs updates the size of the PVCs of the components whose storage size changed. The PVCs
+// of all the components are re-created when the storage class changed.
+func resizeStoragePVCs(ctx context.Context, c client.Client, mcoName string, applied, target *mcov1beta2.AppliedStorageConfig) error {
+	if applied.StorageClass != target.StorageClass {
+		return nil
+	}
+	for _, component := range storageComponents {
+		size := storageComponentSize(target, component)
+		if size == storageComponentSize(applied, component) {
+			continue
+		}
+		if err := updatePVCsSize(ctx, c, storageComponentLabels(mcoName, component), size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recreateStorageStatefulSets deletes the StatefulSets of the components whose storage changed, so that
+// they are re-created by the operators, with their PVCs when the storage class changed.
+func recreateStorageStatefulSets(ctx context.Context, c client.Client, mcoName string, applied, target *mcov1beta2.AppliedStorageConfig) error {
+	for _, component := range storageComponents {
+		labels := storageComponentLabels(mcoName, component)
+		if applied.StorageClass != target.StorageClass {
+			if err := deleteStatefulSetsAndPVCs(ctx, c, labels); err != nil {
+				return err
+			}
+			continue
+		}
+		if storageComponentSize(target, component) == storageComponentSize(applied, component) {
+			continue
+		}
+		if err := deleteStatefulSets(ctx, c, labels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteStatefulSetsAndPVCs(ctx context.Context, c client.Client, matchLabels map[string]string) error {
+	if err := deleteStatefulSets(ctx, c, matchLabels); err != nil {
+		return err
+	}
+	pvcList, err := commonutil.GetPVCList(c, config.GetDefaultNamespace(), matchLabels)
+	if err != nil {
+		return err
+	}
+	for index, pvc := range pvcList {
+		if err := c.Delete(ctx, &pvcList[index]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		log.Info("Deleted PVC due to storage class change", "pvc", pvc.Name)
+	}
+	return nil
+}
+
+func deleteStatefulSets(ctx context.Context, c client.Client, matchLabels map[string]string) error {
+	stsList, err := commonutil.GetStatefulSetList(c, config.GetDefaultNamespace(), matchLabels)
+	if err != nil {
+		return err
+	}
+	for index, sts := range stsList {
+		if err := c.Delete(ctx, &stsList[index]); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		log.Info("Deleted StatefulSet due to storage change", "sts", sts.Name)
+	}
+	return nil
+}
+
+func updatePVCsSize(ctx context.Context, c client.Client, matchLabels map[string]string, storageSize string) error {
+	pvcList, err := commonutil.GetPVCList(c, config.GetDefaultNamespace(), matchLabels)
+	if err != nil {
+		return err
+	}
+
+	newSize, err := resource.ParseQuantity(storageSize)
+	if err != nil {
+		return fmt.Errorf("invalid storage size %q: %w", storageSize, err)
+	}
+	for index, pvc := range pvcList {
+		if pvc.Spec.Resources.Requests.Storage().Equal(newSize) {
+			continue
+		}
+		if currentCap := pvc.Status.Capacity.Storage(); currentCap != nil && newSize.Cmp(*currentCap) < 0 {
+			log.Info("Skipping PVC storage shrink: Kubernetes does not support reducing PVC size",
+				"pvc", pvc.Name, "currentCapacity", currentCap.String(), "requestedSize", storageSize)
+			continue
+		}
+		pvcList[index].Spec.Resources.Requests = corev1.ResourceList{
+			corev1.ResourceStorage: newSize,
+		}
+		err := c.Update(ctx, &pvcList[index])
+		if err != nil {
+			return err
+		}
+		log.Info("Update storage size for PVC", "pvc", pvc.Name)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package multiclusterobservability
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"path"
+	"slices"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	commonutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	awsRoleARNAnnotation        = "eks.amazonaws.com/role-arn"
+	awsAudienceAnnotation       = "eks.amazonaws.com/audience"
+	azureClientIDAnnotation     = "azure.workload.identity/client-id"
+	azureTenantIDAnnotation     = "azure.workload.identity/tenant-id"
+	azureUseLabel               = "azure.workload.identity/use"
+	gcpServiceAccountAnnotation = "iam.gke.io/gcp-service-account"
+	boundSATokenFileName        = "token"
+
+	// openshiftAudience is the audience of the token projected by the Observatorium operator.
+	openshiftAudience = "openshift"
+	// defaultAWSAudience and defaultAzureAudience are the audiences accepted by default by AWS STS and by
+	// Microsoft Entra ID.
+	defaultAWSAudience   = "sts.amazonaws.com"
+	defaultAzureAudience = "api://AzureADTokenExchange"
+)
+
+// usesServiceAccountToken returns true when the Thanos components access the object storage with the projected
+// token of their service account.
+func usesServiceAccountToken(storageConfig *mcov1beta2.StorageConfig) bool {
+	return storageConfig.WorkloadIdentity != nil || storageConfig.MetricObjectStorage.ServiceAccountProjection
+}
+
+// workloadIdentityAudience returns the audience of the projected service account token exchanged for cloud
+// credentials. GCP reads the audience from the external_account credentials config, which points at the token
+// projected by the Observatorium operator.
+func workloadIdentityAudience(identity *mcov1beta2.WorkloadIdentityConfig) string {
+	switch {
+	case identity == nil:
+		return openshiftAudience
+	case identity.AWS != nil:
+		return cmp.Or(identity.AWS.Audience, defaultAWSAudience)
+	case identity.Azure != nil:
+		return cmp.Or(identity.Azure.Audience, defaultAzureAudience)
+	}
+	return openshiftAudience
+}
+
+// usesObservatoriumToken returns true when the Thanos components read the token projected by the Observatorium
+// operator, which has the openshift audience. Otherwise the workload identity webhook of the cloud provider
+// projects the token with the audience of the identity, and sets the env vars of the cloud SDKs.
+func usesObservatoriumToken(storageConfig *mcov1beta2.StorageConfig) bool {
+	return usesServiceAccountToken(storageConfig) && workloadIdentityAudience(storageConfig.WorkloadIdentity) == openshiftAudience
+}
+
+// workloadIdentityAnnotations returns the service account annotations binding the Thanos components to the
+// cloud identity.
+func workloadIdentityAnnotations(identity *mcov1beta2.WorkloadIdentityConfig) map[string]string {
+	switch {
+	case identity == nil:
+		return nil
+	case identity.AWS != nil:
+		return map[string]string{
+			awsRoleARNAnnotation:  identity.AWS.RoleARN,
+			awsAudienceAnnotation: workloadIdentityAudience(identity),
+		}
+	case identity.Azure != nil:
+		return map[string]string{
+			azureClientIDAnnotation: identity.Azure.ClientID,
+			azureTenantIDAnnotation: identity.Azure.TenantID,
+		}
+	case identity.GCP != nil:
+		return map[string]string{gcpServiceAccountAnnotation: identity.GCP.ServiceAccount}
+	}
+	return nil
+}
+
+// workloadIdentityPodLabels returns the pod labels the workload identity webhook of the cloud provider requires to
+// mutate the pods. Only the Azure webhook selects the pods, the others select the service accounts.
+func workloadIdentityPodLabels(storageConfig *mcov1beta2.StorageConfig) map[string]string {
+	if storageConfig == nil || storageConfig.WorkloadIdentity == nil || storageConfig.WorkloadIdentity.Azure == nil ||
+		usesObservatoriumToken(storageConfig) {
+		return nil
+	}
+	return map[string]string{azureUseLabel: "true"}
+}
+
+// workloadIdentityComponents are the Thanos components accessing the object storage.
+var workloadIdentityComponents = []string{"thanos-receive", "thanos-compact", "thanos-rule", "thanos-store"}
+
+// ensureWorkloadIdentityPodLabels adds the workload identity pod labels to the pod templates of the Thanos
+// StatefulSets accessing the object storage. The Observatorium CR has no pod labels, so the StatefulSets
+// rendered by the Observatorium operator are patched.
+func ensureWorkloadIdentityPodLabels(ctx context.Context, c client.Client, mco *mcov1beta2.MultiClusterObservability) error {
+	podLabels := workloadIdentityPodLabels(mco.Spec.StorageConfig)
+	if podLabels == nil {
+		return nil
+	}
+	for _, component := range workloadIdentityComponents {
+		stsList, err := commonutil.GetStatefulSetList(c, mcoconfig.GetDefaultNamespace(), storageComponentLabels(mco.GetName(), component))
+		if err != nil {
+			return err
+		}
+		for index := range stsList {
+			sts := &stsList[index]
+			newSts := sts.DeepCopy()
+			if newSts.Spec.Template.Labels == nil {
+				newSts.Spec.Template.Labels = map[string]string{}
+			}
+			maps.Copy(newSts.Spec.Template.Labels, podLabels)
+			if maps.Equal(newSts.Spec.Template.Labels, sts.Spec.Template.Labels) {
+				continue
+			}
+			if err := c.Patch(ctx, newSts, client.StrategicMergeFrom(sts)); err != nil {
+				return err
+			}
+			log.Info("Added the workload identity pod labels", "sts", sts.Name)
+		}
+	}
+	return nil
+}
+
+// workloadIdentityStatefulSetToMCO requeues the MCO when a Thanos StatefulSet changes, so that the workload
+// identity pod labels are added back when the Observatorium operator renders it again.
+func workloadIdentityStatefulSetToMCO(c client.Client) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		if obj.GetNamespace() != mcoconfig.GetDefaultNamespace() ||
+			!slices.Contains(workloadIdentityComponents, obj.GetLabels()["app.kubernetes.io/name"]) {
+			return nil
+		}
+		instance, err := mcoconfig.GetMCOInstance(ctx, c)
+		if err != nil || workloadIdentityPodLabels(instance.Spec.StorageConfig) == nil ||
+			obj.GetLabels()["app.kubernetes.io/instance"] != instance.GetName() {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: instance.GetName()}}}
+	}
+}
+
+// workloadIdentityEnvVars returns the env vars the cloud SDKs of the Thanos components read to exchange the
+// projected service account token for cloud credentials. The GCP SDK reads the credentials config from the
+// object store config instead.
+func workloadIdentityEnvVars(identity *mcov1beta2.WorkloadIdentityConfig) map[string]string {
+	tokenFile := path.Join(boundSATokenMountPath, boundSATokenFileName)
+	switch {
+	case identity == nil:
+		return nil
+	case identity.AWS != nil:
+		return map[string]string{
+			"AWS_ROLE_ARN":                identity.AWS.RoleARN,
+			"AWS_WEB_IDENTITY_TOKEN_FILE": tokenFile,
+		}
+	case identity.Azure != nil:
+		return map[string]string{
+			"AZURE_CLIENT_ID":            identity.Azure.ClientID,
+			"AZURE_TENANT_ID":            identity.Azure.TenantID,
+			"AZURE_FEDERATED_TOKEN_FILE": tokenFile,
+		}
+	}
+	return nil
+}
+
+// withWorkloadIdentityAnnotations adds the workload identity annotations to the service account annotations of
+// a Thanos component. The annotations of the advanced config take precedence.
+func withWorkloadIdentityAnnotations(storageConfig *mcov1beta2.StorageConfig, annotations map[string]string) map[string]string {
+	identityAnnotations := workloadIdentityAnnotations(storageConfig.WorkloadIdentity)
+	if identityAnnotations == nil {
+		return annotations
+	}
+	maps.Copy(identityAnnotations, annotations)
+	return identityAnnotations
+}
+
+// newWorkloadIdentityEnv returns the workload identity env vars of the containers accessing the object storage.
+func newWorkloadIdentityEnv(storageConfig *mcov1beta2.StorageConfig) []v1.EnvVar {
+	envVars := workloadIdentityEnvVars(storageConfig.WorkloadIdentity)
+	var env []v1.EnvVar
+	for _, name := range slices.Sorted(maps.Keys(envVars)) {
+		env = append(env, v1.EnvVar{Name: name, Value: envVars[name]})
+	}
+	return env
+}
@@ -0,0 +1,193 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package multiclusterobservability
+
+import (
+	"context"
+	"testing"
+
+	mcoshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	observatoriumv1alpha1 "github.com/stolostron/observatorium-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWorkloadIdentityObservatoriumSpec(t *testing.T) {
+	mco := &mcov1beta2.MultiClusterObservability{
+		TypeMeta:   metav1.TypeMeta{Kind: "MultiClusterObservability"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{
+				MetricObjectStorage:     &mcoshared.PreConfiguredStorage{Key: "thanos.yaml", Name: "thanos-object-storage"},
+				AlertmanagerStorageSize: "1Gi",
+				CompactStorageSize:      "1Gi",
+				RuleStorageSize:         "1Gi",
+				ReceiveStorageSize:      "1Gi",
+				StoreStorageSize:        "1Gi",
+				WorkloadIdentity: &mcov1beta2.WorkloadIdentityConfig{
+					AWS: &mcov1beta2.AWSWorkloadIdentity{RoleARN: "arn:aws:iam::123456789012:role/thanos"},
+				},
+			},
+			AdvancedConfig: &mcov1beta2.AdvancedConfig{
+				Store: &mcov1beta2.StoreSpec{
+					ServiceAccountAnnotations: map[string]string{awsRoleARNAnnotation: "arn:aws:iam::123456789012:role/store"},
+				},
+			},
+			ObservabilityAddonSpec: &mcoshared.ObservabilityAddonSpec{},
+		},
+	}
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, mcov1beta2.SchemeBuilder.AddToScheme(s))
+	require.NoError(t, observatoriumv1alpha1.SchemeBuilder.AddToScheme(s))
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(mco, alertmanagerCABundleConfigMap()).Build()
+
+	// The token with the default audience of AWS is projected by the pod identity webhook.
+	obs, err := newDefaultObservatoriumSpec(cl, mco, storageClassName, "")
+	require.NoError(t, err)
+	assert.False(t, obs.ObjectStorageConfig.Thanos.ServiceAccountProjection)
+	assert.Empty(t, obs.EnvVars)
+	for _, annotations := range []map[string]string{
+		obs.Thanos.Receivers.ServiceAccountAnnotations,
+		obs.Thanos.Rule.ServiceAccountAnnotations,
+		obs.Thanos.Compact.ServiceAccountAnnotations,
+	} {
+		assert.Equal(t, map[string]string{
+			awsRoleARNAnnotation:  "arn:aws:iam::123456789012:role/thanos",
+			awsAudienceAnnotation: "sts.amazonaws.com",
+		}, annotations)
+	}
+	// The annotations of the advanced config take precedence.
+	assert.Equal(t, "arn:aws:iam::123456789012:role/store", obs.Thanos.Store.ServiceAccountAnnotations[awsRoleARNAnnotation])
+
+	// The token with the openshift audience is projected by the Observatorium operator.
+	mco.Spec.StorageConfig.WorkloadIdentity.AWS.Audience = openshiftAudience
+	obs, err = newDefaultObservatoriumSpec(cl, mco, storageClassName, "")
+	require.NoError(t, err)
+	assert.True(t, obs.ObjectStorageConfig.Thanos.ServiceAccountProjection)
+	assert.Equal(t, map[string]string{
+		"AWS_ROLE_ARN":                "arn:aws:iam::123456789012:role/thanos",
+		"AWS_WEB_IDENTITY_TOKEN_FILE": "/var/run/secrets/openshift/serviceaccount/token",
+	}, obs.EnvVars)
+
+	mco.Spec.StorageConfig.WorkloadIdentity = &mcov1beta2.WorkloadIdentityConfig{
+		GCP: &mcov1beta2.GCPWorkloadIdentity{ServiceAccount: "thanos@project.iam.gserviceaccount.com"},
+	}
+	obs, err = newDefaultObservatoriumSpec(cl, mco, storageClassName, "")
+	require.NoError(t, err)
+	assert.True(t, obs.ObjectStorageConfig.Thanos.ServiceAccountProjection)
+	assert.Empty(t, obs.EnvVars)
+	assert.Equal(t, map[string]string{gcpServiceAccountAnnotation: "thanos@project.iam.gserviceaccount.com"},
+		obs.Thanos.Compact.ServiceAccountAnnotations)
+
+	mco.Spec.StorageConfig.WorkloadIdentity = nil
+	obs, err = newDefaultObservatoriumSpec(cl, mco, storageClassName, "")
+	require.NoError(t, err)
+	assert.False(t, obs.ObjectStorageConfig.Thanos.ServiceAccountProjection)
+	assert.Nil(t, obs.Thanos.Compact.ServiceAccountAnnotations)
+}
+
+func TestWorkloadIdentityRetentionCronJob(t *testing.T) {
+	mco := &mcov1beta2.MultiClusterObservability{
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{
+				MetricObjectStorage: &mcoshared.PreConfiguredStorage{Key: "thanos.yaml", Name: "thanos-object-storage"},
+				WorkloadIdentity: &mcov1beta2.WorkloadIdentityConfig{
+					Azure: &mcov1beta2.AzureWorkloadIdentity{ClientID: "client", TenantID: "tenant"},
+				},
+			},
+		},
+	}
+	cronJob, err := newTenantRetentionCronJob(mco, observatoriumv1alpha1.APITenant{Name: "dev", ID: "dev-id"},
+		tenantRetention{raw: "14d", fiveMinutes: "14d", oneHour: "14d"}, observatoriumv1alpha1.ThanosSpec{})
+	require.NoError(t, err)
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "AZURE_CLIENT_ID", Value: "client"},
+		{Name: "AZURE_FEDERATED_TOKEN_FILE", Value: "/var/run/secrets/openshift/serviceaccount/token"},
+		{Name: "AZURE_TENANT_ID", Value: "tenant"},
+	}, podSpec.Containers[0].Env)
+	assert.Contains(t, podSpec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{Name: "bound-sa-token", MountPath: boundSATokenMountPath, ReadOnly: true})
+	projection := podSpec.Volumes[len(podSpec.Volumes)-1].Projected.Sources[0].ServiceAccountToken
+	assert.Equal(t, "api://AzureADTokenExchange", projection.Audience)
+
+	mco.Spec.StorageConfig.WorkloadIdentity.Azure.Audience = "api://thanos"
+	cronJob, err = newTenantRetentionCronJob(mco, observatoriumv1alpha1.APITenant{Name: "dev", ID: "dev-id"},
+		tenantRetention{raw: "14d", fiveMinutes: "14d", oneHour: "14d"}, observatoriumv1alpha1.ThanosSpec{})
+	require.NoError(t, err)
+	podSpec = cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assert.Equal(t, "api://thanos", podSpec.Volumes[len(podSpec.Volumes)-1].Projected.Sources[0].ServiceAccountToken.Audience)
+}
+
+func TestWorkloadIdentityPodLabels(t *testing.T) {
+	ctx := context.Background()
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			StorageConfig: &mcov1beta2.StorageConfig{
+				MetricObjectStorage: &mcoshared.PreConfiguredStorage{Key: "thanos.yaml", Name: "thanos-object-storage"},
+				WorkloadIdentity: &mcov1beta2.WorkloadIdentityConfig{
+					Azure: &mcov1beta2.AzureWorkloadIdentity{ClientID: "client", TenantID: "tenant"},
+				},
+			},
+		},
+	}
+	newStatefulSet := func(name, component string) *appsv1.StatefulSet {
+		return &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: mcoconfig.GetDefaultNamespace(),
+				Labels: map[string]string{
+					"app.kubernetes.io/instance": "observability",
+					"app.kubernetes.io/name":     component,
+				},
+			},
+			Spec: appsv1.StatefulSetSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app.kubernetes.io/name": component}},
+				},
+			},
+		}
+	}
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		newStatefulSet("observability-thanos-receive-default", "thanos-receive"),
+		newStatefulSet("observability-thanos-compact", "thanos-compact"),
+		newStatefulSet("observability-thanos-rule", "thanos-rule"),
+		newStatefulSet("observability-thanos-store-shard-0", "thanos-store"),
+		newStatefulSet("observability-thanos-store-memcached", "memcached"),
+	).Build()
+
+	// The Azure webhook only mutates the pods with the use label.
+	require.NoError(t, ensureWorkloadIdentityPodLabels(ctx, cl, mco))
+	stsList := &appsv1.StatefulSetList{}
+	require.NoError(t, cl.List(ctx, stsList))
+	for _, sts := range stsList.Items {
+		if sts.Labels["app.kubernetes.io/name"] == "memcached" {
+			assert.NotContains(t, sts.Spec.Template.Labels, azureUseLabel, sts.Name)
+			continue
+		}
+		assert.Equal(t, "true", sts.Spec.Template.Labels[azureUseLabel], sts.Name)
+		assert.Equal(t, sts.Labels["app.kubernetes.io/name"], sts.Spec.Template.Labels["app.kubernetes.io/name"], sts.Name)
+	}
+
+	// The token projected by the Observatorium operator is read without the webhook.
+	mco.Spec.StorageConfig.WorkloadIdentity.Azure.Audience = openshiftAudience
+	assert.Nil(t, workloadIdentityPodLabels(mco.Spec.StorageConfig))
+	mco.Spec.StorageConfig.WorkloadIdentity = &mcov1beta2.WorkloadIdentityConfig{
+		AWS: &mcov1beta2.AWSWorkloadIdentity{RoleARN: "arn:aws:iam::123456789012:role/thanos"},
+	}
+	assert.Nil(t, workloadIdentityPodLabels(mco.Spec.StorageConfig))
+}
@@ -0,0 +1,360 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilityalertroute
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	amconfig "github.com/prometheus/alertmanager/config"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var log = logf.Log.WithName("controller_observabilityalertroute")
+
+const (
+	reasonAccepted           = "Accepted"
+	reasonInvalidRoute       = "InvalidRoute"
+	reasonConfigConflict     = "ConfigConflict"
+	reasonInvalidBaseConfig  = "InvalidBaseConfig"
+	reasonNoMatchingClusters = "NoMatchingClusters"
+	reasonClusterSetNotBound = "ClusterSetNotBound"
+
+	// secretResyncPeriod is how often the secrets referenced by the ObservabilityAlertRoutes are read again,
+	// as they are in the namespaces of the teams, which are not watched.
+	secretResyncPeriod = 10 * time.Minute
+)
+
+// ObservabilityAlertRouteReconciler merges the ObservabilityAlertRoutes into the base Alertmanager config,
+// and writes the result into the secret mounted by Alertmanager.
+type ObservabilityAlertRouteReconciler struct {
+	Client client.Client
+	// APIReader reads the secrets referenced by the ObservabilityAlertRoutes, the ManagedClusterSetBindings
+	// of their namespaces and the ManagedClusterSets, which are not in the cache.
+	APIReader client.Reader
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=observability.open-cluster-management.io,resources=observabilityalertroutes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=observability.open-cluster-management.io,resources=observabilityalertroutes/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclustersetbindings,verbs=get;list
+// +kubebuilder:rbac:groups=cluster.open-cluster-management.io,resources=managedclustersets,verbs=get;list
+
+// Reconcile merges all the ObservabilityAlertRoutes, as any of them, or any managed cluster, can change the
+// Alertmanager config.
+func (r *ObservabilityAlertRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", req.Namespace, "Request.Name", req.Name)
+	reqLogger.Info("Reconciling ObservabilityAlertRoutes")
+
+	mcoList := &mcov1beta2.MultiClusterObservabilityList{}
+	if err := r.Client.List(ctx, mcoList); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list MultiClusterObservability custom resources: %w", err)
+	}
+	if len(mcoList.Items) == 0 {
+		reqLogger.Info("no MultiClusterObservability CR exists, nothing to do")
+		return ctrl.Result{}, nil
+	}
+	mco := &mcoList.Items[0]
+	if mco.GetDeletionTimestamp() != nil || config.IsPaused(mco.GetAnnotations()) {
+		return ctrl.Result{}, nil
+	}
+
+	// The base config is owned by the users, and created by the MultiClusterObservability controller.
+	base := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: config.AlertmanagerConfigName, Namespace: config.GetDefaultNamespace()}, base)
+	if apierrors.IsNotFound(err) {
+		reqLogger.Info("the base Alertmanager config does not exist yet", "secret", config.AlertmanagerConfigName)
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get the secret %s: %w", config.AlertmanagerConfigName, err)
+	}
+	baseConfig := string(base.Data[config.AlertmanagerConfigFileKey])
+	_, baseErr := amconfig.Load(baseConfig)
+
+	routes := &mcov1beta2.ObservabilityAlertRouteList{}
+	if err := r.Client.List(ctx, routes); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ObservabilityAlertRoutes: %w", err)
+	}
+	slices.SortFunc(routes.Items, func(a, b mcov1beta2.ObservabilityAlertRoute) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	clusters := &clusterv1.ManagedClusterList{}
+	if err := r.Client.List(ctx, clusters); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+	clusterSets := &clusterv1beta2.ManagedClusterSetList{}
+	if err := r.APIReader.List(ctx, clusterSets); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ManagedClusterSets: %w", err)
+	}
+	members := clusterSetMembers(clusterSets.Items, clusters.Items)
+
+	// Each route is merged with the base config and the routes accepted before it, so that a route conflicting
+	// with the others is reported and left out, without blocking the others.
+	merged := baseConfig
+	accepted := []teamConfig{}
+	boundClusterSets := map[string][]string{}
+	statuses := make([]mcov1beta2.ObservabilityAlertRouteStatus, len(routes.Items))
+	for i := range routes.Items {
+		route := &routes.Items[i]
+		status := route.Status.DeepCopy()
+		status.ObservedGeneration = route.Generation
+		condition := metav1.Condition{
+			Type:               mcov1beta2.AlertRouteAcceptedCondition,
+			ObservedGeneration: route.Generation,
+		}
+
+		bound, ok := boundClusterSets[route.Namespace]
+		if !ok {
+			var err error
+			if bound, err = r.getBoundClusterSets(ctx, route.Namespace); err != nil {
+				return ctrl.Result{}, err
+			}
+			boundClusterSets[route.Namespace] = bound
+		}
+		unbound := slices.DeleteFunc(slices.Clone(route.Spec.ClusterSets), func(clusterSet string) bool {
+			return slices.Contains(bound, clusterSet)
+		})
+		targets, err := selectTargets(route, members, bound)
+		status.MatchedClusters = int32(len(targets)) // #nosec G115 -- the number of managed clusters fits in an int32.
+		var team teamConfig
+		if err == nil && len(unbound) == 0 {
+			team, err = renderTeamConfig(ctx, route, targets, r.getSecretKey)
+		}
+		switch {
+		case len(unbound) > 0:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonClusterSetNotBound
+			condition.Message = fmt.Sprintf("The ManagedClusterSets %s are not bound to the namespace %s",
+				strings.Join(unbound, ", "), route.Namespace)
+		case err != nil:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonInvalidRoute
+			condition.Message = err.Error()
+		case len(targets) == 0:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonNoMatchingClusters
+			condition.Message = "No managed cluster matches the cluster sets or selector of the routes"
+		case baseErr != nil:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonInvalidBaseConfig
+			condition.Message = fmt.Sprintf("The Alertmanager config in the secret %s is invalid: %v",
+				config.AlertmanagerConfigName, baseErr)
+		default:
+			content, err := mergeConfig(baseConfig, append(slices.Clone(accepted), team))
+			if err != nil {
+				condition.Status = metav1.ConditionFalse
+				condition.Reason = reasonConfigConflict
+				condition.Message = fmt.Sprintf("The routes conflict with the Alertmanager config: %v", err)
+				break
+			}
+			merged = content
+			accepted = append(accepted, team)
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = reasonAccepted
+			condition.Message = fmt.Sprintf("The routes and %d receivers are merged into the Alertmanager config",
+				len(team.receivers))
+		}
+		meta.SetStatusCondition(&status.Conditions, condition)
+		statuses[i] = *status
+	}
+
+	if err := r.applyConfigSecret(ctx, mco, base, merged, len(accepted)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var errs []error
+	for i := range routes.Items {
+		route := &routes.Items[i]
+		if equality.Semantic.DeepEqual(route.Status, statuses[i]) {
+			continue
+		}
+		route.Status = statuses[i]
+		if err := r.Client.Status().Update(ctx, route); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update the status of the ObservabilityAlertRoute %s/%s: %w",
+				route.Namespace, route.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return ctrl.Result{}, errors.Join(errs...)
+	}
+	if len(routes.Items) > 0 {
+		return ctrl.Result{RequeueAfter: secretResyncPeriod}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// getBoundClusterSets returns the ManagedClusterSets bound to a namespace. Creating a ManagedClusterSetBinding
+// requires the permission to create managedclustersets/bind, so that the routes of a team only target the
+// managed clusters the team is allowed to use, as the Placements of the namespace.
+func (r *ObservabilityAlertRouteReconciler) getBoundClusterSets(ctx context.Context, namespace string) ([]string, error) {
+	bindings := &clusterv1beta2.ManagedClusterSetBindingList{}
+	if err := r.APIReader.List(ctx, bindings, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list the ManagedClusterSetBindings of the namespace %s: %w", namespace, err)
+	}
+	clusterSets := make([]string, 0, len(bindings.Items))
+	for _, binding := range bindings.Items {
+		clusterSets = append(clusterSets, binding.Spec.ClusterSet)
+	}
+	return clusterSets, nil
+}
+
+// clusterSetMembers returns the managed clusters of each ManagedClusterSet, selected by the clusterset label, or
+// by the label selector of the ManagedClusterSet, as for the global ManagedClusterSet.
+func clusterSetMembers(clusterSets []clusterv1beta2.ManagedClusterSet,
+	clusters []clusterv1.ManagedCluster,
+) map[string][]clusterv1.ManagedCluster {
+	members := make(map[string][]clusterv1.ManagedCluster, len(clusterSets))
+	for _, clusterSet := range clusterSets {
+		var selector labels.Selector
+		switch clusterSet.Spec.ClusterSelector.SelectorType {
+		case clusterv1beta2.LabelSelector:
+			var err error
+			selector, err = metav1.LabelSelectorAsSelector(clusterSet.Spec.ClusterSelector.LabelSelector)
+			if err != nil {
+				log.Info("Ignoring the ManagedClusterSet with an invalid label selector", "clusterset", clusterSet.Name,
+					"error", err.Error())
+				continue
+			}
+		default:
+			selector = labels.SelectorFromSet(labels.Set{config.ClusterSetLabelKey: clusterSet.Name})
+		}
+		for _, cluster := range clusters {
+			if selector.Matches(labels.Set(cluster.Labels)) {
+				members[clusterSet.Name] = append(members[clusterSet.Name], cluster)
+			}
+		}
+	}
+	return members
+}
+
+// selectTargets returns the names of the managed clusters targeted by a route: the members of its
+// ManagedClusterSets, and the members of the bound ManagedClusterSets matching its cluster selector.
+// A route without any of them targets all the members of the bound ManagedClusterSets.
+func selectTargets(route *mcov1beta2.ObservabilityAlertRoute, members map[string][]clusterv1.ManagedCluster,
+	bound []string,
+) ([]string, error) {
+	var targets []string
+	for _, clusterSet := range route.Spec.ClusterSets {
+		if !slices.Contains(bound, clusterSet) {
+			continue
+		}
+		for _, cluster := range members[clusterSet] {
+			targets = append(targets, cluster.Name)
+		}
+	}
+	if route.Spec.ClusterSelector != nil || len(route.Spec.ClusterSets) == 0 {
+		var boundClusters []clusterv1.ManagedCluster
+		for _, clusterSet := range bound {
+			boundClusters = append(boundClusters, members[clusterSet]...)
+		}
+		selected, _, err := config.SelectClusters(nil, route.Spec.ClusterSelector, boundClusters)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, selected...)
+	}
+	slices.Sort(targets)
+	return slices.Compact(targets), nil
+}
+
+// getSecretKey reads a key of a secret referenced by an ObservabilityAlertRoute.
+func (r *ObservabilityAlertRouteReconciler) getSecretKey(ctx context.Context, namespace string,
+	selector corev1.SecretKeySelector,
+) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Name: selector.Name, Namespace: namespace}, secret); err != nil {
+		return "", fmt.Errorf("failed to get the secret %s: %w", selector.Name, err)
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return "", fmt.Errorf("the secret %s has no key %s", selector.Name, selector.Key)
+	}
+	return string(value), nil
+}
+
+// applyConfigSecret writes the merged config into the secret mounted by Alertmanager, along with the other keys
+// of the base secret, such as the notification templates.
+func (r *ObservabilityAlertRouteReconciler) applyConfigSecret(ctx context.Context,
+	mco *mcov1beta2.MultiClusterObservability, base *corev1.Secret, merged string, teams int,
+) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.AlertmanagerGeneratedConfigName,
+			Namespace: config.GetDefaultNamespace(),
+		},
+	}
+	res, err := ctrl.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = maps.Clone(base.Data)
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		secret.Data[config.AlertmanagerConfigFileKey] = []byte(merged)
+		return controllerutil.SetControllerReference(mco, secret, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply the secret %s: %w", secret.Name, err)
+	}
+	if res != controllerutil.OperationResultNone {
+		log.Info("Applied the Alertmanager config", "operation", res, "teams", teams)
+	}
+	return nil
+}
+
+// enqueueRoutes maps all the events to a single request, as all the routes are merged into one config.
+func enqueueRoutes(context.Context, client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{
+		Name:      config.AlertmanagerGeneratedConfigName,
+		Namespace: config.GetDefaultNamespace(),
+	}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ObservabilityAlertRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	configSecretsPred := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return (obj.GetName() == config.AlertmanagerConfigName || obj.GetName() == config.AlertmanagerGeneratedConfigName) &&
+			obj.GetNamespace() == config.GetDefaultNamespace()
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("observabilityalertroute").
+		Watches(&mcov1beta2.ObservabilityAlertRoute{}, handler.EnqueueRequestsFromMapFunc(enqueueRoutes),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&mcov1beta2.MultiClusterObservability{}, handler.EnqueueRequestsFromMapFunc(enqueueRoutes),
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		// The routes are restricted to the alerts of the clusters matching their cluster sets or selector.
+		Watches(&clusterv1.ManagedCluster{}, handler.EnqueueRequestsFromMapFunc(enqueueRoutes),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(enqueueRoutes),
+			builder.WithPredicates(configSecretsPred)).
+		Complete(r)
+}
@@ -0,0 +1,233 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilityalertroute
+
+import (
+	"testing"
+
+	amconfig "github.com/prometheus/alertmanager/config"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestObservabilityAlertRouteReconcile(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(s))
+	require.NoError(t, mcov1beta2.AddToScheme(s))
+	require.NoError(t, clusterv1.Install(s))
+	require.NoError(t, clusterv1beta2.Install(s))
+
+	mco := &mcov1beta2.MultiClusterObservability{ObjectMeta: metav1.ObjectMeta{Name: "observability"}}
+	base := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: config.AlertmanagerConfigName, Namespace: config.GetDefaultNamespace()},
+		Data: map[string][]byte{
+			config.AlertmanagerConfigFileKey: []byte(baseConfig),
+			"custom.tmpl":                    []byte(`{{ define "custom" }}{{ end }}`),
+		},
+	}
+	prod := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name:   "prod-1",
+		Labels: map[string]string{config.ClusterSetLabelKey: "prod"},
+	}}
+	webhookSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "webhook", Namespace: "team-a"},
+		Data:       map[string][]byte{"url": []byte("https://team-a.example.com")},
+	}
+	webhookReceiver := mcov1beta2.AlertReceiver{
+		Name: "webhook",
+		WebhookConfigs: []mcov1beta2.AlertWebhookConfig{{
+			URLSecret: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "webhook"}, Key: "url"},
+		}},
+	}
+	accepted := newAlertRoute("team-a", "prod", mcov1beta2.ObservabilityAlertRouteSpec{
+		ClusterSets: []string{"prod"},
+		Receiver:    "webhook",
+		Receivers:   []mcov1beta2.AlertReceiver{webhookReceiver},
+	})
+	staging := newAlertRoute("team-a", "staging", mcov1beta2.ObservabilityAlertRouteSpec{
+		ClusterSets: []string{"staging"},
+		Receiver:    "webhook",
+		Receivers:   []mcov1beta2.AlertReceiver{webhookReceiver},
+	})
+	// The secret is in the namespace of team-a, so it is not found in the namespace of team-b.
+	missingSecret := newAlertRoute("team-b", "prod", mcov1beta2.ObservabilityAlertRouteSpec{
+		Receiver:  "webhook",
+		Receivers: []mcov1beta2.AlertReceiver{webhookReceiver},
+	})
+	conflict := newAlertRoute("team-c", "prod", mcov1beta2.ObservabilityAlertRouteSpec{
+		Receiver: "email",
+		Receivers: []mcov1beta2.AlertReceiver{{
+			Name:         "email",
+			EmailConfigs: []mcov1beta2.AlertEmailConfig{{To: "team-c@example.com"}},
+		}},
+	})
+
+	// The namespace of team-d has no ManagedClusterSetBinding, so it can't target the clusters of prod, neither by
+	// ManagedClusterSet nor by label.
+	emailReceiver := mcov1beta2.AlertReceiver{
+		Name:         "email",
+		EmailConfigs: []mcov1beta2.AlertEmailConfig{{To: "team-d@example.com"}},
+	}
+	unbound := newAlertRoute("team-d", "prod", mcov1beta2.ObservabilityAlertRouteSpec{
+		ClusterSets: []string{"prod"},
+		Receiver:    "email",
+		Receivers:   []mcov1beta2.AlertReceiver{emailReceiver},
+	})
+	selected := newAlertRoute("team-d", "selected", mcov1beta2.ObservabilityAlertRouteSpec{
+		ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{config.ClusterSetLabelKey: "prod"}},
+		Receiver:        "email",
+		Receivers:       []mcov1beta2.AlertReceiver{emailReceiver},
+	})
+
+	// The members of the global ManagedClusterSet are selected by its label selector, not by the clusterset label.
+	global := newClusterSet("global")
+	global.Spec.ClusterSelector = clusterv1beta2.ManagedClusterSelector{
+		SelectorType:  clusterv1beta2.LabelSelector,
+		LabelSelector: &metav1.LabelSelector{},
+	}
+	globalWebhookSecret := webhookSecret.DeepCopy()
+	globalWebhookSecret.Namespace = "team-e"
+	globalRoute := newAlertRoute("team-e", "global", mcov1beta2.ObservabilityAlertRouteSpec{
+		ClusterSets: []string{"global"},
+		Receiver:    "webhook",
+		Receivers:   []mcov1beta2.AlertReceiver{webhookReceiver},
+	})
+	globalSelected := newAlertRoute("team-e", "selected", mcov1beta2.ObservabilityAlertRouteSpec{
+		ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{config.ClusterSetLabelKey: "prod"}},
+		Receiver:        "email",
+		Receivers:       []mcov1beta2.AlertReceiver{emailReceiver},
+	})
+
+	c := fake.NewClientBuilder().WithScheme(s).
+		WithObjects(mco, base, prod, webhookSecret, accepted, staging, missingSecret, conflict, unbound, selected,
+			globalWebhookSecret, globalRoute, globalSelected, newClusterSet("prod"), newClusterSet("staging"), global,
+			newClusterSetBinding("team-a", "prod"), newClusterSetBinding("team-a", "staging"),
+			newClusterSetBinding("team-b", "prod"), newClusterSetBinding("team-c", "prod"),
+			newClusterSetBinding("team-e", "global")).
+		WithStatusSubresource(&mcov1beta2.ObservabilityAlertRoute{}).
+		Build()
+	r := &ObservabilityAlertRouteReconciler{Client: c, APIReader: c, Scheme: s}
+	result, err := r.Reconcile(t.Context(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, secretResyncPeriod, result.RequeueAfter)
+
+	generated := &corev1.Secret{}
+	require.NoError(t, c.Get(t.Context(), types.NamespacedName{
+		Name:      config.AlertmanagerGeneratedConfigName,
+		Namespace: config.GetDefaultNamespace(),
+	}, generated))
+	assert.Equal(t, "observability", generated.OwnerReferences[0].Name)
+	assert.Equal(t, base.Data["custom.tmpl"], generated.Data["custom.tmpl"])
+	merged, err := amconfig.Load(string(generated.Data[config.AlertmanagerConfigFileKey]))
+	require.NoError(t, err)
+	require.Len(t, merged.Route.Routes, 3)
+	assert.Equal(t, "team-a/prod/webhook", merged.Route.Routes[0].Receiver)
+	assert.Equal(t, `cluster="prod-1"`, merged.Route.Routes[0].Matchers[0].String())
+	assert.Equal(t, "team-e/global/webhook", merged.Route.Routes[1].Receiver)
+	assert.Equal(t, `cluster="prod-1"`, merged.Route.Routes[1].Matchers[0].String())
+	assert.Equal(t, "https://team-a.example.com", merged.Receivers[1].WebhookConfigs[0].URL.String())
+
+	type expectedStatus struct {
+		status  metav1.ConditionStatus
+		reason  string
+		matched int32
+	}
+	expected := map[string]expectedStatus{
+		"team-a/prod":     {metav1.ConditionTrue, reasonAccepted, 1},
+		"team-a/staging":  {metav1.ConditionFalse, reasonNoMatchingClusters, 0},
+		"team-b/prod":     {metav1.ConditionFalse, reasonInvalidRoute, 1},
+		"team-c/prod":     {metav1.ConditionFalse, reasonConfigConflict, 1},
+		"team-d/prod":     {metav1.ConditionFalse, reasonClusterSetNotBound, 0},
+		"team-d/selected": {metav1.ConditionFalse, reasonNoMatchingClusters, 0},
+		"team-e/global":   {metav1.ConditionTrue, reasonAccepted, 1},
+		"team-e/selected": {metav1.ConditionFalse, reasonConfigConflict, 1},
+	}
+	assertStatuses := func() {
+		routes := &mcov1beta2.ObservabilityAlertRouteList{}
+		require.NoError(t, c.List(t.Context(), routes))
+		for _, route := range routes.Items {
+			name := route.Namespace + "/" + route.Name
+			condition := meta.FindStatusCondition(route.Status.Conditions, mcov1beta2.AlertRouteAcceptedCondition)
+			require.NotNil(t, condition, name)
+			assert.Equal(t, expected[name].status, condition.Status, name)
+			assert.Equal(t, expected[name].reason, condition.Reason, name)
+			assert.Equal(t, expected[name].matched, route.Status.MatchedClusters, name)
+			assert.Equal(t, int64(1), route.Status.ObservedGeneration, name)
+		}
+	}
+	assertStatuses()
+
+	// An invalid base config is copied as is, and no route is merged.
+	base.Data[config.AlertmanagerConfigFileKey] = []byte("route: {}")
+	require.NoError(t, c.Update(t.Context(), base))
+	_, err = r.Reconcile(t.Context(), ctrl.Request{})
+	require.NoError(t, err)
+	require.NoError(t, c.Get(t.Context(), types.NamespacedName{Name: generated.Name, Namespace: generated.Namespace}, generated))
+	assert.Equal(t, "route: {}", string(generated.Data[config.AlertmanagerConfigFileKey]))
+	expected["team-a/prod"] = expectedStatus{metav1.ConditionFalse, reasonInvalidBaseConfig, 1}
+	expected["team-c/prod"] = expectedStatus{metav1.ConditionFalse, reasonInvalidBaseConfig, 1}
+	expected["team-e/global"] = expectedStatus{metav1.ConditionFalse, reasonInvalidBaseConfig, 1}
+	expected["team-e/selected"] = expectedStatus{metav1.ConditionFalse, reasonInvalidBaseConfig, 1}
+	assertStatuses()
+}
+
+func newClusterSet(name string) *clusterv1beta2.ManagedClusterSet {
+	return &clusterv1beta2.ManagedClusterSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: clusterv1beta2.ManagedClusterSetSpec{
+			ClusterSelector: clusterv1beta2.ManagedClusterSelector{SelectorType: clusterv1beta2.ExclusiveClusterSetLabel},
+		},
+	}
+}
+
+func newClusterSetBinding(namespace, clusterSet string) *clusterv1beta2.ManagedClusterSetBinding {
+	return &clusterv1beta2.ManagedClusterSetBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterSet, Namespace: namespace},
+		Spec:       clusterv1beta2.ManagedClusterSetBindingSpec{ClusterSet: clusterSet},
+	}
+}
+
+func TestClusterSetMembers(t *testing.T) {
+	newCluster := func(name string, labels map[string]string) clusterv1.ManagedCluster {
+		return clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	clusters := []clusterv1.ManagedCluster{
+		newCluster("prod-1", map[string]string{config.ClusterSetLabelKey: "prod", "env": "prod"}),
+		newCluster("dev-1", map[string]string{config.ClusterSetLabelKey: "dev", "env": "dev"}),
+	}
+	labelSelected := newClusterSet("env-prod")
+	labelSelected.Spec.ClusterSelector = clusterv1beta2.ManagedClusterSelector{
+		SelectorType:  clusterv1beta2.LabelSelector,
+		LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+	}
+	invalid := newClusterSet("invalid")
+	invalid.Spec.ClusterSelector = clusterv1beta2.ManagedClusterSelector{
+		SelectorType: clusterv1beta2.LabelSelector,
+		LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "env", Operator: "Unknown"},
+		}},
+	}
+	// The selector type defaults to the clusterset label.
+	dev := clusterv1beta2.ManagedClusterSet{ObjectMeta: metav1.ObjectMeta{Name: "dev"}}
+
+	members := clusterSetMembers(
+		[]clusterv1beta2.ManagedClusterSet{*newClusterSet("prod"), *labelSelected, *invalid, dev}, clusters)
+	assert.Equal(t, map[string][]clusterv1.ManagedCluster{
+		"prod":     {clusters[0]},
+		"env-prod": {clusters[0]},
+		"dev":      {clusters[1]},
+	}, members)
+}
@@ -0,0 +1,247 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilityalertroute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	amconfig "github.com/prometheus/alertmanager/config"
+	amlabels "github.com/prometheus/alertmanager/pkg/labels"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// clusterLabel is the label holding the name of the managed cluster of the alerts.
+	clusterLabel = "cluster"
+	// namespaceLabel is the label holding the namespace of the alerts.
+	namespaceLabel = "namespace"
+)
+
+// secretKeyGetter returns the value of a key of a secret in the given namespace.
+type secretKeyGetter func(ctx context.Context, namespace string, selector corev1.SecretKeySelector) (string, error)
+
+// teamConfig is the route and the receivers of an ObservabilityAlertRoute, in the Alertmanager config format.
+type teamConfig struct {
+	route     map[string]any
+	receivers []any
+}
+
+// receiverName returns the name of a receiver in the Alertmanager config, unique across the ObservabilityAlertRoutes.
+func receiverName(route *mcov1beta2.ObservabilityAlertRoute, receiver string) string {
+	return route.Namespace + "/" + route.Name + "/" + receiver
+}
+
+// listMatcher returns the matcher of the alerts whose label is one of the given values.
+func listMatcher(name string, values []string) (string, error) {
+	if len(values) == 1 {
+		m, err := amlabels.NewMatcher(amlabels.MatchEqual, name, values[0])
+		if err != nil {
+			return "", err
+		}
+		return m.String(), nil
+	}
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, regexp.QuoteMeta(value))
+	}
+	m, err := amlabels.NewMatcher(amlabels.MatchRegexp, name, strings.Join(quoted, "|"))
+	if err != nil {
+		return "", err
+	}
+	return m.String(), nil
+}
+
+// renderTeamConfig validates an ObservabilityAlertRoute, resolves its secret references and returns its route,
+// restricted to the alerts of the given clusters and of its namespaces, and its receivers.
+func renderTeamConfig(ctx context.Context, route *mcov1beta2.ObservabilityAlertRoute, clusters []string,
+	getSecretKey secretKeyGetter,
+) (teamConfig, error) {
+	var errs []error
+	spec := route.Spec
+
+	receivers := make(map[string]bool, len(spec.Receivers))
+	team := teamConfig{receivers: make([]any, 0, len(spec.Receivers))}
+	for _, receiver := range spec.Receivers {
+		receivers[receiver.Name] = true
+		rendered, err := renderReceiver(ctx, route, receiver, getSecretKey)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("receiver %q: %w", receiver.Name, err))
+			continue
+		}
+		team.receivers = append(team.receivers, rendered)
+	}
+	if !receivers[spec.Receiver] {
+		errs = append(errs, fmt.Errorf("unknown receiver %q", spec.Receiver))
+	}
+
+	// The cluster matcher is always set, so that a team only receives the alerts of its clusters.
+	clusterMatcher, err := listMatcher(clusterLabel, clusters)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	matchers := []string{clusterMatcher}
+	if len(spec.Namespaces) > 0 {
+		namespaceMatcher, err := listMatcher(namespaceLabel, spec.Namespaces)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		matchers = append(matchers, namespaceMatcher)
+	}
+
+	// The team route continues, so that the alerts also reach the routes of the base config and of the other teams.
+	team.route = map[string]any{
+		"receiver": receiverName(route, spec.Receiver),
+		"matchers": matchers,
+		"continue": true,
+	}
+	if len(spec.GroupBy) > 0 {
+		team.route["group_by"] = spec.GroupBy
+	}
+	if spec.GroupWait != "" {
+		team.route["group_wait"] = spec.GroupWait
+	}
+	if spec.GroupInterval != "" {
+		team.route["group_interval"] = spec.GroupInterval
+	}
+	if spec.RepeatInterval != "" {
+		team.route["repeat_interval"] = spec.RepeatInterval
+	}
+
+	subRoutes := make([]any, 0, len(spec.Routes))
+	for i, subRoute := range spec.Routes {
+		if !receivers[subRoute.Receiver] {
+			errs = append(errs, fmt.Errorf("route %d: unknown receiver %q", i+1, subRoute.Receiver))
+		}
+		for _, matcher := range subRoute.Matchers {
+			if _, err := amlabels.ParseMatcher(matcher); err != nil {
+				errs = append(errs, fmt.Errorf("route %d: invalid matcher %q: %w", i+1, matcher, err))
+			}
+		}
+		rendered := map[string]any{"receiver": receiverName(route, subRoute.Receiver)}
+		if len(subRoute.Matchers) > 0 {
+			rendered["matchers"] = subRoute.Matchers
+		}
+		if len(subRoute.GroupBy) > 0 {
+			rendered["group_by"] = subRoute.GroupBy
+		}
+		if subRoute.RepeatInterval != "" {
+			rendered["repeat_interval"] = subRoute.RepeatInterval
+		}
+		if subRoute.Continue {
+			rendered["continue"] = true
+		}
+		subRoutes = append(subRoutes, rendered)
+	}
+	if len(subRoutes) > 0 {
+		team.route["routes"] = subRoutes
+	}
+
+	if len(errs) > 0 {
+		return teamConfig{}, errors.Join(errs...)
+	}
+	return team, nil
+}
+
+// renderReceiver returns a receiver in the Alertmanager config format, with its secret references resolved.
+func renderReceiver(ctx context.Context, route *mcov1beta2.ObservabilityAlertRoute, receiver mcov1beta2.AlertReceiver,
+	getSecretKey secretKeyGetter,
+) (map[string]any, error) {
+	var errs []error
+	secretKey := func(selector corev1.SecretKeySelector) string {
+		value, err := getSecretKey(ctx, route.Namespace, selector)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return value
+	}
+	withSendResolved := func(config map[string]any, sendResolved *bool) map[string]any {
+		if sendResolved != nil {
+			config["send_resolved"] = *sendResolved
+		}
+		return config
+	}
+
+	rendered := map[string]any{"name": receiverName(route, receiver.Name)}
+	if len(receiver.WebhookConfigs) > 0 {
+		configs := make([]any, 0, len(receiver.WebhookConfigs))
+		for _, webhook := range receiver.WebhookConfigs {
+			url := webhook.URL
+			if webhook.URLSecret != nil {
+				url = secretKey(*webhook.URLSecret)
+			}
+			configs = append(configs, withSendResolved(map[string]any{"url": url}, webhook.SendResolved))
+		}
+		rendered["webhook_configs"] = configs
+	}
+	if len(receiver.SlackConfigs) > 0 {
+		configs := make([]any, 0, len(receiver.SlackConfigs))
+		for _, slack := range receiver.SlackConfigs {
+			config := map[string]any{"api_url": secretKey(slack.APIURLSecret)}
+			if slack.Channel != "" {
+				config["channel"] = slack.Channel
+			}
+			configs = append(configs, withSendResolved(config, slack.SendResolved))
+		}
+		rendered["slack_configs"] = configs
+	}
+	if len(receiver.PagerDutyConfigs) > 0 {
+		configs := make([]any, 0, len(receiver.PagerDutyConfigs))
+		for _, pagerDuty := range receiver.PagerDutyConfigs {
+			config := map[string]any{"routing_key": secretKey(pagerDuty.RoutingKeySecret)}
+			configs = append(configs, withSendResolved(config, pagerDuty.SendResolved))
+		}
+		rendered["pagerduty_configs"] = configs
+	}
+	if len(receiver.EmailConfigs) > 0 {
+		configs := make([]any, 0, len(receiver.EmailConfigs))
+		for _, email := range receiver.EmailConfigs {
+			configs = append(configs, withSendResolved(map[string]any{"to": email.To}, email.SendResolved))
+		}
+		rendered["email_configs"] = configs
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return rendered, nil
+}
+
+// mergeConfig adds the routes and the receivers of the teams to the base Alertmanager config, and validates the
+// result as Alertmanager does when loading it. The team routes are evaluated before the routes of the base config.
+func mergeConfig(base string, teams []teamConfig) (string, error) {
+	config := map[string]any{}
+	if err := yaml.Unmarshal([]byte(base), &config); err != nil {
+		return "", fmt.Errorf("failed to parse the base config: %w", err)
+	}
+	root, ok := config["route"].(map[string]any)
+	if !ok {
+		return "", errors.New("the base config has no root route")
+	}
+	baseRoutes, _ := root["routes"].([]any)
+	receivers, _ := config["receivers"].([]any)
+
+	routes := make([]any, 0, len(teams)+len(baseRoutes))
+	for _, team := range teams {
+		routes = append(routes, team.route)
+		receivers = append(receivers, team.receivers...)
+	}
+	root["routes"] = append(routes, baseRoutes...)
+	config["receivers"] = receivers
+
+	content, err := yaml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the config: %w", err)
+	}
+	if _, err := amconfig.Load(string(content)); err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
@@ -0,0 +1,154 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilityalertroute
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	amconfig "github.com/prometheus/alertmanager/config"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const baseConfig = `global:
+  resolve_timeout: 5m
+receivers:
+- name: "null"
+route:
+  group_by: [namespace]
+  receiver: "null"
+  routes:
+  - matchers: ['alertname="Watchdog"']
+    receiver: "null"
+`
+
+func staticSecrets(values map[string]string) secretKeyGetter {
+	return func(_ context.Context, namespace string, selector corev1.SecretKeySelector) (string, error) {
+		value, ok := values[namespace+"/"+selector.Name+"/"+selector.Key]
+		if !ok {
+			return "", fmt.Errorf("the secret %s has no key %s", selector.Name, selector.Key)
+		}
+		return value, nil
+	}
+}
+
+func newAlertRoute(namespace, name string, spec mcov1beta2.ObservabilityAlertRouteSpec) *mcov1beta2.ObservabilityAlertRoute {
+	return &mcov1beta2.ObservabilityAlertRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Generation: 1},
+		Spec:       spec,
+	}
+}
+
+func TestListMatcher(t *testing.T) {
+	matcher, err := listMatcher(clusterLabel, []string{"prod-1", "prod.2"})
+	require.NoError(t, err)
+	assert.Equal(t, `cluster=~"prod-1|prod\\.2"`, matcher)
+
+	matcher, err = listMatcher(clusterLabel, []string{"prod.1"})
+	require.NoError(t, err)
+	assert.Equal(t, `cluster="prod.1"`, matcher)
+}
+
+func TestRenderTeamConfig(t *testing.T) {
+	route := newAlertRoute("team-a", "alerts", mcov1beta2.ObservabilityAlertRouteSpec{
+		Namespaces: []string{"api"},
+		Receiver:   "slack",
+		GroupBy:    []string{"alertname", "cluster"},
+		Routes: []mcov1beta2.AlertSubRoute{{
+			Receiver: "pager",
+			Matchers: []string{`severity="critical"`},
+		}},
+		Receivers: []mcov1beta2.AlertReceiver{
+			{
+				Name: "slack",
+				SlackConfigs: []mcov1beta2.AlertSlackConfig{{
+					APIURLSecret: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "slack"}, Key: "url"},
+					Channel:      "#alerts",
+				}},
+			},
+			{
+				Name: "pager",
+				PagerDutyConfigs: []mcov1beta2.AlertPagerDutyConfig{{
+					RoutingKeySecret: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "pagerduty"}, Key: "key"},
+				}},
+			},
+		},
+	})
+	secrets := staticSecrets(map[string]string{
+		"team-a/slack/url":     "https://hooks.slack.com/services/a",
+		"team-a/pagerduty/key": "routing-key",
+	})
+
+	team, err := renderTeamConfig(t.Context(), route, []string{"prod-1", "prod-2"}, secrets)
+	require.NoError(t, err)
+	assert.Equal(t, "team-a/alerts/slack", team.route["receiver"])
+	assert.Equal(t, []string{`cluster=~"prod-1|prod-2"`, `namespace="api"`}, team.route["matchers"])
+	assert.Equal(t, true, team.route["continue"])
+	require.Len(t, team.route["routes"], 1)
+	assert.Equal(t, "team-a/alerts/pager", team.route["routes"].([]any)[0].(map[string]any)["receiver"])
+	require.Len(t, team.receivers, 2)
+	assert.Equal(t, []any{map[string]any{"api_url": "https://hooks.slack.com/services/a", "channel": "#alerts"}},
+		team.receivers[0].(map[string]any)["slack_configs"])
+	assert.Equal(t, []any{map[string]any{"routing_key": "routing-key"}},
+		team.receivers[1].(map[string]any)["pagerduty_configs"])
+
+	route.Spec.Receiver = "unknown"
+	route.Spec.Routes[0].Matchers = []string{`severity=~"(critical"`}
+	_, err = renderTeamConfig(t.Context(), route, []string{"prod-1"}, staticSecrets(nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `receiver "slack": the secret slack has no key url`)
+	assert.Contains(t, err.Error(), `unknown receiver "unknown"`)
+	assert.Contains(t, err.Error(), `route 1: invalid matcher "severity=~\"(critical\""`)
+}
+
+func TestMergeConfig(t *testing.T) {
+	webhook := func(namespace, name string) teamConfig {
+		route := newAlertRoute(namespace, name, mcov1beta2.ObservabilityAlertRouteSpec{
+			Receiver: "webhook",
+			Receivers: []mcov1beta2.AlertReceiver{{
+				Name:           "webhook",
+				WebhookConfigs: []mcov1beta2.AlertWebhookConfig{{URL: "https://" + namespace + ".example.com"}},
+			}},
+		})
+		team, err := renderTeamConfig(t.Context(), route, []string{"prod-1"}, staticSecrets(nil))
+		require.NoError(t, err)
+		return team
+	}
+
+	content, err := mergeConfig(baseConfig, []teamConfig{webhook("team-a", "alerts"), webhook("team-b", "alerts")})
+	require.NoError(t, err)
+	merged, err := amconfig.Load(content)
+	require.NoError(t, err)
+	require.Len(t, merged.Route.Routes, 3)
+	assert.Equal(t, "team-a/alerts/webhook", merged.Route.Routes[0].Receiver)
+	assert.Equal(t, `cluster="prod-1"`, merged.Route.Routes[0].Matchers[0].String())
+	assert.True(t, merged.Route.Routes[0].Continue)
+	assert.Equal(t, "team-b/alerts/webhook", merged.Route.Routes[1].Receiver)
+	assert.Equal(t, "null", merged.Route.Routes[2].Receiver)
+	require.Len(t, merged.Receivers, 3)
+	assert.Equal(t, "https://team-b.example.com", merged.Receivers[2].WebhookConfigs[0].URL.String())
+
+	// Emails need the SMTP settings of the base config.
+	email := newAlertRoute("team-c", "alerts", mcov1beta2.ObservabilityAlertRouteSpec{
+		Receiver: "email",
+		Receivers: []mcov1beta2.AlertReceiver{{
+			Name:         "email",
+			EmailConfigs: []mcov1beta2.AlertEmailConfig{{To: "team-c@example.com"}},
+		}},
+	})
+	team, err := renderTeamConfig(t.Context(), email, []string{"prod-1"}, staticSecrets(nil))
+	require.NoError(t, err)
+	_, err = mergeConfig(baseConfig, []teamConfig{team})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no global SMTP smarthost set")
+
+	_, err = mergeConfig("receivers: []", nil)
+	assert.EqualError(t, err, "the base config has no root route")
+}
@@ -0,0 +1,259 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilitymaintenance
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var log = logf.Log.WithName("controller_observabilitymaintenance")
+
+const (
+	reasonActive             = "Active"
+	reasonScheduled          = "Scheduled"
+	reasonOver               = "Over"
+	reasonInvalidWindow      = "InvalidWindow"
+	reasonNoMatchingClusters = "NoMatchingClusters"
+	reasonSilenceFailed      = "SilenceFailed"
+
+	// silenceResyncPeriod is how often the silences are checked, to restore the silences expired by hand.
+	silenceResyncPeriod = 10 * time.Minute
+	// requestName is the name of the single request the events are mapped to.
+	requestName = "observability-maintenance-windows"
+)
+
+// ObservabilityMaintenanceWindowReconciler silences the alerts of the managed clusters in the hub Alertmanager
+// during their ObservabilityMaintenanceWindows.
+type ObservabilityMaintenanceWindowReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// RestConfig provides the token of the operator to the Alertmanager API.
+	RestConfig *rest.Config
+
+	// silences overrides the Alertmanager client in the tests.
+	silences silenceClient
+	// now overrides the clock in the tests.
+	now func() time.Time
+}
+
+// +kubebuilder:rbac:groups=observability.open-cluster-management.io,resources=observabilitymaintenancewindows,verbs=get;list;watch
+// +kubebuilder:rbac:groups=observability.open-cluster-management.io,resources=observabilitymaintenancewindows/status,verbs=get;update;patch
+
+// Reconcile syncs the silences of all the ObservabilityMaintenanceWindows, as the silences of the operator are
+// expired when they do not belong to any window in progress.
+func (r *ObservabilityMaintenanceWindowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", req.Namespace, "Request.Name", req.Name)
+	reqLogger.Info("Reconciling ObservabilityMaintenanceWindows")
+
+	mcoList := &mcov1beta2.MultiClusterObservabilityList{}
+	if err := r.Client.List(ctx, mcoList); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list MultiClusterObservability custom resources: %w", err)
+	}
+	if len(mcoList.Items) == 0 {
+		reqLogger.Info("no MultiClusterObservability CR exists, nothing to do")
+		return ctrl.Result{}, nil
+	}
+	mco := &mcoList.Items[0]
+	if mco.GetDeletionTimestamp() != nil || config.IsPaused(mco.GetAnnotations()) {
+		return ctrl.Result{}, nil
+	}
+
+	windows := &mcov1beta2.ObservabilityMaintenanceWindowList{}
+	if err := r.Client.List(ctx, windows); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ObservabilityMaintenanceWindows: %w", err)
+	}
+	slices.SortFunc(windows.Items, func(a, b mcov1beta2.ObservabilityMaintenanceWindow) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	clusters := &clusterv1.ManagedClusterList{}
+	if err := r.Client.List(ctx, clusters); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+	clustersByName := make(map[string]*clusterv1.ManagedCluster, len(clusters.Items))
+	for i := range clusters.Items {
+		clustersByName[clusters.Items[i].Name] = &clusters.Items[i]
+	}
+
+	now := time.Now()
+	if r.now != nil {
+		now = r.now()
+	}
+	requeueAfter := silenceResyncPeriod
+	requeueAt := func(t time.Time) {
+		if !t.IsZero() && t.After(now) {
+			requeueAfter = min(requeueAfter, t.Sub(now)+time.Second)
+		}
+	}
+
+	desired := []silence{}
+	var active []mcov1beta2.ActiveMaintenanceWindow
+	statuses := make([]mcov1beta2.ObservabilityMaintenanceWindowStatus, len(windows.Items))
+	for i := range windows.Items {
+		window := &windows.Items[i]
+		status := window.Status.DeepCopy()
+		status.ObservedGeneration = window.Generation
+		condition := metav1.Condition{
+			Type:               mcov1beta2.MaintenanceWindowActiveCondition,
+			ObservedGeneration: window.Generation,
+		}
+
+		targets, _, err := config.SelectClusters(window.Spec.ClusterSets, window.Spec.ClusterSelector, clusters.Items)
+		status.MatchedClusters = int32(len(targets)) // #nosec G115 -- the number of managed clusters fits in an int32.
+		state := windowState{}
+		if err == nil {
+			state, err = evaluateWindow(window.Spec, now)
+		}
+		status.ActiveUntil = optionalTime(state.activeUntil)
+		status.NextWindowStart = optionalTime(state.nextStart)
+		requeueAt(state.activeUntil)
+		requeueAt(state.nextStart)
+		switch {
+		case err != nil:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonInvalidWindow
+			condition.Message = err.Error()
+		case state.activeUntil.IsZero() && !state.nextStart.IsZero():
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonScheduled
+			condition.Message = "The next window starts at " + state.nextStart.UTC().Format(time.RFC3339)
+		case state.activeUntil.IsZero():
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonOver
+			condition.Message = "The window is over"
+		case len(targets) == 0:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonNoMatchingClusters
+			condition.Message = "No managed cluster matches the cluster sets or selector of the window"
+		default:
+			comment := "Maintenance window " + window.Name
+			if window.Spec.Comment != "" {
+				comment += ": " + window.Spec.Comment
+			}
+			for _, name := range targets {
+				desired = append(desired, clusterSilences(clustersByName[name], now, state.activeUntil, comment)...)
+			}
+			active = append(active, mcov1beta2.ActiveMaintenanceWindow{
+				Name:     window.Name,
+				Clusters: status.MatchedClusters,
+				EndsAt:   metav1.NewTime(state.activeUntil),
+			})
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = reasonActive
+			condition.Message = fmt.Sprintf("The alerts of %d managed clusters are silenced until %s",
+				len(targets), state.activeUntil.UTC().Format(time.RFC3339))
+		}
+		meta.SetStatusCondition(&status.Conditions, condition)
+		statuses[i] = *status
+	}
+
+	syncErr := r.syncSilences(ctx, desired)
+	if syncErr != nil {
+		// The windows in progress are reported as failed until their silences are created.
+		for i := range statuses {
+			condition := meta.FindStatusCondition(statuses[i].Conditions, mcov1beta2.MaintenanceWindowActiveCondition)
+			if condition.Reason != reasonActive {
+				continue
+			}
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonSilenceFailed
+			condition.Message = fmt.Sprintf("Failed to sync the Alertmanager silences: %v", syncErr)
+		}
+		active = nil
+	}
+
+	errs := []error{syncErr}
+	for i := range windows.Items {
+		window := &windows.Items[i]
+		if equality.Semantic.DeepEqual(window.Status, statuses[i]) {
+			continue
+		}
+		window.Status = statuses[i]
+		if err := r.Client.Status().Update(ctx, window); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update the status of the ObservabilityMaintenanceWindow %s: %w",
+				window.Name, err))
+		}
+	}
+	if !equality.Semantic.DeepEqual(mco.Status.MaintenanceWindows, active) {
+		orig := mco.DeepCopy()
+		mco.Status.MaintenanceWindows = active
+		// The optimistic lock prevents overwriting the status updated concurrently by the status controller.
+		if err := r.Client.Status().Patch(ctx, mco, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update the maintenance windows of the MultiClusterObservability status: %w", err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// syncSilences syncs the silences of the operator in Alertmanager with the desired silences.
+func (r *ObservabilityMaintenanceWindowReconciler) syncSilences(ctx context.Context, desired []silence) error {
+	silences := r.silences
+	if silences == nil {
+		amClient, err := newAlertmanagerClient(r.Client, r.RestConfig)
+		if err != nil {
+			return err
+		}
+		silences = amClient
+	}
+	created, expired, err := syncSilences(ctx, silences, desired)
+	if created > 0 || expired > 0 {
+		log.Info("Synced the Alertmanager silences of the maintenance windows", "created", created, "expired", expired)
+	}
+	return err
+}
+
+// optionalTime returns nil for the zero time.
+func optionalTime(t time.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+	mt := metav1.NewTime(t)
+	return &mt
+}
+
+// enqueueWindows maps all the events to a single request, as the silences of all the windows are synced together.
+func enqueueWindows(context.Context, client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: requestName}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ObservabilityMaintenanceWindowReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("observabilitymaintenancewindow").
+		Watches(&mcov1beta2.ObservabilityMaintenanceWindow{}, handler.EnqueueRequestsFromMapFunc(enqueueWindows),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&mcov1beta2.MultiClusterObservability{}, handler.EnqueueRequestsFromMapFunc(enqueueWindows),
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		// The windows silence the alerts of the clusters matching their cluster sets or selector.
+		Watches(&clusterv1.ManagedCluster{}, handler.EnqueueRequestsFromMapFunc(enqueueWindows),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Complete(r)
+}
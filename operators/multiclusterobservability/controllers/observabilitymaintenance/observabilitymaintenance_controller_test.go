@@ -0,0 +1,145 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilitymaintenance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestObservabilityMaintenanceWindowReconcile(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, mcov1beta2.AddToScheme(s))
+	require.NoError(t, clusterv1.Install(s))
+
+	// 2025-03-15 is a Saturday.
+	now := time.Date(2025, 3, 15, 3, 0, 0, 0, time.UTC)
+	mco := &mcov1beta2.MultiClusterObservability{ObjectMeta: metav1.ObjectMeta{Name: "observability"}}
+	prod := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name:   "prod-1",
+		Labels: map[string]string{config.ClusterSetLabelKey: "prod", clusterIDLabel: "prod-1-id"},
+	}}
+	staging := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name:   "staging-1",
+		Labels: map[string]string{config.ClusterSetLabelKey: "staging", "env": "staging", clusterIDLabel: "staging-1-id"},
+	}}
+	weekly := newWindow("weekly", mcov1beta2.ObservabilityMaintenanceWindowSpec{
+		ClusterSets: []string{"prod"},
+		Schedule:    "0 2 * * 6",
+		Duration:    "4h",
+		Comment:     "OS upgrades",
+	})
+	start := metav1.NewTime(now.Add(2 * time.Hour))
+	end := metav1.NewTime(now.Add(3 * time.Hour))
+	upcoming := newWindow("upcoming", mcov1beta2.ObservabilityMaintenanceWindowSpec{
+		ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "staging"}},
+		StartTime:       &start,
+		EndTime:         &end,
+	})
+	invalid := newWindow("invalid", mcov1beta2.ObservabilityMaintenanceWindowSpec{
+		ClusterSets: []string{"prod"},
+		Schedule:    "every saturday",
+		Duration:    "4h",
+	})
+	empty := newWindow("empty", mcov1beta2.ObservabilityMaintenanceWindowSpec{
+		ClusterSets: []string{"dev"},
+		Schedule:    "0 2 * * 6",
+		Duration:    "4h",
+	})
+
+	c := fake.NewClientBuilder().WithScheme(s).
+		WithObjects(mco, prod, staging, weekly, upcoming, invalid, empty).
+		WithStatusSubresource(&mcov1beta2.MultiClusterObservability{}, &mcov1beta2.ObservabilityMaintenanceWindow{}).
+		Build()
+	am := &fakeSilences{}
+	r := &ObservabilityMaintenanceWindowReconciler{Client: c, Scheme: s, silences: am, now: func() time.Time { return now }}
+
+	result, err := r.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, silenceResyncPeriod, result.RequeueAfter)
+
+	silences := am.activeSilences()
+	require.Len(t, silences, 2)
+	assert.Equal(t, "prod-1", silences[0].Matchers[0].Value)
+	assert.Equal(t, "prod-1-id", silences[1].Matchers[0].Value)
+	for _, silence := range silences {
+		assert.True(t, now.Add(3*time.Hour).Equal(silence.EndsAt))
+		assert.Equal(t, "Maintenance window weekly: OS upgrades", silence.Comment)
+	}
+
+	expected := map[string]struct {
+		status          metav1.ConditionStatus
+		reason          string
+		matchedClusters int32
+	}{
+		"weekly":   {metav1.ConditionTrue, reasonActive, 1},
+		"upcoming": {metav1.ConditionFalse, reasonScheduled, 1},
+		"invalid":  {metav1.ConditionFalse, reasonInvalidWindow, 1},
+		"empty":    {metav1.ConditionFalse, reasonNoMatchingClusters, 0},
+	}
+	for name, want := range expected {
+		window := &mcov1beta2.ObservabilityMaintenanceWindow{}
+		require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: name}, window))
+		condition := meta.FindStatusCondition(window.Status.Conditions, mcov1beta2.MaintenanceWindowActiveCondition)
+		require.NotNil(t, condition, name)
+		assert.Equal(t, want.status, condition.Status, name)
+		assert.Equal(t, want.reason, condition.Reason, name)
+		assert.Equal(t, want.matchedClusters, window.Status.MatchedClusters, name)
+	}
+
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: "observability"}, mco))
+	require.Len(t, mco.Status.MaintenanceWindows, 1)
+	assert.Equal(t, "weekly", mco.Status.MaintenanceWindows[0].Name)
+	assert.Equal(t, int32(1), mco.Status.MaintenanceWindows[0].Clusters)
+	assert.True(t, now.Add(3*time.Hour).Equal(mco.Status.MaintenanceWindows[0].EndsAt.Time))
+
+	// Once the weekly window is over and the upcoming window started, the silences are switched over.
+	now = now.Add(2*time.Hour + time.Minute)
+	_, err = r.Reconcile(context.Background(), ctrl.Request{})
+	require.NoError(t, err)
+	silences = am.activeSilences()
+	require.Len(t, silences, 4)
+	clusters := map[string]int{}
+	for _, silence := range silences {
+		clusters[silence.Matchers[0].Value]++
+	}
+	assert.Equal(t, map[string]int{"prod-1": 1, "prod-1-id": 1, "staging-1": 1, "staging-1-id": 1}, clusters)
+
+	// The windows in progress are reported as failed when the silences cannot be created.
+	now = now.Add(time.Hour)
+	require.NoError(t, c.Delete(context.Background(), upcoming))
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: "weekly"}, weekly))
+	weekly.Spec.Duration = "8h"
+	require.NoError(t, c.Update(context.Background(), weekly))
+	am.createErr = errors.New("unavailable")
+	_, err = r.Reconcile(context.Background(), ctrl.Request{})
+	require.ErrorContains(t, err, "unavailable")
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: "weekly"}, weekly))
+	condition := meta.FindStatusCondition(weekly.Status.Conditions, mcov1beta2.MaintenanceWindowActiveCondition)
+	assert.Equal(t, reasonSilenceFailed, condition.Reason)
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: "observability"}, mco))
+	assert.Empty(t, mco.Status.MaintenanceWindows)
+}
+
+func newWindow(name string, spec mcov1beta2.ObservabilityMaintenanceWindowSpec) *mcov1beta2.ObservabilityMaintenanceWindow {
+	return &mcov1beta2.ObservabilityMaintenanceWindow{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Generation: 1},
+		Spec:       spec,
+	}
+}
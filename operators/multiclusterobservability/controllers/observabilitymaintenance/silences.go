@@ -0,0 +1,242 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilitymaintenance
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// silenceCreator identifies the silences managed by the operator.
+	silenceCreator = "multicluster-observability-operator"
+	// silenceStateExpired is the state of the expired silences, which are kept by Alertmanager for a while.
+	silenceStateExpired = "expired"
+	// alertmanagerProxyPort is the port of the oauth-proxy of Alertmanager, which accepts the token of the operator.
+	alertmanagerProxyPort = 9095
+)
+
+const (
+	// clusterAlertLabel holds the name of the managed cluster of the alerts evaluated on the hub.
+	clusterAlertLabel = "cluster"
+	// clusterIDLabel is the label of the managed clusters with their ID.
+	clusterIDLabel = "clusterID"
+	// clusterIDClaim is the claim of the managed clusters with their ID, when they do not have the label.
+	clusterIDClaim = "id.k8s.io"
+)
+
+// silenceMatcher is a matcher of the Alertmanager API v2.
+type silenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// silenceStatus is the status of a silence of the Alertmanager API v2.
+type silenceStatus struct {
+	State string `json:"state"`
+}
+
+// silence is a silence of the Alertmanager API v2.
+type silence struct {
+	ID        string           `json:"id,omitempty"`
+	Matchers  []silenceMatcher `json:"matchers"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+	Status    *silenceStatus   `json:"status,omitempty"`
+}
+
+// key identifies the silences with the same matchers, end and comment, regardless of their start and ID.
+func (s silence) key() string {
+	matchers := make([]string, 0, len(s.Matchers))
+	for _, m := range s.Matchers {
+		matchers = append(matchers, fmt.Sprintf("%s=%t,%t,%q", m.Name, m.IsEqual, m.IsRegex, m.Value))
+	}
+	return strings.Join(matchers, ";") + "|" + s.EndsAt.UTC().Truncate(time.Second).Format(time.RFC3339) + "|" + s.Comment
+}
+
+// clusterSilences returns the silences of the alerts of a managed cluster until the given time: the alerts
+// evaluated on the hub hold the name of the cluster, and the alerts forwarded by the cluster hold its ID in the
+// managed_cluster label, set in the external labels of the cluster monitoring configuration.
+func clusterSilences(cluster *clusterv1.ManagedCluster, now, until time.Time, comment string) []silence {
+	matchers := []silenceMatcher{{Name: clusterAlertLabel, Value: cluster.Name, IsEqual: true}}
+	if id := clusterID(cluster); id != "" {
+		matchers = append(matchers, silenceMatcher{Name: operatorconfig.ClusterLabelKeyForAlerts, Value: id, IsEqual: true})
+	}
+	silences := make([]silence, 0, len(matchers))
+	for _, matcher := range matchers {
+		silences = append(silences, silence{
+			Matchers:  []silenceMatcher{matcher},
+			StartsAt:  now,
+			EndsAt:    until,
+			CreatedBy: silenceCreator,
+			Comment:   comment,
+		})
+	}
+	return silences
+}
+
+// clusterID returns the ID of a managed cluster, or an empty string when the cluster does not report it yet.
+func clusterID(cluster *clusterv1.ManagedCluster) string {
+	if id := cluster.Labels[clusterIDLabel]; id != "" {
+		return id
+	}
+	for _, claim := range cluster.Status.ClusterClaims {
+		if claim.Name == clusterIDClaim {
+			return claim.Value
+		}
+	}
+	return ""
+}
+
+// silenceClient manages the silences of Alertmanager.
+type silenceClient interface {
+	listSilences(ctx context.Context) ([]silence, error)
+	createSilence(ctx context.Context, s silence) error
+	expireSilence(ctx context.Context, id string) error
+}
+
+// syncSilences creates the desired silences missing from Alertmanager, and expires the silences of the operator
+// which are no longer desired, such as the silences of a window which was deleted or rescheduled.
+func syncSilences(ctx context.Context, c silenceClient, desired []silence) (created, expired int, err error) {
+	existing, err := c.listSilences(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	wanted := make(map[string]bool, len(desired))
+	for _, s := range desired {
+		wanted[s.key()] = true
+	}
+
+	var errs []error
+	found := map[string]bool{}
+	for _, s := range existing {
+		if s.CreatedBy != silenceCreator || (s.Status != nil && s.Status.State == silenceStateExpired) {
+			continue
+		}
+		key := s.key()
+		if wanted[key] && !found[key] {
+			found[key] = true
+			continue
+		}
+		if err := c.expireSilence(ctx, s.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		expired++
+	}
+	for _, s := range desired {
+		key := s.key()
+		if found[key] {
+			continue
+		}
+		if err := c.createSilence(ctx, s); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		found[key] = true
+		created++
+	}
+	return created, expired, errors.Join(errs...)
+}
+
+// alertmanagerClient calls the Alertmanager API v2 through the oauth-proxy of Alertmanager.
+type alertmanagerClient struct {
+	url    string
+	client *http.Client
+}
+
+// newAlertmanagerClient returns a client of the hub Alertmanager, trusting the service CA of the Alertmanager
+// CA bundle, and authenticated with the token of the operator.
+func newAlertmanagerClient(c client.Client, restConfig *rest.Config) (*alertmanagerClient, error) {
+	ca, err := config.GetAlertmanagerCA(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the CA of Alertmanager: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(ca)) {
+		return nil, fmt.Errorf("no certificate in the ConfigMap %s", config.AlertmanagersDefaultCaBundleName)
+	}
+	// The client is created on each reconcile to pick up the rotated CA, so it does not keep the connections.
+	rt, err := transport.NewBearerAuthWithRefreshRoundTripper(restConfig.BearerToken, restConfig.BearerTokenFile,
+		&http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			DisableKeepAlives: true,
+		})
+	if err != nil {
+		return nil, err
+	}
+	return &alertmanagerClient{
+		url: fmt.Sprintf("https://%s.%s.svc:%d", config.AlertmanagerServiceName, config.GetDefaultNamespace(),
+			alertmanagerProxyPort),
+		client: &http.Client{Transport: rt, Timeout: 30 * time.Second},
+	}, nil
+}
+
+// do sends a request to the Alertmanager API v2, and decodes its response into out when not nil.
+func (c *alertmanagerClient) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(content)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.url+"/api/v2"+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call the Alertmanager API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		content, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s returned %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(content)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *alertmanagerClient) listSilences(ctx context.Context) ([]silence, error) {
+	silences := []silence{}
+	if err := c.do(ctx, http.MethodGet, "/silences", nil, &silences); err != nil {
+		return nil, err
+	}
+	return silences, nil
+}
+
+func (c *alertmanagerClient) createSilence(ctx context.Context, s silence) error {
+	return c.do(ctx, http.MethodPost, "/silences", s, nil)
+}
+
+func (c *alertmanagerClient) expireSilence(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/silence/"+url.PathEscape(id), nil, nil)
+}
@@ -0,0 +1,167 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilitymaintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+var prodCluster = &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+	Name:   "prod-1",
+	Labels: map[string]string{clusterIDLabel: "prod-1-id"},
+}}
+
+// fakeSilences is an in-memory Alertmanager.
+type fakeSilences struct {
+	silences  []silence
+	createErr error
+	nextID    int
+}
+
+func (f *fakeSilences) listSilences(context.Context) ([]silence, error) {
+	return append([]silence{}, f.silences...), nil
+}
+
+func (f *fakeSilences) createSilence(_ context.Context, s silence) error {
+	if f.createErr != nil {
+		return f.createErr
+	}
+	f.nextID++
+	s.ID = strconv.Itoa(f.nextID)
+	s.Status = &silenceStatus{State: "active"}
+	f.silences = append(f.silences, s)
+	return nil
+}
+
+func (f *fakeSilences) expireSilence(_ context.Context, id string) error {
+	for i := range f.silences {
+		if f.silences[i].ID == id {
+			f.silences[i].Status = &silenceStatus{State: silenceStateExpired}
+			return nil
+		}
+	}
+	return errors.New("silence not found")
+}
+
+// activeSilences returns the active silences of the operator.
+func (f *fakeSilences) activeSilences() []silence {
+	var active []silence
+	for _, s := range f.silences {
+		if s.CreatedBy == silenceCreator && s.Status.State != silenceStateExpired {
+			active = append(active, s)
+		}
+	}
+	return active
+}
+
+func TestClusterID(t *testing.T) {
+	assert.Equal(t, "prod-1-id", clusterID(prodCluster))
+	assert.Equal(t, "staging-1-id", clusterID(&clusterv1.ManagedCluster{Status: clusterv1.ManagedClusterStatus{
+		ClusterClaims: []clusterv1.ManagedClusterClaim{{Name: clusterIDClaim, Value: "staging-1-id"}},
+	}}))
+
+	// Without ID, only the alerts evaluated on the hub are silenced.
+	silences := clusterSilences(&clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "new"}},
+		time.Now(), time.Now().Add(time.Hour), "maintenance")
+	require.Len(t, silences, 1)
+	assert.Equal(t, "cluster", silences[0].Matchers[0].Name)
+}
+
+func TestSyncSilences(t *testing.T) {
+	now := time.Date(2025, 3, 15, 3, 0, 0, 0, time.UTC)
+	until := now.Add(3 * time.Hour)
+	desired := clusterSilences(prodCluster, now, until, "Maintenance window weekly")
+	userSilence := silence{
+		ID:        "user",
+		Matchers:  []silenceMatcher{{Name: "alertname", Value: "Watchdog", IsEqual: true}},
+		CreatedBy: "admin",
+		Status:    &silenceStatus{State: "active"},
+	}
+	am := &fakeSilences{silences: []silence{userSilence}}
+
+	created, expired, err := syncSilences(context.Background(), am, desired)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, 0, expired)
+	require.Len(t, am.activeSilences(), 2)
+	assert.Equal(t, silenceMatcher{Name: "cluster", Value: "prod-1", IsEqual: true}, am.activeSilences()[0].Matchers[0])
+	assert.Equal(t, silenceMatcher{Name: "managed_cluster", Value: "prod-1-id", IsEqual: true}, am.activeSilences()[1].Matchers[0])
+
+	// The silences created on a previous reconcile, with an earlier start, are kept.
+	created, expired, err = syncSilences(context.Background(), am,
+		clusterSilences(prodCluster, now.Add(time.Minute), until, "Maintenance window weekly"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, created)
+	assert.Equal(t, 0, expired)
+
+	// The silences of a rescheduled window are replaced.
+	created, expired, err = syncSilences(context.Background(), am,
+		clusterSilences(prodCluster, now, until.Add(time.Hour), "Maintenance window weekly"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, 2, expired)
+
+	// The silences of the operator are expired when no window is in progress, not the silences of the users.
+	created, expired, err = syncSilences(context.Background(), am, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, created)
+	assert.Equal(t, 2, expired)
+	assert.Empty(t, am.activeSilences())
+	assert.Equal(t, "active", am.silences[0].Status.State)
+
+	am.createErr = errors.New("unavailable")
+	_, _, err = syncSilences(context.Background(), am, desired)
+	assert.ErrorContains(t, err, "unavailable")
+}
+
+func TestAlertmanagerClient(t *testing.T) {
+	var requests []string
+	var posted silence
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/silences":
+			_, _ = w.Write([]byte(`[{"id":"1","matchers":[{"name":"cluster","value":"prod-1","isRegex":false,"isEqual":true}],` +
+				`"createdBy":"multicluster-observability-operator","status":{"state":"active"}}]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/silences":
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+			_, _ = w.Write([]byte(`{"silenceID":"2"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v2/silence/1":
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := &alertmanagerClient{url: server.URL, client: server.Client()}
+
+	silences, err := c.listSilences(context.Background())
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	assert.Equal(t, "1", silences[0].ID)
+	assert.Equal(t, "active", silences[0].Status.State)
+
+	s := clusterSilences(prodCluster, time.Now(), time.Now().Add(time.Hour), "maintenance")[0]
+	require.NoError(t, c.createSilence(context.Background(), s))
+	assert.Equal(t, s.Matchers, posted.Matchers)
+	assert.Equal(t, silenceCreator, posted.CreatedBy)
+
+	require.NoError(t, c.expireSilence(context.Background(), "1"))
+	assert.ErrorContains(t, c.expireSilence(context.Background(), "3"), "returned 404: not found")
+	assert.Equal(t, []string{
+		"GET /api/v2/silences", "POST /api/v2/silences", "DELETE /api/v2/silence/1", "DELETE /api/v2/silence/3",
+	}, requests)
+}
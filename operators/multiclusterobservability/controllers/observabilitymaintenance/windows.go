@@ -0,0 +1,125 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilitymaintenance
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/robfig/cron/v3"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+// maxScheduleIterations bounds the search of the window in progress, for schedules much more frequent than
+// the duration of their windows.
+const maxScheduleIterations = 10000
+
+// windowState is the window in progress and the next window of an ObservabilityMaintenanceWindow.
+type windowState struct {
+	// activeFrom and activeUntil are the start and the end of the window in progress, zero when no window is in
+	// progress.
+	activeFrom  time.Time
+	activeUntil time.Time
+	// nextStart and nextEnd are the start and the end of the next window, zero when there is no next window.
+	nextStart time.Time
+	nextEnd   time.Time
+}
+
+// evaluateWindow returns the window in progress at the given time and the next window.
+func evaluateWindow(spec mcov1beta2.ObservabilityMaintenanceWindowSpec, now time.Time) (windowState, error) {
+	state := windowState{}
+	if spec.StartTime != nil {
+		if spec.EndTime == nil {
+			return state, errors.New("endTime is required with startTime")
+		}
+		start, end := spec.StartTime.Time, spec.EndTime.Time
+		if !end.After(start) {
+			return state, errors.New("endTime must be after startTime")
+		}
+		switch {
+		case now.Before(start):
+			state.nextStart, state.nextEnd = start, end
+		case now.Before(end):
+			state.activeFrom, state.activeUntil = start, end
+		}
+		return state, nil
+	}
+
+	duration, err := model.ParseDuration(spec.Duration)
+	if err != nil {
+		return state, fmt.Errorf("invalid duration: %w", err)
+	}
+	if duration <= 0 {
+		return state, errors.New("duration must be positive")
+	}
+	expr := spec.Schedule
+	if spec.TimeZone != "" {
+		if _, err := time.LoadLocation(spec.TimeZone); err != nil {
+			return state, fmt.Errorf("invalid time zone: %w", err)
+		}
+		expr = "CRON_TZ=" + spec.TimeZone + " " + expr
+	}
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return state, fmt.Errorf("invalid schedule: %w", err)
+	}
+
+	// The windows in progress started during the last duration. When they overlap, the last one ends last.
+	start := schedule.Next(now.Add(-time.Duration(duration)))
+	for i := 0; !start.IsZero() && i < maxScheduleIterations; i++ {
+		if start.After(now) {
+			state.nextStart, state.nextEnd = start, start.Add(time.Duration(duration))
+			break
+		}
+		if state.activeFrom.IsZero() {
+			state.activeFrom = start
+		}
+		state.activeUntil = start.Add(time.Duration(duration))
+		start = schedule.Next(start)
+	}
+	return state, nil
+}
+
+// ClusterMaintenanceWindows returns the windows in progress and the next windows of the managed cluster, sent to the
+// managed cluster so that it stops forwarding its alerts to the hub during the windows. The invalid windows are
+// skipped, as they are reported in their status.
+func ClusterMaintenanceWindows(windows []mcov1beta2.ObservabilityMaintenanceWindow, cluster *clusterv1.ManagedCluster,
+	now time.Time,
+) []operatorconfig.MaintenanceWindow {
+	var clusterWindows []operatorconfig.MaintenanceWindow
+	for _, window := range windows {
+		targets, _, err := config.SelectClusters(window.Spec.ClusterSets, window.Spec.ClusterSelector,
+			[]clusterv1.ManagedCluster{*cluster})
+		if err != nil || len(targets) == 0 {
+			continue
+		}
+		state, err := evaluateWindow(window.Spec, now)
+		if err != nil {
+			continue
+		}
+		if !state.activeUntil.IsZero() {
+			clusterWindows = append(clusterWindows, operatorconfig.MaintenanceWindow{
+				Start: state.activeFrom.UTC(),
+				End:   state.activeUntil.UTC(),
+			})
+		}
+		if !state.nextStart.IsZero() {
+			clusterWindows = append(clusterWindows, operatorconfig.MaintenanceWindow{
+				Start: state.nextStart.UTC(),
+				End:   state.nextEnd.UTC(),
+			})
+		}
+	}
+	slices.SortFunc(clusterWindows, func(a, b operatorconfig.MaintenanceWindow) int {
+		return a.Start.Compare(b.Start)
+	})
+	return clusterWindows
+}
@@ -0,0 +1,207 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilitymaintenance
+
+import (
+	"testing"
+	"time"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+func TestEvaluateWindow(t *testing.T) {
+	at := func(value string) time.Time {
+		ts, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return ts
+	}
+	metaTime := func(value string) *metav1.Time {
+		mt := metav1.NewTime(at(value))
+		return &mt
+	}
+	// 2025-03-15 is a Saturday.
+	weekly := mcov1beta2.ObservabilityMaintenanceWindowSpec{Schedule: "0 2 * * 6", Duration: "4h"}
+	paris := mcov1beta2.ObservabilityMaintenanceWindowSpec{Schedule: "0 2 * * 6", Duration: "4h", TimeZone: "Europe/Paris"}
+	oneOff := mcov1beta2.ObservabilityMaintenanceWindowSpec{
+		StartTime: metaTime("2025-03-15T10:00:00Z"),
+		EndTime:   metaTime("2025-03-15T12:00:00Z"),
+	}
+
+	testCases := map[string]struct {
+		spec      mcov1beta2.ObservabilityMaintenanceWindowSpec
+		now       string
+		expected  windowState
+		expectErr string
+	}{
+		"schedule before the window": {
+			spec: weekly,
+			now:  "2025-03-15T01:00:00Z",
+			expected: windowState{
+				nextStart: at("2025-03-15T02:00:00Z"),
+				nextEnd:   at("2025-03-15T06:00:00Z"),
+			},
+		},
+		"schedule during the window": {
+			spec: weekly,
+			now:  "2025-03-15T03:00:00Z",
+			expected: windowState{
+				activeFrom:  at("2025-03-15T02:00:00Z"),
+				activeUntil: at("2025-03-15T06:00:00Z"),
+				nextStart:   at("2025-03-22T02:00:00Z"),
+				nextEnd:     at("2025-03-22T06:00:00Z"),
+			},
+		},
+		"schedule at the end of the window": {
+			spec: weekly,
+			now:  "2025-03-15T06:00:00Z",
+			expected: windowState{
+				nextStart: at("2025-03-22T02:00:00Z"),
+				nextEnd:   at("2025-03-22T06:00:00Z"),
+			},
+		},
+		"schedule in a time zone": {
+			spec: paris,
+			now:  "2025-03-15T03:00:00Z",
+			expected: windowState{
+				activeFrom:  at("2025-03-15T01:00:00Z"),
+				activeUntil: at("2025-03-15T05:00:00Z"),
+				nextStart:   at("2025-03-22T01:00:00Z"),
+				nextEnd:     at("2025-03-22T05:00:00Z"),
+			},
+		},
+		"overlapping windows": {
+			spec: mcov1beta2.ObservabilityMaintenanceWindowSpec{Schedule: "0 * * * *", Duration: "90m"},
+			now:  "2025-03-15T03:30:00Z",
+			expected: windowState{
+				activeFrom:  at("2025-03-15T03:00:00Z"),
+				activeUntil: at("2025-03-15T04:30:00Z"),
+				nextStart:   at("2025-03-15T04:00:00Z"),
+				nextEnd:     at("2025-03-15T05:30:00Z"),
+			},
+		},
+		"one-off window before its start": {
+			spec: oneOff,
+			now:  "2025-03-15T09:00:00Z",
+			expected: windowState{
+				nextStart: at("2025-03-15T10:00:00Z"),
+				nextEnd:   at("2025-03-15T12:00:00Z"),
+			},
+		},
+		"one-off window in progress": {
+			spec: oneOff,
+			now:  "2025-03-15T11:00:00Z",
+			expected: windowState{
+				activeFrom:  at("2025-03-15T10:00:00Z"),
+				activeUntil: at("2025-03-15T12:00:00Z"),
+			},
+		},
+		"one-off window over": {
+			spec: oneOff,
+			now:  "2025-03-15T12:00:00Z",
+		},
+		"end before start": {
+			spec: mcov1beta2.ObservabilityMaintenanceWindowSpec{
+				StartTime: metaTime("2025-03-15T12:00:00Z"),
+				EndTime:   metaTime("2025-03-15T10:00:00Z"),
+			},
+			now:       "2025-03-15T11:00:00Z",
+			expectErr: "endTime must be after startTime",
+		},
+		"invalid schedule": {
+			spec:      mcov1beta2.ObservabilityMaintenanceWindowSpec{Schedule: "every saturday", Duration: "4h"},
+			now:       "2025-03-15T11:00:00Z",
+			expectErr: "invalid schedule",
+		},
+		"invalid time zone": {
+			spec:      mcov1beta2.ObservabilityMaintenanceWindowSpec{Schedule: "0 2 * * 6", Duration: "4h", TimeZone: "Mars/Olympus"},
+			now:       "2025-03-15T11:00:00Z",
+			expectErr: "invalid time zone",
+		},
+		"zero duration": {
+			spec:      mcov1beta2.ObservabilityMaintenanceWindowSpec{Schedule: "0 2 * * 6", Duration: "0"},
+			now:       "2025-03-15T11:00:00Z",
+			expectErr: "duration must be positive",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			state, err := evaluateWindow(tc.spec, at(tc.now))
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tc.expected.activeFrom.Equal(state.activeFrom), "activeFrom: %s", state.activeFrom)
+			assert.True(t, tc.expected.activeUntil.Equal(state.activeUntil), "activeUntil: %s", state.activeUntil)
+			assert.True(t, tc.expected.nextStart.Equal(state.nextStart), "nextStart: %s", state.nextStart)
+			assert.True(t, tc.expected.nextEnd.Equal(state.nextEnd), "nextEnd: %s", state.nextEnd)
+		})
+	}
+}
+
+func TestClusterMaintenanceWindows(t *testing.T) {
+	at := func(value string) time.Time {
+		ts, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		return ts
+	}
+	start := metav1.NewTime(at("2025-03-15T10:00:00Z"))
+	end := metav1.NewTime(at("2025-03-15T12:00:00Z"))
+	windows := []mcov1beta2.ObservabilityMaintenanceWindow{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "weekly"},
+			Spec: mcov1beta2.ObservabilityMaintenanceWindowSpec{
+				ClusterSets: []string{"prod"},
+				Schedule:    "0 2 * * 6",
+				Duration:    "4h",
+				TimeZone:    "Europe/Paris",
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "upgrade"},
+			Spec: mcov1beta2.ObservabilityMaintenanceWindowSpec{
+				ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"upgrade": "true"}},
+				StartTime:       &start,
+				EndTime:         &end,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+			Spec: mcov1beta2.ObservabilityMaintenanceWindowSpec{
+				ClusterSets: []string{"prod"},
+				Schedule:    "every saturday",
+				Duration:    "4h",
+			},
+		},
+	}
+	cluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "cluster1",
+			Labels: map[string]string{config.ClusterSetLabelKey: "prod", "upgrade": "true"},
+		},
+	}
+
+	assert.Equal(t, []operatorconfig.MaintenanceWindow{
+		{Start: at("2025-03-15T01:00:00Z"), End: at("2025-03-15T05:00:00Z")},
+		{Start: at("2025-03-15T10:00:00Z"), End: at("2025-03-15T12:00:00Z")},
+		{Start: at("2025-03-22T01:00:00Z"), End: at("2025-03-22T05:00:00Z")},
+	}, ClusterMaintenanceWindows(windows, cluster, at("2025-03-15T03:00:00Z")))
+
+	// The one-off window is over.
+	assert.Equal(t, []operatorconfig.MaintenanceWindow{
+		{Start: at("2025-03-22T01:00:00Z"), End: at("2025-03-22T05:00:00Z")},
+	}, ClusterMaintenanceWindows(windows, cluster, at("2025-03-15T13:00:00Z")))
+
+	// The windows of the other clusters are not sent.
+	cluster.Labels = map[string]string{config.ClusterSetLabelKey: "dev"}
+	assert.Empty(t, ClusterMaintenanceWindows(windows, cluster, at("2025-03-15T03:00:00Z")))
+}
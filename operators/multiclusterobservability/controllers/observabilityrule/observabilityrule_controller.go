@@ -0,0 +1,192 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilityrule
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var log = logf.Log.WithName("controller_observabilityrule")
+
+const (
+	reasonLoaded             = "Loaded"
+	reasonInvalidRules       = "InvalidRules"
+	reasonNoMatchingClusters = "NoMatchingClusters"
+)
+
+// ObservabilityRuleReconciler aggregates the ObservabilityRules into the rules ConfigMap loaded by thanos-rule.
+type ObservabilityRuleReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=observability.open-cluster-management.io,resources=observabilityrules,verbs=get;list;watch
+// +kubebuilder:rbac:groups=observability.open-cluster-management.io,resources=observabilityrules/status,verbs=get;update;patch
+
+// Reconcile renders all the ObservabilityRules, as any of them, or any managed cluster, can change the rules ConfigMap.
+func (r *ObservabilityRuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", req.Namespace, "Request.Name", req.Name)
+	reqLogger.Info("Reconciling ObservabilityRules")
+
+	mcoList := &mcov1beta2.MultiClusterObservabilityList{}
+	if err := r.Client.List(ctx, mcoList); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list MultiClusterObservability custom resources: %w", err)
+	}
+	if len(mcoList.Items) == 0 {
+		reqLogger.Info("no MultiClusterObservability CR exists, nothing to do")
+		return ctrl.Result{}, nil
+	}
+	mco := &mcoList.Items[0]
+	if mco.GetDeletionTimestamp() != nil || config.IsPaused(mco.GetAnnotations()) {
+		return ctrl.Result{}, nil
+	}
+
+	rules := &mcov1beta2.ObservabilityRuleList{}
+	if err := r.Client.List(ctx, rules); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ObservabilityRules: %w", err)
+	}
+	slices.SortFunc(rules.Items, func(a, b mcov1beta2.ObservabilityRule) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	clusters := &clusterv1.ManagedClusterList{}
+	if err := r.Client.List(ctx, clusters); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ManagedClusters: %w", err)
+	}
+
+	allGroups := []rulefmt.RuleGroup{}
+	statuses := make([]mcov1beta2.ObservabilityRuleStatus, len(rules.Items))
+	for i := range rules.Items {
+		rule := &rules.Items[i]
+		status := rule.Status.DeepCopy()
+		status.ObservedGeneration = rule.Generation
+		condition := metav1.Condition{
+			Type:               mcov1beta2.RuleLoadedCondition,
+			ObservedGeneration: rule.Generation,
+		}
+
+		targets, restricted, err := config.SelectClusters(rule.Spec.ClusterSets, rule.Spec.ClusterSelector, clusters.Items)
+		status.MatchedClusters = int32(len(targets)) // #nosec G115 -- the number of managed clusters fits in an int32.
+		var groups []rulefmt.RuleGroup
+		if err == nil {
+			groups, err = renderRuleGroups(rule, targets, restricted)
+		}
+		switch {
+		case err != nil:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonInvalidRules
+			condition.Message = err.Error()
+		case len(targets) == 0:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonNoMatchingClusters
+			condition.Message = "No managed cluster matches the cluster sets or selector of the rules"
+		default:
+			allGroups = append(allGroups, groups...)
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = reasonLoaded
+			condition.Message = fmt.Sprintf("%d rule groups are loaded in thanos-rule", len(groups))
+		}
+		meta.SetStatusCondition(&status.Conditions, condition)
+		statuses[i] = *status
+	}
+
+	if err := r.applyRulesConfigMap(ctx, mco, allGroups); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var errs []error
+	for i := range rules.Items {
+		rule := &rules.Items[i]
+		if equality.Semantic.DeepEqual(rule.Status, statuses[i]) {
+			continue
+		}
+		rule.Status = statuses[i]
+		if err := r.Client.Status().Update(ctx, rule); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update the status of the ObservabilityRule %s/%s: %w",
+				rule.Namespace, rule.Name, err))
+		}
+	}
+	return ctrl.Result{}, errors.Join(errs...)
+}
+
+// applyRulesConfigMap writes the rule groups into the ConfigMap loaded by thanos-rule.
+func (r *ObservabilityRuleReconciler) applyRulesConfigMap(ctx context.Context,
+	mco *mcov1beta2.MultiClusterObservability, groups []rulefmt.RuleGroup,
+) error {
+	content, err := yaml.Marshal(rulefmt.RuleGroups{Groups: groups})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the rule groups: %w", err)
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.AlertRuleObservabilityConfigMapName,
+			Namespace: config.GetDefaultNamespace(),
+		},
+	}
+	res, err := ctrl.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		cm.Data = map[string]string{config.AlertRuleObservabilityFileKey: string(content)}
+		return controllerutil.SetControllerReference(mco, cm, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply the ConfigMap %s: %w", cm.Name, err)
+	}
+	if res != controllerutil.OperationResultNone {
+		log.Info("Applied the ObservabilityRules ConfigMap", "operation", res, "groups", len(groups))
+	}
+	return nil
+}
+
+// enqueueRules maps all the events to a single request, as all the rules are aggregated into one ConfigMap.
+func enqueueRules(context.Context, client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{
+		Name:      config.AlertRuleObservabilityConfigMapName,
+		Namespace: config.GetDefaultNamespace(),
+	}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ObservabilityRuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	rulesConfigMapPred := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == config.AlertRuleObservabilityConfigMapName &&
+			obj.GetNamespace() == config.GetDefaultNamespace()
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("observabilityrule").
+		Watches(&mcov1beta2.ObservabilityRule{}, handler.EnqueueRequestsFromMapFunc(enqueueRules),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&mcov1beta2.MultiClusterObservability{}, handler.EnqueueRequestsFromMapFunc(enqueueRules),
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		// The rules are restricted to the clusters matching their cluster sets or selector.
+		Watches(&clusterv1.ManagedCluster{}, handler.EnqueueRequestsFromMapFunc(enqueueRules),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(enqueueRules),
+			builder.WithPredicates(rulesConfigMapPred)).
+		Complete(r)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilityrule
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestObservabilityRuleReconcile(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(s))
+	require.NoError(t, mcov1beta2.AddToScheme(s))
+	require.NoError(t, clusterv1.Install(s))
+
+	mco := &mcov1beta2.MultiClusterObservability{ObjectMeta: metav1.ObjectMeta{Name: "observability"}}
+	prod := clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name:   "prod-1",
+		Labels: map[string]string{config.ClusterSetLabelKey: "prod"},
+	}}
+	newRule := func(namespace, name string, spec mcov1beta2.ObservabilityRuleSpec) *mcov1beta2.ObservabilityRule {
+		return &mcov1beta2.ObservabilityRule{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Generation: 1},
+			Spec:       spec,
+		}
+	}
+	group := mcov1beta2.ObservabilityRuleGroup{
+		Name:  "availability",
+		Rules: []mcov1beta2.ObservabilityRuleItem{{Alert: "ClusterDown", Expr: "up == 0"}},
+	}
+	prodRule := newRule("team-a", "prod", mcov1beta2.ObservabilityRuleSpec{
+		ClusterSets: []string{"prod"},
+		Groups:      []mcov1beta2.ObservabilityRuleGroup{group},
+	})
+	stagingRule := newRule("team-a", "staging", mcov1beta2.ObservabilityRuleSpec{
+		ClusterSets: []string{"staging"},
+		Groups:      []mcov1beta2.ObservabilityRuleGroup{group},
+	})
+	invalidRule := newRule("team-b", "invalid", mcov1beta2.ObservabilityRuleSpec{
+		Groups: []mcov1beta2.ObservabilityRuleGroup{{
+			Name:  "invalid",
+			Rules: []mcov1beta2.ObservabilityRuleItem{{Record: "invalid", Expr: "sum("}},
+		}},
+	})
+
+	c := fake.NewClientBuilder().WithScheme(s).
+		WithObjects(mco, &prod, prodRule, stagingRule, invalidRule).
+		WithStatusSubresource(&mcov1beta2.ObservabilityRule{}).
+		Build()
+	r := &ObservabilityRuleReconciler{Client: c, Scheme: s}
+	_, err := r.Reconcile(t.Context(), ctrl.Request{})
+	require.NoError(t, err)
+
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(t.Context(), types.NamespacedName{
+		Name:      config.AlertRuleObservabilityConfigMapName,
+		Namespace: config.GetDefaultNamespace(),
+	}, cm))
+	assert.Equal(t, "observability", cm.OwnerReferences[0].Name)
+	groups, errs := rulefmt.Parse([]byte(cm.Data[config.AlertRuleObservabilityFileKey]), false)
+	require.Empty(t, errs)
+	require.Len(t, groups.Groups, 1)
+	assert.Equal(t, "team-a/prod/availability", groups.Groups[0].Name)
+	assert.Equal(t, `up{cluster="prod-1"} == 0`, groups.Groups[0].Rules[0].Expr)
+
+	expected := map[string]struct {
+		status  metav1.ConditionStatus
+		reason  string
+		matched int32
+	}{
+		"prod":    {metav1.ConditionTrue, reasonLoaded, 1},
+		"staging": {metav1.ConditionFalse, reasonNoMatchingClusters, 0},
+		"invalid": {metav1.ConditionFalse, reasonInvalidRules, 1},
+	}
+	rules := &mcov1beta2.ObservabilityRuleList{}
+	require.NoError(t, c.List(t.Context(), rules))
+	for _, rule := range rules.Items {
+		condition := meta.FindStatusCondition(rule.Status.Conditions, mcov1beta2.RuleLoadedCondition)
+		require.NotNil(t, condition, rule.Name)
+		assert.Equal(t, expected[rule.Name].status, condition.Status, rule.Name)
+		assert.Equal(t, expected[rule.Name].reason, condition.Reason, rule.Name)
+		assert.Equal(t, expected[rule.Name].matched, rule.Status.MatchedClusters, rule.Name)
+		assert.Equal(t, int64(1), rule.Status.ObservedGeneration, rule.Name)
+	}
+
+	// The rules of a new cluster of the cluster set are loaded.
+	staging := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name:   "staging-1",
+		Labels: map[string]string{config.ClusterSetLabelKey: "staging"},
+	}}
+	require.NoError(t, c.Create(t.Context(), staging))
+	_, err = r.Reconcile(t.Context(), ctrl.Request{})
+	require.NoError(t, err)
+	require.NoError(t, c.Get(t.Context(), types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace}, cm))
+	groups, errs = rulefmt.Parse([]byte(cm.Data[config.AlertRuleObservabilityFileKey]), false)
+	require.Empty(t, errs)
+	require.Len(t, groups.Groups, 2)
+	assert.Equal(t, "team-a/staging/availability", groups.Groups[1].Name)
+}
@@ -0,0 +1,129 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilityrule
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql/parser"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+)
+
+// clusterLabel is the label holding the name of the managed cluster of the series.
+const clusterLabel = "cluster"
+
+// clusterMatcherInjector restricts every vector and matrix selector of an expression to the given clusters.
+type clusterMatcherInjector struct {
+	matcher *labels.Matcher
+}
+
+// Visit implements the parser.Visitor interface.
+func (v *clusterMatcherInjector) Visit(node parser.Node, _ []parser.Node) (parser.Visitor, error) {
+	// MatrixSelectors embed VectorSelectors, which are visited as well.
+	if selector, ok := node.(*parser.VectorSelector); ok {
+		selector.LabelMatchers = append(selector.LabelMatchers, v.matcher)
+	}
+	return v, nil
+}
+
+// injectClusterMatcher adds a cluster matcher for the given clusters to all the selectors of the expression.
+func injectClusterMatcher(expr string, clusters []string) (string, error) {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return "", fmt.Errorf("could not parse expression: %w", err)
+	}
+
+	quoted := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		quoted = append(quoted, regexp.QuoteMeta(cluster))
+	}
+	matchType := labels.MatchRegexp
+	if len(clusters) == 1 {
+		matchType = labels.MatchEqual
+		quoted = clusters
+	}
+	matcher, err := labels.NewMatcher(matchType, clusterLabel, strings.Join(quoted, "|"))
+	if err != nil {
+		return "", err
+	}
+	if err := parser.Walk(&clusterMatcherInjector{matcher: matcher}, parsed, nil); err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
+// ruleGroupName returns the name of a rule group in the thanos-rule config, unique across the ObservabilityRules.
+func ruleGroupName(rule *mcov1beta2.ObservabilityRule, group string) string {
+	return rule.Namespace + "/" + rule.Name + "/" + group
+}
+
+// renderRuleGroups validates the rule groups of an ObservabilityRule and returns them in the thanos-rule format,
+// with their expressions restricted to the given clusters when restricted is true.
+func renderRuleGroups(rule *mcov1beta2.ObservabilityRule, clusters []string, restricted bool) ([]rulefmt.RuleGroup, error) {
+	var errs []error
+	groups := make([]rulefmt.RuleGroup, 0, len(rule.Spec.Groups))
+	for _, group := range rule.Spec.Groups {
+		ruleGroup := rulefmt.RuleGroup{Name: ruleGroupName(rule, group.Name)}
+		if group.Interval != "" {
+			interval, err := model.ParseDuration(group.Interval)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("group %q: invalid interval: %w", group.Name, err))
+			}
+			ruleGroup.Interval = interval
+		}
+
+		for i, item := range group.Rules {
+			name := item.Alert
+			if name == "" {
+				name = item.Record
+			}
+			ruleErr := func(err error) error {
+				return fmt.Errorf("group %q, rule %d, %q: %w", group.Name, i+1, name, err)
+			}
+
+			r := rulefmt.Rule{
+				Record:      item.Record,
+				Alert:       item.Alert,
+				Expr:        item.Expr,
+				Labels:      item.Labels,
+				Annotations: item.Annotations,
+			}
+			if item.For != "" {
+				forDuration, err := model.ParseDuration(item.For)
+				if err != nil {
+					errs = append(errs, ruleErr(fmt.Errorf("invalid for: %w", err)))
+				}
+				r.For = forDuration
+			}
+			// Validate the rule as thanos-rule does when loading it.
+			if nodes := r.Validate(rulefmt.RuleNode{}); len(nodes) > 0 {
+				for _, node := range nodes {
+					errs = append(errs, ruleErr((&node).Unwrap()))
+				}
+				continue
+			}
+			if restricted {
+				expr, err := injectClusterMatcher(item.Expr, clusters)
+				if err != nil {
+					errs = append(errs, ruleErr(err))
+					continue
+				}
+				r.Expr = expr
+			}
+			ruleGroup.Rules = append(ruleGroup.Rules, r)
+		}
+		groups = append(groups, ruleGroup)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return groups, nil
+}
@@ -0,0 +1,73 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package observabilityrule
+
+import (
+	"testing"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInjectClusterMatcher(t *testing.T) {
+	expr, err := injectClusterMatcher(`sum by (cluster) (rate(http_requests_total{code="500"}[5m])) / on (cluster) up`,
+		[]string{"prod-1", "prod.2"})
+	require.NoError(t, err)
+	assert.Equal(t, `sum by (cluster) (rate(http_requests_total{cluster=~"prod-1|prod\\.2",code="500"}[5m])) / on (cluster) up{cluster=~"prod-1|prod\\.2"}`, expr)
+
+	expr, err = injectClusterMatcher(`up == 0`, []string{"prod.1"})
+	require.NoError(t, err)
+	assert.Equal(t, `up{cluster="prod.1"} == 0`, expr)
+
+	_, err = injectClusterMatcher(`sum(`, []string{"prod-1"})
+	assert.Error(t, err)
+}
+
+func TestRenderRuleGroups(t *testing.T) {
+	rule := &mcov1beta2.ObservabilityRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "team-a"},
+		Spec: mcov1beta2.ObservabilityRuleSpec{
+			Groups: []mcov1beta2.ObservabilityRuleGroup{{
+				Name:     "api",
+				Interval: "1m",
+				Rules: []mcov1beta2.ObservabilityRuleItem{
+					{Record: "cluster:up:sum", Expr: "sum by (cluster) (up)"},
+					{
+						Alert:       "ClusterDown",
+						Expr:        "up == 0",
+						For:         "5m",
+						Labels:      map[string]string{"severity": "critical"},
+						Annotations: map[string]string{"summary": "{{ $labels.cluster }} is down"},
+					},
+				},
+			}},
+		},
+	}
+
+	groups, err := renderRuleGroups(rule, []string{"prod-1"}, true)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "team-a/api/api", groups[0].Name)
+	assert.Equal(t, "1m", groups[0].Interval.String())
+	require.Len(t, groups[0].Rules, 2)
+	assert.Equal(t, `sum by (cluster) (up{cluster="prod-1"})`, groups[0].Rules[0].Expr)
+	assert.Equal(t, `up{cluster="prod-1"} == 0`, groups[0].Rules[1].Expr)
+	assert.Equal(t, "5m", groups[0].Rules[1].For.String())
+
+	groups, err = renderRuleGroups(rule, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, "up == 0", groups[0].Rules[1].Expr)
+
+	rule.Spec.Groups[0].Rules = append(rule.Spec.Groups[0].Rules,
+		mcov1beta2.ObservabilityRuleItem{Record: "invalid", Expr: "sum("},
+		mcov1beta2.ObservabilityRuleItem{Alert: "BadTemplate", Expr: "up", Annotations: map[string]string{"summary": "{{ .Broken"}},
+	)
+	_, err = renderRuleGroups(rule, []string{"prod-1"}, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `group "api", rule 3, "invalid": could not parse expression`)
+	assert.Contains(t, err.Error(), `group "api", rule 4, "BadTemplate"`)
+}
@@ -10,12 +10,16 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/controllers/observabilitymaintenance"
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
 	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -123,3 +127,53 @@ func generateHubInfoSecret(client client.Client, obsNamespace string,
 		Data: configYamlMap,
 	}, nil
 }
+
+// setHubInfoTenant points the remote write endpoint of the hub info secret at the write path of the tenant.
+func setHubInfoTenant(hubInfoSecret *corev1.Secret, tenant string) error {
+	hubInfo := &operatorconfig.HubInfo{}
+	if err := yaml.Unmarshal(hubInfoSecret.Data[operatorconfig.HubInfoSecretKey], hubInfo); err != nil {
+		return fmt.Errorf("failed to unmarshal hub info: %w", err)
+	}
+	endpoint, found := strings.CutSuffix(hubInfo.ObservatoriumAPIEndpoint, operatorconfig.ObservatoriumAPIRemoteWritePath)
+	if !found {
+		return fmt.Errorf("unexpected Observatorium API endpoint %q", hubInfo.ObservatoriumAPIEndpoint)
+	}
+	hubInfo.ObservatoriumAPIEndpoint = endpoint + "/api/metrics/v1/" + tenant + "/api/v1/receive"
+
+	configYaml, err := yaml.Marshal(hubInfo)
+	if err != nil {
+		return err
+	}
+	hubInfoSecret.Data[operatorconfig.HubInfoSecretKey] = configYaml
+	return nil
+}
+
+// setHubInfoMaintenanceWindows adds the maintenance windows of the cluster to the hub info secret, so that the
+// managed cluster stops forwarding its alerts to the hub during the windows.
+func setHubInfoMaintenanceWindows(ctx context.Context, c client.Client, hubInfoSecret *corev1.Secret,
+	cluster managedClusterInfo,
+) error {
+	windows := &mcov1beta2.ObservabilityMaintenanceWindowList{}
+	if err := c.List(ctx, windows); err != nil {
+		return fmt.Errorf("failed to list ObservabilityMaintenanceWindows: %w", err)
+	}
+	managedCluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: cluster.Name, Labels: cluster.Labels},
+	}
+	clusterWindows := observabilitymaintenance.ClusterMaintenanceWindows(windows.Items, managedCluster, time.Now())
+	if len(clusterWindows) == 0 {
+		return nil
+	}
+
+	hubInfo := &operatorconfig.HubInfo{}
+	if err := yaml.Unmarshal(hubInfoSecret.Data[operatorconfig.HubInfoSecretKey], hubInfo); err != nil {
+		return fmt.Errorf("failed to unmarshal hub info: %w", err)
+	}
+	hubInfo.MaintenanceWindows = clusterWindows
+	configYaml, err := yaml.Marshal(hubInfo)
+	if err != nil {
+		return err
+	}
+	hubInfoSecret.Data[operatorconfig.HubInfoSecretKey] = configYaml
+	return nil
+}
@@ -5,8 +5,10 @@
 package placementrule
 
 import (
+	"context"
 	"strings"
 	"testing"
+	"time"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	routev1 "github.com/openshift/api/route/v1"
@@ -278,3 +280,85 @@ func TestNewBYOSecret(t *testing.T) {
 		)
 	}
 }
+
+func TestSetHubInfoTenant(t *testing.T) {
+	hubInfo := &operatorconfig.HubInfo{
+		ObservatoriumAPIEndpoint: "https://custom-obs:8080/sub-path/api/metrics/v1/default/api/v1/receive",
+		AlertmanagerEndpoint:     "https://custom-obs:8080/sub-path/api/alertmanager/v2/default",
+	}
+	data, err := yaml.Marshal(hubInfo)
+	if err != nil {
+		t.Fatalf("Failed to marshal hub info: %v", err)
+	}
+	secret := &corev1.Secret{Data: map[string][]byte{operatorconfig.HubInfoSecretKey: data}}
+
+	if err := setHubInfoTenant(secret, "finance"); err != nil {
+		t.Fatalf("Failed to set the hub info tenant: %v", err)
+	}
+	hub := &operatorconfig.HubInfo{}
+	if err := yaml.Unmarshal(secret.Data[operatorconfig.HubInfoSecretKey], hub); err != nil {
+		t.Fatalf("Failed to unmarshal data in hub info secret (%v)", err)
+	}
+	if hub.ObservatoriumAPIEndpoint != "https://custom-obs:8080/sub-path/api/metrics/v1/finance/api/v1/receive" {
+		t.Errorf("Unexpected Observatorium API endpoint: %s", hub.ObservatoriumAPIEndpoint)
+	}
+	if hub.AlertmanagerEndpoint != hubInfo.AlertmanagerEndpoint {
+		t.Errorf("Unexpected Alertmanager endpoint: %s", hub.AlertmanagerEndpoint)
+	}
+
+	// The endpoint was already rewritten.
+	if err := setHubInfoTenant(secret, "retail"); err == nil {
+		t.Error("Expected an error for an unexpected Observatorium API endpoint")
+	}
+}
+
+func TestSetHubInfoMaintenanceWindows(t *testing.T) {
+	hubInfo := &operatorconfig.HubInfo{
+		AlertmanagerEndpoint: "https://custom-obs:8080/sub-path/api/alertmanager/v2/default",
+	}
+	data, err := yaml.Marshal(hubInfo)
+	if err != nil {
+		t.Fatalf("Failed to marshal hub info: %v", err)
+	}
+	start := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second).UTC())
+	end := metav1.NewTime(time.Now().Add(time.Hour).Truncate(time.Second).UTC())
+	window := &mcov1beta2.ObservabilityMaintenanceWindow{
+		ObjectMeta: metav1.ObjectMeta{Name: "upgrade"},
+		Spec: mcov1beta2.ObservabilityMaintenanceWindowSpec{
+			ClusterSets: []string{"prod"},
+			StartTime:   &start,
+			EndTime:     &end,
+		},
+	}
+	s := runtime.NewScheme()
+	if err := mcov1beta2.AddToScheme(s); err != nil {
+		t.Fatalf("Failed to add the scheme: %v", err)
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(window).Build()
+
+	// The windows of the other clusters are not sent.
+	secret := &corev1.Secret{Data: map[string][]byte{operatorconfig.HubInfoSecretKey: data}}
+	cluster := managedClusterInfo{Name: "cluster1", Labels: map[string]string{config.ClusterSetLabelKey: "dev"}}
+	if err := setHubInfoMaintenanceWindows(context.Background(), c, secret, cluster); err != nil {
+		t.Fatalf("Failed to set the hub info maintenance windows: %v", err)
+	}
+	if string(secret.Data[operatorconfig.HubInfoSecretKey]) != string(data) {
+		t.Errorf("Unexpected hub info: %s", secret.Data[operatorconfig.HubInfoSecretKey])
+	}
+
+	cluster.Labels[config.ClusterSetLabelKey] = "prod"
+	if err := setHubInfoMaintenanceWindows(context.Background(), c, secret, cluster); err != nil {
+		t.Fatalf("Failed to set the hub info maintenance windows: %v", err)
+	}
+	hub := &operatorconfig.HubInfo{}
+	if err := yaml.Unmarshal(secret.Data[operatorconfig.HubInfoSecretKey], hub); err != nil {
+		t.Fatalf("Failed to unmarshal data in hub info secret (%v)", err)
+	}
+	if len(hub.MaintenanceWindows) != 1 || !hub.MaintenanceWindows[0].Start.Equal(start.Time) ||
+		!hub.MaintenanceWindows[0].End.Equal(end.Time) {
+		t.Errorf("Unexpected maintenance windows: %v", hub.MaintenanceWindows)
+	}
+	if hub.AlertmanagerEndpoint != hubInfo.AlertmanagerEndpoint {
+		t.Errorf("Unexpected Alertmanager endpoint: %s", hub.AlertmanagerEndpoint)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -62,6 +63,7 @@ const (
 var managedManifestWorkAnnotations = []string{
 	workPostponeDeleteAnnoKey,                  // "open-cluster-management/postpone-delete"
 	workv1.ManifestConfigSpecHashAnnotationKey, // "open-cluster-management.io/config-spec-hash"
+	addonRolloutRevisionAnnotation,             // "observability.open-cluster-management.io/addon-rollout-revision"
 }
 
 // intermediate resources for the manifest work.
@@ -198,29 +200,37 @@ func getConfigSpecHashAnnotation(ctx context.Context, c client.Client, namespace
 	}, nil
 }
 
-// removePostponeDeleteAnnotationForManifestwork removes the postpone delete annotation for manifestwork so that
-// the workagent can delete the manifestwork normally
+// removePostponeDeleteAnnotationForManifestwork removes the postpone delete annotation for the manifestworks of
+// the namespace so that the workagent can delete the manifestworks normally
 func removePostponeDeleteAnnotationForManifestwork(c client.Client, namespace string) error {
-	name := namespace + workNameSuffix
-	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		found := &workv1.ManifestWork{}
-		err := c.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, found)
-		if err != nil {
-			log.Error(err, "failed to check manifestwork", "namespace", namespace, "name", name)
-			return err
+	works, err := listClusterManifestWorks(context.TODO(), c, namespace)
+	if err != nil {
+		log.Error(err, "failed to check manifestworks", "namespace", namespace)
+		return err
+	}
+	for _, work := range works {
+		if _, ok := work.GetAnnotations()[workPostponeDeleteAnnoKey]; !ok {
+			continue
 		}
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			found := &workv1.ManifestWork{}
+			err := c.Get(context.TODO(), types.NamespacedName{Name: work.Name, Namespace: namespace}, found)
+			if err != nil {
+				log.Error(err, "failed to check manifestwork", "namespace", namespace, "name", work.Name)
+				return err
+			}
 
-		if found.GetAnnotations() != nil {
-			delete(found.GetAnnotations(), workPostponeDeleteAnnoKey)
-		}
+			if found.GetAnnotations() != nil {
+				delete(found.GetAnnotations(), workPostponeDeleteAnnoKey)
+			}
 
-		err = c.Update(context.TODO(), found)
+			return c.Update(context.TODO(), found)
+		})
 		if err != nil {
 			return err
 		}
-
-		return nil
-	})
+	}
+	return nil
 }
 
 func createManifestwork(ctx context.Context, c client.Client, work *workv1.ManifestWork) error {
@@ -259,6 +269,7 @@ func createManifestwork(ctx context.Context, c client.Client, work *workv1.Manif
 	// Only update annotations we manage, preserving annotations from other controllers
 	updateManagedAnnotations(found, work)
 	found.Spec.Workload.Manifests = work.Spec.Workload.Manifests
+	found.Spec.DeleteOption = work.Spec.DeleteOption
 	err = c.Update(ctx, found)
 	if err != nil {
 		logSizeErrorDetails(fmt.Sprint(err), work)
@@ -304,6 +315,11 @@ func shouldUpdateManifestWork(desiredWork, foundWork *workv1.ManifestWork) bool
 		}
 	}
 
+	// The delete option orphans the resources moved between the split manifestworks
+	if !equality.Semantic.DeepEqual(desiredWork.Spec.DeleteOption, foundWork.Spec.DeleteOption) {
+		return true
+	}
+
 	if len(desiredManifests) != len(foundManifests) {
 		return true
 	}
@@ -402,7 +418,7 @@ func createManifestWorks(
 
 	manifests := work.Spec.Workload.Manifests
 	// inject observabilityAddon
-	obaddon, err := getObservabilityAddon(c, clusterNamespace, mco)
+	obaddon, err := getObservabilityAddon(c, clusterNamespace, mco, addonConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -508,6 +524,25 @@ func createManifestWorks(
 	log.Info(fmt.Sprintf("Cluster: %+v, Spec.NodeSelector (after): %+v", cluster.Name, spec.NodeSelector))
 	log.Info(fmt.Sprintf("Cluster: %+v, Spec.Tolerations (after): %+v", cluster.Name, spec.Tolerations))
 
+	if profile := getMetricsProfile(cluster); profile != "" {
+		spec.Containers[0].Env = append(spec.Containers[0].Env, corev1.EnvVar{
+			Name:  operatorconfig.MetricsProfile,
+			Value: profile,
+		})
+	}
+	if retention := metricsBufferRetention(addonConfig); retention != "" {
+		spec.Containers[0].Env = append(spec.Containers[0].Env, corev1.EnvVar{
+			Name:  operatorconfig.MetricsBufferRetention,
+			Value: retention,
+		})
+	}
+	if cmoConfigDryRun(addonConfig) {
+		spec.Containers[0].Env = append(spec.Containers[0].Env, corev1.EnvVar{
+			Name:  operatorconfig.CmoConfigDryRun,
+			Value: "true",
+		})
+	}
+
 	if cluster.IsLocalCluster {
 		spec.Volumes = []corev1.Volume{}
 		spec.Containers[0].VolumeMounts = []corev1.VolumeMount{}
@@ -560,6 +595,14 @@ func createManifestWorks(
 
 	// inject the hub info secret
 	hubInfo.Data[operatorconfig.ClusterNameKey] = []byte(cluster.Name)
+	if tenant := config.GetClusterTenant(mco, cluster.Labels); tenant != config.GetDefaultTenantName() {
+		if err := setHubInfoTenant(hubInfo, tenant); err != nil {
+			return nil, fmt.Errorf("failed to set the tenant of cluster %s: %w", cluster.Name, err)
+		}
+	}
+	if err := setHubInfoMaintenanceWindows(ctx, c, hubInfo, cluster); err != nil {
+		return nil, fmt.Errorf("failed to set the maintenance windows of cluster %s: %w", cluster.Name, err)
+	}
 	manifests = injectIntoWork(manifests, hubInfo)
 
 	work.Spec.Workload.Manifests = manifests
@@ -1061,6 +1104,26 @@ func generateMetricsListCM(client client.Client) (*corev1.ConfigMap, error) {
 	metricsAllowlistCM.Data[operatorconfig.MetricsConfigMapKey] = string(data)
 	metricsAllowlistCM.Data[operatorconfig.UwlMetricsConfigMapKey] = string(uwlData)
 
+	// The custom metrics are also collected on the MicroShift clusters
+	microshiftAllowlist, err := util.GetMicroShiftAllowList(client,
+		operatorconfig.AllowlistConfigMapName, config.GetDefaultNamespace())
+	if err != nil {
+		log.Error(err, "Failed to get microshift metrics allowlist")
+		return nil, err
+	}
+	if microshiftAllowlist != nil {
+		if customAllowlist != nil {
+			microshiftAllowlist, _ = util.MergeAllowlist(microshiftAllowlist, customAllowlist,
+				&operatorconfig.MetricsAllowlist{}, &operatorconfig.MetricsAllowlist{})
+		}
+		microshiftData, err := yaml.Marshal(microshiftAllowlist)
+		if err != nil {
+			log.Error(err, "Failed to marshal microshift allowlist")
+			return nil, err
+		}
+		metricsAllowlistCM.Data[operatorconfig.MicroShiftMetricsConfigMapKey] = string(microshiftData)
+	}
+
 	return metricsAllowlistCM, nil
 }
 
@@ -1070,8 +1133,9 @@ func generateMetricsListCM(client client.Client) (*corev1.ConfigMap, error) {
 // If the addon is found with the mco source annotation, it will update the existing addon with the new values from MCO
 // If the addon is found with the override source annotation, it will not update the existing addon but it will use the existing values.
 // If the addon is found without any source annotation, it will add the mco source annotation and use the MCO values (upgrade case from ACM 2.12.2).
+// The MCO values are overridden by the AddOnDeploymentConfig of the cluster.
 func getObservabilityAddon(c client.Client, namespace string,
-	mco *mcov1beta2.MultiClusterObservability,
+	mco *mcov1beta2.MultiClusterObservability, addonConfig *addonv1beta1.AddOnDeploymentConfig,
 ) (*mcov1beta1.ObservabilityAddon, error) {
 	if namespace == config.GetDefaultNamespace() {
 		return nil, nil
@@ -1116,7 +1180,10 @@ func getObservabilityAddon(c client.Client, namespace string,
 	addon.Annotations = found.Annotations
 
 	if found.Annotations[addonSourceAnnotation] == addonSourceMCO {
-		setObservabilityAddonSpec(addon, mco.Spec.ObservabilityAddonSpec, config.GetOBAResources(mco.Spec.ObservabilityAddonSpec, mco.Spec.InstanceSize))
+		desiredSpec, _ := desiredObservabilityAddonSpec(mco, addonConfig)
+		if desiredSpec != nil {
+			setObservabilityAddonSpec(addon, desiredSpec, desiredSpec.Resources)
+		}
 	}
 
 	if found.Annotations[addonSourceAnnotation] == addonSourceOverride {
@@ -0,0 +1,324 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package placementrule
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	workv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// manifestWorkSizeLimit is the size limit of the manifests of a ManifestWork enforced by the OCM work webhook.
+	manifestWorkSizeLimit = 500 * 1024
+	// manifestWorkSplitThreshold is the estimated size of the manifests above which a ManifestWork is split.
+	// As in the OCM work builder, the margin covers the difference between the estimation and the size computed
+	// by the webhook.
+	manifestWorkSplitThreshold = manifestWorkSizeLimit * 8 / 10
+)
+
+// The groups of manifests moved to their own ManifestWorks when a ManifestWork is split.
+const (
+	manifestGroupCRDs   = "crds"
+	manifestGroupRBAC   = "rbac"
+	manifestGroupConfig = "config"
+)
+
+var manifestGroups = []string{manifestGroupCRDs, manifestGroupRBAC, manifestGroupConfig}
+
+// manifestGroup returns the group of the manifest moved out of the main ManifestWork when it is split, or "" when
+// the manifest stays in the main ManifestWork. The namespace, the operator deployment and the ObservabilityAddon
+// with its CRD stay in the main ManifestWork: moving the CRD would delete the ObservabilityAddon if the work agent
+// removed the CRD before another ManifestWork adopted it.
+func manifestGroup(kind, name string) string {
+	switch kind {
+	case "CustomResourceDefinition":
+		if strings.HasPrefix(name, "observabilityaddons.") {
+			return ""
+		}
+		return manifestGroupCRDs
+	case "ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding":
+		return manifestGroupRBAC
+	case "ConfigMap", "Secret":
+		return manifestGroupConfig
+	}
+	return ""
+}
+
+// manifestSize estimates the size of the manifest in the ManifestWork.
+func manifestSize(m workv1.Manifest) (int, error) {
+	if len(m.Raw) > 0 {
+		return len(m.Raw), nil
+	}
+	raw, err := json.Marshal(m.Object)
+	if err != nil {
+		return 0, err
+	}
+	return len(raw), nil
+}
+
+// manifestObjectMeta decodes the type and object metadata of the manifest, whether it is decoded or raw.
+func manifestObjectMeta(m workv1.Manifest) (*metav1.PartialObjectMetadata, error) {
+	content, err := m.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	partial := &metav1.PartialObjectMetadata{}
+	if err := json.Unmarshal(content, partial); err != nil {
+		return nil, err
+	}
+	return partial, nil
+}
+
+// isClusterWorkName returns whether the ManifestWork name is the name of the main ManifestWork of the managed cluster
+// namespace or of one of its split ManifestWorks.
+func isClusterWorkName(namespace, name string) bool {
+	mainName := namespace + workNameSuffix
+	if name == mainName {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(name, mainName+"-")
+	if !ok {
+		return false
+	}
+	group, part, hasPart := strings.Cut(suffix, "-")
+	if !slices.Contains(manifestGroups, group) {
+		return false
+	}
+	if !hasPart {
+		return true
+	}
+	n, err := strconv.Atoi(part)
+	return err == nil && n > 0 && strconv.Itoa(n) == part
+}
+
+// splitManifestWork returns the ManifestWorks the manifests of the work are deployed with. The work is returned as
+// is when the estimated size of its manifests is below the manifestWorkSplitThreshold. Otherwise the CRDs, RBAC and
+// configuration manifests are moved to the <work>-crds, <work>-rbac and <work>-config ManifestWorks, split again in
+// numbered parts when their manifests exceed the threshold. The split ManifestWorks keep the labels and annotations
+// of the work.
+func splitManifestWork(work *workv1.ManifestWork) ([]*workv1.ManifestWork, error) {
+	sizes := make([]int, len(work.Spec.Workload.Manifests))
+	total := 0
+	for i, m := range work.Spec.Workload.Manifests {
+		size, err := manifestSize(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate the size of a manifest of manifestwork %s/%s: %w",
+				work.Namespace, work.Name, err)
+		}
+		sizes[i] = size
+		total += size
+	}
+	if total <= manifestWorkSplitThreshold {
+		return []*workv1.ManifestWork{work}, nil
+	}
+
+	main := work.DeepCopy()
+	main.Spec.Workload.Manifests = []workv1.Manifest{}
+	grouped := map[string][]int{}
+	for i, m := range work.Spec.Workload.Manifests {
+		partial, err := manifestObjectMeta(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode a manifest of manifestwork %s/%s: %w", work.Namespace, work.Name, err)
+		}
+		group := manifestGroup(partial.Kind, partial.Name)
+		if group == "" {
+			main.Spec.Workload.Manifests = append(main.Spec.Workload.Manifests, m)
+			continue
+		}
+		grouped[group] = append(grouped[group], i)
+	}
+
+	works := []*workv1.ManifestWork{main}
+	for _, group := range manifestGroups {
+		var part *workv1.ManifestWork
+		parts, partSize := 0, 0
+		for _, i := range grouped[group] {
+			// A manifest larger than the threshold gets a part of its own, and the size error is reported
+			// when the part is created.
+			if part == nil || partSize+sizes[i] > manifestWorkSplitThreshold {
+				name := fmt.Sprintf("%s-%s", work.Name, group)
+				if parts > 0 {
+					name = fmt.Sprintf("%s-%d", name, parts)
+				}
+				part = newManifestwork(name, work.Namespace)
+				part.Labels = maps.Clone(work.Labels)
+				part.Annotations = maps.Clone(work.Annotations)
+				works = append(works, part)
+				parts++
+				partSize = 0
+			}
+			part.Spec.Workload.Manifests = append(part.Spec.Workload.Manifests, work.Spec.Workload.Manifests[i])
+			partSize += sizes[i]
+		}
+	}
+	log.Info("Split the manifestwork exceeding the size threshold", "namespace", work.Namespace, "name", work.Name,
+		"size", total, "threshold", manifestWorkSplitThreshold, "manifestworks", len(works))
+	return works, nil
+}
+
+// listClusterManifestWorks lists the main and split ManifestWorks of the managed cluster namespace.
+func listClusterManifestWorks(ctx context.Context, c client.Client, namespace string) ([]workv1.ManifestWork, error) {
+	workList := &workv1.ManifestWorkList{}
+	if err := c.List(ctx, workList, client.InNamespace(namespace),
+		client.MatchingLabels{ownerLabelKey: ownerLabelValue}); err != nil {
+		return nil, fmt.Errorf("failed to list the manifestworks in namespace %s: %w", namespace, err)
+	}
+	return slices.DeleteFunc(workList.Items, func(w workv1.ManifestWork) bool {
+		return !isClusterWorkName(namespace, w.Name)
+	}), nil
+}
+
+// createManifestWorkSet creates or updates the ManifestWorks of a managed cluster returned by splitManifestWork,
+// then deletes the split ManifestWorks that are no longer desired.
+// A ManifestWork selectively orphans the resources moved to another ManifestWork of the set, so that the work agent
+// does not delete them when they leave it, before the other ManifestWork adopts them. The ManifestWorks gaining
+// resources are applied first for the same reason: the split ManifestWorks when the work is split, the main
+// ManifestWork when it is no longer split.
+func createManifestWorkSet(ctx context.Context, c client.Client, works []*workv1.ManifestWork) error {
+	if len(works) == 0 {
+		return nil
+	}
+	namespace := works[0].Namespace
+	found, err := listClusterManifestWorks(ctx, c, namespace)
+	if err != nil {
+		return err
+	}
+	foundByName := map[string]*workv1.ManifestWork{}
+	for i := range found {
+		foundByName[found[i].Name] = &found[i]
+	}
+	desiredWork := map[workv1.OrphaningRule]string{}
+	for _, work := range works {
+		for _, m := range work.Spec.Workload.Manifests {
+			resource, err := manifestResource(m)
+			if err != nil {
+				return fmt.Errorf("failed to decode a manifest of manifestwork %s/%s: %w", work.Namespace, work.Name, err)
+			}
+			desiredWork[resource] = work.Name
+		}
+	}
+
+	ordered := works
+	if len(works) > 1 {
+		ordered = append(slices.Clone(works[1:]), works[0])
+	}
+	for _, work := range ordered {
+		rules, err := movedResourceRules(foundByName[work.Name], desiredWork)
+		if err != nil {
+			return err
+		}
+		setOrphaningRules(work, rules)
+		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			return createManifestwork(ctx, c, work)
+		}); err != nil {
+			return err
+		}
+	}
+
+	for i := range found {
+		stale := &found[i]
+		if slices.ContainsFunc(works, func(w *workv1.ManifestWork) bool { return w.Name == stale.Name }) {
+			continue
+		}
+		rules, err := movedResourceRules(stale, desiredWork)
+		if err != nil {
+			return err
+		}
+		if len(rules) > 0 {
+			desired := stale.DeepCopy()
+			setOrphaningRules(desired, rules)
+			if !equality.Semantic.DeepEqual(stale.Spec.DeleteOption, desired.Spec.DeleteOption) {
+				if err := c.Update(ctx, desired); err != nil {
+					return fmt.Errorf("failed to orphan the moved resources of manifestwork %s/%s: %w",
+						stale.Namespace, stale.Name, err)
+				}
+			}
+		}
+		log.Info("Deleting the split manifestwork no longer needed", "namespace", stale.Namespace, "name", stale.Name)
+		if err := c.Delete(ctx, stale); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete manifestwork %s/%s: %w", stale.Namespace, stale.Name, err)
+		}
+	}
+	return nil
+}
+
+// movedResourceRules returns the orphaning rules of the resources of the found ManifestWork, or already orphaned by
+// it, that are desired in another ManifestWork.
+func movedResourceRules(found *workv1.ManifestWork, desiredWork map[workv1.OrphaningRule]string) ([]workv1.OrphaningRule, error) {
+	if found == nil {
+		return nil, nil
+	}
+	resources := []workv1.OrphaningRule{}
+	for _, m := range found.Spec.Workload.Manifests {
+		resource, err := manifestResource(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode a manifest of manifestwork %s/%s: %w", found.Namespace, found.Name, err)
+		}
+		resources = append(resources, resource)
+	}
+	// The resources already moved stay orphaned, in case the work agent did not process the move yet.
+	if found.Spec.DeleteOption != nil && found.Spec.DeleteOption.SelectivelyOrphan != nil {
+		resources = append(resources, found.Spec.DeleteOption.SelectivelyOrphan.OrphaningRules...)
+	}
+
+	rules := []workv1.OrphaningRule{}
+	for _, resource := range resources {
+		if name, ok := desiredWork[resource]; ok && name != found.Name && !slices.Contains(rules, resource) {
+			rules = append(rules, resource)
+		}
+	}
+	slices.SortFunc(rules, func(a, b workv1.OrphaningRule) int {
+		return cmp.Or(
+			strings.Compare(a.Group, b.Group),
+			strings.Compare(a.Resource, b.Resource),
+			strings.Compare(a.Namespace, b.Namespace),
+			strings.Compare(a.Name, b.Name),
+		)
+	})
+	return rules, nil
+}
+
+// manifestResource identifies the resource of the manifest as an orphaning rule. The resource is guessed from the
+// kind, as the CRDs of the manifests may not be installed in the hub.
+func manifestResource(m workv1.Manifest) (workv1.OrphaningRule, error) {
+	partial, err := manifestObjectMeta(m)
+	if err != nil {
+		return workv1.OrphaningRule{}, err
+	}
+	gvr, _ := meta.UnsafeGuessKindToResource(schema.FromAPIVersionAndKind(partial.APIVersion, partial.Kind))
+	return workv1.OrphaningRule{
+		Group:     gvr.Group,
+		Resource:  gvr.Resource,
+		Namespace: partial.Namespace,
+		Name:      partial.Name,
+	}, nil
+}
+
+// setOrphaningRules sets the delete option of the work selectively orphaning the resources of the rules.
+func setOrphaningRules(work *workv1.ManifestWork, rules []workv1.OrphaningRule) {
+	if len(rules) == 0 {
+		work.Spec.DeleteOption = nil
+		return
+	}
+	work.Spec.DeleteOption = &workv1.DeleteOption{
+		PropagationPolicy: workv1.DeletePropagationPolicyTypeSelectivelyOrphan,
+		SelectivelyOrphan: &workv1.SelectivelyOrphan{OrphaningRules: rules},
+	}
+}
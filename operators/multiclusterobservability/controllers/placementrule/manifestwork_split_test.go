@@ -0,0 +1,200 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package placementrule
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	workv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// rawManifest returns a manifest of the given kind and name padded to the given size.
+func rawManifest(t *testing.T, apiVersion, kind, name string, size int) workv1.Manifest {
+	t.Helper()
+	format := `{"apiVersion":%q,"kind":%q,"metadata":{"name":%q,"namespace":"open-cluster-management-addon-observability"},"data":{"pad":"%s"}}`
+	base := len(fmt.Sprintf(format, apiVersion, kind, name, ""))
+	require.GreaterOrEqual(t, size, base)
+	raw := fmt.Sprintf(format, apiVersion, kind, name, strings.Repeat("x", size-base))
+	require.Len(t, raw, size)
+	return workv1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(raw)}}
+}
+
+func workNames(works []*workv1.ManifestWork) []string {
+	names := make([]string, 0, len(works))
+	for _, w := range works {
+		names = append(names, w.Name)
+	}
+	return names
+}
+
+func TestSplitManifestWork(t *testing.T) {
+	deployment := func(size int) workv1.Manifest {
+		return rawManifest(t, "apps/v1", "Deployment", "endpoint-observability-operator", size)
+	}
+	configMap := func(name string, size int) workv1.Manifest {
+		return rawManifest(t, "v1", "ConfigMap", name, size)
+	}
+
+	t.Run("at the threshold", func(t *testing.T) {
+		work := newManifestwork(namespace+workNameSuffix, namespace)
+		work.Spec.Workload.Manifests = []workv1.Manifest{deployment(1024), configMap("allowlist", manifestWorkSplitThreshold-1024)}
+		works, err := splitManifestWork(work)
+		require.NoError(t, err)
+		require.Len(t, works, 1)
+		assert.Same(t, work, works[0])
+	})
+
+	t.Run("above the threshold", func(t *testing.T) {
+		work := newManifestwork(namespace+workNameSuffix, namespace)
+		work.Annotations[addonRolloutRevisionAnnotation] = "rev"
+		work.Spec.Workload.Manifests = []workv1.Manifest{
+			deployment(1024),
+			rawManifest(t, "apiextensions.k8s.io/v1", "CustomResourceDefinition", "observabilityaddons.observability.open-cluster-management.io", 1024),
+			rawManifest(t, "apiextensions.k8s.io/v1", "CustomResourceDefinition", "prometheuses.monitoring.coreos.com", 1024),
+			rawManifest(t, "rbac.authorization.k8s.io/v1", "ClusterRole", "endpoint-observability-operator", 1024),
+			configMap("allowlist", manifestWorkSplitThreshold-4096+1),
+		}
+		works, err := splitManifestWork(work)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			namespace + workNameSuffix,
+			namespace + workNameSuffix + "-crds",
+			namespace + workNameSuffix + "-rbac",
+			namespace + workNameSuffix + "-config",
+		}, workNames(works))
+		// The operator and the ObservabilityAddon CRD stay in the main work.
+		assert.Len(t, works[0].Spec.Workload.Manifests, 2)
+		for _, w := range works[1:] {
+			assert.Len(t, w.Spec.Workload.Manifests, 1)
+			assert.Equal(t, work.Labels, w.Labels)
+			assert.Equal(t, "rev", w.Annotations[addonRolloutRevisionAnnotation])
+			assert.Contains(t, w.Annotations, workPostponeDeleteAnnoKey)
+		}
+		// The work is left untouched.
+		assert.Len(t, work.Spec.Workload.Manifests, 5)
+	})
+
+	t.Run("groups split in parts", func(t *testing.T) {
+		work := newManifestwork(namespace+workNameSuffix, namespace)
+		work.Spec.Workload.Manifests = []workv1.Manifest{
+			deployment(1024),
+			configMap("a", manifestWorkSplitThreshold/2),
+			configMap("b", manifestWorkSplitThreshold/2),
+			configMap("c", 1024),
+			configMap("d", manifestWorkSplitThreshold+1),
+		}
+		works, err := splitManifestWork(work)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			namespace + workNameSuffix,
+			namespace + workNameSuffix + "-config",
+			namespace + workNameSuffix + "-config-1",
+			namespace + workNameSuffix + "-config-2",
+		}, workNames(works))
+		assert.Len(t, works[1].Spec.Workload.Manifests, 2)
+		assert.Len(t, works[2].Spec.Workload.Manifests, 1)
+		// The manifest larger than the threshold gets its own part.
+		assert.Len(t, works[3].Spec.Workload.Manifests, 1)
+	})
+}
+
+func TestIsClusterWorkName(t *testing.T) {
+	assert.True(t, isClusterWorkName("cluster1", "cluster1-observability"))
+	assert.True(t, isClusterWorkName("cluster1", "cluster1-observability-crds"))
+	assert.True(t, isClusterWorkName("cluster1", "cluster1-observability-config-2"))
+	assert.False(t, isClusterWorkName("cluster1", "cluster2-observability"))
+	assert.False(t, isClusterWorkName("cluster1", "cluster1-observability-other"))
+	assert.False(t, isClusterWorkName("cluster1", "cluster1-observability-config-0"))
+	assert.False(t, isClusterWorkName("cluster1", "cluster1-observability-config-01"))
+}
+
+func TestCreateManifestWorkSet(t *testing.T) {
+	initSchema(t)
+	ctx := context.Background()
+	c := fake.NewClientBuilder().Build()
+
+	deployment := rawManifest(t, "apps/v1", "Deployment", "endpoint-observability-operator", 1024)
+	allowlist := rawManifest(t, "v1", "ConfigMap", "allowlist", manifestWorkSplitThreshold)
+	allowlistRule := workv1.OrphaningRule{
+		Resource:  "configmaps",
+		Namespace: "open-cluster-management-addon-observability",
+		Name:      "allowlist",
+	}
+	newWork := func() *workv1.ManifestWork {
+		work := newManifestwork(namespace+workNameSuffix, namespace)
+		work.Spec.Workload.Manifests = []workv1.Manifest{deployment, allowlist}
+		return work
+	}
+	getWork := func(name string) (*workv1.ManifestWork, error) {
+		work := &workv1.ManifestWork{}
+		return work, c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, work)
+	}
+
+	// The work below the threshold is created as is.
+	work := newWork()
+	work.Spec.Workload.Manifests = work.Spec.Workload.Manifests[:1]
+	works, err := splitManifestWork(work)
+	require.NoError(t, err)
+	require.NoError(t, createManifestWorkSet(ctx, c, works))
+	main, err := getWork(namespace + workNameSuffix)
+	require.NoError(t, err)
+	assert.Nil(t, main.Spec.DeleteOption)
+
+	// The allowlist grows and moves to the config work, orphaned by the main work.
+	main.Spec.Workload.Manifests = append(main.Spec.Workload.Manifests, rawManifest(t, "v1", "ConfigMap", "allowlist", 1024))
+	require.NoError(t, c.Update(ctx, main))
+	work = newWork()
+	works, err = splitManifestWork(work)
+	require.NoError(t, err)
+	require.Len(t, works, 2)
+	require.NoError(t, createManifestWorkSet(ctx, c, works))
+	main, err = getWork(namespace + workNameSuffix)
+	require.NoError(t, err)
+	assert.Len(t, main.Spec.Workload.Manifests, 1)
+	require.NotNil(t, main.Spec.DeleteOption)
+	assert.Equal(t, workv1.DeletePropagationPolicyTypeSelectivelyOrphan, main.Spec.DeleteOption.PropagationPolicy)
+	assert.Equal(t, []workv1.OrphaningRule{allowlistRule}, main.Spec.DeleteOption.SelectivelyOrphan.OrphaningRules)
+	config, err := getWork(namespace + workNameSuffix + "-config")
+	require.NoError(t, err)
+	assert.Len(t, config.Spec.Workload.Manifests, 1)
+	assert.Nil(t, config.Spec.DeleteOption)
+
+	// The allowlist stays orphaned by the main work while it is in the config work.
+	require.NoError(t, createManifestWorkSet(ctx, c, works))
+	main, err = getWork(namespace + workNameSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, []workv1.OrphaningRule{allowlistRule}, main.Spec.DeleteOption.SelectivelyOrphan.OrphaningRules)
+
+	// The allowlist moves back to the main work and the config work is deleted.
+	work = newWork()
+	work.Spec.Workload.Manifests[1] = rawManifest(t, "v1", "ConfigMap", "allowlist", 1024)
+	works, err = splitManifestWork(work)
+	require.NoError(t, err)
+	require.Len(t, works, 1)
+	require.NoError(t, createManifestWorkSet(ctx, c, works))
+	main, err = getWork(namespace + workNameSuffix)
+	require.NoError(t, err)
+	assert.Len(t, main.Spec.Workload.Manifests, 2)
+	assert.Nil(t, main.Spec.DeleteOption)
+	_, err = getWork(namespace + workNameSuffix + "-config")
+	assert.True(t, err != nil && strings.Contains(err.Error(), "not found"))
+
+	// The postpone delete annotation is removed from all the works.
+	require.NoError(t, createManifestWorkSet(ctx, c, []*workv1.ManifestWork{main, newManifestwork(namespace+workNameSuffix+"-rbac", namespace)}))
+	require.NoError(t, removePostponeDeleteAnnotationForManifestwork(c, namespace))
+	for _, name := range []string{namespace + workNameSuffix, namespace + workNameSuffix + "-rbac"} {
+		w, err := getWork(name)
+		require.NoError(t, err)
+		assert.NotContains(t, w.Annotations, workPostponeDeleteAnnoKey)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -347,6 +348,36 @@ func TestGetAllowList(t *testing.T) {
 	}
 }
 
+func TestGenerateMetricsListCMMicroShift(t *testing.T) {
+	initSchema(t)
+	allowlistCM := NewMetricsAllowListCM()
+	c := fake.NewClientBuilder().WithRuntimeObjects(allowlistCM, NewMetricsCustomAllowListCM()).Build()
+	cm, err := generateMetricsListCM(c)
+	if err != nil {
+		t.Fatalf("Failed to generate the allowlist configmap: %v", err)
+	}
+	if _, ok := cm.Data[operatorconfig.MicroShiftMetricsConfigMapKey]; ok {
+		t.Fatalf("the microshift allowlist should not be set when the default allowlist has none")
+	}
+
+	allowlistCM.Data[operatorconfig.MicroShiftMetricsConfigMapKey] = `
+  names:
+    - m
+`
+	c = fake.NewClientBuilder().WithRuntimeObjects(allowlistCM, NewMetricsCustomAllowListCM()).Build()
+	cm, err = generateMetricsListCM(c)
+	if err != nil {
+		t.Fatalf("Failed to generate the allowlist configmap: %v", err)
+	}
+	microshiftAllowlist := &operatorconfig.MetricsAllowlist{}
+	if err := yaml.Unmarshal([]byte(cm.Data[operatorconfig.MicroShiftMetricsConfigMapKey]), microshiftAllowlist); err != nil {
+		t.Fatalf("Failed to unmarshal the microshift allowlist: %v", err)
+	}
+	if !reflect.DeepEqual(microshiftAllowlist.NameList, []string{"m", "c", "d"}) {
+		t.Errorf("the custom metrics are not merged into the microshift allowlist: %v", microshiftAllowlist.NameList)
+	}
+}
+
 func getRuntimeObjects() []runtime.Object {
 	return []runtime.Object{
 		newTestObsApiRoute(),
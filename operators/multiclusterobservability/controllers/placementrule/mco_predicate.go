@@ -49,6 +49,16 @@ func getMCOPred(c client.Client, crdMap map[string]bool) predicate.Funcs {
 				retval = true
 			}
 
+			// the tenants select the remote write endpoint of each managed cluster
+			if !reflect.DeepEqual(newMCO.Spec.Tenants, oldMCO.Spec.Tenants) {
+				retval = true
+			}
+
+			// the rollout strategy selects the managed clusters receiving the addon updates
+			if !reflect.DeepEqual(newMCO.Spec.AddonRollout, oldMCO.Spec.AddonRollout) {
+				retval = true
+			}
+
 			// if value changed, then mustReconcile is true
 			if oldAlertingStatus != newAlertingStatus {
 				config.SetAlertingDisabled(newAlertingStatus)
@@ -20,6 +20,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -86,7 +87,14 @@ func deleteObsAddonObject(ctx context.Context, c client.Client, namespace string
 // It will initially mirror values from the MultiClusterObservability CR with the mco source annotation.
 // If an existing addon is found with the mco source annotation it will update the existing addon with the new values.
 // If the existing addon is created by the user with the override source annotation, it will not update the existing addon.
-func createObsAddon(mco *mcov1beta2.MultiClusterObservability, c client.Client, namespace string) error {
+// The values of the MCO are overridden by the AddOnDeploymentConfig of the cluster, reported in the
+// addonOverridesAnnotation.
+func createObsAddon(
+	mco *mcov1beta2.MultiClusterObservability,
+	c client.Client,
+	namespace string,
+	addonConfig *addonv1beta1.AddOnDeploymentConfig,
+) error {
 	if namespace == config.GetDefaultNamespace() {
 		return nil
 	}
@@ -107,8 +115,12 @@ func createObsAddon(mco *mcov1beta2.MultiClusterObservability, c client.Client,
 		},
 	}
 
-	if mco.Spec.ObservabilityAddonSpec != nil {
-		setObservabilityAddonSpec(ec, mco.Spec.ObservabilityAddonSpec, config.GetOBAResources(mco.Spec.ObservabilityAddonSpec, mco.Spec.InstanceSize))
+	desiredSpec, overrides := desiredObservabilityAddonSpec(mco, addonConfig)
+	if desiredSpec != nil {
+		setObservabilityAddonSpec(ec, desiredSpec, desiredSpec.Resources)
+	}
+	if overrides != "" {
+		ec.Annotations[addonOverridesAnnotation] = overrides
 	}
 
 	found := &obsv1beta1.ObservabilityAddon{}
@@ -135,9 +147,14 @@ func createObsAddon(mco *mcov1beta2.MultiClusterObservability, c client.Client,
 
 	// Check if existing addon was created by MCO
 	if found.Annotations != nil && found.Annotations[addonSourceAnnotation] == addonSourceMCO {
-		// Only update if specs are different
-		if !equality.Semantic.DeepEqual(found.Spec, ec.Spec) {
+		// Only update if specs or overrides are different
+		if !equality.Semantic.DeepEqual(found.Spec, ec.Spec) || found.Annotations[addonOverridesAnnotation] != overrides {
 			found.Spec = ec.Spec
+			if overrides != "" {
+				found.Annotations[addonOverridesAnnotation] = overrides
+			} else {
+				delete(found.Annotations, addonOverridesAnnotation)
+			}
 			err = c.Update(context.TODO(), found)
 			if err != nil {
 				return fmt.Errorf("failed to update observabilityaddon cr: %w", err)
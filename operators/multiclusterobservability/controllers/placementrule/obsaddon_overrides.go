@@ -0,0 +1,292 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package placementrule
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	obshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	obsv1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// addonOverridesAnnotation reports the settings of the ObservabilityAddon overridden by the
+	// AddOnDeploymentConfig of the managed cluster.
+	addonOverridesAnnotation = "observability.open-cluster-management.io/addon-config-overrides"
+
+	// The customized variables of the AddOnDeploymentConfig overriding the ObservabilityAddon spec of the MCO.
+	addonVariableInterval             = "metricsInterval"
+	addonVariableWorkers              = "metricsWorkers"
+	addonVariableScrapeSizeLimitBytes = "metricsScrapeSizeLimitBytes"
+
+	// addonVariableBufferRetention enables the buffering of the metrics on a persistent volume by the Prometheus
+	// agent of the managed cluster, for the clusters with an intermittent connectivity to the hub.
+	addonVariableBufferRetention = "metricsBufferRetention"
+
+	// addonVariableCmoConfigDryRun only reports the cluster-monitoring-config of the managed cluster merged with
+	// the fields of the addon, without applying it.
+	addonVariableCmoConfigDryRun = "cmoConfigDryRun"
+
+	// metricsCollectorContainerID matches the resource requirements of the AddOnDeploymentConfig
+	// to the metrics-collector container.
+	metricsCollectorContainerID = "deployments:metrics-collector-deployment:metrics-collector"
+
+	// addonSettingsConditionType reports on the ManagedClusterAddOn the effective settings of the ObservabilityAddon
+	// of the managed cluster. Its reason is the source of the settings.
+	addonSettingsConditionType      = "ObservabilityAddonSettings"
+	reasonSettingsFromMCO           = "MultiClusterObservability"
+	reasonSettingsFromAddonConfig   = "AddOnDeploymentConfig"
+	reasonSettingsFromAddonOverride = "ObservabilityAddonOverride"
+)
+
+// desiredObservabilityAddonSpec returns the ObservabilityAddon spec of a managed cluster: the addon spec of the MCO
+// overridden by the customized variables and the metrics-collector resource requirements of the AddOnDeploymentConfig
+// of the cluster. It also returns the overridden settings, in the format of the addonOverridesAnnotation.
+// The invalid variables are ignored so that a typo in a config does not stop the metrics of the clusters using it.
+func desiredObservabilityAddonSpec(
+	mco *mcov1beta2.MultiClusterObservability,
+	addonConfig *addonv1beta1.AddOnDeploymentConfig,
+) (*obshared.ObservabilityAddonSpec, string) {
+	if mco.Spec.ObservabilityAddonSpec == nil {
+		return nil, ""
+	}
+	spec := mco.Spec.ObservabilityAddonSpec.DeepCopy()
+	spec.Resources = config.GetOBAResources(mco.Spec.ObservabilityAddonSpec, mco.Spec.InstanceSize)
+	if addonConfig == nil {
+		return spec, ""
+	}
+
+	overrides := []string{}
+	for _, variable := range addonConfig.Spec.CustomizedVariables {
+		var err error
+		switch variable.Name {
+		case addonVariableInterval:
+			var interval int
+			if interval, err = parseAddonVariable(variable.Value, 15, 3600); err == nil {
+				spec.Interval = int32(interval) // #nosec G115 -- the interval is at most 3600.
+			}
+		case addonVariableWorkers:
+			var workers int
+			if workers, err = parseAddonVariable(variable.Value, 1, 1024); err == nil {
+				spec.Workers = int32(workers) // #nosec G115 -- the workers are at most 1024.
+			}
+		case addonVariableScrapeSizeLimitBytes:
+			var limit int
+			if limit, err = parseAddonVariable(variable.Value, 1, 0); err == nil {
+				spec.ScrapeSizeLimitBytes = limit
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			log.Info("Ignoring the invalid variable of the AddOnDeploymentConfig",
+				"namespace", addonConfig.Namespace, "name", addonConfig.Name, "variable", variable.Name, "error", err.Error())
+			continue
+		}
+		overrides = append(overrides, variable.Name+"="+variable.Value)
+	}
+
+	// As in the addon framework, the last resource requirements matching the container take precedence.
+	var resources *corev1.ResourceRequirements
+	for i := range addonConfig.Spec.ResourceRequirements {
+		if containerIDMatches(addonConfig.Spec.ResourceRequirements[i].ContainerID, metricsCollectorContainerID) {
+			resources = &addonConfig.Spec.ResourceRequirements[i].Resources
+		}
+	}
+	if resources != nil {
+		spec.Resources.Requests = mergeResourceList(spec.Resources.Requests, resources.Requests)
+		spec.Resources.Limits = mergeResourceList(spec.Resources.Limits, resources.Limits)
+		overrides = append(overrides, "resources")
+	}
+
+	if len(overrides) == 0 {
+		return spec, ""
+	}
+	return spec, fmt.Sprintf("%s/%s: %s", addonConfig.Namespace, addonConfig.Name, strings.Join(overrides, ","))
+}
+
+// metricsBufferRetention returns the retention of the metrics buffered by the Prometheus agent of the managed cluster
+// while the hub is unreachable, set by the AddOnDeploymentConfig. It returns an empty string when the buffering is
+// disabled or when the retention is not a valid Prometheus duration.
+func metricsBufferRetention(addonConfig *addonv1beta1.AddOnDeploymentConfig) string {
+	if addonConfig == nil {
+		return ""
+	}
+	retention := ""
+	for _, variable := range addonConfig.Spec.CustomizedVariables {
+		if variable.Name != addonVariableBufferRetention {
+			continue
+		}
+		if d, err := model.ParseDuration(variable.Value); err != nil || d <= 0 {
+			log.Info("Ignoring the invalid variable of the AddOnDeploymentConfig",
+				"namespace", addonConfig.Namespace, "name", addonConfig.Name, "variable", variable.Name, "value", variable.Value)
+			continue
+		}
+		retention = variable.Value
+	}
+	return retention
+}
+
+// cmoConfigDryRun returns whether the AddOnDeploymentConfig enables the dry-run mode of the cluster-monitoring-config
+// of the managed cluster. The invalid booleans are ignored.
+func cmoConfigDryRun(addonConfig *addonv1beta1.AddOnDeploymentConfig) bool {
+	if addonConfig == nil {
+		return false
+	}
+	dryRun := false
+	for _, variable := range addonConfig.Spec.CustomizedVariables {
+		if variable.Name != addonVariableCmoConfigDryRun {
+			continue
+		}
+		b, err := strconv.ParseBool(variable.Value)
+		if err != nil {
+			log.Info("Ignoring the invalid variable of the AddOnDeploymentConfig",
+				"namespace", addonConfig.Namespace, "name", addonConfig.Name, "variable", variable.Name, "value", variable.Value)
+			continue
+		}
+		dryRun = b
+	}
+	return dryRun
+}
+
+// updateAddonSettingsCondition reports the effective settings of the ObservabilityAddon of the managed cluster in the
+// addonSettingsConditionType condition of its ManagedClusterAddOn. The ObservabilityAddon status is not used as it is
+// replaced by the status of the ObservabilityAddon of the managed cluster.
+func updateAddonSettingsCondition(ctx context.Context, c client.Client, addon *addonv1beta1.ManagedClusterAddOn) error {
+	obsAddon := &obsv1beta1.ObservabilityAddon{}
+	if err := c.Get(ctx, types.NamespacedName{Name: obsAddonName, Namespace: addon.Namespace}, obsAddon); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get observabilityaddon %s/%s: %w", addon.Namespace, obsAddonName, err)
+	}
+
+	// The merge patch replaces all the conditions, so it is rejected when the conditions set by the addon manager
+	// changed in between, and retried on the latest ManagedClusterAddOn.
+	condition := addonSettingsCondition(obsAddon)
+	key := client.ObjectKeyFromObject(addon)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &addonv1beta1.ManagedClusterAddOn{}
+		if err := c.Get(ctx, key, latest); err != nil {
+			return err
+		}
+		desiredAddon := latest.DeepCopy()
+		if !meta.SetStatusCondition(&desiredAddon.Status.Conditions, condition) {
+			return nil
+		}
+		return c.Status().Patch(ctx, desiredAddon, client.MergeFromWithOptions(latest, client.MergeFromWithOptimisticLock{}))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update the status of managedclusteraddon %s/%s: %w", addon.Namespace, addon.Name, err)
+	}
+	return nil
+}
+
+// addonSettingsCondition returns the addonSettingsConditionType condition of the ObservabilityAddon.
+func addonSettingsCondition(obsAddon *obsv1beta1.ObservabilityAddon) metav1.Condition {
+	reason := reasonSettingsFromMCO
+	source := "the MultiClusterObservability"
+	switch {
+	case obsAddon.Annotations[addonSourceAnnotation] == addonSourceOverride:
+		reason = reasonSettingsFromAddonOverride
+		source = "the ObservabilityAddon"
+	case obsAddon.Annotations[addonOverridesAnnotation] != "":
+		reason = reasonSettingsFromAddonConfig
+		source = "the MultiClusterObservability overridden by " + obsAddon.Annotations[addonOverridesAnnotation]
+	}
+
+	return metav1.Condition{
+		Type:    addonSettingsConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: fmt.Sprintf("The metrics collector uses %s from %s.", addonSettingsMessage(&obsAddon.Spec), source),
+	}
+}
+
+// addonSettingsMessage returns the settings of the ObservabilityAddon spec,
+// e.g. "interval=300s, workers=1, scrapeSizeLimitBytes=1073741824, limits.memory=1Gi".
+func addonSettingsMessage(spec *obshared.ObservabilityAddonSpec) string {
+	settings := []string{
+		fmt.Sprintf("interval=%ds", spec.Interval),
+		fmt.Sprintf("workers=%d", spec.Workers),
+		fmt.Sprintf("scrapeSizeLimitBytes=%d", spec.ScrapeSizeLimitBytes),
+	}
+	if spec.Resources != nil {
+		settings = append(settings, resourceListSettings("requests", spec.Resources.Requests)...)
+		settings = append(settings, resourceListSettings("limits", spec.Resources.Limits)...)
+	}
+	return strings.Join(settings, ", ")
+}
+
+// resourceListSettings returns the resources of the list sorted by name, e.g. "requests.cpu=100m".
+func resourceListSettings(prefix string, resources corev1.ResourceList) []string {
+	settings := make([]string, 0, len(resources))
+	for name, quantity := range resources {
+		settings = append(settings, fmt.Sprintf("%s.%s=%s", prefix, name, quantity.String()))
+	}
+	slices.Sort(settings)
+	return settings
+}
+
+// parseAddonVariable parses an integer variable between minValue and maxValue, when maxValue is not 0.
+func parseAddonVariable(value string, minValue, maxValue int) (int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer %q", value)
+	}
+	if i < minValue || (maxValue > 0 && i > maxValue) {
+		if maxValue > 0 {
+			return 0, fmt.Errorf("%d is not between %d and %d", i, minValue, maxValue)
+		}
+		return 0, fmt.Errorf("%d is less than %d", i, minValue)
+	}
+	return i, nil
+}
+
+// containerIDMatches reports whether the containerID pattern of an AddOnDeploymentConfig matches the containerID.
+// Each of the resource type, resource name and container name parts of the pattern can be the wildcard "*".
+func containerIDMatches(pattern, containerID string) bool {
+	patternParts := strings.SplitN(pattern, ":", 3)
+	idParts := strings.SplitN(containerID, ":", 3)
+	if len(patternParts) != 3 || len(idParts) != 3 {
+		return false
+	}
+	for i := range patternParts {
+		if patternParts[i] != "*" && patternParts[i] != idParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeResourceList returns the resources overridden by the given overrides.
+func mergeResourceList(resources, overrides corev1.ResourceList) corev1.ResourceList {
+	if len(overrides) == 0 {
+		return resources
+	}
+	merged := resources.DeepCopy()
+	if merged == nil {
+		merged = corev1.ResourceList{}
+	}
+	for name, quantity := range overrides {
+		merged[name] = quantity
+	}
+	return merged
+}
@@ -0,0 +1,287 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package placementrule
+
+import (
+	"context"
+	"testing"
+
+	obshared "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/shared"
+	mcov1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newOverridesMCO() *mcov1beta2.MultiClusterObservability {
+	return &mcov1beta2.MultiClusterObservability{
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			ObservabilityAddonSpec: &obshared.ObservabilityAddonSpec{
+				EnableMetrics:        true,
+				Interval:             300,
+				ScrapeSizeLimitBytes: 1073741824,
+				Workers:              1,
+			},
+		},
+	}
+}
+
+func newOverridesConfig(variables []addonv1beta1.CustomizedVariable,
+	resources []addonv1beta1.ContainerResourceRequirements,
+) *addonv1beta1.AddOnDeploymentConfig {
+	return &addonv1beta1.AddOnDeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "large-clusters", Namespace: "open-cluster-management-observability"},
+		Spec: addonv1beta1.AddOnDeploymentConfigSpec{
+			CustomizedVariables:  variables,
+			ResourceRequirements: resources,
+		},
+	}
+}
+
+func TestDesiredObservabilityAddonSpec(t *testing.T) {
+	mco := newOverridesMCO()
+
+	t.Run("no config", func(t *testing.T) {
+		spec, overrides := desiredObservabilityAddonSpec(mco, nil)
+		assert.EqualValues(t, 300, spec.Interval)
+		assert.EqualValues(t, 1, spec.Workers)
+		assert.NotNil(t, spec.Resources)
+		assert.Empty(t, overrides)
+	})
+
+	t.Run("no addon spec", func(t *testing.T) {
+		spec, overrides := desiredObservabilityAddonSpec(&mcov1beta2.MultiClusterObservability{}, newOverridesConfig(
+			[]addonv1beta1.CustomizedVariable{{Name: addonVariableInterval, Value: "60"}}, nil))
+		assert.Nil(t, spec)
+		assert.Empty(t, overrides)
+	})
+
+	t.Run("variables", func(t *testing.T) {
+		spec, overrides := desiredObservabilityAddonSpec(mco, newOverridesConfig([]addonv1beta1.CustomizedVariable{
+			{Name: addonVariableInterval, Value: "60"},
+			{Name: addonVariableWorkers, Value: "4"},
+			{Name: addonVariableScrapeSizeLimitBytes, Value: "2147483648"},
+			{Name: "unrelated", Value: "value"},
+		}, nil))
+		assert.EqualValues(t, 60, spec.Interval)
+		assert.EqualValues(t, 4, spec.Workers)
+		assert.Equal(t, 2147483648, spec.ScrapeSizeLimitBytes)
+		assert.True(t, spec.EnableMetrics)
+		assert.Equal(t, "open-cluster-management-observability/large-clusters: "+
+			"metricsInterval=60,metricsWorkers=4,metricsScrapeSizeLimitBytes=2147483648", overrides)
+		// The MCO spec is left untouched.
+		assert.EqualValues(t, 300, mco.Spec.ObservabilityAddonSpec.Interval)
+	})
+
+	t.Run("invalid variables are ignored", func(t *testing.T) {
+		spec, overrides := desiredObservabilityAddonSpec(mco, newOverridesConfig([]addonv1beta1.CustomizedVariable{
+			{Name: addonVariableInterval, Value: "5"},
+			{Name: addonVariableWorkers, Value: "many"},
+			{Name: addonVariableScrapeSizeLimitBytes, Value: "0"},
+		}, nil))
+		assert.EqualValues(t, 300, spec.Interval)
+		assert.EqualValues(t, 1, spec.Workers)
+		assert.Equal(t, 1073741824, spec.ScrapeSizeLimitBytes)
+		assert.Empty(t, overrides)
+	})
+
+	t.Run("resources", func(t *testing.T) {
+		spec, overrides := desiredObservabilityAddonSpec(mco, newOverridesConfig(nil,
+			[]addonv1beta1.ContainerResourceRequirements{
+				{
+					ContainerID: "deployments:other:other",
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+					},
+				},
+				{
+					ContainerID: "*:*:*",
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+					},
+				},
+				{
+					ContainerID: "deployments:metrics-collector-deployment:metrics-collector",
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+					},
+				},
+			}))
+		memory := spec.Resources.Limits[corev1.ResourceMemory]
+		assert.Equal(t, "1Gi", memory.String())
+		// The requests of the MCO are kept.
+		assert.NotEmpty(t, spec.Resources.Requests)
+		assert.Equal(t, "open-cluster-management-observability/large-clusters: resources", overrides)
+	})
+}
+
+func TestContainerIDMatches(t *testing.T) {
+	assert.True(t, containerIDMatches(metricsCollectorContainerID, metricsCollectorContainerID))
+	assert.True(t, containerIDMatches("*:*:*", metricsCollectorContainerID))
+	assert.True(t, containerIDMatches("deployments:*:metrics-collector", metricsCollectorContainerID))
+	assert.False(t, containerIDMatches("daemonsets:*:*", metricsCollectorContainerID))
+	assert.False(t, containerIDMatches("deployments:metrics-collector-deployment", metricsCollectorContainerID))
+}
+
+func TestMetricsBufferRetention(t *testing.T) {
+	assert.Empty(t, metricsBufferRetention(nil))
+	assert.Empty(t, metricsBufferRetention(newOverridesConfig(nil, nil)))
+	assert.Equal(t, "24h", metricsBufferRetention(newOverridesConfig([]addonv1beta1.CustomizedVariable{
+		{Name: addonVariableBufferRetention, Value: "24h"},
+	}, nil)))
+	// The invalid retentions are ignored.
+	assert.Equal(t, "2d", metricsBufferRetention(newOverridesConfig([]addonv1beta1.CustomizedVariable{
+		{Name: addonVariableBufferRetention, Value: "2d"},
+		{Name: addonVariableBufferRetention, Value: "1 day"},
+		{Name: addonVariableBufferRetention, Value: "0s"},
+	}, nil)))
+}
+
+func TestCmoConfigDryRun(t *testing.T) {
+	assert.False(t, cmoConfigDryRun(nil))
+	assert.False(t, cmoConfigDryRun(newOverridesConfig(nil, nil)))
+	assert.True(t, cmoConfigDryRun(newOverridesConfig([]addonv1beta1.CustomizedVariable{
+		{Name: addonVariableCmoConfigDryRun, Value: "true"},
+	}, nil)))
+	// The invalid booleans are ignored.
+	assert.True(t, cmoConfigDryRun(newOverridesConfig([]addonv1beta1.CustomizedVariable{
+		{Name: addonVariableCmoConfigDryRun, Value: "true"},
+		{Name: addonVariableCmoConfigDryRun, Value: "yes"},
+	}, nil)))
+}
+
+func TestCreateObsAddonWithOverrides(t *testing.T) {
+	initSchema(t)
+
+	c := fake.NewClientBuilder().Build()
+	mco := newOverridesMCO()
+	addonConfig := newOverridesConfig([]addonv1beta1.CustomizedVariable{{Name: addonVariableInterval, Value: "60"}}, nil)
+	require.NoError(t, createObsAddon(mco, c, namespace, addonConfig))
+
+	found := &mcov1beta1.ObservabilityAddon{}
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: obsAddonName, Namespace: namespace}, found))
+	assert.EqualValues(t, 60, found.Spec.Interval)
+	assert.Equal(t, "open-cluster-management-observability/large-clusters: metricsInterval=60",
+		found.Annotations[addonOverridesAnnotation])
+
+	// The spoke addon gets the overridden spec.
+	addon, err := getObservabilityAddon(c, namespace, mco, addonConfig)
+	require.NoError(t, err)
+	assert.EqualValues(t, 60, addon.Spec.Interval)
+
+	// Removing the variable restores the MCO values and removes the annotation.
+	require.NoError(t, createObsAddon(mco, c, namespace, newOverridesConfig(nil, nil)))
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: obsAddonName, Namespace: namespace}, found))
+	assert.EqualValues(t, 300, found.Spec.Interval)
+	assert.NotContains(t, found.Annotations, addonOverridesAnnotation)
+
+	// The addons overridden by the user are left untouched.
+	found.Annotations[addonSourceAnnotation] = addonSourceOverride
+	require.NoError(t, c.Update(context.Background(), found))
+	require.NoError(t, createObsAddon(mco, c, namespace, addonConfig))
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: obsAddonName, Namespace: namespace}, found))
+	assert.EqualValues(t, 300, found.Spec.Interval)
+	addon, err = getObservabilityAddon(c, namespace, mco, addonConfig)
+	require.NoError(t, err)
+	assert.EqualValues(t, 300, addon.Spec.Interval)
+}
+
+func TestGetManagedClusterAddonConfig(t *testing.T) {
+	initSchema(t)
+
+	specConfig := newOverridesConfig(nil, nil)
+	placementConfig := newOverridesConfig(nil, nil)
+	placementConfig.Name = "cluster-set-config"
+	c := fake.NewClientBuilder().WithRuntimeObjects(specConfig, placementConfig).Build()
+
+	addon := &addonv1beta1.ManagedClusterAddOn{ObjectMeta: metav1.ObjectMeta{Name: "observability-controller", Namespace: namespace}}
+	found, err := getManagedClusterAddonConfig(context.Background(), c, addon)
+	require.NoError(t, err)
+	assert.Same(t, defaultAddonDeploymentConfig, found)
+
+	// The configs resolved from the placements of the ClusterManagementAddOn are reported in the status.
+	addon.Status.ConfigReferences = []addonv1beta1.ConfigReference{{
+		ConfigGroupResource: addonv1beta1.ConfigGroupResource{Group: "addon.open-cluster-management.io", Resource: "addondeploymentconfigs"},
+		DesiredConfig: &addonv1beta1.ConfigSpecHash{
+			ConfigReferent: addonv1beta1.ConfigReferent{Name: placementConfig.Name, Namespace: placementConfig.Namespace},
+		},
+	}}
+	found, err = getManagedClusterAddonConfig(context.Background(), c, addon)
+	require.NoError(t, err)
+	assert.Equal(t, placementConfig.Name, found.Name)
+
+	// The config referenced by the addon takes precedence.
+	addon.Spec.Configs = []addonv1beta1.AddOnConfig{{
+		ConfigGroupResource: addonv1beta1.ConfigGroupResource{Group: "addon.open-cluster-management.io", Resource: "addondeploymentconfigs"},
+		ConfigReferent:      addonv1beta1.ConfigReferent{Name: specConfig.Name, Namespace: specConfig.Namespace},
+	}}
+	found, err = getManagedClusterAddonConfig(context.Background(), c, addon)
+	require.NoError(t, err)
+	assert.Equal(t, specConfig.Name, found.Name)
+
+	// The configs of other resources and the status references not resolved yet are ignored.
+	addon.Spec.Configs = []addonv1beta1.AddOnConfig{{
+		ConfigGroupResource: addonv1beta1.ConfigGroupResource{Group: "addon.open-cluster-management.io", Resource: "other"},
+		ConfigReferent:      addonv1beta1.ConfigReferent{Name: specConfig.Name, Namespace: specConfig.Namespace},
+	}}
+	addon.Status.ConfigReferences[0].DesiredConfig = nil
+	found, err = getManagedClusterAddonConfig(context.Background(), c, addon)
+	require.NoError(t, err)
+	assert.Same(t, defaultAddonDeploymentConfig, found)
+}
+
+func TestCreateManagedClusterResWithOverrides(t *testing.T) {
+	initSchema(t)
+
+	mco := newOverridesMCO()
+	addonConfig := newOverridesConfig([]addonv1beta1.CustomizedVariable{{Name: addonVariableInterval, Value: "60"}}, nil)
+	addon := &addonv1beta1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{Name: config.ManagedClusterAddonName, Namespace: namespace},
+		Spec: addonv1beta1.ManagedClusterAddOnSpec{Configs: []addonv1beta1.AddOnConfig{{
+			ConfigGroupResource: addonv1beta1.ConfigGroupResource{Group: "addon.open-cluster-management.io", Resource: "addondeploymentconfigs"},
+			ConfigReferent:      addonv1beta1.ConfigReferent{Name: addonConfig.Name, Namespace: addonConfig.Namespace},
+		}}},
+		Status: addonv1beta1.ManagedClusterAddOnStatus{Conditions: []metav1.Condition{{
+			Type: "Available", Status: metav1.ConditionTrue, Reason: "ManagedClusterAddOnLeaseUpdated",
+		}}},
+	}
+	c := fake.NewClientBuilder().WithRuntimeObjects(addon, addonConfig).WithStatusSubresource(addon).Build()
+
+	found, err := createManagedClusterRes(context.Background(), c, mco, namespace, namespace)
+	require.NoError(t, err)
+	assert.Equal(t, addonConfig.Name, found.Name)
+
+	// The effective settings are reported on the ManagedClusterAddOn.
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: addon.Name, Namespace: namespace}, addon))
+	condition := meta.FindStatusCondition(addon.Status.Conditions, addonSettingsConditionType)
+	require.NotNil(t, condition)
+	assert.Equal(t, reasonSettingsFromAddonConfig, condition.Reason)
+	assert.True(t, meta.IsStatusConditionTrue(addon.Status.Conditions, "Available"))
+	assert.Equal(t, "The metrics collector uses interval=60s, workers=1, scrapeSizeLimitBytes=1073741824, "+
+		"requests.cpu=10m, requests.memory=100Mi from the MultiClusterObservability overridden by "+
+		"open-cluster-management-observability/large-clusters: metricsInterval=60.", condition.Message)
+
+	// The settings of the ObservabilityAddons overridden by the user are reported as such.
+	obsAddon := &mcov1beta1.ObservabilityAddon{}
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: obsAddonName, Namespace: namespace}, obsAddon))
+	obsAddon.Annotations[addonSourceAnnotation] = addonSourceOverride
+	require.NoError(t, c.Update(context.Background(), obsAddon))
+	_, err = createManagedClusterRes(context.Background(), c, mco, namespace, namespace)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: addon.Name, Namespace: namespace}, addon))
+	condition = meta.FindStatusCondition(addon.Status.Conditions, addonSettingsConditionType)
+	require.NotNil(t, condition)
+	assert.Equal(t, reasonSettingsFromAddonOverride, condition.Reason)
+	assert.Contains(t, condition.Message, "interval=60s")
+	assert.Contains(t, condition.Message, "from the ObservabilityAddon.")
+}
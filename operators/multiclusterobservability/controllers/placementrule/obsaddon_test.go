@@ -36,7 +36,7 @@ func TestObsAddonCR(t *testing.T) {
 		).
 		Build()
 
-	err := createObsAddon(&mcov1beta2.MultiClusterObservability{}, c, namespace)
+	err := createObsAddon(&mcov1beta2.MultiClusterObservability{}, c, namespace, nil)
 	if err != nil {
 		t.Fatalf("Failed to create observabilityaddon: (%v)", err)
 	}
@@ -46,7 +46,7 @@ func TestObsAddonCR(t *testing.T) {
 		t.Fatalf("Failed to get observabilityaddon: (%v)", err)
 	}
 
-	err = createObsAddon(&mcov1beta2.MultiClusterObservability{}, c, namespace)
+	err = createObsAddon(&mcov1beta2.MultiClusterObservability{}, c, namespace, nil)
 	if err != nil {
 		t.Fatalf("Failed to create observabilityaddon: (%v)", err)
 	}
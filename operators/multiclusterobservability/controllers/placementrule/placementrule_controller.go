@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -35,7 +36,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/util/retry"
 	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	workv1 "open-cluster-management.io/api/work/v1"
@@ -144,6 +144,7 @@ func (r *PlacementRuleReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
+	result := ctrl.Result{}
 	// Clean spokes addon resources (except the hub collector) if metrics are disabled.
 	metricsAreDisabled := mco.Spec.ObservabilityAddonSpec != nil && !mco.Spec.ObservabilityAddonSpec.EnableMetrics
 	if mcoIsNotFound || metricsAreDisabled || mcoaForMetricsIsEnabled(mco) {
@@ -188,16 +189,18 @@ func (r *PlacementRuleReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			return ctrl.Result{}, fmt.Errorf("failed to list observabilityaddon resource: %w", err)
 		}
 
-		if err := createAllRelatedRes(
+		rolloutRequeueAfter, err := createAllRelatedRes(
 			ctx,
 			r.Client,
 			req,
 			mco,
 			r.CRDMap,
 			r.KubeClient,
-		); err != nil {
+		)
+		if err != nil {
 			return ctrl.Result{}, fmt.Errorf("failed to create all related resources: %w", err)
 		}
+		result.RequeueAfter = rolloutRequeueAfter
 	}
 
 	// This cleanup must be kept at the end of the reconcile as createAllRelatedRes can remove some observabilityAddon
@@ -208,7 +211,7 @@ func (r *PlacementRuleReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
 	}
 
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 // updateStatus ensures that the addonStatuses are updated at least once on the first reconcile
@@ -314,7 +317,7 @@ func (r *PlacementRuleReconciler) cleanOrphanResources(ctx context.Context, req
 
 	namespacesWithResources := map[string]struct{}{}
 	for _, work := range workList.Items {
-		if work.Name != work.Namespace+workNameSuffix {
+		if !isClusterWorkName(work.Namespace, work.Name) {
 			log.Info("Deleting ManifestWork with invalid name", "namespace", work.Namespace, "name", work.Name)
 			if err := deleteManifestWork(r.Client, work.Name, work.Namespace); err != nil {
 				return false, fmt.Errorf("failed to delete invalid ManifestWork: %w", err)
@@ -519,6 +522,8 @@ func generateImageListConfigMap(mco *mcov1beta2.MultiClusterObservability) (*cor
 	return imageListCm, nil
 }
 
+// createAllRelatedRes creates the resources of all the managed clusters. With an addon rollout strategy, it returns
+// when to reconcile again to advance the rollout.
 func createAllRelatedRes(
 	ctx context.Context,
 	c client.Client,
@@ -526,24 +531,24 @@ func createAllRelatedRes(
 	mco *mcov1beta2.MultiClusterObservability,
 	crdMap map[string]bool,
 	kubeClient kubernetes.Interface,
-) error {
+) (time.Duration, error) {
 	var err error
 	// create the clusterrole if not there
 	if err := createReadMCOClusterRole(ctx, c); err != nil {
-		return fmt.Errorf("failed to ensure cluster rule: %w", err)
+		return 0, fmt.Errorf("failed to ensure cluster rule: %w", err)
 	}
 	if err := createResourceRole(ctx, c); err != nil {
-		return fmt.Errorf("failed to ensure resource role: %w", err)
+		return 0, fmt.Errorf("failed to ensure resource role: %w", err)
 	}
 
 	// Get or create ClusterManagementAddon
 	clusterAddon, err = util.CreateClusterManagementAddon(ctx, c)
 	if err != nil {
-		return fmt.Errorf("failed to ensure ClusterManagementAddon: %w", err)
+		return 0, fmt.Errorf("failed to ensure ClusterManagementAddon: %w", err)
 	}
 
 	if err := setDefaultDeploymentConfigVar(ctx, c); err != nil {
-		return fmt.Errorf("failed to set default deployment config: %w", err)
+		return 0, fmt.Errorf("failed to set default deployment config: %w", err)
 	}
 
 	// need to reload the template and update the the corresponding resources
@@ -551,25 +556,34 @@ func createAllRelatedRes(
 	rawExtensionList, obsAddonCRDv1, obsAddonCRDv1beta1,
 		endpointMetricsOperatorDeploy, imageListConfigMap, err = loadTemplates(mco)
 	if err != nil {
-		return fmt.Errorf("failed to load templates: %w", err)
+		return 0, fmt.Errorf("failed to load templates: %w", err)
 	}
 
 	works, crdv1Work, err := generateGlobalManifestResources(ctx, c, mco, kubeClient)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	// regenerate the hubinfo secret if empty
 	if hubInfoSecret == nil {
 		var err error
 		if hubInfoSecret, err = generateHubInfoSecret(c, config.GetDefaultNamespace(), spokeNameSpace, crdMap, config.IsUWMAlertingDisabledInSpec(mco)); err != nil {
-			return fmt.Errorf("failed to generate hub info secret: %w", err)
+			return 0, fmt.Errorf("failed to generate hub info secret: %w", err)
 		}
 	}
 
 	managedClusterList, err := getManagedClustersList(ctx, c)
 	if err != nil {
-		return fmt.Errorf("failed to get managed clusters list: %w", err)
+		return 0, fmt.Errorf("failed to get managed clusters list: %w", err)
+	}
+
+	revision, err := addonRolloutRevision(endpointMetricsOperatorDeploy, metricsAllowlistConfigMap, imageListConfigMap)
+	if err != nil {
+		return 0, err
+	}
+	rollout, requeueAfter, err := syncAddonRollout(ctx, c, mco, revision, managedClusterList, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to sync the addon rollout: %w", err)
 	}
 
 	var allErrors []error
@@ -577,7 +591,9 @@ func createAllRelatedRes(
 		managedCluster := mci.Name
 		openshiftVersion := mci.OpenshiftVersion
 
-		if managedClustersHaveReconciledOnce && !isReconcileRequired(request, managedCluster) {
+		// The clusters of a new batch of the rollout are updated whatever triggered the reconcile.
+		if managedClustersHaveReconciledOnce && !isReconcileRequired(request, managedCluster) &&
+			(rollout == nil || !rollout.batch[managedCluster]) {
 			continue
 		}
 
@@ -623,6 +639,11 @@ func createAllRelatedRes(
 			log.Error(err, "Failed to create manifestworks")
 			continue
 		}
+		if err := rollout.stage(ctx, c, manifestWork); err != nil {
+			allErrors = append(allErrors, fmt.Errorf("failed to stage the manifestwork: %w", err))
+			log.Error(err, "Failed to stage the manifestwork")
+			continue
+		}
 
 		if managedCluster != namespace && os.Getenv("UNIT_TEST") != "true" {
 			// ACM 8509: Special case for hub/local cluster metrics collection
@@ -634,12 +655,16 @@ func createAllRelatedRes(
 				continue
 			}
 		} else {
-			retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-				return createManifestwork(ctx, c, manifestWork)
-			})
-			if retryErr != nil {
-				allErrors = append(allErrors, fmt.Errorf("failed to create manifestwork: %w", retryErr))
-				log.Error(retryErr, "Failed to create manifestwork")
+			// The manifestworks nearing the size limit are split so that the cluster keeps receiving the updates
+			manifestWorks, err := splitManifestWork(manifestWork)
+			if err != nil {
+				allErrors = append(allErrors, fmt.Errorf("failed to split the manifestwork: %w", err))
+				log.Error(err, "Failed to split the manifestwork")
+				continue
+			}
+			if err := createManifestWorkSet(ctx, c, manifestWorks); err != nil {
+				allErrors = append(allErrors, fmt.Errorf("failed to create manifestwork: %w", err))
+				log.Error(err, "Failed to create manifestwork")
 				continue
 			}
 		}
@@ -650,10 +675,10 @@ func createAllRelatedRes(
 	}
 
 	if len(allErrors) > 0 {
-		return errors.Join(allErrors...)
+		return 0, errors.Join(allErrors...)
 	}
 
-	return nil
+	return requeueAfter, nil
 }
 
 func setDefaultDeploymentConfigVar(ctx context.Context, c client.Client) error {
@@ -731,14 +756,11 @@ func deleteGlobalResource(ctx context.Context, c client.Client) error {
 }
 
 // createManagedClusterRes creates:
-// - the observability addon in the namespace
 // - the role bindings for system groups
 // - the managedClusterAddon named "observability-controller"
+// - the observability addon in the namespace, with the overrides of the AddOnDeploymentConfig of the cluster
+// - the condition reporting the effective settings of the observability addon on the managedClusterAddon
 func createManagedClusterRes(ctx context.Context, c client.Client, mco *mcov1beta2.MultiClusterObservability, name string, namespace string) (*addonv1beta1.AddOnDeploymentConfig, error) {
-	if err := createObsAddon(mco, c, namespace); err != nil {
-		return nil, fmt.Errorf("failed to create observabilityaddon: %w", err)
-	}
-
 	if err := createRolebindings(c, namespace, name); err != nil {
 		return nil, fmt.Errorf("failed to create role bindings: %w", err)
 	}
@@ -748,30 +770,53 @@ func createManagedClusterRes(ctx context.Context, c client.Client, mco *mcov1bet
 		return nil, fmt.Errorf("failed to create ManagedClusterAddon: %w", err)
 	}
 
-	addonConfig := &addonv1beta1.AddOnDeploymentConfig{}
-	isCustomConfig := false
+	addonConfig, err := getManagedClusterAddonConfig(ctx, c, addon)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := createObsAddon(mco, c, namespace, addonConfig); err != nil {
+		return nil, fmt.Errorf("failed to create observabilityaddon: %w", err)
+	}
+
+	if err := updateAddonSettingsCondition(ctx, c, addon); err != nil {
+		return nil, err
+	}
+
+	return addonConfig, nil
+}
+
+// getManagedClusterAddonConfig returns the AddOnDeploymentConfig of the managedClusterAddon: the config referenced
+// in its spec, else the config the addon manager resolved from the placements of the ClusterManagementAddOn,
+// else the default config.
+func getManagedClusterAddonConfig(ctx context.Context, c client.Client, addon *addonv1beta1.ManagedClusterAddOn) (*addonv1beta1.AddOnDeploymentConfig, error) {
+	var ref *addonv1beta1.ConfigReferent
 	for _, config := range addon.Spec.Configs {
 		if config.Group == util.AddonGroup &&
 			config.Resource == util.AddonDeploymentConfigResource {
-			err = c.Get(ctx,
-				types.NamespacedName{
-					Name:      config.Name,
-					Namespace: config.Namespace,
-				},
-				addonConfig,
-			)
-			if err != nil {
-				return nil, err
-			}
-			isCustomConfig = true
-			log.Info("There is AddonDeploymentConfig for current addon", "namespace", namespace)
+			ref = &config.ConfigReferent
 			break
 		}
 	}
-	if !isCustomConfig {
-		addonConfig = defaultAddonDeploymentConfig
+	if ref == nil {
+		for _, config := range addon.Status.ConfigReferences {
+			if config.Group == util.AddonGroup &&
+				config.Resource == util.AddonDeploymentConfigResource &&
+				config.DesiredConfig != nil {
+				ref = &config.DesiredConfig.ConfigReferent
+				break
+			}
+		}
+	}
+	if ref == nil {
+		return defaultAddonDeploymentConfig, nil
 	}
 
+	addonConfig := &addonv1beta1.AddOnDeploymentConfig{}
+	if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, addonConfig); err != nil {
+		return nil, err
+	}
+	log.Info("There is AddonDeploymentConfig for current addon", "namespace", addon.Namespace)
 	return addonConfig, nil
 }
 
@@ -827,10 +872,24 @@ func areManagedClusterLabelsReady(obj client.Object) bool {
 	return true
 }
 
+// prometheusAgentVendors are the vendors of the non-OpenShift managed clusters using the prometheus-agent
+// metrics profile. The other non-OpenShift clusters keep the bundled Prometheus federated by the metrics collector.
+var prometheusAgentVendors = []string{"AKS", "EKS", "GKE"}
+
+// getMetricsProfile returns the metrics profile of the managed cluster chosen from its "vendor" label,
+// or an empty string for the default profile.
+func getMetricsProfile(cluster managedClusterInfo) string {
+	if cluster.OpenshiftVersion == nonOCP && slices.Contains(prometheusAgentVendors, cluster.Labels["vendor"]) {
+		return operatorconfig.PrometheusAgentProfile
+	}
+	return ""
+}
+
 type managedClusterInfo struct {
 	Name             string
 	OpenshiftVersion string
 	IsLocalCluster   bool
+	Labels           map[string]string
 }
 
 // getManagedClustersList returns the list of managed clusters info,
@@ -850,6 +909,7 @@ func getManagedClustersList(ctx context.Context, c client.Client) ([]managedClus
 				Name:             mc.GetName(),
 				OpenshiftVersion: "mimical",
 				IsLocalCluster:   true,
+				Labels:           mc.GetLabels(),
 			})
 			appended = true
 			continue
@@ -881,6 +941,7 @@ func getManagedClustersList(ctx context.Context, c client.Client) ([]managedClus
 			Name:             mc.GetName(),
 			OpenshiftVersion: openshiftVersion,
 			IsLocalCluster:   false,
+			Labels:           mc.GetLabels(),
 		})
 	}
 
@@ -1124,6 +1185,15 @@ func (r *PlacementRuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			}
 		}), builder.WithPredicates(getMCOPred(c, r.CRDMap))).
 
+		// secondary watch for the maintenance windows sent to the managed clusters
+		Watches(&mcov1beta2.ObservabilityMaintenanceWindow{}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			return []reconcile.Request{
+				{NamespacedName: types.NamespacedName{
+					Name: config.MaintenanceWindowsUpdateName,
+				}},
+			}
+		}), builder.WithPredicates(getMaintenanceWindowPred())).
+
 		// secondary watch for custom allowlist configmap
 		Watches(&corev1.ConfigMap{}, &handler.EnqueueRequestForObject{}, builder.WithPredicates(allowlistPred)).
 
@@ -1300,7 +1370,8 @@ func isReconcileRequired(request ctrl.Request, managedCluster string) bool {
 	if request.Name == config.MCOUpdatedRequestName ||
 		request.Name == config.MCHUpdatedRequestName ||
 		request.Name == config.ClusterManagementAddOnUpdateName ||
-		request.Name == config.AddonDeploymentConfigUpdateName {
+		request.Name == config.AddonDeploymentConfigUpdateName ||
+		request.Name == config.MaintenanceWindowsUpdateName {
 		return true
 	}
 	if request.Namespace == config.GetDefaultNamespace() ||
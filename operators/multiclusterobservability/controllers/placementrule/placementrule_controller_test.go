@@ -788,6 +788,48 @@ func TestAreManagedClusterLabelsReady(t *testing.T) {
 	}
 }
 
+func TestGetMetricsProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		cluster  managedClusterInfo
+		expected string
+	}{
+		{
+			name:     "OpenShift cluster",
+			cluster:  managedClusterInfo{OpenshiftVersion: "4.19.3", Labels: map[string]string{"vendor": "OpenShift"}},
+			expected: "",
+		},
+		{
+			name:     "EKS cluster",
+			cluster:  managedClusterInfo{OpenshiftVersion: nonOCP, Labels: map[string]string{"vendor": "EKS"}},
+			expected: operatorconfig.PrometheusAgentProfile,
+		},
+		{
+			name:     "GKE cluster",
+			cluster:  managedClusterInfo{OpenshiftVersion: nonOCP, Labels: map[string]string{"vendor": "GKE"}},
+			expected: operatorconfig.PrometheusAgentProfile,
+		},
+		{
+			name:     "other non-OpenShift cluster",
+			cluster:  managedClusterInfo{OpenshiftVersion: nonOCP, Labels: map[string]string{"vendor": "Other"}},
+			expected: "",
+		},
+		{
+			name:     "local cluster",
+			cluster:  managedClusterInfo{OpenshiftVersion: "mimical", IsLocalCluster: true},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getMetricsProfile(tt.cluster); got != tt.expected {
+				t.Errorf("getMetricsProfile() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
 // TestGetManagedClustersListSkipsNotReady tests that getManagedClustersList skips clusters
 // whose labels aren't ready, preventing premature classification as non-OCP
 func TestGetManagedClustersListSkipsNotReady(t *testing.T) {
@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"strings"
 
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
 	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 	appsv1 "k8s.io/api/apps/v1"
@@ -175,3 +176,17 @@ func getPred(name string, namespace string,
 		DeleteFunc: deleteFunc,
 	}
 }
+
+// getMaintenanceWindowPred selects the changes of the maintenance windows sent to the managed clusters: the changes of
+// the spec, and the start and the end of the windows, reported in the status.
+func getMaintenanceWindowPred() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldWindow := e.ObjectOld.(*mcov1beta2.ObservabilityMaintenanceWindow)
+			newWindow := e.ObjectNew.(*mcov1beta2.ObservabilityMaintenanceWindow)
+			return oldWindow.Generation != newWindow.Generation ||
+				!reflect.DeepEqual(oldWindow.Status.ActiveUntil, newWindow.Status.ActiveUntil) ||
+				!reflect.DeepEqual(oldWindow.Status.NextWindowStart, newWindow.Status.NextWindowStart)
+		},
+	}
+}
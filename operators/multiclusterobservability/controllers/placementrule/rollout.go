@@ -0,0 +1,399 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package placementrule
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	mcov1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	workv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// addonRolloutRevisionAnnotation records on the ManifestWork the revision of its staged manifests.
+	addonRolloutRevisionAnnotation = "observability.open-cluster-management.io/addon-rollout-revision"
+
+	defaultRolloutBatchSize     = 10
+	defaultRolloutPause         = 5 * time.Minute
+	defaultRolloutHealthTimeout = 30 * time.Minute
+	// rolloutHealthCheckPeriod is how often the health of the batch in progress is checked.
+	rolloutHealthCheckPeriod = 30 * time.Second
+)
+
+// addonRolloutRevision returns the hash of the manifests rolled out batch after batch: the endpoint operator
+// deployment, the metrics allowlist and the image list.
+func addonRolloutRevision(dep *appsv1.Deployment, allowlist, imageList *corev1.ConfigMap) (string, error) {
+	content, err := json.Marshal(struct {
+		Deployment appsv1.DeploymentSpec
+		Allowlist  map[string]string
+		Images     map[string]string
+	}{dep.Spec, allowlist.Data, imageList.Data})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the addon rollout revision: %w", err)
+	}
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])[:16], nil
+}
+
+// manifestKey identifies a manifest by its kind, namespace and name, whether it is decoded or raw.
+func manifestKey(m workv1.Manifest) (string, error) {
+	content, err := m.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	partial := &metav1.PartialObjectMetadata{}
+	if err := json.Unmarshal(content, partial); err != nil {
+		return "", err
+	}
+	return partial.Kind + "/" + partial.Namespace + "/" + partial.Name, nil
+}
+
+// isStagedManifest returns whether the manifest with the given key is rolled out batch after batch.
+func isStagedManifest(key string) bool {
+	kind, name, _ := strings.Cut(key, "/")
+	_, name, _ = strings.Cut(name, "/")
+	return kind == "Deployment" ||
+		(kind == "ConfigMap" && (name == operatorconfig.AllowlistConfigMapName || name == operatorconfig.ImageConfigMap))
+}
+
+// rolloutCluster is a managed cluster in the plan of the addon rollout.
+type rolloutCluster struct {
+	name   string
+	canary bool
+	// updated is true when the ManifestWork of the cluster has the revision being rolled out.
+	updated bool
+	healthy bool
+}
+
+// planAddonRollout returns the rollout status and the managed clusters of the next batch, when the last batch is
+// healthy and the pause between batches is over.
+func planAddonRollout(
+	strategy *mcov1beta2.AddonRolloutStrategy,
+	previous *mcov1beta2.AddonRolloutStatus,
+	revision string,
+	clusters []rolloutCluster,
+	now time.Time,
+) (*mcov1beta2.AddonRolloutStatus, []string, time.Duration, error) {
+	batchSize := int(strategy.BatchSize)
+	if batchSize <= 0 {
+		batchSize = defaultRolloutBatchSize
+	}
+	pause, err := parseRolloutDuration(strategy.PauseBetweenBatches, defaultRolloutPause)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("invalid pauseBetweenBatches: %w", err)
+	}
+	healthTimeout, err := parseRolloutDuration(strategy.HealthTimeout, defaultRolloutHealthTimeout)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("invalid healthTimeout: %w", err)
+	}
+
+	status := &mcov1beta2.AddonRolloutStatus{Revision: revision}
+	if previous != nil && previous.Revision == revision {
+		status.BatchStartTime = previous.BatchStartTime
+		status.BatchHealthyTime = previous.BatchHealthyTime
+	}
+
+	// The canary clusters are updated first, then the other clusters in the order of their names.
+	slices.SortFunc(clusters, func(a, b rolloutCluster) int {
+		if a.canary != b.canary {
+			if a.canary {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.name, b.name)
+	})
+	var pending []rolloutCluster
+	for _, cluster := range clusters {
+		switch {
+		case !cluster.updated:
+			pending = append(pending, cluster)
+		case !cluster.healthy:
+			status.UnhealthyClusters = append(status.UnhealthyClusters, cluster.name)
+		}
+	}
+	status.TotalClusters = int32(len(clusters))                  // #nosec G115 -- the number of managed clusters fits in an int32.
+	status.UpdatedClusters = int32(len(clusters) - len(pending)) // #nosec G115 -- the number of managed clusters fits in an int32.
+
+	if len(pending) == 0 {
+		status.Phase = mcov1beta2.AddonRolloutCompleted
+		status.Message = fmt.Sprintf("All the %d managed clusters are updated", len(clusters))
+		return status, nil, 0, nil
+	}
+
+	if len(status.UnhealthyClusters) > int(strategy.MaxUnhealthyClusters) {
+		status.BatchHealthyTime = nil
+		if status.BatchStartTime != nil && !now.Before(status.BatchStartTime.Add(healthTimeout)) {
+			status.Phase = mcov1beta2.AddonRolloutHalted
+			status.Message = fmt.Sprintf("The rollout is halted, as %d updated managed clusters are still unhealthy after %s",
+				len(status.UnhealthyClusters), healthTimeout)
+			return status, nil, rolloutHealthCheckPeriod, nil
+		}
+		status.Phase = mcov1beta2.AddonRolloutProgressing
+		status.Message = fmt.Sprintf("Waiting for %d updated managed clusters to become healthy", len(status.UnhealthyClusters))
+		requeueAfter := rolloutHealthCheckPeriod
+		if status.BatchStartTime != nil {
+			requeueAfter = min(requeueAfter, status.BatchStartTime.Add(healthTimeout).Sub(now))
+		}
+		return status, nil, requeueAfter, nil
+	}
+
+	// The pause starts once the last batch is healthy. There is no pause before the first batch.
+	if status.BatchStartTime != nil {
+		if status.BatchHealthyTime == nil {
+			healthyTime := metav1.NewTime(now)
+			status.BatchHealthyTime = &healthyTime
+		}
+		if resumeTime := status.BatchHealthyTime.Add(pause); now.Before(resumeTime) {
+			status.Phase = mcov1beta2.AddonRolloutPaused
+			status.Message = "The next batch is updated at " + resumeTime.UTC().Format(time.RFC3339)
+			return status, nil, resumeTime.Sub(now), nil
+		}
+	}
+
+	var batch []string
+	for _, cluster := range pending {
+		if pending[0].canary && !cluster.canary {
+			break
+		}
+		if !pending[0].canary && len(batch) == batchSize {
+			break
+		}
+		batch = append(batch, cluster.name)
+	}
+	startTime := metav1.NewTime(now)
+	status.BatchStartTime = &startTime
+	status.BatchHealthyTime = nil
+	status.Phase = mcov1beta2.AddonRolloutProgressing
+	status.Message = fmt.Sprintf("Updating a batch of %d managed clusters", len(batch))
+	return status, batch, rolloutHealthCheckPeriod, nil
+}
+
+func parseRolloutDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := model.ParseDuration(value)
+	return time.Duration(d), err
+}
+
+// addonRollout holds back the staged manifests of the managed clusters which are not updated yet.
+type addonRollout struct {
+	revision string
+	// allowed are the managed clusters receiving the revision: the updated clusters, the clusters of the
+	// batch started by this reconcile, and the new clusters.
+	allowed map[string]bool
+	// batch are the managed clusters of the batch started by this reconcile.
+	batch map[string]bool
+}
+
+// syncAddonRollout plans the rollout of the revision to the managed clusters and records its progress in the
+// MCO status. It returns a nil rollout when the MCO doesn't define a rollout strategy.
+func syncAddonRollout(
+	ctx context.Context,
+	c client.Client,
+	mco *mcov1beta2.MultiClusterObservability,
+	revision string,
+	clusters []managedClusterInfo,
+	now time.Time,
+) (*addonRollout, time.Duration, error) {
+	strategy := mco.Spec.AddonRollout
+	if strategy == nil {
+		return nil, 0, patchAddonRolloutStatus(ctx, c, mco, nil)
+	}
+
+	works := &workv1.ManifestWorkList{}
+	if err := c.List(ctx, works, client.MatchingLabels{ownerLabelKey: ownerLabelValue}); err != nil {
+		return nil, 0, fmt.Errorf("failed to list the manifestworks: %w", err)
+	}
+	workByNamespace := map[string]*workv1.ManifestWork{}
+	for i := range works.Items {
+		if works.Items[i].Name == works.Items[i].Namespace+workNameSuffix {
+			workByNamespace[works.Items[i].Namespace] = &works.Items[i]
+		}
+	}
+	addons := &mcov1beta1.ObservabilityAddonList{}
+	if err := c.List(ctx, addons); err != nil {
+		return nil, 0, fmt.Errorf("failed to list the observabilityaddons: %w", err)
+	}
+	addonByNamespace := map[string]*mcov1beta1.ObservabilityAddon{}
+	for i := range addons.Items {
+		if addons.Items[i].Name == obsAddonName {
+			addonByNamespace[addons.Items[i].Namespace] = &addons.Items[i]
+		}
+	}
+	canarySelector := labels.Nothing()
+	if strategy.CanaryClusterSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(strategy.CanaryClusterSelector)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid canaryClusterSelector: %w", err)
+		}
+		canarySelector = selector
+	}
+
+	rollout := &addonRollout{revision: revision, allowed: map[string]bool{}, batch: map[string]bool{}}
+	var rolloutClusters []rolloutCluster
+	for _, cluster := range clusters {
+		work := workByNamespace[cluster.Name]
+		// The hub metrics collection is not deployed with a ManifestWork, and the new clusters have nothing to
+		// break, so they are not staged.
+		if cluster.IsLocalCluster || work == nil {
+			rollout.allowed[cluster.Name] = true
+			continue
+		}
+		updated := work.Annotations[addonRolloutRevisionAnnotation] == revision
+		rollout.allowed[cluster.Name] = updated
+		rolloutClusters = append(rolloutClusters, rolloutCluster{
+			name:    cluster.Name,
+			canary:  canarySelector.Matches(labels.Set(cluster.Labels)),
+			updated: updated,
+			healthy: isAddonHealthy(work, addonByNamespace[cluster.Name]),
+		})
+	}
+
+	status, batch, requeueAfter, err := planAddonRollout(strategy, mco.Status.AddonRollout, revision, rolloutClusters, now)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, cluster := range batch {
+		rollout.allowed[cluster] = true
+		rollout.batch[cluster] = true
+	}
+	if len(batch) > 0 {
+		log.Info("Rolling out the addon to a new batch of managed clusters", "revision", revision, "clusters", batch)
+	}
+	return rollout, requeueAfter, patchAddonRolloutStatus(ctx, c, mco, status)
+}
+
+// isAddonHealthy returns whether the ManifestWork of a managed cluster is applied and its ObservabilityAddon is
+// available and not degraded.
+func isAddonHealthy(work *workv1.ManifestWork, addon *mcov1beta1.ObservabilityAddon) bool {
+	available := meta.FindStatusCondition(work.Status.Conditions, workv1.WorkAvailable)
+	if available == nil || available.Status != metav1.ConditionTrue || available.ObservedGeneration != work.Generation {
+		return false
+	}
+	if addon == nil {
+		return false
+	}
+	conditions := convertConditionsToMeta(addon.Status.Conditions)
+	return meta.IsStatusConditionTrue(conditions, "Available") && !meta.IsStatusConditionTrue(conditions, "Degraded")
+}
+
+func patchAddonRolloutStatus(ctx context.Context, c client.Client, mco *mcov1beta2.MultiClusterObservability,
+	status *mcov1beta2.AddonRolloutStatus,
+) error {
+	if equality.Semantic.DeepEqual(mco.Status.AddonRollout, status) {
+		return nil
+	}
+	orig := mco.DeepCopy()
+	mco.Status.AddonRollout = status
+	if err := c.Status().Patch(ctx, mco, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})); err != nil {
+		return fmt.Errorf("failed to update the addon rollout status: %w", err)
+	}
+	return nil
+}
+
+// stage sets the revision of the ManifestWork of an allowed managed cluster. For the other clusters, it keeps the
+// staged manifests of their current ManifestWork, unless they are already up to date.
+func (r *addonRollout) stage(ctx context.Context, c client.Client, work *workv1.ManifestWork) error {
+	if r == nil {
+		return nil
+	}
+	if work.Annotations == nil {
+		work.Annotations = map[string]string{}
+	}
+	if r.allowed[work.Namespace] {
+		work.Annotations[addonRolloutRevisionAnnotation] = r.revision
+		return nil
+	}
+
+	// The staged manifests held by the cluster may have been moved to the split manifestworks.
+	works, err := listClusterManifestWorks(ctx, c, work.Namespace)
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(works, func(w workv1.ManifestWork) bool { return w.Name == work.Name })
+	if idx < 0 {
+		work.Annotations[addonRolloutRevisionAnnotation] = r.revision
+		return nil
+	}
+	found := &works[idx]
+	foundManifests := []workv1.Manifest{}
+	for i := range works {
+		foundManifests = append(foundManifests, works[i].Spec.Workload.Manifests...)
+	}
+	foundStaged := map[string]workv1.Manifest{}
+	for _, m := range foundManifests {
+		key, err := manifestKey(m)
+		if err != nil {
+			return fmt.Errorf("failed to decode a manifest of manifestwork %s/%s: %w", work.Namespace, work.Name, err)
+		}
+		if isStagedManifest(key) {
+			foundStaged[key] = m
+		}
+	}
+
+	upToDate := true
+	manifests := make([]workv1.Manifest, 0, len(work.Spec.Workload.Manifests))
+	for _, m := range work.Spec.Workload.Manifests {
+		key, err := manifestKey(m)
+		if err != nil {
+			return fmt.Errorf("failed to decode a manifest of manifestwork %s/%s: %w", work.Namespace, work.Name, err)
+		}
+		if !isStagedManifest(key) {
+			manifests = append(manifests, m)
+			continue
+		}
+		held, ok := foundStaged[key]
+		if !ok {
+			// The new manifests are deployed with their batch.
+			upToDate = false
+			continue
+		}
+		delete(foundStaged, key)
+		if !util.CompareObject(held.RawExtension, m.RawExtension) {
+			upToDate = false
+		}
+		manifests = append(manifests, held)
+	}
+	if upToDate && len(foundStaged) == 0 {
+		work.Annotations[addonRolloutRevisionAnnotation] = r.revision
+		return nil
+	}
+	// The manifests removed from the revision are kept until their batch.
+	for _, m := range foundManifests {
+		if key, _ := manifestKey(m); isStagedManifest(key) {
+			if _, removed := foundStaged[key]; removed {
+				manifests = append(manifests, m)
+			}
+		}
+	}
+	work.Spec.Workload.Manifests = manifests
+	if revision, ok := found.Annotations[addonRolloutRevisionAnnotation]; ok {
+		work.Annotations[addonRolloutRevisionAnnotation] = revision
+	} else {
+		delete(work.Annotations, addonRolloutRevisionAnnotation)
+	}
+	return nil
+}
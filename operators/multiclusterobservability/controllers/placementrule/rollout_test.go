@@ -0,0 +1,335 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package placementrule
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	mcov1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	workv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPlanAddonRollout(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *metav1.Time {
+		mt := metav1.NewTime(now.Add(-d))
+		return &mt
+	}
+	strategy := &mcov1beta2.AddonRolloutStrategy{BatchSize: 2, PauseBetweenBatches: "10m", HealthTimeout: "30m"}
+	clusters := func(updated, unhealthy int) []rolloutCluster {
+		ret := []rolloutCluster{
+			{name: "c1"}, {name: "c2"}, {name: "c3"}, {name: "canary", canary: true}, {name: "c4"},
+		}
+		// The canary cluster is sorted first.
+		order := []int{3, 0, 1, 2, 4}
+		for i := range updated {
+			ret[order[i]].updated = true
+			ret[order[i]].healthy = i >= unhealthy
+		}
+		return ret
+	}
+
+	testCases := map[string]struct {
+		strategy        *mcov1beta2.AddonRolloutStrategy
+		previous        *mcov1beta2.AddonRolloutStatus
+		clusters        []rolloutCluster
+		expectedPhase   mcov1beta2.AddonRolloutPhase
+		expectedBatch   []string
+		expectedRequeue time.Duration
+		expectedUpdated int32
+	}{
+		"new revision starts with the canary clusters": {
+			previous:        &mcov1beta2.AddonRolloutStatus{Revision: "old", BatchStartTime: ago(time.Hour)},
+			clusters:        clusters(0, 0),
+			expectedPhase:   mcov1beta2.AddonRolloutProgressing,
+			expectedBatch:   []string{"canary"},
+			expectedRequeue: rolloutHealthCheckPeriod,
+		},
+		"waiting for the batch to become healthy": {
+			previous:        &mcov1beta2.AddonRolloutStatus{Revision: "rev", BatchStartTime: ago(time.Minute)},
+			clusters:        clusters(1, 1),
+			expectedPhase:   mcov1beta2.AddonRolloutProgressing,
+			expectedRequeue: rolloutHealthCheckPeriod,
+			expectedUpdated: 1,
+		},
+		"halted after the health timeout": {
+			previous:        &mcov1beta2.AddonRolloutStatus{Revision: "rev", BatchStartTime: ago(31 * time.Minute)},
+			clusters:        clusters(1, 1),
+			expectedPhase:   mcov1beta2.AddonRolloutHalted,
+			expectedRequeue: rolloutHealthCheckPeriod,
+			expectedUpdated: 1,
+		},
+		"tolerated unhealthy clusters": {
+			strategy: &mcov1beta2.AddonRolloutStrategy{BatchSize: 2, MaxUnhealthyClusters: 1},
+			previous: &mcov1beta2.AddonRolloutStatus{
+				Revision: "rev", BatchStartTime: ago(time.Hour), BatchHealthyTime: ago(6 * time.Minute),
+			},
+			clusters:        clusters(1, 1),
+			expectedPhase:   mcov1beta2.AddonRolloutProgressing,
+			expectedBatch:   []string{"c1", "c2"},
+			expectedRequeue: rolloutHealthCheckPeriod,
+			expectedUpdated: 1,
+		},
+		"pause once the batch is healthy": {
+			previous:        &mcov1beta2.AddonRolloutStatus{Revision: "rev", BatchStartTime: ago(5 * time.Minute)},
+			clusters:        clusters(1, 0),
+			expectedPhase:   mcov1beta2.AddonRolloutPaused,
+			expectedRequeue: 10 * time.Minute,
+			expectedUpdated: 1,
+		},
+		"next batch after the pause": {
+			previous: &mcov1beta2.AddonRolloutStatus{
+				Revision: "rev", BatchStartTime: ago(20 * time.Minute), BatchHealthyTime: ago(10 * time.Minute),
+			},
+			clusters:        clusters(1, 0),
+			expectedPhase:   mcov1beta2.AddonRolloutProgressing,
+			expectedBatch:   []string{"c1", "c2"},
+			expectedRequeue: rolloutHealthCheckPeriod,
+			expectedUpdated: 1,
+		},
+		"last batch": {
+			previous: &mcov1beta2.AddonRolloutStatus{
+				Revision: "rev", BatchStartTime: ago(20 * time.Minute), BatchHealthyTime: ago(10 * time.Minute),
+			},
+			clusters:        clusters(4, 0),
+			expectedPhase:   mcov1beta2.AddonRolloutProgressing,
+			expectedBatch:   []string{"c4"},
+			expectedRequeue: rolloutHealthCheckPeriod,
+			expectedUpdated: 4,
+		},
+		"completed": {
+			previous:        &mcov1beta2.AddonRolloutStatus{Revision: "rev", BatchStartTime: ago(time.Minute)},
+			clusters:        clusters(5, 0),
+			expectedPhase:   mcov1beta2.AddonRolloutCompleted,
+			expectedUpdated: 5,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			s := strategy
+			if tc.strategy != nil {
+				s = tc.strategy
+			}
+			status, batch, requeueAfter, err := planAddonRollout(s, tc.previous, "rev", tc.clusters, now)
+			require.NoError(t, err)
+			assert.Equal(t, "rev", status.Revision)
+			assert.Equal(t, tc.expectedPhase, status.Phase, status.Message)
+			assert.Equal(t, tc.expectedBatch, batch)
+			assert.Equal(t, tc.expectedRequeue, requeueAfter)
+			assert.Equal(t, tc.expectedUpdated, status.UpdatedClusters)
+			assert.Equal(t, int32(5), status.TotalClusters)
+			if len(batch) > 0 {
+				assert.True(t, now.Equal(status.BatchStartTime.Time))
+				assert.Nil(t, status.BatchHealthyTime)
+			}
+		})
+	}
+
+	_, _, _, err := planAddonRollout(&mcov1beta2.AddonRolloutStrategy{PauseBetweenBatches: "soon"}, nil, "rev", nil, now)
+	assert.ErrorContains(t, err, "invalid pauseBetweenBatches")
+}
+
+func TestAddonRolloutStage(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, workv1.Install(s))
+
+	allowlist := func(data string) workv1.Manifest {
+		return workv1.Manifest{RawExtension: runtime.RawExtension{Object: &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: operatorconfig.AllowlistConfigMapName, Namespace: spokeNameSpace},
+			Data:       map[string]string{operatorconfig.MetricsConfigMapKey: data},
+		}}}
+	}
+	secret := func(data string) workv1.Manifest {
+		return workv1.Manifest{RawExtension: runtime.RawExtension{Object: &corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Name: "hub-info-secret", Namespace: spokeNameSpace},
+			Data:       map[string][]byte{"hub-info.yaml": []byte(data)},
+		}}}
+	}
+	deployment := workv1.Manifest{RawExtension: runtime.RawExtension{Object: &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "endpoint-observability-operator", Namespace: spokeNameSpace},
+	}}}
+	existingWork := func(namespace string, manifests ...workv1.Manifest) *workv1.ManifestWork {
+		work := newManifestwork(namespace+workNameSuffix, namespace)
+		work.Annotations[addonRolloutRevisionAnnotation] = "old"
+		work.Spec.Workload.Manifests = manifests
+		return work
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		existingWork("held", allowlist("old"), secret("old")),
+		existingWork("unchanged", allowlist("new"), secret("old")),
+		existingWork("removed", allowlist("old"), deployment, secret("old")),
+		existingWork("split", secret("old")),
+		newManifestwork("split"+workNameSuffix+"-config", "split"),
+	).Build()
+	rollout := &addonRollout{revision: "new", allowed: map[string]bool{"allowed": true}}
+
+	desiredWork := func(namespace string) *workv1.ManifestWork {
+		work := newManifestwork(namespace+workNameSuffix, namespace)
+		work.Spec.Workload.Manifests = []workv1.Manifest{allowlist("new"), secret("new")}
+		return work
+	}
+	decode := func(t *testing.T, work *workv1.ManifestWork) (string, string) {
+		var data []string
+		for _, m := range work.Spec.Workload.Manifests {
+			content, err := m.MarshalJSON()
+			require.NoError(t, err)
+			obj := map[string]any{}
+			require.NoError(t, json.Unmarshal(content, &obj))
+			if obj["kind"] == "ConfigMap" {
+				data = append(data, obj["data"].(map[string]any)[operatorconfig.MetricsConfigMapKey].(string))
+			}
+		}
+		require.Len(t, data, 1)
+		return data[0], work.Annotations[addonRolloutRevisionAnnotation]
+	}
+
+	// The allowed clusters receive the revision.
+	work := desiredWork("allowed")
+	require.NoError(t, rollout.stage(context.Background(), c, work))
+	data, revision := decode(t, work)
+	assert.Equal(t, "new", data)
+	assert.Equal(t, "new", revision)
+
+	// The held clusters keep their staged manifests, and receive the other manifests.
+	work = desiredWork("held")
+	require.NoError(t, rollout.stage(context.Background(), c, work))
+	data, revision = decode(t, work)
+	assert.Equal(t, "old", data)
+	assert.Equal(t, "old", revision)
+	assert.Len(t, work.Spec.Workload.Manifests, 2)
+	assert.Equal(t, secret("new"), work.Spec.Workload.Manifests[1])
+
+	// The held clusters whose staged manifests are up to date are updated to the revision.
+	work = desiredWork("unchanged")
+	require.NoError(t, rollout.stage(context.Background(), c, work))
+	data, revision = decode(t, work)
+	assert.Equal(t, "new", data)
+	assert.Equal(t, "new", revision)
+
+	// The staged manifests removed from the revision are kept until the batch of the cluster.
+	work = desiredWork("removed")
+	require.NoError(t, rollout.stage(context.Background(), c, work))
+	assert.Len(t, work.Spec.Workload.Manifests, 3)
+	assert.Equal(t, "old", work.Annotations[addonRolloutRevisionAnnotation])
+
+	// The staged manifests held in the split manifestworks are kept.
+	config := &workv1.ManifestWork{}
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: "split" + workNameSuffix + "-config", Namespace: "split"}, config))
+	config.Spec.Workload.Manifests = []workv1.Manifest{allowlist("old")}
+	require.NoError(t, c.Update(context.Background(), config))
+	work = desiredWork("split")
+	require.NoError(t, rollout.stage(context.Background(), c, work))
+	data, revision = decode(t, work)
+	assert.Equal(t, "old", data)
+	assert.Equal(t, "old", revision)
+
+	// The new clusters receive the revision.
+	work = desiredWork("new-cluster")
+	require.NoError(t, rollout.stage(context.Background(), c, work))
+	assert.Equal(t, "new", work.Annotations[addonRolloutRevisionAnnotation])
+
+	// Without a rollout strategy, nothing is staged.
+	var noRollout *addonRollout
+	work = desiredWork("held")
+	require.NoError(t, noRollout.stage(context.Background(), c, work))
+	assert.NotContains(t, work.Annotations, addonRolloutRevisionAnnotation)
+}
+
+func TestSyncAddonRollout(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, workv1.Install(s))
+	require.NoError(t, mcov1beta1.AddToScheme(s))
+	require.NoError(t, mcov1beta2.AddToScheme(s))
+
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	mco := newTestMCO()
+	mco.Spec.AddonRollout = &mcov1beta2.AddonRolloutStrategy{
+		CanaryClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"canary": "true"}},
+		BatchSize:             1,
+	}
+	work := func(namespace, revision string) *workv1.ManifestWork {
+		w := newManifestwork(namespace+workNameSuffix, namespace)
+		w.Annotations[addonRolloutRevisionAnnotation] = revision
+		w.Status.Conditions = []metav1.Condition{{
+			Type: workv1.WorkAvailable, Status: metav1.ConditionTrue, Reason: "ResourcesAvailable",
+		}}
+		return w
+	}
+	addon := func(namespace string) *mcov1beta1.ObservabilityAddon {
+		return &mcov1beta1.ObservabilityAddon{
+			ObjectMeta: metav1.ObjectMeta{Name: obsAddonName, Namespace: namespace},
+			Status: mcov1beta1.ObservabilityAddonStatus{Conditions: []mcov1beta1.StatusCondition{{
+				Type: "Available", Status: metav1.ConditionTrue,
+			}}},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(s).
+		WithObjects(mco, work("a", "old"), work("b", "old"), work("canary", "old"), addon("a"), addon("b"), addon("canary")).
+		WithStatusSubresource(&mcov1beta2.MultiClusterObservability{}, &workv1.ManifestWork{}).
+		Build()
+	clusters := []managedClusterInfo{
+		{Name: "local-cluster", IsLocalCluster: true},
+		{Name: "a"},
+		{Name: "b"},
+		{Name: "canary", Labels: map[string]string{"canary": "true"}},
+		{Name: "new"},
+	}
+
+	rollout, requeueAfter, err := syncAddonRollout(context.Background(), c, mco, "rev", clusters, now)
+	require.NoError(t, err)
+	assert.Equal(t, rolloutHealthCheckPeriod, requeueAfter)
+	assert.Equal(t, map[string]bool{"canary": true}, rollout.batch)
+	assert.Equal(t, map[string]bool{"local-cluster": true, "a": false, "b": false, "canary": true, "new": true}, rollout.allowed)
+
+	found := &mcov1beta2.MultiClusterObservability{}
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: mcoName}, found))
+	require.NotNil(t, found.Status.AddonRollout)
+	assert.Equal(t, mcov1beta2.AddonRolloutProgressing, found.Status.AddonRollout.Phase)
+	assert.Equal(t, int32(3), found.Status.AddonRollout.TotalClusters)
+	assert.Equal(t, int32(0), found.Status.AddonRollout.UpdatedClusters)
+
+	// Removing the strategy clears the status.
+	found.Spec.AddonRollout = nil
+	rollout, _, err = syncAddonRollout(context.Background(), c, found, "rev", clusters, now)
+	require.NoError(t, err)
+	assert.Nil(t, rollout)
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: mcoName}, found))
+	assert.Nil(t, found.Status.AddonRollout)
+}
+
+func TestIsAddonHealthy(t *testing.T) {
+	work := &workv1.ManifestWork{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+	addon := &mcov1beta1.ObservabilityAddon{Status: mcov1beta1.ObservabilityAddonStatus{
+		Conditions: []mcov1beta1.StatusCondition{{Type: "Available", Status: metav1.ConditionTrue}},
+	}}
+	assert.False(t, isAddonHealthy(work, addon))
+
+	// The work must be available at its last generation.
+	work.Status.Conditions = []metav1.Condition{{Type: workv1.WorkAvailable, Status: metav1.ConditionTrue, ObservedGeneration: 1}}
+	assert.False(t, isAddonHealthy(work, addon))
+	work.Status.Conditions[0].ObservedGeneration = 2
+	assert.True(t, isAddonHealthy(work, addon))
+	assert.False(t, isAddonHealthy(work, nil))
+
+	addon.Status.Conditions = append(addon.Status.Conditions, mcov1beta1.StatusCondition{Type: "Degraded", Status: metav1.ConditionTrue})
+	assert.False(t, isAddonHealthy(work, addon))
+}
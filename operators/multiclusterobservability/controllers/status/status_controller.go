@@ -38,14 +38,21 @@ const (
 	ConditionTypeInstalling      = "Installing"
 	ConditionTypeMetricsDisabled = "MetricsDisabled"
 	ConditionTypeMCOADegraded    = "MultiClusterObservabilityAddonDegraded"
-
-	ReasonDeploymentNotFound    = "DeploymentNotFound"
-	ReasonDeploymentNotReady    = "DeploymentNotReady"
-	ReasonStatefulSetNotFound   = "StatefulSetNotFound"
-	ReasonStatefulSetNotReady   = "StatefulSetNotReady"
-	ReasonObjectStorageNotFound = "ObjectStorageSecretNotFound"
-	ReasonObjectStorageInvalid  = "ObjectStorageConfInvalid"
-	ReasonCRDMissing            = "CRDMissing"
+	// ConditionTypeStorageConfigApplied is managed by the MCO controller, its reason is the storage migration phase.
+	ConditionTypeStorageConfigApplied = "StorageConfigApplied"
+	// ConditionTypeTenantRetentionApplied is managed by the MCO controller, it reports the invalid tenant retentions.
+	ConditionTypeTenantRetentionApplied = "TenantRetentionApplied"
+	// ConditionTypeObjectStorageReachable is managed by the MCO controller, it reports the object storage probe.
+	ConditionTypeObjectStorageReachable = "ObjectStorageReachable"
+
+	ReasonDeploymentNotFound       = "DeploymentNotFound"
+	ReasonDeploymentNotReady       = "DeploymentNotReady"
+	ReasonStatefulSetNotFound      = "StatefulSetNotFound"
+	ReasonStatefulSetNotReady      = "StatefulSetNotReady"
+	ReasonObjectStorageNotFound    = "ObjectStorageSecretNotFound"
+	ReasonObjectStorageInvalid     = "ObjectStorageConfInvalid"
+	ReasonObjectStorageUnreachable = "ObjectStorageUnreachable"
+	ReasonCRDMissing               = "CRDMissing"
 )
 
 // StatusReconciler reconciles the status of a MultiClusterObservability object
@@ -203,30 +210,30 @@ func (r *StatusReconciler) updateReadyStatus(
 ) {
 	if objStorageStatus := checkObjStorageStatus(ctx, r.Client, mco); objStorageStatus != nil {
 		RemoveStatusCondition(conditions, ConditionTypeReady)
-		setStatusCondition(conditions, *objStorageStatus)
+		SetStatusCondition(conditions, *objStorageStatus)
 		return
 	}
 
 	if deployStatus := checkDeployStatus(ctx, r.Client); deployStatus != nil {
 		RemoveStatusCondition(conditions, ConditionTypeReady)
-		setStatusCondition(conditions, *deployStatus)
+		SetStatusCondition(conditions, *deployStatus)
 		return
 	}
 
 	if statefulStatus := checkStatefulSetStatus(ctx, r.Client); statefulStatus != nil {
 		RemoveStatusCondition(conditions, ConditionTypeReady)
-		setStatusCondition(conditions, *statefulStatus)
+		SetStatusCondition(conditions, *statefulStatus)
 		return
 	}
 
-	setStatusCondition(conditions, *newReadyCondition())
+	SetStatusCondition(conditions, *newReadyCondition())
 	RemoveStatusCondition(conditions, ConditionTypeFailed)
 	RemoveStatusCondition(conditions, ConditionTypeInstalling)
 }
 
 func (r *StatusReconciler) updateInstallStatus(conditions *[]mcoshared.Condition) {
 	if FindStatusCondition(*conditions, ConditionTypeReady) == nil {
-		setStatusCondition(conditions, *newInstallingCondition())
+		SetStatusCondition(conditions, *newInstallingCondition())
 	}
 }
 
@@ -237,7 +244,7 @@ func (r *StatusReconciler) updateAddonSpecStatus(
 	addonSpec := mco.Spec.ObservabilityAddonSpec
 	if addonSpec != nil && !addonSpec.EnableMetrics {
 		r.Log.Info("Disable metrics collector")
-		setStatusCondition(conditions, *newMetricsDisabledCondition())
+		SetStatusCondition(conditions, *newMetricsDisabledCondition())
 	} else {
 		RemoveStatusCondition(conditions, ConditionTypeMetricsDisabled)
 	}
@@ -283,7 +290,7 @@ outer:
 	}
 
 	mcoaDegraded := newMCOADegradedCondition(missing)
-	setStatusCondition(conds, *mcoaDegraded)
+	SetStatusCondition(conds, *mcoaDegraded)
 }
 
 // --- Helper Functions ---
@@ -305,7 +312,7 @@ func sortConditions(conditions []mcoshared.Condition) {
 	})
 }
 
-func setStatusCondition(conditions *[]mcoshared.Condition, newCondition mcoshared.Condition) {
+func SetStatusCondition(conditions *[]mcoshared.Condition, newCondition mcoshared.Condition) {
 	if conditions == nil {
 		return
 	}
@@ -420,7 +427,12 @@ func checkObjStorageStatus(
 		return newFailedCondition(ReasonObjectStorageInvalid, msg)
 	}
 
-	ok, err = config.CheckObjStorageConf(data)
+	if mco.Spec.StorageConfig.WorkloadIdentity != nil {
+		// the credentials are exchanged for the projected service account token of the Thanos components
+		ok, err = config.CheckWorkloadIdentityObjStorageConf(data)
+	} else {
+		ok, err = config.CheckObjStorageConf(data)
+	}
 	if !ok {
 		msg := "object storage configuration is invalid"
 		if err != nil {
@@ -429,6 +441,12 @@ func checkObjStorageStatus(
 		return newFailedCondition(ReasonObjectStorageInvalid, msg)
 	}
 
+	if reachable := FindStatusCondition(mco.Status.Conditions, ConditionTypeObjectStorageReachable); reachable != nil &&
+		reachable.Status == metav1.ConditionFalse {
+		msg := fmt.Sprintf("object storage is unreachable: %s", reachable.Message)
+		return newFailedCondition(ReasonObjectStorageUnreachable, msg)
+	}
+
 	return nil
 }
 
@@ -100,7 +100,7 @@ func TestSetStatusCondition(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			setStatusCondition(&test.conditions, test.toAdd)
+			SetStatusCondition(&test.conditions, test.toAdd)
 			found := FindStatusCondition(test.conditions, test.toAdd.Type)
 			assert.NotNil(t, found)
 			assert.Equal(t, test.toAdd.Status, found.Status)
@@ -114,7 +114,7 @@ func TestSetStatusCondition(t *testing.T) {
 
 	t.Run("nil-conditions", func(t *testing.T) {
 		var conds []mcoshared.Condition
-		setStatusCondition(&conds, mcoshared.Condition{Type: "Test"})
+		SetStatusCondition(&conds, mcoshared.Condition{Type: "Test"})
 		assert.Len(t, conds, 1)
 		assert.Equal(t, "Test", conds[0].Type)
 	})
@@ -25,14 +25,19 @@ import (
 	observabilityv1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/controllers/analytics"
 	mcoctrl "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/controllers/multiclusterobservability"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/controllers/observabilityalertroute"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/controllers/observabilitymaintenance"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/controllers/observabilityrule"
 	mcostatusctrl "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/controllers/status"
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/fleethealth"
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/util"
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/webhook"
 	operatorconfig "github.com/stolostron/multicluster-observability-operator/operators/pkg/config"
 	operatorsutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/util"
 	observatoriumAPIs "github.com/stolostron/observatorium-operator/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -45,6 +50,7 @@ import (
 	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 	workv1 "open-cluster-management.io/api/work/v1"
 	policyv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -137,6 +143,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := clusterv1beta2.Install(scheme); err != nil {
+		setupLog.Error(err, "")
+		os.Exit(1)
+	}
+
 	if err := policyv1.AddToScheme(scheme); err != nil {
 		setupLog.Error(err, "")
 		os.Exit(1)
@@ -184,6 +195,9 @@ func main() {
 		appsv1.SchemeGroupVersion.WithKind("StatefulSet"): {
 			{FieldSelector: fmt.Sprintf("metadata.namespace==%s", config.GetDefaultNamespace())},
 		},
+		batchv1.SchemeGroupVersion.WithKind("CronJob"): {
+			{FieldSelector: fmt.Sprintf("metadata.namespace==%s", config.GetDefaultNamespace())},
+		},
 
 		workv1.SchemeGroupVersion.WithKind("ManifestWork"): { //nolint:staticcheck // SA1019 SchemeGroupVersion is deprecated but metav1.GroupVersion lacks WithKind().
 			{LabelSelector: "owner==multicluster-observability-operator"},
@@ -334,6 +348,35 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&observabilityrule.ObservabilityRuleReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("ObservabilityRule"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ObservabilityRule")
+		os.Exit(1)
+	}
+
+	if err = (&observabilityalertroute.ObservabilityAlertRouteReconciler{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Log:       ctrl.Log.WithName("controllers").WithName("ObservabilityAlertRoute"),
+		Scheme:    mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ObservabilityAlertRoute")
+		os.Exit(1)
+	}
+
+	if err = (&observabilitymaintenance.ObservabilityMaintenanceWindowReconciler{
+		Client:     mgr.GetClient(),
+		Log:        ctrl.Log.WithName("controllers").WithName("ObservabilityMaintenanceWindow"),
+		Scheme:     mgr.GetScheme(),
+		RestConfig: mgr.GetConfig(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ObservabilityMaintenanceWindow")
+		os.Exit(1)
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("health", healthz.Ping); err != nil {
@@ -348,6 +391,18 @@ func main() {
 		setupLog.Error(err, "unable to set up debug handler")
 		os.Exit(1)
 	}
+	// The managed clusters are read from the API server, as the cache does not hold the disabled clusters.
+	if err := mgr.AddMetricsServerExtraHandler("/fleet-health", &fleethealth.Handler{
+		Client: mgr.GetClient(),
+		Reader: mgr.GetAPIReader(),
+		NewSampleSource: func(ctx context.Context) (fleethealth.SampleSource, error) {
+			return fleethealth.NewObservatoriumSampleSource(ctx, mgr.GetClient())
+		},
+		Log: ctrl.Log.WithName("fleet-health"),
+	}); err != nil {
+		setupLog.Error(err, "unable to set up fleet health handler")
+		os.Exit(1)
+	}
 
 	// Setup Scheme for observatorium resources
 	schemeBuilder := &ctrlruntimescheme.Builder{
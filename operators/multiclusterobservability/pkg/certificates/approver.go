@@ -6,21 +6,78 @@ package certificates
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
 	certificatesv1 "k8s.io/api/certificates/v1"
+	"open-cluster-management.io/addon-framework/pkg/agent"
 	addonapiv1beta1 "open-cluster-management.io/api/addon/v1beta1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func approve(ctx context.Context, cluster *clusterv1.ManagedCluster, addon *addonapiv1beta1.ManagedClusterAddOn,
-	csr *certificatesv1.CertificateSigningRequest,
-) bool {
-	if strings.HasPrefix(csr.Spec.Username, "system:open-cluster-management:"+cluster.Name) {
+const (
+	observabilitySignerName = "open-cluster-management.io/observability-signer"
+	// managedClusterUser is the common name of the client certificates of the managed clusters.
+	managedClusterUser = "managed-cluster-observability"
+)
+
+// approveCheck approves the CSRs requested by the managed cluster, and for the observability signer, only the
+// client certificates granting the writes into the tenant of the managed cluster.
+func approveCheck(c client.Client) agent.CSRApproveFunc {
+	return func(ctx context.Context, cluster *clusterv1.ManagedCluster, addon *addonapiv1beta1.ManagedClusterAddOn,
+		csr *certificatesv1.CertificateSigningRequest,
+	) bool {
+		if !strings.HasPrefix(csr.Spec.Username, "system:open-cluster-management:"+cluster.Name) {
+			log.Info("CSR not approved due to illegal requester", "requester", csr.Spec.Username)
+			return false
+		}
+		if csr.Spec.SignerName == observabilitySignerName {
+			if err := checkObservabilityCSR(ctx, c, cluster, csr); err != nil {
+				log.Info("CSR not approved due to illegal subject", "cluster", cluster.Name, "reason", err.Error())
+				return false
+			}
+		}
 		log.Info("CSR approved")
 		return true
-	} else {
-		log.Info("CSR not approved due to illegal requester", "requester", csr.Spec.Username)
-		return false
 	}
 }
+
+// checkObservabilityCSR returns an error when the client certificate requested by a managed cluster would grant more
+// than the writes into its tenant: the Observatorium API maps the common name of the certificate to a user and its
+// organization units to groups. The common name must be the user of the managed clusters, and the organization units
+// the group of the managed clusters, the group of the tenant of the cluster and the CA hashes.
+func checkObservabilityCSR(ctx context.Context, c client.Client, cluster *clusterv1.ManagedCluster,
+	csr *certificatesv1.CertificateSigningRequest,
+) error {
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return errors.New("the request is not a PEM encoded certificate request")
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse the certificate request: %w", err)
+	}
+	if request.Subject.CommonName != managedClusterUser {
+		return fmt.Errorf("unexpected common name %q", request.Subject.CommonName)
+	}
+
+	tenant, err := getClusterTenant(ctx, c, cluster)
+	if err != nil {
+		return err
+	}
+	tenantGroup := config.GetTenantGroup(tenant)
+	for _, ou := range request.Subject.OrganizationalUnit {
+		switch {
+		case ou == config.ManagedClusterOU, ou == tenantGroup,
+			strings.HasPrefix(ou, "ca-hash-"), strings.HasPrefix(ou, "client-ca-hash-"):
+		default:
+			return fmt.Errorf("unexpected organization unit %q, the managed cluster writes into the tenant %q", ou, tenant)
+		}
+	}
+	return nil
+}
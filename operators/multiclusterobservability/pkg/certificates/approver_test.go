@@ -6,38 +6,140 @@ package certificates
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"testing"
 
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	certificatesv1 "k8s.io/api/certificates/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 const (
 	clusterName = "test"
 )
 
+func newTestCSR(t *testing.T, commonName string, organizationUnits ...string) *certificatesv1.CertificateSigningRequest {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	request, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName, OrganizationalUnit: organizationUnits},
+	}, key)
+	require.NoError(t, err)
+	return &certificatesv1.CertificateSigningRequest{
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Username:   "system:open-cluster-management:" + clusterName,
+			SignerName: observabilitySignerName,
+			Request:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: request}),
+		},
+	}
+}
+
 func TestApprove(t *testing.T) {
 	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, mcov1beta2.AddToScheme(scheme))
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: config.GetMonitoringCRName()},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			Tenants: []mcov1beta2.ObservabilityTenant{{Name: "finance", ClusterSets: []string{"finance"}}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(mco).Build()
+	approve := approveCheck(c)
+
 	cluster := &clusterv1.ManagedCluster{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: clusterName,
 		},
 	}
+	financeCluster := cluster.DeepCopy()
+	financeCluster.Labels = map[string]string{config.ClusterSetLabelKey: "finance"}
+
+	// The kube client CSRs are only checked for their requester.
 	csr := &certificatesv1.CertificateSigningRequest{
 		Spec: certificatesv1.CertificateSigningRequestSpec{
-			Username: "system:open-cluster-management:" + clusterName,
+			Username:   "system:open-cluster-management:" + clusterName,
+			SignerName: certificatesv1.KubeAPIServerClientSignerName,
 		},
 	}
-	if !approve(ctx, cluster, nil, csr) {
-		t.Fatal("csr not approved automatically")
-	}
-	illCsr := &certificatesv1.CertificateSigningRequest{
-		Spec: certificatesv1.CertificateSigningRequestSpec{
-			Username: "illegal",
+	assert.True(t, approve(ctx, cluster, nil, csr), "csr not approved automatically")
+	csr.Spec.Username = "illegal"
+	assert.False(t, approve(ctx, cluster, nil, csr), "illegal csr approved automatically")
+
+	testCases := []struct {
+		name     string
+		cluster  *clusterv1.ManagedCluster
+		csr      *certificatesv1.CertificateSigningRequest
+		approved bool
+	}{
+		{
+			name:     "default tenant",
+			cluster:  cluster,
+			csr:      newTestCSR(t, managedClusterUser, "acm", "tenant-default", "ca-hash-1234", "client-ca-hash-5678"),
+			approved: true,
+		},
+		{
+			name:     "certificate without a tenant group",
+			cluster:  cluster,
+			csr:      newTestCSR(t, managedClusterUser, "acm"),
+			approved: true,
+		},
+		{
+			name:     "tenant of the cluster",
+			cluster:  financeCluster,
+			csr:      newTestCSR(t, managedClusterUser, "acm", "tenant-finance"),
+			approved: true,
+		},
+		{
+			name:    "another tenant",
+			cluster: cluster,
+			csr:     newTestCSR(t, managedClusterUser, "acm", "tenant-finance"),
+		},
+		{
+			name:    "the default tenant for a cluster of another tenant",
+			cluster: financeCluster,
+			csr:     newTestCSR(t, managedClusterUser, "acm", "tenant-default", "tenant-finance"),
+		},
+		{
+			name:    "unknown group",
+			cluster: cluster,
+			csr:     newTestCSR(t, managedClusterUser, "acm", "tenant-default", "system:masters"),
+		},
+		{
+			name:    "user of Grafana",
+			cluster: cluster,
+			csr:     newTestCSR(t, config.GrafanaCN, "acm", "tenant-default"),
+		},
+		{
+			name:    "invalid request",
+			cluster: cluster,
+			csr: &certificatesv1.CertificateSigningRequest{Spec: certificatesv1.CertificateSigningRequestSpec{
+				Username:   "system:open-cluster-management:" + clusterName,
+				SignerName: observabilitySignerName,
+				Request:    []byte("invalid"),
+			}},
 		},
 	}
-	if approve(ctx, cluster, nil, illCsr) {
-		t.Fatal("illegal csr approved automatically")
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.approved, approve(ctx, tc.cluster, nil, tc.csr))
+		})
 	}
+
+	// The CSRs approved manually are not signed either.
+	obsAgent := &ObservabilityAgent{client: c}
+	_, err := obsAgent.GetAgentAddonOptions().Registration.CSRSign(ctx, cluster, nil,
+		newTestCSR(t, managedClusterUser, "acm", "tenant-finance"))
+	assert.ErrorContains(t, err, `unexpected organization unit "tenant-finance", the managed cluster writes into the tenant "default"`)
 }
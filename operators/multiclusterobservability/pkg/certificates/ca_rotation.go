@@ -0,0 +1,81 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package certificates
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// clientCAAnnotation records on the ManagedClusterAddOn of a managed cluster the fingerprint of the client CA
+// which signed its current client certificate, so that the previous client CAs are no longer trusted once all
+// the managed clusters have a certificate of the new one.
+const clientCAAnnotation = "observability.open-cluster-management.io/client-ca"
+
+func caFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordClientCA records the client CA which signed the client certificate of the managed cluster of an addon.
+func recordClientCA(ctx context.Context, c client.Client, addon *addonv1beta1.ManagedClusterAddOn,
+	caCert *x509.Certificate,
+) error {
+	fingerprint := caFingerprint(caCert)
+	if addon.Annotations[clientCAAnnotation] == fingerprint {
+		return nil
+	}
+	patch := fmt.Appendf(nil, `{"metadata":{"annotations":{%q:%q}}}`, clientCAAnnotation, fingerprint)
+	target := &addonv1beta1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{Name: addon.Name, Namespace: addon.Namespace},
+	}
+	if err := c.Patch(ctx, target, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return fmt.Errorf("failed to record the client CA of the addon %s/%s: %w", addon.Namespace, addon.Name, err)
+	}
+	return nil
+}
+
+// clientCAsInUse returns the fingerprints of the client CAs which signed the current client certificates of the
+// managed clusters. It returns false when they are unknown: a managed cluster has a certificate signed before they
+// were recorded, or the client CA bundle also trusts the issuer of the Grafana certificate of cert-manager.
+func clientCAsInUse(ctx context.Context, c client.Client) (map[string]bool, bool, error) {
+	if config.GetMonitoringCRName() == "" {
+		return nil, false, nil
+	}
+	mco := &mcov1beta2.MultiClusterObservability{}
+	if err := c.Get(ctx, types.NamespacedName{Name: config.GetMonitoringCRName()}, mco); err != nil {
+		return nil, false, client.IgnoreNotFound(err)
+	}
+	if mco.Spec.CertManager != nil {
+		return nil, false, nil
+	}
+
+	addons := &addonv1beta1.ManagedClusterAddOnList{}
+	if err := c.List(ctx, addons); err != nil {
+		return nil, false, err
+	}
+	inUse := map[string]bool{}
+	for _, addon := range addons.Items {
+		if addon.Name != addonName {
+			continue
+		}
+		fingerprint, found := addon.Annotations[clientCAAnnotation]
+		if !found {
+			return nil, false, nil
+		}
+		inUse[fingerprint] = true
+	}
+	return inUse, true, nil
+}
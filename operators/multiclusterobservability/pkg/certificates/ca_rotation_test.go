@@ -0,0 +1,77 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package certificates
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newAddon(cluster string) *addonv1beta1.ManagedClusterAddOn {
+	return &addonv1beta1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{Name: addonName, Namespace: cluster},
+	}
+}
+
+func TestClientCARotation(t *testing.T) {
+	s := newCertManagerScheme(t)
+	require.NoError(t, addonv1beta1.Install(s))
+
+	newCA, _, err := NewSigningCertKeyPair("new", 2*time.Hour)
+	require.NoError(t, err)
+	oldCA, _, err := NewSigningCertKeyPair("old", time.Hour)
+	require.NoError(t, err)
+	newCerts, err := parsePEM(newCA, "new")
+	require.NoError(t, err)
+	oldCerts, err := parsePEM(oldCA, "old")
+	require.NoError(t, err)
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: clientCACerts, Namespace: namespace},
+		Data:       map[string][]byte{"tls.crt": append(bytes.Clone(newCA), oldCA...)},
+	}
+	c := newCertManagerClient(t, s, true, getMco(), caSecret, newAddon("cluster1"), newAddon("cluster2"))
+
+	getBundle := func() []byte {
+		secret := &corev1.Secret{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(caSecret), secret))
+		return secret.Data["tls.crt"]
+	}
+	record := func(cluster string, caCert []byte) {
+		addon := &addonv1beta1.ManagedClusterAddOn{}
+		require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: addonName, Namespace: cluster}, addon))
+		certs, err := parsePEM(caCert, cluster)
+		require.NoError(t, err)
+		require.NoError(t, recordClientCA(context.Background(), c, addon, certs[0]))
+	}
+
+	// The client CAs of the managed clusters are unknown.
+	record("cluster1", newCA)
+	removeExpiredCA(c, clientCACerts)
+	assert.Len(t, getBundle(), len(newCA)+len(oldCA))
+
+	// A managed cluster still has a certificate of the previous client CA.
+	record("cluster2", oldCA)
+	removeExpiredCA(c, clientCACerts)
+	assert.Len(t, getBundle(), len(newCA)+len(oldCA))
+	inUse, known, err := clientCAsInUse(context.Background(), c)
+	require.NoError(t, err)
+	assert.True(t, known)
+	assert.Equal(t, map[string]bool{caFingerprint(newCerts[0]): true, caFingerprint(oldCerts[0]): true}, inUse)
+
+	// All the managed clusters rotated their certificate.
+	record("cluster2", newCA)
+	removeExpiredCA(c, clientCACerts)
+	assert.Equal(t, newCA, getBundle())
+}
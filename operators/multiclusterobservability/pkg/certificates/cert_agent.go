@@ -9,9 +9,13 @@ import (
 	"crypto/sha256"
 	"fmt"
 
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
 	certificatesv1 "k8s.io/api/certificates/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"open-cluster-management.io/addon-framework/pkg/agent"
 	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
@@ -42,18 +46,26 @@ func (o *ObservabilityAgent) GetAgentAddonOptions() agent.AgentAddonOptions {
 		addon *addonv1beta1.ManagedClusterAddOn,
 		csr *certificatesv1.CertificateSigningRequest,
 	) ([]byte, error) {
-		res, err := Sign(o.client, csr)
+		// The CSRs approved manually are checked too.
+		if err := checkObservabilityCSR(ctx, o.client, cluster, csr); err != nil {
+			log.Error(err, "refusing to sign the CSR", "cluster", cluster.Name)
+			return nil, err
+		}
+		res, caCert, err := sign(o.client, csr)
 		if err != nil {
 			log.Error(err, "failed to sign")
 			return nil, err
 		}
+		if err := recordClientCA(ctx, o.client, addon, caCert); err != nil {
+			log.Error(err, "failed to record the client CA")
+		}
 		return res, nil
 	}
 	return agent.AgentAddonOptions{
 		AddonName: addonName,
 		Registration: &agent.RegistrationOption{
 			Configurations:  observabilitySignerConfigurations(o.client),
-			CSRApproveCheck: approve,
+			CSRApproveCheck: approveCheck(o.client),
 			PermissionConfig: func(
 				ctx context.Context,
 				cluster *clusterv1.ManagedCluster,
@@ -81,10 +93,15 @@ func observabilitySignerConfigurations(
 		cluster *clusterv1.ManagedCluster,
 		addon *addonv1beta1.ManagedClusterAddOn,
 	) ([]agent.RegistrationConfig, error) {
+		// The group of the tenant limits the writes of the managed cluster to the tenant selecting it.
+		tenant, err := getClusterTenant(ctx, client, cluster)
+		if err != nil {
+			return nil, err
+		}
 		observabilityConfig := &agent.CustomSignerRegistration{
-			SignerName:        "open-cluster-management.io/observability-signer",
-			User:              "managed-cluster-observability",
-			OrganizationUnits: []string{"acm"},
+			SignerName:        observabilitySignerName,
+			User:              managedClusterUser,
+			OrganizationUnits: []string{config.ManagedClusterOU, config.GetTenantGroup(tenant)},
 		}
 
 		kubeClientConfigs, err := agent.KubeClientSignerConfigurations(addonName, agentName)(ctx, cluster, addon)
@@ -94,7 +111,7 @@ func observabilitySignerConfigurations(
 		//nolint:gocritic // Creating new slice with additional config
 		registrationConfigs := append(kubeClientConfigs, observabilityConfig)
 
-		_, _, caCertBytes, caErr := getCA(client, true)
+		caCertBytes, caErr := getCACertBytes(client, serverCACerts)
 		if caErr == nil {
 			caHashStamp := fmt.Sprintf("ca-hash-%x", sha256.Sum256(caCertBytes))
 			for i := range registrationConfigs {
@@ -106,7 +123,33 @@ func observabilitySignerConfigurations(
 				}
 			}
 		}
+		// A new client CA changes the subject of the client certificate, so that the managed clusters rotate it
+		// right away, and the previous client CA can be removed.
+		if clientCACertBytes, err := getCACertBytes(client, clientCACerts); err == nil {
+			for i := range registrationConfigs {
+				if c, ok := registrationConfigs[i].(*agent.CustomSignerRegistration); ok {
+					c.OrganizationUnits = append(c.OrganizationUnits,
+						fmt.Sprintf("client-ca-hash-%x", sha256.Sum256(clientCACertBytes)))
+				}
+			}
+		}
 
 		return registrationConfigs, nil
 	}
 }
+
+// getClusterTenant returns the tenant the managed cluster writes into. A change of the tenant changes the subject
+// of the client certificate, so that the managed cluster rotates it.
+func getClusterTenant(ctx context.Context, c client.Client, cluster *clusterv1.ManagedCluster) (string, error) {
+	if config.GetMonitoringCRName() == "" {
+		return config.GetDefaultTenantName(), nil
+	}
+	mco := &mcov1beta2.MultiClusterObservability{}
+	if err := c.Get(ctx, types.NamespacedName{Name: config.GetMonitoringCRName()}, mco); err != nil {
+		if apierrors.IsNotFound(err) {
+			return config.GetDefaultTenantName(), nil
+		}
+		return "", fmt.Errorf("failed to get the MultiClusterObservability: %w", err)
+	}
+	return config.GetClusterTenant(mco, cluster.Labels), nil
+}
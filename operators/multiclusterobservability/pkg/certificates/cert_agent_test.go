@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
 	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
@@ -42,6 +43,7 @@ func TestCertAgent(t *testing.T) {
 
 	scheme := runtime.NewScheme()
 	scheme.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Secret{})
+	mcov1beta2.SchemeBuilder.AddToScheme(scheme)
 	client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(caSecret).Build()
 
 	obsAgent := &ObservabilityAgent{client: client}
@@ -83,7 +85,20 @@ func TestCertAgent(t *testing.T) {
 		SignerName:        "open-cluster-management.io/observability-signer",
 		User:              "managed-cluster-observability",
 		Groups:            nil,
-		OrganizationUnits: []string{"acm", caHashOrgUnit},
+		OrganizationUnits: []string{"acm", config.GetTenantGroup(config.GetDefaultTenantName()), caHashOrgUnit},
 	}
 	assert.Equal(t, configs[1], obsSignerExpectedRegConfig)
+
+	// The managed clusters selected by a tenant get the group of the tenant.
+	mco := &mcov1beta2.MultiClusterObservability{
+		ObjectMeta: metav1.ObjectMeta{Name: config.GetMonitoringCRName()},
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			Tenants: []mcov1beta2.ObservabilityTenant{{Name: "finance", ClusterSets: []string{"finance"}}},
+		},
+	}
+	assert.NoError(t, client.Create(ctx, mco))
+	cluster.Labels = map[string]string{config.ClusterSetLabelKey: "finance"}
+	configs, err = options.Registration.Configurations(ctx, cluster, addon)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acm", "tenant-finance", caHashOrgUnit}, configs[1].(*agent.CustomSignerRegistration).OrganizationUnits)
 }
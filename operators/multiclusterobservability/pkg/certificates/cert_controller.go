@@ -134,7 +134,8 @@ func updateDeployLabel(c client.Client, dName string, isUpdate bool) {
 
 func needsRenew(s v1.Secret) bool {
 	certSecretNames := []string{serverCACerts, clientCACerts, serverCerts, grafanaCerts, hubMetricsCollectorMtlsCert}
-	if !slices.Contains(certSecretNames, s.Name) {
+	if !slices.Contains(certSecretNames, s.Name) || isCertManagerSecret(&s) {
+		// cert-manager renews the certificates it issues.
 		return false
 	}
 	data := s.Data["tls.crt"]
@@ -162,14 +163,17 @@ func needsRenew(s v1.Secret) bool {
 
 func onAdd(c client.Client) func(obj any) {
 	return func(obj any) {
-		restartPods(c, *obj.(*v1.Secret), false)
+		s := *obj.(*v1.Secret)
+		restartPods(c, s, false)
+		syncCertManager(c, s)
 	}
 }
 
 func onDelete(c client.Client) func(obj any) {
 	return func(obj any) {
 		s := *obj.(*v1.Secret)
-		if slices.Contains(caSecretNames, s.Name) {
+		// The CA bundles assembled from the cert-manager certificates are assembled again.
+		if slices.Contains(caSecretNames, s.Name) && !isCertManagerSecret(&s) {
 			mco := &mcov1beta2.MultiClusterObservability{}
 			err := c.Get(context.TODO(), types.NamespacedName{
 				Name: config.GetMonitoringCRName(),
@@ -218,8 +222,9 @@ func onUpdate(c client.Client, ingressCtlCrdExists bool) func(oldObj, newObj any
 		newS := *newObj.(*v1.Secret)
 		if !reflect.DeepEqual(oldS.Data, newS.Data) {
 			restartPods(c, newS, true)
+			syncCertManager(c, newS)
 		} else {
-			if slices.Contains(caSecretNames, newS.Name) {
+			if slices.Contains(caSecretNames, newS.Name) && !isCertManagerSecret(&newS) {
 				removeExpiredCA(c, newS.Name)
 			}
 			if needsRenew(newS) {
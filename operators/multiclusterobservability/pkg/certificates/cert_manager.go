@@ -0,0 +1,425 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package certificates
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// certSourceLabel marks the CA bundle secrets assembled by the operator from the certificates issued by
+	// cert-manager. They hold no CA key, and are not renewed by the operator.
+	certSourceLabel       = "observability.open-cluster-management.io/cert-source"
+	certSourceCertManager = "cert-manager"
+	// certManagerCertificateAnnotation is set by cert-manager on the secrets of the certificates it issues.
+	certManagerCertificateAnnotation = "cert-manager.io/certificate-name"
+)
+
+var (
+	certificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+	// errCertificateNotIssued is returned while cert-manager has not issued a certificate of the hub yet.
+	errCertificateNotIssued = errors.New("certificate not issued yet by cert-manager")
+)
+
+// isCertManagerSecret returns whether a secret is issued by cert-manager, or assembled from the certificates it
+// issues, so that the operator does not renew it.
+func isCertManagerSecret(s *corev1.Secret) bool {
+	_, issued := s.Annotations[certManagerCertificateAnnotation]
+	return issued || s.Labels[certSourceLabel] == certSourceCertManager
+}
+
+// createCertManagerCerts creates the cert-manager Certificates of the server certificate of the Observatorium API
+// and of the client certificate of Grafana, and the CA bundles trusting them once they are issued.
+func createCertManagerCerts(ctx context.Context, c client.Client, scheme *runtime.Scheme,
+	mco *mcov1beta2.MultiClusterObservability, ingressCtlCrdExists bool,
+) error {
+	spec := mco.Spec.CertManager
+	hosts, err := getHosts(c, ingressCtlCrdExists)
+	if err != nil {
+		return err
+	}
+	if err := ensureCertificate(ctx, c, scheme, mco, serverCerts, serverCertificateCN,
+		append([]string{serverCertificateCN}, hosts...), "server auth", config.GetServerCertDuration(),
+		spec.ServerIssuer); err != nil {
+		return err
+	}
+	if err := ensureCertificate(ctx, c, scheme, mco, grafanaCerts, grafanaCertificateCN,
+		[]string{grafanaCertificateCN}, "client auth", config.GetClientCertDuration(),
+		spec.ClientIssuer); err != nil {
+		return err
+	}
+
+	if spec.ClientCASecretName == "" {
+		// The built-in client CA replaces the bundle of an external client CA.
+		if err := deleteCertManagerSecret(ctx, c, clientCACerts, true); err != nil {
+			return err
+		}
+		if err, _ := createCASecret(c, scheme, mco, false, clientCACerts, clientCACertificateCN); err != nil {
+			return err
+		}
+	}
+	err = syncCertManagerCABundles(ctx, c, scheme, mco)
+	if errors.Is(err, errCertificateNotIssued) {
+		// The CA bundles are synced by the certificate controller once the certificates are issued.
+		log.Info("Waiting for cert-manager to issue the certificates", "reason", err.Error())
+		return nil
+	}
+	return err
+}
+
+// ensureCertificate creates or updates the cert-manager Certificate issuing the given secret.
+func ensureCertificate(ctx context.Context, c client.Client, scheme *runtime.Scheme,
+	mco *mcov1beta2.MultiClusterObservability, secretName, cn string, dnsNames []string, extUsage string,
+	duration time.Duration, issuer mcov1beta2.CertManagerIssuerReference,
+) error {
+	kind := issuer.Kind
+	if kind == "" {
+		kind = "Issuer"
+	}
+	group := issuer.Group
+	if group == "" {
+		group = certificateGVK.Group
+	}
+	// The keys have the configured algorithm, and the encoding of the keys of the built-in CAs.
+	usages := []string{"digital signature", extUsage}
+	algorithm, size := config.GetCertKeyAlgorithm()
+	privateKey := map[string]any{"algorithm": algorithm, "encoding": "PKCS1"}
+	switch algorithm {
+	case config.KeyAlgorithmRSA:
+		usages = []string{"digital signature", "key encipherment", extUsage}
+		privateKey["size"] = int64(size)
+	case config.KeyAlgorithmECDSA:
+		privateKey["size"] = int64(size)
+	default:
+		privateKey["encoding"] = "PKCS8"
+	}
+	spec := map[string]any{
+		"secretName": secretName,
+		"commonName": cn,
+		"dnsNames":   toAnySlice(dnsNames),
+		"usages":     toAnySlice(usages),
+		"duration":   duration.String(),
+		"issuerRef": map[string]any{
+			"name":  issuer.Name,
+			"kind":  kind,
+			"group": group,
+		},
+		"privateKey": privateKey,
+		"secretTemplate": map[string]any{
+			"labels": map[string]any{config.BackupLabelName: config.BackupLabelValue},
+		},
+	}
+
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(certificateGVK)
+	err := c.Get(ctx, types.NamespacedName{Name: secretName, Namespace: config.GetDefaultNamespace()}, found)
+	if meta.IsNoMatchError(err) {
+		return fmt.Errorf("cert-manager is not installed: %w", err)
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get the Certificate %s: %w", secretName, err)
+	}
+	if apierrors.IsNotFound(err) {
+		certificate := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+		certificate.SetGroupVersionKind(certificateGVK)
+		certificate.SetName(secretName)
+		certificate.SetNamespace(config.GetDefaultNamespace())
+		if err := controllerutil.SetControllerReference(mco, certificate, scheme); err != nil {
+			return err
+		}
+		if err := c.Create(ctx, certificate); err != nil {
+			return fmt.Errorf("failed to create the Certificate %s: %w", secretName, err)
+		}
+		log.Info("Certificate created", "name", secretName, "issuer", issuer.Name)
+		return nil
+	}
+
+	// Only the fields set by the operator are compared, the others may be defaulted.
+	foundSpec, _ := found.Object["spec"].(map[string]any)
+	if foundSpec == nil {
+		foundSpec = map[string]any{}
+	}
+	updated := false
+	for k, v := range spec {
+		if !equality.Semantic.DeepEqual(foundSpec[k], v) {
+			foundSpec[k] = v
+			updated = true
+		}
+	}
+	if !updated {
+		return nil
+	}
+	found.Object["spec"] = foundSpec
+	if err := c.Update(ctx, found); err != nil {
+		return fmt.Errorf("failed to update the Certificate %s: %w", secretName, err)
+	}
+	log.Info("Certificate updated", "name", secretName, "issuer", issuer.Name)
+	return nil
+}
+
+func toAnySlice(values []string) []any {
+	s := make([]any, 0, len(values))
+	for _, v := range values {
+		s = append(s, v)
+	}
+	return s
+}
+
+// syncCertManagerCABundles updates the server CA bundle, trusted by the managed clusters and the rbac-query-proxy,
+// with the CA of the issued server certificate, and the client CA bundle, trusted by the Observatorium API, with the
+// CA signing the client certificates of the managed clusters and the CA of the issued Grafana certificate.
+func syncCertManagerCABundles(ctx context.Context, c client.Client, scheme *runtime.Scheme,
+	mco *mcov1beta2.MultiClusterObservability,
+) error {
+	serverCA, err := getIssuedCA(ctx, c, serverCerts)
+	if err != nil {
+		return err
+	}
+	if err := ensureCABundleSecret(ctx, c, scheme, mco, serverCACerts, serverCA); err != nil {
+		return err
+	}
+
+	grafanaCA, err := getIssuedCA(ctx, c, grafanaCerts)
+	if err != nil {
+		return err
+	}
+	if mco.Spec.CertManager.ClientCASecretName != "" {
+		external := &corev1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{
+			Name: mco.Spec.CertManager.ClientCASecretName, Namespace: config.GetDefaultNamespace(),
+		}, external); err != nil {
+			return fmt.Errorf("failed to get the client CA secret %s: %w", mco.Spec.CertManager.ClientCASecretName, err)
+		}
+		bundle, err := appendMissingCerts(external.Data["tls.crt"], grafanaCA)
+		if err != nil {
+			return fmt.Errorf("invalid client CA in the secret %s: %w", external.Name, err)
+		}
+		return ensureCABundleSecret(ctx, c, scheme, mco, clientCACerts, bundle)
+	}
+
+	// The built-in client CA keeps signing the client certificates of the managed clusters, and trusts the issuer
+	// of the Grafana certificate too. It stays first in the bundle, as the signing CA.
+	caSecret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: clientCACerts, Namespace: config.GetDefaultNamespace()}, caSecret); err != nil {
+		return fmt.Errorf("failed to get the client CA secret %s: %w", clientCACerts, err)
+	}
+	bundle, err := appendMissingCerts(caSecret.Data["tls.crt"], grafanaCA)
+	if err != nil {
+		return fmt.Errorf("invalid client CA in the secret %s: %w", clientCACerts, err)
+	}
+	if bytes.Equal(bundle, caSecret.Data["tls.crt"]) {
+		return nil
+	}
+	caSecret.Data["tls.crt"] = bundle
+	if err := c.Update(ctx, caSecret); err != nil {
+		return fmt.Errorf("failed to update the client CA secret %s: %w", clientCACerts, err)
+	}
+	log.Info("Client CA bundle updated with the CA of the Grafana certificate issuer", "name", clientCACerts)
+	return nil
+}
+
+// syncCertManager updates the CA bundles when cert-manager issues a certificate of the hub, or when the external
+// client CA changes.
+func syncCertManager(c client.Client, s corev1.Secret) {
+	if config.GetMonitoringCRName() == "" {
+		return
+	}
+	issued := (s.Name == serverCerts || s.Name == grafanaCerts) && isCertManagerSecret(&s)
+	if !issued && s.Labels[certSourceLabel] == certSourceCertManager {
+		return
+	}
+	mco := &mcov1beta2.MultiClusterObservability{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: config.GetMonitoringCRName()}, mco)
+	if err != nil || mco.Spec.CertManager == nil {
+		return
+	}
+	if !issued && s.Name != mco.Spec.CertManager.ClientCASecretName {
+		return
+	}
+	err = syncCertManagerCABundles(context.TODO(), c, c.Scheme(), mco)
+	if err != nil && !errors.Is(err, errCertificateNotIssued) {
+		log.Error(err, "Failed to update the CA bundles from the cert-manager certificates", "name", s.Name)
+	}
+}
+
+// getIssuedCA returns the CA of a certificate issued by cert-manager.
+func getIssuedCA(ctx context.Context, c client.Client, name string) ([]byte, error) {
+	issued := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: config.GetDefaultNamespace()}, issued)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get the certificate secret %s: %w", name, err)
+	}
+	if apierrors.IsNotFound(err) || !isCertManagerSecret(issued) {
+		return nil, fmt.Errorf("%w: %s", errCertificateNotIssued, name)
+	}
+	if len(issued.Data["ca.crt"]) == 0 {
+		return nil, fmt.Errorf("the issuer of the certificate %s does not provide its CA in ca.crt", name)
+	}
+	return issued.Data["ca.crt"], nil
+}
+
+// ensureCABundleSecret creates or updates a CA bundle secret assembled from the certificates issued by cert-manager.
+// The bundle is in both ca.crt and tls.crt, read by the different consumers of the CA secrets.
+func ensureCABundleSecret(ctx context.Context, c client.Client, scheme *runtime.Scheme,
+	mco *mcov1beta2.MultiClusterObservability, name string, bundle []byte,
+) error {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: config.GetDefaultNamespace()}}
+	res, err := controllerutil.CreateOrUpdate(ctx, c, secret, func() error {
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		secret.Labels[config.BackupLabelName] = config.BackupLabelValue
+		secret.Labels[certSourceLabel] = certSourceCertManager
+		// The key of a previous built-in CA is dropped.
+		secret.Data = map[string][]byte{"ca.crt": bundle, "tls.crt": bundle}
+		return controllerutil.SetControllerReference(mco, secret, scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update the CA bundle secret %s: %w", name, err)
+	}
+	if res != controllerutil.OperationResultNone {
+		log.Info("CA bundle updated from the cert-manager certificates", "name", name, "operation", res)
+	}
+	return nil
+}
+
+// appendMissingCerts appends the certificates of the PEM bundle extra missing from the PEM bundle.
+func appendMissingCerts(bundle, extra []byte) ([]byte, error) {
+	certs, err := parsePEM(bundle, "CA bundle")
+	if err != nil {
+		return nil, err
+	}
+	extraCerts, err := parsePEM(extra, "CA certificate")
+	if err != nil {
+		return nil, err
+	}
+	result := bytes.Clone(bundle)
+	for _, cert := range extraCerts {
+		found := false
+		for _, existing := range certs {
+			if existing.Equal(cert) {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		if len(result) > 0 && result[len(result)-1] != '\n' {
+			result = append(result, '\n')
+		}
+		result = append(result, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+		certs = append(certs, cert)
+	}
+	return result, nil
+}
+
+// removeCertManagerCerts deletes the Certificates and the secrets of cert-manager when it is no longer used, so
+// that the built-in CAs of the operator issue them again.
+func removeCertManagerCerts(ctx context.Context, c client.Client) error {
+	for _, name := range []string{serverCerts, grafanaCerts} {
+		secret := &corev1.Secret{}
+		err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: config.GetDefaultNamespace()}, secret)
+		if apierrors.IsNotFound(err) || (err == nil && !isCertManagerSecret(secret)) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get the certificate secret %s: %w", name, err)
+		}
+		certificate := &unstructured.Unstructured{}
+		certificate.SetGroupVersionKind(certificateGVK)
+		certificate.SetName(name)
+		certificate.SetNamespace(config.GetDefaultNamespace())
+		if err := c.Delete(ctx, certificate); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("failed to delete the Certificate %s: %w", name, err)
+		}
+		if err := deleteCertManagerSecret(ctx, c, name, false); err != nil {
+			return err
+		}
+		log.Info("Certificate no longer issued by cert-manager", "name", name)
+	}
+	for _, name := range []string{serverCACerts, clientCACerts} {
+		if err := deleteCertManagerSecret(ctx, c, name, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteCertManagerSecret deletes a secret issued by cert-manager or assembled from its certificates, or only
+// the latter when bundleOnly is set.
+func deleteCertManagerSecret(ctx context.Context, c client.Client, name string, bundleOnly bool) error {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: config.GetDefaultNamespace()}, secret)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get the secret %s: %w", name, err)
+	}
+	if !isCertManagerSecret(secret) || (bundleOnly && secret.Labels[certSourceLabel] != certSourceCertManager) {
+		return nil
+	}
+	if err := c.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// getSignerCA returns the CA signing the client certificates of the managed clusters: the external client CA of
+// the cert-manager settings of the MCO when set, the built-in client CA otherwise.
+func getSignerCA(ctx context.Context, c client.Client) (*x509.Certificate, crypto.Signer, error) {
+	if config.GetMonitoringCRName() != "" {
+		mco := &mcov1beta2.MultiClusterObservability{}
+		err := c.Get(ctx, types.NamespacedName{Name: config.GetMonitoringCRName()}, mco)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, nil, fmt.Errorf("failed to get the MultiClusterObservability: %w", err)
+		}
+		if err == nil && mco.Spec.CertManager != nil && mco.Spec.CertManager.ClientCASecretName != "" {
+			return getExternalCA(ctx, c, mco.Spec.CertManager.ClientCASecretName)
+		}
+	}
+	caCert, caKey, _, err := getCA(c, false)
+	return caCert, caKey, err
+}
+
+// getExternalCA returns the first certificate and the key of an external CA secret.
+func getExternalCA(ctx context.Context, c client.Client, name string) (*x509.Certificate, crypto.Signer, error) {
+	caSecret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: config.GetDefaultNamespace()}, caSecret); err != nil {
+		return nil, nil, fmt.Errorf("failed to get the client CA secret %s: %w", name, err)
+	}
+	certs, err := parsePEM(caSecret.Data["tls.crt"], name)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := parsePrivateKeyPEM(caSecret.Data["tls.key"])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid key in the client CA secret %s: %w", name, err)
+	}
+	return certs[0], key, nil
+}
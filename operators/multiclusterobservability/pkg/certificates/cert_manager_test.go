@@ -0,0 +1,257 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package certificates
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	routev1 "github.com/openshift/api/route/v1"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func newCertManagerScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	s := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(s))
+	require.NoError(t, routev1.AddToScheme(s))
+	require.NoError(t, mcov1beta2.AddToScheme(s))
+	return s
+}
+
+// newCertManagerClient returns a client serving the cert-manager Certificates when withCRD is set.
+func newCertManagerClient(t *testing.T, s *runtime.Scheme, withCRD bool, objs ...client.Object) client.Client {
+	t.Helper()
+	route := &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "observatorium-api", Namespace: namespace},
+		Spec:       routev1.RouteSpec{Host: "apiServerURL"},
+	}
+	builder := fake.NewClientBuilder().WithScheme(s).WithObjects(append(objs, route)...)
+	if !withCRD {
+		builder = builder.WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if obj.GetObjectKind().GroupVersionKind() == certificateGVK {
+					return &meta.NoKindMatchError{GroupKind: certificateGVK.GroupKind(), SearchedVersions: []string{"v1"}}
+				}
+				return c.Get(ctx, key, obj, opts...)
+			},
+		})
+	}
+	return builder.Build()
+}
+
+func newCertManagerMco() *mcov1beta2.MultiClusterObservability {
+	mco := getMco()
+	mco.Spec.CertManager = &mcov1beta2.CertManagerSpec{
+		ServerIssuer: mcov1beta2.CertManagerIssuerReference{Name: "server-issuer", Kind: "ClusterIssuer"},
+		ClientIssuer: mcov1beta2.CertManagerIssuerReference{Name: "client-issuer"},
+	}
+	return mco
+}
+
+func getCertificate(t *testing.T, c client.Client, name string) *unstructured.Unstructured {
+	t.Helper()
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: name, Namespace: namespace}, certificate))
+	return certificate
+}
+
+func getSecret(t *testing.T, c client.Client, name string) *corev1.Secret {
+	t.Helper()
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: name, Namespace: namespace}, secret))
+	return secret
+}
+
+// issue simulates cert-manager issuing the secret of a Certificate, signed by a new CA whose PEM is returned.
+func issue(t *testing.T, c client.Client, name string) []byte {
+	t.Helper()
+	caCert, _, err := NewSigningCertKeyPair(name+"-issuer", time.Hour)
+	require.NoError(t, err)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: map[string]string{certManagerCertificateAnnotation: name},
+		},
+		Data: map[string][]byte{"ca.crt": caCert, "tls.crt": caCert, "tls.key": []byte("key")},
+	}
+	require.NoError(t, c.Create(context.Background(), secret))
+	return caCert
+}
+
+func TestCreateCertManagerCerts(t *testing.T) {
+	s := newCertManagerScheme(t)
+	mco := newCertManagerMco()
+
+	// cert-manager is not installed.
+	c := newCertManagerClient(t, s, false, mco)
+	err := CreateObservabilityCerts(c, s, mco, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cert-manager is not installed")
+
+	c = newCertManagerClient(t, s, true, mco)
+	// The CA bundles wait for the certificates to be issued.
+	require.NoError(t, CreateObservabilityCerts(c, s, mco, true))
+	certificate := getCertificate(t, c, serverCerts)
+	issuer, _, _ := unstructured.NestedStringMap(certificate.Object, "spec", "issuerRef")
+	assert.Equal(t, map[string]string{"name": "server-issuer", "kind": "ClusterIssuer", "group": "cert-manager.io"}, issuer)
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	assert.Contains(t, dnsNames, serverCertificateCN)
+	assert.Contains(t, dnsNames, "apiServerURL")
+	certificate = getCertificate(t, c, grafanaCerts)
+	issuer, _, _ = unstructured.NestedStringMap(certificate.Object, "spec", "issuerRef")
+	assert.Equal(t, "Issuer", issuer["kind"])
+	_, err = getCACertBytes(c, serverCACerts)
+	assert.Error(t, err)
+
+	// The built-in client CA keeps signing the client certificates of the managed clusters.
+	builtinCA := getSecret(t, c, clientCACerts)
+	assert.NotEmpty(t, builtinCA.Data["tls.key"])
+
+	serverCA := issue(t, c, serverCerts)
+	grafanaCA := issue(t, c, grafanaCerts)
+	require.NoError(t, CreateObservabilityCerts(c, s, mco, true))
+
+	serverBundle := getSecret(t, c, serverCACerts)
+	assert.Equal(t, certSourceCertManager, serverBundle.Labels[certSourceLabel])
+	assert.Equal(t, serverCA, serverBundle.Data["ca.crt"])
+	assert.Equal(t, serverCA, serverBundle.Data["tls.crt"])
+	assert.Empty(t, serverBundle.Data["tls.key"])
+
+	clientBundle := getSecret(t, c, clientCACerts)
+	assert.True(t, bytes.HasPrefix(clientBundle.Data["tls.crt"], builtinCA.Data["tls.crt"]))
+	assert.True(t, bytes.HasSuffix(clientBundle.Data["tls.crt"], grafanaCA))
+	_, _, _, err = getCA(c, false)
+	assert.NoError(t, err)
+
+	// The issuer of a certificate is updated.
+	mco.Spec.CertManager.ServerIssuer = mcov1beta2.CertManagerIssuerReference{Name: "other-issuer"}
+	require.NoError(t, CreateObservabilityCerts(c, s, mco, true))
+	issuer, _, _ = unstructured.NestedStringMap(getCertificate(t, c, serverCerts).Object, "spec", "issuerRef")
+	assert.Equal(t, "other-issuer", issuer["name"])
+
+	// Back to the built-in CAs.
+	mco.Spec.CertManager = nil
+	require.NoError(t, CreateObservabilityCerts(c, s, mco, true))
+	certificate = &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	err = c.Get(context.Background(), types.NamespacedName{Name: serverCerts, Namespace: namespace}, certificate)
+	assert.True(t, client.IgnoreNotFound(err) == nil && err != nil)
+	for _, name := range []string{serverCACerts, clientCACerts, serverCerts, grafanaCerts} {
+		secret := getSecret(t, c, name)
+		assert.False(t, isCertManagerSecret(secret), name)
+		assert.NotEmpty(t, secret.Data["tls.key"], name)
+	}
+}
+
+func newECDSACA(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "external-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestExternalClientCA(t *testing.T) {
+	s := newCertManagerScheme(t)
+	mco := newCertManagerMco()
+	mco.Spec.CertManager.ClientCASecretName = "external-ca"
+	externalCert, externalKey := newECDSACA(t)
+	external := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "external-ca", Namespace: namespace},
+		Data:       map[string][]byte{"tls.crt": externalCert, "tls.key": externalKey},
+	}
+	c := newCertManagerClient(t, s, true, mco, external)
+
+	require.NoError(t, CreateObservabilityCerts(c, s, mco, true))
+	issue(t, c, serverCerts)
+	grafanaCA := issue(t, c, grafanaCerts)
+	require.NoError(t, CreateObservabilityCerts(c, s, mco, true))
+
+	clientBundle := getSecret(t, c, clientCACerts)
+	assert.Equal(t, certSourceCertManager, clientBundle.Labels[certSourceLabel])
+	assert.Equal(t, append(bytes.Clone(externalCert), grafanaCA...), clientBundle.Data["tls.crt"])
+
+	caCert, caKey, err := getSignerCA(context.Background(), c)
+	require.NoError(t, err)
+	assert.Equal(t, "external-ca", caCert.Subject.CommonName)
+	assert.IsType(t, &ecdsa.PrivateKey{}, caKey)
+
+	csr := &certificatesv1.CertificateSigningRequest{
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request: createCSR(),
+			Usages:  []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth},
+		},
+	}
+	signed, err := Sign(c, csr)
+	require.NoError(t, err)
+	block, _ := pem.Decode(signed)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	assert.NoError(t, cert.CheckSignatureFrom(caCert))
+
+	// A rotation of the external CA is added to the bundle by the certificate controller.
+	rotatedCert, rotatedKey := newECDSACA(t)
+	external.Data = map[string][]byte{"tls.crt": rotatedCert, "tls.key": rotatedKey}
+	require.NoError(t, c.Update(context.Background(), external))
+	syncCertManager(c, *external)
+	clientBundle = getSecret(t, c, clientCACerts)
+	assert.True(t, bytes.HasPrefix(clientBundle.Data["tls.crt"], rotatedCert))
+}
+
+func TestAppendMissingCerts(t *testing.T) {
+	ca1, _, err := NewSigningCertKeyPair("ca1", time.Hour)
+	require.NoError(t, err)
+	ca2, _, err := NewSigningCertKeyPair("ca2", time.Hour)
+	require.NoError(t, err)
+
+	bundle, err := appendMissingCerts(ca1, ca2)
+	require.NoError(t, err)
+	assert.Equal(t, append(bytes.Clone(ca1), ca2...), bundle)
+
+	same, err := appendMissingCerts(bundle, ca1)
+	require.NoError(t, err)
+	assert.Equal(t, bundle, same)
+
+	_, err = appendMissingCerts(ca1, []byte("invalid"))
+	assert.Error(t, err)
+}
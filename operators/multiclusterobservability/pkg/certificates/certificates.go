@@ -7,6 +7,7 @@ package certificates
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -59,7 +60,14 @@ func CreateObservabilityCerts(
 	mco *mcov1beta2.MultiClusterObservability,
 	ingressCtlCrdExists bool,
 ) error {
-	config.SetCertDuration(mco.Annotations)
+	config.SetCertConfig(mco)
+
+	if mco.Spec.CertManager != nil {
+		return createCertManagerCerts(context.TODO(), c, scheme, mco, ingressCtlCrdExists)
+	}
+	if err := removeCertManagerCerts(context.TODO(), c); err != nil {
+		return err
+	}
 
 	err, serverCrtUpdated := createCASecret(c, scheme, mco, false, serverCACerts, serverCACertifcateCN)
 	if err != nil {
@@ -107,7 +115,10 @@ func createCASecret(c client.Client,
 			if err != nil {
 				return err
 			}
-			certPEM, keyPEM := pemEncode(cert, key)
+			certPEM, keyPEM, err := pemEncode(cert, key)
+			if err != nil {
+				return err
+			}
 			caSecret = &corev1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      name,
@@ -136,22 +147,30 @@ func createCASecret(c client.Client,
 			return nil
 		}
 
+		caKey, err := parsePrivateKeyPEM(caSecret.Data["tls.key"])
+		if !isRenew && err == nil && !keyMatches(caKey) {
+			log.Info("The key algorithm of the CA changed, renew it", "name", name)
+			isRenew = true
+		}
 		if !isRenew {
 			log.Info("CA secrets already existed", "name", name)
 			return mcoutil.AddBackupLabelToSecretObj(c, caSecret)
 		}
 
-		block, _ := pem.Decode(caSecret.Data["tls.key"])
-		caKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
 		if err != nil {
 			log.Error(err, "Wrong private key found, create new one", "name", name)
 			caKey = nil
+		} else if !keyMatches(caKey) {
+			caKey = nil
 		}
 		key, cert, err := createCACertificate(cn, caKey)
 		if err != nil {
 			return err
 		}
-		certPEM, keyPEM := pemEncode(cert, key)
+		certPEM, keyPEM, err := pemEncode(cert, key)
+		if err != nil {
+			return err
+		}
 		caSecret.Data["ca.crt"] = certPEM.Bytes()
 		caSecret.Data["tls.crt"] = append(certPEM.Bytes(), caSecret.Data["tls.crt"]...)
 		caSecret.Data["tls.key"] = keyPEM.Bytes()
@@ -166,7 +185,7 @@ func createCASecret(c client.Client,
 	return err, updated
 }
 
-func createCACertificate(cn string, caKey *rsa.PrivateKey) ([]byte, []byte, error) {
+func createCACertificate(cn string, caKey crypto.Signer) (crypto.Signer, []byte, error) {
 	sn, err := rand.Int(rand.Reader, serialNumberLimit)
 	if err != nil {
 		log.Error(err, "failed to generate serial number")
@@ -180,27 +199,27 @@ func createCACertificate(cn string, caKey *rsa.PrivateKey) ([]byte, []byte, erro
 			CommonName:   cn,
 		},
 		NotBefore:             time.Now(),
-		NotAfter:              time.Now().Add(config.GetCertDuration() * 5),
+		NotAfter:              time.Now().Add(config.GetCACertDuration()),
 		IsCA:                  true,
-		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
 		BasicConstraintsValid: true,
 	}
 
 	if caKey == nil {
-		caKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		caKey, err = generateKey()
 		if err != nil {
 			log.Error(err, "Failed to generate private key", "cn", cn)
 			return nil, nil, err
 		}
 	}
+	ca.KeyUsage = keyUsage(caKey) | x509.KeyUsageCertSign
 
-	caBytes, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	caBytes, err := x509.CreateCertificate(rand.Reader, ca, ca, caKey.Public(), caKey)
 	if err != nil {
 		log.Error(err, "Failed to create certificate", "cn", cn)
 		return nil, nil, err
 	}
 
-	return x509.MarshalPKCS1PrivateKey(caKey), caBytes, nil
+	return caKey, caBytes, nil
 }
 
 //nolint:unparam
@@ -229,7 +248,10 @@ func createCertSecret(c client.Client,
 			if err != nil {
 				return err
 			}
-			certPEM, keyPEM := pemEncode(cert, key)
+			certPEM, keyPEM, err := pemEncode(cert, key)
+			if err != nil {
+				return err
+			}
 			crtSecret = &corev1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      name,
@@ -278,26 +300,34 @@ func createCertSecret(c client.Client,
 			}
 		}
 
+		crtkey, err := parsePrivateKeyPEM(crtSecret.Data["tls.key"])
+		if !isRenew && err == nil && !keyMatches(crtkey) {
+			log.Info("The key algorithm of the certificate changed, renew it", "name", name)
+			isRenew = true
+		}
 		if !isRenew {
 			log.Info("Certificate secrets already existed", "name", name)
 			return mcoutil.AddBackupLabelToSecretObj(c, crtSecret)
 		}
 
+		if err != nil {
+			log.Error(err, "Wrong private key found, create new one", "name", name)
+			crtkey = nil
+		} else if !keyMatches(crtkey) {
+			crtkey = nil
+		}
 		caCert, caKey, caCertBytes, err := getCA(c, isServer)
 		if err != nil {
 			return err
 		}
-		block, _ := pem.Decode(crtSecret.Data["tls.key"])
-		crtkey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		key, cert, err := createCertificate(isServer, cn, ou, dns, ips, caCert, caKey, crtkey)
 		if err != nil {
-			log.Error(err, "Wrong private key found, create new one", "name", name)
-			crtkey = nil
+			return err
 		}
-		key, cert, err := createCertificate(isServer, cn, ou, dns, ips, caCert, caKey, crtkey)
+		certPEM, keyPEM, err := pemEncode(cert, key)
 		if err != nil {
 			return err
 		}
-		certPEM, keyPEM := pemEncode(cert, key)
 		crtSecret.Data["ca.crt"] = caCertBytes
 		crtSecret.Data["tls.crt"] = certPEM.Bytes()
 		crtSecret.Data["tls.key"] = keyPEM.Bytes()
@@ -312,8 +342,8 @@ func createCertSecret(c client.Client,
 }
 
 func createCertificate(isServer bool, cn string, ou []string, dns []string, ips []net.IP,
-	caCert *x509.Certificate, caKey *rsa.PrivateKey, key *rsa.PrivateKey,
-) ([]byte, []byte, error) {
+	caCert *x509.Certificate, caKey crypto.Signer, key crypto.Signer,
+) (crypto.Signer, []byte, error) {
 	sn, err := rand.Int(rand.Reader, serialNumberLimit)
 	if err != nil {
 		log.Error(err, "failed to generate serial number")
@@ -328,11 +358,11 @@ func createCertificate(isServer bool, cn string, ou []string, dns []string, ips
 			CommonName:   cn,
 		},
 		NotBefore:   time.Now(),
-		NotAfter:    time.Now().Add(config.GetCertDuration()),
+		NotAfter:    time.Now().Add(config.GetServerCertDuration()),
 		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
-		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
 	}
 	if !isServer {
+		cert.NotAfter = time.Now().Add(config.GetClientCertDuration())
 		cert.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
 	}
 	if ou != nil {
@@ -350,23 +380,23 @@ func createCertificate(isServer bool, cn string, ou []string, dns []string, ips
 	}
 
 	if key == nil {
-		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		key, err = generateKey()
 		if err != nil {
 			log.Error(err, "Failed to generate private key", "cn", cn)
 			return nil, nil, err
 		}
 	}
+	cert.KeyUsage = keyUsage(key)
 
-	caBytes, err := x509.CreateCertificate(rand.Reader, cert, caCert, &key.PublicKey, caKey)
+	caBytes, err := x509.CreateCertificate(rand.Reader, cert, caCert, key.Public(), caKey)
 	if err != nil {
 		log.Error(err, "Failed to create certificate", "cn", cn)
 		return nil, nil, err
 	}
-	keyBytes := x509.MarshalPKCS1PrivateKey(key)
-	return keyBytes, caBytes, nil
+	return key, caBytes, nil
 }
 
-func getCA(c client.Client, isServer bool) (*x509.Certificate, *rsa.PrivateKey, []byte, error) {
+func getCA(c client.Client, isServer bool) (*x509.Certificate, crypto.Signer, []byte, error) {
 	caCertName := serverCACerts
 	if !isServer {
 		caCertName = clientCACerts
@@ -392,8 +422,8 @@ func getCA(c client.Client, isServer bool) (*x509.Certificate, *rsa.PrivateKey,
 		log.Error(err, "Failed to parse ca cert", "name", caCertName)
 		return nil, nil, nil, err
 	}
-	block2, _ := pem.Decode(caSecret.Data["tls.key"])
-	caKey, err := x509.ParsePKCS1PrivateKey(block2.Bytes)
+	// The CA bundles assembled from the certificates issued by cert-manager have no key.
+	caKey, err := parsePrivateKeyPEM(caSecret.Data["tls.key"])
 	if err != nil {
 		log.Error(err, "Failed to parse ca key", "name", caCertName)
 		return nil, nil, nil, err
@@ -401,6 +431,20 @@ func getCA(c client.Client, isServer bool) (*x509.Certificate, *rsa.PrivateKey,
 	return caCerts[0], caKey, caCertBytes, nil
 }
 
+// getCACertBytes returns the PEM of the current CA certificate of a CA secret, the first one of its tls.crt.
+func getCACertBytes(c client.Client, name string) ([]byte, error) {
+	caSecret := &corev1.Secret{}
+	err := c.Get(context.TODO(), types.NamespacedName{Namespace: config.GetDefaultNamespace(), Name: name}, caSecret)
+	if err != nil {
+		return nil, err
+	}
+	block, rest := pem.Decode(caSecret.Data["tls.crt"])
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode ca cert: %s", name)
+	}
+	return caSecret.Data["tls.crt"][:len(caSecret.Data["tls.crt"])-len(rest)], nil
+}
+
 func removeExpiredCA(c client.Client, name string) {
 	caSecret := &corev1.Secret{}
 	err := c.Get(context.TODO(), types.NamespacedName{Namespace: config.GetDefaultNamespace(), Name: name}, caSecret)
@@ -408,6 +452,16 @@ func removeExpiredCA(c client.Client, name string) {
 		log.Error(err, "Failed to get ca secret", "name", name)
 		return
 	}
+	// The previous client CAs are removed once all the managed clusters have a certificate of a newer one,
+	// rather than once they expire.
+	var inUse map[string]bool
+	inUseKnown := false
+	if name == clientCACerts {
+		inUse, inUseKnown, err = clientCAsInUse(context.TODO(), c)
+		if err != nil {
+			log.Error(err, "Failed to get the client CAs of the managed clusters", "name", name)
+		}
+	}
 	data := caSecret.Data["tls.crt"]
 	_, restData := pem.Decode(data)
 	caSecret.Data["tls.crt"] = data[:len(data)-len(restData)]
@@ -424,6 +478,9 @@ func removeExpiredCA(c client.Client, name string) {
 			} else if time.Now().After(certs[0].NotAfter) {
 				log.Info("CA certificate expired, needs to remove it", "name", name)
 				removeFlag = true
+			} else if inUseKnown && !inUse[caFingerprint(certs[0])] {
+				log.Info("CA certificate no longer used by the managed clusters, needs to remove it", "name", name)
+				removeFlag = true
 			}
 			if !removeFlag {
 				caSecret.Data["tls.crt"] = append(caSecret.Data["tls.crt"], data[index:len(data)-len(restData)]...)
@@ -443,7 +500,7 @@ func removeExpiredCA(c client.Client, name string) {
 	}
 }
 
-func pemEncode(cert []byte, key []byte) (*bytes.Buffer, *bytes.Buffer) {
+func pemEncode(cert []byte, key crypto.Signer) (*bytes.Buffer, *bytes.Buffer, error) {
 	certPEM := new(bytes.Buffer)
 	err := pem.Encode(certPEM, &pem.Block{
 		Type:  "CERTIFICATE",
@@ -453,16 +510,18 @@ func pemEncode(cert []byte, key []byte) (*bytes.Buffer, *bytes.Buffer) {
 		log.Error(err, "Failed to encode cert")
 	}
 
+	keyBlock, err := encodePrivateKey(key)
+	if err != nil {
+		log.Error(err, "Failed to marshal key")
+		return nil, nil, err
+	}
 	keyPEM := new(bytes.Buffer)
-	err = pem.Encode(keyPEM, &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: key,
-	})
+	err = pem.Encode(keyPEM, keyBlock)
 	if err != nil {
 		log.Error(err, "Failed to encode key")
 	}
 
-	return certPEM, keyPEM
+	return certPEM, keyPEM, nil
 }
 
 func getHosts(c client.Client, ingressCtlCrdExists bool) ([]string, error) {
@@ -494,7 +553,7 @@ func getHosts(c client.Client, ingressCtlCrdExists bool) ([]string, error) {
 }
 
 func GenerateKeyAndCSR() ([]byte, []byte, error) {
-	keys, err := rsa.GenerateKey(rand.Reader, 2048)
+	keys, err := generateKey()
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed generate private key: %w", err)
 	}
@@ -508,12 +567,16 @@ func GenerateKeyAndCSR() ([]byte, []byte, error) {
 			Country:      []string{"US"},
 			CommonName:   operatorconfig.ClientCACertificateCN,
 			ExtraNames: []pkix.AttributeTypeAndValue{
-				{Type: oidOrganization, Value: "acm"},
+				{Type: oidOrganization, Value: config.ManagedClusterOU},
+				// The metrics collector of the hub writes into the default tenant.
+				{Type: oidOrganization, Value: config.GetTenantGroup(config.GetDefaultTenantName())},
 				{Type: oidUser, Value: "managed-cluster-observability"},
 			},
 		},
-		DNSNames:           []string{"observability-controller.addon.open-cluster-management.io"},
-		SignatureAlgorithm: x509.SHA512WithRSA,
+		DNSNames: []string{"observability-controller.addon.open-cluster-management.io"},
+	}
+	if _, ok := keys.(*rsa.PrivateKey); ok {
+		csrTemplate.SignatureAlgorithm = x509.SHA512WithRSA
 	}
 	csrCertificate, err := x509.CreateCertificateRequest(rand.Reader, &csrTemplate, keys)
 	if err != nil {
@@ -523,9 +586,11 @@ func GenerateKeyAndCSR() ([]byte, []byte, error) {
 		Type: "CERTIFICATE REQUEST", Bytes: csrCertificate,
 	})
 
-	privateKey := pem.EncodeToMemory(&pem.Block{
-		Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(keys),
-	})
+	keyBlock, err := encodePrivateKey(keys)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	privateKey := pem.EncodeToMemory(keyBlock)
 
 	return csr, privateKey, nil
 }
@@ -569,6 +634,12 @@ func CreateUpdateMtlsCertSecretForHubCollector(ctx context.Context, c client.Cli
 			return renew()
 		}
 
+		// renew if the key algorithm changed
+		if key, err := parsePrivateKeyPEM(hubMtlsSecret.Data["tls.key"]); err != nil || !keyMatches(key) {
+			updateReason = "mTLS key algorithm changed"
+			return renew()
+		}
+
 		// renew if the mTLS certificate is approaching end of life
 		if mtlsCertShouldBeRenewed(hubMtlsSecret) {
 			updateReason = "mTLS cert should be renewed"
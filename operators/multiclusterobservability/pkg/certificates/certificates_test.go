@@ -51,7 +51,7 @@ func getExpiredCertSecret() *v1.Secret {
 	}
 	caKey, _ := rsa.GenerateKey(rand.Reader, 2048)
 	caBytes, _ := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
-	certPEM, keyPEM := pemEncode(caBytes, x509.MarshalPKCS1PrivateKey(caKey))
+	certPEM, keyPEM, _ := pemEncode(caBytes, caKey)
 	caSecret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      serverCACerts,
@@ -0,0 +1,118 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package certificates
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+)
+
+// generateKey generates a private key of the configured algorithm and size.
+func generateKey() (crypto.Signer, error) {
+	algorithm, size := config.GetCertKeyAlgorithm()
+	switch algorithm {
+	case config.KeyAlgorithmECDSA:
+		return ecdsa.GenerateKey(ellipticCurve(size), rand.Reader)
+	case config.KeyAlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return rsa.GenerateKey(rand.Reader, size)
+	}
+}
+
+func ellipticCurve(size int) elliptic.Curve {
+	switch size {
+	case 384:
+		return elliptic.P384()
+	case 521:
+		return elliptic.P521()
+	default:
+		return elliptic.P256()
+	}
+}
+
+// keyMatches returns whether a private key has the configured algorithm and size, so that it can be reused
+// when renewing its certificate.
+func keyMatches(key crypto.Signer) bool {
+	algorithm, size := config.GetCertKeyAlgorithm()
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return algorithm == config.KeyAlgorithmRSA && k.N.BitLen() == size
+	case *ecdsa.PrivateKey:
+		return algorithm == config.KeyAlgorithmECDSA && k.Curve == ellipticCurve(size)
+	case ed25519.PrivateKey:
+		return algorithm == config.KeyAlgorithmEd25519
+	default:
+		return false
+	}
+}
+
+// keyUsage returns the key usage of the certificates of a key. Only RSA keys are used for key encipherment.
+func keyUsage(key crypto.Signer) x509.KeyUsage {
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		return x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	}
+	return x509.KeyUsageDigitalSignature
+}
+
+// encodePrivateKey encodes the RSA keys in PKCS#1, as they always were, the ECDSA keys in SEC 1 and the Ed25519
+// keys in PKCS#8.
+func encodePrivateKey(key crypto.Signer) (*pem.Block, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}, nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}, nil
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
+	}
+}
+
+// parsePrivateKeyPEM parses a PKCS#1, SEC 1 or PKCS#8 private key.
+func parsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package certificates
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+)
+
+func setCertificates(spec *mcov1beta2.CertificatesSpec) {
+	mco := getMco()
+	mco.Spec.Certificates = spec
+	config.SetCertConfig(mco)
+}
+
+func TestGenerateKey(t *testing.T) {
+	defer setCertificates(nil)
+
+	for _, tc := range []struct {
+		spec     *mcov1beta2.CertificatesSpec
+		expected func(t *testing.T, key any)
+		block    string
+	}{
+		{
+			spec: nil,
+			expected: func(t *testing.T, key any) {
+				require.IsType(t, &rsa.PrivateKey{}, key)
+				assert.Equal(t, 2048, key.(*rsa.PrivateKey).N.BitLen())
+			},
+			block: "RSA PRIVATE KEY",
+		},
+		{
+			spec: &mcov1beta2.CertificatesSpec{KeyAlgorithm: config.KeyAlgorithmECDSA, KeySize: 384},
+			expected: func(t *testing.T, key any) {
+				require.IsType(t, &ecdsa.PrivateKey{}, key)
+				assert.Equal(t, elliptic.P384(), key.(*ecdsa.PrivateKey).Curve)
+			},
+			block: "EC PRIVATE KEY",
+		},
+		{
+			spec: &mcov1beta2.CertificatesSpec{KeyAlgorithm: config.KeyAlgorithmEd25519},
+			expected: func(t *testing.T, key any) {
+				assert.IsType(t, ed25519.PrivateKey{}, key)
+			},
+			block: "PRIVATE KEY",
+		},
+	} {
+		setCertificates(tc.spec)
+		key, err := generateKey()
+		require.NoError(t, err)
+		tc.expected(t, key)
+		assert.True(t, keyMatches(key))
+
+		block, err := encodePrivateKey(key)
+		require.NoError(t, err)
+		assert.Equal(t, tc.block, block.Type)
+		parsed, err := parsePrivateKeyPEM(pem.EncodeToMemory(block))
+		require.NoError(t, err)
+		assert.Equal(t, key, parsed)
+	}
+
+	setCertificates(&mcov1beta2.CertificatesSpec{KeyAlgorithm: config.KeyAlgorithmRSA, KeySize: 3072})
+	_, rsaKey, err := NewSigningCertKeyPair("rsa", time.Hour)
+	require.NoError(t, err)
+	key, err := parsePrivateKeyPEM(rsaKey)
+	require.NoError(t, err)
+	assert.False(t, keyMatches(key))
+
+	_, err = parsePrivateKeyPEM([]byte("invalid"))
+	assert.Error(t, err)
+}
+
+func TestRenewWithKeyAlgorithm(t *testing.T) {
+	defer setCertificates(nil)
+	s := newCertManagerScheme(t)
+	mco := getMco()
+	c := newCertManagerClient(t, s, true, mco)
+	require.NoError(t, CreateObservabilityCerts(c, s, mco, true))
+	rsaCA := getSecret(t, c, clientCACerts)
+
+	// A change of the key algorithm renews the CAs and the certificates, the previous CAs staying trusted.
+	mco.Spec.Certificates = &mcov1beta2.CertificatesSpec{
+		KeyAlgorithm:       config.KeyAlgorithmECDSA,
+		CADuration:         "2y",
+		ServerCertDuration: "90d",
+		ClientCertDuration: "30d",
+	}
+	require.NoError(t, CreateObservabilityCerts(c, s, mco, true))
+
+	caCert, caKey, _, err := getCA(c, false)
+	require.NoError(t, err)
+	assert.IsType(t, &ecdsa.PrivateKey{}, caKey)
+	assert.WithinDuration(t, time.Now().Add(2*365*24*time.Hour), caCert.NotAfter, time.Minute)
+	bundle, err := parsePEM(getSecret(t, c, clientCACerts).Data["tls.crt"], clientCACerts)
+	require.NoError(t, err)
+	require.Len(t, bundle, 2)
+	previous, err := parsePEM(rsaCA.Data["tls.crt"], clientCACerts)
+	require.NoError(t, err)
+	assert.True(t, bundle[1].Equal(previous[0]))
+
+	for name, duration := range map[string]time.Duration{serverCerts: 90 * 24 * time.Hour, grafanaCerts: 30 * 24 * time.Hour} {
+		secret := getSecret(t, c, name)
+		key, err := parsePrivateKeyPEM(secret.Data["tls.key"])
+		require.NoError(t, err)
+		assert.IsType(t, &ecdsa.PrivateKey{}, key, name)
+		certs, err := parsePEM(secret.Data["tls.crt"], name)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(duration), certs[0].NotAfter, time.Minute, name)
+		assert.Equal(t, x509.KeyUsageDigitalSignature, certs[0].KeyUsage, name)
+	}
+
+	// The client certificates of the managed clusters are signed by the new CA, with the client lifetime.
+	mco.Spec.Certificates.KeyAlgorithm = config.KeyAlgorithmEd25519
+	require.NoError(t, CreateObservabilityCerts(c, s, mco, true))
+	csr := &certificatesv1.CertificateSigningRequest{
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request: createCSR(),
+			Usages:  []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth},
+		},
+	}
+	signed, signer, err := sign(c, csr)
+	require.NoError(t, err)
+	assert.IsType(t, ed25519.PublicKey{}, signer.PublicKey)
+	certs, err := parsePEM(signed, "client")
+	require.NoError(t, err)
+	assert.NoError(t, certs[0].CheckSignatureFrom(signer))
+	// cfssl backdates the certificates by 5 minutes.
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), certs[0].NotAfter, 10*time.Minute)
+
+	// The hub metrics collector certificate gets a key of the new algorithm.
+	require.NoError(t, CreateUpdateMtlsCertSecretForHubCollector(context.Background(), c))
+	key, err := parsePrivateKeyPEM(getSecret(t, c, hubMetricsCollectorMtlsCert).Data["tls.key"])
+	require.NoError(t, err)
+	assert.IsType(t, ed25519.PrivateKey{}, key)
+}
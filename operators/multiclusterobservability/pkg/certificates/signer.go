@@ -5,6 +5,8 @@
 package certificates
 
 import (
+	"context"
+	"crypto/x509"
 	"fmt"
 	"os"
 	"time"
@@ -12,22 +14,29 @@ import (
 	"github.com/cloudflare/cfssl/config"
 	"github.com/cloudflare/cfssl/signer"
 	"github.com/cloudflare/cfssl/signer/local"
+	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
 	certificatesv1 "k8s.io/api/certificates/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func Sign(c client.Client, csr *certificatesv1.CertificateSigningRequest) ([]byte, error) {
+	signedCert, _, err := sign(c, csr)
+	return signedCert, err
+}
+
+// sign signs a CSR with the client CA, and returns the certificate and the CA signing it.
+func sign(c client.Client, csr *certificatesv1.CertificateSigningRequest) ([]byte, *x509.Certificate, error) {
 	if os.Getenv("TEST") != "" {
 		// Create the CA secret
 		err, _ := createCASecret(c, nil, nil, false, clientCACerts, clientCACertificateCN) // creates the
 		if err != nil {
-			return nil, fmt.Errorf("failed to create CA secret: %w", err)
+			return nil, nil, fmt.Errorf("failed to create CA secret: %w", err)
 		}
 	}
 
-	caCert, caKey, _, err := getCA(c, false) // gets client CA
+	caCert, caKey, err := getSignerCA(context.TODO(), c) // gets client CA
 	if err != nil {
-		return nil, fmt.Errorf("failed to get client CA: %w", err)
+		return nil, nil, fmt.Errorf("failed to get client CA: %w", err)
 	}
 
 	usages := make([]string, 0, len(csr.Spec.Usages))
@@ -35,10 +44,10 @@ func Sign(c client.Client, csr *certificatesv1.CertificateSigningRequest) ([]byt
 		usages = append(usages, string(usage))
 	}
 
-	certExpiryDuration := 365 * 24 * time.Hour
+	certExpiryDuration := mcoconfig.GetClientCertDuration()
 	durationUntilExpiry := time.Until(caCert.NotAfter)
 	if durationUntilExpiry <= 0 {
-		return nil, fmt.Errorf("signer has expired: %s", caCert.NotAfter)
+		return nil, nil, fmt.Errorf("signer has expired: %s", caCert.NotAfter)
 	}
 	if durationUntilExpiry < certExpiryDuration {
 		certExpiryDuration = durationUntilExpiry
@@ -53,14 +62,14 @@ func Sign(c client.Client, csr *certificatesv1.CertificateSigningRequest) ([]byt
 	}
 	cfs, err := local.NewSigner(caKey, caCert, signer.DefaultSigAlgo(caKey), policy)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create new local signer: %w", err)
+		return nil, nil, fmt.Errorf("failed to create new local signer: %w", err)
 	}
 
 	signedCert, err := cfs.Sign(signer.SignRequest{
 		Request: string(csr.Spec.Request),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign the CSR: %w", err)
+		return nil, nil, fmt.Errorf("failed to sign the CSR: %w", err)
 	}
-	return signedCert, nil
+	return signedCert, caCert, nil
 }
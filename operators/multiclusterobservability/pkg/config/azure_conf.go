@@ -11,12 +11,13 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-func validateAzure(conf Config) error {
+func validateAzure(conf Config, workloadIdentity bool) error {
 	if conf.StorageAccount == "" {
 		return errors.New("no storage_account as azure storage account in config file")
 	}
 
-	if conf.StorageAccountKey == "" {
+	// the workload identity replaces the storage account key
+	if conf.StorageAccountKey == "" && !workloadIdentity {
 		return errors.New("no storage_account_key as azure storage account key in config file")
 	}
 
@@ -33,6 +34,10 @@ func validateAzure(conf Config) error {
 
 // IsValidAzureConf is used to validate azure configuration.
 func IsValidAzureConf(data []byte) (bool, error) {
+	return isValidAzureConf(data, false)
+}
+
+func isValidAzureConf(data []byte, workloadIdentity bool) (bool, error) {
 	var objectConfg ObjectStorgeConf
 	err := yaml.Unmarshal(data, &objectConfg)
 	if err != nil {
@@ -43,7 +48,7 @@ func IsValidAzureConf(data []byte) (bool, error) {
 		return false, errors.New("invalid type config, only azure type is supported")
 	}
 
-	err = validateAzure(objectConfg.Config)
+	err = validateAzure(objectConfg.Config, workloadIdentity)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,101 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package config
+
+import (
+	"time"
+
+	"github.com/prometheus/common/model"
+	observabilityv1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+)
+
+// The algorithms of the keys of the certificates issued by the operator.
+const (
+	KeyAlgorithmRSA     = "RSA"
+	KeyAlgorithmECDSA   = "ECDSA"
+	KeyAlgorithmEd25519 = "Ed25519"
+
+	defaultRSAKeySize   = 2048
+	defaultECDSAKeySize = 256
+)
+
+var (
+	certKeyAlgorithm   = KeyAlgorithmRSA
+	certKeySize        = defaultRSAKeySize
+	caCertDuration     time.Duration
+	serverCertDuration time.Duration
+	clientCertDuration time.Duration
+)
+
+// SetCertConfig sets the keys and the lifetimes of the certificates from the certificates spec of the MCO, and
+// from its mco-cert-duration annotation for the lifetimes the spec does not set.
+func SetCertConfig(mco *observabilityv1beta2.MultiClusterObservability) {
+	SetCertDuration(mco.Annotations)
+	certKeyAlgorithm, certKeySize = KeyAlgorithmRSA, defaultRSAKeySize
+	caCertDuration, serverCertDuration, clientCertDuration = 0, 0, 0
+
+	spec := mco.Spec.Certificates
+	if spec == nil {
+		return
+	}
+	switch spec.KeyAlgorithm {
+	case KeyAlgorithmECDSA:
+		certKeyAlgorithm, certKeySize = KeyAlgorithmECDSA, defaultECDSAKeySize
+		if spec.KeySize == 384 || spec.KeySize == 521 {
+			certKeySize = int(spec.KeySize)
+		}
+	case KeyAlgorithmEd25519:
+		certKeyAlgorithm, certKeySize = KeyAlgorithmEd25519, 0
+	default:
+		if spec.KeySize == 3072 || spec.KeySize == 4096 {
+			certKeySize = int(spec.KeySize)
+		}
+	}
+	caCertDuration = parseCertDuration(spec.CADuration)
+	serverCertDuration = parseCertDuration(spec.ServerCertDuration)
+	clientCertDuration = parseCertDuration(spec.ClientCertDuration)
+}
+
+func parseCertDuration(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	d, err := model.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Error(err, "Failed to parse cert duration, use default one", "duration", value)
+		return 0
+	}
+	return time.Duration(d)
+}
+
+// GetCertKeyAlgorithm returns the algorithm and the size of the keys of the certificates issued by the operator.
+// The size is the one of the curve for ECDSA, and 0 for Ed25519.
+func GetCertKeyAlgorithm() (string, int) {
+	return certKeyAlgorithm, certKeySize
+}
+
+// GetCACertDuration returns the lifetime of the CAs of the operator.
+func GetCACertDuration() time.Duration {
+	if caCertDuration != 0 {
+		return caCertDuration
+	}
+	return GetServerCertDuration() * 5
+}
+
+// GetServerCertDuration returns the lifetime of the server certificate of the Observatorium API.
+func GetServerCertDuration() time.Duration {
+	if serverCertDuration != 0 {
+		return serverCertDuration
+	}
+	return certDuration
+}
+
+// GetClientCertDuration returns the lifetime of the client certificates of the hub and of the managed clusters.
+func GetClientCertDuration() time.Duration {
+	if clientCertDuration != 0 {
+		return clientCertDuration
+	}
+	return certDuration
+}
@@ -0,0 +1,49 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetCertConfig(t *testing.T) {
+	mco := &mcov1beta2.MultiClusterObservability{}
+	defer SetCertConfig(mco)
+
+	SetCertConfig(mco)
+	algorithm, size := GetCertKeyAlgorithm()
+	assert.Equal(t, KeyAlgorithmRSA, algorithm)
+	assert.Equal(t, 2048, size)
+	assert.Equal(t, 365*24*time.Hour, GetServerCertDuration())
+	assert.Equal(t, 365*24*time.Hour, GetClientCertDuration())
+	assert.Equal(t, 5*365*24*time.Hour, GetCACertDuration())
+
+	// The annotation sets the lifetimes the spec does not set.
+	mco.ObjectMeta = metav1.ObjectMeta{Annotations: map[string]string{AnnotationCertDuration: "1h"}}
+	mco.Spec.Certificates = &mcov1beta2.CertificatesSpec{
+		KeyAlgorithm:       KeyAlgorithmECDSA,
+		KeySize:            521,
+		ClientCertDuration: "30d",
+	}
+	SetCertConfig(mco)
+	algorithm, size = GetCertKeyAlgorithm()
+	assert.Equal(t, KeyAlgorithmECDSA, algorithm)
+	assert.Equal(t, 521, size)
+	assert.Equal(t, time.Hour, GetServerCertDuration())
+	assert.Equal(t, 30*24*time.Hour, GetClientCertDuration())
+	assert.Equal(t, 5*time.Hour, GetCACertDuration())
+
+	mco.Spec.Certificates = &mcov1beta2.CertificatesSpec{KeyAlgorithm: KeyAlgorithmEd25519, CADuration: "invalid"}
+	SetCertConfig(mco)
+	algorithm, size = GetCertKeyAlgorithm()
+	assert.Equal(t, KeyAlgorithmEd25519, algorithm)
+	assert.Equal(t, 0, size)
+	assert.Equal(t, 5*time.Hour, GetCACertDuration())
+}
@@ -62,6 +62,7 @@ const (
 	MCOUpdatedRequestName               = "mco-updated-request"
 	ClusterManagementAddOnUpdateName    = "clustermgmtaddon-updated-request"
 	AddonDeploymentConfigUpdateName     = "addondc-updated-request"
+	MaintenanceWindowsUpdateName        = "maintenancewindows-updated-request"
 	MulticloudConsoleRouteName          = "multicloud-console"
 	ImageManifestConfigMapNamePrefix    = "mch-image-manifest-"
 	OCMManifestConfigMapTypeLabelKey    = "ocm-configmap-type"
@@ -88,13 +89,17 @@ const (
 	AlertmanagerServiceName        = "alertmanager"
 	AlertmanagerRouteName          = "alertmanager"
 
-	AlertRuleDefaultConfigMapName    = "thanos-ruler-default-rules"
-	AlertRuleDefaultFileKey          = "default_rules.yaml"
-	AlertRuleHubMetricsConfigMapName = "thanos-ruler-hub-metrics-rules"
-	AlertRuleHubMetricsFileKey       = "hub_rules.yaml"
-	AlertRuleCustomConfigMapName     = "thanos-ruler-custom-rules"
-	AlertRuleCustomFileKey           = "custom_rules.yaml"
-	AlertmanagerConfigName           = "alertmanager-config"
+	AlertRuleDefaultConfigMapName       = "thanos-ruler-default-rules"
+	AlertRuleDefaultFileKey             = "default_rules.yaml"
+	AlertRuleHubMetricsConfigMapName    = "thanos-ruler-hub-metrics-rules"
+	AlertRuleHubMetricsFileKey          = "hub_rules.yaml"
+	AlertRuleCustomConfigMapName        = "thanos-ruler-custom-rules"
+	AlertRuleCustomFileKey              = "custom_rules.yaml"
+	AlertRuleObservabilityConfigMapName = "thanos-ruler-observability-rules"
+	AlertRuleObservabilityFileKey       = "observability_rules.yaml"
+	AlertmanagerConfigName              = "alertmanager-config"
+	AlertmanagerGeneratedConfigName     = "alertmanager-config-generated"
+	AlertmanagerConfigFileKey           = "alertmanager.yaml"
 
 	AlertmanagersDefaultConfigMapName     = "thanos-ruler-config"
 	AlertmanagersDefaultConfigFileKey     = "config.yaml"
@@ -0,0 +1,60 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package config
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+func validateCOS(conf Config) error {
+	if conf.SecretID == "" || conf.SecretKey == "" {
+		return errors.New("no secret_id or secret_key as cos credentials in config file")
+	}
+
+	// the endpoint replaces the bucket, app_id and region
+	if conf.Endpoint != "" {
+		if _, err := url.Parse(conf.Endpoint); err != nil {
+			return errors.New("invalid endpoint as cos endpoint in config file")
+		}
+		return nil
+	}
+
+	if conf.Bucket == "" {
+		return errors.New("no bucket as cos bucket name in config file")
+	}
+
+	if conf.AppID == "" {
+		return errors.New("no app_id as cos app id in config file")
+	}
+
+	if conf.Region == "" {
+		return errors.New("no region as cos region in config file")
+	}
+
+	return nil
+}
+
+func IsValidCOSConf(data []byte) (bool, error) {
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return false, err
+	}
+
+	if strings.ToLower(objectConfg.Type) != "cos" {
+		return false, errors.New("invalid type config, only COS type is supported")
+	}
+
+	err = validateCOS(objectConfg.Config)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,41 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package config
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// validateFilesystem validates the local filesystem backend. The Thanos components don't share the directory,
+// so it's only meant for test clusters.
+func validateFilesystem(conf Config) error {
+	if conf.Directory == "" {
+		return errors.New("no directory as filesystem directory in config file")
+	}
+
+	return nil
+}
+
+func IsValidFilesystemConf(data []byte) (bool, error) {
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return false, err
+	}
+
+	if strings.ToLower(objectConfg.Type) != "filesystem" {
+		return false, errors.New("invalid type config, only filesystem type is supported")
+	}
+
+	err = validateFilesystem(objectConfg.Config)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -5,6 +5,7 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,7 +13,7 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-func validateGCS(conf Config) error {
+func validateGCS(conf Config, workloadIdentity bool) error {
 	if conf.Bucket == "" {
 		return errors.New("no bucket as gcs bucket name in config file")
 	}
@@ -32,11 +33,26 @@ func validateGCS(conf Config) error {
 		return errors.New("no service_account as google application credentials in config file")
 	}
 
+	// The workload identity federation works on any cluster with the external_account credentials config, while
+	// the GKE metadata server is only available on GKE.
+	if workloadIdentity {
+		var credentials struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal([]byte(conf.ServiceAccount), &credentials); err != nil || credentials.Type != "external_account" {
+			return errors.New("service_account must be an external_account credentials config with a workload identity")
+		}
+	}
+
 	return nil
 }
 
 // IsValidGCSConf is used to validate GCS configuration.
 func IsValidGCSConf(data []byte) (bool, error) {
+	return isValidGCSConf(data, false)
+}
+
+func isValidGCSConf(data []byte, workloadIdentity bool) (bool, error) {
 	var objectConfg ObjectStorgeConf
 	err := yaml.Unmarshal(data, &objectConfg)
 	if err != nil {
@@ -47,7 +63,7 @@ func IsValidGCSConf(data []byte) (bool, error) {
 		return false, errors.New("invalid type config, only GCS type is supported")
 	}
 
-	err = validateGCS(objectConfg.Config)
+	err = validateGCS(objectConfg.Config, workloadIdentity)
 	if err != nil {
 		return false, err
 	}
@@ -32,6 +32,36 @@ type Config struct {
 	// gcs configuration
 	// Endpoint  string `yaml:"endpoint"`
 	ServiceAccount string `yaml:"service_account"`
+
+	// swift configuration
+	AuthURL                     string `yaml:"auth_url"`
+	Username                    string `yaml:"username"`
+	UserID                      string `yaml:"user_id"`
+	Password                    string `yaml:"password"`
+	ApplicationCredentialID     string `yaml:"application_credential_id"`
+	ApplicationCredentialName   string `yaml:"application_credential_name"`
+	ApplicationCredentialSecret string `yaml:"application_credential_secret"`
+	ContainerName               string `yaml:"container_name"`
+
+	// cos configuration
+	// Bucket    string `yaml:"bucket"`
+	// Endpoint  string `yaml:"endpoint"`
+	// SecretKey string `yaml:"secret_key"`
+	Region   string `yaml:"region"`
+	AppID    string `yaml:"app_id"`
+	SecretID string `yaml:"secret_id"`
+
+	// oci configuration
+	// Bucket string `yaml:"bucket"`
+	// Region string `yaml:"region"`
+	Provider    string `yaml:"provider"`
+	Tenancy     string `yaml:"tenancy_ocid"`
+	User        string `yaml:"user_ocid"`
+	Fingerprint string `yaml:"fingerprint"`
+	PrivateKey  string `yaml:"privatekey"`
+
+	// filesystem configuration
+	Directory string `yaml:"directory"`
 }
 
 // HTTPConfig stores the http.Transport configuration for the s3 minio client.
@@ -68,6 +98,16 @@ type TLSConfig struct {
 
 // CheckObjStorageConf is used to check/valid the object storage configurations.
 func CheckObjStorageConf(data []byte) (bool, error) {
+	return checkObjStorageConf(data, false)
+}
+
+// CheckWorkloadIdentityObjStorageConf checks the object storage configurations accessed with a cloud workload
+// identity, which don't need static credentials.
+func CheckWorkloadIdentityObjStorageConf(data []byte) (bool, error) {
+	return checkObjStorageConf(data, true)
+}
+
+func checkObjStorageConf(data []byte, workloadIdentity bool) (bool, error) {
 	var objectConfg ObjectStorgeConf
 	err := yaml.Unmarshal(data, &objectConfg)
 	if err != nil {
@@ -79,10 +119,22 @@ func CheckObjStorageConf(data []byte) (bool, error) {
 		return IsValidS3Conf(data)
 
 	case "gcs":
-		return IsValidGCSConf(data)
+		return isValidGCSConf(data, workloadIdentity)
 
 	case "azure":
-		return IsValidAzureConf(data)
+		return isValidAzureConf(data, workloadIdentity)
+
+	case "swift":
+		return IsValidSwiftConf(data)
+
+	case "cos":
+		return IsValidCOSConf(data)
+
+	case "oci":
+		return IsValidOCIConf(data)
+
+	case "filesystem":
+		return IsValidFilesystemConf(data)
 
 	default:
 		return false, errors.New("invalid object storage type config")
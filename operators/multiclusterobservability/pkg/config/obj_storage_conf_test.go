@@ -263,6 +263,125 @@ config:
 			name:     "gcs bucket name minimum length (3 chars)",
 			expected: true,
 		},
+
+		{
+			conf: []byte(`type: SWIFT
+config:
+  auth_url: https://keystone.example.com/v3
+  username: username
+  password: password
+  container_name: container`),
+			name:     "valid swift conf",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: SWIFT
+config:
+  auth_url: https://keystone.example.com/v3
+  application_credential_id: id
+  application_credential_secret: secret
+  container_name: container`),
+			name:     "valid swift conf with application credential",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: SWIFT
+config:
+  auth_url: https://keystone.example.com/v3
+  username: username
+  password: password`),
+			name:     "no swift container_name",
+			expected: false,
+		},
+
+		{
+			conf: []byte(`type: SWIFT
+config:
+  auth_url: https://keystone.example.com/v3
+  container_name: container`),
+			name:     "no swift credentials",
+			expected: false,
+		},
+
+		{
+			conf: []byte(`type: COS
+config:
+  bucket: bucket
+  region: ap-beijing
+  app_id: "1250000000"
+  secret_id: secret_id
+  secret_key: secret_key`),
+			name:     "valid cos conf",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: COS
+config:
+  endpoint: https://bucket-1250000000.cos.ap-beijing.myqcloud.com
+  secret_id: secret_id
+  secret_key: secret_key`),
+			name:     "valid cos conf with endpoint",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: COS
+config:
+  bucket: bucket
+  secret_id: secret_id
+  secret_key: secret_key`),
+			name:     "no cos app_id and region",
+			expected: false,
+		},
+
+		{
+			conf: []byte(`type: OCI
+config:
+  provider: instance-principal
+  bucket: bucket`),
+			name:     "valid oci conf",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: OCI
+config:
+  provider: raw
+  bucket: bucket
+  tenancy_ocid: tenancy
+  user_ocid: user
+  region: us-ashburn-1`),
+			name:     "no oci raw fingerprint and privatekey",
+			expected: false,
+		},
+
+		{
+			conf: []byte(`type: OCI
+config:
+  provider: unknown
+  bucket: bucket`),
+			name:     "invalid oci provider",
+			expected: false,
+		},
+
+		{
+			conf: []byte(`type: FILESYSTEM
+config:
+  directory: /var/thanos/bucket`),
+			name:     "valid filesystem conf",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: FILESYSTEM
+config:
+  directory: ""`),
+			name:     "no filesystem directory",
+			expected: false,
+		},
 	}
 
 	for _, c := range caseList {
@@ -274,3 +393,77 @@ config:
 		})
 	}
 }
+
+func TestCheckWorkloadIdentityObjStorageConf(t *testing.T) {
+	caseList := []struct {
+		conf     []byte
+		name     string
+		expected bool
+		// static is true when the config holds static credentials, valid without a workload identity
+		static bool
+	}{
+		{
+			conf: []byte(`type: azure
+config:
+  storage_account: storage_account
+  container: container
+  endpoint: endpoint`),
+			name:     "azure conf without storage_account_key",
+			expected: true,
+		},
+
+		{
+			conf: []byte(`type: gcs
+config:
+  bucket: bucket
+  service_account: |-
+    {"type": "external_account", "audience": "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/p/providers/p"}`),
+			name:     "gcs conf with external_account credentials",
+			expected: true,
+			static:   true,
+		},
+
+		{
+			conf: []byte(`type: gcs
+config:
+  bucket: bucket`),
+			name:     "gcs conf without service_account",
+			expected: false,
+		},
+
+		{
+			conf: []byte(`type: gcs
+config:
+  bucket: bucket
+  service_account: |-
+    {"type": "service_account", "private_key": "key"}`),
+			name:     "gcs conf with a service account key",
+			expected: false,
+		},
+
+		{
+			conf: []byte(`type: azure
+config:
+  storage_account: storage_account
+  endpoint: endpoint`),
+			name:     "no azure container",
+			expected: false,
+		},
+	}
+
+	for _, c := range caseList {
+		t.Run(c.name, func(t *testing.T) {
+			output, _ := CheckWorkloadIdentityObjStorageConf(c.conf)
+			if output != c.expected {
+				t.Errorf("case (%v) output (%v) is not the expected (%v)", c.name, output, c.expected)
+			}
+			// the static credentials are still required without a workload identity
+			if output {
+				output, _ = CheckObjStorageConf(c.conf)
+				if output != c.static {
+					t.Errorf("case (%v) output without a workload identity (%v) is not the expected (%v)", c.name, output, c.static)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+func validateOCI(conf Config) error {
+	if conf.Bucket == "" {
+		return errors.New("no bucket as oci bucket name in config file")
+	}
+
+	switch strings.ToLower(conf.Provider) {
+	case "", "default", "instance-principal", "oke-workload-identity":
+		return nil
+
+	case "raw":
+		if conf.Tenancy == "" || conf.User == "" || conf.Region == "" || conf.Fingerprint == "" || conf.PrivateKey == "" {
+			return errors.New("no tenancy_ocid, user_ocid, region, fingerprint or privatekey for the oci raw provider in config file")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("invalid provider '%s' as oci provider in config file", conf.Provider)
+	}
+}
+
+func IsValidOCIConf(data []byte) (bool, error) {
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return false, err
+	}
+
+	if strings.ToLower(objectConfg.Type) != "oci" {
+		return false, errors.New("invalid type config, only OCI type is supported")
+	}
+
+	err = validateOCI(objectConfg.Config)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package config
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+func validateSwift(conf Config) error {
+	if conf.AuthURL == "" {
+		return errors.New("no auth_url as swift keystone endpoint in config file")
+	}
+
+	if conf.ContainerName == "" {
+		return errors.New("no container_name as swift container in config file")
+	}
+
+	if conf.Password == "" && conf.ApplicationCredentialSecret == "" {
+		return errors.New("no password or application_credential_secret as swift credentials in config file")
+	}
+
+	if conf.Password != "" && conf.Username == "" && conf.UserID == "" {
+		return errors.New("no username or user_id as swift user in config file")
+	}
+
+	if conf.ApplicationCredentialSecret != "" && conf.ApplicationCredentialID == "" && conf.ApplicationCredentialName == "" {
+		return errors.New("no application_credential_id or application_credential_name as swift application credential in config file")
+	}
+
+	return nil
+}
+
+func IsValidSwiftConf(data []byte) (bool, error) {
+	var objectConfg ObjectStorgeConf
+	err := yaml.Unmarshal(data, &objectConfg)
+	if err != nil {
+		return false, err
+	}
+
+	if strings.ToLower(objectConfg.Type) != "swift" {
+		return false, errors.New("invalid type config, only swift type is supported")
+	}
+
+	err = validateSwift(objectConfg.Config)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
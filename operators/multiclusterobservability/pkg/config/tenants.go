@@ -0,0 +1,97 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package config
+
+import (
+	"fmt"
+	"slices"
+
+	observabilityv1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+// ClusterSetLabelKey is the label set on a managed cluster with the name of its ManagedClusterSet.
+const ClusterSetLabelKey = "cluster.open-cluster-management.io/clusterset"
+
+// tenantGroupPrefix prefixes the name of a tenant in the organizational unit of the client certificates of the
+// managed clusters writing into it.
+const tenantGroupPrefix = "tenant-"
+
+// GetTenantGroup returns the group of the managed clusters writing into a tenant, set as an organizational unit
+// of their client certificates.
+func GetTenantGroup(tenant string) string {
+	return tenantGroupPrefix + tenant
+}
+
+// GetTenantNames returns the names of all the tenants, starting with the default tenant.
+func GetTenantNames(mco *observabilityv1beta2.MultiClusterObservability) []string {
+	names := []string{defaultTenantName}
+	for _, tenant := range mco.Spec.Tenants {
+		names = append(names, tenant.Name)
+	}
+	return names
+}
+
+// GetClusterTenant returns the name of the tenant the metrics of a managed cluster are written into:
+// the first tenant selecting the cluster, either by ManagedClusterSet or by label, or the default tenant.
+func GetClusterTenant(mco *observabilityv1beta2.MultiClusterObservability, clusterLabels map[string]string) string {
+	if mco == nil {
+		return defaultTenantName
+	}
+	for _, tenant := range mco.Spec.Tenants {
+		if clusterSet, ok := clusterLabels[ClusterSetLabelKey]; ok && slices.Contains(tenant.ClusterSets, clusterSet) {
+			return tenant.Name
+		}
+		if tenant.ClusterSelector == nil {
+			continue
+		}
+		selector, err := v1.LabelSelectorAsSelector(tenant.ClusterSelector)
+		if err != nil {
+			log.Error(err, "Invalid cluster selector, ignoring it", "tenant", tenant.Name)
+			continue
+		}
+		// An empty selector matches every cluster, which is most likely a mistake.
+		if selector.Empty() {
+			continue
+		}
+		if selector.Matches(labels.Set(clusterLabels)) {
+			return tenant.Name
+		}
+	}
+	return defaultTenantName
+}
+
+// SelectClusters returns the sorted names of the managed clusters belonging to one of the ManagedClusterSets or
+// matching the selector, and whether the selection is restricted: without cluster sets nor selector, all the
+// managed clusters are selected.
+func SelectClusters(clusterSets []string, clusterSelector *v1.LabelSelector,
+	clusters []clusterv1.ManagedCluster,
+) ([]string, bool, error) {
+	restricted := len(clusterSets) > 0 || clusterSelector != nil
+	var selector labels.Selector
+	if clusterSelector != nil {
+		var err error
+		selector, err = v1.LabelSelectorAsSelector(clusterSelector)
+		if err != nil {
+			return nil, restricted, fmt.Errorf("invalid cluster selector: %w", err)
+		}
+	}
+
+	names := []string{}
+	for _, cluster := range clusters {
+		if restricted {
+			clusterSet, ok := cluster.Labels[ClusterSetLabelKey]
+			inClusterSets := ok && slices.Contains(clusterSets, clusterSet)
+			if !inClusterSets && (selector == nil || !selector.Matches(labels.Set(cluster.Labels))) {
+				continue
+			}
+		}
+		names = append(names, cluster.Name)
+	}
+	slices.Sort(names)
+	return names, restricted, nil
+}
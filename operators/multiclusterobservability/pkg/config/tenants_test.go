@@ -0,0 +1,112 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package config
+
+import (
+	"testing"
+
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+func TestGetClusterTenant(t *testing.T) {
+	mco := &mcov1beta2.MultiClusterObservability{
+		Spec: mcov1beta2.MultiClusterObservabilitySpec{
+			Tenants: []mcov1beta2.ObservabilityTenant{
+				{Name: "finance", ClusterSets: []string{"finance-prod", "finance-dev"}},
+				{Name: "retail", ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"business-unit": "retail"}}},
+				{Name: "empty-selector", ClusterSelector: &metav1.LabelSelector{}},
+				{Name: "invalid-selector", ClusterSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: "Unknown"}},
+				}},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		mco      *mcov1beta2.MultiClusterObservability
+		labels   map[string]string
+		expected string
+	}{
+		{name: "cluster set", mco: mco, labels: map[string]string{ClusterSetLabelKey: "finance-dev"}, expected: "finance"},
+		{name: "label selector", mco: mco, labels: map[string]string{ClusterSetLabelKey: "default", "business-unit": "retail"}, expected: "retail"},
+		{name: "first tenant wins", mco: mco, labels: map[string]string{ClusterSetLabelKey: "finance-prod", "business-unit": "retail"}, expected: "finance"},
+		{name: "no matching tenant", mco: mco, labels: map[string]string{"env": "dev"}, expected: "default"},
+		{name: "no tenants", mco: &mcov1beta2.MultiClusterObservability{}, labels: map[string]string{ClusterSetLabelKey: "finance-dev"}, expected: "default"},
+		{name: "no MCO", labels: map[string]string{ClusterSetLabelKey: "finance-dev"}, expected: "default"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetClusterTenant(tc.mco, tc.labels))
+		})
+	}
+
+	assert.Equal(t, []string{"default", "finance", "retail", "empty-selector", "invalid-selector"}, GetTenantNames(mco))
+}
+
+func newManagedCluster(name string, labels map[string]string) clusterv1.ManagedCluster {
+	return clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestSelectClusters(t *testing.T) {
+	clusters := []clusterv1.ManagedCluster{
+		newManagedCluster("prod-2", map[string]string{ClusterSetLabelKey: "prod"}),
+		newManagedCluster("prod-1", map[string]string{ClusterSetLabelKey: "prod"}),
+		newManagedCluster("dev-1", map[string]string{ClusterSetLabelKey: "dev", "env": "dev"}),
+		newManagedCluster("local-cluster", map[string]string{"local-cluster": "true"}),
+	}
+
+	testCases := map[string]struct {
+		clusterSets        []string
+		clusterSelector    *metav1.LabelSelector
+		expectedClusters   []string
+		expectedRestricted bool
+		expectedErr        bool
+	}{
+		"all clusters": {
+			expectedClusters: []string{"dev-1", "local-cluster", "prod-1", "prod-2"},
+		},
+		"cluster set": {
+			clusterSets:        []string{"prod"},
+			expectedClusters:   []string{"prod-1", "prod-2"},
+			expectedRestricted: true,
+		},
+		"cluster set or selector": {
+			clusterSets:        []string{"prod"},
+			clusterSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}},
+			expectedClusters:   []string{"dev-1", "prod-1", "prod-2"},
+			expectedRestricted: true,
+		},
+		"no matching cluster": {
+			clusterSets:        []string{"staging"},
+			expectedClusters:   []string{},
+			expectedRestricted: true,
+		},
+		"invalid selector": {
+			clusterSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: "Unknown"}},
+			},
+			expectedRestricted: true,
+			expectedErr:        true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			names, restricted, err := SelectClusters(tc.clusterSets, tc.clusterSelector, clusters)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedClusters, names)
+			assert.Equal(t, tc.expectedRestricted, restricted)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package fleethealth
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// The export formats of the report.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// csvHeader is the header of the CSV export, one row per cluster.
+var csvHeader = []string{
+	"cluster", "vendor", "openshiftVersion", "available", "category", "message",
+	"addonReason", "addonMessage", "manifestWorkStatus", "lastSampleTime",
+}
+
+// Write writes the report in the given format.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatCSV:
+		return r.WriteCSV(w)
+	default:
+		return fmt.Errorf("unsupported format %q, expected %s or %s", format, FormatJSON, FormatCSV)
+	}
+}
+
+// WriteJSON writes the report as an indented JSON document.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes the clusters of the report as CSV, without the summary and the warnings.
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, cluster := range r.Clusters {
+		lastSample := ""
+		if cluster.LastSampleTime != nil {
+			lastSample = cluster.LastSampleTime.UTC().Format(time.RFC3339)
+		}
+		if err := writer.Write([]string{
+			cluster.Name, cluster.Vendor, cluster.OpenshiftVersion, strconv.FormatBool(cluster.Available),
+			string(cluster.Category), cluster.Message, cluster.AddonReason, cluster.AddonMessage,
+			cluster.ManifestWorkStatus, lastSample,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,141 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package fleethealth
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// reportTimeout is the timeout of the report of a request.
+const reportTimeout = time.Minute
+
+// Handler serves the health report of the managed clusters, in the format given by the format query parameter,
+// JSON by default. The requests are authenticated by their bearer token, and allowed for the users who can list
+// the managed clusters, as the server of the operator endpoints has no authentication of its own.
+type Handler struct {
+	// Client creates the TokenReviews and SubjectAccessReviews of the requests.
+	Client client.Client
+	// Reader reads the managed clusters, ObservabilityAddons and ManifestWorks of the report.
+	Reader client.Reader
+	// NewSampleSource returns the source of the last samples of a report, which is built without them when nil.
+	NewSampleSource func(ctx context.Context) (SampleSource, error)
+	Log             logr.Logger
+}
+
+// failedSampleSource reports the failure to create a sample source as the failure to get the last samples.
+type failedSampleSource struct {
+	err error
+}
+
+func (s failedSampleSource) LastSamples(context.Context, time.Time) (map[string]time.Time, error) {
+	return nil, s.err
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = FormatJSON
+	}
+	if format != FormatJSON && format != FormatCSV {
+		http.Error(w, "unsupported format "+format+", expected json or csv", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), reportTimeout)
+	defer cancel()
+	if code, msg := h.authorize(ctx, req); code != http.StatusOK {
+		http.Error(w, msg, code)
+		return
+	}
+
+	var samples SampleSource
+	if h.NewSampleSource != nil {
+		var err error
+		if samples, err = h.NewSampleSource(ctx); err != nil {
+			samples = failedSampleSource{err: err}
+		}
+	}
+	report, err := Build(ctx, h.Reader, samples, time.Now())
+	if err != nil {
+		h.Log.Error(err, "Failed to build the fleet health report")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, warning := range report.Warnings {
+		h.Log.Info("Fleet health report incomplete", "warning", warning)
+	}
+
+	// The report is written to a buffer so that an error returns an error status rather than a truncated report.
+	buf := &bytes.Buffer{}
+	if err := report.Write(buf, format); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if format == FormatCSV {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="fleet-health.csv"`)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	_, _ = w.Write(buf.Bytes())
+}
+
+// authorize returns http.StatusOK when the bearer token of the request belongs to a user allowed to list the managed
+// clusters, the error status and its message otherwise.
+func (h *Handler) authorize(ctx context.Context, req *http.Request) (int, string) {
+	token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		return http.StatusUnauthorized, "missing bearer token"
+	}
+
+	review := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	if err := h.Client.Create(ctx, review); err != nil {
+		h.Log.Error(err, "Failed to review the token of the fleet health request")
+		return http.StatusInternalServerError, "failed to review the token"
+	}
+	if !review.Status.Authenticated {
+		return http.StatusUnauthorized, "invalid bearer token"
+	}
+
+	user := review.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	access := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Verb:     "list",
+				Group:    "cluster.open-cluster-management.io",
+				Resource: "managedclusters",
+			},
+		},
+	}
+	if err := h.Client.Create(ctx, access); err != nil {
+		h.Log.Error(err, "Failed to review the access of the fleet health request", "user", user.Username)
+		return http.StatusInternalServerError, "failed to review the access"
+	}
+	if !access.Status.Allowed {
+		return http.StatusForbidden, "user " + user.Username + " cannot list the managed clusters"
+	}
+	return http.StatusOK, ""
+}
@@ -0,0 +1,83 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package fleethealth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestHandler(t *testing.T) {
+	scheme := newScheme(t)
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newCluster("cluster1", true, nil)).Build()
+	// The token "admin" authenticates the admin user, allowed to list the managed clusters, and "viewer" another user.
+	reviews := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+			switch review := obj.(type) {
+			case *authenticationv1.TokenReview:
+				if review.Spec.Token == "admin" || review.Spec.Token == "viewer" {
+					review.Status.Authenticated = true
+					review.Status.User.Username = review.Spec.Token
+				}
+			case *authorizationv1.SubjectAccessReview:
+				attributes := review.Spec.ResourceAttributes
+				review.Status.Allowed = review.Spec.User == "admin" && attributes.Verb == "list" &&
+					attributes.Group == "cluster.open-cluster-management.io" && attributes.Resource == "managedclusters"
+			}
+			return nil
+		},
+	}).Build()
+	handler := &Handler{
+		Client: reviews,
+		Reader: reader,
+		NewSampleSource: func(context.Context) (SampleSource, error) {
+			return nil, errors.New("no observatorium API")
+		},
+		Log: logr.Discard(),
+	}
+
+	serve := func(method, target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/fleet-health", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/fleet-health", "invalid").Code)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/fleet-health", "viewer").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "/fleet-health", "admin").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/fleet-health?format=yaml", "admin").Code)
+
+	rec := serve(http.MethodGet, "/fleet-health", "admin")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"name": "cluster1"`)
+	// The failure to create the sample source is reported as a warning.
+	assert.Contains(t, rec.Body.String(), "no observatorium API")
+
+	rec = serve(http.MethodGet, "/fleet-health?format=csv", "admin")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(strings.Split(rec.Body.String(), "\n")[1], "cluster1,OpenShift,"))
+}
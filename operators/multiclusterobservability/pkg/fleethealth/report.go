@@ -0,0 +1,317 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+// Package fleethealth builds the observability health report of the managed clusters of the hub, joining the
+// ManagedClusters, the conditions of their ObservabilityAddon, the status of their ManifestWorks and the time of
+// their last metrics sample received by Thanos.
+package fleethealth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	mcov1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/status"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// workNameSuffix is the suffix of the ManifestWorks of the managed clusters, named after their namespace.
+	workNameSuffix = "-observability"
+	// The label of the ManifestWorks created by the operator.
+	ownerLabelKey   = "owner"
+	ownerLabelValue = "multicluster-observability-operator"
+	// disabledLabelKey disables the observability of a managed cluster when set to disabledLabelValue.
+	disabledLabelKey   = "observability"
+	disabledLabelValue = "disabled"
+
+	// defaultInterval is the metrics collection interval of the ObservabilityAddon, in seconds, when not set.
+	defaultInterval = 300
+	// minStaleAfter is the minimum age of the last sample of a cluster for its metrics to be reported as not recent,
+	// so that the clusters collecting their metrics often are not reported because of the step of the samples query.
+	minStaleAfter = 10 * time.Minute
+)
+
+// Category is the reason category of the health of a managed cluster.
+type Category string
+
+// The categories are listed by precedence: a cluster matching several of them is reported with the first one.
+const (
+	// CategoryClusterUnavailable is the category of the clusters whose ManagedCluster is not available.
+	CategoryClusterUnavailable Category = "ClusterUnavailable"
+	// CategoryDisabled is the category of the clusters whose observability is disabled.
+	CategoryDisabled Category = "Disabled"
+	// CategoryAddonMissing is the category of the clusters without ObservabilityAddon.
+	CategoryAddonMissing Category = "AddonMissing"
+	// CategoryManifestWorkFailed is the category of the clusters whose ManifestWorks are missing, not applied
+	// or not available.
+	CategoryManifestWorkFailed Category = "ManifestWorkFailed"
+	// CategoryCMOConflict is the category of the clusters where the metrics collector and the cluster monitoring
+	// operator keep overwriting each other's configuration.
+	CategoryCMOConflict Category = "CMOConflict"
+	// CategoryCertificateExpired is the category of the clusters failing to forward their metrics or alerts
+	// because of an expired certificate.
+	CategoryCertificateExpired Category = "CertificateExpired"
+	// CategoryForwardFailed is the category of the clusters failing to forward their metrics to the hub.
+	CategoryForwardFailed Category = "ForwardFailed"
+	// CategoryUpdateFailed is the category of the clusters failing to update their metrics collector.
+	CategoryUpdateFailed Category = "UpdateFailed"
+	// CategoryNotSupported is the category of the OpenShift clusters without Prometheus service.
+	CategoryNotSupported Category = "NotSupported"
+	// CategoryNotOCP is the category of the clusters which are not OpenShift and do not send metrics.
+	CategoryNotOCP Category = "NotOCP"
+	// CategoryNoRecentMetrics is the category of the clusters without recent metrics in Thanos.
+	CategoryNoRecentMetrics Category = "NoRecentMetrics"
+	// CategoryHealthy is the category of the clusters sending their metrics.
+	CategoryHealthy Category = "Healthy"
+)
+
+// The status of the ManifestWorks of a managed cluster.
+const (
+	WorkStatusAvailable = "Available"
+	WorkStatusApplied   = "Applied"
+	WorkStatusPending   = "Pending"
+	WorkStatusFailed    = "Failed"
+	WorkStatusNotFound  = "NotFound"
+)
+
+// ClusterHealth is the observability health of a managed cluster.
+type ClusterHealth struct {
+	Name             string `json:"name"`
+	Vendor           string `json:"vendor,omitempty"`
+	OpenshiftVersion string `json:"openshiftVersion,omitempty"`
+	// Available is whether the ManagedCluster is available.
+	Available bool     `json:"available"`
+	Category  Category `json:"category"`
+	// Message explains the category.
+	Message string `json:"message,omitempty"`
+	// AddonReason and AddonMessage are the reason and message of the aggregated condition of the ObservabilityAddon.
+	AddonReason  string `json:"addonReason,omitempty"`
+	AddonMessage string `json:"addonMessage,omitempty"`
+	// ManifestWorkStatus is empty for the local cluster, whose resources are not deployed by ManifestWorks.
+	ManifestWorkStatus string `json:"manifestWorkStatus,omitempty"`
+	// LastSampleTime is the time of the last metrics sample of the cluster received by Thanos, within the window
+	// of the samples query.
+	LastSampleTime *time.Time `json:"lastSampleTime,omitempty"`
+}
+
+// Report is the observability health report of the managed clusters.
+type Report struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Summary counts the clusters by category.
+	Summary  map[Category]int `json:"summary"`
+	Clusters []ClusterHealth  `json:"clusters"`
+	// Warnings reports the data missing from the report, such as the last samples when Thanos could not be queried.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Build builds the health report of the managed clusters at the given time. When the samples source is nil or fails,
+// the report is built without the last samples, and the clusters are not checked for recent metrics.
+func Build(ctx context.Context, c client.Reader, samples SampleSource, now time.Time) (*Report, error) {
+	clusters := &clusterv1.ManagedClusterList{}
+	if err := c.List(ctx, clusters); err != nil {
+		return nil, fmt.Errorf("failed to list the managed clusters: %w", err)
+	}
+	addons := &mcov1beta1.ObservabilityAddonList{}
+	if err := c.List(ctx, addons); err != nil {
+		return nil, fmt.Errorf("failed to list the ObservabilityAddons: %w", err)
+	}
+	works := &workv1.ManifestWorkList{}
+	if err := c.List(ctx, works, client.MatchingLabels{ownerLabelKey: ownerLabelValue}); err != nil {
+		return nil, fmt.Errorf("failed to list the ManifestWorks: %w", err)
+	}
+
+	report := &Report{GeneratedAt: now.UTC(), Summary: map[Category]int{}, Clusters: []ClusterHealth{}}
+	var lastSamples map[string]time.Time
+	if samples != nil {
+		var err error
+		if lastSamples, err = samples.LastSamples(ctx, now); err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("the last samples are not reported: %v", err))
+		}
+	} else {
+		report.Warnings = append(report.Warnings, "the last samples are not reported: no metrics query endpoint")
+	}
+
+	addonsByNamespace := make(map[string]*mcov1beta1.ObservabilityAddon, len(addons.Items))
+	for i := range addons.Items {
+		addonsByNamespace[addons.Items[i].Namespace] = &addons.Items[i]
+	}
+	worksByNamespace := map[string][]*workv1.ManifestWork{}
+	for i := range works.Items {
+		work := &works.Items[i]
+		if strings.HasPrefix(work.Name, work.Namespace+workNameSuffix) {
+			worksByNamespace[work.Namespace] = append(worksByNamespace[work.Namespace], work)
+		}
+	}
+
+	for i := range clusters.Items {
+		cluster := &clusters.Items[i]
+		local := cluster.Labels["local-cluster"] == "true"
+		namespace := cluster.Name
+		if local {
+			// The resources of the local cluster live in the namespace of the operands.
+			namespace = config.GetDefaultNamespace()
+		}
+		var lastSample *time.Time
+		if sample, ok := lastSamples[cluster.Name]; ok {
+			lastSample = &sample
+		}
+		health := clusterHealth(cluster, addonsByNamespace[namespace], worksByNamespace[namespace], local,
+			lastSample, lastSamples != nil, now)
+		report.Clusters = append(report.Clusters, health)
+		report.Summary[health.Category]++
+	}
+	slices.SortFunc(report.Clusters, func(a, b ClusterHealth) int { return strings.Compare(a.Name, b.Name) })
+	return report, nil
+}
+
+// clusterHealth returns the health of a managed cluster. The recent metrics are only checked when the last samples
+// are known.
+func clusterHealth(cluster *clusterv1.ManagedCluster, addon *mcov1beta1.ObservabilityAddon,
+	works []*workv1.ManifestWork, local bool, lastSample *time.Time, samplesKnown bool, now time.Time,
+) ClusterHealth {
+	health := ClusterHealth{
+		Name:             cluster.Name,
+		Vendor:           cluster.Labels["vendor"],
+		OpenshiftVersion: cluster.Labels["openshiftVersion"],
+		Available:        meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1.ManagedClusterConditionAvailable),
+		LastSampleTime:   lastSample,
+	}
+	if addon != nil {
+		if condition := addonCondition(addon); condition != nil {
+			health.AddonReason = condition.Reason
+			health.AddonMessage = condition.Message
+		}
+	}
+	var workMessage string
+	if !local {
+		health.ManifestWorkStatus, workMessage = manifestWorkStatus(works)
+	}
+
+	switch {
+	case !health.Available:
+		health.Category = CategoryClusterUnavailable
+		health.Message = "the ManagedCluster is not available"
+	case cluster.Labels[disabledLabelKey] == disabledLabelValue:
+		health.Category = CategoryDisabled
+		health.Message = fmt.Sprintf("the ManagedCluster has the label %s=%s", disabledLabelKey, disabledLabelValue)
+	case health.AddonReason == string(status.Disabled):
+		health.Category = CategoryDisabled
+		health.Message = health.AddonMessage
+	case addon == nil:
+		health.Category = CategoryAddonMissing
+		health.Message = "the ObservabilityAddon is not found"
+	case health.ManifestWorkStatus == WorkStatusNotFound || health.ManifestWorkStatus == WorkStatusFailed:
+		health.Category = CategoryManifestWorkFailed
+		health.Message = workMessage
+	case health.AddonReason == string(status.CmoReconcileLoopDetected):
+		health.Category = CategoryCMOConflict
+		health.Message = health.AddonMessage
+	case (health.AddonReason == string(status.ForwardFailed) || health.AddonReason == string(status.UpdateFailed)) &&
+		isCertificateExpired(health.AddonMessage):
+		health.Category = CategoryCertificateExpired
+		health.Message = health.AddonMessage
+	case health.AddonReason == string(status.ForwardFailed):
+		health.Category = CategoryForwardFailed
+		health.Message = health.AddonMessage
+	case health.AddonReason == string(status.UpdateFailed):
+		health.Category = CategoryUpdateFailed
+		health.Message = health.AddonMessage
+	case health.AddonReason == string(status.NotSupported) && !isOpenShift(cluster):
+		health.Category = CategoryNotOCP
+		health.Message = health.AddonMessage
+	case health.AddonReason == string(status.NotSupported):
+		health.Category = CategoryNotSupported
+		health.Message = health.AddonMessage
+	case samplesKnown && !isRecent(lastSample, addon, now):
+		health.Category = CategoryNoRecentMetrics
+		if !isOpenShift(cluster) {
+			health.Category = CategoryNotOCP
+		}
+		health.Message = "no metrics sample received recently"
+		if lastSample != nil {
+			health.Message = fmt.Sprintf("no metrics sample received since %s", lastSample.UTC().Format(time.RFC3339))
+		}
+	default:
+		health.Category = CategoryHealthy
+	}
+	return health
+}
+
+// addonCondition returns the aggregated condition of the ObservabilityAddon which is true, the most recent one when
+// there are several.
+func addonCondition(addon *mcov1beta1.ObservabilityAddon) *mcov1beta1.StatusCondition {
+	var found *mcov1beta1.StatusCondition
+	for i := range addon.Status.Conditions {
+		condition := &addon.Status.Conditions[i]
+		if condition.Status != metav1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case "Available", "Progressing", "Degraded":
+		default:
+			continue
+		}
+		if found == nil || condition.LastTransitionTime.After(found.LastTransitionTime.Time) {
+			found = condition
+		}
+	}
+	return found
+}
+
+// manifestWorkStatus returns the status of the ManifestWorks of a managed cluster, the worst status of its works,
+// and a message explaining the failures.
+func manifestWorkStatus(works []*workv1.ManifestWork) (string, string) {
+	if len(works) == 0 {
+		return WorkStatusNotFound, "the ManifestWork is not found"
+	}
+	result := WorkStatusAvailable
+	for _, work := range works {
+		applied := meta.FindStatusCondition(work.Status.Conditions, workv1.WorkApplied)
+		available := meta.FindStatusCondition(work.Status.Conditions, workv1.WorkAvailable)
+		switch {
+		case applied != nil && applied.Status == metav1.ConditionFalse:
+			return WorkStatusFailed, fmt.Sprintf("the ManifestWork %s is not applied: %s", work.Name, applied.Message)
+		case available != nil && available.Status == metav1.ConditionFalse:
+			return WorkStatusFailed, fmt.Sprintf("the ManifestWork %s is not available: %s", work.Name, available.Message)
+		case applied == nil || applied.Status != metav1.ConditionTrue:
+			result = WorkStatusPending
+		case (available == nil || available.Status != metav1.ConditionTrue) && result != WorkStatusPending:
+			result = WorkStatusApplied
+		}
+	}
+	return result, ""
+}
+
+// isCertificateExpired returns whether the message of a condition reports an expired certificate, as reported by
+// the TLS client or by the server rejecting the client certificate.
+func isCertificateExpired(message string) bool {
+	return strings.Contains(message, "certificate has expired") || strings.Contains(message, "expired certificate")
+}
+
+func isOpenShift(cluster *clusterv1.ManagedCluster) bool {
+	return cluster.Labels["vendor"] == "OpenShift" || cluster.Labels["openshiftVersion"] != ""
+}
+
+// isRecent returns whether the last sample of a cluster is more recent than three collection intervals of its
+// ObservabilityAddon.
+func isRecent(lastSample *time.Time, addon *mcov1beta1.ObservabilityAddon, now time.Time) bool {
+	if lastSample == nil {
+		return false
+	}
+	interval := int32(defaultInterval)
+	if addon != nil && addon.Spec.Interval > 0 {
+		interval = addon.Spec.Interval
+	}
+	staleAfter := max(3*time.Duration(interval)*time.Second, minStaleAfter)
+	return now.Sub(*lastSample) <= staleAfter
+}
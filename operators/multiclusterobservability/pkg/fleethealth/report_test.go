@@ -0,0 +1,233 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package fleethealth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	mcov1beta1 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta1"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+type fakeSampleSource struct {
+	samples map[string]time.Time
+	err     error
+}
+
+func (s fakeSampleSource) LastSamples(context.Context, time.Time) (map[string]time.Time, error) {
+	return s.samples, s.err
+}
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clusterv1.Install(scheme))
+	require.NoError(t, workv1.Install(scheme))
+	require.NoError(t, mcov1beta1.AddToScheme(scheme))
+	return scheme
+}
+
+func newCluster(name string, available bool, labels map[string]string) *clusterv1.ManagedCluster {
+	status := metav1.ConditionTrue
+	if !available {
+		status = metav1.ConditionUnknown
+	}
+	if labels == nil {
+		labels = map[string]string{"vendor": "OpenShift", "openshiftVersion": "4.18.0"}
+	}
+	return &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Status: clusterv1.ManagedClusterStatus{Conditions: []metav1.Condition{
+			{Type: clusterv1.ManagedClusterConditionAvailable, Status: status},
+		}},
+	}
+}
+
+func newAddon(namespace, conditionType, reason, message string) *mcov1beta1.ObservabilityAddon {
+	addon := &mcov1beta1.ObservabilityAddon{
+		ObjectMeta: metav1.ObjectMeta{Name: "observability-addon", Namespace: namespace},
+	}
+	addon.Status.Conditions = []mcov1beta1.StatusCondition{
+		{Type: "MetricsCollector", Status: metav1.ConditionTrue, Reason: reason, Message: message},
+		{Type: conditionType, Status: metav1.ConditionTrue, Reason: reason, Message: message},
+	}
+	return addon
+}
+
+func newWork(namespace string, applied, available metav1.ConditionStatus) *workv1.ManifestWork {
+	return &workv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namespace + workNameSuffix,
+			Namespace: namespace,
+			Labels:    map[string]string{ownerLabelKey: ownerLabelValue},
+		},
+		Status: workv1.ManifestWorkStatus{Conditions: []metav1.Condition{
+			{Type: workv1.WorkApplied, Status: applied, Message: "failed to apply"},
+			{Type: workv1.WorkAvailable, Status: available},
+		}},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	recent := now.Add(-time.Minute)
+	old := now.Add(-time.Hour)
+	objects := []client.Object{
+		newCluster("healthy", true, nil),
+		newAddon("healthy", "Available", "ForwardSuccessful", "Metrics forwarded"),
+		newWork("healthy", metav1.ConditionTrue, metav1.ConditionTrue),
+
+		newCluster("unavailable", false, nil),
+
+		newCluster("disabled", true, map[string]string{"vendor": "OpenShift", "observability": "disabled"}),
+
+		newCluster("no-addon", true, nil),
+
+		newCluster("work-failed", true, nil),
+		newAddon("work-failed", "Available", "ForwardSuccessful", ""),
+		newWork("work-failed", metav1.ConditionFalse, metav1.ConditionUnknown),
+
+		newCluster("cmo", true, nil),
+		newAddon("cmo", "Degraded", "CMOReconcileLoopDetected", "the cluster monitoring operator reverts the config"),
+		newWork("cmo", metav1.ConditionTrue, metav1.ConditionTrue),
+
+		newCluster("expired", true, nil),
+		newAddon("expired", "Degraded", "ForwardFailed", "Post: tls: failed to verify certificate: x509: certificate has expired or is not yet valid"),
+		newWork("expired", metav1.ConditionTrue, metav1.ConditionTrue),
+
+		newCluster("forward", true, nil),
+		newAddon("forward", "Degraded", "ForwardFailed", "503 Service Unavailable"),
+		newWork("forward", metav1.ConditionTrue, metav1.ConditionTrue),
+
+		newCluster("eks", true, map[string]string{"vendor": "EKS"}),
+		newAddon("eks", "Degraded", "NotSupported", "Prometheus service not found"),
+		newWork("eks", metav1.ConditionTrue, metav1.ConditionTrue),
+
+		newCluster("stale", true, nil),
+		newAddon("stale", "Available", "ForwardSuccessful", ""),
+		newWork("stale", metav1.ConditionTrue, metav1.ConditionTrue),
+
+		newCluster("local", true, map[string]string{"vendor": "OpenShift", "local-cluster": "true"}),
+		newAddon(config.GetDefaultNamespace(), "Available", "ForwardSuccessful", ""),
+	}
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(objects...).
+		WithStatusSubresource(&mcov1beta1.ObservabilityAddon{}).Build()
+
+	samples := fakeSampleSource{samples: map[string]time.Time{"healthy": recent, "stale": old, "local": recent}}
+	report, err := Build(context.Background(), c, samples, now)
+	require.NoError(t, err)
+	assert.Empty(t, report.Warnings)
+
+	categories := map[string]Category{}
+	for _, cluster := range report.Clusters {
+		categories[cluster.Name] = cluster.Category
+	}
+	assert.Equal(t, map[string]Category{
+		"healthy":     CategoryHealthy,
+		"unavailable": CategoryClusterUnavailable,
+		"disabled":    CategoryDisabled,
+		"no-addon":    CategoryAddonMissing,
+		"work-failed": CategoryManifestWorkFailed,
+		"cmo":         CategoryCMOConflict,
+		"expired":     CategoryCertificateExpired,
+		"forward":     CategoryForwardFailed,
+		"eks":         CategoryNotOCP,
+		"stale":       CategoryNoRecentMetrics,
+		"local":       CategoryHealthy,
+	}, categories)
+	assert.Equal(t, 2, report.Summary[CategoryHealthy])
+	assert.Equal(t, "cmo", report.Clusters[0].Name)
+
+	for _, cluster := range report.Clusters {
+		switch cluster.Name {
+		case "healthy":
+			assert.Equal(t, WorkStatusAvailable, cluster.ManifestWorkStatus)
+			assert.Equal(t, "ForwardSuccessful", cluster.AddonReason)
+			require.NotNil(t, cluster.LastSampleTime)
+			assert.Equal(t, recent, *cluster.LastSampleTime)
+		case "work-failed":
+			assert.Equal(t, WorkStatusFailed, cluster.ManifestWorkStatus)
+			assert.Contains(t, cluster.Message, "failed to apply")
+		case "local":
+			// The local cluster has no ManifestWork.
+			assert.Empty(t, cluster.ManifestWorkStatus)
+		case "stale":
+			assert.Contains(t, cluster.Message, old.Format(time.RFC3339))
+		}
+	}
+
+	// Without the last samples, the clusters are not checked for recent metrics.
+	report, err = Build(context.Background(), c, fakeSampleSource{err: errors.New("connection refused")}, now)
+	require.NoError(t, err)
+	require.Len(t, report.Warnings, 1)
+	assert.Contains(t, report.Warnings[0], "connection refused")
+	for _, cluster := range report.Clusters {
+		if cluster.Name == "stale" {
+			assert.Equal(t, CategoryHealthy, cluster.Category)
+			assert.Nil(t, cluster.LastSampleTime)
+		}
+	}
+}
+
+func TestIsRecent(t *testing.T) {
+	addon := &mcov1beta1.ObservabilityAddon{}
+	assert.False(t, isRecent(nil, addon, now))
+	// Three default intervals.
+	assert.True(t, isRecent(ptr(now.Add(-15*time.Minute)), addon, now))
+	assert.False(t, isRecent(ptr(now.Add(-16*time.Minute)), addon, now))
+	// At least the minimum age for the short intervals.
+	addon.Spec.Interval = 30
+	assert.True(t, isRecent(ptr(now.Add(-9*time.Minute)), addon, now))
+	assert.False(t, isRecent(ptr(now.Add(-11*time.Minute)), addon, now))
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestWrite(t *testing.T) {
+	sample := time.Date(2026, 10, 19, 11, 59, 0, 0, time.UTC)
+	report := &Report{
+		GeneratedAt: now,
+		Summary:     map[Category]int{CategoryHealthy: 1, CategoryForwardFailed: 1},
+		Clusters: []ClusterHealth{
+			{Name: "a", Vendor: "OpenShift", Available: true, Category: CategoryHealthy, LastSampleTime: &sample},
+			{Name: "b", Available: true, Category: CategoryForwardFailed, Message: "503, retrying", AddonReason: "ForwardFailed"},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, report.Write(buf, FormatCSV))
+	assert.Equal(t, strings.Join([]string{
+		"cluster,vendor,openshiftVersion,available,category,message,addonReason,addonMessage,manifestWorkStatus,lastSampleTime",
+		"a,OpenShift,,true,Healthy,,,,,2026-10-19T11:59:00Z",
+		`b,,,true,ForwardFailed,"503, retrying",ForwardFailed,,,`,
+		"",
+	}, "\n"), buf.String())
+
+	buf.Reset()
+	require.NoError(t, report.Write(buf, FormatJSON))
+	decoded := &Report{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), decoded))
+	assert.Equal(t, 1, decoded.Summary[CategoryForwardFailed])
+	assert.Equal(t, sample, *decoded.Clusters[0].LastSampleTime)
+
+	assert.Error(t, report.Write(buf, "yaml"))
+}
@@ -0,0 +1,106 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package fleethealth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// lastSamplesQuery returns the time of the last sample of up of each cluster over the last hour. The subquery
+	// step matches the default lookback delta, so that no sample of the window is missed.
+	lastSamplesQuery = "max by (cluster) (max_over_time(timestamp(up)[1h:5m]))"
+	// queryTimeout is the timeout of the samples query.
+	queryTimeout = 30 * time.Second
+)
+
+// SampleSource returns the time of the last metrics sample of the managed clusters, keyed by cluster name.
+type SampleSource interface {
+	LastSamples(ctx context.Context, now time.Time) (map[string]time.Time, error)
+}
+
+// querySampleSource queries the last samples from a Prometheus compatible API.
+type querySampleSource struct {
+	api v1.API
+}
+
+// NewQuerySampleSource returns a source querying the last samples from the Prometheus compatible API at the given
+// address, such as the rbac-query-proxy or the observatorium API, with the given round tripper.
+func NewQuerySampleSource(address string, rt http.RoundTripper) (SampleSource, error) {
+	c, err := api.NewClient(api.Config{Address: address, RoundTripper: rt})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the query client: %w", err)
+	}
+	return &querySampleSource{api: v1.NewAPI(c)}, nil
+}
+
+// NewObservatoriumSampleSource returns a source querying the observatorium API of the hub, in the default tenant,
+// authenticated with the client certificate of Grafana as the rbac-query-proxy does.
+func NewObservatoriumSampleSource(ctx context.Context, c client.Client) (SampleSource, error) {
+	ca, err := config.GetObsAPIServerCA(c)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(ca)) {
+		return nil, fmt.Errorf("no certificate in the secret %s", config.ServerCACerts)
+	}
+	certs := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: config.GrafanaCerts, Namespace: config.GetDefaultNamespace()}, certs); err != nil {
+		return nil, fmt.Errorf("failed to get the client certificate secret %s: %w", config.GrafanaCerts, err)
+	}
+	cert, err := tls.X509KeyPair(certs.Data["tls.crt"], certs.Data["tls.key"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate in the secret %s: %w", config.GrafanaCerts, err)
+	}
+	// The source is created for each report to pick up the rotated certificates, so it does not keep the connections.
+	rt := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			RootCAs:      pool,
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		},
+		DisableKeepAlives: true,
+	}
+	address := fmt.Sprintf("https://%s%s.%s.svc.cluster.local:8080/api/metrics/v1/%s",
+		config.GetOperandNamePrefix(), config.ObservatoriumAPI, config.GetDefaultNamespace(), config.GetDefaultTenantName())
+	return NewQuerySampleSource(address, rt)
+}
+
+func (s *querySampleSource) LastSamples(ctx context.Context, now time.Time) (map[string]time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	result, _, err := s.api.Query(ctx, lastSamplesQuery, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the last samples: %w", err)
+	}
+	vector, ok := result.(model.Vector)
+	if !ok {
+		return nil, errors.New("unexpected result type of the last samples query: " + result.Type().String())
+	}
+	samples := make(map[string]time.Time, len(vector))
+	for _, sample := range vector {
+		cluster := string(sample.Metric["cluster"])
+		if cluster == "" {
+			continue
+		}
+		samples[cluster] = time.UnixMilli(int64(float64(sample.Value) * 1000)).UTC()
+	}
+	return samples, nil
+}
@@ -0,0 +1,40 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package fleethealth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerySampleSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		assert.Equal(t, "/api/metrics/v1/default/api/v1/query", req.URL.Path)
+		assert.Equal(t, lastSamplesQuery, req.Form.Get("query"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"cluster":"cluster1"},"value":[1792411200,"1792411140.5"]},
+			{"metric":{},"value":[1792411200,"1792411140"]}
+		]}}`))
+	}))
+	defer server.Close()
+
+	source, err := NewQuerySampleSource(server.URL+"/api/metrics/v1/default", http.DefaultTransport)
+	require.NoError(t, err)
+	samples, err := source.LastSamples(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Time{"cluster1": time.UnixMilli(1792411140500).UTC()}, samples)
+
+	server.Close()
+	_, err = source.LastSamples(context.Background(), now)
+	assert.Error(t, err)
+}
@@ -7,6 +7,7 @@ package rendering
 import (
 	"context"
 	"fmt"
+	"path"
 	"strings"
 
 	mcoconfig "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
@@ -19,6 +20,9 @@ import (
 	"sigs.k8s.io/kustomize/api/resource"
 )
 
+// federationTLSDir is the directory of the mTLS credentials of the federation peers in the rbac-query-proxy.
+const federationTLSDir = "/var/rbac_proxy/federation"
+
 func (r *MCORenderer) newProxyRenderer() {
 	r.renderProxyFns = map[string]rendererutil.RenderFn{
 		"Deployment":            r.renderProxyDeployment,
@@ -71,6 +75,25 @@ func (r *MCORenderer) renderProxyDeployment(ctx context.Context, res *resource.R
 	}
 	queryTimeout := mcoconfig.GetGrafanaQueryTimeout(r.cr)
 	args0 = append(args0, fmt.Sprintf("--proxy-timeout=%s", queryTimeout))
+	if federation := r.cr.Spec.Federation; federation != nil {
+		// The peers' mTLS credentials are mounted under /var/rbac_proxy/federation/<peer name>.
+		args0 = append(args0, "--federation-hub="+federation.HubName)
+		for i, peer := range federation.Peers {
+			args0 = append(args0, fmt.Sprintf("--federation-peer=%s=%s", peer.Name, peer.URL))
+			volumeName := fmt.Sprintf("federation-peer-%d", i)
+			spec.Volumes = append(spec.Volumes, corev1.Volume{
+				Name: volumeName,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: peer.TLSSecretName},
+				},
+			})
+			spec.Containers[0].VolumeMounts = append(spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+				Name:      volumeName,
+				MountPath: path.Join(federationTLSDir, peer.Name),
+				ReadOnly:  true,
+			})
+		}
+	}
 	args0, err = util.SetTLSSecurityConfiguration(ctx, args0, "--tls-cipher-suites=", "--tls-min-version=")
 	if err != nil {
 		return nil, err
@@ -0,0 +1,72 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package rendering
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	imagev1 "github.com/openshift/api/image/v1"
+	fakeimageclient "github.com/openshift/client-go/image/clientset/versioned/fake"
+	fakeimagev1client "github.com/openshift/client-go/image/clientset/versioned/typed/image/v1/fake"
+	mcov1beta2 "github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/api/v1beta2"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/operators/multiclusterobservability/pkg/rendering/templates"
+	templatesutil "github.com/stolostron/multicluster-observability-operator/operators/pkg/rendering/templates"
+	"github.com/stolostron/multicluster-observability-operator/operators/pkg/util/tlstesting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProxyRendererFederation(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Setenv(templatesutil.TemplatesPathEnvVar, filepath.Join(wd, "..", "..", "manifests"))
+
+	mco := makeBaseMco()
+	mco.Spec.Federation = &mcov1beta2.FederationSpec{
+		HubName: "hub-a",
+		Peers: []mcov1beta2.FederationPeer{
+			{Name: "hub-b", URL: "https://observatorium-api.hub-b.example.com", TLSSecretName: "hub-b-certs"},
+		},
+	}
+	kubeClient := tlstesting.NewFakeTLSClientBuilder().Build(t)
+	imageClient := &fakeimagev1client.FakeImageV1{Fake: &(fakeimageclient.NewSimpleClientset().Fake)}
+	_, err = imageClient.ImageStreams(config.OauthProxyImageStreamNamespace).Create(t.Context(),
+		&imagev1.ImageStream{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      config.OauthProxyImageStreamName,
+				Namespace: config.OauthProxyImageStreamNamespace,
+			},
+			Spec: imagev1.ImageStreamSpec{
+				Tags: []imagev1.TagReference{{
+					Name: "v4.4",
+					From: &corev1.ObjectReference{Kind: "DockerImage", Name: "quay.io/openshift-release-dev/ocp-v4.0-art-dev"},
+				}},
+			},
+		}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	renderer := NewMCORenderer(mco, kubeClient, imageClient)
+
+	proxyTemplates, err := templates.GetOrLoadProxyTemplates(templatesutil.GetTemplateRenderer())
+	require.NoError(t, err)
+	objects, err := renderer.renderProxyTemplates(t.Context(), proxyTemplates, "namespace", map[string]string{"test": "test"})
+	require.NoError(t, err)
+
+	dep := getResource[*appsv1.Deployment](objects, "")
+	container := dep.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, container.Args, "--federation-hub=hub-a")
+	assert.Contains(t, container.Args, "--federation-peer=hub-b=https://observatorium-api.hub-b.example.com")
+	assert.Contains(t, dep.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name:         "federation-peer-0",
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "hub-b-certs"}},
+	})
+	assert.Contains(t, container.VolumeMounts,
+		corev1.VolumeMount{Name: "federation-peer-0", MountPath: "/var/rbac_proxy/federation/hub-b", ReadOnly: true})
+}
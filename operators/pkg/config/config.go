@@ -19,6 +19,9 @@ const (
 
 	CollectorImage               = "COLLECTOR_IMAGE"
 	InstallPrometheus            = "INSTALL_PROM"
+	MetricsProfile               = "METRICS_PROFILE"
+	MetricsBufferRetention       = "METRICS_BUFFER_RETENTION"
+	CmoConfigDryRun              = "CMO_CONFIG_DRY_RUN"
 	PullSecret                   = "PULL_SECRET"
 	ImageConfigMap               = "images-list"
 	AllowlistConfigMapName       = "observability-metrics-allowlist"
@@ -35,6 +38,18 @@ const (
 	LegacyCleanerManagerName           = "legacy-cleaner"
 )
 
+// The metrics profiles of the clusters without OpenShift monitoring, in which a Prometheus in agent mode
+// remote writes the metrics to the hub instead of a Prometheus federated by the metrics collector.
+const (
+	// PrometheusAgentProfile is the metrics profile of the managed Kubernetes services, set by the hub.
+	PrometheusAgentProfile = "prometheus-agent"
+	// MicroShiftProfile is the metrics profile of the MicroShift clusters, detected by the endpoint operator.
+	// The agent scrapes the kubelet and the embedded API server directly and uses the reduced MicroShift allowlist.
+	MicroShiftProfile = "microshift"
+	// MicroShiftMetricsConfigMapKey is the key of the allowlist of the MicroShift profile in the allowlist configmap.
+	MicroShiftMetricsConfigMapKey = "microshift_metrics_list.yaml"
+)
+
 const (
 	OCPClusterMonitoringNamespace         = "openshift-monitoring"
 	OCPClusterMonitoringConfigMapName     = "cluster-monitoring-config"
@@ -5,6 +5,8 @@
 package config
 
 import (
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -19,6 +21,33 @@ type HubInfo struct {
 	AlertmanagerRouterCA     string `yaml:"alertmanager-router-ca"`
 	UWMAlertingDisabled      bool   `yaml:"uwm-alerting-disabled"`
 	HubClusterID             string `yaml:"hub-cluster-id"`
+	// MaintenanceWindows are the maintenance windows of the managed cluster, in progress or next, during which
+	// the alerts are not forwarded to the hub.
+	MaintenanceWindows []MaintenanceWindow `yaml:"maintenance-windows,omitempty"`
+}
+
+// MaintenanceWindow is a maintenance window of a managed cluster.
+type MaintenanceWindow struct {
+	Start time.Time `yaml:"start"`
+	End   time.Time `yaml:"end"`
+}
+
+// InMaintenance reports whether a maintenance window is in progress at the given time, and returns the next start
+// or end of a window, zero when there is none.
+func (h *HubInfo) InMaintenance(now time.Time) (bool, time.Time) {
+	inMaintenance := false
+	var next time.Time
+	for _, window := range h.MaintenanceWindows {
+		if !now.Before(window.Start) && now.Before(window.End) {
+			inMaintenance = true
+		}
+		for _, t := range []time.Time{window.Start, window.End} {
+			if t.After(now) && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+	return inMaintenance, next
 }
 
 type RecordingRule struct {
@@ -57,4 +86,7 @@ type MetricsAllowlist struct {
 	RuleList             []RecordingRule    `yaml:"rules"` // deprecated
 	RecordingRuleList    []RecordingRule    `yaml:"recording_rules"`
 	CollectRuleGroupList []CollectRuleGroup `yaml:"collect_rules"`
+	// HostedControlPlaneComponentList lists the hypershift hosted control plane components scraped by the
+	// user workload monitoring. It is only read from the uwl allowlist.
+	HostedControlPlaneComponentList []string `yaml:"hosted_control_plane_components"`
 }
@@ -0,0 +1,73 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHubInfoInMaintenance(t *testing.T) {
+	at := func(value string) time.Time {
+		ts, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	hubInfo := &HubInfo{
+		MaintenanceWindows: []MaintenanceWindow{
+			{Start: at("2025-03-15T01:00:00Z"), End: at("2025-03-15T05:00:00Z")},
+			{Start: at("2025-03-15T04:00:00Z"), End: at("2025-03-15T06:00:00Z")},
+			{Start: at("2025-03-22T01:00:00Z"), End: at("2025-03-22T05:00:00Z")},
+		},
+	}
+
+	testCases := map[string]struct {
+		now           string
+		inMaintenance bool
+		next          string
+	}{
+		"before the windows": {
+			now:  "2025-03-15T00:00:00Z",
+			next: "2025-03-15T01:00:00Z",
+		},
+		"at the start of a window": {
+			now:           "2025-03-15T01:00:00Z",
+			inMaintenance: true,
+			next:          "2025-03-15T04:00:00Z",
+		},
+		"in overlapping windows": {
+			now:           "2025-03-15T04:30:00Z",
+			inMaintenance: true,
+			next:          "2025-03-15T05:00:00Z",
+		},
+		"at the end of the windows": {
+			now:  "2025-03-15T06:00:00Z",
+			next: "2025-03-22T01:00:00Z",
+		},
+		"after the windows": {
+			now: "2025-03-22T05:00:00Z",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			inMaintenance, next := hubInfo.InMaintenance(at(tc.now))
+			assert.Equal(t, tc.inMaintenance, inMaintenance)
+			if tc.next == "" {
+				assert.True(t, next.IsZero(), "next: %s", next)
+			} else {
+				assert.True(t, at(tc.next).Equal(next), "next: %s", next)
+			}
+		})
+	}
+
+	inMaintenance, next := (&HubInfo{}).InMaintenance(at("2025-03-15T00:00:00Z"))
+	assert.False(t, inMaintenance)
+	assert.True(t, next.IsZero())
+}
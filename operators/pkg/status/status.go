@@ -25,6 +25,9 @@ type Component string
 const (
 	MetricsCollector    Component = "MetricsCollector"
 	UwlMetricsCollector Component = "UwlMetricsCollector"
+	// CmoConfig reports the drift of the fields injected by the addon into the cluster-monitoring-config.
+	// It is not aggregated into the standard conditions of the ObservabilityAddon.
+	CmoConfig Component = "CMOConfig"
 )
 
 var ErrInvalidTransition = errors.New("invalid status transition")
@@ -43,6 +46,9 @@ var (
 	CmoReconcileLoopStopped  Reason = "CMOReconcileLoopStopped"
 	Disabled                 Reason = "Disabled"
 	NotSupported             Reason = "NotSupported"
+	CmoConfigDriftDetected   Reason = "CMOConfigDriftDetected"
+	CmoConfigInSync          Reason = "CMOConfigInSync"
+	CmoConfigDryRun          Reason = "CMOConfigDryRun"
 )
 
 // componentTransitions defines the valid transitions between component conditions
@@ -100,6 +106,22 @@ var componentTransitions = map[Reason]map[Reason]struct{}{
 		UpdateFailed:     {},
 		Disabled:         {},
 	},
+	// The messages of the CMOConfig component change with the fields, all the transitions are enabled
+	CmoConfigDriftDetected: {
+		CmoConfigDriftDetected: {},
+		CmoConfigInSync:        {},
+		CmoConfigDryRun:        {},
+	},
+	CmoConfigInSync: {
+		CmoConfigDriftDetected: {},
+		CmoConfigInSync:        {},
+		CmoConfigDryRun:        {},
+	},
+	CmoConfigDryRun: {
+		CmoConfigDriftDetected: {},
+		CmoConfigInSync:        {},
+		CmoConfigDryRun:        {},
+	},
 }
 
 // Status provides a method to update the status of the ObservabilityAddon for a specific component
@@ -52,6 +52,28 @@ func ParseAllowlistConfigMap(cm corev1.ConfigMap) (*operatorconfig.MetricsAllowl
 	return allowlist, uwlAllowlist, nil
 }
 
+// GetMicroShiftAllowList returns the allowlist of the MicroShift metrics profile of the allowlist configmap,
+// or nil when the configmap does not have one.
+func GetMicroShiftAllowList(client client.Client, name, namespace string) (*operatorconfig.MetricsAllowlist, error) {
+	found := &corev1.ConfigMap{}
+	err := client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, found)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := found.Data[operatorconfig.MicroShiftMetricsConfigMapKey]
+	if !ok {
+		return nil, nil
+	}
+	allowlist := &operatorconfig.MetricsAllowlist{}
+	if err := yaml.Unmarshal([]byte(data), allowlist); err != nil {
+		log.Error(err, "Failed to unmarshal microshift_metrics_list.yaml data in configmap ",
+			"namespace", found.Namespace, "name", found.Name)
+		return nil, err
+	}
+	return allowlist, nil
+}
+
 func MergeAllowlist(allowlist, customAllowlist, uwlAllowlist,
 	customUwlAllowlist *operatorconfig.MetricsAllowlist) (*operatorconfig.MetricsAllowlist,
 	*operatorconfig.MetricsAllowlist,
@@ -72,6 +94,10 @@ func MergeAllowlist(allowlist, customAllowlist, uwlAllowlist,
 	maps.Copy(allowlist.RenameMap, customAllowlist.RenameMap)
 	uwlAllowlist.NameList = mergeMetrics(uwlAllowlist.NameList, customUwlAllowlist.NameList)
 	uwlAllowlist.MatchList = mergeMetrics(uwlAllowlist.MatchList, customUwlAllowlist.MatchList)
+	if uwlAllowlist.HostedControlPlaneComponentList != nil || customUwlAllowlist.HostedControlPlaneComponentList != nil {
+		uwlAllowlist.HostedControlPlaneComponentList = mergeMetrics(uwlAllowlist.HostedControlPlaneComponentList,
+			customUwlAllowlist.HostedControlPlaneComponentList)
+	}
 	uwlAllowlist.RuleList = append(uwlAllowlist.RuleList, customUwlAllowlist.RuleList...)
 	uwlAllowlist.RecordingRuleList = append(uwlAllowlist.RecordingRuleList, customUwlAllowlist.RecordingRuleList...)
 	if uwlAllowlist.RenameMap == nil {
@@ -52,6 +52,9 @@ collect_rules:
 names:
   - uwl_a
   - uwl_b
+hosted_control_plane_components:
+  - etcd
+  - kube-apiserver
 `,
 		},
 	}
@@ -94,6 +97,9 @@ collect_rules:
 names:
   - custom_uwl_a
   - custom_uwl_b
+hosted_control_plane_components:
+  - -kube-apiserver
+  - kube-scheduler
 `,
 		},
 	}
@@ -119,6 +125,34 @@ func TestMergeAllowList(t *testing.T) {
 	if !slices.Contains(uwlList.NameList, "custom_uwl_a") {
 		t.Error("metrics custom_uwl_a not merged into uwl allowlist")
 	}
+	if !reflect.DeepEqual(uwlList.HostedControlPlaneComponentList, []string{"etcd", "kube-scheduler"}) {
+		t.Errorf("hosted control plane components not merged into uwl allowlist: %v", uwlList.HostedControlPlaneComponentList)
+	}
+}
+
+func TestGetMicroShiftAllowList(t *testing.T) {
+	cm := getAllowlistCM()
+	c := fake.NewClientBuilder().WithRuntimeObjects(cm).Build()
+	allowlist, err := GetMicroShiftAllowList(c, operatorconfig.AllowlistConfigMapName, config.GetDefaultNamespace())
+	if err != nil {
+		t.Errorf("Failed to get microshift allowlist: (%v)", err)
+	}
+	if allowlist != nil {
+		t.Errorf("microshift allowlist should be nil when the configmap has none: %v", allowlist)
+	}
+
+	cm.Data[operatorconfig.MicroShiftMetricsConfigMapKey] = `
+names:
+  - microshift_a
+`
+	c = fake.NewClientBuilder().WithRuntimeObjects(cm).Build()
+	allowlist, err = GetMicroShiftAllowList(c, operatorconfig.AllowlistConfigMapName, config.GetDefaultNamespace())
+	if err != nil {
+		t.Errorf("Failed to get microshift allowlist: (%v)", err)
+	}
+	if allowlist == nil || !reflect.DeepEqual(allowlist.NameList, []string{"microshift_a"}) {
+		t.Errorf("unexpected microshift allowlist: %v", allowlist)
+	}
 }
 
 func TestMergeMetrics(t *testing.T) {
@@ -12,15 +12,19 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/cache"
+	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/guardrail"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/informer"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/proxy"
 	"github.com/stolostron/rbac-api-utils/pkg/rbac"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
@@ -41,6 +45,12 @@ type proxyConf struct {
 	tlsMinVersion      string
 	tlsCipherSuites    []string
 	proxyTimeout       time.Duration
+	alertmanagerServer string
+	alertmanagerCaFile string
+	alertmanagerToken  string
+	federationHub      string
+	federationPeers    []string
+	federationTLSDir   string
 }
 
 func main() {
@@ -73,6 +83,19 @@ func run() error {
 		"Comma-separated list of cipher suites for the server. Values are from tls package constants (https://golang.org/pkg/crypto/tls/#pkg-constants). If omitted, the default Go cipher suites will be used",
 	)
 	flagset.DurationVar(&cfg.proxyTimeout, "proxy-timeout", 5*time.Minute, "The timeout for the proxy to wait for the downstream server response.")
+	flagset.StringVar(&cfg.alertmanagerServer, "alertmanager-server", "",
+		"The address of the Alertmanager server. If unset, the Alertmanager alerts and silences APIs are not proxied.")
+	flagset.StringVar(&cfg.alertmanagerCaFile, "alertmanager-ca-file", "/var/run/secrets/kubernetes.io/serviceaccount/service-ca.crt",
+		"The path to the CA certificate file for connecting to the Alertmanager server.")
+	flagset.StringVar(&cfg.alertmanagerToken, "alertmanager-token-file", "/var/run/secrets/kubernetes.io/serviceaccount/token",
+		"The path to the bearer token file used to authenticate the proxy against the Alertmanager server.")
+
+	flagset.StringVar(&cfg.federationHub, "federation-hub", "",
+		"The name of this hub in the global query mode. If unset, the /federation path is not served.")
+	flagset.StringArrayVar(&cfg.federationPeers, "federation-peer", nil,
+		"A peer hub of the global query mode, as <name>=<observatorium API URL>. Can be repeated.")
+	flagset.StringVar(&cfg.federationTLSDir, "federation-tls-dir", "/var/rbac_proxy/federation",
+		"The directory holding the ca.crt, tls.crt and tls.key files of each peer hub, in a subdirectory named after the peer.")
 
 	_ = flagset.Parse(os.Args[1:])
 
@@ -87,6 +110,7 @@ func run() error {
 	klog.Infof("proxy server will running on: %s", cfg.listenAddress)
 	klog.Infof("metrics server is: %s", cfg.metricServer)
 	klog.Infof("kubeconfig is: %s", cfg.kubeconfigLocation)
+	klog.Infof("alertmanager server is: %s", cfg.alertmanagerServer)
 
 	// create a context that is canceled on SIGINT/SIGTERM
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
@@ -137,6 +161,59 @@ func run() error {
 		return fmt.Errorf("failed to create proxy: %w", err)
 	}
 
+	if cfg.alertmanagerServer != "" {
+		alertmanagerURL, err := url.Parse(cfg.alertmanagerServer)
+		if err != nil {
+			return fmt.Errorf("failed to parse alertmanager server url: %w", err)
+		}
+
+		// The proxy authenticates with its own service account, access is enforced per user by the proxy.
+		alertmanagerTransport, err := rest.TransportFor(&rest.Config{
+			BearerTokenFile: cfg.alertmanagerToken,
+			TLSClientConfig: rest.TLSClientConfig{CAFile: cfg.alertmanagerCaFile},
+			Timeout:         cfg.proxyTimeout,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create alertmanager transport: %w", err)
+		}
+		p.SetAlertmanager(alertmanagerURL, alertmanagerTransport)
+	}
+
+	if cfg.federationHub != "" {
+		var peers []proxy.FederationPeer
+		for _, peerFlag := range cfg.federationPeers {
+			name, rawURL, found := strings.Cut(peerFlag, "=")
+			if !found || name == "" {
+				return fmt.Errorf("invalid --federation-peer %q, expected <name>=<url>", peerFlag)
+			}
+			peerURL, err := url.Parse(rawURL)
+			if err != nil {
+				return fmt.Errorf("failed to parse the url of the federation peer %s: %w", name, err)
+			}
+			peerTransport, err := proxy.NewTransport(&proxy.TLSOptions{
+				CaFile:          filepath.Join(cfg.federationTLSDir, name, "ca.crt"),
+				KeyFile:         filepath.Join(cfg.federationTLSDir, name, "tls.key"),
+				CertFile:        filepath.Join(cfg.federationTLSDir, name, "tls.crt"),
+				PollingInterval: 15 * time.Second,
+				ProxyTimeout:    cfg.proxyTimeout,
+				MinTLSVersion:   cfg.tlsMinVersion,
+				CipherSuites:    cfg.tlsCipherSuites,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to set tls transport of the federation peer %s: %w", name, err)
+			}
+			defer peerTransport.Close()
+			peers = append(peers, proxy.FederationPeer{Name: name, URL: peerURL, Transport: peerTransport})
+		}
+		klog.Infof("federation hub is: %s, with %d peers", cfg.federationHub, len(peers))
+		p.SetFederation(cfg.federationHub, peers)
+	}
+
+	// watch the query guardrails configuration
+	guardrails := guardrail.NewPolicyStore(ctx, kubeClient)
+	guardrails.Run()
+	p.SetGuardrails(guardrails)
+
 	handlers := http.NewServeMux()
 	handlers.Handle("/", p)
 	s := http.Server{
@@ -0,0 +1,215 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+// Package alertquery is responsible for enforcing multicluster Role-Based Access Control (RBAC) on
+// alerting APIs. It filters the responses of the Prometheus-compatible `/api/v1/alerts` and `/api/v1/rules`
+// endpoints and of the Alertmanager `/api/v2/alerts` and `/api/v2/silences` endpoints, so that users only
+// see alerts and silences for the clusters and namespaces they are authorized to access. It also validates
+// that silences created or expired by a user only target permitted clusters.
+//
+// The access map used throughout this package is the one computed by metricquery: keys are managed
+// cluster names, values are the namespaces accessible on that cluster, "*" meaning all of them.
+package alertquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+const (
+	clusterLabel   = "cluster"
+	namespaceLabel = "namespace"
+
+	alertStateFiring   = "firing"
+	alertStatePending  = "pending"
+	alertStateInactive = "inactive"
+)
+
+// labeledObject is used to decode only the labels of an alert, leaving other fields untouched.
+type labeledObject struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// LabelsAllowed returns true if a set of alert labels belongs to a cluster, and if relevant a namespace,
+// that the user has access to. Alerts without a cluster label are never visible to scoped users.
+func LabelsAllowed(lbls map[string]string, userMetricsAccess map[string][]string) bool {
+	namespaces, ok := userMetricsAccess[lbls[clusterLabel]]
+	if !ok {
+		return false
+	}
+
+	if hasAllNamespaces(namespaces) {
+		return true
+	}
+
+	ns, ok := lbls[namespaceLabel]
+	return ok && slices.Contains(namespaces, ns)
+}
+
+// FilterPrometheusAlerts filters the body of a Prometheus `/api/v1/alerts` response.
+func FilterPrometheusAlerts(body []byte, userMetricsAccess map[string][]string) ([]byte, error) {
+	resp, data, err := decodePrometheusResponse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	rawAlerts, ok := data["alerts"]
+	if !ok {
+		return body, nil
+	}
+
+	var alerts []json.RawMessage
+	if err := json.Unmarshal(rawAlerts, &alerts); err != nil {
+		return nil, fmt.Errorf("failed to decode alerts: %w", err)
+	}
+
+	filtered, _, err := filterAlerts(alerts, userMetricsAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	if data["alerts"], err = json.Marshal(filtered); err != nil {
+		return nil, err
+	}
+
+	return encodePrometheusResponse(resp, data)
+}
+
+// FilterPrometheusRules filters the body of a Prometheus `/api/v1/rules` response.
+// Rule definitions are global to the hub and are kept, but the alerts attached to each
+// alerting rule are filtered and the rule state is recomputed from the remaining alerts.
+func FilterPrometheusRules(body []byte, userMetricsAccess map[string][]string) ([]byte, error) {
+	resp, data, err := decodePrometheusResponse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	rawGroups, ok := data["groups"]
+	if !ok {
+		return body, nil
+	}
+
+	var groups []map[string]json.RawMessage
+	if err := json.Unmarshal(rawGroups, &groups); err != nil {
+		return nil, fmt.Errorf("failed to decode rule groups: %w", err)
+	}
+
+	for _, group := range groups {
+		var rules []map[string]json.RawMessage
+		if err := json.Unmarshal(group["rules"], &rules); err != nil {
+			return nil, fmt.Errorf("failed to decode rules: %w", err)
+		}
+
+		for _, rule := range rules {
+			rawAlerts, ok := rule["alerts"]
+			if !ok {
+				// Recording rules don't have alerts.
+				continue
+			}
+
+			var alerts []json.RawMessage
+			if err := json.Unmarshal(rawAlerts, &alerts); err != nil {
+				return nil, fmt.Errorf("failed to decode rule alerts: %w", err)
+			}
+
+			filtered, state, err := filterAlerts(alerts, userMetricsAccess)
+			if err != nil {
+				return nil, err
+			}
+
+			if rule["alerts"], err = json.Marshal(filtered); err != nil {
+				return nil, err
+			}
+			if _, ok := rule["state"]; ok {
+				if rule["state"], err = json.Marshal(state); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if group["rules"], err = json.Marshal(rules); err != nil {
+			return nil, err
+		}
+	}
+
+	if data["groups"], err = json.Marshal(groups); err != nil {
+		return nil, err
+	}
+
+	return encodePrometheusResponse(resp, data)
+}
+
+// FilterAlertmanagerAlerts filters the body of an Alertmanager `/api/v2/alerts` response.
+func FilterAlertmanagerAlerts(body []byte, userMetricsAccess map[string][]string) ([]byte, error) {
+	var alerts []json.RawMessage
+	if err := json.Unmarshal(body, &alerts); err != nil {
+		return nil, fmt.Errorf("failed to decode alertmanager alerts: %w", err)
+	}
+
+	filtered, _, err := filterAlerts(alerts, userMetricsAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(filtered)
+}
+
+// filterAlerts returns the alerts the user has access to, along with the resulting alerting rule state.
+func filterAlerts(alerts []json.RawMessage, userMetricsAccess map[string][]string) ([]json.RawMessage, string, error) {
+	filtered := make([]json.RawMessage, 0, len(alerts))
+	state := alertStateInactive
+	for _, rawAlert := range alerts {
+		alert := struct {
+			labeledObject
+			State string `json:"state"`
+		}{}
+		if err := json.Unmarshal(rawAlert, &alert); err != nil {
+			return nil, "", fmt.Errorf("failed to decode alert: %w", err)
+		}
+
+		if !LabelsAllowed(alert.Labels, userMetricsAccess) {
+			continue
+		}
+
+		filtered = append(filtered, rawAlert)
+		switch alert.State {
+		case alertStateFiring:
+			state = alertStateFiring
+		case alertStatePending:
+			if state != alertStateFiring {
+				state = alertStatePending
+			}
+		}
+	}
+
+	return filtered, state, nil
+}
+
+// decodePrometheusResponse decodes a Prometheus API response while preserving unknown fields.
+func decodePrometheusResponse(body []byte) (map[string]json.RawMessage, map[string]json.RawMessage, error) {
+	resp := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	data := map[string]json.RawMessage{}
+	if err := json.Unmarshal(resp["data"], &data); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode response data: %w", err)
+	}
+
+	return resp, data, nil
+}
+
+func encodePrometheusResponse(resp, data map[string]json.RawMessage) ([]byte, error) {
+	var err error
+	if resp["data"], err = json.Marshal(data); err != nil {
+		return nil, err
+	}
+	return json.Marshal(resp)
+}
+
+func hasAllNamespaces(namespaces []string) bool {
+	return len(namespaces) == 0 || slices.Contains(namespaces, "*")
+}
@@ -0,0 +1,125 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package alertquery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testUserMetricsAccess = map[string][]string{
+	"cluster1": {"*"},
+	"cluster2": {"ns1", "ns2"},
+}
+
+func TestLabelsAllowed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		labels   map[string]string
+		expected bool
+	}{
+		{
+			name:     "cluster with all namespaces access",
+			labels:   map[string]string{"cluster": "cluster1", "namespace": "any"},
+			expected: true,
+		},
+		{
+			name:     "cluster with all namespaces access and no namespace label",
+			labels:   map[string]string{"cluster": "cluster1"},
+			expected: true,
+		},
+		{
+			name:     "cluster with restricted namespaces and allowed namespace",
+			labels:   map[string]string{"cluster": "cluster2", "namespace": "ns1"},
+			expected: true,
+		},
+		{
+			name:     "cluster with restricted namespaces and forbidden namespace",
+			labels:   map[string]string{"cluster": "cluster2", "namespace": "ns3"},
+			expected: false,
+		},
+		{
+			name:     "cluster with restricted namespaces and no namespace label",
+			labels:   map[string]string{"cluster": "cluster2"},
+			expected: false,
+		},
+		{
+			name:     "forbidden cluster",
+			labels:   map[string]string{"cluster": "cluster3"},
+			expected: false,
+		},
+		{
+			name:     "no cluster label",
+			labels:   map[string]string{"alertname": "Watchdog"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, LabelsAllowed(tc.labels, testUserMetricsAccess))
+		})
+	}
+}
+
+func TestFilterPrometheusAlerts(t *testing.T) {
+	body := `{"status":"success","data":{"alerts":[
+		{"labels":{"alertname":"a","cluster":"cluster1"},"state":"firing"},
+		{"labels":{"alertname":"b","cluster":"cluster3"},"state":"firing"},
+		{"labels":{"alertname":"c","cluster":"cluster2","namespace":"ns2"},"state":"pending"}
+	]},"warnings":["w"]}`
+	expected := `{"status":"success","data":{"alerts":[
+		{"labels":{"alertname":"a","cluster":"cluster1"},"state":"firing"},
+		{"labels":{"alertname":"c","cluster":"cluster2","namespace":"ns2"},"state":"pending"}
+	]},"warnings":["w"]}`
+
+	filtered, err := FilterPrometheusAlerts([]byte(body), testUserMetricsAccess)
+	assert.NoError(t, err)
+	assert.JSONEq(t, expected, string(filtered))
+
+	_, err = FilterPrometheusAlerts([]byte(`not json`), testUserMetricsAccess)
+	assert.Error(t, err)
+}
+
+func TestFilterPrometheusRules(t *testing.T) {
+	body := `{"status":"success","data":{"groups":[{"name":"g","file":"f","rules":[
+		{"name":"r1","type":"alerting","state":"firing","alerts":[
+			{"labels":{"cluster":"cluster3"},"state":"firing"},
+			{"labels":{"cluster":"cluster1"},"state":"pending"}
+		]},
+		{"name":"r2","type":"alerting","state":"firing","alerts":[
+			{"labels":{"cluster":"cluster2","namespace":"ns3"},"state":"firing"}
+		]},
+		{"name":"r3","type":"recording","query":"up"}
+	]}]}}`
+	expected := `{"status":"success","data":{"groups":[{"name":"g","file":"f","rules":[
+		{"name":"r1","type":"alerting","state":"pending","alerts":[
+			{"labels":{"cluster":"cluster1"},"state":"pending"}
+		]},
+		{"name":"r2","type":"alerting","state":"inactive","alerts":[]},
+		{"name":"r3","type":"recording","query":"up"}
+	]}]}}`
+
+	filtered, err := FilterPrometheusRules([]byte(body), testUserMetricsAccess)
+	assert.NoError(t, err)
+	assert.JSONEq(t, expected, string(filtered))
+}
+
+func TestFilterAlertmanagerAlerts(t *testing.T) {
+	body := `[
+		{"labels":{"alertname":"a","cluster":"cluster1"},"status":{"state":"active"}},
+		{"labels":{"alertname":"b","cluster":"cluster2","namespace":"ns3"},"status":{"state":"active"}}
+	]`
+	expected := `[{"labels":{"alertname":"a","cluster":"cluster1"},"status":{"state":"active"}}]`
+
+	filtered, err := FilterAlertmanagerAlerts([]byte(body), testUserMetricsAccess)
+	assert.NoError(t, err)
+	assert.JSONEq(t, expected, string(filtered))
+
+	filtered, err = FilterAlertmanagerAlerts([]byte(`[]`), testUserMetricsAccess)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[]`, string(filtered))
+}
@@ -0,0 +1,134 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package alertquery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// silenceMatcher mirrors the Alertmanager v2 API matcher definition.
+type silenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	// IsEqual defaults to true when omitted, as in the Alertmanager API.
+	IsEqual *bool `json:"isEqual,omitempty"`
+}
+
+func (m silenceMatcher) isEqual() bool {
+	return m.IsEqual == nil || *m.IsEqual
+}
+
+type silence struct {
+	Matchers []silenceMatcher `json:"matchers"`
+}
+
+// CheckSilence decodes an Alertmanager silence and returns an error if the user is not allowed to manage it.
+func CheckSilence(body []byte, userMetricsAccess map[string][]string) error {
+	s := silence{}
+	if err := json.Unmarshal(body, &s); err != nil {
+		return fmt.Errorf("failed to decode silence: %w", err)
+	}
+	return checkSilenceMatchers(s.Matchers, userMetricsAccess)
+}
+
+// FilterSilences filters the body of an Alertmanager `/api/v2/silences` response, keeping
+// only the silences that the user would be allowed to create.
+func FilterSilences(body []byte, userMetricsAccess map[string][]string) ([]byte, error) {
+	var silences []json.RawMessage
+	if err := json.Unmarshal(body, &silences); err != nil {
+		return nil, fmt.Errorf("failed to decode silences: %w", err)
+	}
+
+	filtered := make([]json.RawMessage, 0, len(silences))
+	for _, rawSilence := range silences {
+		s := silence{}
+		if err := json.Unmarshal(rawSilence, &s); err != nil {
+			return nil, fmt.Errorf("failed to decode silence: %w", err)
+		}
+		if checkSilenceMatchers(s.Matchers, userMetricsAccess) == nil {
+			filtered = append(filtered, rawSilence)
+		}
+	}
+
+	return json.Marshal(filtered)
+}
+
+// checkSilenceMatchers ensures that a silence only targets clusters, and namespaces when the user's
+// access is restricted on a cluster, that the user has access to. A silence must have a positive `cluster`
+// matcher, either an equality or a regex made of literal cluster names separated by `|`.
+func checkSilenceMatchers(matchers []silenceMatcher, userMetricsAccess map[string][]string) error {
+	var clusters []string
+	var namespace *silenceMatcher
+	for i, m := range matchers {
+		switch m.Name {
+		case clusterLabel:
+			if !m.isEqual() {
+				continue
+			}
+			values, err := literalValues(m)
+			if err != nil {
+				return err
+			}
+			clusters = append(clusters, values...)
+		case namespaceLabel:
+			namespace = &matchers[i]
+		}
+	}
+
+	if len(clusters) == 0 {
+		return errors.New("silence must have a positive matcher on the cluster label")
+	}
+
+	for _, cluster := range clusters {
+		namespaces, ok := userMetricsAccess[cluster]
+		if !ok {
+			return fmt.Errorf("access to cluster %q is not allowed", cluster)
+		}
+
+		if hasAllNamespaces(namespaces) {
+			continue
+		}
+
+		if namespace == nil || !namespace.isEqual() {
+			return fmt.Errorf("silence on cluster %q must have a positive matcher on the namespace label", cluster)
+		}
+		values, err := literalValues(*namespace)
+		if err != nil {
+			return err
+		}
+		for _, ns := range values {
+			if !slices.Contains(namespaces, ns) {
+				return fmt.Errorf("access to namespace %q on cluster %q is not allowed", ns, cluster)
+			}
+		}
+	}
+
+	return nil
+}
+
+// literalValues returns the values matched by a positive matcher. Regex matchers are only accepted
+// when they are an alternation of literal values, as the set of matched values must be known.
+func literalValues(m silenceMatcher) ([]string, error) {
+	if !m.IsRegex {
+		return []string{m.Value}, nil
+	}
+
+	var values []string
+	for v := range strings.SplitSeq(m.Value, "|") {
+		// Dots are common in cluster names and are expected to be escaped.
+		unescaped := strings.ReplaceAll(v, `\.`, ".")
+		if unescaped == "" || regexp.QuoteMeta(unescaped) != v {
+			return nil, fmt.Errorf("regex matcher %s=~%q must only contain literal values separated by '|'", m.Name, m.Value)
+		}
+		values = append(values, unescaped)
+	}
+	return values, nil
+}
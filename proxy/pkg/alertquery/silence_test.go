@@ -0,0 +1,93 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package alertquery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSilence(t *testing.T) {
+	testCases := []struct {
+		name        string
+		silence     string
+		expectedErr string
+	}{
+		{
+			name:    "equality matcher on allowed cluster",
+			silence: `{"matchers":[{"name":"cluster","value":"cluster1","isRegex":false}]}`,
+		},
+		{
+			name:    "regex matcher on allowed clusters",
+			silence: `{"matchers":[{"name":"cluster","value":"cluster1|cluster2","isRegex":true},{"name":"namespace","value":"ns1","isRegex":false}]}`,
+		},
+		{
+			name:    "regex matcher with escaped dots",
+			silence: `{"matchers":[{"name":"cluster","value":"cluster\\.example","isRegex":true}]}`,
+			// The cluster name is valid, but not in the user's access list.
+			expectedErr: `access to cluster "cluster.example" is not allowed`,
+		},
+		{
+			name:        "no cluster matcher",
+			silence:     `{"matchers":[{"name":"alertname","value":"Watchdog","isRegex":false}]}`,
+			expectedErr: "positive matcher on the cluster label",
+		},
+		{
+			name:        "negative cluster matcher",
+			silence:     `{"matchers":[{"name":"cluster","value":"cluster3","isRegex":false,"isEqual":false}]}`,
+			expectedErr: "positive matcher on the cluster label",
+		},
+		{
+			name:        "forbidden cluster",
+			silence:     `{"matchers":[{"name":"cluster","value":"cluster3","isRegex":false}]}`,
+			expectedErr: `access to cluster "cluster3" is not allowed`,
+		},
+		{
+			name:        "non literal regex matcher",
+			silence:     `{"matchers":[{"name":"cluster","value":"cluster.*","isRegex":true}]}`,
+			expectedErr: "must only contain literal values",
+		},
+		{
+			name:        "restricted cluster without namespace matcher",
+			silence:     `{"matchers":[{"name":"cluster","value":"cluster2","isRegex":false}]}`,
+			expectedErr: "positive matcher on the namespace label",
+		},
+		{
+			name:        "restricted cluster with forbidden namespace",
+			silence:     `{"matchers":[{"name":"cluster","value":"cluster2","isRegex":false},{"name":"namespace","value":"ns1|ns3","isRegex":true}]}`,
+			expectedErr: `access to namespace "ns3" on cluster "cluster2" is not allowed`,
+		},
+		{
+			name:        "invalid json",
+			silence:     `{`,
+			expectedErr: "failed to decode silence",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckSilence([]byte(tc.silence), testUserMetricsAccess)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestFilterSilences(t *testing.T) {
+	body := `[
+		{"id":"1","matchers":[{"name":"cluster","value":"cluster1","isRegex":false}]},
+		{"id":"2","matchers":[{"name":"cluster","value":"cluster3","isRegex":false}]},
+		{"id":"3","matchers":[{"name":"alertname","value":"Watchdog","isRegex":false}]}
+	]`
+	expected := `[{"id":"1","matchers":[{"name":"cluster","value":"cluster1","isRegex":false}]}]`
+
+	filtered, err := FilterSilences([]byte(body), testUserMetricsAccess)
+	assert.NoError(t, err)
+	assert.JSONEq(t, expected, string(filtered))
+}
@@ -39,6 +39,8 @@ type userProject struct {
 	UserName    string
 	Timestamp   time.Time
 	ProjectList []string
+	Groups      []string
+	Hubs        []string
 }
 
 // NewUserProjectInfo creates and starts a new UserProjectInfo cache.
@@ -83,6 +85,61 @@ func (upi *UserProjectInfo) GetUserProjectList(token string) ([]string, bool) {
 	return []string{}, false
 }
 
+// UpdateUserGroups sets the groups of a user already in the cache. It is a no-op if the token is not cached.
+func (upi *UserProjectInfo) UpdateUserGroups(token string, groups []string) {
+	if groups == nil {
+		groups = []string{}
+	}
+	upi.mu.Lock()
+	defer upi.mu.Unlock()
+	up, ok := upi.projectInfo[token]
+	if !ok {
+		return
+	}
+	up.Groups = groups
+	upi.projectInfo[token] = up
+}
+
+// GetUserGroups retrieves a user's groups from the cache using their token.
+// It returns a copy of the groups and a boolean indicating if the groups were found.
+func (upi *UserProjectInfo) GetUserGroups(token string) ([]string, bool) {
+	upi.mu.RLock()
+	up, ok := upi.projectInfo[token]
+	upi.mu.RUnlock()
+	if ok && up.Groups != nil {
+		return slices.Clone(up.Groups), true
+	}
+	return []string{}, false
+}
+
+// UpdateUserHubs sets the federated hubs a user can query, when already in the cache. It is a no-op if the
+// token is not cached.
+func (upi *UserProjectInfo) UpdateUserHubs(token string, hubs []string) {
+	if hubs == nil {
+		hubs = []string{}
+	}
+	upi.mu.Lock()
+	defer upi.mu.Unlock()
+	up, ok := upi.projectInfo[token]
+	if !ok {
+		return
+	}
+	up.Hubs = hubs
+	upi.projectInfo[token] = up
+}
+
+// GetUserHubs retrieves the federated hubs a user can query from the cache using their token.
+// It returns a copy of the hubs and a boolean indicating if the hubs were found.
+func (upi *UserProjectInfo) GetUserHubs(token string) ([]string, bool) {
+	upi.mu.RLock()
+	up, ok := upi.projectInfo[token]
+	upi.mu.RUnlock()
+	if ok && up.Hubs != nil {
+		return slices.Clone(up.Hubs), true
+	}
+	return []string{}, false
+}
+
 // GetUserName retrieves a user's name from the cache using their token.
 // It returns the username and a boolean indicating if the entry was found.
 func (upi *UserProjectInfo) GetUserName(token string) (string, bool) {
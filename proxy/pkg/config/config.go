@@ -7,6 +7,8 @@ package config
 import (
 	projectv1 "github.com/openshift/api/project/v1"
 	userv1 "github.com/openshift/api/user/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -17,6 +19,10 @@ const (
 	ManagedClusterLabelAllowListConfigMapKey  = "managed_cluster.yaml"
 	ManagedClusterLabelAllowListNamespace     = "open-cluster-management-observability"
 
+	QueryGuardrailsConfigMapName = "observability-query-guardrails"
+	QueryGuardrailsConfigMapKey  = "guardrails.yaml"
+	QueryGuardrailsNamespace     = "open-cluster-management-observability"
+
 	RBACProxyLabelMetricName              = "acm_label_names"
 	ACMManagedClusterLabelNamesMetricName = "acm_managed_cluster_labels"
 )
@@ -30,6 +36,8 @@ var (
 func init() {
 	_ = userv1.AddToScheme(Scheme)
 	_ = projectv1.AddToScheme(Scheme)
+	_ = authenticationv1.AddToScheme(Scheme)
+	_ = authorizationv1.AddToScheme(Scheme)
 }
 
 // CreateManagedClusterLabelAllowListCM creates a managedcluster label allowlist configmap object.
@@ -0,0 +1,139 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package guardrail
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"k8s.io/klog/v2"
+)
+
+// Error is returned when a query violates the guardrail policy. Its message is meant to be
+// shown to the user, so it explains how to fix the query.
+type Error struct {
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return "query rejected by guardrails: " + e.Reason
+}
+
+// policyVisitor walks the PromQL AST, rewriting what can be safely rewritten and
+// returning an *Error for the first violation of the policy.
+type policyVisitor struct {
+	policy *Policy
+}
+
+// Visit implements the parser.Visitor interface.
+func (v *policyVisitor) Visit(node parser.Node, path []parser.Node) (parser.Visitor, error) {
+	switch n := node.(type) {
+	case *parser.VectorSelector:
+		if err := v.checkSelector(n); err != nil {
+			return nil, err
+		}
+	case *parser.MatrixSelector:
+		if err := v.checkRange(&n.Range, n.String()); err != nil {
+			return nil, err
+		}
+	case *parser.SubqueryExpr:
+		if err := v.checkRange(&n.Range, n.String()); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func (v *policyVisitor) checkSelector(vs *parser.VectorSelector) error {
+	matchers := make([]*labels.Matcher, 0, len(vs.LabelMatchers))
+	for _, m := range vs.LabelMatchers {
+		if slices.Contains(v.policy.HighCardinalityLabels, m.Name) && m.Type == labels.MatchRegexp {
+			switch normalizeRegex(m.Value) {
+			case ".*":
+				// Matches every value, including the empty one: this is a no-op that can be dropped.
+				klog.V(1).Infof("guardrails: dropping no-op matcher %s from %s", m, vs)
+				continue
+			case ".+":
+				return &Error{Reason: fmt.Sprintf(
+					"the matcher %s in %s selects every value of the high-cardinality label %q; use an exact value or a more specific regex",
+					m, vs, m.Name)}
+			}
+		}
+		matchers = append(matchers, m)
+	}
+	// A selector needs at least one matcher, keep the original ones if they were all dropped.
+	if len(matchers) > 0 {
+		vs.LabelMatchers = matchers
+	}
+
+	if !v.policy.RequireMetricNameOrCluster {
+		return nil
+	}
+	for _, m := range vs.LabelMatchers {
+		if m.Name == labels.MetricName || m.Name == "cluster" {
+			return nil
+		}
+	}
+	return &Error{Reason: fmt.Sprintf(
+		"the selector %s has neither a metric name nor a cluster matcher; add a metric name (e.g. up%s) or a cluster matcher (e.g. {cluster=\"my-cluster\"})",
+		vs, strings.TrimPrefix(vs.String(), vs.Name))}
+}
+
+func (v *policyVisitor) checkRange(r *time.Duration, expr string) error {
+	maxRange := time.Duration(v.policy.MaxRangeDuration)
+	if maxRange <= 0 || *r <= maxRange {
+		return nil
+	}
+
+	if v.policy.ClampRanges {
+		klog.V(1).Infof("guardrails: clamping the range of %s to %s", expr, v.policy.MaxRangeDuration)
+		*r = maxRange
+		return nil
+	}
+	return &Error{Reason: fmt.Sprintf(
+		"the range of %s is longer than the maximum allowed of %s; reduce the range or split the query",
+		expr, v.policy.MaxRangeDuration)}
+}
+
+// normalizeRegex removes the anchors and the enclosing group of a regex so that
+// equivalent forms like `^(.*)$` and `.*` can be compared.
+func normalizeRegex(re string) string {
+	re = strings.TrimSuffix(strings.TrimPrefix(re, "^"), "$")
+	for strings.HasPrefix(re, "(") && strings.HasSuffix(re, ")") {
+		re = re[1 : len(re)-1]
+	}
+	return re
+}
+
+// Evaluate checks a PromQL query against a policy. It returns the query, rewritten if
+// the policy allows it, or an *Error if the query violates the policy. Queries that
+// can't be parsed are returned unchanged, they are rejected further down the line.
+func Evaluate(query string, policy *Policy) (string, error) {
+	if query == "" || policy == nil || isEmpty(policy) {
+		return query, nil
+	}
+
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		klog.V(1).Infof("guardrails: skipping query that can't be parsed <%s>: %v", query, err)
+		return query, nil
+	}
+
+	if err := parser.Walk(&policyVisitor{policy: policy}, expr, nil); err != nil {
+		return "", err
+	}
+
+	return expr.String(), nil
+}
+
+func isEmpty(policy *Policy) bool {
+	return !policy.RequireMetricNameOrCluster && len(policy.HighCardinalityLabels) == 0 &&
+		policy.MaxRangeDuration == model.Duration(0)
+}
@@ -0,0 +1,150 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package guardrail
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	strict := &Policy{
+		RequireMetricNameOrCluster: true,
+		HighCardinalityLabels:      []string{"pod", "container"},
+		MaxRangeDuration:           model.Duration(24 * time.Hour),
+	}
+	clamp := &Policy{
+		MaxRangeDuration: model.Duration(time.Hour),
+		ClampRanges:      true,
+	}
+
+	testCases := []struct {
+		name        string
+		query       string
+		policy      *Policy
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "nil policy",
+			query:    `{job="a"}`,
+			policy:   nil,
+			expected: `{job="a"}`,
+		},
+		{
+			name:     "empty policy",
+			query:    `{job="a"}`,
+			policy:   &Policy{},
+			expected: `{job="a"}`,
+		},
+		{
+			name:     "unparsable query is returned unchanged",
+			query:    `sum(`,
+			policy:   strict,
+			expected: `sum(`,
+		},
+		{
+			name:     "metric name",
+			query:    `up{job="a"}`,
+			policy:   strict,
+			expected: `up{job="a"}`,
+		},
+		{
+			name:     "cluster matcher",
+			query:    `{cluster="c1"}`,
+			policy:   strict,
+			expected: `{cluster="c1"}`,
+		},
+		{
+			name:        "neither metric name nor cluster",
+			query:       `{job="a"}`,
+			policy:      strict,
+			expectError: true,
+		},
+		{
+			name:        "neither metric name nor cluster in nested selector",
+			query:       `sum(up) / count({job="a"})`,
+			policy:      strict,
+			expectError: true,
+		},
+		{
+			name:     "match-all regex on high-cardinality label is dropped",
+			query:    `up{pod=~".*",job="a"}`,
+			policy:   strict,
+			expected: `up{job="a"}`,
+		},
+		{
+			name:     "anchored match-all regex on high-cardinality label is dropped",
+			query:    `up{pod=~"^(.*)$"}`,
+			policy:   strict,
+			expected: `up`,
+		},
+		{
+			name:        "match-any regex on high-cardinality label",
+			query:       `up{container=~".+"}`,
+			policy:      strict,
+			expectError: true,
+		},
+		{
+			name:     "specific regex on high-cardinality label",
+			query:    `up{pod=~"api-.*"}`,
+			policy:   strict,
+			expected: `up{pod=~"api-.*"}`,
+		},
+		{
+			name:     "match-any regex on other label",
+			query:    `up{job=~".+"}`,
+			policy:   strict,
+			expected: `up{job=~".+"}`,
+		},
+		{
+			name:     "range within the limit",
+			query:    `rate(up[5m])`,
+			policy:   strict,
+			expected: `rate(up[5m])`,
+		},
+		{
+			name:        "range over the limit",
+			query:       `rate(up[7d])`,
+			policy:      strict,
+			expectError: true,
+		},
+		{
+			name:        "subquery over the limit",
+			query:       `max_over_time(rate(up[5m])[2d:5m])`,
+			policy:      strict,
+			expectError: true,
+		},
+		{
+			name:     "range over the limit is clamped",
+			query:    `rate(up[1d])`,
+			policy:   clamp,
+			expected: `rate(up[1h])`,
+		},
+		{
+			name:     "subquery over the limit is clamped",
+			query:    `max_over_time(rate(up[5m])[1d:5m])`,
+			policy:   clamp,
+			expected: `max_over_time(rate(up[5m])[1h:5m])`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Evaluate(tc.query, tc.policy)
+			if tc.expectError {
+				var guardrailErr *Error
+				assert.True(t, errors.As(err, &guardrailErr))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
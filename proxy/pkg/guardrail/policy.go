@@ -0,0 +1,61 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+// Package guardrail evaluates the cost of PromQL queries before they are sent to Thanos.
+//
+// Queries that select every series of the fleet, use match-all regex matchers on high-cardinality
+// labels or read very long ranges can take down the query path for everyone. A Policy describes which
+// of those patterns are rejected or rewritten. Policies are loaded from the
+// 'observability-query-guardrails' ConfigMap and can be scoped to the groups the user belongs to.
+package guardrail
+
+import (
+	"slices"
+
+	"github.com/prometheus/common/model"
+)
+
+// Policy defines the guardrails enforced on PromQL queries. The zero value doesn't enforce anything.
+type Policy struct {
+	// RequireMetricNameOrCluster rejects selectors that have neither a metric name nor a cluster matcher.
+	RequireMetricNameOrCluster bool `yaml:"requireMetricNameOrCluster,omitempty"`
+	// HighCardinalityLabels lists the labels on which match-all regex matchers are not allowed.
+	// Matchers equivalent to no matcher at all, like `=~".*"`, are removed from the query.
+	HighCardinalityLabels []string `yaml:"highCardinalityLabels,omitempty"`
+	// MaxRangeDuration is the longest range allowed for range vectors and subqueries. Zero disables the check.
+	MaxRangeDuration model.Duration `yaml:"maxRangeDuration,omitempty"`
+	// ClampRanges rewrites the ranges longer than MaxRangeDuration instead of rejecting the query.
+	ClampRanges bool `yaml:"clampRanges,omitempty"`
+}
+
+// GroupPolicy is a policy that applies to the members of some groups.
+type GroupPolicy struct {
+	Groups []string `yaml:"groups"`
+	Policy `yaml:",inline"`
+}
+
+// Config is the content of the guardrails ConfigMap.
+type Config struct {
+	// Default applies to users that are not matched by any group policy.
+	Default Policy `yaml:"default"`
+	// Groups are evaluated in order, the first policy matching one of the user's groups applies.
+	Groups []GroupPolicy `yaml:"groups,omitempty"`
+}
+
+// PolicyProvider returns the policy that applies to a user, given the groups they belong to.
+type PolicyProvider interface {
+	PolicyFor(groups []string) *Policy
+}
+
+// PolicyFor implements the PolicyProvider interface.
+func (c *Config) PolicyFor(groups []string) *Policy {
+	for i := range c.Groups {
+		for _, g := range c.Groups[i].Groups {
+			if slices.Contains(groups, g) {
+				return &c.Groups[i].Policy
+			}
+		}
+	}
+	return &c.Default
+}
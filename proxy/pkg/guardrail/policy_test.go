@@ -0,0 +1,54 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package guardrail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyFor(t *testing.T) {
+	config := &Config{
+		Default: Policy{RequireMetricNameOrCluster: true},
+		Groups: []GroupPolicy{
+			{Groups: []string{"sre", "admins"}, Policy: Policy{HighCardinalityLabels: []string{"pod"}}},
+			{Groups: []string{"developers"}, Policy: Policy{ClampRanges: true}},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		groups   []string
+		expected Policy
+	}{
+		{
+			name:     "no groups",
+			groups:   nil,
+			expected: config.Default,
+		},
+		{
+			name:     "unmatched groups",
+			groups:   []string{"other"},
+			expected: config.Default,
+		},
+		{
+			name:     "matched group",
+			groups:   []string{"developers"},
+			expected: config.Groups[1].Policy,
+		},
+		{
+			name:     "first matching policy wins",
+			groups:   []string{"developers", "admins"},
+			expected: config.Groups[0].Policy,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, *config.PolicyFor(tc.groups))
+		})
+	}
+}
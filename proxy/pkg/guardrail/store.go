@@ -0,0 +1,99 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package guardrail
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	proxyconfig "github.com/stolostron/multicluster-observability-operator/proxy/pkg/config"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// PolicyStore keeps the guardrails configuration in sync with the guardrails ConfigMap.
+// When the ConfigMap doesn't exist or is invalid, no guardrail is enforced.
+type PolicyStore struct {
+	ctx        context.Context
+	kubeClient kubernetes.Interface
+	config     *Config
+	configMtx  sync.RWMutex
+}
+
+// NewPolicyStore creates a new PolicyStore.
+func NewPolicyStore(ctx context.Context, kubeClient kubernetes.Interface) *PolicyStore {
+	return &PolicyStore{
+		ctx:        ctx,
+		kubeClient: kubeClient,
+		config:     &Config{},
+	}
+}
+
+// Run starts the ConfigMap informer.
+func (s *PolicyStore) Run() {
+	watchlist := cache.NewListWatchFromClient(s.kubeClient.CoreV1().RESTClient(), "configmaps",
+		proxyconfig.QueryGuardrailsNamespace,
+		fields.OneTermEqualSelector("metadata.name", proxyconfig.QueryGuardrailsConfigMapName))
+	_, controller := cache.NewInformerWithOptions(cache.InformerOptions{
+		ListerWatcher: watchlist,
+		ObjectType:    &v1.ConfigMap{},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj any) {
+				s.update(obj.(*v1.ConfigMap))
+			},
+			UpdateFunc: func(_, newObj any) {
+				s.update(newObj.(*v1.ConfigMap))
+			},
+			DeleteFunc: func(obj any) {
+				klog.Infof("ConfigMap %s was deleted, disabling query guardrails", proxyconfig.QueryGuardrailsConfigMapName)
+				s.setConfig(&Config{})
+			},
+		},
+	})
+
+	go controller.Run(s.ctx.Done())
+}
+
+// PolicyFor implements the PolicyProvider interface.
+func (s *PolicyStore) PolicyFor(groups []string) *Policy {
+	s.configMtx.RLock()
+	defer s.configMtx.RUnlock()
+	return s.config.PolicyFor(groups)
+}
+
+func (s *PolicyStore) update(cm *v1.ConfigMap) {
+	config, err := parseConfig(cm)
+	if err != nil {
+		// Keep the last valid configuration rather than silently dropping all guardrails.
+		klog.Errorf("Failed to load query guardrails from ConfigMap %s, keeping the previous configuration: %v", cm.Name, err)
+		return
+	}
+	klog.Infof("Loaded query guardrails from ConfigMap %s with %d group policies", cm.Name, len(config.Groups))
+	s.setConfig(config)
+}
+
+func (s *PolicyStore) setConfig(config *Config) {
+	s.configMtx.Lock()
+	s.config = config
+	s.configMtx.Unlock()
+}
+
+func parseConfig(cm *v1.ConfigMap) (*Config, error) {
+	data, ok := cm.Data[proxyconfig.QueryGuardrailsConfigMapKey]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found", proxyconfig.QueryGuardrailsConfigMapKey)
+	}
+
+	config := &Config{}
+	if err := yaml.UnmarshalStrict([]byte(data), config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal query guardrails: %w", err)
+	}
+	return config, nil
+}
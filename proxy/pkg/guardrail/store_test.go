@@ -0,0 +1,90 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package guardrail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	proxyconfig "github.com/stolostron/multicluster-observability-operator/proxy/pkg/config"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestParseConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		data        map[string]string
+		expected    *Config
+		expectError bool
+	}{
+		{
+			name: "valid config",
+			data: map[string]string{proxyconfig.QueryGuardrailsConfigMapKey: `
+default:
+  requireMetricNameOrCluster: true
+  highCardinalityLabels: [pod]
+  maxRangeDuration: 7d
+groups:
+- groups: [sre]
+  maxRangeDuration: 30d
+  clampRanges: true
+`},
+			expected: &Config{
+				Default: Policy{
+					RequireMetricNameOrCluster: true,
+					HighCardinalityLabels:      []string{"pod"},
+					MaxRangeDuration:           model.Duration(7 * 24 * time.Hour),
+				},
+				Groups: []GroupPolicy{{
+					Groups: []string{"sre"},
+					Policy: Policy{
+						MaxRangeDuration: model.Duration(30 * 24 * time.Hour),
+						ClampRanges:      true,
+					},
+				}},
+			},
+		},
+		{
+			name:        "missing key",
+			data:        map[string]string{"other.yaml": ""},
+			expectError: true,
+		},
+		{
+			name:        "unknown field",
+			data:        map[string]string{proxyconfig.QueryGuardrailsConfigMapKey: "default:\n  maxRange: 1d\n"},
+			expectError: true,
+		},
+		{
+			name:        "invalid duration",
+			data:        map[string]string{proxyconfig.QueryGuardrailsConfigMapKey: "default:\n  maxRangeDuration: forever\n"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := parseConfig(&v1.ConfigMap{Data: tc.data})
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, config)
+		})
+	}
+}
+
+func TestPolicyStoreKeepsPreviousConfigOnError(t *testing.T) {
+	s := NewPolicyStore(t.Context(), nil)
+	s.update(&v1.ConfigMap{Data: map[string]string{
+		proxyconfig.QueryGuardrailsConfigMapKey: "default:\n  requireMetricNameOrCluster: true\n",
+	}})
+	assert.True(t, s.PolicyFor(nil).RequireMetricNameOrCluster)
+
+	s.update(&v1.ConfigMap{Data: map[string]string{proxyconfig.QueryGuardrailsConfigMapKey: "invalid: [yaml"}})
+	assert.True(t, s.PolicyFor(nil).RequireMetricNameOrCluster)
+}
@@ -10,6 +10,7 @@
 package metricquery
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"maps"
@@ -20,6 +21,7 @@ import (
 
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/cache"
 	proxyconfig "github.com/stolostron/multicluster-observability-operator/proxy/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/guardrail"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/informer"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/rewrite"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/util"
@@ -43,11 +45,15 @@ type Modifier struct {
 	UPI                 *cache.UserProjectInfo
 	MCI                 informer.ManagedClusterInformable
 	KubeClientTransport http.RoundTripper
+	// Guardrails is the query guardrails policy that applies to the user, if any.
+	Guardrails *guardrail.Policy
 }
 
 // Modify inspects the incoming HTTP request, determines the user's access rights,
 // and rewrites the PromQL query parameters (`query` and `match[]`) to enforce RBAC.
-// If the user has access to all clusters and namespaces, the query is not modified.
+// If the user has access to all clusters and namespaces, the query is not modified
+// except for the query guardrails, which apply to every user.
+// A *guardrail.Error is returned when the query is rejected by the guardrails.
 func (mqm *Modifier) Modify() error {
 	userName := mqm.Req.Header.Get("X-Forwarded-User")
 	klog.V(1).Infof("user is %v", userName)
@@ -55,60 +61,53 @@ func (mqm *Modifier) Modify() error {
 	klog.V(1).Infof("URL path is: %v", mqm.Req.URL.Path)
 	klog.V(1).Infof("URL RawQuery is: %v", mqm.Req.URL.RawQuery)
 
-	token := mqm.Req.Header.Get("X-Forwarded-Access-Token")
-	if token == "" {
-		return fmt.Errorf("failed to get token from http header")
-	}
-
-	userMetricsAccess, err := mqm.getUserMetricsACLs(userName, token)
+	userMetricsAccess, allAccess, err := mqm.GetUserMetricsAccess()
 	if err != nil {
-		return fmt.Errorf("failed to determine user's metrics access: %w", err)
+		return err
 	}
-
-	klog.V(1).Infof("user <%v> have metrics access to : %v", userName, userMetricsAccess)
-
-	allAccess := canAccessAll(userMetricsAccess, mqm.MCI.GetAllManagedClusterNames())
-	if allAccess {
-		klog.V(1).Infof("user <%v> have access to all clusters and all namespaces", userName)
+	if allAccess && mqm.Guardrails == nil {
 		return nil
 	}
 
-	var rawQuery string
+	var queryValues url.Values
 	if mqm.Req.Method == http.MethodPost {
 		body, _ := io.ReadAll(mqm.Req.Body)
 		_ = mqm.Req.Body.Close()
-		queryValues, err := url.ParseQuery(string(body))
+		// Restore the body in case the values are left untouched.
+		mqm.Req.Body = io.NopCloser(bytes.NewReader(body))
+		queryValues, err = url.ParseQuery(string(body))
 		if err != nil {
 			return fmt.Errorf("failed to parse request body: %w", err)
 		}
-		if len(queryValues) == 0 {
-			klog.V(1).Info("no query values found in POST body, skipping rewrite")
-			return nil
+	} else {
+		queryValues = mqm.Req.URL.Query()
+	}
+	if len(queryValues) == 0 {
+		klog.V(1).Info("no query values found in request, skipping rewrite")
+		return nil
+	}
+
+	if mqm.Guardrails != nil {
+		queryValues, err = applyGuardrails(queryValues, mqm.Guardrails)
+		if err != nil {
+			return err
 		}
+	}
 
-		modifiedQueryValues, err := rewriteQueryValues(queryValues, userMetricsAccess)
+	if !allAccess {
+		queryValues, err = rewriteQueryValues(queryValues, userMetricsAccess)
 		if err != nil {
 			return err
 		}
+	}
 
-		rawQuery = modifiedQueryValues.Encode()
+	rawQuery := queryValues.Encode()
+	if mqm.Req.Method == http.MethodPost {
 		mqm.Req.Body = io.NopCloser(strings.NewReader(rawQuery))
 		mqm.Req.Header.Set("Content-Length", fmt.Sprint(len([]rune(rawQuery))))
 		mqm.Req.ContentLength = int64(len([]rune(rawQuery)))
 	} else {
-		queryValues := mqm.Req.URL.Query()
-		if len(queryValues) == 0 {
-			klog.V(1).Info("no query values found in URL, skipping rewrite")
-			return nil
-		}
-
-		modifiedQueryValues, err := rewriteQueryValues(queryValues, userMetricsAccess)
-		if err != nil {
-			return err
-		}
-
-		mqm.Req.URL.RawQuery = modifiedQueryValues.Encode()
-		rawQuery = mqm.Req.URL.RawQuery
+		mqm.Req.URL.RawQuery = rawQuery
 	}
 
 	klog.V(1).Info("modified URL is:")
@@ -118,6 +117,30 @@ func (mqm *Modifier) Modify() error {
 	return nil
 }
 
+// GetUserMetricsAccess returns the metrics ACLs of the user making the request, keyed by managed cluster name,
+// and whether they grant access to all namespaces of all managed clusters.
+func (mqm *Modifier) GetUserMetricsAccess() (map[string][]string, bool, error) {
+	userName := mqm.Req.Header.Get("X-Forwarded-User")
+	token := mqm.Req.Header.Get("X-Forwarded-Access-Token")
+	if token == "" {
+		return nil, false, fmt.Errorf("failed to get token from http header")
+	}
+
+	userMetricsAccess, err := mqm.getUserMetricsACLs(userName, token)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to determine user's metrics access: %w", err)
+	}
+
+	klog.V(1).Infof("user <%v> have metrics access to : %v", userName, userMetricsAccess)
+
+	allAccess := canAccessAll(userMetricsAccess, mqm.MCI.GetAllManagedClusterNames())
+	if allAccess {
+		klog.V(1).Infof("user <%v> have access to all clusters and all namespaces", userName)
+	}
+
+	return userMetricsAccess, allAccess, nil
+}
+
 func (mqm *Modifier) getKubeClientWithToken(token string) (client.Client, error) {
 	cfg, err := config.GetConfig()
 	if err != nil {
@@ -249,6 +272,31 @@ func rewriteQueryValues(queryValues url.Values, userMetricsAccess map[string][]s
 	return queryValues, nil
 }
 
+// applyGuardrails evaluates the `query` and `match[]` parameters against the guardrails policy,
+// and returns the url.Values with the queries rewritten by the policy.
+func applyGuardrails(queryValues url.Values, policy *guardrail.Policy) (url.Values, error) {
+	if originalQuery := queryValues.Get("query"); originalQuery != "" {
+		modifiedQuery, err := guardrail.Evaluate(originalQuery, policy)
+		if err != nil {
+			return nil, err
+		}
+		queryValues.Set("query", modifiedQuery)
+	}
+
+	if originalMatches, ok := queryValues["match[]"]; ok {
+		modifiedMatches := make([]string, 0, len(originalMatches))
+		for _, originalMatch := range originalMatches {
+			modifiedMatch, err := guardrail.Evaluate(originalMatch, policy)
+			if err != nil {
+				return nil, err
+			}
+			modifiedMatches = append(modifiedMatches, modifiedMatch)
+		}
+		queryValues["match[]"] = modifiedMatches
+	}
+	return queryValues, nil
+}
+
 // rewriteQuery is the core logic that injects `cluster` and `namespace` label matchers
 // into a PromQL query based on the user's permissions.
 func rewriteQuery(originalQuery string, userMetricsAccess map[string][]string) (string, error) {
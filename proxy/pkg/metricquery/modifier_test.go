@@ -11,8 +11,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/common/model"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/cache"
 	proxyconfig "github.com/stolostron/multicluster-observability-operator/proxy/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/guardrail"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -309,7 +311,10 @@ func TestModifyMetricsQueryParams(t *testing.T) {
 	testCases := []struct {
 		name               string
 		clusters           map[string]struct{}
+		query              string
 		expected           string
+		guardrails         *guardrail.Policy
+		expectError        bool
 		mockAccessReviewer *MockAccessReviewer
 	}{
 		{
@@ -352,6 +357,26 @@ func TestModifyMetricsQueryParams(t *testing.T) {
 				metricsAccess: map[string][]string{"c0": {"ns1"}},
 			},
 		},
+		{
+			name:       "guardrails apply to users with access to all clusters",
+			clusters:   map[string]struct{}{"c0": {}},
+			query:      `foo{pod=~".*"}`,
+			expected:   "query=foo",
+			guardrails: &guardrail.Policy{HighCardinalityLabels: []string{"pod"}},
+			mockAccessReviewer: &MockAccessReviewer{
+				metricsAccess: map[string][]string{"c0": {"*"}},
+			},
+		},
+		{
+			name:        "guardrails reject the query before the RBAC rewrite",
+			clusters:    map[string]struct{}{"c0": {}, "c2": {}},
+			query:       "rate(foo[1d])",
+			guardrails:  &guardrail.Policy{MaxRangeDuration: model.Duration(time.Hour)},
+			expectError: true,
+			mockAccessReviewer: &MockAccessReviewer{
+				metricsAccess: map[string][]string{"c0": {"*"}},
+			},
+		},
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -370,8 +395,17 @@ func TestModifyMetricsQueryParams(t *testing.T) {
 				AccessReviewer: tc.mockAccessReviewer,
 				UPI:            upi,
 				MCI:            mci,
+				Guardrails:     tc.guardrails,
+			}
+			if tc.query != "" {
+				req.URL.RawQuery = url.Values{"query": {tc.query}}.Encode()
+			}
+			err := modifier.Modify()
+			if tc.expectError {
+				var guardrailErr *guardrail.Error
+				assert.ErrorAs(t, err, &guardrailErr)
+				return
 			}
-			modifier.Modify()
 			decodedQuery, _ := url.QueryUnescape(req.URL.RawQuery)
 			assert.Equal(t, tc.expected, decodedQuery)
 		})
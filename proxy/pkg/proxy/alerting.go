@@ -0,0 +1,259 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/alertquery"
+	"k8s.io/klog/v2"
+)
+
+const (
+	apiAlertsPath            = "/api/v1/alerts"
+	apiRulesPath             = "/api/v1/rules"
+	alertmanagerAPIPrefix    = "/api/v2/"
+	alertmanagerAlertsPath   = "/api/v2/alerts"
+	alertmanagerSilencesPath = "/api/v2/silences"
+	alertmanagerSilencePath  = "/api/v2/silence/"
+)
+
+// userMetricsAccessKey is the request context key holding the ACLs used to filter alerting responses.
+// It is only set for users that don't have access to all clusters and namespaces.
+type userMetricsAccessKey struct{}
+
+// errSilenceNotFound is returned when a silence doesn't exist or is not visible to the user.
+var errSilenceNotFound = errors.New("silence not found")
+
+// SetAlertmanager enables proxying of the Alertmanager alerts and silences APIs to the given server.
+// The transport is expected to carry the proxy's own credentials, as access is enforced by the proxy.
+func (p *Proxy) SetAlertmanager(serverURL *url.URL, transport http.RoundTripper) {
+	p.alertmanagerURL = serverURL
+	p.alertmanagerClient = &http.Client{Transport: transport}
+	p.alertmanagerProxy = &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = serverURL.Scheme
+			req.URL.Host = serverURL.Host
+			req.URL.Path = path.Join(serverURL.Path, req.URL.Path)
+			req.Host = serverURL.Host
+			// The user's credentials must not be forwarded, the transport authenticates the proxy itself.
+			req.Header.Del("Authorization")
+			req.Header.Del("X-Forwarded-Access-Token")
+			// Let the transport handle the compression so that responses can be filtered.
+			req.Header.Del("Accept-Encoding")
+		},
+		Transport:      transport,
+		ModifyResponse: filterAlertingResponse,
+	}
+}
+
+func newAlertingProxy(serverURL *url.URL, transport http.RoundTripper) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = serverURL.Scheme
+			req.URL.Host = serverURL.Host
+			req.Host = serverURL.Host
+			req.Header.Del("Accept-Encoding")
+		},
+		Transport:      transport,
+		ModifyResponse: filterAlertingResponse,
+	}
+}
+
+// isAlertingPath returns true for the alerts, rules and Alertmanager API paths.
+func isAlertingPath(urlPath string) bool {
+	return urlPath == apiAlertsPath || urlPath == apiRulesPath || strings.HasPrefix(urlPath, alertmanagerAPIPrefix)
+}
+
+// serveAlerting proxies the alerting APIs, enforcing the user's cluster and namespace ACLs on both
+// the requests that modify silences and the responses listing alerts, rules and silences.
+func (p *Proxy) serveAlerting(res http.ResponseWriter, req *http.Request) {
+	if err := p.preCheckRequest(req); err != nil {
+		klog.Warningf("pre-check failed for user <%s>: %v", req.Header.Get("X-Forwarded-User"), err)
+		http.Error(res, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	isAlertmanager := strings.HasPrefix(req.URL.Path, alertmanagerAPIPrefix)
+	if isAlertmanager && p.alertmanagerProxy == nil {
+		http.Error(res, "alertmanager proxy is not configured", http.StatusNotFound)
+		return
+	}
+
+	if status, err := checkAlertingMethod(req); err != nil {
+		http.Error(res, err.Error(), status)
+		return
+	}
+
+	access, allAccess, err := p.newModifier(req).GetUserMetricsAccess()
+	if err != nil {
+		klog.Errorf("failed to get user metrics access: %v", err)
+		http.Error(res, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if !allAccess {
+		if err := p.checkSilenceRequest(req, access); err != nil {
+			klog.Warningf("rejected silence request for user <%s>: %v", req.Header.Get("X-Forwarded-User"), err)
+			status := http.StatusForbidden
+			if errors.Is(err, errSilenceNotFound) {
+				status = http.StatusNotFound
+			}
+			http.Error(res, err.Error(), status)
+			return
+		}
+		req = req.WithContext(context.WithValue(req.Context(), userMetricsAccessKey{}, access))
+	}
+
+	req.Header.Set("X-Forwarded-Host", req.Header.Get("Host"))
+	if isAlertmanager {
+		p.alertmanagerProxy.ServeHTTP(res, req)
+		return
+	}
+
+	req.Host = p.metricsServerURL.Host
+	req.URL.Path = path.Join(basePath, req.URL.Path)
+	p.alertingProxy.ServeHTTP(res, req)
+}
+
+// checkAlertingMethod restricts the supported methods, only silences can be modified through the proxy.
+func checkAlertingMethod(req *http.Request) (int, error) {
+	allowed := []string{http.MethodGet}
+	switch {
+	case req.URL.Path == alertmanagerSilencesPath:
+		allowed = append(allowed, http.MethodPost)
+	case strings.HasPrefix(req.URL.Path, alertmanagerSilencePath):
+		allowed = append(allowed, http.MethodDelete)
+	case req.URL.Path == apiAlertsPath, req.URL.Path == apiRulesPath, req.URL.Path == alertmanagerAlertsPath:
+	default:
+		return http.StatusNotFound, fmt.Errorf("unsupported alertmanager API path %s", req.URL.Path)
+	}
+
+	for _, m := range allowed {
+		if req.Method == m {
+			return http.StatusOK, nil
+		}
+	}
+	return http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed on %s", req.Method, req.URL.Path)
+}
+
+// checkSilenceRequest ensures that a user only creates, updates or expires silences on permitted clusters.
+func (p *Proxy) checkSilenceRequest(req *http.Request, access map[string][]string) error {
+	switch {
+	case req.Method == http.MethodPost && req.URL.Path == alertmanagerSilencesPath:
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		if err := alertquery.CheckSilence(body, access); err != nil {
+			return err
+		}
+
+		// Updating a silence expires the existing one, which must be permitted as well.
+		existing := struct {
+			ID string `json:"id"`
+		}{}
+		if err := json.Unmarshal(body, &existing); err != nil {
+			return fmt.Errorf("failed to decode silence: %w", err)
+		}
+		if existing.ID != "" {
+			return p.checkExistingSilence(req.Context(), existing.ID, access)
+		}
+	case req.Method == http.MethodDelete:
+		return p.checkExistingSilence(req.Context(), strings.TrimPrefix(req.URL.Path, alertmanagerSilencePath), access)
+	}
+	return nil
+}
+
+func (p *Proxy) checkExistingSilence(ctx context.Context, id string, access map[string][]string) error {
+	silenceURL := *p.alertmanagerURL
+	silenceURL.Path = path.Join(silenceURL.Path, alertmanagerSilencePath, id)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, silenceURL.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.alertmanagerClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get silence %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errSilenceNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get silence %s: unexpected status code %d", id, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read silence %s: %w", id, err)
+	}
+
+	if err := alertquery.CheckSilence(body, access); err != nil {
+		klog.V(1).Infof("silence %s is not accessible: %v", id, err)
+		return errSilenceNotFound
+	}
+	return nil
+}
+
+// filterAlertingResponse removes the alerts, rules alerts and silences that the user can't access from
+// successful responses. It is a no-op for requests made by users with access to all clusters.
+func filterAlertingResponse(resp *http.Response) error {
+	access, ok := resp.Request.Context().Value(userMetricsAccessKey{}).(map[string][]string)
+	if !ok || resp.StatusCode != http.StatusOK || resp.Request.Method != http.MethodGet {
+		return nil
+	}
+
+	urlPath := resp.Request.URL.Path
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	var filtered []byte
+	switch {
+	case strings.HasSuffix(urlPath, apiAlertsPath):
+		filtered, err = alertquery.FilterPrometheusAlerts(body, access)
+	case strings.HasSuffix(urlPath, apiRulesPath):
+		filtered, err = alertquery.FilterPrometheusRules(body, access)
+	case strings.HasSuffix(urlPath, alertmanagerAlertsPath):
+		filtered, err = alertquery.FilterAlertmanagerAlerts(body, access)
+	case strings.HasSuffix(urlPath, alertmanagerSilencesPath):
+		filtered, err = alertquery.FilterSilences(body, access)
+	case strings.Contains(urlPath, alertmanagerSilencePath):
+		filtered = body
+		if alertquery.CheckSilence(body, access) != nil {
+			resp.StatusCode = http.StatusNotFound
+			resp.Status = fmt.Sprintf("%d %s", http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			filtered = []byte(strconv.Quote(errSilenceNotFound.Error()))
+		}
+	default:
+		filtered = body
+	}
+	if err != nil {
+		return fmt.Errorf("failed to filter response for %s: %w", urlPath, err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(filtered))
+	resp.ContentLength = int64(len(filtered))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(filtered)))
+	return nil
+}
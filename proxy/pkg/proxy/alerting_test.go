@@ -0,0 +1,225 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	userv1 "github.com/openshift/api/user/v1"
+	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestAlertingProxy(t *testing.T, metricsServerURL *url.URL, access map[string][]string) *Proxy {
+	cfg := &rest.Config{Host: metricsServerURL.Host}
+	upi := cache.NewUserProjectInfo(t.Context(), time.Minute, time.Minute)
+	mockInformer := &MockManagedClusterInformer{
+		clusters: map[string]struct{}{"cluster1": {}, "cluster2": {}},
+	}
+
+	p, err := NewProxy(cfg, metricsServerURL, http.DefaultTransport, upi, mockInformer, &MockAccessReviewer{metricsAccess: access})
+	assert.NoError(t, err)
+
+	scheme := runtime.NewScheme()
+	_ = userv1.AddToScheme(scheme)
+	_ = projectv1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(&userv1.User{ObjectMeta: metav1.ObjectMeta{Name: "~"}}).
+		WithLists(&projectv1.ProjectList{}).
+		Build()
+	p.getKubeClientWithTokenFunc = func(token string) (client.Client, error) {
+		return fakeClient, nil
+	}
+	return p
+}
+
+func TestServeAlertingPrometheusAPI(t *testing.T) {
+	var upstreamPath string
+	metricsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"alerts":[
+			{"labels":{"cluster":"cluster1"},"state":"firing"},
+			{"labels":{"cluster":"cluster2"},"state":"firing"}
+		]}}`))
+	}))
+	defer metricsServer.Close()
+	metricsServerURL, err := url.Parse(metricsServer.URL)
+	assert.NoError(t, err)
+
+	t.Run("scoped user gets filtered alerts", func(t *testing.T) {
+		p := newTestAlertingProxy(t, metricsServerURL, map[string][]string{"cluster1": {"*"}})
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/api/v1/alerts", nil)
+		req.Header.Set("X-Forwarded-Access-Token", "scoped-token")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, basePath+apiAlertsPath, upstreamPath)
+		assert.JSONEq(t, `{"status":"success","data":{"alerts":[{"labels":{"cluster":"cluster1"},"state":"firing"}]}}`, w.Body.String())
+	})
+
+	t.Run("admin user gets all alerts", func(t *testing.T) {
+		p := newTestAlertingProxy(t, metricsServerURL, map[string][]string{"*": {"*"}})
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/api/v1/alerts", nil)
+		req.Header.Set("X-Forwarded-Access-Token", "admin-token")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "cluster2")
+	})
+
+	t.Run("alertmanager is not configured", func(t *testing.T) {
+		p := newTestAlertingProxy(t, metricsServerURL, map[string][]string{"cluster1": {"*"}})
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/api/v2/alerts", nil)
+		req.Header.Set("X-Forwarded-Access-Token", "scoped-token")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestServeAlertingAlertmanagerAPI(t *testing.T) {
+	var upstreamCalls []string
+	var upstreamAuth string
+	alertmanagerServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls = append(upstreamCalls, r.Method+" "+r.URL.Path)
+		upstreamAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/prefix"+alertmanagerSilencesPath && r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`[
+				{"id":"1","matchers":[{"name":"cluster","value":"cluster1","isRegex":false}]},
+				{"id":"2","matchers":[{"name":"cluster","value":"cluster2","isRegex":false}]}
+			]`))
+		case r.URL.Path == "/prefix"+alertmanagerSilencePath+"2":
+			_, _ = w.Write([]byte(`{"id":"2","matchers":[{"name":"cluster","value":"cluster2","isRegex":false}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"silenceID":"3"}`))
+		}
+	}))
+	defer alertmanagerServer.Close()
+	alertmanagerURL, err := url.Parse(alertmanagerServer.URL + "/prefix")
+	assert.NoError(t, err)
+	metricsServerURL, err := url.Parse("http://localhost:1")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedCode   int
+		expectedBody   string
+		expectedCalled []string
+	}{
+		{
+			name:           "list silences is filtered",
+			method:         http.MethodGet,
+			path:           alertmanagerSilencesPath,
+			expectedCode:   http.StatusOK,
+			expectedBody:   `[{"id":"1","matchers":[{"name":"cluster","value":"cluster1","isRegex":false}]}]`,
+			expectedCalled: []string{"GET /prefix" + alertmanagerSilencesPath},
+		},
+		{
+			name:           "create silence on allowed cluster",
+			method:         http.MethodPost,
+			path:           alertmanagerSilencesPath,
+			body:           `{"matchers":[{"name":"cluster","value":"cluster1","isRegex":false}]}`,
+			expectedCode:   http.StatusOK,
+			expectedCalled: []string{"POST /prefix" + alertmanagerSilencesPath},
+		},
+		{
+			name:         "create silence on forbidden cluster",
+			method:       http.MethodPost,
+			path:         alertmanagerSilencesPath,
+			body:         `{"matchers":[{"name":"cluster","value":"cluster2","isRegex":false}]}`,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:           "update forbidden silence",
+			method:         http.MethodPost,
+			path:           alertmanagerSilencesPath,
+			body:           `{"id":"2","matchers":[{"name":"cluster","value":"cluster1","isRegex":false}]}`,
+			expectedCode:   http.StatusNotFound,
+			expectedCalled: []string{"GET /prefix" + alertmanagerSilencePath + "2"},
+		},
+		{
+			name:           "expire forbidden silence",
+			method:         http.MethodDelete,
+			path:           alertmanagerSilencePath + "2",
+			expectedCode:   http.StatusNotFound,
+			expectedCalled: []string{"GET /prefix" + alertmanagerSilencePath + "2"},
+		},
+		{
+			name:           "get forbidden silence",
+			method:         http.MethodGet,
+			path:           alertmanagerSilencePath + "2",
+			expectedCode:   http.StatusNotFound,
+			expectedCalled: []string{"GET /prefix" + alertmanagerSilencePath + "2"},
+		},
+		{
+			name:         "post alerts is not allowed",
+			method:       http.MethodPost,
+			path:         alertmanagerAlertsPath,
+			body:         `[]`,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "unsupported path",
+			method:       http.MethodGet,
+			path:         "/api/v2/status",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			upstreamCalls = nil
+			upstreamAuth = ""
+			p := newTestAlertingProxy(t, metricsServerURL, map[string][]string{"cluster1": {"*"}})
+			p.SetAlertmanager(alertmanagerURL, http.DefaultTransport)
+
+			var body io.Reader
+			if tc.body != "" {
+				body = strings.NewReader(tc.body)
+			}
+			req := httptest.NewRequest(tc.method, "http://localhost"+tc.path, body)
+			req.Header.Set("Authorization", "Bearer user-token")
+			w := httptest.NewRecorder()
+			p.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			assert.Equal(t, tc.expectedCalled, upstreamCalls)
+			assert.Empty(t, upstreamAuth, "user credentials must not be forwarded to alertmanager")
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestIsAlertingPath(t *testing.T) {
+	assert.True(t, isAlertingPath("/api/v1/alerts"))
+	assert.True(t, isAlertingPath("/api/v1/rules"))
+	assert.True(t, isAlertingPath("/api/v2/silences"))
+	assert.True(t, isAlertingPath("/api/v2/silence/abc"))
+	assert.False(t, isAlertingPath("/api/v1/query"))
+	assert.False(t, isAlertingPath("/api/v1/series"))
+}
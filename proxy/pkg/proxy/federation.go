@@ -0,0 +1,401 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/alertquery"
+	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/guardrail"
+	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/util"
+	"k8s.io/klog/v2"
+)
+
+const (
+	federationPathPrefix = "/federation"
+	// hubLabel is the label added to the series and alerts of the federated responses.
+	hubLabel = "hub"
+	// hubParam selects the hubs of a federated request, all the hubs the user can query by default.
+	hubParam = "hub"
+)
+
+// FederationPeer is a peer hub queried in the global query mode.
+type FederationPeer struct {
+	// Name is the value of the hub label of the series and alerts of the peer hub.
+	Name string
+	// URL is the URL of the Observatorium API of the peer hub.
+	URL *url.URL
+	// Transport carries the client certificate the peer hub grants read access to.
+	Transport http.RoundTripper
+}
+
+type federation struct {
+	hub   string
+	peers []FederationPeer
+}
+
+// hubResponse is the response of a hub to a federated request.
+type hubResponse struct {
+	hub        string
+	statusCode int
+	body       []byte
+	err        error
+}
+
+// apiResponse is the envelope of the Prometheus HTTP API responses.
+type apiResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	ErrorType string          `json:"errorType,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Warnings  []string        `json:"warnings,omitempty"`
+}
+
+// SetFederation enables the global query mode: the queries and alerts of the /federation path are fanned
+// out to this hub, named hub, and to the peer hubs the user can query, and the results are labeled with the hub.
+func (p *Proxy) SetFederation(hub string, peers []FederationPeer) {
+	p.federation = &federation{hub: hub, peers: peers}
+}
+
+func isFederationPath(urlPath string) bool {
+	return urlPath == federationPathPrefix || strings.HasPrefix(urlPath, federationPathPrefix+"/")
+}
+
+// serveFederation fans out the query, range query and alerts requests to the hubs. The cluster and namespace
+// ACLs of the user apply to this hub, the peer hubs are queried entirely when the user can access them. A hub
+// which fails to respond is reported in the warnings of the response.
+func (p *Proxy) serveFederation(res http.ResponseWriter, req *http.Request) {
+	if p.federation == nil {
+		http.Error(res, "federation is not configured", http.StatusNotFound)
+		return
+	}
+	apiPath := strings.TrimPrefix(req.URL.Path, federationPathPrefix)
+	if apiPath != apiQueryPath && apiPath != apiQueryRangePath && apiPath != apiAlertsPath {
+		http.Error(res, fmt.Sprintf("unsupported federation API path %s", apiPath), http.StatusNotFound)
+		return
+	}
+	if req.Method != http.MethodGet && (req.Method != http.MethodPost || apiPath == apiAlertsPath) {
+		http.Error(res, fmt.Sprintf("method %s is not allowed on %s", req.Method, req.URL.Path), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := p.preCheckRequest(req); err != nil {
+		klog.Warningf("pre-check failed for user <%s>: %v", req.Header.Get("X-Forwarded-User"), err)
+		http.Error(res, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	values, err := requestValues(req)
+	if err != nil {
+		writeBadDataResponse(res, err.Error())
+		return
+	}
+	hubs, err := p.userHubs(req, values[hubParam])
+	if err != nil {
+		klog.Errorf("failed to get the hubs of user <%s>: %v", req.Header.Get("X-Forwarded-User"), err)
+		http.Error(res, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	values.Del(hubParam)
+
+	if query := values.Get("query"); query != "" {
+		if policy := p.guardrailsPolicy(req); policy != nil {
+			query, err = guardrail.Evaluate(query, policy)
+			var guardrailErr *guardrail.Error
+			if errors.As(err, &guardrailErr) {
+				klog.Infof("rejected query for user <%s>: %v", req.Header.Get("X-Forwarded-User"), err)
+				writeBadDataResponse(res, guardrailErr.Error())
+				return
+			}
+			if err != nil {
+				klog.Errorf("failed to evaluate query guardrails: %v", err)
+				http.Error(res, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			values.Set("query", query)
+		}
+	}
+
+	responses := make([]hubResponse, len(hubs))
+	var wg sync.WaitGroup
+	for i, hub := range hubs {
+		wg.Go(func() {
+			responses[i] = p.requestHub(req, hub, apiPath, values)
+		})
+	}
+	wg.Wait()
+
+	statusCode, body := mergeHubResponses(apiPath, responses)
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(statusCode)
+	if _, err := res.Write(body); err != nil {
+		klog.Errorf("failed to write response: %v", err)
+	}
+}
+
+// requestValues returns the query parameters of a GET request, or the form of a POST request.
+func requestValues(req *http.Request) (url.Values, error) {
+	if req.Method != http.MethodPost {
+		return req.URL.Query(), nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+	return values, nil
+}
+
+// userHubs returns this hub and the peer hubs the user can query, restricted to the selected ones if any.
+func (p *Proxy) userHubs(req *http.Request, selected []string) ([]string, error) {
+	token := req.Header.Get("X-Forwarded-Access-Token")
+	peerHubs, ok := p.userProjectInfo.GetUserHubs(token)
+	if !ok {
+		names := make([]string, 0, len(p.federation.peers))
+		for _, peer := range p.federation.peers {
+			names = append(names, peer.Name)
+		}
+		c, err := p.getKubeClientWithTokenFunc(token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get kube client: %w", err)
+		}
+		peerHubs, err = util.GetUserHubs(req.Context(), c, names)
+		if err != nil {
+			return nil, err
+		}
+		p.userProjectInfo.UpdateUserHubs(token, peerHubs)
+	}
+
+	hubs := append([]string{p.federation.hub}, peerHubs...)
+	if len(selected) == 0 {
+		return hubs, nil
+	}
+	return slices.DeleteFunc(hubs, func(hub string) bool { return !slices.Contains(selected, hub) }), nil
+}
+
+// requestHub sends the federated request to a hub. The ACLs of the user are enforced on this hub.
+func (p *Proxy) requestHub(req *http.Request, hub, apiPath string, values url.Values) hubResponse {
+	response := hubResponse{hub: hub}
+	serverURL, transport := p.metricsServerURL, p.metricsTransport
+	if hub != p.federation.hub {
+		idx := slices.IndexFunc(p.federation.peers, func(peer FederationPeer) bool { return peer.Name == hub })
+		serverURL, transport = p.federation.peers[idx].URL, p.federation.peers[idx].Transport
+	}
+
+	hubReq, err := newHubRequest(req.Context(), serverURL, apiPath, values)
+	if err != nil {
+		response.err = err
+		return response
+	}
+
+	var access map[string][]string
+	if hub == p.federation.hub {
+		hubReq.Header.Set("X-Forwarded-User", req.Header.Get("X-Forwarded-User"))
+		hubReq.Header.Set("X-Forwarded-Access-Token", req.Header.Get("X-Forwarded-Access-Token"))
+		modifier := p.newModifier(hubReq)
+		// the guardrails were already applied to the federated request
+		modifier.Guardrails = nil
+		if apiPath == apiAlertsPath {
+			var allAccess bool
+			access, allAccess, err = modifier.GetUserMetricsAccess()
+			if allAccess {
+				access = nil
+			}
+		} else {
+			err = modifier.Modify()
+		}
+		if err != nil {
+			response.err = fmt.Errorf("failed to enforce the user metrics access: %w", err)
+			return response
+		}
+		// Only the user's ACLs authorize the request, the transport authenticates the proxy itself.
+		hubReq.Header.Del("X-Forwarded-Access-Token")
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Do(hubReq)
+	if err != nil {
+		response.err = err
+		return response
+	}
+	defer resp.Body.Close()
+	response.statusCode = resp.StatusCode
+	response.body, response.err = io.ReadAll(resp.Body)
+	if response.err == nil && access != nil && resp.StatusCode == http.StatusOK {
+		response.body, response.err = alertquery.FilterPrometheusAlerts(response.body, access)
+	}
+	return response
+}
+
+// newHubRequest returns the request of the API path of the default tenant of the hub Observatorium API.
+// The queries are sent as forms, the alerts requests have no parameters.
+func newHubRequest(ctx context.Context, serverURL *url.URL, apiPath string, values url.Values) (*http.Request, error) {
+	hubURL := url.URL{
+		Scheme: serverURL.Scheme,
+		Host:   serverURL.Host,
+		Path:   basePath + apiPath,
+	}
+	if apiPath == apiAlertsPath {
+		return http.NewRequestWithContext(ctx, http.MethodGet, hubURL.String(), nil)
+	}
+	form := values.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hubURL.String(), strings.NewReader(form))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// mergeHubResponses merges the successful responses of the hubs, adding the hub label to their series or
+// alerts. The failed hubs are reported in the warnings. The error of the first hub is returned when all of
+// them failed.
+func mergeHubResponses(apiPath string, responses []hubResponse) (int, []byte) {
+	merged := apiResponse{Status: "success"}
+	var results [][]json.RawMessage
+	var resultType string
+	var firstFailure *hubResponse
+	for i := range responses {
+		r := &responses[i]
+		var resp apiResponse
+		if r.err == nil && r.statusCode != http.StatusOK {
+			r.err = fmt.Errorf("unexpected status code %d", r.statusCode)
+		}
+		if r.err == nil {
+			if err := json.Unmarshal(r.body, &resp); err != nil {
+				r.err = fmt.Errorf("failed to decode response: %w", err)
+			}
+		}
+		var items []json.RawMessage
+		var itemsType string
+		if r.err == nil {
+			items, itemsType, r.err = labelHubItems(apiPath, resp.Data, r.hub)
+		}
+		if r.err == nil && resultType != "" && itemsType != resultType {
+			r.err = fmt.Errorf("result type %s differs from %s", itemsType, resultType)
+		}
+		if r.err != nil {
+			klog.Warningf("federated request to hub %s failed: %v", r.hub, r.err)
+			merged.Warnings = append(merged.Warnings, fmt.Sprintf("hub %s: %v", r.hub, r.err))
+			if firstFailure == nil {
+				firstFailure = r
+			}
+			continue
+		}
+		resultType = itemsType
+		results = append(results, items)
+		merged.Warnings = append(merged.Warnings, resp.Warnings...)
+	}
+
+	if len(results) == 0 && firstFailure != nil {
+		switch {
+		case firstFailure.statusCode == http.StatusOK:
+			// the response can't be federated
+			body, _ := json.Marshal(apiResponse{Status: "error", ErrorType: "bad_data", Error: firstFailure.err.Error()})
+			return http.StatusBadRequest, body
+		case firstFailure.statusCode != 0 && len(firstFailure.body) > 0:
+			return firstFailure.statusCode, firstFailure.body
+		}
+		body, _ := json.Marshal(apiResponse{Status: "error", ErrorType: "unavailable", Error: firstFailure.err.Error()})
+		return http.StatusBadGateway, body
+	}
+
+	items := slices.Concat(results...)
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+	var data any
+	if apiPath == apiAlertsPath {
+		data = struct {
+			Alerts []json.RawMessage `json:"alerts"`
+		}{Alerts: items}
+	} else {
+		if resultType == "" {
+			resultType = "vector"
+			if apiPath == apiQueryRangePath {
+				resultType = "matrix"
+			}
+		}
+		data = struct {
+			ResultType string            `json:"resultType"`
+			Result     []json.RawMessage `json:"result"`
+		}{ResultType: resultType, Result: items}
+	}
+	var err error
+	if merged.Data, err = json.Marshal(data); err != nil {
+		body, _ := json.Marshal(apiResponse{Status: "error", ErrorType: "internal", Error: err.Error()})
+		return http.StatusInternalServerError, body
+	}
+	body, err := json.Marshal(merged)
+	if err != nil {
+		return http.StatusInternalServerError, []byte(`{"status":"error","errorType":"internal"}`)
+	}
+	return http.StatusOK, body
+}
+
+// labelHubItems returns the series or alerts of the response data, with the hub label. The scalar and string
+// results can't be labeled, and are rejected.
+func labelHubItems(apiPath string, data json.RawMessage, hub string) ([]json.RawMessage, string, error) {
+	labelsKey := "metric"
+	var items []map[string]json.RawMessage
+	var resultType string
+	if apiPath == apiAlertsPath {
+		labelsKey = "labels"
+		alerts := struct {
+			Alerts []map[string]json.RawMessage `json:"alerts"`
+		}{}
+		if err := json.Unmarshal(data, &alerts); err != nil {
+			return nil, "", fmt.Errorf("failed to decode alerts: %w", err)
+		}
+		items = alerts.Alerts
+	} else {
+		result := struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		}{}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, "", fmt.Errorf("failed to decode query result: %w", err)
+		}
+		if result.ResultType != "vector" && result.ResultType != "matrix" {
+			return nil, "", fmt.Errorf("%s results can't be federated", result.ResultType)
+		}
+		resultType = result.ResultType
+		if err := json.Unmarshal(result.Result, &items); err != nil {
+			return nil, "", fmt.Errorf("failed to decode query result: %w", err)
+		}
+	}
+
+	labeled := make([]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		labels := map[string]string{}
+		if raw, ok := item[labelsKey]; ok {
+			if err := json.Unmarshal(raw, &labels); err != nil {
+				return nil, "", fmt.Errorf("failed to decode labels: %w", err)
+			}
+		}
+		labels[hubLabel] = hub
+		raw, err := json.Marshal(labels)
+		if err != nil {
+			return nil, "", err
+		}
+		item[labelsKey] = raw
+		if raw, err = json.Marshal(item); err != nil {
+			return nil, "", err
+		}
+		labeled = append(labeled, raw)
+	}
+	return labeled, resultType, nil
+}
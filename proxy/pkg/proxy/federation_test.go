@@ -0,0 +1,170 @@
+// Copyright (c) Red Hat, Inc.
+// Copyright Contributors to the Open Cluster Management project
+// Licensed under the Apache License 2.0
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// newHubServer returns an Observatorium API answering the queries with a series, and the alerts with an alert.
+func newHubServer(t *testing.T, queries chan<- string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case basePath + apiQueryPath:
+			require.NoError(t, r.ParseForm())
+			if queries != nil {
+				queries <- r.PostForm.Get("query")
+			}
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` +
+				`{"metric":{"__name__":"up","cluster":"cluster1"},"value":[1,"1"]}]}}`))
+		case basePath + apiAlertsPath:
+			_, _ = w.Write([]byte(`{"status":"success","data":{"alerts":[` +
+				`{"labels":{"alertname":"Down","cluster":"cluster1"},"state":"firing"},` +
+				`{"labels":{"alertname":"Down","cluster":"cluster2"},"state":"firing"}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestServeFederation(t *testing.T) {
+	localQueries := make(chan string, 10)
+	local := newHubServer(t, localQueries)
+	peerB := newHubServer(t, nil)
+	peerC := newHubServer(t, nil)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+
+	parseURL := func(raw string) *url.URL {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		return u
+	}
+	upi := cache.NewUserProjectInfo(t.Context(), time.Minute, time.Minute)
+	mockInformer := &MockManagedClusterInformer{clusters: map[string]struct{}{"cluster1": {}, "cluster2": {}}}
+	mockAccessReviewer := &MockAccessReviewer{metricsAccess: map[string][]string{"cluster1": {"*"}}}
+	p, err := NewProxy(&rest.Config{Host: "localhost"}, parseURL(local.URL), http.DefaultTransport, upi, mockInformer, mockAccessReviewer)
+	require.NoError(t, err)
+	p.SetFederation("hub-a", []FederationPeer{
+		{Name: "hub-b", URL: parseURL(peerB.URL), Transport: http.DefaultTransport},
+		{Name: "hub-c", URL: parseURL(peerC.URL), Transport: http.DefaultTransport},
+		{Name: "hub-d", URL: parseURL(failing.URL), Transport: http.DefaultTransport},
+	})
+
+	scheme := runtime.NewScheme()
+	_ = projectv1.AddToScheme(scheme)
+	_ = authorizationv1.AddToScheme(scheme)
+	p.getKubeClientWithTokenFunc = func(token string) (client.Client, error) {
+		return fake.NewClientBuilder().WithScheme(scheme).
+			WithLists(&projectv1.ProjectList{Items: []projectv1.Project{{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}}}}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					if review, ok := obj.(*authorizationv1.SelfSubjectAccessReview); ok {
+						// the user can query the hubs b and d, not c
+						review.Status.Allowed = review.Spec.ResourceAttributes.Name != "hub-c"
+						return nil
+					}
+					return c.Create(ctx, obj, opts...)
+				},
+			}).Build(), nil
+	}
+
+	serve := func(method, target string, body string) (*httptest.ResponseRecorder, apiResponse) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if method == http.MethodPost {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		req.Header.Set("X-Forwarded-User", "test")
+		req.Header.Set("X-Forwarded-Access-Token", "test")
+		recorder := httptest.NewRecorder()
+		p.ServeHTTP(recorder, req)
+		var resp apiResponse
+		_ = json.Unmarshal(recorder.Body.Bytes(), &resp)
+		return recorder, resp
+	}
+	hubsOf := func(t *testing.T, data json.RawMessage, key string) []string {
+		var items []struct {
+			Metric map[string]string `json:"metric"`
+			Labels map[string]string `json:"labels"`
+		}
+		result := map[string]json.RawMessage{}
+		require.NoError(t, json.Unmarshal(data, &result))
+		require.NoError(t, json.Unmarshal(result[key], &items))
+		var hubs []string
+		for _, item := range items {
+			hubs = append(hubs, item.Metric[hubLabel]+item.Labels[hubLabel])
+		}
+		return hubs
+	}
+
+	t.Run("query", func(t *testing.T) {
+		recorder, resp := serve(http.MethodGet, "http://localhost/federation/api/v1/query?query=up", "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "success", resp.Status)
+		assert.Equal(t, []string{"hub-a", "hub-b"}, hubsOf(t, resp.Data, "result"))
+		require.Len(t, resp.Warnings, 1)
+		assert.Contains(t, resp.Warnings[0], "hub hub-d")
+		// the cluster ACLs of the user apply to the local hub
+		assert.Contains(t, <-localQueries, "cluster1")
+	})
+
+	t.Run("selected hubs", func(t *testing.T) {
+		recorder, resp := serve(http.MethodPost, "http://localhost/federation/api/v1/query", "query=up&hub=hub-b&hub=hub-c")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, []string{"hub-b"}, hubsOf(t, resp.Data, "result"))
+		assert.Empty(t, resp.Warnings)
+	})
+
+	t.Run("alerts", func(t *testing.T) {
+		recorder, resp := serve(http.MethodGet, "http://localhost/federation/api/v1/alerts?hub=hub-a&hub=hub-b", "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		// the alerts of the clusters the user can't access are filtered out on the local hub only
+		assert.Equal(t, []string{"hub-a", "hub-b", "hub-b"}, hubsOf(t, resp.Data, "alerts"))
+	})
+
+	t.Run("unsupported path", func(t *testing.T) {
+		recorder, _ := serve(http.MethodGet, "http://localhost/federation/api/v1/series", "")
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("all hubs failed", func(t *testing.T) {
+		recorder, _ := serve(http.MethodGet, "http://localhost/federation/api/v1/query?query=up&hub=hub-d", "")
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	})
+}
+
+func TestMergeHubResponsesScalar(t *testing.T) {
+	statusCode, body := mergeHubResponses(apiQueryPath, []hubResponse{{
+		hub:        "hub-a",
+		statusCode: http.StatusOK,
+		body:       []byte(`{"status":"success","data":{"resultType":"scalar","result":[1,"1"]}}`),
+	}})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	assert.Contains(t, string(body), "scalar results can't be federated")
+}
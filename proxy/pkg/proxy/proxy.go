@@ -14,12 +14,14 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"path"
+	"regexp"
 	"slices"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/cache"
 	proxyconfig "github.com/stolostron/multicluster-observability-operator/proxy/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/guardrail"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/health"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/informer"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/metricquery"
@@ -32,6 +34,8 @@ import (
 
 const (
 	basePath               = "/api/metrics/v1/default"
+	tenantBasePath         = "/api/metrics/v1/"
+	tenantsPathPrefix      = "/tenants/"
 	projectsAPIPath        = "/apis/project.openshift.io/v1/projects"
 	userAPIPath            = "/apis/user.openshift.io/v1/users/~"
 	apiSeriesPath          = "/api/v1/series"
@@ -40,11 +44,18 @@ const (
 	apiQueryRangePath      = "/api/v1/query_range"
 )
 
+var tenantNameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
 // Proxy is a reverse proxy for the metrics server.
 type Proxy struct {
 	metricsServerURL       *url.URL
+	metricsTransport       http.RoundTripper
 	apiServerHost          string
 	proxy                  *httputil.ReverseProxy
+	alertingProxy          *httputil.ReverseProxy
+	alertmanagerURL        *url.URL
+	alertmanagerProxy      *httputil.ReverseProxy
+	alertmanagerClient     *http.Client
 	userProjectInfo        *cache.UserProjectInfo
 	managedClusterInformer informer.ManagedClusterInformable
 	accessReviewer         metricquery.AccessReviewer
@@ -52,6 +63,8 @@ type Proxy struct {
 	// getKubeClientWithTokenFunc is used for dependency injection in tests.
 	getKubeClientWithTokenFunc func(token string) (client.Client, error)
 	healthChecker              *health.Checker
+	guardrails                 guardrail.PolicyProvider
+	federation                 *federation
 }
 
 // NewProxy creates a new Proxy.
@@ -69,6 +82,7 @@ func NewProxy(
 	}
 	p := &Proxy{
 		metricsServerURL:       serverURL,
+		metricsTransport:       transport,
 		apiServerHost:          cfg.Host,
 		userProjectInfo:        upi,
 		managedClusterInformer: managedClusterInformer,
@@ -87,6 +101,7 @@ func NewProxy(
 		},
 		Transport: transport,
 	}
+	p.alertingProxy = newAlertingProxy(serverURL, transport)
 
 	return p, nil
 }
@@ -98,6 +113,22 @@ func (p *Proxy) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if isFederationPath(req.URL.Path) {
+		p.serveFederation(res, req)
+		return
+	}
+
+	metricsBasePath, err := tenantMetricsBasePath(req)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if isAlertingPath(req.URL.Path) {
+		p.serveAlerting(res, req)
+		return
+	}
+
 	if err := p.preCheckRequest(req); err != nil {
 		klog.Warningf("pre-check failed for user <%s>: %v", req.Header.Get("X-Forwarded-User"), err)
 		res.Header().Set("Content-Type", "application/json")
@@ -114,21 +145,62 @@ func (p *Proxy) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 
 	req.Header.Set("X-Forwarded-Host", req.Header.Get("Host"))
 	req.Host = p.metricsServerURL.Host
-	req.URL.Path = path.Join(basePath, req.URL.Path)
-	modifier := &metricquery.Modifier{
+	req.URL.Path = path.Join(metricsBasePath, req.URL.Path)
+	if err := p.newModifier(req).Modify(); err != nil {
+		var guardrailErr *guardrail.Error
+		if errors.As(err, &guardrailErr) {
+			klog.Infof("rejected query for user <%s>: %v", req.Header.Get("X-Forwarded-User"), err)
+			writeBadDataResponse(res, guardrailErr.Error())
+			return
+		}
+		klog.Errorf("failed to modify query: %v", err)
+		http.Error(res, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	p.proxy.ServeHTTP(res, req)
+}
+
+// tenantMetricsBasePath returns the Observatorium API base path of the tenant selected by the
+// "/tenants/<name>" prefix of the request path, and strips the prefix from the request. Requests
+// without prefix query the default tenant.
+func tenantMetricsBasePath(req *http.Request) (string, error) {
+	rest, found := strings.CutPrefix(req.URL.Path, tenantsPathPrefix)
+	if !found {
+		return basePath, nil
+	}
+	tenant, apiPath, _ := strings.Cut(rest, "/")
+	if !tenantNameRegexp.MatchString(tenant) {
+		return "", fmt.Errorf("invalid tenant %q", tenant)
+	}
+	req.URL.Path = "/" + apiPath
+	req.URL.RawPath = ""
+	return tenantBasePath + tenant, nil
+}
+
+// SetGuardrails enables the evaluation of queries against the guardrails policies of the provider.
+func (p *Proxy) SetGuardrails(provider guardrail.PolicyProvider) {
+	p.guardrails = provider
+}
+
+// guardrailsPolicy returns the guardrails policy that applies to the user making the request, if any.
+func (p *Proxy) guardrailsPolicy(req *http.Request) *guardrail.Policy {
+	if p.guardrails == nil {
+		return nil
+	}
+	groups, _ := p.userProjectInfo.GetUserGroups(req.Header.Get("X-Forwarded-Access-Token"))
+	return p.guardrails.PolicyFor(groups)
+}
+
+func (p *Proxy) newModifier(req *http.Request) *metricquery.Modifier {
+	return &metricquery.Modifier{
 		Req:                 req,
 		ReqURL:              p.apiServerHost + projectsAPIPath,
 		AccessReviewer:      p.accessReviewer,
 		UPI:                 p.userProjectInfo,
 		MCI:                 p.managedClusterInformer,
 		KubeClientTransport: p.kubeClientTransport,
+		Guardrails:          p.guardrailsPolicy(req),
 	}
-	if err := modifier.Modify(); err != nil {
-		klog.Errorf("failed to modify query: %v", err)
-		http.Error(res, "Internal Server Error", http.StatusInternalServerError)
-		return
-	}
-	p.proxy.ServeHTTP(res, req)
 }
 
 func (p *Proxy) getKubeClientWithToken(token string) (client.Client, error) {
@@ -215,6 +287,25 @@ func (p *Proxy) preCheckRequest(req *http.Request) error {
 		p.userProjectInfo.UpdateUserProject(userName, token, projectList)
 	}
 
+	// Groups are only needed to select the guardrails policy of the user.
+	if _, ok := p.userProjectInfo.GetUserGroups(token); !ok && p.guardrails != nil {
+		if c == nil {
+			var err error
+			c, err = p.getKubeClientWithTokenFunc(token)
+			if err != nil {
+				return fmt.Errorf("failed to get kube client: %w", err)
+			}
+		}
+
+		groups, err := util.GetUserGroups(req.Context(), c)
+		if err != nil {
+			// if we cannot fetch the groups, the default guardrails policy applies.
+			klog.Errorf("failed to fetch user groups: %v", err)
+			groups = []string{}
+		}
+		p.userProjectInfo.UpdateUserGroups(token, groups)
+	}
+
 	if len(p.managedClusterInformer.GetAllManagedClusterNames()) == 0 {
 		return errors.New("no project or cluster found")
 	}
@@ -296,6 +387,29 @@ func createQueryResponse(labels []string, metricName string, urlPath string) ([]
 	return json.Marshal(response)
 }
 
+// writeBadDataResponse writes an error in the Prometheus API format, so that it is displayed by Grafana.
+func writeBadDataResponse(res http.ResponseWriter, msg string) {
+	body, err := json.Marshal(struct {
+		Status    string `json:"status"`
+		ErrorType string `json:"errorType"`
+		Error     string `json:"error"`
+	}{
+		Status:    "error",
+		ErrorType: "bad_data",
+		Error:     msg,
+	})
+	if err != nil {
+		klog.Errorf("failed to marshal error response: %v", err)
+		http.Error(res, msg, http.StatusBadRequest)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusBadRequest)
+	if _, err := res.Write(body); err != nil {
+		klog.Errorf("failed to write response: %v", err)
+	}
+}
+
 func newEmptyMatrixHTTPBody() []byte {
 	return []byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`)
 }
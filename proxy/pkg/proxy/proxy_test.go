@@ -5,6 +5,7 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
 	"io"
 	"net/http"
@@ -20,12 +21,15 @@ import (
 	userv1 "github.com/openshift/api/user/v1"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/cache"
 	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/config"
+	"github.com/stolostron/multicluster-observability-operator/proxy/pkg/guardrail"
 	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 var promLabelRegex = regexp.MustCompile(`[^\w]+`)
@@ -580,3 +584,121 @@ func TestProxyIntegrationScenarios(t *testing.T) {
 		})
 	}
 }
+
+func TestProxyGuardrails(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		query                string
+		groups               []string
+		expectedResponseCode int
+		expectUpstreamCalled bool
+	}{
+		{
+			name:                 "query with a metric name is accepted",
+			query:                `up{job="a"}`,
+			expectedResponseCode: http.StatusOK,
+			expectUpstreamCalled: true,
+		},
+		{
+			name:                 "query without metric name nor cluster is rejected",
+			query:                `{job="a"}`,
+			expectedResponseCode: http.StatusBadRequest,
+		},
+		{
+			name:                 "group policy overrides the default one",
+			query:                `{job="a"}`,
+			groups:               []string{"sre"},
+			expectedResponseCode: http.StatusOK,
+			expectUpstreamCalled: true,
+		},
+	}
+
+	var upstreamCalled bool
+	metricsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalled = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer metricsServer.Close()
+	metricsServerURL, err := url.Parse(metricsServer.URL)
+	assert.NoError(t, err)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			upstreamCalled = false
+			transport := &http.Transport{
+				TLSClientConfig: &tls.Config{
+					RootCAs: metricsServer.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+				},
+			}
+			upi := cache.NewUserProjectInfo(t.Context(), time.Minute, time.Minute)
+			mockInformer := &MockManagedClusterInformer{clusters: map[string]struct{}{"cluster1": {}}}
+			mockAccessReviewer := &MockAccessReviewer{metricsAccess: map[string][]string{"cluster1": {"*"}}}
+
+			p, err := NewProxy(&rest.Config{Host: "localhost"}, metricsServerURL, transport, upi, mockInformer, mockAccessReviewer)
+			assert.NoError(t, err)
+			p.SetGuardrails(&guardrail.Config{
+				Default: guardrail.Policy{RequireMetricNameOrCluster: true},
+				Groups:  []guardrail.GroupPolicy{{Groups: []string{"sre"}}},
+			})
+
+			scheme := runtime.NewScheme()
+			_ = projectv1.AddToScheme(scheme)
+			_ = authenticationv1.AddToScheme(scheme)
+			p.getKubeClientWithTokenFunc = func(token string) (client.Client, error) {
+				return fake.NewClientBuilder().WithScheme(scheme).
+					WithLists(&projectv1.ProjectList{Items: []projectv1.Project{{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}}}}).
+					WithInterceptorFuncs(interceptor.Funcs{
+						Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+							if review, ok := obj.(*authenticationv1.SelfSubjectReview); ok {
+								review.Status.UserInfo.Groups = tc.groups
+								return nil
+							}
+							return c.Create(ctx, obj, opts...)
+						},
+					}).Build(), nil
+			}
+
+			req := httptest.NewRequest("GET", "http://localhost/api/v1/query?query="+url.QueryEscape(tc.query), nil)
+			req.Header.Set("X-Forwarded-User", "test")
+			req.Header.Set("X-Forwarded-Access-Token", "test")
+			recorder := httptest.NewRecorder()
+			p.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedResponseCode, recorder.Code)
+			assert.Equal(t, tc.expectUpstreamCalled, upstreamCalled)
+			if tc.expectedResponseCode == http.StatusBadRequest {
+				assert.Contains(t, recorder.Body.String(), `"errorType":"bad_data"`)
+			}
+		})
+	}
+}
+
+func TestTenantMetricsBasePath(t *testing.T) {
+	testCases := []struct {
+		name         string
+		path         string
+		expectedBase string
+		expectedPath string
+		expectErr    bool
+	}{
+		{name: "default tenant", path: "/api/v1/query", expectedBase: "/api/metrics/v1/default", expectedPath: "/api/v1/query"},
+		{name: "tenant prefix", path: "/tenants/finance/api/v1/query_range", expectedBase: "/api/metrics/v1/finance", expectedPath: "/api/v1/query_range"},
+		{name: "tenant without path", path: "/tenants/finance", expectedBase: "/api/metrics/v1/finance", expectedPath: "/"},
+		{name: "invalid tenant", path: "/tenants/../api/v1/query", expectErr: true},
+		{name: "empty tenant", path: "/tenants//api/v1/query", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://localhost"+tc.path, nil)
+			base, err := tenantMetricsBasePath(req)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedBase, base)
+			assert.Equal(t, tc.expectedPath, req.URL.Path)
+		})
+	}
+}
@@ -10,6 +10,8 @@ import (
 
 	projectv1 "github.com/openshift/api/project/v1"
 	userv1 "github.com/openshift/api/user/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -35,3 +37,39 @@ func GetUserName(ctx context.Context, c client.Client) (string, error) {
 	}
 	return user.Name, nil
 }
+
+// GetUserGroups returns the groups of the user associated with the client's token.
+func GetUserGroups(ctx context.Context, c client.Client) ([]string, error) {
+	review := &authenticationv1.SelfSubjectReview{}
+	if err := c.Create(ctx, review); err != nil {
+		return nil, fmt.Errorf("failed to create self subject review: %w", err)
+	}
+	return review.Status.UserInfo.Groups, nil
+}
+
+// GetUserHubs returns the hubs, among the given ones, whose metrics the user associated with the client's token
+// can query. Access to the metrics of a hub is granted by the get verb on its hubs/metrics resource of the
+// observability.open-cluster-management.io group.
+func GetUserHubs(ctx context.Context, c client.Client, hubs []string) ([]string, error) {
+	allowed := []string{}
+	for _, hub := range hubs {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Group:       "observability.open-cluster-management.io",
+					Resource:    "hubs",
+					Subresource: "metrics",
+					Name:        hub,
+					Verb:        "get",
+				},
+			},
+		}
+		if err := c.Create(ctx, review); err != nil {
+			return nil, fmt.Errorf("failed to create self subject access review for hub %s: %w", hub, err)
+		}
+		if review.Status.Allowed {
+			allowed = append(allowed, hub)
+		}
+	}
+	return allowed, nil
+}
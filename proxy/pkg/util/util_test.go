@@ -11,9 +11,12 @@ import (
 	projectv1 "github.com/openshift/api/project/v1"
 	userv1 "github.com/openshift/api/user/v1"
 	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 func TestFetchUserProjectList(t *testing.T) {
@@ -59,3 +62,21 @@ func TestGetUserName(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "~", userName)
 }
+
+func TestGetUserHubs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = authorizationv1.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			review := obj.(*authorizationv1.SelfSubjectAccessReview)
+			attributes := review.Spec.ResourceAttributes
+			review.Status.Allowed = attributes.Resource == "hubs" && attributes.Subresource == "metrics" &&
+				attributes.Verb == "get" && attributes.Name == "hub-b"
+			return nil
+		},
+	}).Build()
+
+	hubs, err := GetUserHubs(context.TODO(), fakeClient, []string{"hub-b", "hub-c"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hub-b"}, hubs)
+}
@@ -83,7 +83,7 @@ var _ = Describe("", func() {
 
 					if strings.Contains(stsInfo.Name, "-alertmanager") {
 						By("The statefulset: " + stsInfo.Name + " should have the appropriate secret mounted")
-						Expect(stsInfo.Spec.Template.Spec.Volumes[0].Secret.SecretName).To(Equal("alertmanager-config"))
+						Expect(stsInfo.Spec.Template.Spec.Volumes[0].Secret.SecretName).To(Equal("alertmanager-config-generated"))
 					}
 
 					if strings.Contains(stsInfo.Name, "-thanos-rule") {